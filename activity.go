@@ -0,0 +1,66 @@
+package todo
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"time"
+)
+
+// Entities and actions of activity records.
+const (
+	ActivityList = "list"
+	ActivityItem = "item"
+
+	ActionCreate  = "create"
+	ActionUpdate  = "update"
+	ActionMove    = "move"
+	ActionDelete  = "delete"
+	ActionRestore = "restore"
+)
+
+// Change is the value of a field before and after a change, as JSON. From is
+// null for created entities.
+type Change struct {
+	From json.RawMessage `json:"from" swaggertype:"object"`
+	To   json.RawMessage `json:"to" swaggertype:"object"`
+}
+
+// Changes are the changed fields of an entity by name. They are stored as a
+// JSON object.
+type Changes map[string]Change
+
+func (c Changes) Value() (driver.Value, error) {
+	if len(c) == 0 {
+		return nil, nil
+	}
+	return json.Marshal(c)
+}
+
+func (c *Changes) Scan(src interface{}) error {
+	switch data := src.(type) {
+	case nil:
+		*c = nil
+		return nil
+	case []byte:
+		return json.Unmarshal(data, c)
+	case string:
+		return json.Unmarshal([]byte(data), c)
+	default:
+		return errors.New("unsupported type of changes")
+	}
+}
+
+// Activity records who changed a list or one of its items, and how. ItemId is
+// nil for changes of the list itself.
+type Activity struct {
+	Id        int       `json:"id" db:"id"`
+	ListId    int       `json:"list_id" db:"list_id"`
+	ItemId    *int      `json:"item_id,omitempty" db:"item_id"`
+	UserId    int       `json:"user_id" db:"user_id"`
+	Username  string    `json:"username" db:"username"`
+	Entity    string    `json:"entity" db:"entity"`
+	Action    string    `json:"action" db:"action"`
+	Changes   Changes   `json:"changes,omitempty" db:"changes"`
+	CreatedAt time.Time `json:"created_at" db:"created_at"`
+}
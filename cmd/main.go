@@ -5,15 +5,26 @@ import (
 	todo "do-app"
 	_ "do-app/docs"
 	"do-app/pkg/handler"
+	"do-app/pkg/notifier"
 	"do-app/pkg/repository"
 	"do-app/pkg/service"
+	"do-app/schema"
+	"errors"
+	"fmt"
+	"github.com/jmoiron/sqlx"
 	"github.com/joho/godotenv"
 	_ "github.com/lib/pq"
+	_ "github.com/mattn/go-sqlite3"
 	"github.com/sirupsen/logrus"
 	"github.com/spf13/viper"
+	"io/fs"
+	"net/http"
 	"os"
 	"os/signal"
+	"strconv"
+	"strings"
 	"syscall"
+	"time"
 )
 
 // @title ToDo App Api
@@ -37,45 +48,258 @@ func main() {
 		logrus.Fatalf("error loading env variables %s", err.Error())
 	}
 
-	db, err := repository.NewPostgresDB(repository.Config{
-		Host:     viper.GetString("db.host"),
-		Port:     viper.GetString("db.port"),
-		Username: viper.GetString("db.username"),
-		Password: os.Getenv("DB_PASSWORD"),
-		DBName:   viper.GetString("db.dbname"),
-		SSLMode:  viper.GetString("db.sslmode"),
-	})
+	var db *sqlx.DB
+	var repos *repository.Repository
+	switch storage := viper.GetString("storage"); storage {
+	case "memory":
+		if len(os.Args) > 1 && os.Args[1] == "migrate" {
+			logrus.Fatalf("error migrate: memory storage has no migrations")
+		}
+		logrus.Warn("using memory storage, data is lost on shutdown")
+		repos = repository.NewMemoryRepository()
+	case "", "database", "postgres":
+		var err error
+		db, err = repository.NewDB(repository.Config{
+			Driver:   viper.GetString("db.driver"),
+			Path:     viper.GetString("db.path"),
+			Host:     viper.GetString("db.host"),
+			Port:     viper.GetString("db.port"),
+			Username: viper.GetString("db.username"),
+			Password: os.Getenv("DB_PASSWORD"),
+			DBName:   viper.GetString("db.dbname"),
+			SSLMode:  viper.GetString("db.sslmode"),
+		})
+		if err != nil {
+			logrus.Fatalf("error initialize db: %s", err.Error())
+		}
+
+		var migrations fs.FS = schema.Migrations
+		if db.DriverName() == "sqlite3" {
+			migrations = schema.SQLiteMigrations
+		}
+		migrator, err := repository.NewMigrator(db, migrations)
+		if err != nil {
+			logrus.Fatalf("error initialize migrations: %s", err.Error())
+		}
+		if len(os.Args) > 1 && os.Args[1] == "migrate" {
+			if err = runMigrate(migrator, os.Args[2:]); err != nil {
+				logrus.Fatalf("error migrate: %s", err.Error())
+			}
+			return
+		}
+		if viper.GetBool("db.auto_migrate") {
+			if err = migrator.Up(context.Background()); err != nil {
+				logrus.Fatalf("error migrate: %s", err.Error())
+			}
+		}
+
+		isolation, err := repository.ParseIsolation(viper.GetString("db.tx.isolation"))
+		if err != nil {
+			logrus.Fatalf("error initialize db: %s", err.Error())
+		}
+		repos = repository.NewRepository(db, repository.TxConfig{
+			Isolation:  isolation,
+			MaxRetries: viper.GetInt("db.tx.max_retries"),
+		})
+	default:
+		logrus.Fatalf("unknown storage %q, expected database or memory", storage)
+	}
+
+	authConfig, err := initAuthConfig()
 	if err != nil {
-		logrus.Fatalf("error initialize db: %s", err.Error())
+		logrus.Fatalf("error initialize auth config: %s", err.Error())
 	}
 
-	repos := repository.NewRepository(db)
-	services := service.NewService(repos)
-	handlers := handler.NewHandler(services)
+	services := service.NewService(repos, authConfig, service.UndoConfig{
+		Window: viper.GetDuration("undo.window"),
+	})
+	handlers := handler.NewHandler(services, handler.Config{
+		QueryTimeout: viper.GetDuration("db.query_timeout"),
+	})
 
-	srv := new(todo.Server)
+	srv := todo.NewServer(viper.GetString("port"), handlers.InitRoutes())
 	go func() {
-		if err := srv.Run(viper.GetString("port"), handlers.InitRoutes()); err != nil {
+		if err := srv.Run(); err != nil && !errors.Is(err, http.ErrServerClosed) {
 			logrus.Fatalf("error run http server: %s", err.Error())
 		}
 	}()
 	logrus.Print("TodoApp Started")
 
+	var scheduler *service.ReminderScheduler
+	if viper.GetBool("reminders.enabled") {
+		remindersNotifier, err := initNotifier()
+		if err != nil {
+			logrus.Fatalf("error initialize notifier: %s", err.Error())
+		}
+		scheduler = service.NewReminderScheduler(repos.Reminders, remindersNotifier, service.ReminderConfig{
+			Interval:   viper.GetDuration("reminders.interval"),
+			BatchSize:  viper.GetInt("reminders.batch_size"),
+			RetryDelay: viper.GetDuration("reminders.retry_delay"),
+		})
+		scheduler.Start()
+	}
+
+	purger := service.NewTrashPurger(repos.Trash, service.TrashConfig{
+		Retention: viper.GetDuration("trash.retention"),
+		Interval:  viper.GetDuration("trash.purge_interval"),
+	})
+	purger.Start()
+
 	quit := make(chan os.Signal, 1)
 	signal.Notify(quit, syscall.SIGTERM, syscall.SIGINT)
 	<-quit
 
 	logrus.Print("Shutting down app")
-	if err = srv.Shutdown(context.Background()); err != nil {
-		logrus.Errorf("error ocured shut donw: %w", err.Error())
+	// Every part gets a budget of its own, so that slow requests cannot use up
+	// the time the reminder batch in progress needs to finish.
+	if err = shutdown(srv.Shutdown, "shutdown.server"); err != nil {
+		logrus.Errorf("error ocured shut donw: %s", err.Error())
+	}
+	if err = shutdown(purger.Shutdown, "shutdown.trash_purger"); err != nil {
+		logrus.Errorf("error stop trash purger: %s", err.Error())
 	}
-	if err = db.Close(); err != nil {
-		logrus.Errorf("error dont close db: %w", err.Error())
+	if scheduler != nil {
+		if err = shutdown(scheduler.Shutdown, "shutdown.reminders"); err != nil {
+			logrus.Errorf("error stop reminder scheduler: %s", err.Error())
+		}
+	}
+	if db != nil {
+		if err = db.Close(); err != nil {
+			logrus.Errorf("error dont close db: %s", err.Error())
+		}
+	}
+}
+
+// defaultShutdownTimeout is the budget of a part of the app whose shutdown
+// timeout is not configured.
+const defaultShutdownTimeout = 10 * time.Second
+
+// shutdown calls stop with a context that expires after the timeout
+// configured under key.
+func shutdown(stop func(ctx context.Context) error, key string) error {
+	timeout := viper.GetDuration(key)
+	if timeout <= 0 {
+		timeout = defaultShutdownTimeout
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	return stop(ctx)
+}
+
+// runMigrate runs the migrate subcommand: up, down, status or goto N.
+func runMigrate(migrator *repository.Migrator, args []string) error {
+	ctx := context.Background()
+	if len(args) == 0 {
+		return fmt.Errorf("usage: migrate up|down|status|goto N")
+	}
+	switch args[0] {
+	case "up":
+		return migrator.Up(ctx)
+	case "down":
+		return migrator.Down(ctx)
+	case "goto":
+		if len(args) != 2 {
+			return fmt.Errorf("usage: migrate goto N")
+		}
+		version, err := strconv.Atoi(args[1])
+		if err != nil {
+			return fmt.Errorf("invalid version %q", args[1])
+		}
+		return migrator.Goto(ctx, version)
+	case "status":
+		statuses, err := migrator.Status(ctx)
+		if err != nil {
+			return err
+		}
+		for _, status := range statuses {
+			appliedAt := ""
+			if status.AppliedAt != nil {
+				appliedAt = status.AppliedAt.Format(time.RFC3339)
+			}
+			fmt.Printf("%06d  %-24s %-9s %s\n", status.Version, status.Name, status.State, appliedAt)
+		}
+		return nil
+	default:
+		return fmt.Errorf("unknown migrate command %q, expected up, down, status or goto", args[0])
 	}
 }
 
 func initConfig() error {
 	viper.AddConfigPath("configs")
 	viper.SetConfigName("config")
+	viper.SetEnvKeyReplacer(strings.NewReplacer(".", "_"))
+	viper.AutomaticEnv()
 	return viper.ReadInConfig()
 }
+
+type signingKeyConfig struct {
+	Kid            string `mapstructure:"kid"`
+	Algorithm      string `mapstructure:"algorithm"`
+	SecretEnv      string `mapstructure:"secret_env"`
+	PrivateKeyFile string `mapstructure:"private_key_file"`
+	PrivateKeyEnv  string `mapstructure:"private_key_env"`
+}
+
+func initAuthConfig() (service.AuthConfig, error) {
+	var keyConfigs []signingKeyConfig
+	if err := viper.UnmarshalKey("auth.keys", &keyConfigs); err != nil {
+		return service.AuthConfig{}, err
+	}
+
+	keys := make([]service.SigningKeyConfig, 0, len(keyConfigs))
+	for _, cfg := range keyConfigs {
+		key := service.SigningKeyConfig{
+			Kid:       cfg.Kid,
+			Algorithm: cfg.Algorithm,
+		}
+		if cfg.SecretEnv != "" {
+			key.Secret = os.Getenv(cfg.SecretEnv)
+		}
+		if cfg.PrivateKeyEnv != "" {
+			key.PrivateKeyPEM = []byte(os.Getenv(cfg.PrivateKeyEnv))
+		}
+		if cfg.PrivateKeyFile != "" {
+			data, err := os.ReadFile(cfg.PrivateKeyFile)
+			if err != nil {
+				return service.AuthConfig{}, err
+			}
+			key.PrivateKeyPEM = data
+		}
+		keys = append(keys, key)
+	}
+
+	keySet, err := service.NewKeySet(viper.GetString("auth.active_key"), keys)
+	if err != nil {
+		return service.AuthConfig{}, err
+	}
+
+	return service.AuthConfig{
+		AccessTokenTTL:  viper.GetDuration("auth.access_token_ttl"),
+		RefreshTokenTTL: viper.GetDuration("auth.refresh_token_ttl"),
+		Keys:            keySet,
+	}, nil
+}
+
+func initNotifier() (notifier.Notifier, error) {
+	switch kind := viper.GetString("reminders.notifier"); kind {
+	case "", "log":
+		return notifier.NewLogNotifier(), nil
+	case "webhook":
+		return notifier.NewWebhookNotifier(
+			viper.GetString("reminders.webhook.url"),
+			os.Getenv("WEBHOOK_SECRET"),
+			10*time.Second,
+		), nil
+	case "smtp":
+		return notifier.NewSMTPNotifier(notifier.SMTPConfig{
+			Host:     viper.GetString("reminders.smtp.host"),
+			Port:     viper.GetString("reminders.smtp.port"),
+			Username: viper.GetString("reminders.smtp.username"),
+			Password: os.Getenv("SMTP_PASSWORD"),
+			From:     viper.GetString("reminders.smtp.from"),
+			Timeout:  viper.GetDuration("reminders.smtp.timeout"),
+		}), nil
+	default:
+		return nil, fmt.Errorf("unknown notifier %q", kind)
+	}
+}
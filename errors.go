@@ -0,0 +1,40 @@
+package todo
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Kinds of domain errors. Handlers map them to HTTP status codes, so services
+// and repositories return them instead of driver errors wherever the cause is
+// on the client side.
+var (
+	ErrNotFound   = errors.New("not found")
+	ErrForbidden  = errors.New("forbidden")
+	ErrConflict   = errors.New("conflict")
+	ErrValidation = errors.New("validation failed")
+	// ErrUnauthorized means the presented credentials do not identify a user.
+	ErrUnauthorized = errors.New("unauthorized")
+	// ErrPreconditionFailed means the entity has changed since the client
+	// read the version it expected.
+	ErrPreconditionFailed = errors.New("precondition failed")
+)
+
+// Error is a domain error of one of the kinds above. Its message is meant for
+// clients, so it must not contain SQL or other internals.
+type Error struct {
+	Kind    error
+	Message string
+}
+
+func NewError(kind error, format string, args ...interface{}) error {
+	return &Error{Kind: kind, Message: fmt.Errorf(format, args...).Error()}
+}
+
+func (e *Error) Error() string {
+	return e.Message
+}
+
+func (e *Error) Unwrap() error {
+	return e.Kind
+}
@@ -0,0 +1,56 @@
+package todo
+
+import "time"
+
+const (
+	defaultInviteTTL = 7 * 24 * time.Hour
+	maxInviteTTL     = 30 * 24 * time.Hour
+)
+
+// ListInvite grants the role to every user that redeems its token. A nil
+// MaxUses means the invite can be redeemed until it expires or is revoked.
+type ListInvite struct {
+	Id        int       `json:"id" db:"id"`
+	ListId    int       `json:"list_id" db:"list_id"`
+	Token     string    `json:"token,omitempty" db:"-"`
+	Role      string    `json:"role" db:"role"`
+	CreatedBy int       `json:"created_by" db:"created_by"`
+	MaxUses   *int      `json:"max_uses" db:"max_uses"`
+	Uses      int       `json:"uses" db:"uses"`
+	ExpiresAt time.Time `json:"expires_at" db:"expires_at"`
+	Revoked   bool      `json:"revoked" db:"revoked"`
+	CreatedAt time.Time `json:"created_at" db:"created_at"`
+}
+
+// CreateInviteInput describes a new invite. MaxUses defaults to a single use,
+// zero makes the invite unlimited. ExpiresIn is a Go duration string.
+type CreateInviteInput struct {
+	Role      string `json:"role" binding:"required"`
+	MaxUses   *int   `json:"max_uses"`
+	ExpiresIn string `json:"expires_in"`
+}
+
+func (i CreateInviteInput) Validate() error {
+	if !ValidRole(i.Role) {
+		return NewError(ErrValidation, "unknown role %q", i.Role)
+	}
+	if i.MaxUses != nil && *i.MaxUses < 0 {
+		return NewError(ErrValidation, "max_uses must not be negative")
+	}
+	_, err := i.TTL()
+	return err
+}
+
+func (i CreateInviteInput) TTL() (time.Duration, error) {
+	if i.ExpiresIn == "" {
+		return defaultInviteTTL, nil
+	}
+	ttl, err := time.ParseDuration(i.ExpiresIn)
+	if err != nil {
+		return 0, NewError(ErrValidation, "invalid expires_in: %w", err)
+	}
+	if ttl <= 0 || ttl > maxInviteTTL {
+		return 0, NewError(ErrValidation, "expires_in must be between 0 and %s", maxInviteTTL)
+	}
+	return ttl, nil
+}
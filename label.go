@@ -0,0 +1,44 @@
+package todo
+
+import "regexp"
+
+// DefaultLabelColor is used for labels created without a color.
+const DefaultLabelColor = "#9e9e9e"
+
+var labelColor = regexp.MustCompile(`^#[0-9a-fA-F]{6}$`)
+
+// Label is a user-scoped tag that can be attached to items of any list the
+// user can see.
+type Label struct {
+	Id    int    `json:"id" db:"id"`
+	Name  string `json:"name" db:"name" binding:"required"`
+	Color string `json:"color" db:"color"`
+}
+
+func (l Label) Validate() error {
+	if len(l.Name) > 64 {
+		return NewError(ErrValidation, "label name is longer than 64 characters")
+	}
+	if l.Color != "" && !labelColor.MatchString(l.Color) {
+		return NewError(ErrValidation, "color must be a hex value like #1e88e5")
+	}
+	return nil
+}
+
+type UpdateLabelInput struct {
+	Name  *string `json:"name"`
+	Color *string `json:"color"`
+}
+
+func (i UpdateLabelInput) Validate() error {
+	if i.Name == nil && i.Color == nil {
+		return NewError(ErrValidation, "update structure has no values")
+	}
+	if i.Name != nil && (*i.Name == "" || len(*i.Name) > 64) {
+		return NewError(ErrValidation, "label name must have 1 to 64 characters")
+	}
+	if i.Color != nil && !labelColor.MatchString(*i.Color) {
+		return NewError(ErrValidation, "color must be a hex value like #1e88e5")
+	}
+	return nil
+}
@@ -0,0 +1,48 @@
+package todo
+
+import "strings"
+
+const (
+	DefaultPageLimit = 50
+	MaxPageLimit     = 200
+)
+
+// Sort keys of list and item collections.
+const (
+	SortTitle    = "title"
+	SortCreated  = "created"
+	SortUpdated  = "updated"
+	SortDue      = "due"
+	SortPriority = "priority"
+	SortPosition = "position"
+)
+
+// PageRequest asks for one page of a collection. Cursor is the next_cursor of
+// the previous page and is only valid with the same Sort. Sort names a sort
+// key, a leading "-" reverses the order.
+type PageRequest struct {
+	Limit  int
+	Cursor string
+	Sort   string
+}
+
+func (p PageRequest) Validate(sorts ...string) error {
+	if p.Limit < 0 || p.Limit > MaxPageLimit {
+		return NewError(ErrValidation, "limit must be between 1 and %d", MaxPageLimit)
+	}
+	if p.Sort == "" {
+		return nil
+	}
+	name := strings.TrimPrefix(p.Sort, "-")
+	for _, sort := range sorts {
+		if name == sort {
+			return nil
+		}
+	}
+	return NewError(ErrValidation, "unknown sort %q, expected one of %s", name, strings.Join(sorts, ", "))
+}
+
+// ListFilter selects lists of a user. Query matches a substring of the title.
+type ListFilter struct {
+	Query string
+}
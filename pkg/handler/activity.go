@@ -0,0 +1,111 @@
+package handler
+
+import (
+	todo "do-app"
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"strconv"
+)
+
+type activityResponse struct {
+	Data       []todo.Activity `json:"data"`
+	NextCursor string          `json:"next_cursor,omitempty"`
+}
+
+// @Summary Get list activity
+// @Tags lists
+// @Security ApiKeyAuth
+// @Description changes of the list and its items, newest first
+// @ID get-list-activity
+// @Accept json
+// @Produce json
+// @Param id path string true "list id"
+// @Param limit query int false "page size, 50 by default" minimum(1) maximum(200)
+// @Param cursor query string false "next_cursor of the previous page"
+// @Param sort query string false "sort key, prefix with - for descending" Enums(created, -created)
+// @Success 200 {object} activityResponse
+// @Failure 400 {object} problemResponse
+// @Failure 401 {object} problemResponse
+// @Failure 404 {object} problemResponse
+// @Failure 500 {object} problemResponse
+// @Failure default {object} problemResponse
+// @Router /api/lists/{id}/activity [get]
+func (h *Handler) getListActivity(c *gin.Context) {
+	userId, err := getUserId(c)
+	if err != nil {
+		newErrorResponse(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	listId, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		newErrorResponse(c, http.StatusBadRequest, "invalid id param")
+		return
+	}
+
+	page, err := parsePageRequest(c)
+	if err != nil {
+		newErrorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	activities, next, err := h.services.Activity.GetByList(c.Request.Context(), userId, listId, page)
+	if err != nil {
+		newServiceErrorResponse(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, activityResponse{
+		Data:       activities,
+		NextCursor: next,
+	})
+}
+
+// @Summary Get item history
+// @Tags items
+// @Security ApiKeyAuth
+// @Description changes of the item, newest first
+// @ID get-item-history
+// @Accept json
+// @Produce json
+// @Param id path string true "item id"
+// @Param limit query int false "page size, 50 by default" minimum(1) maximum(200)
+// @Param cursor query string false "next_cursor of the previous page"
+// @Param sort query string false "sort key, prefix with - for descending" Enums(created, -created)
+// @Success 200 {object} activityResponse
+// @Failure 400 {object} problemResponse
+// @Failure 401 {object} problemResponse
+// @Failure 404 {object} problemResponse
+// @Failure 500 {object} problemResponse
+// @Failure default {object} problemResponse
+// @Router /api/items/{id}/history [get]
+func (h *Handler) getItemHistory(c *gin.Context) {
+	userId, err := getUserId(c)
+	if err != nil {
+		newErrorResponse(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	itemId, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		newErrorResponse(c, http.StatusBadRequest, "invalid id param")
+		return
+	}
+
+	page, err := parsePageRequest(c)
+	if err != nil {
+		newErrorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	activities, next, err := h.services.Activity.GetByItem(c.Request.Context(), userId, itemId, page)
+	if err != nil {
+		newServiceErrorResponse(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, activityResponse{
+		Data:       activities,
+		NextCursor: next,
+	})
+}
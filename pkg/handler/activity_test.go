@@ -0,0 +1,160 @@
+package handler
+
+import (
+	todo "do-app"
+	"do-app/pkg/service"
+	mock_service "do-app/pkg/service/mocks"
+	"encoding/json"
+	"github.com/gin-gonic/gin"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestHandler_getListActivity(t *testing.T) {
+	type mockBehavior func(s *mock_service.MockActivity)
+
+	itemId := 4
+	createdAt := time.Date(2030, 1, 7, 9, 0, 0, 0, time.UTC)
+
+	testTable := []struct {
+		name              string
+		listId            string
+		query             string
+		mockBehavior      mockBehavior
+		expectStatusCode  int
+		expectRequestBody string
+	}{
+		{
+			name:   "OK",
+			listId: "2",
+			query:  "?limit=1",
+			mockBehavior: func(s *mock_service.MockActivity) {
+				s.EXPECT().GetByList(gomock.Any(), 1, 2, todo.PageRequest{Limit: 1}).Return([]todo.Activity{
+					{Id: 8, ListId: 2, ItemId: &itemId, UserId: 1, Username: "alice", Entity: "item", Action: "update",
+						Changes:   todo.Changes{"title": {From: json.RawMessage(`"milk"`), To: json.RawMessage(`"oat milk"`)}},
+						CreatedAt: createdAt},
+				}, "next", nil)
+			},
+			expectStatusCode: 200,
+			expectRequestBody: `{"data":[{"id":8,"list_id":2,"item_id":4,"user_id":1,"username":"alice","entity":"item",` +
+				`"action":"update","changes":{"title":{"from":"milk","to":"oat milk"}},"created_at":"2030-01-07T09:00:00Z"}],` +
+				`"next_cursor":"next"}`,
+		},
+		{
+			name:              "Invalid id",
+			listId:            "groceries",
+			mockBehavior:      func(s *mock_service.MockActivity) {},
+			expectStatusCode:  400,
+			expectRequestBody: `{"type":"about:blank","title":"Bad Request","status":400,"detail":"invalid id param","code":"bad_request"}`,
+		},
+		{
+			name:   "Not a member",
+			listId: "2",
+			mockBehavior: func(s *mock_service.MockActivity) {
+				s.EXPECT().GetByList(gomock.Any(), 1, 2, todo.PageRequest{}).Return(nil, "", todo.NewError(todo.ErrNotFound, "list not found"))
+			},
+			expectStatusCode:  404,
+			expectRequestBody: `{"type":"about:blank","title":"Not Found","status":404,"detail":"list not found","code":"not_found"}`,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			c := gomock.NewController(t)
+			defer c.Finish()
+
+			activity := mock_service.NewMockActivity(c)
+			testCase.mockBehavior(activity)
+
+			services := &service.Service{Activity: activity}
+			handler := NewHandler(services, Config{})
+
+			r := gin.New()
+			r.GET("/lists/:id/activity", func(ctx *gin.Context) {
+				ctx.Set(userCtx, 1)
+			}, handler.getListActivity)
+
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest("GET", "/lists/"+testCase.listId+"/activity"+testCase.query, nil)
+
+			r.ServeHTTP(w, req)
+
+			assert.Equal(t, testCase.expectStatusCode, w.Code)
+			assert.Equal(t, testCase.expectRequestBody, w.Body.String())
+		})
+	}
+}
+
+func TestHandler_getItemHistory(t *testing.T) {
+	type mockBehavior func(s *mock_service.MockActivity)
+
+	itemId := 4
+	createdAt := time.Date(2030, 1, 7, 9, 0, 0, 0, time.UTC)
+
+	testTable := []struct {
+		name              string
+		itemId            string
+		mockBehavior      mockBehavior
+		expectStatusCode  int
+		expectRequestBody string
+	}{
+		{
+			name:   "OK",
+			itemId: "4",
+			mockBehavior: func(s *mock_service.MockActivity) {
+				s.EXPECT().GetByItem(gomock.Any(), 1, 4, todo.PageRequest{}).Return([]todo.Activity{
+					{Id: 9, ListId: 2, ItemId: &itemId, UserId: 1, Username: "alice", Entity: "item", Action: "delete",
+						CreatedAt: createdAt},
+				}, "", nil)
+			},
+			expectStatusCode: 200,
+			expectRequestBody: `{"data":[{"id":9,"list_id":2,"item_id":4,"user_id":1,"username":"alice","entity":"item",` +
+				`"action":"delete","created_at":"2030-01-07T09:00:00Z"}]}`,
+		},
+		{
+			name:              "Invalid id",
+			itemId:            "milk",
+			mockBehavior:      func(s *mock_service.MockActivity) {},
+			expectStatusCode:  400,
+			expectRequestBody: `{"type":"about:blank","title":"Bad Request","status":400,"detail":"invalid id param","code":"bad_request"}`,
+		},
+		{
+			name:   "Service Error",
+			itemId: "4",
+			mockBehavior: func(s *mock_service.MockActivity) {
+				s.EXPECT().GetByItem(gomock.Any(), 1, 4, todo.PageRequest{}).Return(nil, "", assert.AnError)
+			},
+			expectStatusCode:  500,
+			expectRequestBody: `{"type":"about:blank","title":"Internal Server Error","status":500,"code":"internal_error"}`,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			c := gomock.NewController(t)
+			defer c.Finish()
+
+			activity := mock_service.NewMockActivity(c)
+			testCase.mockBehavior(activity)
+
+			services := &service.Service{Activity: activity}
+			handler := NewHandler(services, Config{})
+
+			r := gin.New()
+			r.GET("/items/:id/history", func(ctx *gin.Context) {
+				ctx.Set(userCtx, 1)
+			}, handler.getItemHistory)
+
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest("GET", "/items/"+testCase.itemId+"/history", nil)
+
+			r.ServeHTTP(w, req)
+
+			assert.Equal(t, testCase.expectStatusCode, w.Code)
+			assert.Equal(t, testCase.expectRequestBody, w.Body.String())
+		})
+	}
+}
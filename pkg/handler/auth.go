@@ -14,24 +14,23 @@ import (
 // @Produce json
 // @Param input body todo.User true "account info"
 // @Success 200 {integer} integer 1
-// @Failure 400 {object} errorResponse
-// @Failure 500 {object} errorResponse
-// @Failure default {object} errorResponse
+// @Failure 400 {object} problemResponse
+// @Failure 409 {object} problemResponse
+// @Failure 500 {object} problemResponse
+// @Failure default {object} problemResponse
 // @Router /auth/sign-up [post]
 func (h *Handler) SignUp(c *gin.Context) {
 	var input todo.User
 
-	if err := c.BindJSON(&input); err != nil {
-
-		newErrorResponse(c, http.StatusBadRequest, "invalid input body")
-
+	if err := c.ShouldBindJSON(&input); err != nil {
+		newBindErrorResponse(c, err)
 		return
 	}
 
-	id, err := h.services.Authorization.CreateUser(input)
+	id, err := h.services.Authorization.CreateUser(c.Request.Context(), input)
 	if err != nil {
 
-		newErrorResponse(c, http.StatusInternalServerError, err.Error())
+		newServiceErrorResponse(c, err)
 		return
 	}
 
@@ -52,28 +51,101 @@ type signInInput struct {
 // @Accept json
 // @Produce json
 // @Param input body signInInput true "login and password"
-// @Success 200 {integer} integer 1
-// @Failure 400 {object} errorResponse
-// @Failure 500 {object} errorResponse
-// @Failure default {object} errorResponse
+// @Success 200 {object} todo.Tokens
+// @Failure 400 {object} problemResponse
+// @Failure 401 {object} problemResponse
+// @Failure 500 {object} problemResponse
+// @Failure default {object} problemResponse
 // @Router /auth/sign-in [post]
 func (h *Handler) signIn(c *gin.Context) {
 	var input signInInput
 
-	if err := c.BindJSON(&input); err != nil {
+	if err := c.ShouldBindJSON(&input); err != nil {
+		newBindErrorResponse(c, err)
+		return
+	}
 
-		newErrorResponse(c, http.StatusBadRequest, "invalid input body")
+	tokens, err := h.services.Authorization.GenerateToken(c.Request.Context(), input.Username, input.Password)
+	if err != nil {
+		newServiceErrorResponse(c, err)
+		return
+	}
 
+	c.JSON(http.StatusOK, tokens)
+}
+
+type refreshInput struct {
+	RefreshToken string `json:"refresh_token" binding:"required"`
+}
+
+// @Summary Refresh
+// @Tags auth
+// @Description exchange refresh token for a new token pair
+// @ID refresh-token
+// @Accept json
+// @Produce json
+// @Param input body refreshInput true "refresh token"
+// @Success 200 {object} todo.Tokens
+// @Failure 400 {object} problemResponse
+// @Failure 401 {object} problemResponse
+// @Failure 500 {object} problemResponse
+// @Failure default {object} problemResponse
+// @Router /auth/refresh [post]
+func (h *Handler) refresh(c *gin.Context) {
+	var input refreshInput
+
+	if err := c.ShouldBindJSON(&input); err != nil {
+		newBindErrorResponse(c, err)
 		return
 	}
 
-	token, err := h.services.Authorization.GenerateToken(input.Username, input.Password)
+	tokens, err := h.services.Authorization.RefreshToken(c.Request.Context(), input.RefreshToken)
 	if err != nil {
-		newErrorResponse(c, http.StatusInternalServerError, "error generate token")
+		newServiceErrorResponse(c, err)
 		return
 	}
 
-	c.JSON(http.StatusOK, map[string]interface{}{
-		"token": token,
+	c.JSON(http.StatusOK, tokens)
+}
+
+// @Summary Sign Out
+// @Tags auth
+// @Description revoke session of refresh token
+// @ID sign-out
+// @Accept json
+// @Produce json
+// @Param input body refreshInput true "refresh token"
+// @Success 200 {object} statusResponse
+// @Failure 400 {object} problemResponse
+// @Failure 401 {object} problemResponse
+// @Failure 500 {object} problemResponse
+// @Failure default {object} problemResponse
+// @Router /auth/sign-out [post]
+func (h *Handler) signOut(c *gin.Context) {
+	var input refreshInput
+
+	if err := c.ShouldBindJSON(&input); err != nil {
+		newBindErrorResponse(c, err)
+		return
+	}
+
+	if err := h.services.Authorization.SignOut(c.Request.Context(), input.RefreshToken); err != nil {
+		newServiceErrorResponse(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, statusResponse{
+		Status: "ok",
 	})
 }
+
+// @Summary JWKS
+// @Tags auth
+// @Description public keys for verifying access tokens
+// @ID jwks
+// @Produce json
+// @Success 200 {object} todo.JSONWebKeySet
+// @Router /.well-known/jwks.json [get]
+func (h *Handler) jwks(c *gin.Context) {
+	c.JSON(http.StatusOK, h.services.Authorization.JWKS())
+}
@@ -6,6 +6,7 @@ import (
 	"do-app/pkg/service"
 	mock_service "do-app/pkg/service/mocks"
 	"errors"
+	"fmt"
 	"github.com/gin-gonic/gin"
 	"github.com/golang/mock/gomock"
 	"github.com/stretchr/testify/assert"
@@ -33,7 +34,7 @@ func TestHandler_SingUp(t *testing.T) {
 				Password: "qwerty",
 			},
 			mockBehavior: func(s *mock_service.MockAuthorization, user todo.User) {
-				s.EXPECT().CreateUser(user).Return(1, nil)
+				s.EXPECT().CreateUser(gomock.Any(), user).Return(1, nil)
 			},
 			expectStatusCode:  200,
 			expectRequestBody: `{"id":1}`,
@@ -43,7 +44,7 @@ func TestHandler_SingUp(t *testing.T) {
 			inputBody:         `{"username":"test","password":"qwerty"}`,
 			mockBehavior:      func(s *mock_service.MockAuthorization, user todo.User) {},
 			expectStatusCode:  400,
-			expectRequestBody: `{"message":"invalid input body"}`,
+			expectRequestBody: `{"type":"about:blank","title":"Bad Request","status":400,"detail":"invalid input body","code":"invalid_body","errors":[{"field":"name","rule":"required","message":"is required"}]}`,
 		},
 		{
 			name:      "Service failure",
@@ -54,10 +55,10 @@ func TestHandler_SingUp(t *testing.T) {
 				Password: "qwerty",
 			},
 			mockBehavior: func(s *mock_service.MockAuthorization, user todo.User) {
-				s.EXPECT().CreateUser(user).Return(1, errors.New("service failure"))
+				s.EXPECT().CreateUser(gomock.Any(), user).Return(1, errors.New("service failure"))
 			},
 			expectStatusCode:  500,
-			expectRequestBody: `{"message":"service failure"}`,
+			expectRequestBody: `{"type":"about:blank","title":"Internal Server Error","status":500,"code":"internal_error"}`,
 		},
 	}
 
@@ -70,7 +71,7 @@ func TestHandler_SingUp(t *testing.T) {
 			testCase.mockBehavior(auth, testCase.inputUser)
 
 			services := &service.Service{Authorization: auth}
-			handler := NewHandler(services)
+			handler := NewHandler(services, Config{})
 
 			r := gin.New()
 			r.POST("/sign-up", handler.SignUp)
@@ -106,17 +107,33 @@ func TestHandler_signIn(t *testing.T) {
 				Password: "qwerty",
 			},
 			mockBehavior: func(s *mock_service.MockAuthorization, user signInInput) {
-				s.EXPECT().GenerateToken(user.Username, user.Password).Return("1", nil)
+				s.EXPECT().GenerateToken(gomock.Any(), user.Username, user.Password).
+					Return(todo.Tokens{AccessToken: "1", RefreshToken: "2"}, nil)
 			},
 			expectStatusCode:  200,
-			expectRequestBody: `{"token":"1"}`,
+			expectRequestBody: `{"token":"1","refresh_token":"2"}`,
 		},
 		{
 			name:              "No Pole",
 			inputBody:         `{"username":"test"}`,
 			mockBehavior:      func(s *mock_service.MockAuthorization, user signInInput) {},
 			expectStatusCode:  400,
-			expectRequestBody: `{"message":"invalid input body"}`,
+			expectRequestBody: `{"type":"about:blank","title":"Bad Request","status":400,"detail":"invalid input body","code":"invalid_body","errors":[{"field":"password","rule":"required","message":"is required"}]}`,
+		},
+		{
+			name:      "Wrong credentials",
+			inputBody: `{"username":"test", "password":"qwerty"}`,
+			inputUser: signInInput{
+				Username: "test",
+				Password: "qwerty",
+			},
+			mockBehavior: func(s *mock_service.MockAuthorization, user signInInput) {
+				s.EXPECT().GenerateToken(gomock.Any(), user.Username, user.Password).
+					Return(todo.Tokens{}, fmt.Errorf("generate token: %w",
+						todo.NewError(todo.ErrUnauthorized, "invalid username or password")))
+			},
+			expectStatusCode:  401,
+			expectRequestBody: `{"type":"about:blank","title":"Unauthorized","status":401,"detail":"invalid username or password","code":"unauthorized"}`,
 		},
 		{
 			name:      "Failed in service",
@@ -126,10 +143,10 @@ func TestHandler_signIn(t *testing.T) {
 				Password: "qwerty",
 			},
 			mockBehavior: func(s *mock_service.MockAuthorization, user signInInput) {
-				s.EXPECT().GenerateToken(user.Username, user.Password).Return("1", errors.New("error generate token"))
+				s.EXPECT().GenerateToken(gomock.Any(), user.Username, user.Password).Return(todo.Tokens{}, errors.New("error generate token"))
 			},
 			expectStatusCode:  500,
-			expectRequestBody: `{"message":"error generate token"}`,
+			expectRequestBody: `{"type":"about:blank","title":"Internal Server Error","status":500,"code":"internal_error"}`,
 		},
 	}
 
@@ -143,7 +160,7 @@ func TestHandler_signIn(t *testing.T) {
 			testCase.mockBehavior(auth, testCase.inputUser)
 
 			services := &service.Service{Authorization: auth}
-			handler := NewHandler(services)
+			handler := NewHandler(services, Config{})
 
 			r := gin.New()
 			r.POST("/sign-in", handler.signIn)
@@ -159,3 +176,176 @@ func TestHandler_signIn(t *testing.T) {
 		})
 	}
 }
+
+func TestHandler_refresh(t *testing.T) {
+	type mockBehavior func(s *mock_service.MockAuthorization, refreshToken string)
+
+	testTable := []struct {
+		name              string
+		inputBody         string
+		refreshToken      string
+		mockBehavior      mockBehavior
+		expectStatusCode  int
+		expectRequestBody string
+	}{
+		{
+			name:         "OK",
+			inputBody:    `{"refresh_token":"old"}`,
+			refreshToken: "old",
+			mockBehavior: func(s *mock_service.MockAuthorization, refreshToken string) {
+				s.EXPECT().RefreshToken(gomock.Any(), refreshToken).
+					Return(todo.Tokens{AccessToken: "access", RefreshToken: "new"}, nil)
+			},
+			expectStatusCode:  200,
+			expectRequestBody: `{"token":"access","refresh_token":"new"}`,
+		},
+		{
+			name:              "No Pole",
+			inputBody:         `{}`,
+			mockBehavior:      func(s *mock_service.MockAuthorization, refreshToken string) {},
+			expectStatusCode:  400,
+			expectRequestBody: `{"type":"about:blank","title":"Bad Request","status":400,"detail":"invalid input body","code":"invalid_body","errors":[{"field":"refresh_token","rule":"required","message":"is required"}]}`,
+		},
+		{
+			name:         "Revoked",
+			inputBody:    `{"refresh_token":"old"}`,
+			refreshToken: "old",
+			mockBehavior: func(s *mock_service.MockAuthorization, refreshToken string) {
+				s.EXPECT().RefreshToken(gomock.Any(), refreshToken).Return(todo.Tokens{}, fmt.Errorf("refresh token: %w",
+					todo.NewError(todo.ErrUnauthorized, "invalid refresh token")))
+			},
+			expectStatusCode:  401,
+			expectRequestBody: `{"type":"about:blank","title":"Unauthorized","status":401,"detail":"invalid refresh token","code":"unauthorized"}`,
+		},
+		{
+			name:         "Failed in service",
+			inputBody:    `{"refresh_token":"old"}`,
+			refreshToken: "old",
+			mockBehavior: func(s *mock_service.MockAuthorization, refreshToken string) {
+				s.EXPECT().RefreshToken(gomock.Any(), refreshToken).Return(todo.Tokens{}, errors.New("connection refused"))
+			},
+			expectStatusCode:  500,
+			expectRequestBody: `{"type":"about:blank","title":"Internal Server Error","status":500,"code":"internal_error"}`,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			c := gomock.NewController(t)
+			defer c.Finish()
+
+			auth := mock_service.NewMockAuthorization(c)
+			testCase.mockBehavior(auth, testCase.refreshToken)
+
+			services := &service.Service{Authorization: auth}
+			handler := NewHandler(services, Config{})
+
+			r := gin.New()
+			r.POST("/refresh", handler.refresh)
+
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest("POST", "/refresh",
+				bytes.NewBufferString(testCase.inputBody))
+
+			r.ServeHTTP(w, req)
+
+			assert.Equal(t, testCase.expectStatusCode, w.Code)
+			assert.Equal(t, testCase.expectRequestBody, w.Body.String())
+		})
+	}
+}
+
+func TestHandler_signOut(t *testing.T) {
+	type mockBehavior func(s *mock_service.MockAuthorization, refreshToken string)
+
+	testTable := []struct {
+		name              string
+		inputBody         string
+		refreshToken      string
+		mockBehavior      mockBehavior
+		expectStatusCode  int
+		expectRequestBody string
+	}{
+		{
+			name:         "OK",
+			inputBody:    `{"refresh_token":"token"}`,
+			refreshToken: "token",
+			mockBehavior: func(s *mock_service.MockAuthorization, refreshToken string) {
+				s.EXPECT().SignOut(gomock.Any(), refreshToken).Return(nil)
+			},
+			expectStatusCode:  200,
+			expectRequestBody: `{"status":"ok"}`,
+		},
+		{
+			name:         "Unknown token",
+			inputBody:    `{"refresh_token":"token"}`,
+			refreshToken: "token",
+			mockBehavior: func(s *mock_service.MockAuthorization, refreshToken string) {
+				s.EXPECT().SignOut(gomock.Any(), refreshToken).Return(fmt.Errorf("sign out: %w",
+					todo.NewError(todo.ErrUnauthorized, "invalid refresh token")))
+			},
+			expectStatusCode:  401,
+			expectRequestBody: `{"type":"about:blank","title":"Unauthorized","status":401,"detail":"invalid refresh token","code":"unauthorized"}`,
+		},
+		{
+			name:         "Failed in service",
+			inputBody:    `{"refresh_token":"token"}`,
+			refreshToken: "token",
+			mockBehavior: func(s *mock_service.MockAuthorization, refreshToken string) {
+				s.EXPECT().SignOut(gomock.Any(), refreshToken).Return(errors.New("connection refused"))
+			},
+			expectStatusCode:  500,
+			expectRequestBody: `{"type":"about:blank","title":"Internal Server Error","status":500,"code":"internal_error"}`,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			c := gomock.NewController(t)
+			defer c.Finish()
+
+			auth := mock_service.NewMockAuthorization(c)
+			testCase.mockBehavior(auth, testCase.refreshToken)
+
+			services := &service.Service{Authorization: auth}
+			handler := NewHandler(services, Config{})
+
+			r := gin.New()
+			r.POST("/sign-out", handler.signOut)
+
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest("POST", "/sign-out",
+				bytes.NewBufferString(testCase.inputBody))
+
+			r.ServeHTTP(w, req)
+
+			assert.Equal(t, testCase.expectStatusCode, w.Code)
+			assert.Equal(t, testCase.expectRequestBody, w.Body.String())
+		})
+	}
+}
+
+func TestHandler_jwks(t *testing.T) {
+	c := gomock.NewController(t)
+	defer c.Finish()
+
+	auth := mock_service.NewMockAuthorization(c)
+	auth.EXPECT().JWKS().Return(todo.JSONWebKeySet{Keys: []todo.JSONWebKey{
+		{Kty: "OKP", Kid: "ed-1", Alg: "EdDSA", Use: "sig", Crv: "Ed25519", X: "abc"},
+	}})
+
+	services := &service.Service{Authorization: auth}
+	handler := NewHandler(services, Config{})
+
+	r := gin.New()
+	r.GET("/.well-known/jwks.json", handler.jwks)
+
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest("GET", "/.well-known/jwks.json", nil)
+
+	r.ServeHTTP(w, req)
+
+	assert.Equal(t, 200, w.Code)
+	assert.Equal(t, `{"keys":[{"kty":"OKP","kid":"ed-1","alg":"EdDSA","use":"sig","crv":"Ed25519","x":"abc"}]}`,
+		w.Body.String())
+}
@@ -5,25 +5,38 @@ import (
 	"github.com/gin-gonic/gin"
 	swaggerFiles "github.com/swaggo/files"
 	ginSwagger "github.com/swaggo/gin-swagger"
+	"time"
 )
 
+// Config tunes request handling. QueryTimeout bounds the time the queries of
+// a single request may take; zero means no deadline.
+type Config struct {
+	QueryTimeout time.Duration
+}
+
 type Handler struct {
 	services *service.Service
+	cfg      Config
 }
 
-func NewHandler(services *service.Service) *Handler {
-	return &Handler{services: services}
+func NewHandler(services *service.Service, cfg Config) *Handler {
+	jsonFieldNames()
+	return &Handler{services: services, cfg: cfg}
 }
 
 func (h *Handler) InitRoutes() *gin.Engine {
 	router := gin.New()
+	router.Use(h.requestId, h.queryDeadline)
 
 	router.GET("/swagger/*any", ginSwagger.WrapHandler(swaggerFiles.Handler))
+	router.GET("/.well-known/jwks.json", h.jwks)
 
 	auth := router.Group("/auth")
 	{
 		auth.POST("/sign-up", h.SignUp)
 		auth.POST("/sign-in", h.signIn)
+		auth.POST("/refresh", h.refresh)
+		auth.POST("/sign-out", h.signOut)
 	}
 
 	api := router.Group("/api", h.userIdentity)
@@ -35,19 +48,68 @@ func (h *Handler) InitRoutes() *gin.Engine {
 			lists.GET("/:id", h.getListById)
 			lists.PUT("/:id", h.updateList)
 			lists.DELETE("/:id", h.deleteList)
+			lists.POST("/:id/copy", h.copyList)
+			lists.GET("/:id/activity", h.getListActivity)
 
 			items := lists.Group(":id/items")
 			{
 				items.POST("/", h.createItem)
 				items.GET("/", h.getAllItems)
 			}
+
+			members := lists.Group(":id/members")
+			{
+				members.GET("/", h.getAllMembers)
+				members.POST("/", h.addMember)
+				members.PUT("/:userId", h.updateMember)
+				members.DELETE("/:userId", h.deleteMember)
+			}
+
+			invites := lists.Group(":id/invites")
+			{
+				invites.POST("/", h.createInvite)
+				invites.GET("/", h.getAllInvites)
+				invites.DELETE("/:inviteId", h.revokeInvite)
+			}
+		}
+		invites := api.Group("/invites")
+		{
+			invites.POST("/:token/accept", h.acceptInvite)
 		}
 		items := api.Group("/items")
 		{
+			items.GET("/", h.getUserItems)
 			items.GET("/:id", h.getItemById)
 			items.PUT("/:id", h.updateItem)
 			items.DELETE("/:id", h.deleteItem)
+			items.POST("/:id/move", h.moveItem)
+			items.GET("/:id/history", h.getItemHistory)
+			items.POST("/:id/subtasks", h.createSubtask)
+			items.GET("/:id/subtasks", h.getAllSubtasks)
+			items.PUT("/:id/subtasks/:subtaskId", h.updateSubtask)
+			items.DELETE("/:id/subtasks/:subtaskId", h.deleteSubtask)
+			items.GET("/:id/series", h.getItemSeries)
+			items.PUT("/:id/series", h.updateItemSeries)
+			items.DELETE("/:id/series", h.stopItemSeries)
+			items.GET("/:id/labels", h.getItemLabels)
+			items.POST("/:id/labels/:labelId", h.attachLabel)
+			items.DELETE("/:id/labels/:labelId", h.detachLabel)
+		}
+		labels := api.Group("/labels")
+		{
+			labels.POST("/", h.createLabel)
+			labels.GET("/", h.getAllLabels)
+			labels.GET("/:id", h.getLabelById)
+			labels.PUT("/:id", h.updateLabel)
+			labels.DELETE("/:id", h.deleteLabel)
+		}
+		trash := api.Group("/trash")
+		{
+			trash.GET("/", h.getTrash)
+			trash.POST("/:type/:id/restore", h.restoreTrash)
 		}
+		api.GET("/search", h.search)
+		api.POST("/undo/:token", h.undo)
 	}
 
 	return router
@@ -0,0 +1,177 @@
+package handler
+
+import (
+	todo "do-app"
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"strconv"
+)
+
+type GetAllInvitesResponse struct {
+	Data []todo.ListInvite `json:"data"`
+}
+
+// @Summary Create invite
+// @Tags invites
+// @Security ApiKeyAuth
+// @Description create invitation link for list
+// @ID create-invite
+// @Accept json
+// @Produce json
+// @Param id path string true "list id"
+// @Param input body todo.CreateInviteInput true "invite information"
+// @Success 200 {object} todo.ListInvite
+// @Failure 400 {object} problemResponse
+// @Failure 401 {object} problemResponse
+// @Failure 403 {object} problemResponse
+// @Failure 404 {object} problemResponse
+// @Failure 500 {object} problemResponse
+// @Failure default {object} problemResponse
+// @Router /api/lists/{id}/invites [post]
+func (h *Handler) createInvite(c *gin.Context) {
+	userId, err := getUserId(c)
+	if err != nil {
+		newErrorResponse(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	listId, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		newErrorResponse(c, http.StatusBadRequest, "invalid list id param")
+		return
+	}
+
+	var input todo.CreateInviteInput
+	if err = c.ShouldBindJSON(&input); err != nil {
+		newBindErrorResponse(c, err)
+		return
+	}
+
+	invite, err := h.services.ListInvites.Create(c.Request.Context(), userId, listId, input)
+	if err != nil {
+		newServiceErrorResponse(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, invite)
+}
+
+// @Summary Get invites
+// @Tags invites
+// @Security ApiKeyAuth
+// @Description get all invites of list
+// @ID get-invites
+// @Accept json
+// @Produce json
+// @Param id path string true "list id"
+// @Success 200 {object} GetAllInvitesResponse
+// @Failure 400 {object} problemResponse
+// @Failure 401 {object} problemResponse
+// @Failure 403 {object} problemResponse
+// @Failure 404 {object} problemResponse
+// @Failure 500 {object} problemResponse
+// @Failure default {object} problemResponse
+// @Router /api/lists/{id}/invites [get]
+func (h *Handler) getAllInvites(c *gin.Context) {
+	userId, err := getUserId(c)
+	if err != nil {
+		newErrorResponse(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	listId, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		newErrorResponse(c, http.StatusBadRequest, "invalid list id param")
+		return
+	}
+
+	invites, err := h.services.ListInvites.GetAll(c.Request.Context(), userId, listId)
+	if err != nil {
+		newServiceErrorResponse(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, GetAllInvitesResponse{
+		Data: invites,
+	})
+}
+
+// @Summary Revoke invite
+// @Tags invites
+// @Security ApiKeyAuth
+// @Description revoke invitation link
+// @ID revoke-invite
+// @Accept json
+// @Produce json
+// @Param id path string true "list id"
+// @Param inviteId path string true "invite id"
+// @Success 200 {object} statusResponse
+// @Failure 400 {object} problemResponse
+// @Failure 401 {object} problemResponse
+// @Failure 403 {object} problemResponse
+// @Failure 404 {object} problemResponse
+// @Failure 500 {object} problemResponse
+// @Failure default {object} problemResponse
+// @Router /api/lists/{id}/invites/{inviteId} [delete]
+func (h *Handler) revokeInvite(c *gin.Context) {
+	userId, err := getUserId(c)
+	if err != nil {
+		newErrorResponse(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	listId, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		newErrorResponse(c, http.StatusBadRequest, "invalid list id param")
+		return
+	}
+
+	inviteId, err := strconv.Atoi(c.Param("inviteId"))
+	if err != nil {
+		newErrorResponse(c, http.StatusBadRequest, "invalid invite id param")
+		return
+	}
+
+	if err = h.services.ListInvites.Revoke(c.Request.Context(), userId, listId, inviteId); err != nil {
+		newServiceErrorResponse(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, statusResponse{
+		Status: "ok",
+	})
+}
+
+// @Summary Accept invite
+// @Tags invites
+// @Security ApiKeyAuth
+// @Description join list through invitation link
+// @ID accept-invite
+// @Accept json
+// @Produce json
+// @Param token path string true "invite token"
+// @Success 200 {integer} integer 1
+// @Failure 401 {object} problemResponse
+// @Failure 403 {object} problemResponse
+// @Failure 404 {object} problemResponse
+// @Failure 409 {object} problemResponse
+// @Failure 500 {object} problemResponse
+// @Failure default {object} problemResponse
+// @Router /api/invites/{token}/accept [post]
+func (h *Handler) acceptInvite(c *gin.Context) {
+	userId, err := getUserId(c)
+	if err != nil {
+		newErrorResponse(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	listId, err := h.services.ListInvites.Accept(c.Request.Context(), userId, c.Param("token"))
+	if err != nil {
+		newServiceErrorResponse(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, map[string]interface{}{
+		"list_id": listId,
+	})
+}
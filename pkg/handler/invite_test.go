@@ -0,0 +1,163 @@
+package handler
+
+import (
+	"bytes"
+	todo "do-app"
+	"do-app/pkg/service"
+	mock_service "do-app/pkg/service/mocks"
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestHandler_createInvite(t *testing.T) {
+	type mockBehavior func(s *mock_service.MockListInvites, userId, listId int, input todo.CreateInviteInput)
+
+	expiresAt := time.Date(2030, 1, 2, 3, 4, 5, 0, time.UTC)
+	maxUses := 1
+
+	testTable := []struct {
+		name              string
+		inputBody         string
+		input             todo.CreateInviteInput
+		mockBehavior      mockBehavior
+		expectStatusCode  int
+		expectRequestBody string
+	}{
+		{
+			name:      "OK",
+			inputBody: `{"role":"viewer","expires_in":"24h"}`,
+			input:     todo.CreateInviteInput{Role: todo.RoleViewer, ExpiresIn: "24h"},
+			mockBehavior: func(s *mock_service.MockListInvites, userId, listId int, input todo.CreateInviteInput) {
+				s.EXPECT().Create(gomock.Any(), userId, listId, input).Return(todo.ListInvite{
+					Id:        1,
+					ListId:    listId,
+					Token:     "token",
+					Role:      todo.RoleViewer,
+					CreatedBy: userId,
+					MaxUses:   &maxUses,
+					ExpiresAt: expiresAt,
+					CreatedAt: expiresAt,
+				}, nil)
+			},
+			expectStatusCode: 200,
+			expectRequestBody: `{"id":1,"list_id":1,"token":"token","role":"viewer","created_by":1,"max_uses":1,` +
+				`"uses":0,"expires_at":"2030-01-02T03:04:05Z","revoked":false,"created_at":"2030-01-02T03:04:05Z"}`,
+		},
+		{
+			name:              "No Role",
+			inputBody:         `{"max_uses":3}`,
+			mockBehavior:      func(s *mock_service.MockListInvites, userId, listId int, input todo.CreateInviteInput) {},
+			expectStatusCode:  400,
+			expectRequestBody: `{"type":"about:blank","title":"Bad Request","status":400,"detail":"invalid input body","code":"invalid_body","errors":[{"field":"role","rule":"required","message":"is required"}]}`,
+		},
+		{
+			name:      "Service Error",
+			inputBody: `{"role":"editor"}`,
+			input:     todo.CreateInviteInput{Role: todo.RoleEditor},
+			mockBehavior: func(s *mock_service.MockListInvites, userId, listId int, input todo.CreateInviteInput) {
+				s.EXPECT().Create(gomock.Any(), userId, listId, input).Return(todo.ListInvite{}, fmt.Errorf("service failure"))
+			},
+			expectStatusCode:  500,
+			expectRequestBody: `{"type":"about:blank","title":"Internal Server Error","status":500,"code":"internal_error"}`,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			c := gomock.NewController(t)
+			defer c.Finish()
+
+			invites := mock_service.NewMockListInvites(c)
+			testCase.mockBehavior(invites, 1, 1, testCase.input)
+
+			services := &service.Service{ListInvites: invites}
+			handler := NewHandler(services, Config{})
+
+			r := gin.New()
+			r.POST("/:id/invites", func(ctx *gin.Context) {
+				ctx.Set(userCtx, 1)
+			}, handler.createInvite)
+
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest("POST", "/1/invites",
+				bytes.NewBufferString(testCase.inputBody))
+
+			r.ServeHTTP(w, req)
+
+			assert.Equal(t, testCase.expectStatusCode, w.Code)
+			assert.Equal(t, testCase.expectRequestBody, w.Body.String())
+		})
+	}
+}
+
+func TestHandler_acceptInvite(t *testing.T) {
+	type mockBehavior func(s *mock_service.MockListInvites, userId int, token string)
+
+	testTable := []struct {
+		name              string
+		userId            int
+		mockBehavior      mockBehavior
+		expectStatusCode  int
+		expectRequestBody string
+	}{
+		{
+			name:   "OK",
+			userId: 2,
+			mockBehavior: func(s *mock_service.MockListInvites, userId int, token string) {
+				s.EXPECT().Accept(gomock.Any(), userId, token).Return(5, nil)
+			},
+			expectStatusCode:  200,
+			expectRequestBody: `{"list_id":5}`,
+		},
+		{
+			name:              "No User",
+			mockBehavior:      func(s *mock_service.MockListInvites, userId int, token string) {},
+			expectStatusCode:  500,
+			expectRequestBody: `{"type":"about:blank","title":"Internal Server Error","status":500,"detail":"user id not found","code":"internal_error"}`,
+		},
+		{
+			name:   "Expired",
+			userId: 2,
+			mockBehavior: func(s *mock_service.MockListInvites, userId int, token string) {
+				s.EXPECT().Accept(gomock.Any(), userId, token).Return(0, fmt.Errorf("Redeem invite repository: %w",
+					todo.NewError(todo.ErrNotFound, "invite not found")))
+			},
+			expectStatusCode:  404,
+			expectRequestBody: `{"type":"about:blank","title":"Not Found","status":404,"detail":"invite not found","code":"not_found"}`,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			c := gomock.NewController(t)
+			defer c.Finish()
+
+			invites := mock_service.NewMockListInvites(c)
+			testCase.mockBehavior(invites, testCase.userId, "token")
+
+			services := &service.Service{ListInvites: invites}
+			handler := NewHandler(services, Config{})
+
+			r := gin.New()
+			r.POST("/:token/accept", func(ctx *gin.Context) {
+				if testCase.userId == 0 {
+					return
+				}
+				ctx.Set(userCtx, testCase.userId)
+			}, handler.acceptInvite)
+
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest("POST", "/token/accept", nil)
+
+			r.ServeHTTP(w, req)
+
+			assert.Equal(t, testCase.expectStatusCode, w.Code)
+			assert.Equal(t, testCase.expectRequestBody, w.Body.String())
+		})
+	}
+}
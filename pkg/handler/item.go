@@ -2,11 +2,22 @@ package handler
 
 import (
 	todo "do-app"
+	"errors"
 	"github.com/gin-gonic/gin"
 	"net/http"
 	"strconv"
+	"time"
 )
 
+type GetAllItemsResponse struct {
+	Data       []todo.TodoItem `json:"data"`
+	NextCursor string          `json:"next_cursor,omitempty"`
+}
+
+type moveItemResponse struct {
+	Position float64 `json:"position"`
+}
+
 // @Summary Create item
 // @Tags items
 // @Security ApiKeyAuth
@@ -17,10 +28,12 @@ import (
 // @Param id path string true "list id"
 // @Param input body todo.TodoItem true "item information"
 // @Success 200 {integer} integer 1
-// @Failure 400 {object} errorResponse
-// @Failure 401 {object} errorResponse
-// @Failure 500 {object} errorResponse
-// @Failure default {object} errorResponse
+// @Failure 400 {object} problemResponse
+// @Failure 401 {object} problemResponse
+// @Failure 403 {object} problemResponse
+// @Failure 404 {object} problemResponse
+// @Failure 500 {object} problemResponse
+// @Failure default {object} problemResponse
 // @Router /api/lists/{id}/items [post]
 func (h *Handler) createItem(c *gin.Context) {
 	userId, err := getUserId(c)
@@ -36,14 +49,14 @@ func (h *Handler) createItem(c *gin.Context) {
 	}
 
 	var input todo.TodoItem
-	if err = c.BindJSON(&input); err != nil {
-		newErrorResponse(c, http.StatusBadRequest, "invalid input body")
+	if err = c.ShouldBindJSON(&input); err != nil {
+		newBindErrorResponse(c, err)
 		return
 	}
 
-	id, err := h.services.TodoItems.Create(userId, listId, input)
+	id, err := h.services.TodoItems.Create(c.Request.Context(), userId, listId, input)
 	if err != nil {
-		newErrorResponse(c, http.StatusInternalServerError, err.Error())
+		newServiceErrorResponse(c, err)
 		return
 	}
 
@@ -60,11 +73,22 @@ func (h *Handler) createItem(c *gin.Context) {
 // @Accept json
 // @Produce json
 // @Param id path string true "list id"
-// @Success 200 {integer} integer 1
-// @Failure 400 {object} errorResponse
-// @Failure 401 {object} errorResponse
-// @Failure 500 {object} errorResponse
-// @Failure default {object} errorResponse
+// @Param due_before query string false "RFC 3339 time or date (YYYY-MM-DD)"
+// @Param overdue query boolean false "only unfinished items past their due date"
+// @Param label query []string false "label names" collectionFormat(multi)
+// @Param label_match query string false "any or all of the labels" Enums(any, all)
+// @Param done query boolean false "only done or only unfinished items"
+// @Param q query string false "substring of the title"
+// @Param limit query int false "page size, 50 by default" minimum(1) maximum(200)
+// @Param cursor query string false "next_cursor of the previous page"
+// @Param sort query string false "sort key, prefix with - for descending" Enums(position, title, created, updated, due, priority, -position, -title, -created, -updated, -due, -priority)
+// @Success 200 {object} GetAllItemsResponse
+// @Failure 400 {object} problemResponse
+// @Failure 401 {object} problemResponse
+// @Failure 403 {object} problemResponse
+// @Failure 404 {object} problemResponse
+// @Failure 500 {object} problemResponse
+// @Failure default {object} problemResponse
 // @Router /api/lists/{id}/items [get]
 func (h *Handler) getAllItems(c *gin.Context) {
 	userId, err := getUserId(c)
@@ -79,12 +103,133 @@ func (h *Handler) getAllItems(c *gin.Context) {
 		return
 	}
 
-	items, err := h.services.TodoItems.GetAll(userId, listId)
+	filter, err := parseItemFilter(c)
+	if err != nil {
+		newErrorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	page, err := parsePageRequest(c)
+	if err != nil {
+		newErrorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	items, next, err := h.services.TodoItems.GetAll(c.Request.Context(), userId, listId, filter, page)
+	if err != nil {
+		newServiceErrorResponse(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, GetAllItemsResponse{
+		Data:       items,
+		NextCursor: next,
+	})
+}
+
+// @Summary Get items of user
+// @Tags items
+// @Security ApiKeyAuth
+// @Description get items across all lists of user
+// @ID get-user-items
+// @Accept json
+// @Produce json
+// @Param due_before query string false "RFC 3339 time or date (YYYY-MM-DD)"
+// @Param overdue query boolean false "only unfinished items past their due date"
+// @Param label query []string false "label names" collectionFormat(multi)
+// @Param label_match query string false "any or all of the labels" Enums(any, all)
+// @Param done query boolean false "only done or only unfinished items"
+// @Param q query string false "substring of the title"
+// @Param limit query int false "page size, 50 by default" minimum(1) maximum(200)
+// @Param cursor query string false "next_cursor of the previous page"
+// @Param sort query string false "sort key, prefix with - for descending" Enums(position, title, created, updated, due, priority, -position, -title, -created, -updated, -due, -priority)
+// @Success 200 {object} GetAllItemsResponse
+// @Failure 400 {object} problemResponse
+// @Failure 401 {object} problemResponse
+// @Failure 500 {object} problemResponse
+// @Failure default {object} problemResponse
+// @Router /api/items [get]
+func (h *Handler) getUserItems(c *gin.Context) {
+	userId, err := getUserId(c)
 	if err != nil {
 		newErrorResponse(c, http.StatusInternalServerError, err.Error())
 		return
 	}
-	c.JSON(http.StatusOK, items)
+
+	filter, err := parseItemFilter(c)
+	if err != nil {
+		newErrorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	page, err := parsePageRequest(c)
+	if err != nil {
+		newErrorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	items, next, err := h.services.TodoItems.GetAllByUser(c.Request.Context(), userId, filter, page)
+	if err != nil {
+		newServiceErrorResponse(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, GetAllItemsResponse{
+		Data:       items,
+		NextCursor: next,
+	})
+}
+
+// parseItemFilter reads the item filter from the query string. The label param
+// may be repeated; label_match=all requires every label instead of any.
+func parseItemFilter(c *gin.Context) (todo.ItemFilter, error) {
+	var filter todo.ItemFilter
+	if value := c.Query("due_before"); value != "" {
+		dueBefore, err := parseTimeParam(value)
+		if err != nil {
+			return filter, errors.New("invalid due_before param")
+		}
+		filter.DueBefore = &dueBefore
+	}
+	if value := c.Query("overdue"); value != "" {
+		overdue, err := strconv.ParseBool(value)
+		if err != nil {
+			return filter, errors.New("invalid overdue param")
+		}
+		filter.Overdue = overdue
+	}
+
+	seen := make(map[string]bool)
+	for _, label := range c.QueryArray("label") {
+		if label != "" && !seen[label] {
+			seen[label] = true
+			filter.Labels = append(filter.Labels, label)
+		}
+	}
+	switch c.DefaultQuery("label_match", "any") {
+	case "any":
+	case "all":
+		filter.AllLabels = true
+	default:
+		return filter, errors.New("invalid label_match param")
+	}
+
+	if value := c.Query("done"); value != "" {
+		done, err := strconv.ParseBool(value)
+		if err != nil {
+			return filter, errors.New("invalid done param")
+		}
+		filter.Done = &done
+	}
+	filter.Query = c.Query("q")
+	return filter, nil
+}
+
+// parseTimeParam accepts RFC 3339 timestamps and plain dates, which stand for
+// midnight UTC of that day.
+func parseTimeParam(value string) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	return time.Parse(time.DateOnly, value)
 }
 
 // @Summary Get Item By Id
@@ -95,11 +240,16 @@ func (h *Handler) getAllItems(c *gin.Context) {
 // @Accept json
 // @Produce json
 // @Param id path string true "item id"
+// @Param If-None-Match header string false "ETag of a cached copy"
 // @Success 200 {integer} integer 1
-// @Failure 400 {object} errorResponse
-// @Failure 401 {object} errorResponse
-// @Failure 500 {object} errorResponse
-// @Failure default {object} errorResponse
+// @Header 200 {string} ETag "version of the item"
+// @Success 304 "not modified"
+// @Failure 400 {object} problemResponse
+// @Failure 401 {object} problemResponse
+// @Failure 403 {object} problemResponse
+// @Failure 404 {object} problemResponse
+// @Failure 500 {object} problemResponse
+// @Failure default {object} problemResponse
 // @Router /api/items/{id} [get]
 func (h *Handler) getItemById(c *gin.Context) {
 	userId, err := getUserId(c)
@@ -114,9 +264,12 @@ func (h *Handler) getItemById(c *gin.Context) {
 		return
 	}
 
-	item, err := h.services.TodoItems.GetById(userId, itemId)
+	item, err := h.services.TodoItems.GetById(c.Request.Context(), userId, itemId)
 	if err != nil {
-		newErrorResponse(c, http.StatusInternalServerError, err.Error())
+		newServiceErrorResponse(c, err)
+		return
+	}
+	if notModified(c, item.Version) {
 		return
 	}
 	c.JSON(http.StatusOK, item)
@@ -131,11 +284,16 @@ func (h *Handler) getItemById(c *gin.Context) {
 // @Produce json
 // @Param id path string true "item id"
 // @Param input body todo.UpdateItemInput false "information for update"
-// @Success 200 {integer} integer 1
-// @Failure 400 {object} errorResponse
-// @Failure 401 {object} errorResponse
-// @Failure 500 {object} errorResponse
-// @Failure default {object} errorResponse
+// @Param If-Match header string false "ETag the item is expected to have"
+// @Success 200 {object} undoableResponse
+// @Header 200 {string} ETag "new version of the item"
+// @Failure 400 {object} problemResponse
+// @Failure 401 {object} problemResponse
+// @Failure 403 {object} problemResponse
+// @Failure 404 {object} problemResponse
+// @Failure 412 {object} problemResponse
+// @Failure 500 {object} problemResponse
+// @Failure default {object} problemResponse
 // @Router /api/items/{id} [put]
 func (h *Handler) updateItem(c *gin.Context) {
 	userId, err := getUserId(c)
@@ -150,35 +308,95 @@ func (h *Handler) updateItem(c *gin.Context) {
 		return
 	}
 
+	version, err := parseIfMatch(c)
+	if err != nil {
+		newErrorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
 	var input todo.UpdateItemInput
-	if err = c.BindJSON(&input); err != nil {
-		newErrorResponse(c, http.StatusBadRequest, "invalid input body")
+	if err = c.ShouldBindJSON(&input); err != nil {
+		newBindErrorResponse(c, err)
 		return
 	}
 
-	if err = h.services.TodoItems.Update(userId, id, input); err != nil {
+	undoToken, newVersion, err := h.services.TodoItems.Update(c.Request.Context(), userId, id, input, version)
+	if err != nil {
+		newServiceErrorResponse(c, err)
+		return
+	}
+
+	c.Header("ETag", etag(newVersion))
+	c.JSON(http.StatusOK, undoableResponse{
+		Status:    "ok",
+		UndoToken: undoToken,
+	})
+}
+
+// @Summary Move Item
+// @Tags items
+// @Security ApiKeyAuth
+// @Description reorder item or move it to another list the user owns
+// @ID move-item
+// @Accept json
+// @Produce json
+// @Param id path string true "item id"
+// @Param input body todo.MoveItemInput false "target list and neighbour item"
+// @Success 200 {object} moveItemResponse
+// @Failure 400 {object} problemResponse
+// @Failure 401 {object} problemResponse
+// @Failure 403 {object} problemResponse
+// @Failure 404 {object} problemResponse
+// @Failure 500 {object} problemResponse
+// @Failure default {object} problemResponse
+// @Router /api/items/{id}/move [post]
+func (h *Handler) moveItem(c *gin.Context) {
+	userId, err := getUserId(c)
+	if err != nil {
 		newErrorResponse(c, http.StatusInternalServerError, err.Error())
 		return
 	}
 
-	c.JSON(http.StatusOK, statusResponse{
-		Status: "ok",
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		newErrorResponse(c, http.StatusBadRequest, "invalid item id param")
+		return
+	}
+
+	var input todo.MoveItemInput
+	if err = c.ShouldBindJSON(&input); err != nil {
+		newBindErrorResponse(c, err)
+		return
+	}
+
+	position, err := h.services.TodoItems.Move(c.Request.Context(), userId, id, input)
+	if err != nil {
+		newServiceErrorResponse(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, moveItemResponse{
+		Position: position,
 	})
 }
 
 // @Summary Delete Item
 // @Tags items
 // @Security ApiKeyAuth
-// @Description delete item
+// @Description move item to the trash
 // @ID delete-item
 // @Accept json
 // @Produce json
 // @Param id path string true "item id"
-// @Success 200 {integer} integer 1
-// @Failure 400 {object} errorResponse
-// @Failure 401 {object} errorResponse
-// @Failure 500 {object} errorResponse
-// @Failure default {object} errorResponse
+// @Param If-Match header string false "ETag the item is expected to have"
+// @Success 200 {object} undoableResponse
+// @Failure 400 {object} problemResponse
+// @Failure 401 {object} problemResponse
+// @Failure 403 {object} problemResponse
+// @Failure 404 {object} problemResponse
+// @Failure 412 {object} problemResponse
+// @Failure 500 {object} problemResponse
+// @Failure default {object} problemResponse
 // @Router /api/items/{id} [delete]
 func (h *Handler) deleteItem(c *gin.Context) {
 	userId, err := getUserId(c)
@@ -193,13 +411,20 @@ func (h *Handler) deleteItem(c *gin.Context) {
 		return
 	}
 
-	err = h.services.TodoItems.Delete(userId, itemId)
+	version, err := parseIfMatch(c)
 	if err != nil {
-		newErrorResponse(c, http.StatusInternalServerError, err.Error())
+		newErrorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	undoToken, err := h.services.TodoItems.Delete(c.Request.Context(), userId, itemId, version)
+	if err != nil {
+		newServiceErrorResponse(c, err)
 		return
 	}
-	c.JSON(http.StatusOK, statusResponse{
-		Status: "ok",
+	c.JSON(http.StatusOK, undoableResponse{
+		Status:    "ok",
+		UndoToken: undoToken,
 	})
 
 }
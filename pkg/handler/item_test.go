@@ -11,6 +11,7 @@ import (
 	"github.com/stretchr/testify/assert"
 	"net/http/httptest"
 	"testing"
+	"time"
 )
 
 func TestHandler_createItem(t *testing.T) {
@@ -35,7 +36,7 @@ func TestHandler_createItem(t *testing.T) {
 			userId: 1,
 			listId: 1,
 			mockBehavior: func(s *mock_service.MockTodoItems, userId, listId int, input todo.TodoItem) {
-				s.EXPECT().Create(userId, listId, input).Return(1, nil)
+				s.EXPECT().Create(gomock.Any(), userId, listId, input).Return(1, nil)
 			},
 			expectStatusCode:  200,
 			expectRequestBody: `{"id":1}`,
@@ -49,7 +50,7 @@ func TestHandler_createItem(t *testing.T) {
 			listId:            1,
 			mockBehavior:      func(s *mock_service.MockTodoItems, userId, listId int, input todo.TodoItem) {},
 			expectStatusCode:  500,
-			expectRequestBody: `{"message":"user id not found"}`,
+			expectRequestBody: `{"type":"about:blank","title":"Internal Server Error","status":500,"detail":"user id not found","code":"internal_error"}`,
 		},
 		{
 			name:      "No List",
@@ -60,7 +61,7 @@ func TestHandler_createItem(t *testing.T) {
 			userId:            1,
 			mockBehavior:      func(s *mock_service.MockTodoItems, userId, listId int, input todo.TodoItem) {},
 			expectStatusCode:  400,
-			expectRequestBody: `{"message":"invalid list id param"}`,
+			expectRequestBody: `{"type":"about:blank","title":"Bad Request","status":400,"detail":"invalid list id param","code":"bad_request"}`,
 		},
 		{
 			name:              "invalid input",
@@ -68,7 +69,7 @@ func TestHandler_createItem(t *testing.T) {
 			listId:            1,
 			mockBehavior:      func(s *mock_service.MockTodoItems, userId, listId int, input todo.TodoItem) {},
 			expectStatusCode:  400,
-			expectRequestBody: `{"message":"invalid input body"}`,
+			expectRequestBody: `{"type":"about:blank","title":"Bad Request","status":400,"detail":"invalid input body","code":"invalid_body"}`,
 		},
 		{
 			name:      "Error service",
@@ -79,10 +80,10 @@ func TestHandler_createItem(t *testing.T) {
 			userId: 1,
 			listId: 1,
 			mockBehavior: func(s *mock_service.MockTodoItems, userId, listId int, input todo.TodoItem) {
-				s.EXPECT().Create(userId, listId, input).Return(1, fmt.Errorf("service failure"))
+				s.EXPECT().Create(gomock.Any(), userId, listId, input).Return(1, fmt.Errorf("service failure"))
 			},
 			expectStatusCode:  500,
-			expectRequestBody: `{"message":"service failure"}`,
+			expectRequestBody: `{"type":"about:blank","title":"Internal Server Error","status":500,"code":"internal_error"}`,
 		},
 	}
 
@@ -96,7 +97,7 @@ func TestHandler_createItem(t *testing.T) {
 			testCase.mockBehavior(item, testCase.userId, testCase.listId, testCase.inputItem)
 
 			services := &service.Service{TodoItems: item}
-			handler := NewHandler(services)
+			handler := NewHandler(services, Config{})
 
 			r := gin.New()
 			r.POST("/:id/items", func(ctx *gin.Context) {
@@ -142,38 +143,38 @@ func TestHandler_getAllItems(t *testing.T) {
 			userId: 1,
 			listId: 1,
 			mockBehavior: func(s *mock_service.MockTodoItems, userId, listId int) {
-				s.EXPECT().GetAll(userId, listId).Return([]todo.TodoItem{
+				s.EXPECT().GetAll(gomock.Any(), userId, listId, todo.ItemFilter{}, todo.PageRequest{}).Return([]todo.TodoItem{
 					{
 						Title: "test",
 					},
-				}, nil)
+				}, "", nil)
 			},
 			expectStatusCode:  200,
-			expectRequestBody: `[{"id":0,"title":"test","description":"","done":false}]`,
+			expectRequestBody: `{"data":[{"id":0,"title":"test","description":"","done":false,"subtasks_done":0,"created_at":"0001-01-01T00:00:00Z","updated_at":"0001-01-01T00:00:00Z"}]}`,
 		},
 		{
 			name:              "No User",
 			listId:            1,
 			mockBehavior:      func(s *mock_service.MockTodoItems, userId, listId int) {},
 			expectStatusCode:  500,
-			expectRequestBody: `{"message":"user id not found"}`,
+			expectRequestBody: `{"type":"about:blank","title":"Internal Server Error","status":500,"detail":"user id not found","code":"internal_error"}`,
 		},
 		{
 			name:              "No List",
 			userId:            1,
 			mockBehavior:      func(s *mock_service.MockTodoItems, userId, listId int) {},
 			expectStatusCode:  400,
-			expectRequestBody: `{"message":"invalid list id param"}`,
+			expectRequestBody: `{"type":"about:blank","title":"Bad Request","status":400,"detail":"invalid list id param","code":"bad_request"}`,
 		},
 		{
 			name:   "Service error",
 			userId: 1,
 			listId: 1,
 			mockBehavior: func(s *mock_service.MockTodoItems, userId, listId int) {
-				s.EXPECT().GetAll(userId, listId).Return([]todo.TodoItem{}, fmt.Errorf("service failure"))
+				s.EXPECT().GetAll(gomock.Any(), userId, listId, todo.ItemFilter{}, todo.PageRequest{}).Return(nil, "", fmt.Errorf("service failure"))
 			},
 			expectStatusCode:  500,
-			expectRequestBody: `{"message":"service failure"}`,
+			expectRequestBody: `{"type":"about:blank","title":"Internal Server Error","status":500,"code":"internal_error"}`,
 		},
 	}
 
@@ -187,7 +188,7 @@ func TestHandler_getAllItems(t *testing.T) {
 			testCase.mockBehavior(item, testCase.userId, testCase.listId)
 
 			services := &service.Service{TodoItems: item}
-			handler := NewHandler(services)
+			handler := NewHandler(services, Config{})
 
 			r := gin.New()
 			r.GET("/:id/items", func(ctx *gin.Context) {
@@ -216,6 +217,133 @@ func TestHandler_getAllItems(t *testing.T) {
 	}
 }
 
+func TestHandler_getUserItems(t *testing.T) {
+	type mockBehavior func(s *mock_service.MockTodoItems, filter todo.ItemFilter, page todo.PageRequest)
+
+	dueBefore := time.Date(2030, 1, 2, 0, 0, 0, 0, time.UTC)
+	notDone := false
+
+	testTable := []struct {
+		name              string
+		query             string
+		filter            todo.ItemFilter
+		page              todo.PageRequest
+		mockBehavior      mockBehavior
+		expectStatusCode  int
+		expectRequestBody string
+	}{
+		{
+			name:   "Due before date",
+			query:  "?due_before=2030-01-02",
+			filter: todo.ItemFilter{DueBefore: &dueBefore},
+			mockBehavior: func(s *mock_service.MockTodoItems, filter todo.ItemFilter, page todo.PageRequest) {
+				s.EXPECT().GetAllByUser(gomock.Any(), 1, filter, page).Return([]todo.TodoItem{
+					{Id: 1, ListId: 2, Title: "test", DueAt: &dueBefore},
+				}, "", nil)
+			},
+			expectStatusCode:  200,
+			expectRequestBody: `{"data":[{"id":1,"list_id":2,"title":"test","description":"","done":false,"due_at":"2030-01-02T00:00:00Z","subtasks_done":0,"created_at":"0001-01-01T00:00:00Z","updated_at":"0001-01-01T00:00:00Z"}]}`,
+		},
+		{
+			name:   "Overdue",
+			query:  "?overdue=true",
+			filter: todo.ItemFilter{Overdue: true},
+			mockBehavior: func(s *mock_service.MockTodoItems, filter todo.ItemFilter, page todo.PageRequest) {
+				s.EXPECT().GetAllByUser(gomock.Any(), 1, filter, page).Return([]todo.TodoItem{}, "", nil)
+			},
+			expectStatusCode:  200,
+			expectRequestBody: `{"data":[]}`,
+		},
+		{
+			name:   "All labels",
+			query:  "?label=work&label=urgent&label=work&label_match=all",
+			filter: todo.ItemFilter{Labels: []string{"work", "urgent"}, AllLabels: true},
+			mockBehavior: func(s *mock_service.MockTodoItems, filter todo.ItemFilter, page todo.PageRequest) {
+				s.EXPECT().GetAllByUser(gomock.Any(), 1, filter, page).Return([]todo.TodoItem{}, "", nil)
+			},
+			expectStatusCode:  200,
+			expectRequestBody: `{"data":[]}`,
+		},
+		{
+			name:   "Page",
+			query:  "?done=false&q=milk&limit=2&sort=-priority&cursor=abc",
+			filter: todo.ItemFilter{Done: &notDone, Query: "milk"},
+			page:   todo.PageRequest{Limit: 2, Cursor: "abc", Sort: "-priority"},
+			mockBehavior: func(s *mock_service.MockTodoItems, filter todo.ItemFilter, page todo.PageRequest) {
+				s.EXPECT().GetAllByUser(gomock.Any(), 1, filter, page).Return([]todo.TodoItem{
+					{Id: 3, Title: "milk"},
+					{Id: 4, Title: "oat milk"},
+				}, "next", nil)
+			},
+			expectStatusCode: 200,
+			expectRequestBody: `{"data":[{"id":3,"title":"milk","description":"","done":false,"subtasks_done":0,"created_at":"0001-01-01T00:00:00Z","updated_at":"0001-01-01T00:00:00Z"},` +
+				`{"id":4,"title":"oat milk","description":"","done":false,"subtasks_done":0,"created_at":"0001-01-01T00:00:00Z","updated_at":"0001-01-01T00:00:00Z"}],"next_cursor":"next"}`,
+		},
+		{
+			name:              "Invalid limit",
+			query:             "?limit=500",
+			mockBehavior:      func(s *mock_service.MockTodoItems, filter todo.ItemFilter, page todo.PageRequest) {},
+			expectStatusCode:  400,
+			expectRequestBody: `{"type":"about:blank","title":"Bad Request","status":400,"detail":"invalid limit param","code":"bad_request"}`,
+		},
+		{
+			name:              "Invalid done",
+			query:             "?done=sometimes",
+			mockBehavior:      func(s *mock_service.MockTodoItems, filter todo.ItemFilter, page todo.PageRequest) {},
+			expectStatusCode:  400,
+			expectRequestBody: `{"type":"about:blank","title":"Bad Request","status":400,"detail":"invalid done param","code":"bad_request"}`,
+		},
+		{
+			name:              "Invalid label_match",
+			query:             "?label=work&label_match=some",
+			mockBehavior:      func(s *mock_service.MockTodoItems, filter todo.ItemFilter, page todo.PageRequest) {},
+			expectStatusCode:  400,
+			expectRequestBody: `{"type":"about:blank","title":"Bad Request","status":400,"detail":"invalid label_match param","code":"bad_request"}`,
+		},
+		{
+			name:              "Invalid due_before",
+			query:             "?due_before=tomorrow",
+			mockBehavior:      func(s *mock_service.MockTodoItems, filter todo.ItemFilter, page todo.PageRequest) {},
+			expectStatusCode:  400,
+			expectRequestBody: `{"type":"about:blank","title":"Bad Request","status":400,"detail":"invalid due_before param","code":"bad_request"}`,
+		},
+		{
+			name:              "Invalid overdue",
+			query:             "?overdue=maybe",
+			mockBehavior:      func(s *mock_service.MockTodoItems, filter todo.ItemFilter, page todo.PageRequest) {},
+			expectStatusCode:  400,
+			expectRequestBody: `{"type":"about:blank","title":"Bad Request","status":400,"detail":"invalid overdue param","code":"bad_request"}`,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+
+			c := gomock.NewController(t)
+			defer c.Finish()
+
+			item := mock_service.NewMockTodoItems(c)
+			testCase.mockBehavior(item, testCase.filter, testCase.page)
+
+			services := &service.Service{TodoItems: item}
+			handler := NewHandler(services, Config{})
+
+			r := gin.New()
+			r.GET("/items", func(ctx *gin.Context) {
+				ctx.Set(userCtx, 1)
+			}, handler.getUserItems)
+
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest("GET", "/items"+testCase.query, nil)
+
+			r.ServeHTTP(w, req)
+
+			assert.Equal(t, testCase.expectStatusCode, w.Code)
+			assert.Equal(t, testCase.expectRequestBody, w.Body.String())
+		})
+	}
+}
+
 func TestHandler_getItemById(t *testing.T) {
 	type mockBehavior func(s *mock_service.MockTodoItems, userId, itemId int)
 
@@ -223,45 +351,86 @@ func TestHandler_getItemById(t *testing.T) {
 		name              string
 		userId            int
 		itemId            int
+		ifNoneMatch       string
 		mockBehavior      mockBehavior
 		expectStatusCode  int
 		expectRequestBody string
+		expectETag        string
 	}{
 		{
 			name:   "OK",
 			userId: 1,
 			itemId: 1,
 			mockBehavior: func(s *mock_service.MockTodoItems, userId, itemId int) {
-				s.EXPECT().GetById(userId, itemId).Return(todo.TodoItem{
+				s.EXPECT().GetById(gomock.Any(), userId, itemId).Return(todo.TodoItem{
 					Title: "test",
 				}, nil)
 			},
 			expectStatusCode:  200,
-			expectRequestBody: `{"id":0,"title":"test","description":"","done":false}`,
+			expectRequestBody: `{"id":0,"title":"test","description":"","done":false,"subtasks_done":0,"created_at":"0001-01-01T00:00:00Z","updated_at":"0001-01-01T00:00:00Z"}`,
+			expectETag:        `"0"`,
+		},
+		{
+			name:        "Stale Copy",
+			userId:      1,
+			itemId:      1,
+			ifNoneMatch: `"2"`,
+			mockBehavior: func(s *mock_service.MockTodoItems, userId, itemId int) {
+				s.EXPECT().GetById(gomock.Any(), userId, itemId).Return(todo.TodoItem{Id: 1, Title: "test", Version: 3}, nil)
+			},
+			expectStatusCode:  200,
+			expectRequestBody: `{"id":1,"title":"test","description":"","done":false,"subtasks_done":0,"version":3,"created_at":"0001-01-01T00:00:00Z","updated_at":"0001-01-01T00:00:00Z"}`,
+			expectETag:        `"3"`,
+		},
+		{
+			name:   "Completed",
+			userId: 1,
+			itemId: 1,
+			mockBehavior: func(s *mock_service.MockTodoItems, userId, itemId int) {
+				created := time.Date(2030, 1, 1, 9, 0, 0, 0, time.UTC)
+				completed := time.Date(2030, 1, 2, 18, 30, 0, 0, time.UTC)
+				s.EXPECT().GetById(gomock.Any(), userId, itemId).Return(todo.TodoItem{Id: 1, Title: "test", Done: true, Version: 2,
+					CreatedAt: created, UpdatedAt: completed, CompletedAt: &completed}, nil)
+			},
+			expectStatusCode: 200,
+			expectRequestBody: `{"id":1,"title":"test","description":"","done":true,"subtasks_done":0,"version":2,"created_at":"2030-01-01T09:00:00Z",` +
+				`"updated_at":"2030-01-02T18:30:00Z","completed_at":"2030-01-02T18:30:00Z"}`,
+			expectETag: `"2"`,
+		},
+		{
+			name:        "Not Modified",
+			userId:      1,
+			itemId:      1,
+			ifNoneMatch: `"2", W/"3"`,
+			mockBehavior: func(s *mock_service.MockTodoItems, userId, itemId int) {
+				s.EXPECT().GetById(gomock.Any(), userId, itemId).Return(todo.TodoItem{Id: 1, Title: "test", Version: 3}, nil)
+			},
+			expectStatusCode: 304,
+			expectETag:       `"3"`,
 		},
 		{
 			name:              "No Item",
 			userId:            1,
 			mockBehavior:      func(s *mock_service.MockTodoItems, userId, itemId int) {},
 			expectStatusCode:  400,
-			expectRequestBody: `{"message":"invalid item id param"}`,
+			expectRequestBody: `{"type":"about:blank","title":"Bad Request","status":400,"detail":"invalid item id param","code":"bad_request"}`,
 		},
 		{
 			name:              "No User",
 			itemId:            1,
 			mockBehavior:      func(s *mock_service.MockTodoItems, userId, itemId int) {},
 			expectStatusCode:  500,
-			expectRequestBody: `{"message":"user id not found"}`,
+			expectRequestBody: `{"type":"about:blank","title":"Internal Server Error","status":500,"detail":"user id not found","code":"internal_error"}`,
 		},
 		{
 			name:   "Service Error",
 			userId: 1,
 			itemId: 1,
 			mockBehavior: func(s *mock_service.MockTodoItems, userId, itemId int) {
-				s.EXPECT().GetById(userId, itemId).Return(todo.TodoItem{}, fmt.Errorf("service failure"))
+				s.EXPECT().GetById(gomock.Any(), userId, itemId).Return(todo.TodoItem{}, fmt.Errorf("service failure"))
 			},
 			expectStatusCode:  500,
-			expectRequestBody: `{"message":"service failure"}`,
+			expectRequestBody: `{"type":"about:blank","title":"Internal Server Error","status":500,"code":"internal_error"}`,
 		},
 	}
 
@@ -275,7 +444,7 @@ func TestHandler_getItemById(t *testing.T) {
 			testCase.mockBehavior(item, testCase.userId, testCase.itemId)
 
 			services := &service.Service{TodoItems: item}
-			handler := NewHandler(services)
+			handler := NewHandler(services, Config{})
 
 			r := gin.New()
 			r.GET("/:id", func(ctx *gin.Context) {
@@ -295,11 +464,15 @@ func TestHandler_getItemById(t *testing.T) {
 
 			w := httptest.NewRecorder()
 			req := httptest.NewRequest("GET", a, nil)
+			if testCase.ifNoneMatch != "" {
+				req.Header.Set("If-None-Match", testCase.ifNoneMatch)
+			}
 
 			r.ServeHTTP(w, req)
 
 			assert.Equal(t, testCase.expectRequestBody, w.Body.String())
 			assert.Equal(t, testCase.expectStatusCode, w.Code)
+			assert.Equal(t, testCase.expectETag, w.Header().Get("ETag"))
 		})
 	}
 }
@@ -313,9 +486,11 @@ func TestHandler_updateItem(t *testing.T) {
 		inputItem         todo.UpdateItemInput
 		userId            int
 		itemId            int
+		ifMatch           string
 		mockBehavior      mockBehavior
 		expectStatusCode  int
 		expectRequestBody string
+		expectETag        string
 	}{
 		{
 			name:      "OK",
@@ -324,10 +499,11 @@ func TestHandler_updateItem(t *testing.T) {
 			userId:    1,
 			itemId:    1,
 			mockBehavior: func(s *mock_service.MockTodoItems, userId, itemId int, input todo.UpdateItemInput) {
-				s.EXPECT().Update(userId, itemId, input).Return(nil)
+				s.EXPECT().Update(gomock.Any(), userId, itemId, input, 0).Return("undo-token", 4, nil)
 			},
 			expectStatusCode:  200,
-			expectRequestBody: `{"status":"ok"}`,
+			expectRequestBody: `{"status":"ok","undo_token":"undo-token"}`,
+			expectETag:        `"4"`,
 		},
 		{
 			name:              "No User",
@@ -336,7 +512,7 @@ func TestHandler_updateItem(t *testing.T) {
 			itemId:            1,
 			mockBehavior:      func(s *mock_service.MockTodoItems, userId, itemId int, input todo.UpdateItemInput) {},
 			expectStatusCode:  500,
-			expectRequestBody: `{"message":"user id not found"}`,
+			expectRequestBody: `{"type":"about:blank","title":"Internal Server Error","status":500,"detail":"user id not found","code":"internal_error"}`,
 		},
 		{
 			name:              "No Item",
@@ -345,7 +521,7 @@ func TestHandler_updateItem(t *testing.T) {
 			userId:            1,
 			mockBehavior:      func(s *mock_service.MockTodoItems, userId, itemId int, input todo.UpdateItemInput) {},
 			expectStatusCode:  400,
-			expectRequestBody: `{"message":"invalid item id param"}`,
+			expectRequestBody: `{"type":"about:blank","title":"Bad Request","status":400,"detail":"invalid item id param","code":"bad_request"}`,
 		},
 		{
 			name:              "Invalid Body",
@@ -354,7 +530,7 @@ func TestHandler_updateItem(t *testing.T) {
 			itemId:            1,
 			mockBehavior:      func(s *mock_service.MockTodoItems, userId, itemId int, input todo.UpdateItemInput) {},
 			expectStatusCode:  400,
-			expectRequestBody: `{"message":"invalid input body"}`,
+			expectRequestBody: `{"type":"about:blank","title":"Bad Request","status":400,"detail":"invalid input body","code":"invalid_body"}`,
 		},
 		{
 			name:      "Service error",
@@ -363,10 +539,63 @@ func TestHandler_updateItem(t *testing.T) {
 			userId:    1,
 			itemId:    1,
 			mockBehavior: func(s *mock_service.MockTodoItems, userId, itemId int, input todo.UpdateItemInput) {
-				s.EXPECT().Update(userId, itemId, input).Return(fmt.Errorf("service failure"))
+				s.EXPECT().Update(gomock.Any(), userId, itemId, input, 0).Return("", 0, fmt.Errorf("service failure"))
 			},
 			expectStatusCode:  500,
-			expectRequestBody: `{"message":"service failure"}`,
+			expectRequestBody: `{"type":"about:blank","title":"Internal Server Error","status":500,"code":"internal_error"}`,
+		},
+		{
+			name:      "If-Match",
+			inputBody: `{}`,
+			inputItem: todo.UpdateItemInput{},
+			userId:    1,
+			itemId:    1,
+			ifMatch:   `"3"`,
+			mockBehavior: func(s *mock_service.MockTodoItems, userId, itemId int, input todo.UpdateItemInput) {
+				s.EXPECT().Update(gomock.Any(), userId, itemId, input, 3).Return("undo-token", 4, nil)
+			},
+			expectStatusCode:  200,
+			expectRequestBody: `{"status":"ok","undo_token":"undo-token"}`,
+			expectETag:        `"4"`,
+		},
+		{
+			name:      "Any Version",
+			inputBody: `{}`,
+			inputItem: todo.UpdateItemInput{},
+			userId:    1,
+			itemId:    1,
+			ifMatch:   "*",
+			mockBehavior: func(s *mock_service.MockTodoItems, userId, itemId int, input todo.UpdateItemInput) {
+				s.EXPECT().Update(gomock.Any(), userId, itemId, input, 0).Return("undo-token", 4, nil)
+			},
+			expectStatusCode:  200,
+			expectRequestBody: `{"status":"ok","undo_token":"undo-token"}`,
+			expectETag:        `"4"`,
+		},
+		{
+			name:              "Invalid If-Match",
+			inputBody:         `{}`,
+			inputItem:         todo.UpdateItemInput{},
+			userId:            1,
+			itemId:            1,
+			ifMatch:           `W/"3"`,
+			mockBehavior:      func(s *mock_service.MockTodoItems, userId, itemId int, input todo.UpdateItemInput) {},
+			expectStatusCode:  400,
+			expectRequestBody: `{"type":"about:blank","title":"Bad Request","status":400,"detail":"invalid If-Match header","code":"bad_request"}`,
+		},
+		{
+			name:      "Precondition Failed",
+			inputBody: `{}`,
+			inputItem: todo.UpdateItemInput{},
+			userId:    1,
+			itemId:    1,
+			ifMatch:   `"3"`,
+			mockBehavior: func(s *mock_service.MockTodoItems, userId, itemId int, input todo.UpdateItemInput) {
+				s.EXPECT().Update(gomock.Any(), userId, itemId, input, 3).Return("", 0, fmt.Errorf("Update service item: %w",
+					todo.NewError(todo.ErrPreconditionFailed, "item has been changed by someone else")))
+			},
+			expectStatusCode:  412,
+			expectRequestBody: `{"type":"about:blank","title":"Precondition Failed","status":412,"detail":"item has been changed by someone else","code":"precondition_failed"}`,
 		},
 	}
 
@@ -380,7 +609,7 @@ func TestHandler_updateItem(t *testing.T) {
 			testCase.mockBehavior(item, testCase.userId, testCase.itemId, testCase.inputItem)
 
 			services := &service.Service{TodoItems: item}
-			handler := NewHandler(services)
+			handler := NewHandler(services, Config{})
 
 			r := gin.New()
 			r.PUT("/:id", func(ctx *gin.Context) {
@@ -401,11 +630,15 @@ func TestHandler_updateItem(t *testing.T) {
 			w := httptest.NewRecorder()
 			req := httptest.NewRequest("PUT", a,
 				bytes.NewBufferString(testCase.inputBody))
+			if testCase.ifMatch != "" {
+				req.Header.Set("If-Match", testCase.ifMatch)
+			}
 
 			r.ServeHTTP(w, req)
 
 			assert.Equal(t, testCase.expectStatusCode, w.Code)
 			assert.Equal(t, testCase.expectRequestBody, w.Body.String())
+			assert.Equal(t, testCase.expectETag, w.Header().Get("ETag"))
 		})
 	}
 }
@@ -417,6 +650,7 @@ func TestHandler_deleteItem(t *testing.T) {
 		name              string
 		userId            int
 		itemId            int
+		ifMatch           string
 		mockBehavior      mockBehavior
 		expectStatusCode  int
 		expectRequestBody string
@@ -426,34 +660,54 @@ func TestHandler_deleteItem(t *testing.T) {
 			userId: 1,
 			itemId: 1,
 			mockBehavior: func(s *mock_service.MockTodoItems, userId, itemId int) {
-				s.EXPECT().Delete(userId, itemId).Return(nil)
+				s.EXPECT().Delete(gomock.Any(), userId, itemId, 0).Return("undo-token", nil)
 			},
 			expectStatusCode:  200,
-			expectRequestBody: `{"status":"ok"}`,
+			expectRequestBody: `{"status":"ok","undo_token":"undo-token"}`,
 		},
 		{
 			name:              "No User",
 			itemId:            1,
 			mockBehavior:      func(s *mock_service.MockTodoItems, userId, itemId int) {},
 			expectStatusCode:  500,
-			expectRequestBody: `{"message":"user id not found"}`,
+			expectRequestBody: `{"type":"about:blank","title":"Internal Server Error","status":500,"detail":"user id not found","code":"internal_error"}`,
 		},
 		{
 			name:              "No Item",
 			userId:            1,
 			mockBehavior:      func(s *mock_service.MockTodoItems, userId, itemId int) {},
 			expectStatusCode:  400,
-			expectRequestBody: `{"message":"invalid item id param"}`,
+			expectRequestBody: `{"type":"about:blank","title":"Bad Request","status":400,"detail":"invalid item id param","code":"bad_request"}`,
 		},
 		{
 			name:   "Service Error",
 			userId: 1,
 			itemId: 1,
 			mockBehavior: func(s *mock_service.MockTodoItems, userId, itemId int) {
-				s.EXPECT().Delete(userId, itemId).Return(fmt.Errorf("service failure"))
+				s.EXPECT().Delete(gomock.Any(), userId, itemId, 0).Return("", fmt.Errorf("service failure"))
 			},
 			expectStatusCode:  500,
-			expectRequestBody: `{"message":"service failure"}`,
+			expectRequestBody: `{"type":"about:blank","title":"Internal Server Error","status":500,"code":"internal_error"}`,
+		},
+		{
+			name:    "If-Match",
+			userId:  1,
+			itemId:  1,
+			ifMatch: `"3"`,
+			mockBehavior: func(s *mock_service.MockTodoItems, userId, itemId int) {
+				s.EXPECT().Delete(gomock.Any(), userId, itemId, 3).Return("undo-token", nil)
+			},
+			expectStatusCode:  200,
+			expectRequestBody: `{"status":"ok","undo_token":"undo-token"}`,
+		},
+		{
+			name:              "Invalid If-Match",
+			userId:            1,
+			itemId:            1,
+			ifMatch:           "3",
+			mockBehavior:      func(s *mock_service.MockTodoItems, userId, itemId int) {},
+			expectStatusCode:  400,
+			expectRequestBody: `{"type":"about:blank","title":"Bad Request","status":400,"detail":"invalid If-Match header","code":"bad_request"}`,
 		},
 	}
 
@@ -467,7 +721,7 @@ func TestHandler_deleteItem(t *testing.T) {
 			testCase.mockBehavior(item, testCase.userId, testCase.itemId)
 
 			services := &service.Service{TodoItems: item}
-			handler := NewHandler(services)
+			handler := NewHandler(services, Config{})
 
 			r := gin.New()
 			r.DELETE("/:id", func(ctx *gin.Context) {
@@ -487,6 +741,77 @@ func TestHandler_deleteItem(t *testing.T) {
 
 			w := httptest.NewRecorder()
 			req := httptest.NewRequest("DELETE", a, nil)
+			if testCase.ifMatch != "" {
+				req.Header.Set("If-Match", testCase.ifMatch)
+			}
+
+			r.ServeHTTP(w, req)
+
+			assert.Equal(t, testCase.expectStatusCode, w.Code)
+			assert.Equal(t, testCase.expectRequestBody, w.Body.String())
+		})
+	}
+}
+
+func TestHandler_moveItem(t *testing.T) {
+	type mockBehavior func(s *mock_service.MockTodoItems, input todo.MoveItemInput)
+
+	listId, afterId := 2, 4
+
+	testTable := []struct {
+		name              string
+		inputBody         string
+		input             todo.MoveItemInput
+		mockBehavior      mockBehavior
+		expectStatusCode  int
+		expectRequestBody string
+	}{
+		{
+			name:      "OK",
+			inputBody: `{"list_id":2,"after_id":4}`,
+			input:     todo.MoveItemInput{ListId: &listId, AfterId: &afterId},
+			mockBehavior: func(s *mock_service.MockTodoItems, input todo.MoveItemInput) {
+				s.EXPECT().Move(gomock.Any(), 1, 3, input).Return(2.5, nil)
+			},
+			expectStatusCode:  200,
+			expectRequestBody: `{"position":2.5}`,
+		},
+		{
+			name:              "Invalid Body",
+			inputBody:         `{"after_id":"first"}`,
+			mockBehavior:      func(s *mock_service.MockTodoItems, input todo.MoveItemInput) {},
+			expectStatusCode:  400,
+			expectRequestBody: `{"type":"about:blank","title":"Bad Request","status":400,"detail":"invalid input body","code":"invalid_body","errors":[{"field":"after_id","rule":"type","message":"must be a number"}]}`,
+		},
+		{
+			name:      "Service Error",
+			inputBody: `{}`,
+			mockBehavior: func(s *mock_service.MockTodoItems, input todo.MoveItemInput) {
+				s.EXPECT().Move(gomock.Any(), 1, 3, input).Return(0.0, fmt.Errorf("service failure"))
+			},
+			expectStatusCode:  500,
+			expectRequestBody: `{"type":"about:blank","title":"Internal Server Error","status":500,"code":"internal_error"}`,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			c := gomock.NewController(t)
+			defer c.Finish()
+
+			item := mock_service.NewMockTodoItems(c)
+			testCase.mockBehavior(item, testCase.input)
+
+			services := &service.Service{TodoItems: item}
+			handler := NewHandler(services, Config{})
+
+			r := gin.New()
+			r.POST("/items/:id/move", func(ctx *gin.Context) {
+				ctx.Set(userCtx, 1)
+			}, handler.moveItem)
+
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest("POST", "/items/3/move", bytes.NewBufferString(testCase.inputBody))
 
 			r.ServeHTTP(w, req)
 
@@ -0,0 +1,332 @@
+package handler
+
+import (
+	todo "do-app"
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"strconv"
+)
+
+type GetAllLabelsResponse struct {
+	Data []todo.Label `json:"data"`
+}
+
+// @Summary Create label
+// @Tags labels
+// @Security ApiKeyAuth
+// @Description create new label
+// @ID create-label
+// @Accept json
+// @Produce json
+// @Param input body todo.Label true "label name and color"
+// @Success 200 {integer} integer 1
+// @Failure 400 {object} problemResponse
+// @Failure 401 {object} problemResponse
+// @Failure 409 {object} problemResponse
+// @Failure 500 {object} problemResponse
+// @Failure default {object} problemResponse
+// @Router /api/labels [post]
+func (h *Handler) createLabel(c *gin.Context) {
+	userId, err := getUserId(c)
+	if err != nil {
+		newErrorResponse(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	var input todo.Label
+	if err = c.ShouldBindJSON(&input); err != nil {
+		newBindErrorResponse(c, err)
+		return
+	}
+
+	id, err := h.services.Labels.Create(c.Request.Context(), userId, input)
+	if err != nil {
+		newServiceErrorResponse(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, map[string]interface{}{
+		"id": id,
+	})
+}
+
+// @Summary Get all labels
+// @Tags labels
+// @Security ApiKeyAuth
+// @Description get all labels of user
+// @ID get-labels
+// @Accept json
+// @Produce json
+// @Success 200 {object} GetAllLabelsResponse
+// @Failure 401 {object} problemResponse
+// @Failure 500 {object} problemResponse
+// @Failure default {object} problemResponse
+// @Router /api/labels [get]
+func (h *Handler) getAllLabels(c *gin.Context) {
+	userId, err := getUserId(c)
+	if err != nil {
+		newErrorResponse(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	labels, err := h.services.Labels.GetAll(c.Request.Context(), userId)
+	if err != nil {
+		newServiceErrorResponse(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, GetAllLabelsResponse{
+		Data: labels,
+	})
+}
+
+// @Summary Get label by id
+// @Tags labels
+// @Security ApiKeyAuth
+// @Description get one label by id
+// @ID get-label
+// @Accept json
+// @Produce json
+// @Param id path string true "label id"
+// @Success 200 {object} todo.Label
+// @Failure 400 {object} problemResponse
+// @Failure 401 {object} problemResponse
+// @Failure 403 {object} problemResponse
+// @Failure 404 {object} problemResponse
+// @Failure 500 {object} problemResponse
+// @Failure default {object} problemResponse
+// @Router /api/labels/{id} [get]
+func (h *Handler) getLabelById(c *gin.Context) {
+	userId, err := getUserId(c)
+	if err != nil {
+		newErrorResponse(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	labelId, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		newErrorResponse(c, http.StatusBadRequest, "invalid label id param")
+		return
+	}
+
+	label, err := h.services.Labels.GetById(c.Request.Context(), userId, labelId)
+	if err != nil {
+		newServiceErrorResponse(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, label)
+}
+
+// @Summary Update label
+// @Tags labels
+// @Security ApiKeyAuth
+// @Description rename label or change its color
+// @ID update-label
+// @Accept json
+// @Produce json
+// @Param id path string true "label id"
+// @Param input body todo.UpdateLabelInput true "information for update"
+// @Success 200 {object} statusResponse
+// @Failure 400 {object} problemResponse
+// @Failure 401 {object} problemResponse
+// @Failure 403 {object} problemResponse
+// @Failure 404 {object} problemResponse
+// @Failure 409 {object} problemResponse
+// @Failure 500 {object} problemResponse
+// @Failure default {object} problemResponse
+// @Router /api/labels/{id} [put]
+func (h *Handler) updateLabel(c *gin.Context) {
+	userId, err := getUserId(c)
+	if err != nil {
+		newErrorResponse(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	labelId, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		newErrorResponse(c, http.StatusBadRequest, "invalid label id param")
+		return
+	}
+
+	var input todo.UpdateLabelInput
+	if err = c.ShouldBindJSON(&input); err != nil {
+		newBindErrorResponse(c, err)
+		return
+	}
+
+	if err = h.services.Labels.Update(c.Request.Context(), userId, labelId, input); err != nil {
+		newServiceErrorResponse(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, statusResponse{
+		Status: "ok",
+	})
+}
+
+// @Summary Delete label
+// @Tags labels
+// @Security ApiKeyAuth
+// @Description delete label and detach it from all items
+// @ID delete-label
+// @Accept json
+// @Produce json
+// @Param id path string true "label id"
+// @Success 200 {object} statusResponse
+// @Failure 400 {object} problemResponse
+// @Failure 401 {object} problemResponse
+// @Failure 403 {object} problemResponse
+// @Failure 404 {object} problemResponse
+// @Failure 500 {object} problemResponse
+// @Failure default {object} problemResponse
+// @Router /api/labels/{id} [delete]
+func (h *Handler) deleteLabel(c *gin.Context) {
+	userId, err := getUserId(c)
+	if err != nil {
+		newErrorResponse(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	labelId, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		newErrorResponse(c, http.StatusBadRequest, "invalid label id param")
+		return
+	}
+
+	if err = h.services.Labels.Delete(c.Request.Context(), userId, labelId); err != nil {
+		newServiceErrorResponse(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, statusResponse{
+		Status: "ok",
+	})
+}
+
+// @Summary Get item labels
+// @Tags labels
+// @Security ApiKeyAuth
+// @Description get labels of user attached to item
+// @ID get-item-labels
+// @Accept json
+// @Produce json
+// @Param id path string true "item id"
+// @Success 200 {object} GetAllLabelsResponse
+// @Failure 400 {object} problemResponse
+// @Failure 401 {object} problemResponse
+// @Failure 403 {object} problemResponse
+// @Failure 404 {object} problemResponse
+// @Failure 500 {object} problemResponse
+// @Failure default {object} problemResponse
+// @Router /api/items/{id}/labels [get]
+func (h *Handler) getItemLabels(c *gin.Context) {
+	userId, err := getUserId(c)
+	if err != nil {
+		newErrorResponse(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	itemId, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		newErrorResponse(c, http.StatusBadRequest, "invalid item id param")
+		return
+	}
+
+	labels, err := h.services.Labels.GetByItem(c.Request.Context(), userId, itemId)
+	if err != nil {
+		newServiceErrorResponse(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, GetAllLabelsResponse{
+		Data: labels,
+	})
+}
+
+// @Summary Attach label
+// @Tags labels
+// @Security ApiKeyAuth
+// @Description attach label to item
+// @ID attach-label
+// @Accept json
+// @Produce json
+// @Param id path string true "item id"
+// @Param labelId path string true "label id"
+// @Success 200 {object} statusResponse
+// @Failure 400 {object} problemResponse
+// @Failure 401 {object} problemResponse
+// @Failure 403 {object} problemResponse
+// @Failure 404 {object} problemResponse
+// @Failure 500 {object} problemResponse
+// @Failure default {object} problemResponse
+// @Router /api/items/{id}/labels/{labelId} [post]
+func (h *Handler) attachLabel(c *gin.Context) {
+	userId, err := getUserId(c)
+	if err != nil {
+		newErrorResponse(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	itemId, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		newErrorResponse(c, http.StatusBadRequest, "invalid item id param")
+		return
+	}
+
+	labelId, err := strconv.Atoi(c.Param("labelId"))
+	if err != nil {
+		newErrorResponse(c, http.StatusBadRequest, "invalid label id param")
+		return
+	}
+
+	if err = h.services.Labels.Attach(c.Request.Context(), userId, itemId, labelId); err != nil {
+		newServiceErrorResponse(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, statusResponse{
+		Status: "ok",
+	})
+}
+
+// @Summary Detach label
+// @Tags labels
+// @Security ApiKeyAuth
+// @Description detach label from item
+// @ID detach-label
+// @Accept json
+// @Produce json
+// @Param id path string true "item id"
+// @Param labelId path string true "label id"
+// @Success 200 {object} statusResponse
+// @Failure 400 {object} problemResponse
+// @Failure 401 {object} problemResponse
+// @Failure 403 {object} problemResponse
+// @Failure 404 {object} problemResponse
+// @Failure 500 {object} problemResponse
+// @Failure default {object} problemResponse
+// @Router /api/items/{id}/labels/{labelId} [delete]
+func (h *Handler) detachLabel(c *gin.Context) {
+	userId, err := getUserId(c)
+	if err != nil {
+		newErrorResponse(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	itemId, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		newErrorResponse(c, http.StatusBadRequest, "invalid item id param")
+		return
+	}
+
+	labelId, err := strconv.Atoi(c.Param("labelId"))
+	if err != nil {
+		newErrorResponse(c, http.StatusBadRequest, "invalid label id param")
+		return
+	}
+
+	if err = h.services.Labels.Detach(c.Request.Context(), userId, itemId, labelId); err != nil {
+		newServiceErrorResponse(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, statusResponse{
+		Status: "ok",
+	})
+}
@@ -0,0 +1,199 @@
+package handler
+
+import (
+	"bytes"
+	todo "do-app"
+	"do-app/pkg/service"
+	mock_service "do-app/pkg/service/mocks"
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestHandler_createLabel(t *testing.T) {
+	type mockBehavior func(s *mock_service.MockLabels, label todo.Label)
+
+	testTable := []struct {
+		name              string
+		inputBody         string
+		label             todo.Label
+		mockBehavior      mockBehavior
+		expectStatusCode  int
+		expectRequestBody string
+	}{
+		{
+			name:      "OK",
+			inputBody: `{"name":"work","color":"#1e88e5"}`,
+			label:     todo.Label{Name: "work", Color: "#1e88e5"},
+			mockBehavior: func(s *mock_service.MockLabels, label todo.Label) {
+				s.EXPECT().Create(gomock.Any(), 1, label).Return(3, nil)
+			},
+			expectStatusCode:  200,
+			expectRequestBody: `{"id":3}`,
+		},
+		{
+			name:              "No Name",
+			inputBody:         `{"color":"#1e88e5"}`,
+			mockBehavior:      func(s *mock_service.MockLabels, label todo.Label) {},
+			expectStatusCode:  400,
+			expectRequestBody: `{"type":"about:blank","title":"Bad Request","status":400,"detail":"invalid input body","code":"invalid_body","errors":[{"field":"name","rule":"required","message":"is required"}]}`,
+		},
+		{
+			name:      "Service Error",
+			inputBody: `{"name":"work"}`,
+			label:     todo.Label{Name: "work"},
+			mockBehavior: func(s *mock_service.MockLabels, label todo.Label) {
+				s.EXPECT().Create(gomock.Any(), 1, label).Return(0, fmt.Errorf("service failure"))
+			},
+			expectStatusCode:  500,
+			expectRequestBody: `{"type":"about:blank","title":"Internal Server Error","status":500,"code":"internal_error"}`,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			c := gomock.NewController(t)
+			defer c.Finish()
+
+			labels := mock_service.NewMockLabels(c)
+			testCase.mockBehavior(labels, testCase.label)
+
+			services := &service.Service{Labels: labels}
+			handler := NewHandler(services, Config{})
+
+			r := gin.New()
+			r.POST("/labels", func(ctx *gin.Context) {
+				ctx.Set(userCtx, 1)
+			}, handler.createLabel)
+
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest("POST", "/labels", bytes.NewBufferString(testCase.inputBody))
+
+			r.ServeHTTP(w, req)
+
+			assert.Equal(t, testCase.expectStatusCode, w.Code)
+			assert.Equal(t, testCase.expectRequestBody, w.Body.String())
+		})
+	}
+}
+
+func TestHandler_getAllLabels(t *testing.T) {
+	type mockBehavior func(s *mock_service.MockLabels)
+
+	testTable := []struct {
+		name              string
+		mockBehavior      mockBehavior
+		expectStatusCode  int
+		expectRequestBody string
+	}{
+		{
+			name: "OK",
+			mockBehavior: func(s *mock_service.MockLabels) {
+				s.EXPECT().GetAll(gomock.Any(), 1).Return([]todo.Label{{Id: 3, Name: "work", Color: "#1e88e5"}}, nil)
+			},
+			expectStatusCode:  200,
+			expectRequestBody: `{"data":[{"id":3,"name":"work","color":"#1e88e5"}]}`,
+		},
+		{
+			name: "Service Error",
+			mockBehavior: func(s *mock_service.MockLabels) {
+				s.EXPECT().GetAll(gomock.Any(), 1).Return(nil, fmt.Errorf("service failure"))
+			},
+			expectStatusCode:  500,
+			expectRequestBody: `{"type":"about:blank","title":"Internal Server Error","status":500,"code":"internal_error"}`,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			c := gomock.NewController(t)
+			defer c.Finish()
+
+			labels := mock_service.NewMockLabels(c)
+			testCase.mockBehavior(labels)
+
+			services := &service.Service{Labels: labels}
+			handler := NewHandler(services, Config{})
+
+			r := gin.New()
+			r.GET("/labels", func(ctx *gin.Context) {
+				ctx.Set(userCtx, 1)
+			}, handler.getAllLabels)
+
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest("GET", "/labels", nil)
+
+			r.ServeHTTP(w, req)
+
+			assert.Equal(t, testCase.expectStatusCode, w.Code)
+			assert.Equal(t, testCase.expectRequestBody, w.Body.String())
+		})
+	}
+}
+
+func TestHandler_attachLabel(t *testing.T) {
+	type mockBehavior func(s *mock_service.MockLabels)
+
+	testTable := []struct {
+		name              string
+		path              string
+		mockBehavior      mockBehavior
+		expectStatusCode  int
+		expectRequestBody string
+	}{
+		{
+			name: "OK",
+			path: "/items/2/labels/3",
+			mockBehavior: func(s *mock_service.MockLabels) {
+				s.EXPECT().Attach(gomock.Any(), 1, 2, 3).Return(nil)
+			},
+			expectStatusCode:  200,
+			expectRequestBody: `{"status":"ok"}`,
+		},
+		{
+			name:              "Invalid Label",
+			path:              "/items/2/labels/work",
+			mockBehavior:      func(s *mock_service.MockLabels) {},
+			expectStatusCode:  400,
+			expectRequestBody: `{"type":"about:blank","title":"Bad Request","status":400,"detail":"invalid label id param","code":"bad_request"}`,
+		},
+		{
+			name: "Service Error",
+			path: "/items/2/labels/3",
+			mockBehavior: func(s *mock_service.MockLabels) {
+				s.EXPECT().Attach(gomock.Any(), 1, 2, 3).Return(fmt.Errorf("service failure"))
+			},
+			expectStatusCode:  500,
+			expectRequestBody: `{"type":"about:blank","title":"Internal Server Error","status":500,"code":"internal_error"}`,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			c := gomock.NewController(t)
+			defer c.Finish()
+
+			labels := mock_service.NewMockLabels(c)
+			testCase.mockBehavior(labels)
+
+			services := &service.Service{Labels: labels}
+			handler := NewHandler(services, Config{})
+
+			r := gin.New()
+			r.POST("/items/:id/labels/:labelId", func(ctx *gin.Context) {
+				ctx.Set(userCtx, 1)
+			}, handler.attachLabel)
+
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest("POST", testCase.path, nil)
+
+			r.ServeHTTP(w, req)
+
+			assert.Equal(t, testCase.expectStatusCode, w.Code)
+			assert.Equal(t, testCase.expectRequestBody, w.Body.String())
+		})
+	}
+}
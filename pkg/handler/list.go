@@ -8,7 +8,8 @@ import (
 )
 
 type GetAllListResponse struct {
-	Data []todo.TodoList `json:"data"`
+	Data       []todo.TodoList `json:"data"`
+	NextCursor string          `json:"next_cursor,omitempty"`
 }
 
 // @Summary Create list
@@ -20,10 +21,10 @@ type GetAllListResponse struct {
 // @Produce json
 // @Param input body todo.TodoList true "list information"
 // @Success 200 {integer} integer 1
-// @Failure 400 {object} errorResponse
-// @Failure 401 {object} errorResponse
-// @Failure 500 {object} errorResponse
-// @Failure default {object} errorResponse
+// @Failure 400 {object} problemResponse
+// @Failure 401 {object} problemResponse
+// @Failure 500 {object} problemResponse
+// @Failure default {object} problemResponse
 // @Router /api/lists [post]
 func (h *Handler) createList(c *gin.Context) {
 	userId, err := getUserId(c)
@@ -33,14 +34,14 @@ func (h *Handler) createList(c *gin.Context) {
 	}
 
 	var input todo.TodoList
-	if err := c.BindJSON(&input); err != nil {
-		newErrorResponse(c, http.StatusBadRequest, "invalid input body")
+	if err := c.ShouldBindJSON(&input); err != nil {
+		newBindErrorResponse(c, err)
 		return
 	}
 
-	id, err := h.services.TodoLists.Create(userId, input)
+	id, err := h.services.TodoLists.Create(c.Request.Context(), userId, input)
 	if err != nil {
-		newErrorResponse(c, http.StatusInternalServerError, err.Error())
+		newServiceErrorResponse(c, err)
 		return
 	}
 
@@ -56,11 +57,15 @@ func (h *Handler) createList(c *gin.Context) {
 // @ID all-lists
 // @Accept json
 // @Produce json
-// @Success 200 {integer} integer 1
-// @Failure 400 {object} errorResponse
-// @Failure 401 {object} errorResponse
-// @Failure 500 {object} errorResponse
-// @Failure default {object} errorResponse
+// @Param q query string false "substring of the title"
+// @Param limit query int false "page size, 50 by default" minimum(1) maximum(200)
+// @Param cursor query string false "next_cursor of the previous page"
+// @Param sort query string false "sort key, prefix with - for descending" Enums(title, created, updated, -title, -created, -updated)
+// @Success 200 {object} GetAllListResponse
+// @Failure 400 {object} problemResponse
+// @Failure 401 {object} problemResponse
+// @Failure 500 {object} problemResponse
+// @Failure default {object} problemResponse
 // @Router /api/lists [get]
 func (h *Handler) getAllLists(c *gin.Context) {
 	userId, err := getUserId(c)
@@ -69,14 +74,21 @@ func (h *Handler) getAllLists(c *gin.Context) {
 		return
 	}
 
-	lists, err := h.services.TodoLists.GetAll(userId)
+	page, err := parsePageRequest(c)
 	if err != nil {
-		newErrorResponse(c, http.StatusInternalServerError, err.Error())
+		newErrorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	lists, next, err := h.services.TodoLists.GetAll(c.Request.Context(), userId, todo.ListFilter{Query: c.Query("q")}, page)
+	if err != nil {
+		newServiceErrorResponse(c, err)
 		return
 	}
 
 	c.JSON(http.StatusOK, GetAllListResponse{
-		Data: lists,
+		Data:       lists,
+		NextCursor: next,
 	})
 }
 
@@ -88,11 +100,16 @@ func (h *Handler) getAllLists(c *gin.Context) {
 // @Accept json
 // @Produce json
 // @Param id path string true "list id"
+// @Param If-None-Match header string false "ETag of a cached copy"
 // @Success 200 {integer} integer 1
-// @Failure 400 {object} errorResponse
-// @Failure 401 {object} errorResponse
-// @Failure 500 {object} errorResponse
-// @Failure default {object} errorResponse
+// @Header 200 {string} ETag "version of the list"
+// @Success 304 "not modified"
+// @Failure 400 {object} problemResponse
+// @Failure 401 {object} problemResponse
+// @Failure 403 {object} problemResponse
+// @Failure 404 {object} problemResponse
+// @Failure 500 {object} problemResponse
+// @Failure default {object} problemResponse
 // @Router /api/lists/{id} [get]
 func (h *Handler) getListById(c *gin.Context) {
 	userId, err := getUserId(c)
@@ -107,9 +124,12 @@ func (h *Handler) getListById(c *gin.Context) {
 		return
 	}
 
-	list, err := h.services.TodoLists.GetById(userId, id)
+	list, err := h.services.TodoLists.GetById(c.Request.Context(), userId, id)
 	if err != nil {
-		newErrorResponse(c, http.StatusInternalServerError, err.Error())
+		newServiceErrorResponse(c, err)
+		return
+	}
+	if notModified(c, list.Version) {
 		return
 	}
 
@@ -125,11 +145,16 @@ func (h *Handler) getListById(c *gin.Context) {
 // @Produce json
 // @Param id path string true "list id"
 // @Param input body todo.UpdateListInput false "information for update"
-// @Success 200 {integer} integer 1
-// @Failure 400 {object} errorResponse
-// @Failure 401 {object} errorResponse
-// @Failure 500 {object} errorResponse
-// @Failure default {object} errorResponse
+// @Param If-Match header string false "ETag the list is expected to have"
+// @Success 200 {object} undoableResponse
+// @Header 200 {string} ETag "new version of the list"
+// @Failure 400 {object} problemResponse
+// @Failure 401 {object} problemResponse
+// @Failure 403 {object} problemResponse
+// @Failure 404 {object} problemResponse
+// @Failure 412 {object} problemResponse
+// @Failure 500 {object} problemResponse
+// @Failure default {object} problemResponse
 // @Router /api/lists/{id} [put]
 func (h *Handler) updateList(c *gin.Context) {
 	userId, err := getUserId(c)
@@ -144,35 +169,48 @@ func (h *Handler) updateList(c *gin.Context) {
 		return
 	}
 
+	version, err := parseIfMatch(c)
+	if err != nil {
+		newErrorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
 	var input todo.UpdateListInput
-	if err = c.BindJSON(&input); err != nil {
-		newErrorResponse(c, http.StatusBadRequest, "invalid input body")
+	if err = c.ShouldBindJSON(&input); err != nil {
+		newBindErrorResponse(c, err)
 		return
 	}
 
-	if err = h.services.TodoLists.Update(userId, id, input); err != nil {
-		newErrorResponse(c, http.StatusInternalServerError, err.Error())
+	undoToken, newVersion, err := h.services.TodoLists.Update(c.Request.Context(), userId, id, input, version)
+	if err != nil {
+		newServiceErrorResponse(c, err)
 		return
 	}
 
-	c.JSON(http.StatusOK, statusResponse{
-		Status: "ok",
+	c.Header("ETag", etag(newVersion))
+	c.JSON(http.StatusOK, undoableResponse{
+		Status:    "ok",
+		UndoToken: undoToken,
 	})
 }
 
 // @Summary Delete list
 // @Tags lists
 // @Security ApiKeyAuth
-// @Description delete list by id
+// @Description move list with its items to the trash
 // @ID delete-lists
 // @Accept json
 // @Produce json
 // @Param id path string true "list id"
-// @Success 200 {integer} integer 1
-// @Failure 400 {object} errorResponse
-// @Failure 401 {object} errorResponse
-// @Failure 500 {object} errorResponse
-// @Failure default {object} errorResponse
+// @Param If-Match header string false "ETag the list is expected to have"
+// @Success 200 {object} undoableResponse
+// @Failure 400 {object} problemResponse
+// @Failure 401 {object} problemResponse
+// @Failure 403 {object} problemResponse
+// @Failure 404 {object} problemResponse
+// @Failure 412 {object} problemResponse
+// @Failure 500 {object} problemResponse
+// @Failure default {object} problemResponse
 // @Router /api/lists/{id} [delete]
 func (h *Handler) deleteList(c *gin.Context) {
 	userId, err := getUserId(c)
@@ -187,13 +225,58 @@ func (h *Handler) deleteList(c *gin.Context) {
 		return
 	}
 
-	err = h.services.TodoLists.Delete(userId, id)
+	version, err := parseIfMatch(c)
+	if err != nil {
+		newErrorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	undoToken, err := h.services.TodoLists.Delete(c.Request.Context(), userId, id, version)
+	if err != nil {
+		newServiceErrorResponse(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, undoableResponse{
+		Status:    "ok",
+		UndoToken: undoToken,
+	})
+}
+
+// @Summary Copy list
+// @Tags lists
+// @Security ApiKeyAuth
+// @Description copy the list with its items into a new list of the user
+// @ID copy-list
+// @Produce json
+// @Param id path string true "list id"
+// @Success 200 {integer} integer 1
+// @Failure 400 {object} problemResponse
+// @Failure 401 {object} problemResponse
+// @Failure 404 {object} problemResponse
+// @Failure 500 {object} problemResponse
+// @Failure default {object} problemResponse
+// @Router /api/lists/{id}/copy [post]
+func (h *Handler) copyList(c *gin.Context) {
+	userId, err := getUserId(c)
 	if err != nil {
 		newErrorResponse(c, http.StatusInternalServerError, err.Error())
 		return
 	}
 
-	c.JSON(http.StatusOK, statusResponse{
-		Status: "ok",
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		newErrorResponse(c, http.StatusBadRequest, "invalid id param")
+		return
+	}
+
+	copyId, err := h.services.TodoLists.Copy(c.Request.Context(), userId, id)
+	if err != nil {
+		newServiceErrorResponse(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, map[string]interface{}{
+		"id": copyId,
 	})
 }
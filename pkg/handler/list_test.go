@@ -10,6 +10,7 @@ import (
 	"github.com/golang/mock/gomock"
 	"github.com/stretchr/testify/assert"
 	"net/http/httptest"
+	"strconv"
 	"testing"
 )
 
@@ -34,7 +35,7 @@ func TestHandler_createList(t *testing.T) {
 			},
 			userId: 1,
 			mockBehavior: func(s *mock_service.MockTodoLists, list todo.TodoList, userId int) {
-				s.EXPECT().Create(userId, list).Return(1, nil)
+				s.EXPECT().Create(gomock.Any(), userId, list).Return(1, nil)
 			},
 			expectStatusCode:  200,
 			expectRequestBody: `{"id":1}`,
@@ -48,14 +49,14 @@ func TestHandler_createList(t *testing.T) {
 			},
 			mockBehavior:      func(s *mock_service.MockTodoLists, list todo.TodoList, userId int) {},
 			expectStatusCode:  500,
-			expectRequestBody: `{"message":"user id not found"}`,
+			expectRequestBody: `{"type":"about:blank","title":"Internal Server Error","status":500,"detail":"user id not found","code":"internal_error"}`,
 		},
 		{
 			name:              "No required pole",
 			userId:            1,
 			mockBehavior:      func(s *mock_service.MockTodoLists, list todo.TodoList, userId int) {},
 			expectStatusCode:  400,
-			expectRequestBody: `{"message":"invalid input body"}`,
+			expectRequestBody: `{"type":"about:blank","title":"Bad Request","status":400,"detail":"invalid input body","code":"invalid_body"}`,
 		},
 		{
 			name:      "Server failure",
@@ -66,10 +67,10 @@ func TestHandler_createList(t *testing.T) {
 			},
 			userId: 1,
 			mockBehavior: func(s *mock_service.MockTodoLists, list todo.TodoList, userId int) {
-				s.EXPECT().Create(userId, list).Return(1, fmt.Errorf("server failure"))
+				s.EXPECT().Create(gomock.Any(), userId, list).Return(1, fmt.Errorf("server failure"))
 			},
 			expectStatusCode:  500,
-			expectRequestBody: `{"message":"server failure"}`,
+			expectRequestBody: `{"type":"about:blank","title":"Internal Server Error","status":500,"code":"internal_error"}`,
 		},
 	}
 
@@ -83,7 +84,7 @@ func TestHandler_createList(t *testing.T) {
 			testCase.mockBehavior(list, testCase.inputList, testCase.userId)
 
 			services := &service.Service{TodoLists: list}
-			handler := NewHandler(services)
+			handler := NewHandler(services, Config{})
 
 			r := gin.New()
 			r.POST("/", func(ctx *gin.Context) {
@@ -119,31 +120,31 @@ func TestHandler_getAllLists(t *testing.T) {
 			name:   "OK",
 			userId: 1,
 			mockBehavior: func(s *mock_service.MockTodoLists, userId int) {
-				s.EXPECT().GetAll(userId).Return([]todo.TodoList{
+				s.EXPECT().GetAll(gomock.Any(), userId, todo.ListFilter{}, todo.PageRequest{}).Return([]todo.TodoList{
 					{
 						Title:       "test",
 						Description: "testdesc",
 						Id:          1,
 					},
-				}, nil)
+				}, "", nil)
 			},
 			expectStatusCode:  200,
-			expectRequestBody: `{"data":[{"id":1,"title":"test","description":"testdesc"}]}`,
+			expectRequestBody: `{"data":[{"id":1,"title":"test","description":"testdesc","created_at":"0001-01-01T00:00:00Z","updated_at":"0001-01-01T00:00:00Z"}]}`,
 		},
 		{
 			name:              "No User",
 			mockBehavior:      func(s *mock_service.MockTodoLists, userId int) {},
 			expectStatusCode:  500,
-			expectRequestBody: `{"message":"user id not found"}`,
+			expectRequestBody: `{"type":"about:blank","title":"Internal Server Error","status":500,"detail":"user id not found","code":"internal_error"}`,
 		},
 		{
 			name:   "Service failure",
 			userId: 1,
 			mockBehavior: func(s *mock_service.MockTodoLists, userId int) {
-				s.EXPECT().GetAll(userId).Return(nil, fmt.Errorf("service failure"))
+				s.EXPECT().GetAll(gomock.Any(), userId, todo.ListFilter{}, todo.PageRequest{}).Return(nil, "", fmt.Errorf("service failure"))
 			},
 			expectStatusCode:  500,
-			expectRequestBody: `{"message":"service failure"}`,
+			expectRequestBody: `{"type":"about:blank","title":"Internal Server Error","status":500,"code":"internal_error"}`,
 		},
 	}
 
@@ -157,7 +158,7 @@ func TestHandler_getAllLists(t *testing.T) {
 			testCase.mockBehavior(list, testCase.userId)
 
 			services := &service.Service{TodoLists: list}
-			handler := NewHandler(services)
+			handler := NewHandler(services, Config{})
 
 			r := gin.New()
 			r.GET("/", func(ctx *gin.Context) {
@@ -185,6 +186,7 @@ func TestHandler_getListById(t *testing.T) {
 		name              string
 		userId            int
 		listId            int
+		ifNoneMatch       string
 		mockBehavior      mockBehavior
 		expectStatusCode  int
 		expectRequestBody string
@@ -194,14 +196,24 @@ func TestHandler_getListById(t *testing.T) {
 			userId: 1,
 			listId: 1,
 			mockBehavior: func(s *mock_service.MockTodoLists, userId, listId int) {
-				s.EXPECT().GetById(userId, listId).Return(todo.TodoList{
+				s.EXPECT().GetById(gomock.Any(), userId, listId).Return(todo.TodoList{
 					Title:       "test",
 					Description: "testdesc",
 					Id:          1,
 				}, nil)
 			},
 			expectStatusCode:  200,
-			expectRequestBody: `{"id":1,"title":"test","description":"testdesc"}`,
+			expectRequestBody: `{"id":1,"title":"test","description":"testdesc","created_at":"0001-01-01T00:00:00Z","updated_at":"0001-01-01T00:00:00Z"}`,
+		},
+		{
+			name:        "Not Modified",
+			userId:      1,
+			listId:      1,
+			ifNoneMatch: `"4"`,
+			mockBehavior: func(s *mock_service.MockTodoLists, userId, listId int) {
+				s.EXPECT().GetById(gomock.Any(), userId, listId).Return(todo.TodoList{Id: 1, Title: "test", Version: 4}, nil)
+			},
+			expectStatusCode: 304,
 		},
 		{
 			name:              "No user",
@@ -209,24 +221,45 @@ func TestHandler_getListById(t *testing.T) {
 			listId:            1,
 			mockBehavior:      func(s *mock_service.MockTodoLists, userId, listId int) {},
 			expectStatusCode:  500,
-			expectRequestBody: `{"message":"user id not found"}`,
+			expectRequestBody: `{"type":"about:blank","title":"Internal Server Error","status":500,"detail":"user id not found","code":"internal_error"}`,
 		},
 		{
 			name:              "Invalid Param",
 			userId:            1,
 			mockBehavior:      func(s *mock_service.MockTodoLists, userId, listId int) {},
 			expectStatusCode:  400,
-			expectRequestBody: `{"message":"invalid id param"}`,
+			expectRequestBody: `{"type":"about:blank","title":"Bad Request","status":400,"detail":"invalid id param","code":"bad_request"}`,
 		},
 		{
 			name:   "Error Service",
 			userId: 1,
 			listId: 1,
 			mockBehavior: func(s *mock_service.MockTodoLists, userId, listId int) {
-				s.EXPECT().GetById(userId, listId).Return(todo.TodoList{}, fmt.Errorf("service failure"))
+				s.EXPECT().GetById(gomock.Any(), userId, listId).Return(todo.TodoList{}, fmt.Errorf("service failure"))
 			},
 			expectStatusCode:  500,
-			expectRequestBody: `{"message":"service failure"}`,
+			expectRequestBody: `{"type":"about:blank","title":"Internal Server Error","status":500,"code":"internal_error"}`,
+		},
+		{
+			name:   "Not Found",
+			userId: 1,
+			listId: 1,
+			mockBehavior: func(s *mock_service.MockTodoLists, userId, listId int) {
+				s.EXPECT().GetById(gomock.Any(), userId, listId).Return(todo.TodoList{},
+					fmt.Errorf("GetById list repository: %w", todo.NewError(todo.ErrNotFound, "list not found")))
+			},
+			expectStatusCode:  404,
+			expectRequestBody: `{"type":"about:blank","title":"Not Found","status":404,"detail":"list not found","code":"not_found"}`,
+		},
+		{
+			name:   "Bare Kind",
+			userId: 1,
+			listId: 1,
+			mockBehavior: func(s *mock_service.MockTodoLists, userId, listId int) {
+				s.EXPECT().GetById(gomock.Any(), userId, listId).Return(todo.TodoList{}, todo.ErrForbidden)
+			},
+			expectStatusCode:  403,
+			expectRequestBody: `{"type":"about:blank","title":"Forbidden","status":403,"detail":"forbidden","code":"forbidden"}`,
 		},
 	}
 
@@ -240,7 +273,7 @@ func TestHandler_getListById(t *testing.T) {
 			testCase.mockBehavior(list, testCase.userId, testCase.listId)
 
 			services := &service.Service{TodoLists: list}
-			handler := NewHandler(services)
+			handler := NewHandler(services, Config{})
 
 			r := gin.New()
 			r.GET("/:id", func(ctx *gin.Context) {
@@ -259,6 +292,9 @@ func TestHandler_getListById(t *testing.T) {
 
 			w := httptest.NewRecorder()
 			req := httptest.NewRequest("GET", a, nil)
+			if testCase.ifNoneMatch != "" {
+				req.Header.Set("If-None-Match", testCase.ifNoneMatch)
+			}
 
 			r.ServeHTTP(w, req)
 
@@ -277,9 +313,11 @@ func TestHandler_updateList(t *testing.T) {
 		updateList        todo.UpdateListInput
 		userId            int
 		listId            int
+		ifMatch           string
 		mockBehavior      mockBehavior
 		expectStatusCode  int
 		expectRequestBody string
+		expectETag        string
 	}{
 		{
 			name:        "OK",
@@ -288,10 +326,11 @@ func TestHandler_updateList(t *testing.T) {
 			userId:      1,
 			listId:      1,
 			mockBehavior: func(s *mock_service.MockTodoLists, userId, listId int, input todo.UpdateListInput) {
-				s.EXPECT().Update(userId, listId, input).Return(nil)
+				s.EXPECT().Update(gomock.Any(), userId, listId, input, 0).Return("undo-token", 4, nil)
 			},
 			expectStatusCode:  200,
-			expectRequestBody: `{"status":"ok"}`,
+			expectRequestBody: `{"status":"ok","undo_token":"undo-token"}`,
+			expectETag:        `"4"`,
 		},
 		{
 			name:              "No User",
@@ -300,7 +339,7 @@ func TestHandler_updateList(t *testing.T) {
 			listId:            1,
 			mockBehavior:      func(s *mock_service.MockTodoLists, userId, listId int, input todo.UpdateListInput) {},
 			expectStatusCode:  500,
-			expectRequestBody: `{"message":"user id not found"}`,
+			expectRequestBody: `{"type":"about:blank","title":"Internal Server Error","status":500,"detail":"user id not found","code":"internal_error"}`,
 		},
 		{
 			name:              "No List",
@@ -309,7 +348,7 @@ func TestHandler_updateList(t *testing.T) {
 			userId:            1,
 			mockBehavior:      func(s *mock_service.MockTodoLists, userId, listId int, input todo.UpdateListInput) {},
 			expectStatusCode:  400,
-			expectRequestBody: `{"message":"invalid id param"}`,
+			expectRequestBody: `{"type":"about:blank","title":"Bad Request","status":400,"detail":"invalid id param","code":"bad_request"}`,
 		},
 		{
 			name:        "Service Error",
@@ -318,10 +357,24 @@ func TestHandler_updateList(t *testing.T) {
 			userId:      1,
 			listId:      1,
 			mockBehavior: func(s *mock_service.MockTodoLists, userId, listId int, input todo.UpdateListInput) {
-				s.EXPECT().Update(userId, listId, input).Return(fmt.Errorf("service failure"))
+				s.EXPECT().Update(gomock.Any(), userId, listId, input, 0).Return("", 0, fmt.Errorf("service failure"))
 			},
 			expectStatusCode:  500,
-			expectRequestBody: `{"message":"service failure"}`,
+			expectRequestBody: `{"type":"about:blank","title":"Internal Server Error","status":500,"code":"internal_error"}`,
+		},
+		{
+			name:        "Precondition Failed",
+			inputUpdate: `{}`,
+			updateList:  todo.UpdateListInput{},
+			userId:      1,
+			listId:      1,
+			ifMatch:     `"4"`,
+			mockBehavior: func(s *mock_service.MockTodoLists, userId, listId int, input todo.UpdateListInput) {
+				s.EXPECT().Update(gomock.Any(), userId, listId, input, 4).Return("", 0, fmt.Errorf("Update list repository: %w",
+					todo.NewError(todo.ErrPreconditionFailed, "list has been changed by someone else")))
+			},
+			expectStatusCode:  412,
+			expectRequestBody: `{"type":"about:blank","title":"Precondition Failed","status":412,"detail":"list has been changed by someone else","code":"precondition_failed"}`,
 		},
 		{
 			name:              "Invalid Body",
@@ -329,7 +382,7 @@ func TestHandler_updateList(t *testing.T) {
 			listId:            1,
 			mockBehavior:      func(s *mock_service.MockTodoLists, userId, listId int, input todo.UpdateListInput) {},
 			expectStatusCode:  400,
-			expectRequestBody: `{"message":"invalid input body"}`,
+			expectRequestBody: `{"type":"about:blank","title":"Bad Request","status":400,"detail":"invalid input body","code":"invalid_body"}`,
 		},
 	}
 	for _, testCase := range testTable {
@@ -342,7 +395,7 @@ func TestHandler_updateList(t *testing.T) {
 			testCase.mockBehavior(list, testCase.userId, testCase.listId, testCase.updateList)
 
 			services := &service.Service{TodoLists: list}
-			handler := NewHandler(services)
+			handler := NewHandler(services, Config{})
 
 			r := gin.New()
 			r.PUT("/:id", func(ctx *gin.Context) {
@@ -363,11 +416,15 @@ func TestHandler_updateList(t *testing.T) {
 			w := httptest.NewRecorder()
 			req := httptest.NewRequest("PUT", a,
 				bytes.NewBufferString(testCase.inputUpdate))
+			if testCase.ifMatch != "" {
+				req.Header.Set("If-Match", testCase.ifMatch)
+			}
 
 			r.ServeHTTP(w, req)
 
 			assert.Equal(t, testCase.expectStatusCode, w.Code)
 			assert.Equal(t, testCase.expectRequestBody, w.Body.String())
+			assert.Equal(t, testCase.expectETag, w.Header().Get("ETag"))
 		})
 	}
 }
@@ -388,34 +445,34 @@ func TestHandler_deleteList(t *testing.T) {
 			userId: 1,
 			listId: 1,
 			mockBehavior: func(s *mock_service.MockTodoLists, userId, listId int) {
-				s.EXPECT().Delete(userId, listId).Return(nil)
+				s.EXPECT().Delete(gomock.Any(), userId, listId, 0).Return("undo-token", nil)
 			},
 			expectStatusCode:  200,
-			expectRequestBody: `{"status":"ok"}`,
+			expectRequestBody: `{"status":"ok","undo_token":"undo-token"}`,
 		},
 		{
 			name:              "No User",
 			listId:            1,
 			mockBehavior:      func(s *mock_service.MockTodoLists, userId, listId int) {},
 			expectStatusCode:  500,
-			expectRequestBody: `{"message":"user id not found"}`,
+			expectRequestBody: `{"type":"about:blank","title":"Internal Server Error","status":500,"detail":"user id not found","code":"internal_error"}`,
 		},
 		{
 			name:              "No List",
 			userId:            1,
 			mockBehavior:      func(s *mock_service.MockTodoLists, userId, listId int) {},
 			expectStatusCode:  400,
-			expectRequestBody: `{"message":"invalid id param"}`,
+			expectRequestBody: `{"type":"about:blank","title":"Bad Request","status":400,"detail":"invalid id param","code":"bad_request"}`,
 		},
 		{
 			name:   "Service Error",
 			userId: 1,
 			listId: 1,
 			mockBehavior: func(s *mock_service.MockTodoLists, userId, listId int) {
-				s.EXPECT().Delete(userId, listId).Return(fmt.Errorf("service failure"))
+				s.EXPECT().Delete(gomock.Any(), userId, listId, 0).Return("", fmt.Errorf("service failure"))
 			},
 			expectStatusCode:  500,
-			expectRequestBody: `{"message":"service failure"}`,
+			expectRequestBody: `{"type":"about:blank","title":"Internal Server Error","status":500,"code":"internal_error"}`,
 		},
 	}
 
@@ -429,7 +486,7 @@ func TestHandler_deleteList(t *testing.T) {
 			testCase.mockBehavior(list, testCase.userId, testCase.listId)
 
 			services := &service.Service{TodoLists: list}
-			handler := NewHandler(services)
+			handler := NewHandler(services, Config{})
 
 			r := gin.New()
 			r.DELETE("/:id", func(ctx *gin.Context) {
@@ -457,3 +514,72 @@ func TestHandler_deleteList(t *testing.T) {
 		})
 	}
 }
+
+func TestHandler_copyList(t *testing.T) {
+	type mockBehavior func(s *mock_service.MockTodoLists, userId, listId int)
+
+	testTable := []struct {
+		name              string
+		userId            int
+		listId            string
+		mockBehavior      mockBehavior
+		expectStatusCode  int
+		expectRequestBody string
+	}{
+		{
+			name:   "OK",
+			userId: 1,
+			listId: "2",
+			mockBehavior: func(s *mock_service.MockTodoLists, userId, listId int) {
+				s.EXPECT().Copy(gomock.Any(), userId, listId).Return(3, nil)
+			},
+			expectStatusCode:  200,
+			expectRequestBody: `{"id":3}`,
+		},
+		{
+			name:              "Invalid id",
+			userId:            1,
+			listId:            "id",
+			mockBehavior:      func(s *mock_service.MockTodoLists, userId, listId int) {},
+			expectStatusCode:  400,
+			expectRequestBody: `{"type":"about:blank","title":"Bad Request","status":400,"detail":"invalid id param","code":"bad_request"}`,
+		},
+		{
+			name:   "List not found",
+			userId: 1,
+			listId: "2",
+			mockBehavior: func(s *mock_service.MockTodoLists, userId, listId int) {
+				s.EXPECT().Copy(gomock.Any(), userId, listId).Return(0, todo.NewError(todo.ErrNotFound, "list not found"))
+			},
+			expectStatusCode:  404,
+			expectRequestBody: `{"type":"about:blank","title":"Not Found","status":404,"detail":"list not found","code":"not_found"}`,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			c := gomock.NewController(t)
+			defer c.Finish()
+
+			list := mock_service.NewMockTodoLists(c)
+			listId, _ := strconv.Atoi(testCase.listId)
+			testCase.mockBehavior(list, testCase.userId, listId)
+
+			services := &service.Service{TodoLists: list}
+			handler := NewHandler(services, Config{})
+
+			r := gin.New()
+			r.POST("/:id/copy", func(ctx *gin.Context) {
+				ctx.Set(userCtx, testCase.userId)
+			}, handler.copyList)
+
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest("POST", "/"+testCase.listId+"/copy", nil)
+
+			r.ServeHTTP(w, req)
+
+			assert.Equal(t, testCase.expectStatusCode, w.Code)
+			assert.Equal(t, testCase.expectRequestBody, w.Body.String())
+		})
+	}
+}
@@ -0,0 +1,201 @@
+package handler
+
+import (
+	todo "do-app"
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"strconv"
+)
+
+type GetAllMembersResponse struct {
+	Data []todo.ListMember `json:"data"`
+}
+
+// @Summary Get list members
+// @Tags members
+// @Security ApiKeyAuth
+// @Description get all members of list
+// @ID get-members
+// @Accept json
+// @Produce json
+// @Param id path string true "list id"
+// @Success 200 {object} GetAllMembersResponse
+// @Failure 400 {object} problemResponse
+// @Failure 401 {object} problemResponse
+// @Failure 403 {object} problemResponse
+// @Failure 404 {object} problemResponse
+// @Failure 500 {object} problemResponse
+// @Failure default {object} problemResponse
+// @Router /api/lists/{id}/members [get]
+func (h *Handler) getAllMembers(c *gin.Context) {
+	userId, err := getUserId(c)
+	if err != nil {
+		newErrorResponse(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	listId, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		newErrorResponse(c, http.StatusBadRequest, "invalid list id param")
+		return
+	}
+
+	members, err := h.services.ListMembers.GetAll(c.Request.Context(), userId, listId)
+	if err != nil {
+		newServiceErrorResponse(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, GetAllMembersResponse{
+		Data: members,
+	})
+}
+
+// @Summary Add list member
+// @Tags members
+// @Security ApiKeyAuth
+// @Description share list with another user
+// @ID add-member
+// @Accept json
+// @Produce json
+// @Param id path string true "list id"
+// @Param input body todo.AddMemberInput true "member username and role"
+// @Success 200 {integer} integer 1
+// @Failure 400 {object} problemResponse
+// @Failure 401 {object} problemResponse
+// @Failure 403 {object} problemResponse
+// @Failure 404 {object} problemResponse
+// @Failure 409 {object} problemResponse
+// @Failure 500 {object} problemResponse
+// @Failure default {object} problemResponse
+// @Router /api/lists/{id}/members [post]
+func (h *Handler) addMember(c *gin.Context) {
+	userId, err := getUserId(c)
+	if err != nil {
+		newErrorResponse(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	listId, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		newErrorResponse(c, http.StatusBadRequest, "invalid list id param")
+		return
+	}
+
+	var input todo.AddMemberInput
+	if err = c.ShouldBindJSON(&input); err != nil {
+		newBindErrorResponse(c, err)
+		return
+	}
+
+	memberId, err := h.services.ListMembers.Add(c.Request.Context(), userId, listId, input)
+	if err != nil {
+		newServiceErrorResponse(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, map[string]interface{}{
+		"user_id": memberId,
+	})
+}
+
+// @Summary Update list member
+// @Tags members
+// @Security ApiKeyAuth
+// @Description change role of list member
+// @ID update-member
+// @Accept json
+// @Produce json
+// @Param id path string true "list id"
+// @Param userId path string true "member user id"
+// @Param input body todo.UpdateMemberInput true "new role"
+// @Success 200 {object} statusResponse
+// @Failure 400 {object} problemResponse
+// @Failure 401 {object} problemResponse
+// @Failure 403 {object} problemResponse
+// @Failure 404 {object} problemResponse
+// @Failure 409 {object} problemResponse
+// @Failure 500 {object} problemResponse
+// @Failure default {object} problemResponse
+// @Router /api/lists/{id}/members/{userId} [put]
+func (h *Handler) updateMember(c *gin.Context) {
+	userId, err := getUserId(c)
+	if err != nil {
+		newErrorResponse(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	listId, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		newErrorResponse(c, http.StatusBadRequest, "invalid list id param")
+		return
+	}
+
+	memberId, err := strconv.Atoi(c.Param("userId"))
+	if err != nil {
+		newErrorResponse(c, http.StatusBadRequest, "invalid user id param")
+		return
+	}
+
+	var input todo.UpdateMemberInput
+	if err = c.ShouldBindJSON(&input); err != nil {
+		newBindErrorResponse(c, err)
+		return
+	}
+
+	if err = h.services.ListMembers.UpdateRole(c.Request.Context(), userId, listId, memberId, input); err != nil {
+		newServiceErrorResponse(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, statusResponse{
+		Status: "ok",
+	})
+}
+
+// @Summary Remove list member
+// @Tags members
+// @Security ApiKeyAuth
+// @Description remove member from list
+// @ID delete-member
+// @Accept json
+// @Produce json
+// @Param id path string true "list id"
+// @Param userId path string true "member user id"
+// @Success 200 {object} statusResponse
+// @Failure 400 {object} problemResponse
+// @Failure 401 {object} problemResponse
+// @Failure 403 {object} problemResponse
+// @Failure 404 {object} problemResponse
+// @Failure 409 {object} problemResponse
+// @Failure 500 {object} problemResponse
+// @Failure default {object} problemResponse
+// @Router /api/lists/{id}/members/{userId} [delete]
+func (h *Handler) deleteMember(c *gin.Context) {
+	userId, err := getUserId(c)
+	if err != nil {
+		newErrorResponse(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	listId, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		newErrorResponse(c, http.StatusBadRequest, "invalid list id param")
+		return
+	}
+
+	memberId, err := strconv.Atoi(c.Param("userId"))
+	if err != nil {
+		newErrorResponse(c, http.StatusBadRequest, "invalid user id param")
+		return
+	}
+
+	if err = h.services.ListMembers.Remove(c.Request.Context(), userId, listId, memberId); err != nil {
+		newServiceErrorResponse(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, statusResponse{
+		Status: "ok",
+	})
+}
@@ -0,0 +1,224 @@
+package handler
+
+import (
+	"bytes"
+	todo "do-app"
+	"do-app/pkg/service"
+	mock_service "do-app/pkg/service/mocks"
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestHandler_getAllMembers(t *testing.T) {
+	type mockBehavior func(s *mock_service.MockListMembers, userId, listId int)
+
+	testTable := []struct {
+		name              string
+		userId            int
+		listId            int
+		mockBehavior      mockBehavior
+		expectStatusCode  int
+		expectRequestBody string
+	}{
+		{
+			name:   "OK",
+			userId: 1,
+			listId: 1,
+			mockBehavior: func(s *mock_service.MockListMembers, userId, listId int) {
+				s.EXPECT().GetAll(gomock.Any(), userId, listId).Return([]todo.ListMember{
+					{UserId: 1, Name: "Test", Username: "test", Role: todo.RoleOwner},
+				}, nil)
+			},
+			expectStatusCode:  200,
+			expectRequestBody: `{"data":[{"user_id":1,"name":"Test","username":"test","role":"owner"}]}`,
+		},
+		{
+			name:              "No List",
+			userId:            1,
+			mockBehavior:      func(s *mock_service.MockListMembers, userId, listId int) {},
+			expectStatusCode:  400,
+			expectRequestBody: `{"type":"about:blank","title":"Bad Request","status":400,"detail":"invalid list id param","code":"bad_request"}`,
+		},
+		{
+			name:   "Service Error",
+			userId: 1,
+			listId: 1,
+			mockBehavior: func(s *mock_service.MockListMembers, userId, listId int) {
+				s.EXPECT().GetAll(gomock.Any(), userId, listId).Return(nil, fmt.Errorf("service failure"))
+			},
+			expectStatusCode:  500,
+			expectRequestBody: `{"type":"about:blank","title":"Internal Server Error","status":500,"code":"internal_error"}`,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			c := gomock.NewController(t)
+			defer c.Finish()
+
+			members := mock_service.NewMockListMembers(c)
+			testCase.mockBehavior(members, testCase.userId, testCase.listId)
+
+			services := &service.Service{ListMembers: members}
+			handler := NewHandler(services, Config{})
+
+			r := gin.New()
+			r.GET("/:id/members", func(ctx *gin.Context) {
+				ctx.Set(userCtx, testCase.userId)
+			}, handler.getAllMembers)
+
+			var a string
+			switch testCase.listId {
+			case 0:
+				a = "/id/members"
+			default:
+				a = fmt.Sprintf("/%d/members", testCase.listId)
+			}
+
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest("GET", a, nil)
+
+			r.ServeHTTP(w, req)
+
+			assert.Equal(t, testCase.expectStatusCode, w.Code)
+			assert.Equal(t, testCase.expectRequestBody, w.Body.String())
+		})
+	}
+}
+
+func TestHandler_addMember(t *testing.T) {
+	type mockBehavior func(s *mock_service.MockListMembers, userId, listId int, input todo.AddMemberInput)
+
+	testTable := []struct {
+		name              string
+		inputBody         string
+		input             todo.AddMemberInput
+		mockBehavior      mockBehavior
+		expectStatusCode  int
+		expectRequestBody string
+	}{
+		{
+			name:      "OK",
+			inputBody: `{"username":"friend","role":"editor"}`,
+			input:     todo.AddMemberInput{Username: "friend", Role: todo.RoleEditor},
+			mockBehavior: func(s *mock_service.MockListMembers, userId, listId int, input todo.AddMemberInput) {
+				s.EXPECT().Add(gomock.Any(), userId, listId, input).Return(2, nil)
+			},
+			expectStatusCode:  200,
+			expectRequestBody: `{"user_id":2}`,
+		},
+		{
+			name:              "No Role",
+			inputBody:         `{"username":"friend"}`,
+			mockBehavior:      func(s *mock_service.MockListMembers, userId, listId int, input todo.AddMemberInput) {},
+			expectStatusCode:  400,
+			expectRequestBody: `{"type":"about:blank","title":"Bad Request","status":400,"detail":"invalid input body","code":"invalid_body","errors":[{"field":"role","rule":"required","message":"is required"}]}`,
+		},
+		{
+			name:      "Not Owner",
+			inputBody: `{"username":"friend","role":"viewer"}`,
+			input:     todo.AddMemberInput{Username: "friend", Role: todo.RoleViewer},
+			mockBehavior: func(s *mock_service.MockListMembers, userId, listId int, input todo.AddMemberInput) {
+				s.EXPECT().Add(gomock.Any(), userId, listId, input).Return(0, fmt.Errorf("Add member service: %w",
+					todo.NewError(todo.ErrForbidden, "only list owners can manage members")))
+			},
+			expectStatusCode:  403,
+			expectRequestBody: `{"type":"about:blank","title":"Forbidden","status":403,"detail":"only list owners can manage members","code":"forbidden"}`,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			c := gomock.NewController(t)
+			defer c.Finish()
+
+			members := mock_service.NewMockListMembers(c)
+			testCase.mockBehavior(members, 1, 1, testCase.input)
+
+			services := &service.Service{ListMembers: members}
+			handler := NewHandler(services, Config{})
+
+			r := gin.New()
+			r.POST("/:id/members", func(ctx *gin.Context) {
+				ctx.Set(userCtx, 1)
+			}, handler.addMember)
+
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest("POST", "/1/members",
+				bytes.NewBufferString(testCase.inputBody))
+
+			r.ServeHTTP(w, req)
+
+			assert.Equal(t, testCase.expectStatusCode, w.Code)
+			assert.Equal(t, testCase.expectRequestBody, w.Body.String())
+		})
+	}
+}
+
+func TestHandler_deleteMember(t *testing.T) {
+	type mockBehavior func(s *mock_service.MockListMembers, userId, listId, memberId int)
+
+	testTable := []struct {
+		name              string
+		path              string
+		mockBehavior      mockBehavior
+		expectStatusCode  int
+		expectRequestBody string
+	}{
+		{
+			name: "OK",
+			path: "/1/members/2",
+			mockBehavior: func(s *mock_service.MockListMembers, userId, listId, memberId int) {
+				s.EXPECT().Remove(gomock.Any(), userId, listId, memberId).Return(nil)
+			},
+			expectStatusCode:  200,
+			expectRequestBody: `{"status":"ok"}`,
+		},
+		{
+			name:              "Invalid member",
+			path:              "/1/members/user",
+			mockBehavior:      func(s *mock_service.MockListMembers, userId, listId, memberId int) {},
+			expectStatusCode:  400,
+			expectRequestBody: `{"type":"about:blank","title":"Bad Request","status":400,"detail":"invalid user id param","code":"bad_request"}`,
+		},
+		{
+			name: "Last Owner",
+			path: "/1/members/2",
+			mockBehavior: func(s *mock_service.MockListMembers, userId, listId, memberId int) {
+				s.EXPECT().Remove(gomock.Any(), userId, listId, memberId).Return(todo.NewError(todo.ErrConflict, "list must keep at least one owner"))
+			},
+			expectStatusCode:  409,
+			expectRequestBody: `{"type":"about:blank","title":"Conflict","status":409,"detail":"list must keep at least one owner","code":"conflict"}`,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			c := gomock.NewController(t)
+			defer c.Finish()
+
+			members := mock_service.NewMockListMembers(c)
+			testCase.mockBehavior(members, 1, 1, 2)
+
+			services := &service.Service{ListMembers: members}
+			handler := NewHandler(services, Config{})
+
+			r := gin.New()
+			r.DELETE("/:id/members/:userId", func(ctx *gin.Context) {
+				ctx.Set(userCtx, 1)
+			}, handler.deleteMember)
+
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest("DELETE", testCase.path, nil)
+
+			r.ServeHTTP(w, req)
+
+			assert.Equal(t, testCase.expectStatusCode, w.Code)
+			assert.Equal(t, testCase.expectRequestBody, w.Body.String())
+		})
+	}
+}
@@ -1,6 +1,9 @@
 package handler
 
 import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
 	"fmt"
 	"github.com/gin-gonic/gin"
 	"net/http"
@@ -9,9 +12,38 @@ import (
 
 const (
 	authorizationHeader = "Authorization"
+	requestIdHeader     = "X-Request-Id"
 	userCtx             = "userId"
+	requestIdCtx        = "requestId"
 )
 
+// requestId tags the request with the id sent by the client, or a new random
+// one, and echoes it in the response so problems can be traced in the logs.
+func (h *Handler) requestId(c *gin.Context) {
+	id := c.GetHeader(requestIdHeader)
+	if id == "" || len(id) > 128 {
+		buf := make([]byte, 16)
+		if _, err := rand.Read(buf); err == nil {
+			id = hex.EncodeToString(buf)
+		}
+	}
+	c.Set(requestIdCtx, id)
+	c.Header(requestIdHeader, id)
+}
+
+// queryDeadline puts the configured query timeout on the request context, so
+// that the queries of a request are canceled once it expires, as they are
+// when the client disconnects.
+func (h *Handler) queryDeadline(c *gin.Context) {
+	if h.cfg.QueryTimeout <= 0 {
+		return
+	}
+	ctx, cancel := context.WithTimeout(c.Request.Context(), h.cfg.QueryTimeout)
+	defer cancel()
+	c.Request = c.Request.WithContext(ctx)
+	c.Next()
+}
+
 func (h *Handler) userIdentity(c *gin.Context) {
 	header := c.GetHeader(authorizationHeader)
 	if header == "" {
@@ -25,7 +57,7 @@ func (h *Handler) userIdentity(c *gin.Context) {
 		return
 	}
 
-	userId, err := h.services.Authorization.ParseToken(headerParts[1])
+	userId, err := h.services.Authorization.ParseToken(c.Request.Context(), headerParts[1])
 	if err != nil {
 		newErrorResponse(c, http.StatusUnauthorized, "invalid parse token")
 		return
@@ -10,6 +10,7 @@ import (
 	"github.com/stretchr/testify/assert"
 	"net/http/httptest"
 	"testing"
+	"time"
 )
 
 func TestHandler_userIdentity(t *testing.T) {
@@ -30,7 +31,7 @@ func TestHandler_userIdentity(t *testing.T) {
 			headerValue: "Bearer token",
 			token:       "token",
 			mockBehavior: func(s *mock_service.MockAuthorization, token string) {
-				s.EXPECT().ParseToken(token).Return(1, nil)
+				s.EXPECT().ParseToken(gomock.Any(), token).Return(1, nil)
 			},
 			expectStatusCode:    200,
 			expectResponsesBody: "1",
@@ -42,7 +43,7 @@ func TestHandler_userIdentity(t *testing.T) {
 			token:               "token",
 			mockBehavior:        func(s *mock_service.MockAuthorization, token string) {},
 			expectStatusCode:    401,
-			expectResponsesBody: `{"message":"empty auth header"}`,
+			expectResponsesBody: `{"type":"about:blank","title":"Unauthorized","status":401,"detail":"empty auth header","code":"unauthorized"}`,
 		},
 		{
 			name:                "Invalid header",
@@ -51,7 +52,7 @@ func TestHandler_userIdentity(t *testing.T) {
 			token:               "token",
 			mockBehavior:        func(s *mock_service.MockAuthorization, token string) {},
 			expectStatusCode:    401,
-			expectResponsesBody: `{"message":"invalid auth header"}`,
+			expectResponsesBody: `{"type":"about:blank","title":"Unauthorized","status":401,"detail":"invalid auth header","code":"unauthorized"}`,
 		},
 		{
 			name:        "Service failrule",
@@ -59,10 +60,10 @@ func TestHandler_userIdentity(t *testing.T) {
 			headerValue: "Bearer token",
 			token:       "token",
 			mockBehavior: func(s *mock_service.MockAuthorization, token string) {
-				s.EXPECT().ParseToken(token).Return(1, errors.New("invalid parse token"))
+				s.EXPECT().ParseToken(gomock.Any(), token).Return(1, errors.New("invalid parse token"))
 			},
 			expectStatusCode:    401,
-			expectResponsesBody: `{"message":"invalid parse token"}`,
+			expectResponsesBody: `{"type":"about:blank","title":"Unauthorized","status":401,"detail":"invalid parse token","code":"unauthorized"}`,
 		},
 	}
 
@@ -75,7 +76,7 @@ func TestHandler_userIdentity(t *testing.T) {
 			testCase.mockBehavior(auth, testCase.token)
 
 			services := &service.Service{Authorization: auth}
-			handler := NewHandler(services)
+			handler := NewHandler(services, Config{})
 
 			r := gin.New()
 			r.GET("/protected", handler.userIdentity, func(ctx *gin.Context) {
@@ -94,3 +95,37 @@ func TestHandler_userIdentity(t *testing.T) {
 		})
 	}
 }
+
+func TestHandler_queryDeadline(t *testing.T) {
+	testTable := []struct {
+		name           string
+		timeout        time.Duration
+		expectDeadline bool
+	}{
+		{
+			name:           "Timeout",
+			timeout:        time.Second,
+			expectDeadline: true,
+		},
+		{
+			name: "No timeout",
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			handler := NewHandler(&service.Service{}, Config{QueryTimeout: testCase.timeout})
+
+			var hasDeadline bool
+			r := gin.New()
+			r.GET("/", handler.queryDeadline, func(c *gin.Context) {
+				_, hasDeadline = c.Request.Context().Deadline()
+			})
+
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, httptest.NewRequest("GET", "/", nil))
+
+			assert.Equal(t, testCase.expectDeadline, hasDeadline)
+		})
+	}
+}
@@ -0,0 +1,25 @@
+package handler
+
+import (
+	todo "do-app"
+	"errors"
+	"github.com/gin-gonic/gin"
+	"strconv"
+)
+
+// parsePageRequest reads limit, cursor and sort from the query string. The
+// sort key itself is checked by the service, which knows the allowed keys.
+func parsePageRequest(c *gin.Context) (todo.PageRequest, error) {
+	page := todo.PageRequest{
+		Cursor: c.Query("cursor"),
+		Sort:   c.Query("sort"),
+	}
+	if value := c.Query("limit"); value != "" {
+		limit, err := strconv.Atoi(value)
+		if err != nil || limit < 1 || limit > todo.MaxPageLimit {
+			return page, errors.New("invalid limit param")
+		}
+		page.Limit = limit
+	}
+	return page, nil
+}
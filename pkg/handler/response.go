@@ -1,19 +1,216 @@
 package handler
 
 import (
+	"context"
+	todo "do-app"
+	"encoding/json"
+	"errors"
+	"fmt"
 	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/go-playground/validator/v10"
 	"github.com/sirupsen/logrus"
+	"net/http"
+	"reflect"
+	"strings"
+	"sync"
 )
 
+const problemContentType = "application/problem+json"
+
 type statusResponse struct {
 	Status string `json:"status"`
 }
 
-type errorResponse struct {
+// problemResponse is an RFC 7807 problem details object. Code is a stable,
+// machine-readable name of the problem; Errors lists the invalid fields of a
+// request body.
+type problemResponse struct {
+	Type      string       `json:"type"`
+	Title     string       `json:"title"`
+	Status    int          `json:"status"`
+	Detail    string       `json:"detail,omitempty"`
+	Code      string       `json:"code"`
+	RequestId string       `json:"request_id,omitempty"`
+	Errors    []fieldError `json:"errors,omitempty"`
+}
+
+type fieldError struct {
+	Field   string `json:"field"`
+	Rule    string `json:"rule"`
 	Message string `json:"message"`
 }
 
+// Problem codes. Statuses without a domain meaning get the code of their
+// status from statusCodes.
+const (
+	codeBadRequest       = "bad_request"
+	codeUnauthorized     = "unauthorized"
+	codeForbidden        = "forbidden"
+	codeNotFound         = "not_found"
+	codeConflict         = "conflict"
+	codePrecondition     = "precondition_failed"
+	codeValidationFailed = "validation_failed"
+	codeInvalidBody      = "invalid_body"
+	codeInternal         = "internal_error"
+	codeTimeout          = "timeout"
+)
+
+var statusCodes = map[int]string{
+	http.StatusBadRequest:          codeBadRequest,
+	http.StatusUnauthorized:        codeUnauthorized,
+	http.StatusForbidden:           codeForbidden,
+	http.StatusNotFound:            codeNotFound,
+	http.StatusConflict:            codeConflict,
+	http.StatusPreconditionFailed:  codePrecondition,
+	http.StatusInternalServerError: codeInternal,
+}
+
 func newErrorResponse(c *gin.Context, statusCode int, message string) {
 	logrus.Error(message)
-	c.AbortWithStatusJSON(statusCode, errorResponse{message})
+	code, ok := statusCodes[statusCode]
+	if !ok {
+		code = strings.ToLower(strings.ReplaceAll(http.StatusText(statusCode), " ", "_"))
+	}
+	abortWithProblem(c, newProblem(c, statusCode, code, message))
+}
+
+func newProblem(c *gin.Context, statusCode int, code, detail string) problemResponse {
+	return problemResponse{
+		Type:      "about:blank",
+		Title:     http.StatusText(statusCode),
+		Status:    statusCode,
+		Detail:    detail,
+		Code:      code,
+		RequestId: c.GetString(requestIdCtx),
+	}
+}
+
+func abortWithProblem(c *gin.Context, problem problemResponse) {
+	c.Header("Content-Type", problemContentType)
+	c.AbortWithStatusJSON(problem.Status, problem)
+}
+
+// errorKinds maps the kinds of domain errors, and the expired query deadline
+// of a request, to HTTP statuses and codes.
+var errorKinds = map[error]struct {
+	status int
+	code   string
+}{
+	todo.ErrNotFound:           {http.StatusNotFound, codeNotFound},
+	todo.ErrForbidden:          {http.StatusForbidden, codeForbidden},
+	todo.ErrConflict:           {http.StatusConflict, codeConflict},
+	todo.ErrValidation:         {http.StatusBadRequest, codeValidationFailed},
+	todo.ErrUnauthorized:       {http.StatusUnauthorized, codeUnauthorized},
+	todo.ErrPreconditionFailed: {http.StatusPreconditionFailed, codePrecondition},
+	context.DeadlineExceeded:   {http.StatusServiceUnavailable, codeTimeout},
+}
+
+// newServiceErrorResponse answers with the status of the domain error in err.
+// Any other error is unexpected; it is logged, but the client only gets a
+// generic problem so that no SQL or other internals leak.
+func newServiceErrorResponse(c *gin.Context, err error) {
+	for kind, mapping := range errorKinds {
+		if !errors.Is(err, kind) {
+			continue
+		}
+		detail := kind.Error()
+		var domainErr *todo.Error
+		if errors.As(err, &domainErr) {
+			detail = domainErr.Message
+		}
+		logrus.Error(err.Error())
+		abortWithProblem(c, newProblem(c, mapping.status, mapping.code, detail))
+		return
+	}
+	logrus.Error(err.Error())
+	abortWithProblem(c, newProblem(c, http.StatusInternalServerError, codeInternal, ""))
+}
+
+// newBindErrorResponse describes why a request body could not be bound, with
+// one entry per invalid field where the cause is known.
+func newBindErrorResponse(c *gin.Context, err error) {
+	logrus.Error(err.Error())
+	problem := newProblem(c, http.StatusBadRequest, codeInvalidBody, "invalid input body")
+
+	var validationErrs validator.ValidationErrors
+	var typeErr *json.UnmarshalTypeError
+	switch {
+	case errors.As(err, &validationErrs):
+		for _, fe := range validationErrs {
+			problem.Errors = append(problem.Errors, fieldError{
+				Field:   fieldPath(fe),
+				Rule:    fe.Tag(),
+				Message: ruleMessage(fe),
+			})
+		}
+	case errors.As(err, &typeErr):
+		problem.Errors = append(problem.Errors, fieldError{
+			Field:   typeErr.Field,
+			Rule:    "type",
+			Message: fmt.Sprintf("must be %s", jsonType(typeErr.Type)),
+		})
+	}
+	abortWithProblem(c, problem)
+}
+
+var useJSONFieldNames sync.Once
+
+// jsonFieldNames makes the validator report fields by their JSON names, so
+// that field errors match the request body.
+func jsonFieldNames() {
+	useJSONFieldNames.Do(func() {
+		v, ok := binding.Validator.Engine().(*validator.Validate)
+		if !ok {
+			return
+		}
+		v.RegisterTagNameFunc(func(field reflect.StructField) string {
+			name := strings.SplitN(field.Tag.Get("json"), ",", 2)[0]
+			if name == "-" {
+				return ""
+			}
+			return name
+		})
+	})
+}
+
+// fieldPath drops the name of the bound struct from the field namespace.
+func fieldPath(fe validator.FieldError) string {
+	namespace := fe.Namespace()
+	if i := strings.Index(namespace, "."); i >= 0 {
+		return namespace[i+1:]
+	}
+	return namespace
+}
+
+func ruleMessage(fe validator.FieldError) string {
+	switch fe.Tag() {
+	case "required":
+		return "is required"
+	case "min", "gte":
+		return fmt.Sprintf("must be at least %s", fe.Param())
+	case "max", "lte":
+		return fmt.Sprintf("must be at most %s", fe.Param())
+	case "oneof":
+		return fmt.Sprintf("must be one of %s", fe.Param())
+	default:
+		return fmt.Sprintf("failed the %s rule", fe.Tag())
+	}
+}
+
+func jsonType(t reflect.Type) string {
+	switch t.Kind() {
+	case reflect.Bool:
+		return "a boolean"
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return "a number"
+	case reflect.String:
+		return "a string"
+	case reflect.Slice, reflect.Array:
+		return "an array"
+	default:
+		return "an object"
+	}
 }
@@ -0,0 +1,105 @@
+package handler
+
+import (
+	"bytes"
+	"context"
+	todo "do-app"
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestHandler_problemResponse(t *testing.T) {
+	jsonFieldNames()
+
+	testTable := []struct {
+		name              string
+		requestId         string
+		body              string
+		handle            func(c *gin.Context)
+		expectStatusCode  int
+		expectRequestBody string
+	}{
+		{
+			name:      "Validation error",
+			requestId: "req-1",
+			handle: func(c *gin.Context) {
+				newServiceErrorResponse(c, fmt.Errorf("Update service item: %w",
+					todo.NewError(todo.ErrValidation, "update structure has no values")))
+			},
+			expectStatusCode: 400,
+			expectRequestBody: `{"type":"about:blank","title":"Bad Request","status":400,` +
+				`"detail":"update structure has no values","code":"validation_failed","request_id":"req-1"}`,
+		},
+		{
+			name:      "Internal error",
+			requestId: "req-2",
+			handle: func(c *gin.Context) {
+				newServiceErrorResponse(c, fmt.Errorf("GetById list repository: pq: relation does not exist"))
+			},
+			expectStatusCode: 500,
+			expectRequestBody: `{"type":"about:blank","title":"Internal Server Error","status":500,` +
+				`"code":"internal_error","request_id":"req-2"}`,
+		},
+		{
+			name:      "Query timeout",
+			requestId: "req-5",
+			handle: func(c *gin.Context) {
+				newServiceErrorResponse(c, fmt.Errorf("GetAll list repository: %w", context.DeadlineExceeded))
+			},
+			expectStatusCode: 503,
+			expectRequestBody: `{"type":"about:blank","title":"Service Unavailable","status":503,` +
+				`"detail":"context deadline exceeded","code":"timeout","request_id":"req-5"}`,
+		},
+		{
+			name:      "Field errors",
+			requestId: "req-3",
+			body:      `{"title":"","done":"yes"}`,
+			handle: func(c *gin.Context) {
+				var input todo.TodoItem
+				if err := c.ShouldBindJSON(&input); err != nil {
+					newBindErrorResponse(c, err)
+				}
+			},
+			expectStatusCode: 400,
+			expectRequestBody: `{"type":"about:blank","title":"Bad Request","status":400,"detail":"invalid input body",` +
+				`"code":"invalid_body","request_id":"req-3","errors":[{"field":"done","rule":"type","message":"must be a boolean"}]}`,
+		},
+		{
+			name:      "Missing field",
+			requestId: "req-4",
+			body:      `{"description":"no title"}`,
+			handle: func(c *gin.Context) {
+				var input todo.TodoItem
+				if err := c.ShouldBindJSON(&input); err != nil {
+					newBindErrorResponse(c, err)
+				}
+			},
+			expectStatusCode: 400,
+			expectRequestBody: `{"type":"about:blank","title":"Bad Request","status":400,"detail":"invalid input body",` +
+				`"code":"invalid_body","request_id":"req-4","errors":[{"field":"title","rule":"required","message":"is required"}]}`,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			handler := &Handler{}
+
+			r := gin.New()
+			r.POST("/", handler.requestId, testCase.handle)
+
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest("POST", "/", bytes.NewBufferString(testCase.body))
+			req.Header.Set("X-Request-Id", testCase.requestId)
+
+			r.ServeHTTP(w, req)
+
+			assert.Equal(t, testCase.expectStatusCode, w.Code)
+			assert.Equal(t, testCase.expectRequestBody, w.Body.String())
+			assert.Equal(t, "application/problem+json", w.Header().Get("Content-Type"))
+			assert.Equal(t, testCase.requestId, w.Header().Get("X-Request-Id"))
+		})
+	}
+}
@@ -0,0 +1,69 @@
+package handler
+
+import (
+	todo "do-app"
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+type searchResponse struct {
+	Data []todo.SearchResult `json:"data"`
+}
+
+// @Summary Search
+// @Tags search
+// @Security ApiKeyAuth
+// @Description full-text search over titles and descriptions of all lists and items of user
+// @ID search
+// @Accept json
+// @Produce json
+// @Param q query string true "search query, supports quoted phrases, or and -word"
+// @Param type query string false "only lists or only items" Enums(list, item)
+// @Param list_id query int false "only the list and its items"
+// @Param limit query int false "number of results, 20 by default" minimum(1) maximum(100)
+// @Success 200 {object} searchResponse
+// @Failure 400 {object} problemResponse
+// @Failure 401 {object} problemResponse
+// @Failure 500 {object} problemResponse
+// @Failure default {object} problemResponse
+// @Router /api/search [get]
+func (h *Handler) search(c *gin.Context) {
+	userId, err := getUserId(c)
+	if err != nil {
+		newErrorResponse(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	query := todo.SearchQuery{
+		Query: strings.TrimSpace(c.Query("q")),
+		Type:  c.Query("type"),
+	}
+	if query.Query == "" {
+		newErrorResponse(c, http.StatusBadRequest, "invalid q param")
+		return
+	}
+	if value := c.Query("list_id"); value != "" {
+		if query.ListId, err = strconv.Atoi(value); err != nil {
+			newErrorResponse(c, http.StatusBadRequest, "invalid list_id param")
+			return
+		}
+	}
+	if value := c.Query("limit"); value != "" {
+		if query.Limit, err = strconv.Atoi(value); err != nil || query.Limit < 1 {
+			newErrorResponse(c, http.StatusBadRequest, "invalid limit param")
+			return
+		}
+	}
+
+	results, err := h.services.Search.Search(c.Request.Context(), userId, query)
+	if err != nil {
+		newServiceErrorResponse(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, searchResponse{
+		Data: results,
+	})
+}
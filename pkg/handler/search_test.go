@@ -0,0 +1,95 @@
+package handler
+
+import (
+	todo "do-app"
+	"do-app/pkg/service"
+	mock_service "do-app/pkg/service/mocks"
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestHandler_search(t *testing.T) {
+	type mockBehavior func(s *mock_service.MockSearch)
+
+	testTable := []struct {
+		name              string
+		query             string
+		mockBehavior      mockBehavior
+		expectStatusCode  int
+		expectRequestBody string
+	}{
+		{
+			name:  "OK",
+			query: "?q=milk&type=item&list_id=2&limit=5",
+			mockBehavior: func(s *mock_service.MockSearch) {
+				s.EXPECT().Search(gomock.Any(), 1, todo.SearchQuery{Query: "milk", Type: "item", ListId: 2, Limit: 5}).
+					Return([]todo.SearchResult{
+						{Type: "item", Id: 4, ListId: 2, Title: "buy milk", Snippet: "buy <b>milk</b>", Rank: 0.6},
+					}, nil)
+			},
+			expectStatusCode: 200,
+			expectRequestBody: `{"data":[{"type":"item","id":4,"list_id":2,"title":"buy milk",` +
+				`"snippet":"buy \u003cb\u003emilk\u003c/b\u003e","rank":0.6}]}`,
+		},
+		{
+			name:              "Empty query",
+			query:             "?q=%20",
+			mockBehavior:      func(s *mock_service.MockSearch) {},
+			expectStatusCode:  400,
+			expectRequestBody: `{"type":"about:blank","title":"Bad Request","status":400,"detail":"invalid q param","code":"bad_request"}`,
+		},
+		{
+			name:              "Invalid list_id",
+			query:             "?q=milk&list_id=groceries",
+			mockBehavior:      func(s *mock_service.MockSearch) {},
+			expectStatusCode:  400,
+			expectRequestBody: `{"type":"about:blank","title":"Bad Request","status":400,"detail":"invalid list_id param","code":"bad_request"}`,
+		},
+		{
+			name:              "Invalid limit",
+			query:             "?q=milk&limit=0",
+			mockBehavior:      func(s *mock_service.MockSearch) {},
+			expectStatusCode:  400,
+			expectRequestBody: `{"type":"about:blank","title":"Bad Request","status":400,"detail":"invalid limit param","code":"bad_request"}`,
+		},
+		{
+			name:  "Service Error",
+			query: "?q=milk",
+			mockBehavior: func(s *mock_service.MockSearch) {
+				s.EXPECT().Search(gomock.Any(), 1, todo.SearchQuery{Query: "milk"}).Return(nil, fmt.Errorf("service failure"))
+			},
+			expectStatusCode:  500,
+			expectRequestBody: `{"type":"about:blank","title":"Internal Server Error","status":500,"code":"internal_error"}`,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			c := gomock.NewController(t)
+			defer c.Finish()
+
+			search := mock_service.NewMockSearch(c)
+			testCase.mockBehavior(search)
+
+			services := &service.Service{Search: search}
+			handler := NewHandler(services, Config{})
+
+			r := gin.New()
+			r.GET("/search", func(ctx *gin.Context) {
+				ctx.Set(userCtx, 1)
+			}, handler.search)
+
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest("GET", "/search"+testCase.query, nil)
+
+			r.ServeHTTP(w, req)
+
+			assert.Equal(t, testCase.expectStatusCode, w.Code)
+			assert.Equal(t, testCase.expectRequestBody, w.Body.String())
+		})
+	}
+}
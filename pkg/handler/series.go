@@ -0,0 +1,130 @@
+package handler
+
+import (
+	todo "do-app"
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"strconv"
+)
+
+// @Summary Get item series
+// @Tags items
+// @Security ApiKeyAuth
+// @Description get recurrence series of item
+// @ID get-item-series
+// @Accept json
+// @Produce json
+// @Param id path string true "item id"
+// @Success 200 {object} todo.ItemSeries
+// @Failure 400 {object} problemResponse
+// @Failure 401 {object} problemResponse
+// @Failure 403 {object} problemResponse
+// @Failure 404 {object} problemResponse
+// @Failure 500 {object} problemResponse
+// @Failure default {object} problemResponse
+// @Router /api/items/{id}/series [get]
+func (h *Handler) getItemSeries(c *gin.Context) {
+	userId, err := getUserId(c)
+	if err != nil {
+		newErrorResponse(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	itemId, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		newErrorResponse(c, http.StatusBadRequest, "invalid item id param")
+		return
+	}
+
+	series, err := h.services.TodoItems.GetSeries(c.Request.Context(), userId, itemId)
+	if err != nil {
+		newServiceErrorResponse(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, series)
+}
+
+// @Summary Update item series
+// @Tags items
+// @Security ApiKeyAuth
+// @Description change rule of series and open occurrences
+// @ID update-item-series
+// @Accept json
+// @Produce json
+// @Param id path string true "item id"
+// @Param input body todo.UpdateSeriesInput true "information for update"
+// @Success 200 {object} statusResponse
+// @Failure 400 {object} problemResponse
+// @Failure 401 {object} problemResponse
+// @Failure 403 {object} problemResponse
+// @Failure 404 {object} problemResponse
+// @Failure 409 {object} problemResponse
+// @Failure 500 {object} problemResponse
+// @Failure default {object} problemResponse
+// @Router /api/items/{id}/series [put]
+func (h *Handler) updateItemSeries(c *gin.Context) {
+	userId, err := getUserId(c)
+	if err != nil {
+		newErrorResponse(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	itemId, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		newErrorResponse(c, http.StatusBadRequest, "invalid item id param")
+		return
+	}
+
+	var input todo.UpdateSeriesInput
+	if err = c.ShouldBindJSON(&input); err != nil {
+		newBindErrorResponse(c, err)
+		return
+	}
+
+	if err = h.services.TodoItems.UpdateSeries(c.Request.Context(), userId, itemId, input); err != nil {
+		newServiceErrorResponse(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, statusResponse{
+		Status: "ok",
+	})
+}
+
+// @Summary Stop item series
+// @Tags items
+// @Security ApiKeyAuth
+// @Description stop creating further occurrences of item
+// @ID stop-item-series
+// @Accept json
+// @Produce json
+// @Param id path string true "item id"
+// @Success 200 {object} statusResponse
+// @Failure 400 {object} problemResponse
+// @Failure 401 {object} problemResponse
+// @Failure 403 {object} problemResponse
+// @Failure 404 {object} problemResponse
+// @Failure 409 {object} problemResponse
+// @Failure 500 {object} problemResponse
+// @Failure default {object} problemResponse
+// @Router /api/items/{id}/series [delete]
+func (h *Handler) stopItemSeries(c *gin.Context) {
+	userId, err := getUserId(c)
+	if err != nil {
+		newErrorResponse(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	itemId, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		newErrorResponse(c, http.StatusBadRequest, "invalid item id param")
+		return
+	}
+
+	if err = h.services.TodoItems.StopSeries(c.Request.Context(), userId, itemId); err != nil {
+		newServiceErrorResponse(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, statusResponse{
+		Status: "ok",
+	})
+}
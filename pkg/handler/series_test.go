@@ -0,0 +1,199 @@
+package handler
+
+import (
+	"bytes"
+	todo "do-app"
+	"do-app/pkg/service"
+	mock_service "do-app/pkg/service/mocks"
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestHandler_getItemSeries(t *testing.T) {
+	type mockBehavior func(s *mock_service.MockTodoItems, userId, itemId int)
+
+	dtstart := time.Date(2030, 1, 7, 9, 0, 0, 0, time.UTC)
+
+	testTable := []struct {
+		name              string
+		mockBehavior      mockBehavior
+		expectStatusCode  int
+		expectRequestBody string
+	}{
+		{
+			name: "OK",
+			mockBehavior: func(s *mock_service.MockTodoItems, userId, itemId int) {
+				s.EXPECT().GetSeries(gomock.Any(), userId, itemId).Return(todo.ItemSeries{
+					Id:      3,
+					RRule:   "FREQ=WEEKLY;BYDAY=MO",
+					Dtstart: dtstart,
+				}, nil)
+			},
+			expectStatusCode:  200,
+			expectRequestBody: `{"id":3,"rrule":"FREQ=WEEKLY;BYDAY=MO","dtstart":"2030-01-07T09:00:00Z"}`,
+		},
+		{
+			name: "Service Error",
+			mockBehavior: func(s *mock_service.MockTodoItems, userId, itemId int) {
+				s.EXPECT().GetSeries(gomock.Any(), userId, itemId).Return(todo.ItemSeries{}, fmt.Errorf("service failure"))
+			},
+			expectStatusCode:  500,
+			expectRequestBody: `{"type":"about:blank","title":"Internal Server Error","status":500,"code":"internal_error"}`,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			c := gomock.NewController(t)
+			defer c.Finish()
+
+			items := mock_service.NewMockTodoItems(c)
+			testCase.mockBehavior(items, 1, 2)
+
+			services := &service.Service{TodoItems: items}
+			handler := NewHandler(services, Config{})
+
+			r := gin.New()
+			r.GET("/items/:id/series", func(ctx *gin.Context) {
+				ctx.Set(userCtx, 1)
+			}, handler.getItemSeries)
+
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest("GET", "/items/2/series", nil)
+
+			r.ServeHTTP(w, req)
+
+			assert.Equal(t, testCase.expectStatusCode, w.Code)
+			assert.Equal(t, testCase.expectRequestBody, w.Body.String())
+		})
+	}
+}
+
+func TestHandler_updateItemSeries(t *testing.T) {
+	type mockBehavior func(s *mock_service.MockTodoItems, userId, itemId int, input todo.UpdateSeriesInput)
+
+	rule := "FREQ=MONTHLY;BYMONTHDAY=1"
+
+	testTable := []struct {
+		name              string
+		inputBody         string
+		input             todo.UpdateSeriesInput
+		mockBehavior      mockBehavior
+		expectStatusCode  int
+		expectRequestBody string
+	}{
+		{
+			name:      "OK",
+			inputBody: `{"rrule":"FREQ=MONTHLY;BYMONTHDAY=1"}`,
+			input:     todo.UpdateSeriesInput{RRule: &rule},
+			mockBehavior: func(s *mock_service.MockTodoItems, userId, itemId int, input todo.UpdateSeriesInput) {
+				s.EXPECT().UpdateSeries(gomock.Any(), userId, itemId, input).Return(nil)
+			},
+			expectStatusCode:  200,
+			expectRequestBody: `{"status":"ok"}`,
+		},
+		{
+			name:              "Invalid Body",
+			inputBody:         `{"rrule":`,
+			mockBehavior:      func(s *mock_service.MockTodoItems, userId, itemId int, input todo.UpdateSeriesInput) {},
+			expectStatusCode:  400,
+			expectRequestBody: `{"type":"about:blank","title":"Bad Request","status":400,"detail":"invalid input body","code":"invalid_body"}`,
+		},
+		{
+			name:      "Service Error",
+			inputBody: `{"rrule":"FREQ=MONTHLY;BYMONTHDAY=1"}`,
+			input:     todo.UpdateSeriesInput{RRule: &rule},
+			mockBehavior: func(s *mock_service.MockTodoItems, userId, itemId int, input todo.UpdateSeriesInput) {
+				s.EXPECT().UpdateSeries(gomock.Any(), userId, itemId, input).Return(fmt.Errorf("service failure"))
+			},
+			expectStatusCode:  500,
+			expectRequestBody: `{"type":"about:blank","title":"Internal Server Error","status":500,"code":"internal_error"}`,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			c := gomock.NewController(t)
+			defer c.Finish()
+
+			items := mock_service.NewMockTodoItems(c)
+			testCase.mockBehavior(items, 1, 2, testCase.input)
+
+			services := &service.Service{TodoItems: items}
+			handler := NewHandler(services, Config{})
+
+			r := gin.New()
+			r.PUT("/items/:id/series", func(ctx *gin.Context) {
+				ctx.Set(userCtx, 1)
+			}, handler.updateItemSeries)
+
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest("PUT", "/items/2/series",
+				bytes.NewBufferString(testCase.inputBody))
+
+			r.ServeHTTP(w, req)
+
+			assert.Equal(t, testCase.expectStatusCode, w.Code)
+			assert.Equal(t, testCase.expectRequestBody, w.Body.String())
+		})
+	}
+}
+
+func TestHandler_stopItemSeries(t *testing.T) {
+	type mockBehavior func(s *mock_service.MockTodoItems, userId, itemId int)
+
+	testTable := []struct {
+		name              string
+		mockBehavior      mockBehavior
+		expectStatusCode  int
+		expectRequestBody string
+	}{
+		{
+			name: "OK",
+			mockBehavior: func(s *mock_service.MockTodoItems, userId, itemId int) {
+				s.EXPECT().StopSeries(gomock.Any(), userId, itemId).Return(nil)
+			},
+			expectStatusCode:  200,
+			expectRequestBody: `{"status":"ok"}`,
+		},
+		{
+			name: "Service Error",
+			mockBehavior: func(s *mock_service.MockTodoItems, userId, itemId int) {
+				s.EXPECT().StopSeries(gomock.Any(), userId, itemId).Return(fmt.Errorf("service failure"))
+			},
+			expectStatusCode:  500,
+			expectRequestBody: `{"type":"about:blank","title":"Internal Server Error","status":500,"code":"internal_error"}`,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			c := gomock.NewController(t)
+			defer c.Finish()
+
+			items := mock_service.NewMockTodoItems(c)
+			testCase.mockBehavior(items, 1, 2)
+
+			services := &service.Service{TodoItems: items}
+			handler := NewHandler(services, Config{})
+
+			r := gin.New()
+			r.DELETE("/items/:id/series", func(ctx *gin.Context) {
+				ctx.Set(userCtx, 1)
+			}, handler.stopItemSeries)
+
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest("DELETE", "/items/2/series", nil)
+
+			r.ServeHTTP(w, req)
+
+			assert.Equal(t, testCase.expectStatusCode, w.Code)
+			assert.Equal(t, testCase.expectRequestBody, w.Body.String())
+		})
+	}
+}
@@ -0,0 +1,196 @@
+package handler
+
+import (
+	todo "do-app"
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"strconv"
+)
+
+type GetAllSubtasksResponse struct {
+	Data []todo.Subtask `json:"data"`
+}
+
+// @Summary Create subtask
+// @Tags subtasks
+// @Security ApiKeyAuth
+// @Description add checklist entry to item
+// @ID create-subtask
+// @Accept json
+// @Produce json
+// @Param id path string true "item id"
+// @Param input body todo.Subtask true "subtask information"
+// @Success 200 {integer} integer 1
+// @Failure 400 {object} problemResponse
+// @Failure 401 {object} problemResponse
+// @Failure 403 {object} problemResponse
+// @Failure 404 {object} problemResponse
+// @Failure 500 {object} problemResponse
+// @Failure default {object} problemResponse
+// @Router /api/items/{id}/subtasks [post]
+func (h *Handler) createSubtask(c *gin.Context) {
+	userId, err := getUserId(c)
+	if err != nil {
+		newErrorResponse(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	itemId, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		newErrorResponse(c, http.StatusBadRequest, "invalid item id param")
+		return
+	}
+
+	var input todo.Subtask
+	if err = c.ShouldBindJSON(&input); err != nil {
+		newBindErrorResponse(c, err)
+		return
+	}
+
+	id, err := h.services.Subtasks.Create(c.Request.Context(), userId, itemId, input)
+	if err != nil {
+		newServiceErrorResponse(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, map[string]interface{}{
+		"id": id,
+	})
+}
+
+// @Summary Get subtasks
+// @Tags subtasks
+// @Security ApiKeyAuth
+// @Description get checklist of item
+// @ID get-subtasks
+// @Accept json
+// @Produce json
+// @Param id path string true "item id"
+// @Success 200 {object} GetAllSubtasksResponse
+// @Failure 400 {object} problemResponse
+// @Failure 401 {object} problemResponse
+// @Failure 403 {object} problemResponse
+// @Failure 404 {object} problemResponse
+// @Failure 500 {object} problemResponse
+// @Failure default {object} problemResponse
+// @Router /api/items/{id}/subtasks [get]
+func (h *Handler) getAllSubtasks(c *gin.Context) {
+	userId, err := getUserId(c)
+	if err != nil {
+		newErrorResponse(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	itemId, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		newErrorResponse(c, http.StatusBadRequest, "invalid item id param")
+		return
+	}
+
+	subtasks, err := h.services.Subtasks.GetAll(c.Request.Context(), userId, itemId)
+	if err != nil {
+		newServiceErrorResponse(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, GetAllSubtasksResponse{
+		Data: subtasks,
+	})
+}
+
+// @Summary Update subtask
+// @Tags subtasks
+// @Security ApiKeyAuth
+// @Description rename or check off subtask
+// @ID update-subtask
+// @Accept json
+// @Produce json
+// @Param id path string true "item id"
+// @Param subtaskId path string true "subtask id"
+// @Param input body todo.UpdateSubtaskInput true "information for update"
+// @Success 200 {object} statusResponse
+// @Failure 400 {object} problemResponse
+// @Failure 401 {object} problemResponse
+// @Failure 403 {object} problemResponse
+// @Failure 404 {object} problemResponse
+// @Failure 500 {object} problemResponse
+// @Failure default {object} problemResponse
+// @Router /api/items/{id}/subtasks/{subtaskId} [put]
+func (h *Handler) updateSubtask(c *gin.Context) {
+	userId, err := getUserId(c)
+	if err != nil {
+		newErrorResponse(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	itemId, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		newErrorResponse(c, http.StatusBadRequest, "invalid item id param")
+		return
+	}
+
+	subtaskId, err := strconv.Atoi(c.Param("subtaskId"))
+	if err != nil {
+		newErrorResponse(c, http.StatusBadRequest, "invalid subtask id param")
+		return
+	}
+
+	var input todo.UpdateSubtaskInput
+	if err = c.ShouldBindJSON(&input); err != nil {
+		newBindErrorResponse(c, err)
+		return
+	}
+
+	if err = h.services.Subtasks.Update(c.Request.Context(), userId, itemId, subtaskId, input); err != nil {
+		newServiceErrorResponse(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, statusResponse{
+		Status: "ok",
+	})
+}
+
+// @Summary Delete subtask
+// @Tags subtasks
+// @Security ApiKeyAuth
+// @Description remove checklist entry from item
+// @ID delete-subtask
+// @Accept json
+// @Produce json
+// @Param id path string true "item id"
+// @Param subtaskId path string true "subtask id"
+// @Success 200 {object} statusResponse
+// @Failure 400 {object} problemResponse
+// @Failure 401 {object} problemResponse
+// @Failure 403 {object} problemResponse
+// @Failure 404 {object} problemResponse
+// @Failure 500 {object} problemResponse
+// @Failure default {object} problemResponse
+// @Router /api/items/{id}/subtasks/{subtaskId} [delete]
+func (h *Handler) deleteSubtask(c *gin.Context) {
+	userId, err := getUserId(c)
+	if err != nil {
+		newErrorResponse(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	itemId, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		newErrorResponse(c, http.StatusBadRequest, "invalid item id param")
+		return
+	}
+
+	subtaskId, err := strconv.Atoi(c.Param("subtaskId"))
+	if err != nil {
+		newErrorResponse(c, http.StatusBadRequest, "invalid subtask id param")
+		return
+	}
+
+	if err = h.services.Subtasks.Delete(c.Request.Context(), userId, itemId, subtaskId); err != nil {
+		newServiceErrorResponse(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, statusResponse{
+		Status: "ok",
+	})
+}
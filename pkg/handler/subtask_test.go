@@ -0,0 +1,154 @@
+package handler
+
+import (
+	"bytes"
+	todo "do-app"
+	"do-app/pkg/service"
+	mock_service "do-app/pkg/service/mocks"
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestHandler_createSubtask(t *testing.T) {
+	type mockBehavior func(s *mock_service.MockSubtasks, subtask todo.Subtask)
+
+	testTable := []struct {
+		name              string
+		inputBody         string
+		subtask           todo.Subtask
+		mockBehavior      mockBehavior
+		expectStatusCode  int
+		expectRequestBody string
+	}{
+		{
+			name:      "OK",
+			inputBody: `{"title":"collect receipts"}`,
+			subtask:   todo.Subtask{Title: "collect receipts"},
+			mockBehavior: func(s *mock_service.MockSubtasks, subtask todo.Subtask) {
+				s.EXPECT().Create(gomock.Any(), 1, 2, subtask).Return(4, nil)
+			},
+			expectStatusCode:  200,
+			expectRequestBody: `{"id":4}`,
+		},
+		{
+			name:              "No Title",
+			inputBody:         `{"done":true}`,
+			mockBehavior:      func(s *mock_service.MockSubtasks, subtask todo.Subtask) {},
+			expectStatusCode:  400,
+			expectRequestBody: `{"type":"about:blank","title":"Bad Request","status":400,"detail":"invalid input body","code":"invalid_body","errors":[{"field":"title","rule":"required","message":"is required"}]}`,
+		},
+		{
+			name:      "Service Error",
+			inputBody: `{"title":"collect receipts"}`,
+			subtask:   todo.Subtask{Title: "collect receipts"},
+			mockBehavior: func(s *mock_service.MockSubtasks, subtask todo.Subtask) {
+				s.EXPECT().Create(gomock.Any(), 1, 2, subtask).Return(0, fmt.Errorf("service failure"))
+			},
+			expectStatusCode:  500,
+			expectRequestBody: `{"type":"about:blank","title":"Internal Server Error","status":500,"code":"internal_error"}`,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			c := gomock.NewController(t)
+			defer c.Finish()
+
+			subtasks := mock_service.NewMockSubtasks(c)
+			testCase.mockBehavior(subtasks, testCase.subtask)
+
+			services := &service.Service{Subtasks: subtasks}
+			handler := NewHandler(services, Config{})
+
+			r := gin.New()
+			r.POST("/items/:id/subtasks", func(ctx *gin.Context) {
+				ctx.Set(userCtx, 1)
+			}, handler.createSubtask)
+
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest("POST", "/items/2/subtasks", bytes.NewBufferString(testCase.inputBody))
+
+			r.ServeHTTP(w, req)
+
+			assert.Equal(t, testCase.expectStatusCode, w.Code)
+			assert.Equal(t, testCase.expectRequestBody, w.Body.String())
+		})
+	}
+}
+
+func TestHandler_updateSubtask(t *testing.T) {
+	type mockBehavior func(s *mock_service.MockSubtasks, input todo.UpdateSubtaskInput)
+
+	done := true
+
+	testTable := []struct {
+		name              string
+		path              string
+		inputBody         string
+		input             todo.UpdateSubtaskInput
+		mockBehavior      mockBehavior
+		expectStatusCode  int
+		expectRequestBody string
+	}{
+		{
+			name:      "OK",
+			path:      "/items/2/subtasks/4",
+			inputBody: `{"done":true}`,
+			input:     todo.UpdateSubtaskInput{Done: &done},
+			mockBehavior: func(s *mock_service.MockSubtasks, input todo.UpdateSubtaskInput) {
+				s.EXPECT().Update(gomock.Any(), 1, 2, 4, input).Return(nil)
+			},
+			expectStatusCode:  200,
+			expectRequestBody: `{"status":"ok"}`,
+		},
+		{
+			name:              "Invalid Subtask",
+			path:              "/items/2/subtasks/first",
+			inputBody:         `{"done":true}`,
+			mockBehavior:      func(s *mock_service.MockSubtasks, input todo.UpdateSubtaskInput) {},
+			expectStatusCode:  400,
+			expectRequestBody: `{"type":"about:blank","title":"Bad Request","status":400,"detail":"invalid subtask id param","code":"bad_request"}`,
+		},
+		{
+			name:      "Service Error",
+			path:      "/items/2/subtasks/4",
+			inputBody: `{"done":true}`,
+			input:     todo.UpdateSubtaskInput{Done: &done},
+			mockBehavior: func(s *mock_service.MockSubtasks, input todo.UpdateSubtaskInput) {
+				s.EXPECT().Update(gomock.Any(), 1, 2, 4, input).Return(fmt.Errorf("service failure"))
+			},
+			expectStatusCode:  500,
+			expectRequestBody: `{"type":"about:blank","title":"Internal Server Error","status":500,"code":"internal_error"}`,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			c := gomock.NewController(t)
+			defer c.Finish()
+
+			subtasks := mock_service.NewMockSubtasks(c)
+			testCase.mockBehavior(subtasks, testCase.input)
+
+			services := &service.Service{Subtasks: subtasks}
+			handler := NewHandler(services, Config{})
+
+			r := gin.New()
+			r.PUT("/items/:id/subtasks/:subtaskId", func(ctx *gin.Context) {
+				ctx.Set(userCtx, 1)
+			}, handler.updateSubtask)
+
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest("PUT", testCase.path, bytes.NewBufferString(testCase.inputBody))
+
+			r.ServeHTTP(w, req)
+
+			assert.Equal(t, testCase.expectStatusCode, w.Code)
+			assert.Equal(t, testCase.expectRequestBody, w.Body.String())
+		})
+	}
+}
@@ -0,0 +1,81 @@
+package handler
+
+import (
+	todo "do-app"
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"strconv"
+)
+
+type trashResponse struct {
+	Data []todo.TrashEntry `json:"data"`
+}
+
+// @Summary Get trash
+// @Tags trash
+// @Security ApiKeyAuth
+// @Description deleted lists and items that can still be restored, most recently deleted first
+// @ID get-trash
+// @Accept json
+// @Produce json
+// @Success 200 {object} trashResponse
+// @Failure 401 {object} problemResponse
+// @Failure 500 {object} problemResponse
+// @Failure default {object} problemResponse
+// @Router /api/trash [get]
+func (h *Handler) getTrash(c *gin.Context) {
+	userId, err := getUserId(c)
+	if err != nil {
+		newErrorResponse(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	entries, err := h.services.Trash.GetAll(c.Request.Context(), userId)
+	if err != nil {
+		newServiceErrorResponse(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, trashResponse{
+		Data: entries,
+	})
+}
+
+// @Summary Restore from trash
+// @Tags trash
+// @Security ApiKeyAuth
+// @Description restore a deleted list together with its items, or a deleted item
+// @ID restore-trash
+// @Accept json
+// @Produce json
+// @Param type path string true "entry type" Enums(list, item)
+// @Param id path string true "list or item id"
+// @Success 200 {object} statusResponse
+// @Failure 400 {object} problemResponse
+// @Failure 401 {object} problemResponse
+// @Failure 404 {object} problemResponse
+// @Failure 500 {object} problemResponse
+// @Failure default {object} problemResponse
+// @Router /api/trash/{type}/{id}/restore [post]
+func (h *Handler) restoreTrash(c *gin.Context) {
+	userId, err := getUserId(c)
+	if err != nil {
+		newErrorResponse(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		newErrorResponse(c, http.StatusBadRequest, "invalid id param")
+		return
+	}
+
+	if err = h.services.Trash.Restore(c.Request.Context(), userId, c.Param("type"), id); err != nil {
+		newServiceErrorResponse(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, statusResponse{
+		Status: "ok",
+	})
+}
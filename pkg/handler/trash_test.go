@@ -0,0 +1,146 @@
+package handler
+
+import (
+	todo "do-app"
+	"do-app/pkg/service"
+	mock_service "do-app/pkg/service/mocks"
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestHandler_getTrash(t *testing.T) {
+	type mockBehavior func(s *mock_service.MockTrash)
+
+	deletedAt := time.Date(2030, 1, 2, 10, 0, 0, 0, time.UTC)
+
+	testTable := []struct {
+		name              string
+		mockBehavior      mockBehavior
+		expectStatusCode  int
+		expectRequestBody string
+	}{
+		{
+			name: "OK",
+			mockBehavior: func(s *mock_service.MockTrash) {
+				s.EXPECT().GetAll(gomock.Any(), 1).Return([]todo.TrashEntry{
+					{Type: "item", Id: 5, ListId: 3, Title: "call mom", DeletedAt: deletedAt},
+				}, nil)
+			},
+			expectStatusCode:  200,
+			expectRequestBody: `{"data":[{"type":"item","id":5,"list_id":3,"title":"call mom","deleted_at":"2030-01-02T10:00:00Z"}]}`,
+		},
+		{
+			name: "Service Error",
+			mockBehavior: func(s *mock_service.MockTrash) {
+				s.EXPECT().GetAll(gomock.Any(), 1).Return(nil, fmt.Errorf("service failure"))
+			},
+			expectStatusCode:  500,
+			expectRequestBody: `{"type":"about:blank","title":"Internal Server Error","status":500,"code":"internal_error"}`,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			c := gomock.NewController(t)
+			defer c.Finish()
+
+			trash := mock_service.NewMockTrash(c)
+			testCase.mockBehavior(trash)
+
+			services := &service.Service{Trash: trash}
+			handler := NewHandler(services, Config{})
+
+			r := gin.New()
+			r.GET("/trash", func(ctx *gin.Context) {
+				ctx.Set(userCtx, 1)
+			}, handler.getTrash)
+
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest("GET", "/trash", nil)
+
+			r.ServeHTTP(w, req)
+
+			assert.Equal(t, testCase.expectStatusCode, w.Code)
+			assert.Equal(t, testCase.expectRequestBody, w.Body.String())
+		})
+	}
+}
+
+func TestHandler_restoreTrash(t *testing.T) {
+	type mockBehavior func(s *mock_service.MockTrash)
+
+	testTable := []struct {
+		name              string
+		path              string
+		mockBehavior      mockBehavior
+		expectStatusCode  int
+		expectRequestBody string
+	}{
+		{
+			name: "OK",
+			path: "/trash/list/2/restore",
+			mockBehavior: func(s *mock_service.MockTrash) {
+				s.EXPECT().Restore(gomock.Any(), 1, "list", 2).Return(nil)
+			},
+			expectStatusCode:  200,
+			expectRequestBody: `{"status":"ok"}`,
+		},
+		{
+			name:              "Invalid Id",
+			path:              "/trash/item/five/restore",
+			mockBehavior:      func(s *mock_service.MockTrash) {},
+			expectStatusCode:  400,
+			expectRequestBody: `{"type":"about:blank","title":"Bad Request","status":400,"detail":"invalid id param","code":"bad_request"}`,
+		},
+		{
+			name: "Unknown Type",
+			path: "/trash/label/2/restore",
+			mockBehavior: func(s *mock_service.MockTrash) {
+				s.EXPECT().Restore(gomock.Any(), 1, "label", 2).Return(todo.NewError(todo.ErrValidation, "type must be list or item"))
+			},
+			expectStatusCode:  400,
+			expectRequestBody: `{"type":"about:blank","title":"Bad Request","status":400,"detail":"type must be list or item","code":"validation_failed"}`,
+		},
+		{
+			name: "Not In Trash",
+			path: "/trash/item/5/restore",
+			mockBehavior: func(s *mock_service.MockTrash) {
+				s.EXPECT().Restore(gomock.Any(), 1, "item", 5).Return(fmt.Errorf("RestoreItem trash repository: %w",
+					todo.NewError(todo.ErrNotFound, "item not found in trash")))
+			},
+			expectStatusCode:  404,
+			expectRequestBody: `{"type":"about:blank","title":"Not Found","status":404,"detail":"item not found in trash","code":"not_found"}`,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			c := gomock.NewController(t)
+			defer c.Finish()
+
+			trash := mock_service.NewMockTrash(c)
+			testCase.mockBehavior(trash)
+
+			services := &service.Service{Trash: trash}
+			handler := NewHandler(services, Config{})
+
+			r := gin.New()
+			r.POST("/trash/:type/:id/restore", func(ctx *gin.Context) {
+				ctx.Set(userCtx, 1)
+			}, handler.restoreTrash)
+
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest("POST", testCase.path, nil)
+
+			r.ServeHTTP(w, req)
+
+			assert.Equal(t, testCase.expectStatusCode, w.Code)
+			assert.Equal(t, testCase.expectRequestBody, w.Body.String())
+		})
+	}
+}
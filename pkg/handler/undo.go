@@ -0,0 +1,46 @@
+package handler
+
+import (
+	"github.com/gin-gonic/gin"
+	"net/http"
+)
+
+// undoableResponse answers changes that can be undone by posting the undo
+// token to /api/undo within the undo window. Changes of a recurring series
+// cannot be undone and are answered without a token.
+type undoableResponse struct {
+	Status    string `json:"status"`
+	UndoToken string `json:"undo_token,omitempty"`
+}
+
+// @Summary Undo change
+// @Tags undo
+// @Security ApiKeyAuth
+// @Description revert the update or deletion the undo token was issued for, unless the list or item has been changed since
+// @ID undo
+// @Accept json
+// @Produce json
+// @Param token path string true "undo token"
+// @Success 200 {object} statusResponse
+// @Failure 401 {object} problemResponse
+// @Failure 404 {object} problemResponse
+// @Failure 409 {object} problemResponse
+// @Failure 500 {object} problemResponse
+// @Failure default {object} problemResponse
+// @Router /api/undo/{token} [post]
+func (h *Handler) undo(c *gin.Context) {
+	userId, err := getUserId(c)
+	if err != nil {
+		newErrorResponse(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	if err = h.services.Undo.Undo(c.Request.Context(), userId, c.Param("token")); err != nil {
+		newServiceErrorResponse(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, statusResponse{
+		Status: "ok",
+	})
+}
@@ -0,0 +1,76 @@
+package handler
+
+import (
+	todo "do-app"
+	"do-app/pkg/service"
+	mock_service "do-app/pkg/service/mocks"
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestHandler_undo(t *testing.T) {
+	type mockBehavior func(s *mock_service.MockUndo)
+
+	testTable := []struct {
+		name              string
+		mockBehavior      mockBehavior
+		expectStatusCode  int
+		expectRequestBody string
+	}{
+		{
+			name: "OK",
+			mockBehavior: func(s *mock_service.MockUndo) {
+				s.EXPECT().Undo(gomock.Any(), 1, "undo-token").Return(nil)
+			},
+			expectStatusCode:  200,
+			expectRequestBody: `{"status":"ok"}`,
+		},
+		{
+			name: "Changed since",
+			mockBehavior: func(s *mock_service.MockUndo) {
+				s.EXPECT().Undo(gomock.Any(), 1, "undo-token").Return(fmt.Errorf("Undo service: %w",
+					todo.NewError(todo.ErrConflict, "item has been changed since")))
+			},
+			expectStatusCode:  409,
+			expectRequestBody: `{"type":"about:blank","title":"Conflict","status":409,"detail":"item has been changed since","code":"conflict"}`,
+		},
+		{
+			name: "Expired",
+			mockBehavior: func(s *mock_service.MockUndo) {
+				s.EXPECT().Undo(gomock.Any(), 1, "undo-token").Return(todo.NewError(todo.ErrNotFound, "undo token not found"))
+			},
+			expectStatusCode:  404,
+			expectRequestBody: `{"type":"about:blank","title":"Not Found","status":404,"detail":"undo token not found","code":"not_found"}`,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			c := gomock.NewController(t)
+			defer c.Finish()
+
+			undo := mock_service.NewMockUndo(c)
+			testCase.mockBehavior(undo)
+
+			services := &service.Service{Undo: undo}
+			handler := NewHandler(services, Config{})
+
+			r := gin.New()
+			r.POST("/undo/:token", func(ctx *gin.Context) {
+				ctx.Set(userCtx, 1)
+			}, handler.undo)
+
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest("POST", "/undo/undo-token", nil)
+
+			r.ServeHTTP(w, req)
+
+			assert.Equal(t, testCase.expectStatusCode, w.Code)
+			assert.Equal(t, testCase.expectRequestBody, w.Body.String())
+		})
+	}
+}
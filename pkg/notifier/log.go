@@ -0,0 +1,22 @@
+package notifier
+
+import (
+	"context"
+	todo "do-app"
+	"github.com/sirupsen/logrus"
+)
+
+type LogNotifier struct{}
+
+func NewLogNotifier() *LogNotifier {
+	return &LogNotifier{}
+}
+
+func (n *LogNotifier) Notify(ctx context.Context, reminder todo.Reminder) error {
+	logrus.WithFields(logrus.Fields{
+		"item_id":    reminder.ItemId,
+		"list_id":    reminder.ListId,
+		"recipients": len(reminder.Recipients),
+	}).Infof("reminder: %s", reminder.Title)
+	return nil
+}
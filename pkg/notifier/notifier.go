@@ -0,0 +1,12 @@
+package notifier
+
+import (
+	"context"
+	todo "do-app"
+)
+
+// Notifier delivers a due reminder. A returned error means the reminder was
+// not delivered and has to be retried. Notifiers give up when ctx is done.
+type Notifier interface {
+	Notify(ctx context.Context, reminder todo.Reminder) error
+}
@@ -0,0 +1,137 @@
+package notifier
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	todo "do-app"
+	"fmt"
+	"mime"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"strings"
+	"time"
+)
+
+var headerReplacer = strings.NewReplacer("\r", " ", "\n", " ")
+
+// defaultSMTPTimeout bounds a delivery when the config sets no timeout.
+const defaultSMTPTimeout = 30 * time.Second
+
+// SMTPConfig configures the mail server. Timeout bounds the whole delivery of
+// a reminder, from dialing to the end of the session.
+type SMTPConfig struct {
+	Host     string
+	Port     string
+	Username string
+	Password string
+	From     string
+	Timeout  time.Duration
+}
+
+// SMTPNotifier mails reminders to every list member whose username is an
+// e-mail address. Reminders without such a recipient fail, so they show up in
+// the delivery log instead of disappearing.
+type SMTPNotifier struct {
+	cfg SMTPConfig
+}
+
+func NewSMTPNotifier(cfg SMTPConfig) *SMTPNotifier {
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = defaultSMTPTimeout
+	}
+	return &SMTPNotifier{cfg: cfg}
+}
+
+func (n *SMTPNotifier) Notify(ctx context.Context, reminder todo.Reminder) error {
+	to := make([]string, 0, len(reminder.Recipients))
+	for _, recipient := range reminder.Recipients {
+		if address, err := mail.ParseAddress(recipient.Username); err == nil {
+			to = append(to, address.Address)
+		}
+	}
+	if len(to) == 0 {
+		return fmt.Errorf("smtp notifier: no recipient with an e-mail address")
+	}
+
+	if err := n.send(ctx, to, n.message(reminder)); err != nil {
+		return fmt.Errorf("smtp notifier: %w", err)
+	}
+	return nil
+}
+
+// send is smtp.SendMail with a deadline: smtp.SendMail neither takes a context
+// nor times out, so a stalled server would block the scheduler forever.
+func (n *SMTPNotifier) send(ctx context.Context, to []string, msg []byte) error {
+	ctx, cancel := context.WithTimeout(ctx, n.cfg.Timeout)
+	defer cancel()
+
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, "tcp", net.JoinHostPort(n.cfg.Host, n.cfg.Port))
+	if err != nil {
+		return err
+	}
+	deadline, _ := ctx.Deadline()
+	if err = conn.SetDeadline(deadline); err != nil {
+		conn.Close()
+		return err
+	}
+	// Canceling ctx ends the session, not only the dial.
+	stop := context.AfterFunc(ctx, func() { conn.SetDeadline(time.Now()) })
+	defer stop()
+
+	client, err := smtp.NewClient(conn, n.cfg.Host)
+	if err != nil {
+		conn.Close()
+		return err
+	}
+	defer client.Close()
+
+	if ok, _ := client.Extension("STARTTLS"); ok {
+		if err = client.StartTLS(&tls.Config{ServerName: n.cfg.Host}); err != nil {
+			return err
+		}
+	}
+	if n.cfg.Username != "" {
+		if err = client.Auth(smtp.PlainAuth("", n.cfg.Username, n.cfg.Password, n.cfg.Host)); err != nil {
+			return err
+		}
+	}
+	if err = client.Mail(n.cfg.From); err != nil {
+		return err
+	}
+	for _, address := range to {
+		if err = client.Rcpt(address); err != nil {
+			return err
+		}
+	}
+	w, err := client.Data()
+	if err != nil {
+		return err
+	}
+	if _, err = w.Write(msg); err != nil {
+		return err
+	}
+	if err = w.Close(); err != nil {
+		return err
+	}
+	return client.Quit()
+}
+
+func (n *SMTPNotifier) message(reminder todo.Reminder) []byte {
+	var msg bytes.Buffer
+	fmt.Fprintf(&msg, "From: %s\r\n", n.cfg.From)
+	subject := headerReplacer.Replace("Reminder: " + reminder.Title)
+	fmt.Fprintf(&msg, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", subject))
+	fmt.Fprintf(&msg, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	msg.WriteString("Content-Type: text/plain; charset=UTF-8\r\n\r\n")
+	msg.WriteString(reminder.Title + "\r\n")
+	if reminder.Description != "" {
+		msg.WriteString("\r\n" + reminder.Description + "\r\n")
+	}
+	if reminder.DueAt != nil {
+		fmt.Fprintf(&msg, "\r\nDue: %s\r\n", reminder.DueAt.Format(time.RFC1123))
+	}
+	return msg.Bytes()
+}
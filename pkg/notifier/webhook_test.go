@@ -0,0 +1,71 @@
+package notifier
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	todo "do-app"
+	"encoding/hex"
+	"github.com/stretchr/testify/assert"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestWebhookNotifier_Notify(t *testing.T) {
+	testTable := []struct {
+		name       string
+		secret     string
+		statusCode int
+		wantErr    assert.ErrorAssertionFunc
+	}{
+		{
+			name:       "OK",
+			statusCode: http.StatusNoContent,
+			wantErr:    assert.NoError,
+		},
+		{
+			name:       "Signed",
+			secret:     "secret",
+			statusCode: http.StatusOK,
+			wantErr:    assert.NoError,
+		},
+		{
+			name:       "Receiver failure",
+			statusCode: http.StatusBadGateway,
+			wantErr:    assert.Error,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				body, _ := io.ReadAll(r.Body)
+				assert.JSONEq(t, `{"item_id":1,"list_id":2,"title":"report","description":"",`+
+					`"remind_at":"2030-01-02T09:00:00Z","recipients":null}`, string(body))
+
+				if testCase.secret != "" {
+					mac := hmac.New(sha256.New, []byte(testCase.secret))
+					mac.Write(body)
+					assert.Equal(t, "sha256="+hex.EncodeToString(mac.Sum(nil)), r.Header.Get(signatureHeader))
+				} else {
+					assert.Empty(t, r.Header.Get(signatureHeader))
+				}
+				w.WriteHeader(testCase.statusCode)
+			}))
+			defer server.Close()
+
+			n := NewWebhookNotifier(server.URL, testCase.secret, time.Second)
+			err := n.Notify(context.Background(), todo.Reminder{
+				ItemId:   1,
+				ListId:   2,
+				Title:    "report",
+				RemindAt: time.Date(2030, 1, 2, 9, 0, 0, 0, time.UTC),
+			})
+
+			testCase.wantErr(t, err)
+		})
+	}
+}
@@ -0,0 +1,69 @@
+package repository
+
+import (
+	"context"
+	todo "do-app"
+	"fmt"
+	"strconv"
+)
+
+type ActivityMemory struct {
+	store *memoryStore
+}
+
+func NewActivityMemory(store *memoryStore) *ActivityMemory {
+	return &ActivityMemory{store: store}
+}
+
+// GetByList returns the activity of the list and all of its items, newest
+// first.
+func (r *ActivityMemory) GetByList(ctx context.Context, listId int, page todo.PageRequest) ([]todo.Activity, string, error) {
+	activities, next, err := r.selectPage(ctx, func(a todo.Activity) bool {
+		return a.ListId == listId
+	}, page)
+	if err != nil {
+		return nil, "", fmt.Errorf("GetByList activity repository: %w", err)
+	}
+	return activities, next, nil
+}
+
+// GetByItem returns the activity of the item, newest first.
+func (r *ActivityMemory) GetByItem(ctx context.Context, itemId int, page todo.PageRequest) ([]todo.Activity, string, error) {
+	activities, next, err := r.selectPage(ctx, func(a todo.Activity) bool {
+		return a.ItemId != nil && *a.ItemId == itemId
+	}, page)
+	if err != nil {
+		return nil, "", fmt.Errorf("GetByItem activity repository: %w", err)
+	}
+	return activities, next, nil
+}
+
+func (r *ActivityMemory) selectPage(ctx context.Context, match func(a todo.Activity) bool,
+	page todo.PageRequest) ([]todo.Activity, string, error) {
+	keys, err := newKeyset(page, "-"+todo.SortCreated, activitySortKeys, "a.id")
+	if err != nil {
+		return nil, "", err
+	}
+
+	var activities []todo.Activity
+	var next string
+	err = r.store.read(ctx, func(d *memoryData) error {
+		var all []todo.Activity
+		for _, a := range d.activities {
+			if match(a) {
+				a.Username = d.users[a.UserId].Username
+				all = append(all, a)
+			}
+		}
+
+		rows, cursor, err := memoryPage(keys, len(all), func(i int) (string, int) {
+			return strconv.Itoa(all[i].Id), all[i].Id
+		})
+		for _, i := range rows {
+			activities = append(activities, all[i])
+		}
+		next = cursor
+		return err
+	})
+	return activities, next, err
+}
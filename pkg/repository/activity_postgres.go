@@ -0,0 +1,241 @@
+package repository
+
+import (
+	"bytes"
+	"context"
+	todo "do-app"
+	"encoding/json"
+	"fmt"
+	"github.com/jmoiron/sqlx"
+	"strconv"
+	"strings"
+)
+
+type ActivityPostgres struct {
+	db *sqlx.DB
+}
+
+func NewActivityPostgres(db *sqlx.DB) *ActivityPostgres {
+	return &ActivityPostgres{db: db}
+}
+
+var activitySelect = fmt.Sprintf(`SELECT a.id, a.list_id, a.item_id, a.user_id, u.username, a.entity, a.action,
+								 a.changes, a.created_at FROM %s a INNER JOIN %s u on u.id = a.user_id`,
+	activitiesTable, usersTable)
+
+var activitySortKeys = map[string]sortKey{
+	todo.SortCreated: {expr: "a.id", cast: "int"},
+}
+
+// GetByList returns the activity of the list and all of its items, newest
+// first.
+func (r *ActivityPostgres) GetByList(ctx context.Context, listId int, page todo.PageRequest) ([]todo.Activity, string, error) {
+	activities, next, err := r.selectPage(ctx, "a.list_id = $1", listId, page)
+	if err != nil {
+		return nil, "", fmt.Errorf("GetByList activity repository: %w", err)
+	}
+	return activities, next, nil
+}
+
+// GetByItem returns the activity of the item, newest first.
+func (r *ActivityPostgres) GetByItem(ctx context.Context, itemId int, page todo.PageRequest) ([]todo.Activity, string, error) {
+	activities, next, err := r.selectPage(ctx, "a.item_id = $1", itemId, page)
+	if err != nil {
+		return nil, "", fmt.Errorf("GetByItem activity repository: %w", err)
+	}
+	return activities, next, nil
+}
+
+func (r *ActivityPostgres) selectPage(ctx context.Context, condition string, id int, page todo.PageRequest) ([]todo.Activity, string, error) {
+	keys, err := newKeyset(page, "-"+todo.SortCreated, activitySortKeys, "a.id")
+	if err != nil {
+		return nil, "", err
+	}
+	conditions, args := keys.conditions([]string{condition}, []interface{}{id})
+
+	var activities []todo.Activity
+	query := fmt.Sprintf("%s WHERE %s %s", activitySelect, strings.Join(conditions, " AND "), keys.orderBy())
+	if err = conn(ctx, r.db).SelectContext(ctx, &activities, query, args...); err != nil {
+		return nil, "", err
+	}
+
+	n, next := keys.page(len(activities), func(i int) (string, int) {
+		return strconv.Itoa(activities[i].Id), activities[i].Id
+	})
+	return activities[:n], next, nil
+}
+
+// recordActivity appends an activity record and returns its id. Callers pass
+// the transaction of the change, so a change is never stored without its
+// record.
+func recordActivity(ctx context.Context, q sqlx.QueryerContext, activity todo.Activity) (int, error) {
+	var id int
+	query := fmt.Sprintf(`INSERT INTO %s (list_id, item_id, user_id, entity, action, changes)
+								 VALUES ($1, $2, $3, $4, $5, $6) RETURNING id`, activitiesTable)
+	err := q.QueryRowxContext(ctx, query, activity.ListId, activity.ItemId, activity.UserId, activity.Entity, activity.Action,
+		activity.Changes).Scan(&id)
+	return id, err
+}
+
+// recordListChange records the action of the user on the list with the
+// differences between the snapshots of the list taken before and after it.
+func recordListChange(ctx context.Context, q sqlx.QueryerContext, userId, listId int,
+	action string, before, after []byte) (int, error) {
+	changes, err := diffSnapshots(before, after)
+	if err != nil {
+		return 0, err
+	}
+	return recordActivity(ctx, q, todo.Activity{ListId: listId, UserId: userId, Entity: todo.ActivityList,
+		Action: action, Changes: changes})
+}
+
+// recordItemChange is recordListChange for an item of the list.
+func recordItemChange(ctx context.Context, q sqlx.QueryerContext, userId, listId, itemId int,
+	action string, before, after []byte) (int, error) {
+	changes, err := diffSnapshots(before, after)
+	if err != nil {
+		return 0, err
+	}
+	return recordActivity(ctx, q, todo.Activity{ListId: listId, ItemId: &itemId, UserId: userId,
+		Entity: todo.ActivityItem, Action: action, Changes: changes})
+}
+
+// newChange is a change between two values that are not snapshot columns.
+func newChange(from, to interface{}) todo.Change {
+	fromValue, _ := json.Marshal(from)
+	toValue, _ := json.Marshal(to)
+	return todo.Change{From: fromValue, To: toValue}
+}
+
+// activityIgnored are columns that change with every write or only matter
+// internally, so they are left out of activity changes.
+var activityIgnored = map[string]bool{
+	"id":                true,
+	"version":           true,
+	"created_at":        true,
+	"updated_at":        true,
+	"deleted_at":        true,
+	"search":            true,
+	"reminder_sent_at":  true,
+	"reminder_retry_at": true,
+}
+
+// diffSnapshots compares two to_jsonb snapshots of a row and returns the
+// columns that differ. A nil before snapshot makes every column a change from
+// null and a nil after snapshot one to null, so creations and deletions record
+// the whole row; without either snapshot there are no changes at all.
+func diffSnapshots(before, after []byte) (todo.Changes, error) {
+	if before == nil && after == nil {
+		return nil, nil
+	}
+	var from, to map[string]json.RawMessage
+	if before != nil {
+		if err := json.Unmarshal(before, &from); err != nil {
+			return nil, err
+		}
+	}
+	if after != nil {
+		if err := json.Unmarshal(after, &to); err != nil {
+			return nil, err
+		}
+	}
+
+	null := json.RawMessage("null")
+	changes := todo.Changes{}
+	for name, value := range to {
+		old, ok := from[name]
+		if !ok {
+			old = null
+		}
+		if !activityIgnored[name] && !bytes.Equal(old, value) {
+			changes[name] = todo.Change{From: old, To: value}
+		}
+	}
+	for name, old := range from {
+		if _, ok := to[name]; !ok && !activityIgnored[name] && !bytes.Equal(old, null) {
+			changes[name] = todo.Change{From: old, To: null}
+		}
+	}
+	return changes, nil
+}
+
+// snapshotDone reports whether the item of a snapshot is done.
+func snapshotDone(snapshot []byte) (bool, error) {
+	var item struct {
+		Done bool `json:"done"`
+	}
+	err := json.Unmarshal(snapshot, &item)
+	return item.Done, err
+}
+
+// listSnapshot locks the list for the rest of the transaction and returns it
+// as JSON.
+func listSnapshot(ctx context.Context, tx *Tx, listId int) ([]byte, error) {
+	var snapshot []byte
+	query := fmt.Sprintf("SELECT to_jsonb(tl) FROM %s tl WHERE tl.id = $1 AND tl.deleted_at IS NULL FOR UPDATE",
+		todoListsTable)
+	if err := tx.GetContext(ctx, &snapshot, query, listId); err != nil {
+		return nil, domainError(err, "list")
+	}
+	return snapshot, nil
+}
+
+// itemJSON is the snapshot of the item ti. Clients see the rule of the active
+// series as part of the item, so it is recorded along with the columns.
+var itemJSON = fmt.Sprintf(`to_jsonb(ti) || jsonb_build_object('rrule',
+								 (SELECT s.rrule FROM %s s WHERE s.id = ti.series_id AND s.stopped_at IS NULL))`,
+	itemSeriesTable)
+
+// itemSnapshot locks the item for the rest of the transaction and returns its
+// list and the item as JSON.
+func itemSnapshot(ctx context.Context, tx *Tx, itemId int) (int, []byte, error) {
+	var snapshot struct {
+		ListId int    `db:"list_id"`
+		Item   []byte `db:"item"`
+	}
+	query := fmt.Sprintf(`SELECT li.list_id, %s AS item FROM %s ti INNER JOIN %s li on li.item_id = ti.id
+								 WHERE ti.id = $1 AND ti.deleted_at IS NULL FOR UPDATE OF ti`,
+		itemJSON, todoItemsTable, listsItemsTable)
+	if err := tx.GetContext(ctx, &snapshot, query, itemId); err != nil {
+		return 0, nil, domainError(err, "item")
+	}
+	return snapshot.ListId, snapshot.Item, nil
+}
+
+// seriesItem is the snapshot of an open occurrence of a series.
+type seriesItem struct {
+	ListId int    `db:"list_id"`
+	ItemId int    `db:"item_id"`
+	Item   []byte `db:"item"`
+}
+
+// seriesSnapshot locks the open occurrences of the series in lists the user may
+// edit for the rest of the transaction and returns them as JSON.
+func seriesSnapshot(ctx context.Context, tx *Tx, userId, seriesId int) ([]seriesItem, error) {
+	var items []seriesItem
+	query := fmt.Sprintf(`SELECT li.list_id, ti.id AS item_id, %s AS item FROM %s ti INNER JOIN %s li on li.item_id = ti.id
+								 INNER JOIN %s ul on ul.list_id = li.list_id
+								 WHERE ti.series_id = $1 AND ul.user_id = $2 AND ul.role IN (%s)
+								 AND ti.done = false AND ti.deleted_at IS NULL ORDER BY ti.id FOR UPDATE OF ti`,
+		itemJSON, todoItemsTable, listsItemsTable, usersListsTable, editorRoles)
+	if err := tx.SelectContext(ctx, &items, query, seriesId, userId); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+// recordSeriesChange records the change of the user to each occurrence of a
+// series, given the snapshots of the occurrences before and after it.
+func recordSeriesChange(ctx context.Context, q sqlx.QueryerContext, userId int, before, after []seriesItem) error {
+	snapshots := make(map[int][]byte, len(before))
+	for _, item := range before {
+		snapshots[item.ItemId] = item.Item
+	}
+	for _, item := range after {
+		if _, err := recordItemChange(ctx, q, userId, item.ListId, item.ItemId, todo.ActionUpdate,
+			snapshots[item.ItemId], item.Item); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,187 @@
+package repository
+
+import (
+	"context"
+	todo "do-app"
+	"encoding/json"
+	"github.com/stretchr/testify/assert"
+	sqlmock "github.com/zhashkevych/go-sqlxmock"
+	"log"
+	"testing"
+	"time"
+)
+
+func expectListSnapshot(mock sqlmock.Sqlmock, listId int, list string) *sqlmock.ExpectedQuery {
+	return mock.ExpectQuery(`SELECT to_jsonb\(tl\) FROM todo_lists tl WHERE tl.id = \$1 AND tl.deleted_at IS NULL FOR UPDATE`).
+		WithArgs(listId).WillReturnRows(sqlmock.NewRows([]string{"to_jsonb"}).AddRow(list))
+}
+
+func expectItemSnapshot(mock sqlmock.Sqlmock, itemId, listId int, item string) *sqlmock.ExpectedQuery {
+	return mock.ExpectQuery(`SELECT li.list_id, to_jsonb\(ti\) (.+) AS item FROM todo_items ti (.+) WHERE ti.id = \$1 (.+) FOR UPDATE OF ti`).
+		WithArgs(itemId).WillReturnRows(sqlmock.NewRows([]string{"list_id", "item"}).AddRow(listId, item))
+}
+
+func expectSeriesSnapshot(mock sqlmock.Sqlmock, seriesId, userId, listId, itemId int, item string) *sqlmock.ExpectedQuery {
+	return mock.ExpectQuery(`SELECT li.list_id, ti.id AS item_id, to_jsonb\(ti\) (.+) WHERE ti.series_id = \$1 AND ul.user_id = \$2 (.+) FOR UPDATE OF ti`).
+		WithArgs(seriesId, userId).WillReturnRows(sqlmock.NewRows([]string{"list_id", "item_id", "item"}).AddRow(listId, itemId, item))
+}
+
+func expectActivity(mock sqlmock.Sqlmock, listId, userId int, entity, action string) *sqlmock.ExpectedQuery {
+	return mock.ExpectQuery(`INSERT INTO activities (.+) RETURNING id`).
+		WithArgs(listId, sqlmock.AnyArg(), userId, entity, action, sqlmock.AnyArg())
+}
+
+func activityRow(id int) *sqlmock.Rows {
+	return sqlmock.NewRows([]string{"id"}).AddRow(id)
+}
+
+func TestActivityPostgres_GetByList(t *testing.T) {
+	db, mock, err := sqlmock.Newx()
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer db.Close()
+
+	r := NewActivityPostgres(db)
+
+	itemId := 4
+	createdAt := time.Date(2030, 1, 7, 9, 0, 0, 0, time.UTC)
+	columns := []string{"id", "list_id", "item_id", "user_id", "username", "entity", "action", "changes", "created_at"}
+
+	testTable := []struct {
+		name         string
+		page         todo.PageRequest
+		mockBehavior func()
+		want         []todo.Activity
+		wantNext     string
+		wantErr      bool
+	}{
+		{
+			name: "OK",
+			mockBehavior: func() {
+				rows := sqlmock.NewRows(columns).
+					AddRow(8, 2, itemId, 1, "alice", "item", "update", `{"title": {"from": "milk", "to": "oat milk"}}`, createdAt).
+					AddRow(7, 2, nil, 1, "alice", "list", "create", nil, createdAt)
+				mock.ExpectQuery(`SELECT a.id, (.+) FROM activities a INNER JOIN users u on u.id = a.user_id ` +
+					`WHERE a.list_id = \$1 ORDER BY a.id DESC, a.id DESC LIMIT 51`).
+					WithArgs(2).WillReturnRows(rows)
+			},
+			want: []todo.Activity{
+				{Id: 8, ListId: 2, ItemId: &itemId, UserId: 1, Username: "alice", Entity: "item", Action: "update",
+					Changes:   todo.Changes{"title": {From: json.RawMessage(`"milk"`), To: json.RawMessage(`"oat milk"`)}},
+					CreatedAt: createdAt},
+				{Id: 7, ListId: 2, UserId: 1, Username: "alice", Entity: "list", Action: "create", CreatedAt: createdAt},
+			},
+		},
+		{
+			name: "Next page",
+			page: todo.PageRequest{Limit: 1},
+			mockBehavior: func() {
+				rows := sqlmock.NewRows(columns).
+					AddRow(8, 2, nil, 1, "alice", "list", "update", nil, createdAt).
+					AddRow(7, 2, nil, 1, "alice", "list", "create", nil, createdAt)
+				mock.ExpectQuery(`WHERE a.list_id = \$1 ORDER BY a.id DESC, a.id DESC LIMIT 2`).
+					WithArgs(2).WillReturnRows(rows)
+			},
+			want: []todo.Activity{
+				{Id: 8, ListId: 2, UserId: 1, Username: "alice", Entity: "list", Action: "update", CreatedAt: createdAt},
+			},
+			wantNext: encodeCursor(cursor{Sort: "-created", Value: "8", Id: 8}),
+		},
+		{
+			name: "After cursor",
+			page: todo.PageRequest{Cursor: encodeCursor(cursor{Sort: "-created", Value: "8", Id: 8})},
+			mockBehavior: func() {
+				mock.ExpectQuery(`WHERE a.list_id = \$1 AND \(a.id, a.id\) < \(\$2::int, \$3\) ORDER BY`).
+					WithArgs(2, "8", 8).WillReturnRows(sqlmock.NewRows(columns))
+			},
+		},
+		{
+			name:         "Unknown sort",
+			page:         todo.PageRequest{Sort: "title"},
+			mockBehavior: func() {},
+			wantErr:      true,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			testCase.mockBehavior()
+
+			got, next, err := r.GetByList(context.Background(), 2, testCase.page)
+
+			if testCase.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, testCase.want, got)
+				assert.Equal(t, testCase.wantNext, next)
+			}
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestActivityPostgres_GetByItem(t *testing.T) {
+	db, mock, err := sqlmock.Newx()
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer db.Close()
+
+	r := NewActivityPostgres(db)
+
+	mock.ExpectQuery(`FROM activities a INNER JOIN users u on u.id = a.user_id WHERE a.item_id = \$1 ORDER BY`).
+		WithArgs(4).WillReturnError(assert.AnError)
+
+	_, _, err = r.GetByItem(context.Background(), 4, todo.PageRequest{})
+
+	assert.ErrorIs(t, err, assert.AnError)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestDiffSnapshots(t *testing.T) {
+	testTable := []struct {
+		name   string
+		before string
+		after  string
+		want   todo.Changes
+	}{
+		{
+			name:   "Changed fields",
+			before: `{"id": 4, "title": "milk", "done": false, "priority": 1, "version": 2}`,
+			after:  `{"id": 4, "title": "oat milk", "done": true, "priority": 1, "version": 3}`,
+			want: todo.Changes{
+				"title": {From: json.RawMessage(`"milk"`), To: json.RawMessage(`"oat milk"`)},
+				"done":  {From: json.RawMessage(`false`), To: json.RawMessage(`true`)},
+			},
+		},
+		{
+			name:  "Created",
+			after: `{"id": 4, "title": "milk", "due_at": null, "updated_at": "2030-01-07T09:00:00Z"}`,
+			want: todo.Changes{
+				"title": {From: json.RawMessage(`null`), To: json.RawMessage(`"milk"`)},
+			},
+		},
+		{
+			name:   "Nothing changed",
+			before: `{"id": 4, "title": "milk", "version": 2}`,
+			after:  `{"id": 4, "title": "milk", "version": 3}`,
+			want:   todo.Changes{},
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			var before []byte
+			if testCase.before != "" {
+				before = []byte(testCase.before)
+			}
+
+			got, err := diffSnapshots(before, []byte(testCase.after))
+
+			assert.NoError(t, err)
+			assert.Equal(t, testCase.want, got)
+		})
+	}
+}
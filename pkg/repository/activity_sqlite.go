@@ -0,0 +1,114 @@
+package repository
+
+import (
+	"context"
+	todo "do-app"
+	"fmt"
+	"github.com/jmoiron/sqlx"
+	"strconv"
+	"strings"
+)
+
+type ActivitySQLite struct {
+	db *sqlx.DB
+}
+
+func NewActivitySQLite(db *sqlx.DB) *ActivitySQLite {
+	return &ActivitySQLite{db: db}
+}
+
+// GetByList returns the activity of the list and all of its items, newest
+// first.
+func (r *ActivitySQLite) GetByList(ctx context.Context, listId int, page todo.PageRequest) ([]todo.Activity, string, error) {
+	activities, next, err := r.selectPage(ctx, "a.list_id = ?1", listId, page)
+	if err != nil {
+		return nil, "", fmt.Errorf("GetByList activity repository: %w", err)
+	}
+	return activities, next, nil
+}
+
+// GetByItem returns the activity of the item, newest first.
+func (r *ActivitySQLite) GetByItem(ctx context.Context, itemId int, page todo.PageRequest) ([]todo.Activity, string, error) {
+	activities, next, err := r.selectPage(ctx, "a.item_id = ?1", itemId, page)
+	if err != nil {
+		return nil, "", fmt.Errorf("GetByItem activity repository: %w", err)
+	}
+	return activities, next, nil
+}
+
+func (r *ActivitySQLite) selectPage(ctx context.Context, condition string, id int, page todo.PageRequest) ([]todo.Activity, string, error) {
+	keys, err := newKeyset(page, "-"+todo.SortCreated, activitySortKeys, "a.id")
+	if err != nil {
+		return nil, "", err
+	}
+	conditions, args, err := keys.sqliteConditions([]string{condition}, []interface{}{id})
+	if err != nil {
+		return nil, "", err
+	}
+
+	var activities []todo.Activity
+	query := fmt.Sprintf("%s WHERE %s %s", activitySelect, strings.Join(conditions, " AND "), keys.orderBy())
+	if err = conn(ctx, r.db).SelectContext(ctx, &activities, query, args...); err != nil {
+		return nil, "", err
+	}
+
+	n, next := keys.page(len(activities), func(i int) (string, int) {
+		return strconv.Itoa(activities[i].Id), activities[i].Id
+	})
+	return activities[:n], next, nil
+}
+
+// SQLite has no to_jsonb, so snapshots name the columns activity records
+// compare. Booleans and times are converted to their JSON form, which is what
+// revertChangesSQLite reads them back from.
+var (
+	sqliteListSnapshot = "json_object('id', tl.id, 'title', tl.title, 'description', tl.description)"
+	sqliteItemSnapshot = `json_object('id', ti.id, 'title', ti.title, 'description', ti.description,
+								 'done', json(iif(ti.done, 'true', 'false')), 'due_at', strftime('%Y-%m-%dT%H:%M:%fZ', ti.due_at),
+								 'remind_at', strftime('%Y-%m-%dT%H:%M:%fZ', ti.remind_at), 'series_id', ti.series_id,
+								 'priority', ti.priority, 'auto_complete', json(iif(ti.auto_complete, 'true', 'false')),
+								 'completed_at', strftime('%Y-%m-%dT%H:%M:%fZ', ti.completed_at),
+								 'rrule', (SELECT s.rrule FROM ` + itemSeriesTable + ` s WHERE s.id = ti.series_id AND s.stopped_at IS NULL))`
+)
+
+// listSnapshotSQLite returns the list as JSON. Transactions on SQLite hold
+// the write lock, so the list cannot change before they end.
+func listSnapshotSQLite(ctx context.Context, tx *Tx, listId int) ([]byte, error) {
+	var snapshot []byte
+	query := fmt.Sprintf("SELECT %s FROM %s tl WHERE tl.id = ?1 AND tl.deleted_at IS NULL",
+		sqliteListSnapshot, todoListsTable)
+	if err := tx.GetContext(ctx, &snapshot, query, listId); err != nil {
+		return nil, domainError(err, "list")
+	}
+	return snapshot, nil
+}
+
+// itemSnapshotSQLite returns the list of the item and the item as JSON.
+func itemSnapshotSQLite(ctx context.Context, tx *Tx, itemId int) (int, []byte, error) {
+	var snapshot struct {
+		ListId int    `db:"list_id"`
+		Item   []byte `db:"item"`
+	}
+	query := fmt.Sprintf(`SELECT li.list_id, %s AS item FROM %s ti INNER JOIN %s li on li.item_id = ti.id
+								 WHERE ti.id = ?1 AND ti.deleted_at IS NULL`,
+		sqliteItemSnapshot, todoItemsTable, listsItemsTable)
+	if err := tx.GetContext(ctx, &snapshot, query, itemId); err != nil {
+		return 0, nil, domainError(err, "item")
+	}
+	return snapshot.ListId, snapshot.Item, nil
+}
+
+// seriesSnapshotSQLite returns the open occurrences of the series in lists the
+// user may edit as JSON.
+func seriesSnapshotSQLite(ctx context.Context, tx *Tx, userId, seriesId int) ([]seriesItem, error) {
+	var items []seriesItem
+	query := fmt.Sprintf(`SELECT li.list_id, ti.id AS item_id, %s AS item FROM %s ti INNER JOIN %s li on li.item_id = ti.id
+								 INNER JOIN %s ul on ul.list_id = li.list_id
+								 WHERE ti.series_id = ?1 AND ul.user_id = ?2 AND ul.role IN (%s)
+								 AND ti.done = false AND ti.deleted_at IS NULL ORDER BY ti.id`,
+		sqliteItemSnapshot, todoItemsTable, listsItemsTable, usersListsTable, editorRoles)
+	if err := tx.SelectContext(ctx, &items, query, seriesId, userId); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
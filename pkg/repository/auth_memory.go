@@ -0,0 +1,65 @@
+package repository
+
+import (
+	"context"
+	todo "do-app"
+	"fmt"
+)
+
+type AuthMemory struct {
+	store *memoryStore
+}
+
+func NewAuthMemory(store *memoryStore) *AuthMemory {
+	return &AuthMemory{store: store}
+}
+
+func (r *AuthMemory) CreateUser(ctx context.Context, user todo.User) (int, error) {
+	var id int
+	err := r.store.write(ctx, func(d *memoryData) error {
+		for _, u := range d.users {
+			if u.Username == user.Username {
+				return todo.NewError(todo.ErrConflict, "user already exists")
+			}
+		}
+		id = d.nextId(usersTable)
+		user.Id = id
+		put(d, d.users, id, user)
+		return nil
+	})
+	if err != nil {
+		return 0, fmt.Errorf("Create user repository: %w", err)
+	}
+	return id, nil
+}
+
+func (r *AuthMemory) GetUser(ctx context.Context, username string) (todo.User, error) {
+	var user todo.User
+	err := r.store.read(ctx, func(d *memoryData) error {
+		for _, u := range d.users {
+			if u.Username == username {
+				user = todo.User{Id: u.Id, Password: u.Password, PasswordAlgo: u.PasswordAlgo}
+				return nil
+			}
+		}
+		return todo.NewError(todo.ErrNotFound, "user not found")
+	})
+	if err != nil {
+		return user, fmt.Errorf("Get user repository: %w", err)
+	}
+	return user, nil
+}
+
+func (r *AuthMemory) UpdatePasswordHash(ctx context.Context, userId int, passwordHash, algo string) error {
+	err := r.store.write(ctx, func(d *memoryData) error {
+		if user, ok := d.users[userId]; ok {
+			user.Password, user.PasswordAlgo = passwordHash, algo
+			put(d, d.users, userId, user)
+		}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("Update password hash repository: %w", err)
+	}
+	return nil
+}
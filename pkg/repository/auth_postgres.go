@@ -1,6 +1,7 @@
 package repository
 
 import (
+	"context"
 	todo "do-app"
 	"fmt"
 	"github.com/jmoiron/sqlx"
@@ -14,23 +15,31 @@ func NewAuthPostgres(db *sqlx.DB) *AuthPostgres {
 	return &AuthPostgres{db: db}
 }
 
-func (r *AuthPostgres) CreateUser(user todo.User) (int, error) {
+func (r *AuthPostgres) CreateUser(ctx context.Context, user todo.User) (int, error) {
 	var id int
-	query := fmt.Sprintf(`INSERT INTO %s (name, username, password_hash) 
-								  values ($1, $2, $3) RETURNING id`, usersTable)
-	row := r.db.QueryRow(query, user.Name, user.Username, user.Password)
+	query := fmt.Sprintf(`INSERT INTO %s (name, username, password_hash, password_algo) 
+								  values ($1, $2, $3, $4) RETURNING id`, usersTable)
+	row := conn(ctx, r.db).QueryRowContext(ctx, query, user.Name, user.Username, user.Password, user.PasswordAlgo)
 	if err := row.Scan(&id); err != nil {
-		return 0, fmt.Errorf("Create user repository: %w", err)
+		return 0, fmt.Errorf("Create user repository: %w", domainError(err, "user"))
 	}
 	return id, nil
 }
 
-func (r *AuthPostgres) GetUser(username, password string) (todo.User, error) {
+func (r *AuthPostgres) GetUser(ctx context.Context, username string) (todo.User, error) {
 	var user todo.User
-	query := fmt.Sprintf("SELECT id FROM %s WHERE username=$1 AND password_hash=$2", usersTable)
-	err := r.db.Get(&user, query, username, password)
+	query := fmt.Sprintf("SELECT id, password_hash, password_algo FROM %s WHERE username=$1", usersTable)
+	err := conn(ctx, r.db).GetContext(ctx, &user, query, username)
 	if err != nil {
-		return user, fmt.Errorf("Get user repository: %w", err)
+		return user, fmt.Errorf("Get user repository: %w", domainError(err, "user"))
 	}
 	return user, nil
 }
+
+func (r *AuthPostgres) UpdatePasswordHash(ctx context.Context, userId int, passwordHash, algo string) error {
+	query := fmt.Sprintf("UPDATE %s SET password_hash=$1, password_algo=$2 WHERE id=$3", usersTable)
+	if _, err := conn(ctx, r.db).ExecContext(ctx, query, passwordHash, algo, userId); err != nil {
+		return fmt.Errorf("Update password hash repository: %w", err)
+	}
+	return nil
+}
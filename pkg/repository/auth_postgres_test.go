@@ -1,6 +1,7 @@
 package repository
 
 import (
+	"context"
 	todo "do-app"
 	"github.com/stretchr/testify/assert"
 	sqlmock "github.com/zhashkevych/go-sqlxmock"
@@ -34,9 +35,10 @@ func TestAuthPostgres_CreateUser(t *testing.T) {
 			name: "OK",
 			args: args{
 				input: todo.User{
-					Name:     "Test name",
-					Username: "username test",
-					Password: "qwerty",
+					Name:         "Test name",
+					Username:     "username test",
+					Password:     "qwerty",
+					PasswordAlgo: "bcrypt",
 				},
 			},
 			id: 1,
@@ -44,7 +46,7 @@ func TestAuthPostgres_CreateUser(t *testing.T) {
 
 				row := sqlmock.NewRows([]string{"id"}).AddRow(id)
 				mock.ExpectQuery(`INSERT INTO users`).
-					WithArgs(args.input.Name, args.input.Username, args.input.Password).
+					WithArgs(args.input.Name, args.input.Username, args.input.Password, args.input.PasswordAlgo).
 					WillReturnRows(row)
 
 			},
@@ -55,7 +57,7 @@ func TestAuthPostgres_CreateUser(t *testing.T) {
 			mockBehavior: func(args args, id int) {
 
 				mock.ExpectQuery(`INSERT INTO users`).
-					WithArgs(args.input.Name, args.input.Username, args.input.Password).
+					WithArgs(args.input.Name, args.input.Username, args.input.Password, args.input.PasswordAlgo).
 					WillReturnError(assert.AnError)
 
 			},
@@ -68,7 +70,7 @@ func TestAuthPostgres_CreateUser(t *testing.T) {
 
 			testCase.mockBehavior(testCase.args, testCase.id)
 
-			got, err := r.CreateUser(testCase.args.input)
+			got, err := r.CreateUser(context.Background(), testCase.args.input)
 
 			testCase.wantErr(t, err)
 			assert.Equal(t, testCase.id, got)
@@ -87,7 +89,6 @@ func TestAuthPostgres_GetUser(t *testing.T) {
 
 	type args struct {
 		username string
-		password string
 	}
 
 	type mockBehavior func(args args, user todo.User)
@@ -103,20 +104,18 @@ func TestAuthPostgres_GetUser(t *testing.T) {
 			name: "OK",
 			args: args{
 				username: "test",
-				password: "qwerty",
 			},
 			user: todo.User{
-				Id:       1,
-				Name:     "testt",
-				Username: "test",
-				Password: "qwerty",
+				Id:           1,
+				Password:     "hash",
+				PasswordAlgo: "bcrypt",
 			},
 			mockBehavior: func(args args, user todo.User) {
 
-				row := sqlmock.NewRows([]string{"id", "name", "username", "password"}).
-					AddRow(user.Id, user.Name, user.Username, user.Password)
-				mock.ExpectQuery(`SELECT id FROM users`).
-					WithArgs(args.username, args.password).WillReturnRows(row)
+				row := sqlmock.NewRows([]string{"id", "password_hash", "password_algo"}).
+					AddRow(user.Id, user.Password, user.PasswordAlgo)
+				mock.ExpectQuery(`SELECT id, password_hash, password_algo FROM users`).
+					WithArgs(args.username).WillReturnRows(row)
 			},
 			wantErr: assert.NoError,
 		},
@@ -124,12 +123,11 @@ func TestAuthPostgres_GetUser(t *testing.T) {
 			name: "Failed",
 			args: args{
 				username: "test",
-				password: "qwerty",
 			},
 			mockBehavior: func(args args, user todo.User) {
 
-				mock.ExpectQuery(`SELECT id FROM users`).
-					WithArgs(args.username, args.password).WillReturnError(assert.AnError)
+				mock.ExpectQuery(`SELECT id, password_hash, password_algo FROM users`).
+					WithArgs(args.username).WillReturnError(assert.AnError)
 			},
 			wantErr: assert.Error,
 		},
@@ -139,10 +137,53 @@ func TestAuthPostgres_GetUser(t *testing.T) {
 		t.Run(testCase.name, func(t *testing.T) {
 			testCase.mockBehavior(testCase.args, testCase.user)
 
-			got, err := r.GetUser(testCase.args.username, testCase.args.password)
+			got, err := r.GetUser(context.Background(), testCase.args.username)
 
 			testCase.wantErr(t, err)
 			assert.Equal(t, testCase.user, got)
 		})
 	}
 }
+
+func TestAuthPostgres_UpdatePasswordHash(t *testing.T) {
+	db, mock, err := sqlmock.Newx()
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer db.Close()
+
+	r := NewAuthPostgres(db)
+
+	testTable := []struct {
+		name         string
+		mockBehavior func()
+		wantErr      assert.ErrorAssertionFunc
+	}{
+		{
+			name: "OK",
+			mockBehavior: func() {
+				mock.ExpectExec(`UPDATE users SET password_hash`).
+					WithArgs("hash", "bcrypt", 1).WillReturnResult(sqlmock.NewResult(0, 1))
+			},
+			wantErr: assert.NoError,
+		},
+		{
+			name: "Failed",
+			mockBehavior: func() {
+				mock.ExpectExec(`UPDATE users SET password_hash`).
+					WithArgs("hash", "bcrypt", 1).WillReturnError(assert.AnError)
+			},
+			wantErr: assert.Error,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			testCase.mockBehavior()
+
+			err := r.UpdatePasswordHash(context.Background(), 1, "hash", "bcrypt")
+
+			testCase.wantErr(t, err)
+		})
+	}
+}
@@ -0,0 +1,45 @@
+package repository
+
+import (
+	"context"
+	todo "do-app"
+	"fmt"
+	"github.com/jmoiron/sqlx"
+)
+
+type AuthSQLite struct {
+	db *sqlx.DB
+}
+
+func NewAuthSQLite(db *sqlx.DB) *AuthSQLite {
+	return &AuthSQLite{db: db}
+}
+
+func (r *AuthSQLite) CreateUser(ctx context.Context, user todo.User) (int, error) {
+	var id int
+	query := fmt.Sprintf(`INSERT INTO %s (name, username, password_hash, password_algo) 
+								  values (?1, ?2, ?3, ?4) RETURNING id`, usersTable)
+	row := conn(ctx, r.db).QueryRowContext(ctx, query, user.Name, user.Username, user.Password, user.PasswordAlgo)
+	if err := row.Scan(&id); err != nil {
+		return 0, fmt.Errorf("Create user repository: %w", domainError(err, "user"))
+	}
+	return id, nil
+}
+
+func (r *AuthSQLite) GetUser(ctx context.Context, username string) (todo.User, error) {
+	var user todo.User
+	query := fmt.Sprintf("SELECT id, password_hash, password_algo FROM %s WHERE username=?1", usersTable)
+	err := conn(ctx, r.db).GetContext(ctx, &user, query, username)
+	if err != nil {
+		return user, fmt.Errorf("Get user repository: %w", domainError(err, "user"))
+	}
+	return user, nil
+}
+
+func (r *AuthSQLite) UpdatePasswordHash(ctx context.Context, userId int, passwordHash, algo string) error {
+	query := fmt.Sprintf("UPDATE %s SET password_hash=?1, password_algo=?2 WHERE id=?3", usersTable)
+	if _, err := conn(ctx, r.db).ExecContext(ctx, query, passwordHash, algo, userId); err != nil {
+		return fmt.Errorf("Update password hash repository: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,1056 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	todo "do-app"
+	"do-app/schema"
+	"github.com/jmoiron/sqlx"
+	"github.com/stretchr/testify/assert"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+)
+
+// The conformance suite checks the behaviour every implementation of the
+// repositories has to share: ownership of lists and items through list
+// membership, versions, the trash, pagination, activity and undo. Each case
+// gets an empty repository.
+func testConformance(t *testing.T, newRepository func(t *testing.T) *Repository) {
+	testTable := []struct {
+		name string
+		run  func(t *testing.T, r *Repository)
+	}{
+		{name: "Users", run: testUsers},
+		{name: "Sessions", run: testSessions},
+		{name: "List ownership", run: testListOwnership},
+		{name: "Members", run: testMembers},
+		{name: "Invites", run: testInvites},
+		{name: "Items", run: testItems},
+		{name: "Item pages", run: testItemPages},
+		{name: "Move", run: testMove},
+		{name: "Trash", run: testTrash},
+		{name: "Labels", run: testLabels},
+		{name: "Subtasks", run: testSubtasks},
+		{name: "Series", run: testSeries},
+		{name: "Series of other users", run: testForeignSeries},
+		{name: "Activity and undo", run: testActivityAndUndo},
+		{name: "Search", run: testSearch},
+		{name: "Reminders", run: testReminders},
+		{name: "Units of work", run: testUnitsOfWork},
+		{name: "Concurrent writes", run: testConcurrentWrites},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			testCase.run(t, newRepository(t))
+		})
+	}
+}
+
+func TestMemoryRepository_Conformance(t *testing.T) {
+	testConformance(t, func(t *testing.T) *Repository {
+		return NewMemoryRepository()
+	})
+}
+
+// TestPostgresRepository_Conformance runs the suite against the database of
+// TEST_DATABASE_URL, which is migrated down and up again for every case. All
+// data in that database is lost.
+func TestPostgresRepository_Conformance(t *testing.T) {
+	url := os.Getenv("TEST_DATABASE_URL")
+	if url == "" {
+		t.Skip("TEST_DATABASE_URL is not set")
+	}
+	db, err := sqlx.Connect("postgres", url)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	migrator, err := NewMigrator(db, schema.Migrations)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	testConformance(t, func(t *testing.T) *Repository {
+		ctx := context.Background()
+		if err := migrator.Goto(ctx, 0); err != nil {
+			t.Fatal(err)
+		}
+		if err := migrator.Up(ctx); err != nil {
+			t.Fatal(err)
+		}
+		return NewRepository(db, TxConfig{Isolation: sql.LevelReadCommitted, MaxRetries: 3})
+	})
+}
+
+// TestSQLiteRepository_Conformance runs the suite against a new SQLite file
+// for every case.
+func TestSQLiteRepository_Conformance(t *testing.T) {
+	if sqliteErrors == nil {
+		t.Skip("SQLite needs cgo")
+	}
+	testConformance(t, func(t *testing.T) *Repository {
+		db, err := NewSQLiteDB(Config{Path: filepath.Join(t.TempDir(), "todo.db")})
+		if err != nil {
+			t.Fatal(err)
+		}
+		t.Cleanup(func() { db.Close() })
+		migrator, err := NewMigrator(db, schema.SQLiteMigrations)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err = migrator.Up(context.Background()); err != nil {
+			t.Fatal(err)
+		}
+		return NewRepository(db, TxConfig{MaxRetries: 3})
+	})
+}
+
+func createTestUser(t *testing.T, r *Repository, username string) int {
+	id, err := r.CreateUser(context.Background(), todo.User{Name: username, Username: username, Password: "hash",
+		PasswordAlgo: "argon2id"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return id
+}
+
+func createTestList(t *testing.T, r *Repository, userId int, title string) int {
+	id, err := r.TodoLists.Create(context.Background(), userId, todo.TodoList{Title: title})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return id
+}
+
+func createTestItem(t *testing.T, r *Repository, userId, listId int, item todo.TodoItem) int {
+	id, err := r.TodoItems.Create(context.Background(), userId, listId, item)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return id
+}
+
+func addTestMember(t *testing.T, r *Repository, listId int, username, role string) {
+	if _, err := r.ListMembers.Add(context.Background(), listId, todo.AddMemberInput{Username: username, Role: role}); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func itemTitles(items []todo.TodoItem) []string {
+	titles := make([]string, 0, len(items))
+	for _, item := range items {
+		titles = append(titles, item.Title)
+	}
+	return titles
+}
+
+func stringPtr(s string) *string {
+	return &s
+}
+
+func testUsers(t *testing.T, r *Repository) {
+	ctx := context.Background()
+	id := createTestUser(t, r, "alice")
+
+	_, err := r.CreateUser(ctx, todo.User{Name: "Alice", Username: "alice", Password: "hash"})
+	assert.ErrorIs(t, err, todo.ErrConflict)
+
+	user, err := r.GetUser(ctx, "alice")
+	assert.NoError(t, err)
+	assert.Equal(t, todo.User{Id: id, Password: "hash", PasswordAlgo: "argon2id"}, user)
+
+	assert.NoError(t, r.UpdatePasswordHash(ctx, id, "rehashed", "bcrypt"))
+	user, err = r.GetUser(ctx, "alice")
+	assert.NoError(t, err)
+	assert.Equal(t, todo.User{Id: id, Password: "rehashed", PasswordAlgo: "bcrypt"}, user)
+
+	_, err = r.GetUser(ctx, "bob")
+	assert.ErrorIs(t, err, todo.ErrNotFound)
+}
+
+func testSessions(t *testing.T, r *Repository) {
+	ctx := context.Background()
+	userId := createTestUser(t, r, "alice")
+	expiresAt := time.Now().Add(time.Hour)
+
+	id, err := r.Sessions.Create(ctx, todo.Session{UserId: userId, RefreshTokenHash: "first", ExpiresAt: expiresAt})
+	assert.NoError(t, err)
+	session, err := r.GetByRefreshToken(ctx, "first")
+	assert.NoError(t, err)
+	assert.Equal(t, id, session.Id)
+	assert.Equal(t, userId, session.UserId)
+	active, err := r.IsActive(ctx, id)
+	assert.NoError(t, err)
+	assert.True(t, active)
+
+	assert.Error(t, r.Rotate(ctx, id, "stale", "second", expiresAt))
+	assert.NoError(t, r.Rotate(ctx, id, "first", "second", expiresAt))
+	_, err = r.GetByRefreshToken(ctx, "first")
+	assert.ErrorIs(t, err, todo.ErrNotFound)
+
+	assert.NoError(t, r.Sessions.Revoke(ctx, id))
+	active, err = r.IsActive(ctx, id)
+	assert.NoError(t, err)
+	assert.False(t, active)
+	assert.Error(t, r.Rotate(ctx, id, "second", "third", expiresAt))
+}
+
+func testListOwnership(t *testing.T, r *Repository) {
+	ctx := context.Background()
+	alice := createTestUser(t, r, "alice")
+	bob := createTestUser(t, r, "bob")
+	listId := createTestList(t, r, alice, "groceries")
+
+	list, err := r.TodoLists.GetById(ctx, alice, listId)
+	assert.NoError(t, err)
+	assert.Equal(t, "groceries", list.Title)
+	assert.Equal(t, 1, list.Version)
+
+	// Lists of other users do not exist for bob.
+	_, err = r.TodoLists.GetById(ctx, bob, listId)
+	assert.ErrorIs(t, err, todo.ErrNotFound)
+	lists, _, err := r.TodoLists.GetAll(ctx, bob, todo.ListFilter{}, todo.PageRequest{})
+	assert.NoError(t, err)
+	assert.Empty(t, lists)
+	_, err = r.TodoLists.Update(ctx, bob, listId, todo.UpdateListInput{Title: stringPtr("mine")}, 0)
+	assert.ErrorIs(t, err, todo.ErrNotFound)
+	_, err = r.GetRole(ctx, bob, listId)
+	assert.ErrorIs(t, err, todo.ErrNotFound)
+
+	// Viewers can read but not change the list.
+	addTestMember(t, r, listId, "bob", todo.RoleViewer)
+	_, err = r.TodoLists.GetById(ctx, bob, listId)
+	assert.NoError(t, err)
+	_, err = r.TodoLists.Update(ctx, bob, listId, todo.UpdateListInput{Title: stringPtr("mine")}, 0)
+	assert.ErrorIs(t, err, todo.ErrNotFound)
+
+	// Editors can change the list, owners can delete it.
+	assert.NoError(t, r.UpdateRole(ctx, listId, bob, todo.RoleEditor))
+	_, err = r.TodoLists.Update(ctx, bob, listId, todo.UpdateListInput{Title: stringPtr("weekly groceries")}, 1)
+	assert.NoError(t, err)
+	list, err = r.TodoLists.GetById(ctx, alice, listId)
+	assert.NoError(t, err)
+	assert.Equal(t, "weekly groceries", list.Title)
+	assert.Equal(t, 2, list.Version)
+	assert.False(t, list.UpdatedAt.Before(list.CreatedAt))
+
+	_, err = r.TodoLists.Update(ctx, alice, listId, todo.UpdateListInput{Title: stringPtr("stale")}, 1)
+	assert.ErrorIs(t, err, todo.ErrPreconditionFailed)
+	_, err = r.TodoLists.Delete(ctx, bob, listId, 0)
+	assert.ErrorIs(t, err, todo.ErrNotFound)
+	_, err = r.TodoLists.Delete(ctx, alice, listId, 1)
+	assert.ErrorIs(t, err, todo.ErrPreconditionFailed)
+	_, err = r.TodoLists.Delete(ctx, alice, listId, 2)
+	assert.NoError(t, err)
+
+	_, err = r.TodoLists.GetById(ctx, alice, listId)
+	assert.ErrorIs(t, err, todo.ErrNotFound)
+	_, err = r.GetRole(ctx, alice, listId)
+	assert.ErrorIs(t, err, todo.ErrNotFound)
+	_, err = r.TodoLists.Delete(ctx, alice, listId, 0)
+	assert.ErrorIs(t, err, todo.ErrNotFound)
+}
+
+func testMembers(t *testing.T, r *Repository) {
+	ctx := context.Background()
+	alice := createTestUser(t, r, "alice")
+	bob := createTestUser(t, r, "bob")
+	listId := createTestList(t, r, alice, "groceries")
+
+	memberId, err := r.ListMembers.Add(ctx, listId, todo.AddMemberInput{Username: "bob", Role: todo.RoleEditor})
+	assert.NoError(t, err)
+	assert.Equal(t, bob, memberId)
+	_, err = r.ListMembers.Add(ctx, listId, todo.AddMemberInput{Username: "bob", Role: todo.RoleViewer})
+	assert.ErrorIs(t, err, todo.ErrConflict)
+	_, err = r.ListMembers.Add(ctx, listId, todo.AddMemberInput{Username: "carol", Role: todo.RoleViewer})
+	assert.ErrorIs(t, err, todo.ErrNotFound)
+
+	members, err := r.ListMembers.GetAll(ctx, listId)
+	assert.NoError(t, err)
+	assert.Equal(t, []todo.ListMember{
+		{UserId: alice, Name: "alice", Username: "alice", Role: todo.RoleOwner},
+		{UserId: bob, Name: "bob", Username: "bob", Role: todo.RoleEditor},
+	}, members)
+	owners, err := r.CountOwners(ctx, listId)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, owners)
+
+	assert.NoError(t, r.Remove(ctx, listId, bob))
+	assert.ErrorIs(t, r.Remove(ctx, listId, bob), todo.ErrNotFound)
+	assert.ErrorIs(t, r.UpdateRole(ctx, listId, bob, todo.RoleOwner), todo.ErrNotFound)
+}
+
+func testInvites(t *testing.T, r *Repository) {
+	ctx := context.Background()
+	alice := createTestUser(t, r, "alice")
+	bob := createTestUser(t, r, "bob")
+	carol := createTestUser(t, r, "carol")
+	listId := createTestList(t, r, alice, "groceries")
+	once := 1
+	expiresAt := time.Now().Add(time.Hour)
+
+	inviteId, err := r.ListInvites.Create(ctx, todo.ListInvite{ListId: listId, Role: todo.RoleEditor, CreatedBy: alice,
+		MaxUses: &once, ExpiresAt: expiresAt}, "once")
+	assert.NoError(t, err)
+	_, err = r.ListInvites.Create(ctx, todo.ListInvite{ListId: listId, Role: todo.RoleViewer, CreatedBy: alice,
+		ExpiresAt: time.Now().Add(-time.Hour)}, "expired")
+	assert.NoError(t, err)
+
+	redeemedList, err := r.Redeem(ctx, "once", bob)
+	assert.NoError(t, err)
+	assert.Equal(t, listId, redeemedList)
+	role, err := r.GetRole(ctx, bob, listId)
+	assert.NoError(t, err)
+	assert.Equal(t, todo.RoleEditor, role)
+
+	_, err = r.Redeem(ctx, "once", carol)
+	assert.ErrorIs(t, err, todo.ErrNotFound)
+	_, err = r.Redeem(ctx, "expired", carol)
+	assert.ErrorIs(t, err, todo.ErrNotFound)
+
+	invites, err := r.ListInvites.GetAll(ctx, listId)
+	assert.NoError(t, err)
+	if assert.Len(t, invites, 2) {
+		assert.Equal(t, inviteId, invites[0].Id)
+		assert.Equal(t, 1, invites[0].Uses)
+	}
+
+	_, err = r.ListInvites.Create(ctx, todo.ListInvite{ListId: listId, Role: todo.RoleViewer, CreatedBy: alice,
+		ExpiresAt: expiresAt}, "open")
+	assert.NoError(t, err)
+	_, err = r.Redeem(ctx, "open", bob)
+	assert.ErrorIs(t, err, todo.ErrConflict)
+
+	assert.NoError(t, r.ListInvites.Revoke(ctx, listId, inviteId))
+	assert.ErrorIs(t, r.ListInvites.Revoke(ctx, listId+1, inviteId), todo.ErrNotFound)
+
+	// Invites to a list in the trash cannot be redeemed.
+	if _, err = r.TodoLists.Delete(ctx, alice, listId, 0); err != nil {
+		t.Fatal(err)
+	}
+	_, err = r.Redeem(ctx, "open", carol)
+	assert.ErrorIs(t, err, todo.ErrNotFound)
+}
+
+func testItems(t *testing.T, r *Repository) {
+	ctx := context.Background()
+	alice := createTestUser(t, r, "alice")
+	bob := createTestUser(t, r, "bob")
+	listId := createTestList(t, r, alice, "groceries")
+	due := time.Date(2030, 1, 2, 10, 0, 0, 0, time.UTC)
+
+	milk := createTestItem(t, r, alice, listId, todo.TodoItem{Title: "milk", Priority: todo.PriorityHigh})
+	bread := createTestItem(t, r, alice, listId, todo.TodoItem{Title: "bread", DueAt: &due})
+
+	item, err := r.TodoItems.GetById(ctx, alice, bread)
+	assert.NoError(t, err)
+	assert.Equal(t, listId, item.ListId)
+	assert.Equal(t, 2.0, item.Position)
+	assert.Equal(t, 1, item.Version)
+	if assert.NotNil(t, item.DueAt) {
+		assert.True(t, due.Equal(*item.DueAt))
+	}
+
+	_, err = r.TodoItems.GetById(ctx, bob, milk)
+	assert.ErrorIs(t, err, todo.ErrNotFound)
+	_, err = r.TodoItems.Update(ctx, bob, milk, todo.UpdateItemInput{Title: stringPtr("mine")}, 0)
+	assert.ErrorIs(t, err, todo.ErrNotFound)
+	items, _, err := r.TodoItems.GetAllByUser(ctx, bob, todo.ItemFilter{}, todo.PageRequest{})
+	assert.NoError(t, err)
+	assert.Empty(t, items)
+
+	done := true
+	_, err = r.TodoItems.Update(ctx, alice, milk, todo.UpdateItemInput{Done: &done}, 1)
+	assert.NoError(t, err)
+	_, err = r.TodoItems.Update(ctx, alice, milk, todo.UpdateItemInput{Title: stringPtr("oat milk")}, 1)
+	assert.ErrorIs(t, err, todo.ErrPreconditionFailed)
+	item, err = r.TodoItems.GetById(ctx, alice, milk)
+	assert.NoError(t, err)
+	assert.True(t, item.Done)
+	assert.NotNil(t, item.CompletedAt)
+	assert.Equal(t, 2, item.Version)
+
+	items, _, err = r.TodoItems.GetAll(ctx, alice, listId, todo.ItemFilter{Done: &done}, todo.PageRequest{})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"milk"}, itemTitles(items))
+	items, _, err = r.TodoItems.GetAll(ctx, alice, listId, todo.ItemFilter{Query: "BRE"}, todo.PageRequest{})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"bread"}, itemTitles(items))
+
+	// Items without a due date come last in the default order of all items.
+	items, _, err = r.TodoItems.GetAllByUser(ctx, alice, todo.ItemFilter{}, todo.PageRequest{})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"bread", "milk"}, itemTitles(items))
+	items, _, err = r.TodoItems.GetAllByUser(ctx, alice, todo.ItemFilter{}, todo.PageRequest{Sort: "-" + todo.SortPriority})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"milk", "bread"}, itemTitles(items))
+
+	// Viewers see the items of the list but cannot change them.
+	addTestMember(t, r, listId, "bob", todo.RoleViewer)
+	_, err = r.TodoItems.GetById(ctx, bob, milk)
+	assert.NoError(t, err)
+	_, err = r.TodoItems.Delete(ctx, bob, milk, 0)
+	assert.ErrorIs(t, err, todo.ErrNotFound)
+
+	_, err = r.TodoItems.Delete(ctx, alice, milk, 2)
+	assert.NoError(t, err)
+	_, err = r.TodoItems.GetById(ctx, alice, milk)
+	assert.ErrorIs(t, err, todo.ErrNotFound)
+	items, _, err = r.TodoItems.GetAll(ctx, alice, listId, todo.ItemFilter{}, todo.PageRequest{})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"bread"}, itemTitles(items))
+}
+
+func testItemPages(t *testing.T, r *Repository) {
+	ctx := context.Background()
+	alice := createTestUser(t, r, "alice")
+	listId := createTestList(t, r, alice, "groceries")
+	for _, title := range []string{"cheese", "apples", "eggs", "bread", "dates"} {
+		createTestItem(t, r, alice, listId, todo.TodoItem{Title: title})
+	}
+
+	var titles []string
+	page := todo.PageRequest{Limit: 2, Sort: todo.SortTitle}
+	for pages := 0; pages < 5; pages++ {
+		items, next, err := r.TodoItems.GetAll(ctx, alice, listId, todo.ItemFilter{}, page)
+		if !assert.NoError(t, err) {
+			return
+		}
+		assert.LessOrEqual(t, len(items), 2)
+		titles = append(titles, itemTitles(items)...)
+		if next == "" {
+			break
+		}
+		page.Cursor = next
+	}
+	assert.Equal(t, []string{"apples", "bread", "cheese", "dates", "eggs"}, titles)
+
+	items, next, err := r.TodoItems.GetAll(ctx, alice, listId, todo.ItemFilter{}, todo.PageRequest{Limit: 3, Sort: "-" + todo.SortPosition})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"dates", "bread", "eggs"}, itemTitles(items))
+	items, _, err = r.TodoItems.GetAll(ctx, alice, listId, todo.ItemFilter{},
+		todo.PageRequest{Limit: 3, Sort: "-" + todo.SortPosition, Cursor: next})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"apples", "cheese"}, itemTitles(items))
+
+	_, _, err = r.TodoItems.GetAll(ctx, alice, listId, todo.ItemFilter{}, todo.PageRequest{Sort: todo.SortTitle, Cursor: next})
+	assert.ErrorIs(t, err, todo.ErrValidation)
+
+	lists, next, err := r.TodoLists.GetAll(ctx, alice, todo.ListFilter{Query: "GROC"}, todo.PageRequest{Limit: 1})
+	assert.NoError(t, err)
+	assert.Len(t, lists, 1)
+	assert.Empty(t, next)
+
+	// A due date cursor stands for an instant, whatever offset it is written
+	// with.
+	dueList := createTestList(t, r, alice, "deadlines")
+	cet := time.FixedZone("CET", 60*60)
+	for _, due := range []time.Time{
+		time.Date(2030, 1, 2, 10, 0, 0, 0, cet),
+		time.Date(2030, 1, 2, 9, 30, 0, 0, time.UTC),
+		time.Date(2030, 1, 2, 10, 30, 0, 0, time.UTC),
+	} {
+		createTestItem(t, r, alice, dueList, todo.TodoItem{Title: due.UTC().Format("15:04"), DueAt: &due})
+	}
+	createTestItem(t, r, alice, dueList, todo.TodoItem{Title: "someday"})
+	items, next, err = r.TodoItems.GetAll(ctx, alice, dueList, todo.ItemFilter{}, todo.PageRequest{Limit: 1, Sort: todo.SortDue})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"09:00"}, itemTitles(items))
+	after, err := decodeCursor(next)
+	if err != nil {
+		t.Fatal(err)
+	}
+	value, err := time.Parse(time.RFC3339Nano, after.Value)
+	if err != nil {
+		t.Fatal(err)
+	}
+	after.Value = value.In(time.FixedZone("EST", -5*60*60)).Format(time.RFC3339Nano)
+	items, _, err = r.TodoItems.GetAll(ctx, alice, dueList, todo.ItemFilter{},
+		todo.PageRequest{Sort: todo.SortDue, Cursor: encodeCursor(after)})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"09:30", "10:30", "someday"}, itemTitles(items))
+}
+
+func testMove(t *testing.T, r *Repository) {
+	ctx := context.Background()
+	alice := createTestUser(t, r, "alice")
+	listId := createTestList(t, r, alice, "groceries")
+	otherId := createTestList(t, r, alice, "hardware")
+	a := createTestItem(t, r, alice, listId, todo.TodoItem{Title: "a"})
+	b := createTestItem(t, r, alice, listId, todo.TodoItem{Title: "b"})
+	c := createTestItem(t, r, alice, listId, todo.TodoItem{Title: "c"})
+	nails := createTestItem(t, r, alice, otherId, todo.TodoItem{Title: "nails"})
+
+	titles := func(listId int) []string {
+		items, _, err := r.TodoItems.GetAll(ctx, alice, listId, todo.ItemFilter{}, todo.PageRequest{})
+		assert.NoError(t, err)
+		return itemTitles(items)
+	}
+
+	position, err := r.Move(ctx, alice, c, listId, todo.MoveItemInput{AfterId: &a})
+	assert.NoError(t, err)
+	assert.Equal(t, 1.5, position)
+	assert.Equal(t, []string{"a", "c", "b"}, titles(listId))
+
+	_, err = r.Move(ctx, alice, b, listId, todo.MoveItemInput{BeforeId: &a})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"b", "a", "c"}, titles(listId))
+
+	// Moving between the same neighbours again and again exhausts the gap
+	// between them, which renumbers the list.
+	for i := 0; i < 60; i++ {
+		moved := c
+		if i%2 == 1 {
+			moved = a
+		}
+		if _, err = r.Move(ctx, alice, moved, listId, todo.MoveItemInput{AfterId: &b}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	assert.Len(t, titles(listId), 3)
+
+	_, err = r.Move(ctx, alice, a, otherId, todo.MoveItemInput{})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"nails", "a"}, titles(otherId))
+	item, err := r.TodoItems.GetById(ctx, alice, a)
+	assert.NoError(t, err)
+	assert.Equal(t, otherId, item.ListId)
+
+	_, err = r.Move(ctx, alice, b, listId, todo.MoveItemInput{AfterId: &nails})
+	assert.ErrorIs(t, err, todo.ErrValidation)
+
+	activities, _, err := r.Activity.GetByItem(ctx, a, todo.PageRequest{Limit: 1})
+	assert.NoError(t, err)
+	if assert.Len(t, activities, 1) {
+		assert.Equal(t, todo.ActionMove, activities[0].Action)
+		assert.Equal(t, otherId, activities[0].ListId)
+		assert.Contains(t, activities[0].Changes, "list_id")
+	}
+}
+
+func testTrash(t *testing.T, r *Repository) {
+	ctx := context.Background()
+	alice := createTestUser(t, r, "alice")
+	bob := createTestUser(t, r, "bob")
+	listId := createTestList(t, r, alice, "groceries")
+	milk := createTestItem(t, r, alice, listId, todo.TodoItem{Title: "milk"})
+	bread := createTestItem(t, r, alice, listId, todo.TodoItem{Title: "bread"})
+	addTestMember(t, r, listId, "bob", todo.RoleEditor)
+
+	_, err := r.TodoItems.Delete(ctx, bob, milk, 0)
+	assert.NoError(t, err)
+	entries, err := r.Trash.GetAll(ctx, bob)
+	assert.NoError(t, err)
+	if assert.Len(t, entries, 1) {
+		assert.Equal(t, todo.TrashEntry{Type: todo.TrashItem, Id: milk, ListId: listId, Title: "milk",
+			DeletedAt: entries[0].DeletedAt}, entries[0])
+	}
+	assert.NoError(t, r.RestoreItem(ctx, alice, milk))
+	assert.ErrorIs(t, r.RestoreItem(ctx, alice, milk), todo.ErrNotFound)
+
+	// Items deleted on their own stay in the trash when their list is
+	// restored.
+	_, err = r.TodoItems.Delete(ctx, alice, bread, 0)
+	assert.NoError(t, err)
+	time.Sleep(10 * time.Millisecond)
+	_, err = r.TodoLists.Delete(ctx, alice, listId, 0)
+	assert.NoError(t, err)
+
+	entries, err = r.Trash.GetAll(ctx, alice)
+	assert.NoError(t, err)
+	assert.Equal(t, []todo.TrashEntry{{Type: todo.TrashList, Id: listId, ListId: listId, Title: "groceries",
+		DeletedAt: entries[0].DeletedAt}}, entries)
+	entries, err = r.Trash.GetAll(ctx, bob)
+	assert.NoError(t, err)
+	assert.Empty(t, entries)
+
+	assert.ErrorIs(t, r.RestoreList(ctx, bob, listId), todo.ErrNotFound)
+	assert.ErrorIs(t, r.RestoreItem(ctx, alice, milk), todo.ErrNotFound)
+	assert.NoError(t, r.RestoreList(ctx, alice, listId))
+	items, _, err := r.TodoItems.GetAll(ctx, alice, listId, todo.ItemFilter{}, todo.PageRequest{})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"milk"}, itemTitles(items))
+
+	purged, err := r.Purge(ctx, time.Now().Add(time.Hour))
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), purged)
+	assert.ErrorIs(t, r.RestoreItem(ctx, alice, bread), todo.ErrNotFound)
+
+	_, err = r.TodoLists.Delete(ctx, alice, listId, 0)
+	assert.NoError(t, err)
+	purged, err = r.Purge(ctx, time.Now().Add(-time.Hour))
+	assert.NoError(t, err)
+	assert.Equal(t, int64(0), purged)
+	purged, err = r.Purge(ctx, time.Now().Add(time.Hour))
+	assert.NoError(t, err)
+	assert.Equal(t, int64(2), purged)
+	assert.ErrorIs(t, r.RestoreList(ctx, alice, listId), todo.ErrNotFound)
+}
+
+func testLabels(t *testing.T, r *Repository) {
+	ctx := context.Background()
+	alice := createTestUser(t, r, "alice")
+	bob := createTestUser(t, r, "bob")
+	listId := createTestList(t, r, alice, "chores")
+	dishes := createTestItem(t, r, alice, listId, todo.TodoItem{Title: "dishes"})
+	laundry := createTestItem(t, r, alice, listId, todo.TodoItem{Title: "laundry"})
+
+	home, err := r.Labels.Create(ctx, alice, todo.Label{Name: "home", Color: todo.DefaultLabelColor})
+	assert.NoError(t, err)
+	urgent, err := r.Labels.Create(ctx, alice, todo.Label{Name: "urgent", Color: "#ff0000"})
+	assert.NoError(t, err)
+	_, err = r.Labels.Create(ctx, alice, todo.Label{Name: "home", Color: todo.DefaultLabelColor})
+	assert.ErrorIs(t, err, todo.ErrConflict)
+	_, err = r.Labels.Create(ctx, bob, todo.Label{Name: "home", Color: todo.DefaultLabelColor})
+	assert.NoError(t, err)
+	assert.ErrorIs(t, r.Labels.Update(ctx, alice, urgent, todo.UpdateLabelInput{Name: stringPtr("home")}), todo.ErrConflict)
+	assert.ErrorIs(t, r.Labels.Update(ctx, bob, urgent, todo.UpdateLabelInput{Name: stringPtr("later")}), todo.ErrNotFound)
+	_, err = r.Labels.GetById(ctx, bob, home)
+	assert.ErrorIs(t, err, todo.ErrNotFound)
+
+	assert.NoError(t, r.Attach(ctx, dishes, home))
+	assert.NoError(t, r.Attach(ctx, dishes, home))
+	assert.NoError(t, r.Attach(ctx, dishes, urgent))
+	assert.NoError(t, r.Attach(ctx, laundry, home))
+
+	labels, err := r.Labels.GetByItem(ctx, alice, dishes)
+	assert.NoError(t, err)
+	assert.Equal(t, []todo.Label{
+		{Id: home, Name: "home", Color: todo.DefaultLabelColor},
+		{Id: urgent, Name: "urgent", Color: "#ff0000"},
+	}, labels)
+
+	items, _, err := r.TodoItems.GetAll(ctx, alice, listId, todo.ItemFilter{Labels: []string{"home", "urgent"}}, todo.PageRequest{})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"dishes", "laundry"}, itemTitles(items))
+	items, _, err = r.TodoItems.GetAll(ctx, alice, listId,
+		todo.ItemFilter{Labels: []string{"home", "urgent"}, AllLabels: true}, todo.PageRequest{})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"dishes"}, itemTitles(items))
+
+	assert.ErrorIs(t, r.Detach(ctx, bob, dishes, urgent), todo.ErrNotFound)
+	assert.NoError(t, r.Detach(ctx, alice, dishes, urgent))
+	assert.ErrorIs(t, r.Detach(ctx, alice, dishes, urgent), todo.ErrNotFound)
+
+	assert.NoError(t, r.Labels.Delete(ctx, alice, home))
+	labels, err = r.Labels.GetByItem(ctx, alice, laundry)
+	assert.NoError(t, err)
+	assert.Empty(t, labels)
+	labels, err = r.Labels.GetAll(ctx, alice)
+	assert.NoError(t, err)
+	assert.Equal(t, []todo.Label{{Id: urgent, Name: "urgent", Color: "#ff0000"}}, labels)
+}
+
+func testSubtasks(t *testing.T, r *Repository) {
+	ctx := context.Background()
+	alice := createTestUser(t, r, "alice")
+	listId := createTestList(t, r, alice, "trip")
+	packing := createTestItem(t, r, alice, listId, todo.TodoItem{Title: "packing"})
+	other := createTestItem(t, r, alice, listId, todo.TodoItem{Title: "tickets"})
+
+	socks, err := r.Subtasks.Create(ctx, packing, todo.Subtask{Title: "socks"})
+	assert.NoError(t, err)
+	_, err = r.Subtasks.Create(ctx, packing, todo.Subtask{Title: "shirts"})
+	assert.NoError(t, err)
+	_, err = r.Subtasks.Create(ctx, packing+other, todo.Subtask{Title: "nowhere"})
+	assert.ErrorIs(t, err, todo.ErrConflict)
+
+	done := true
+	assert.NoError(t, r.Subtasks.Update(ctx, packing, socks, todo.UpdateSubtaskInput{Done: &done}))
+	assert.ErrorIs(t, r.Subtasks.Update(ctx, other, socks, todo.UpdateSubtaskInput{Done: &done}), todo.ErrNotFound)
+
+	doneCount, total, err := r.Progress(ctx, packing)
+	assert.NoError(t, err)
+	assert.Equal(t, [2]int{1, 2}, [2]int{doneCount, total})
+
+	// Subtasks are part of the item as clients see it.
+	item, err := r.TodoItems.GetById(ctx, alice, packing)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, item.SubtasksDone)
+	assert.Equal(t, 2, item.SubtasksTotal)
+	assert.Equal(t, 4, item.Version)
+
+	assert.ErrorIs(t, r.Subtasks.Delete(ctx, other, socks), todo.ErrNotFound)
+	assert.NoError(t, r.Subtasks.Delete(ctx, packing, socks))
+	subtasks, err := r.Subtasks.GetAll(ctx, packing)
+	assert.NoError(t, err)
+	if assert.Len(t, subtasks, 1) {
+		assert.Equal(t, "shirts", subtasks[0].Title)
+	}
+}
+
+func testSeries(t *testing.T, r *Repository) {
+	ctx := context.Background()
+	alice := createTestUser(t, r, "alice")
+	listId := createTestList(t, r, alice, "plants")
+	due := time.Date(2030, 1, 2, 10, 0, 0, 0, time.UTC)
+	water := createTestItem(t, r, alice, listId, todo.TodoItem{Title: "water", DueAt: &due, RRule: "FREQ=WEEKLY"})
+	if _, err := r.Subtasks.Create(ctx, water, todo.Subtask{Title: "ficus", Done: true}); err != nil {
+		t.Fatal(err)
+	}
+
+	series, err := r.GetSeries(ctx, water)
+	assert.NoError(t, err)
+	assert.Equal(t, "FREQ=WEEKLY", series.RRule)
+	item, err := r.TodoItems.GetById(ctx, alice, water)
+	assert.NoError(t, err)
+	assert.Equal(t, "FREQ=WEEKLY", item.RRule)
+
+	nextDue := due.AddDate(0, 0, 7)
+	next := todo.TodoItem{ListId: listId, Title: "water", DueAt: &nextDue, SeriesId: &series.Id}
+	done := true
+	complete := todo.UpdateItemInput{Done: &done}
+	_, err = r.CompleteOccurrence(ctx, alice, water, complete, next, item.Version+1)
+	assert.ErrorIs(t, err, todo.ErrPreconditionFailed)
+	bob := createTestUser(t, r, "bob")
+	addTestMember(t, r, listId, "bob", todo.RoleViewer)
+	_, err = r.CompleteOccurrence(ctx, bob, water, complete, next, 0)
+	assert.ErrorIs(t, err, todo.ErrNotFound)
+	nextId, err := r.CompleteOccurrence(ctx, alice, water, complete, next, item.Version)
+	assert.NoError(t, err)
+	assert.NotZero(t, nextId)
+	again, err := r.CompleteOccurrence(ctx, alice, water, complete, next, 0)
+	assert.NoError(t, err)
+	assert.Zero(t, again)
+
+	item, err = r.TodoItems.GetById(ctx, alice, nextId)
+	assert.NoError(t, err)
+	assert.False(t, item.Done)
+	assert.Equal(t, 0, item.SubtasksDone)
+	assert.Equal(t, 1, item.SubtasksTotal)
+
+	assert.NoError(t, r.UpdateSeries(ctx, alice, series.Id, todo.UpdateSeriesInput{Title: stringPtr("water plants")}))
+	items, _, err := r.TodoItems.GetAll(ctx, alice, listId, todo.ItemFilter{}, todo.PageRequest{})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"water", "water plants"}, itemTitles(items))
+	activities, _, err := r.Activity.GetByItem(ctx, nextId, todo.PageRequest{Limit: 1})
+	assert.NoError(t, err)
+	if assert.Len(t, activities, 1) {
+		assert.Equal(t, todo.Changes{"title": {From: []byte(`"water"`), To: []byte(`"water plants"`)}}, activities[0].Changes)
+	}
+
+	// The rule is part of every occurrence, done ones included.
+	completed, err := r.TodoItems.GetById(ctx, alice, water)
+	assert.NoError(t, err)
+	assert.NoError(t, r.UpdateSeries(ctx, alice, series.Id, todo.UpdateSeriesInput{RRule: stringPtr("FREQ=WEEKLY;INTERVAL=1")}))
+	assert.NoError(t, r.UpdateSeries(ctx, alice, series.Id, todo.UpdateSeriesInput{RRule: stringPtr("FREQ=WEEKLY")}))
+	item, err = r.TodoItems.GetById(ctx, alice, water)
+	assert.NoError(t, err)
+	assert.Greater(t, item.Version, completed.Version)
+
+	assert.NoError(t, r.StopSeries(ctx, alice, series.Id))
+	activities, _, err = r.Activity.GetByItem(ctx, nextId, todo.PageRequest{Limit: 1})
+	assert.NoError(t, err)
+	if assert.Len(t, activities, 1) {
+		assert.Equal(t, todo.Changes{"rrule": {From: []byte(`"FREQ=WEEKLY"`), To: []byte("null")}}, activities[0].Changes)
+	}
+	stopped, err := r.TodoItems.GetById(ctx, alice, water)
+	assert.NoError(t, err)
+	assert.Greater(t, stopped.Version, item.Version)
+	item, err = r.TodoItems.GetById(ctx, alice, nextId)
+	assert.NoError(t, err)
+	assert.Empty(t, item.RRule)
+	_, err = r.SetSeriesRule(ctx, alice, nextId, "FREQ=DAILY", nextDue, item.Version+1)
+	assert.ErrorIs(t, err, todo.ErrPreconditionFailed)
+	resumed, err := r.SetSeriesRule(ctx, alice, nextId, "FREQ=DAILY", nextDue, item.Version)
+	assert.NoError(t, err)
+	assert.Equal(t, series.Id, resumed)
+	resumedItem, err := r.TodoItems.GetById(ctx, alice, nextId)
+	assert.NoError(t, err)
+	assert.Equal(t, "FREQ=DAILY", resumedItem.RRule)
+	assert.Greater(t, resumedItem.Version, item.Version)
+}
+
+func testForeignSeries(t *testing.T, r *Repository) {
+	ctx := context.Background()
+	alice := createTestUser(t, r, "alice")
+	listId := createTestList(t, r, alice, "plants")
+	due := time.Date(2030, 1, 2, 10, 0, 0, 0, time.UTC)
+	water := createTestItem(t, r, alice, listId, todo.TodoItem{Title: "water", DueAt: &due, RRule: "FREQ=WEEKLY"})
+	series, err := r.GetSeries(ctx, water)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	mallory := createTestUser(t, r, "mallory")
+	malloryList := createTestList(t, r, mallory, "mine")
+	joined := createTestItem(t, r, mallory, malloryList, todo.TodoItem{Title: "join", SeriesId: &series.Id})
+	item, err := r.TodoItems.GetById(ctx, mallory, joined)
+	assert.NoError(t, err)
+	assert.Nil(t, item.SeriesId)
+
+	bob := createTestUser(t, r, "bob")
+	addTestMember(t, r, listId, "bob", todo.RoleViewer)
+	for _, userId := range []int{mallory, bob} {
+		err = r.UpdateSeries(ctx, userId, series.Id, todo.UpdateSeriesInput{Title: stringPtr("stolen"),
+			RRule: stringPtr("FREQ=DAILY")})
+		assert.ErrorIs(t, err, todo.ErrNotFound)
+		assert.ErrorIs(t, r.StopSeries(ctx, userId, series.Id), todo.ErrNotFound)
+	}
+
+	item, err = r.TodoItems.GetById(ctx, alice, water)
+	assert.NoError(t, err)
+	assert.Equal(t, "water", item.Title)
+	assert.Equal(t, "FREQ=WEEKLY", item.RRule)
+}
+
+func testActivityAndUndo(t *testing.T, r *Repository) {
+	ctx := context.Background()
+	alice := createTestUser(t, r, "alice")
+	bob := createTestUser(t, r, "bob")
+	listId := createTestList(t, r, alice, "groceries")
+	milk := createTestItem(t, r, alice, listId, todo.TodoItem{Title: "milk"})
+	expiresAt := time.Now().Add(time.Hour)
+
+	updateId, err := r.TodoLists.Update(ctx, alice, listId, todo.UpdateListInput{Title: stringPtr("weekly groceries")}, 0)
+	assert.NoError(t, err)
+	activities, _, err := r.Activity.GetByList(ctx, listId, todo.PageRequest{})
+	assert.NoError(t, err)
+	if assert.Len(t, activities, 3) {
+		assert.Equal(t, updateId, activities[0].Id)
+		assert.Equal(t, "alice", activities[0].Username)
+		assert.Equal(t, todo.ActionUpdate, activities[0].Action)
+		assert.Equal(t, todo.Changes{"title": {From: []byte(`"groceries"`), To: []byte(`"weekly groceries"`)}},
+			activities[0].Changes)
+		assert.Equal(t, todo.ActionCreate, activities[1].Action)
+		assert.Equal(t, &milk, activities[1].ItemId)
+		assert.Equal(t, todo.ActionCreate, activities[2].Action)
+		assert.Nil(t, activities[2].ItemId)
+	}
+	activities, next, err := r.Activity.GetByList(ctx, listId, todo.PageRequest{Limit: 2})
+	assert.NoError(t, err)
+	assert.Len(t, activities, 2)
+	activities, _, err = r.Activity.GetByList(ctx, listId, todo.PageRequest{Limit: 2, Cursor: next})
+	assert.NoError(t, err)
+	assert.Len(t, activities, 1)
+
+	assert.NoError(t, r.Undo.Create(ctx, alice, updateId, "title", expiresAt))
+	assert.ErrorIs(t, r.Undo.Undo(ctx, bob, "title"), todo.ErrNotFound)
+	assert.NoError(t, r.Undo.Undo(ctx, alice, "title"))
+	assert.ErrorIs(t, r.Undo.Undo(ctx, alice, "title"), todo.ErrNotFound)
+	list, err := r.TodoLists.GetById(ctx, alice, listId)
+	assert.NoError(t, err)
+	assert.Equal(t, "groceries", list.Title)
+
+	deleteId, err := r.TodoItems.Delete(ctx, alice, milk, 0)
+	assert.NoError(t, err)
+	activities, _, err = r.Activity.GetByItem(ctx, milk, todo.PageRequest{Limit: 1})
+	assert.NoError(t, err)
+	if assert.Len(t, activities, 1) {
+		assert.Equal(t, todo.ActionDelete, activities[0].Action)
+		assert.Equal(t, todo.Change{From: []byte(`"milk"`), To: []byte("null")}, activities[0].Changes["title"])
+	}
+	assert.NoError(t, r.Undo.Create(ctx, alice, deleteId, "delete", expiresAt))
+	assert.NoError(t, r.Undo.Undo(ctx, alice, "delete"))
+	_, err = r.TodoItems.GetById(ctx, alice, milk)
+	assert.NoError(t, err)
+
+	// Changes cannot be undone once someone changed the entity again.
+	done := true
+	doneId, err := r.TodoItems.Update(ctx, alice, milk, todo.UpdateItemInput{Done: &done}, 0)
+	assert.NoError(t, err)
+	_, err = r.TodoItems.Update(ctx, alice, milk, todo.UpdateItemInput{Title: stringPtr("oat milk")}, 0)
+	assert.NoError(t, err)
+	assert.NoError(t, r.Undo.Create(ctx, alice, doneId, "done", expiresAt))
+	assert.ErrorIs(t, r.Undo.Undo(ctx, alice, "done"), todo.ErrConflict)
+
+	activities, _, err = r.Activity.GetByItem(ctx, milk, todo.PageRequest{Limit: 1})
+	assert.NoError(t, err)
+	if assert.Len(t, activities, 1) {
+		assert.Equal(t, todo.ActionUpdate, activities[0].Action)
+		assert.Contains(t, activities[0].Changes, "title")
+	}
+}
+
+func testSearch(t *testing.T, r *Repository) {
+	ctx := context.Background()
+	alice := createTestUser(t, r, "alice")
+	bob := createTestUser(t, r, "bob")
+	listId := createTestList(t, r, alice, "groceries")
+	milk := createTestItem(t, r, alice, listId, todo.TodoItem{Title: "buy milk", Description: "two liters"})
+	createTestItem(t, r, alice, listId, todo.TodoItem{Title: "bread", Description: "and milk for the cat"})
+	createTestItem(t, r, alice, listId, todo.TodoItem{Title: "<script>alert(1)</script> butter"})
+
+	results, err := r.Search.Search(ctx, alice, todo.SearchQuery{Query: "milk"})
+	assert.NoError(t, err)
+	if assert.Len(t, results, 2) {
+		assert.Equal(t, milk, results[0].Id)
+		assert.Equal(t, todo.SearchItem, results[0].Type)
+		assert.Equal(t, listId, results[0].ListId)
+		assert.Contains(t, results[0].Snippet, "<b>milk</b>")
+		assert.Greater(t, results[0].Rank, results[1].Rank)
+	}
+
+	results, err = r.Search.Search(ctx, alice, todo.SearchQuery{Query: "milk -cat"})
+	assert.NoError(t, err)
+	assert.Len(t, results, 1)
+	results, err = r.Search.Search(ctx, alice, todo.SearchQuery{Query: "groceries", Type: todo.SearchList})
+	assert.NoError(t, err)
+	assert.Len(t, results, 1)
+	results, err = r.Search.Search(ctx, alice, todo.SearchQuery{Query: "milk", Limit: 1})
+	assert.NoError(t, err)
+	assert.Len(t, results, 1)
+	results, err = r.Search.Search(ctx, bob, todo.SearchQuery{Query: "milk"})
+	assert.NoError(t, err)
+	assert.Empty(t, results)
+	results, err = r.Search.Search(ctx, alice, todo.SearchQuery{Query: "bread or butter"})
+	assert.NoError(t, err)
+	assert.Len(t, results, 2)
+
+	// Changes of titles are found right away.
+	_, err = r.TodoItems.Update(ctx, alice, milk, todo.UpdateItemInput{Title: stringPtr("buy oat milk")}, 0)
+	assert.NoError(t, err)
+	results, err = r.Search.Search(ctx, alice, todo.SearchQuery{Query: `"oat milk"`})
+	assert.NoError(t, err)
+	if assert.Len(t, results, 1) {
+		assert.Equal(t, milk, results[0].Id)
+	}
+
+	// Snippets are HTML, so the text around the highlights is escaped.
+	results, err = r.Search.Search(ctx, alice, todo.SearchQuery{Query: "butter"})
+	assert.NoError(t, err)
+	if assert.Len(t, results, 1) {
+		assert.NotContains(t, results[0].Snippet, "<script>")
+		assert.Contains(t, results[0].Snippet, "&lt;script&gt;")
+		assert.Contains(t, results[0].Snippet, "<b>butter</b>")
+	}
+}
+
+func testReminders(t *testing.T, r *Repository) {
+	ctx := context.Background()
+	alice := createTestUser(t, r, "alice")
+	listId := createTestList(t, r, alice, "errands")
+	remindAt := time.Now().Add(-time.Hour)
+	later := time.Now().Add(time.Hour)
+	post := createTestItem(t, r, alice, listId, todo.TodoItem{Title: "post office", RemindAt: &remindAt})
+	createTestItem(t, r, alice, listId, todo.TodoItem{Title: "bank", RemindAt: &later})
+	failing := createTestItem(t, r, alice, listId, todo.TodoItem{Title: "fail", RemindAt: &remindAt})
+	// Completed items are never reminded of.
+	finished := createTestItem(t, r, alice, listId, todo.TodoItem{Title: "finished", RemindAt: &remindAt})
+	done := true
+	if _, err := r.TodoItems.Update(ctx, alice, finished, todo.UpdateItemInput{Done: &done}, 0); err != nil {
+		t.Fatal(err)
+	}
+	versions := make(map[int]int)
+	for _, id := range []int{post, failing} {
+		item, err := r.TodoItems.GetById(ctx, alice, id)
+		if err != nil {
+			t.Fatal(err)
+		}
+		versions[id] = item.Version
+	}
+
+	var delivered []todo.Reminder
+	var deliver func(ctx context.Context, reminder todo.Reminder) error
+	deliver = func(ctx context.Context, reminder todo.Reminder) error {
+		if reminder.Title == "fail" {
+			return assert.AnError
+		}
+		// Reminders being delivered are leased, so another scheduler polling
+		// meanwhile leaves them alone.
+		n, err := r.ProcessDue(ctx, 10, time.Hour, deliver)
+		assert.NoError(t, err)
+		assert.Zero(t, n)
+		delivered = append(delivered, reminder)
+		return nil
+	}
+	n, err := r.ProcessDue(ctx, 10, time.Hour, deliver)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, n)
+	if assert.Len(t, delivered, 1) {
+		assert.Equal(t, post, delivered[0].ItemId)
+		assert.Equal(t, []todo.ReminderRecipient{{UserId: alice, Name: "alice", Username: "alice"}},
+			delivered[0].Recipients)
+	}
+
+	// Delivered reminders are not sent again, failed ones only after the
+	// retry delay.
+	n, err = r.ProcessDue(ctx, 10, time.Hour, deliver)
+	assert.NoError(t, err)
+	assert.Zero(t, n)
+
+	// Reminder bookkeeping is not a change of the item.
+	for id, version := range versions {
+		item, err := r.TodoItems.GetById(ctx, alice, id)
+		assert.NoError(t, err)
+		assert.Equal(t, version, item.Version)
+	}
+}
+
+func testUnitsOfWork(t *testing.T, r *Repository) {
+	ctx := context.Background()
+	alice := createTestUser(t, r, "alice")
+
+	err := r.WithinTx(ctx, TxOptions{}, func(ctx context.Context) error {
+		if _, err := r.TodoLists.Create(ctx, alice, todo.TodoList{Title: "discarded"}); err != nil {
+			return err
+		}
+		return assert.AnError
+	})
+	assert.ErrorIs(t, err, assert.AnError)
+
+	err = r.WithinTx(ctx, TxOptions{}, func(ctx context.Context) error {
+		if _, err := r.TodoLists.Create(ctx, alice, todo.TodoList{Title: "kept"}); err != nil {
+			return err
+		}
+		inner := r.WithinTx(ctx, TxOptions{}, func(ctx context.Context) error {
+			if _, err := r.TodoLists.Create(ctx, alice, todo.TodoList{Title: "nested"}); err != nil {
+				return err
+			}
+			return assert.AnError
+		})
+		assert.ErrorIs(t, inner, assert.AnError)
+		// A failed repository call only discards its own changes.
+		_, err := r.TodoItems.Create(ctx, alice, 1_000_000, todo.TodoItem{Title: "orphan"})
+		assert.Error(t, err)
+		return nil
+	})
+	assert.NoError(t, err)
+
+	lists, _, err := r.TodoLists.GetAll(ctx, alice, todo.ListFilter{}, todo.PageRequest{})
+	assert.NoError(t, err)
+	if assert.Len(t, lists, 1) {
+		assert.Equal(t, "kept", lists[0].Title)
+	}
+}
+
+func testConcurrentWrites(t *testing.T, r *Repository) {
+	ctx := context.Background()
+	alice := createTestUser(t, r, "alice")
+	listId := createTestList(t, r, alice, "groceries")
+
+	const writers = 10
+	var wg sync.WaitGroup
+	errs := make(chan error, writers)
+	for i := 0; i < writers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			itemId, err := r.TodoItems.Create(ctx, alice, listId, todo.TodoItem{Title: "item"})
+			if err == nil {
+				_, err = r.TodoItems.Update(ctx, alice, itemId, todo.UpdateItemInput{Title: stringPtr("updated")}, 1)
+			}
+			errs <- err
+		}()
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		assert.NoError(t, err)
+	}
+
+	items, _, err := r.TodoItems.GetAll(ctx, alice, listId, todo.ItemFilter{}, todo.PageRequest{})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"updated", "updated", "updated", "updated", "updated", "updated", "updated", "updated",
+		"updated", "updated"}, itemTitles(items))
+	// Items added at the same time still get a place of their own.
+	positions := make(map[float64]bool, len(items))
+	for _, item := range items {
+		positions[item.Position] = true
+	}
+	assert.Len(t, positions, writers)
+}
@@ -0,0 +1,80 @@
+package repository
+
+import (
+	"database/sql"
+	todo "do-app"
+	"errors"
+	"github.com/lib/pq"
+)
+
+const (
+	foreignKeyViolation = "23503"
+	uniqueViolation     = "23505"
+)
+
+// errSessionInactive is returned when a session to rotate has been revoked or
+// already got another refresh token.
+var errSessionInactive = todo.NewError(todo.ErrConflict, "session is not active")
+
+// domainError translates driver errors caused by the request into domain
+// errors about entity. Any other error is returned unchanged.
+func domainError(err error, entity string) error {
+	if errors.Is(err, sql.ErrNoRows) {
+		return todo.NewError(todo.ErrNotFound, "%s not found", entity)
+	}
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) {
+		switch pqErr.Code {
+		case uniqueViolation:
+			return todo.NewError(todo.ErrConflict, "%s already exists", entity)
+		case foreignKeyViolation:
+			return todo.NewError(todo.ErrConflict, "%s refers to a missing entity", entity)
+		}
+	}
+	if sqliteErrors != nil {
+		if mapped := sqliteErrors.domainError(err, entity); mapped != nil {
+			return mapped
+		}
+	}
+	return err
+}
+
+// driverErrors classifies the errors of a database driver that is not always
+// built in.
+type driverErrors interface {
+	// domainError is domainError for the errors of the driver. It returns nil
+	// for errors it does not translate.
+	domainError(err error, entity string) error
+	// retryable is retryable for the errors of the driver.
+	retryable(err error) bool
+}
+
+// checkAffected reports entity as not found when a statement matched no rows.
+func checkAffected(res sql.Result, entity string) error {
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return todo.NewError(todo.ErrNotFound, "%s not found", entity)
+	}
+	return nil
+}
+
+// checkVersion is checkAffected for statements that only match the entity at
+// the expected version. A version of 0 matches any version. Callers check that
+// the entity exists beforehand, so a statement at a version that matched no
+// rows means the entity has been changed or removed in the meantime.
+func checkVersion(res sql.Result, entity string, version int) error {
+	if version == 0 {
+		return checkAffected(res, entity)
+	}
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return todo.NewError(todo.ErrPreconditionFailed, "%s has been changed by someone else", entity)
+	}
+	return nil
+}
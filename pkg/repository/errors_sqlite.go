@@ -0,0 +1,36 @@
+//go:build cgo
+
+package repository
+
+import (
+	todo "do-app"
+	"errors"
+	"github.com/mattn/go-sqlite3"
+)
+
+// sqliteErrors classifies the errors of the SQLite driver, which is only
+// built in with cgo.
+var sqliteErrors driverErrors = sqliteDriverErrors{}
+
+type sqliteDriverErrors struct{}
+
+func (sqliteDriverErrors) domainError(err error, entity string) error {
+	var sqliteErr sqlite3.Error
+	if errors.As(err, &sqliteErr) {
+		switch sqliteErr.ExtendedCode {
+		case sqlite3.ErrConstraintUnique, sqlite3.ErrConstraintPrimaryKey:
+			return todo.NewError(todo.ErrConflict, "%s already exists", entity)
+		case sqlite3.ErrConstraintForeignKey:
+			return todo.NewError(todo.ErrConflict, "%s refers to a missing entity", entity)
+		}
+	}
+	return nil
+}
+
+func (sqliteDriverErrors) retryable(err error) bool {
+	var sqliteErr sqlite3.Error
+	if errors.As(err, &sqliteErr) {
+		return sqliteErr.Code == sqlite3.ErrBusy || sqliteErr.Code == sqlite3.ErrLocked
+	}
+	return false
+}
@@ -0,0 +1,7 @@
+//go:build !cgo
+
+package repository
+
+// sqliteErrors is nil without cgo: the SQLite driver is then a stub that fails
+// to open any database, so there are no SQLite errors to classify.
+var sqliteErrors driverErrors
@@ -0,0 +1,176 @@
+package repository
+
+import (
+	"context"
+	todo "do-app"
+	"fmt"
+	"sort"
+)
+
+type LabelMemory struct {
+	store *memoryStore
+}
+
+func NewLabelMemory(store *memoryStore) *LabelMemory {
+	return &LabelMemory{store: store}
+}
+
+// nameTaken reports whether the user has another label with the name.
+func (d *memoryData) nameTaken(userId, labelId int, name string) bool {
+	for _, label := range d.labels {
+		if label.UserId == userId && label.Name == name && label.Id != labelId {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *LabelMemory) Create(ctx context.Context, userId int, label todo.Label) (int, error) {
+	var id int
+	err := r.store.write(ctx, func(d *memoryData) error {
+		if _, ok := d.users[userId]; !ok {
+			return todo.NewError(todo.ErrConflict, "label refers to a missing entity")
+		}
+		if d.nameTaken(userId, 0, label.Name) {
+			return todo.NewError(todo.ErrConflict, "label already exists")
+		}
+		id = d.nextId(labelsTable)
+		put(d, d.labels, id, memoryLabel{Label: todo.Label{Id: id, Name: label.Name, Color: label.Color}, UserId: userId})
+		return nil
+	})
+	if err != nil {
+		return 0, fmt.Errorf("Create label repository: %w", err)
+	}
+	return id, nil
+}
+
+// userLabels returns the labels of the user that match, ordered by name.
+func (d *memoryData) userLabels(userId int, match func(label memoryLabel) bool) []todo.Label {
+	var labels []todo.Label
+	for _, label := range d.labels {
+		if label.UserId == userId && match(label) {
+			labels = append(labels, label.Label)
+		}
+	}
+	sort.Slice(labels, func(i, j int) bool {
+		return labels[i].Name < labels[j].Name
+	})
+	return labels
+}
+
+func (r *LabelMemory) GetAll(ctx context.Context, userId int) ([]todo.Label, error) {
+	var labels []todo.Label
+	err := r.store.read(ctx, func(d *memoryData) error {
+		labels = d.userLabels(userId, func(memoryLabel) bool { return true })
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("GetAll label repository: %w", err)
+	}
+	return labels, nil
+}
+
+func (r *LabelMemory) GetById(ctx context.Context, userId, labelId int) (todo.Label, error) {
+	var label todo.Label
+	err := r.store.read(ctx, func(d *memoryData) error {
+		stored, ok := d.labels[labelId]
+		if !ok || stored.UserId != userId {
+			return todo.NewError(todo.ErrNotFound, "label not found")
+		}
+		label = stored.Label
+		return nil
+	})
+	if err != nil {
+		return label, fmt.Errorf("GetById label repository: %w", err)
+	}
+	return label, nil
+}
+
+func (r *LabelMemory) GetByItem(ctx context.Context, userId, itemId int) ([]todo.Label, error) {
+	var labels []todo.Label
+	err := r.store.read(ctx, func(d *memoryData) error {
+		labels = d.userLabels(userId, func(label memoryLabel) bool {
+			return d.itemLabels[itemLabel{ItemId: itemId, LabelId: label.Id}]
+		})
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("GetByItem label repository: %w", err)
+	}
+	return labels, nil
+}
+
+func (r *LabelMemory) Update(ctx context.Context, userId, labelId int, input todo.UpdateLabelInput) error {
+	err := r.store.write(ctx, func(d *memoryData) error {
+		label, ok := d.labels[labelId]
+		if !ok || label.UserId != userId {
+			return todo.NewError(todo.ErrNotFound, "label not found")
+		}
+		if input.Name != nil {
+			if d.nameTaken(userId, labelId, *input.Name) {
+				return todo.NewError(todo.ErrConflict, "label already exists")
+			}
+			label.Name = *input.Name
+		}
+		if input.Color != nil {
+			label.Color = *input.Color
+		}
+		put(d, d.labels, labelId, label)
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("Update label repository: %w", err)
+	}
+	return nil
+}
+
+func (r *LabelMemory) Delete(ctx context.Context, userId, labelId int) error {
+	err := r.store.write(ctx, func(d *memoryData) error {
+		label, ok := d.labels[labelId]
+		if !ok || label.UserId != userId {
+			return todo.NewError(todo.ErrNotFound, "label not found")
+		}
+		remove(d, d.labels, labelId)
+		for link := range d.itemLabels {
+			if link.LabelId == labelId {
+				remove(d, d.itemLabels, link)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("Delete label repository: %w", err)
+	}
+	return nil
+}
+
+func (r *LabelMemory) Attach(ctx context.Context, itemId, labelId int) error {
+	err := r.store.write(ctx, func(d *memoryData) error {
+		_, itemOk := d.items[itemId]
+		_, labelOk := d.labels[labelId]
+		if !itemOk || !labelOk {
+			return todo.NewError(todo.ErrConflict, "label refers to a missing entity")
+		}
+		put(d, d.itemLabels, itemLabel{ItemId: itemId, LabelId: labelId}, true)
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("Attach label repository: %w", err)
+	}
+	return nil
+}
+
+func (r *LabelMemory) Detach(ctx context.Context, userId, itemId, labelId int) error {
+	err := r.store.write(ctx, func(d *memoryData) error {
+		link := itemLabel{ItemId: itemId, LabelId: labelId}
+		if label, ok := d.labels[labelId]; !ok || label.UserId != userId || !d.itemLabels[link] {
+			return todo.NewError(todo.ErrNotFound, "label not found")
+		}
+		remove(d, d.itemLabels, link)
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("Detach label repository: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,182 @@
+package repository
+
+import (
+	"context"
+	todo "do-app"
+	"github.com/stretchr/testify/assert"
+	sqlmock "github.com/zhashkevych/go-sqlxmock"
+	"log"
+	"testing"
+)
+
+func TestLabelPostgres_Create(t *testing.T) {
+	db, mock, err := sqlmock.Newx()
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer db.Close()
+
+	r := NewLabelPostgres(db)
+
+	label := todo.Label{Name: "work", Color: "#1e88e5"}
+
+	testTable := []struct {
+		name         string
+		mockBehavior func()
+		id           int
+		wantErr      assert.ErrorAssertionFunc
+	}{
+		{
+			name: "OK",
+			mockBehavior: func() {
+				mock.ExpectQuery(`INSERT INTO labels`).WithArgs(1, label.Name, label.Color).
+					WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(3))
+			},
+			id:      3,
+			wantErr: assert.NoError,
+		},
+		{
+			name: "Duplicate name",
+			mockBehavior: func() {
+				mock.ExpectQuery(`INSERT INTO labels`).WithArgs(1, label.Name, label.Color).
+					WillReturnError(assert.AnError)
+			},
+			wantErr: assert.Error,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			testCase.mockBehavior()
+
+			got, err := r.Create(context.Background(), 1, label)
+
+			testCase.wantErr(t, err)
+			assert.Equal(t, testCase.id, got)
+		})
+	}
+}
+
+func TestLabelPostgres_GetByItem(t *testing.T) {
+	db, mock, err := sqlmock.Newx()
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer db.Close()
+
+	r := NewLabelPostgres(db)
+
+	testTable := []struct {
+		name         string
+		mockBehavior func()
+		labels       []todo.Label
+		wantErr      assert.ErrorAssertionFunc
+	}{
+		{
+			name: "OK",
+			mockBehavior: func() {
+				rows := sqlmock.NewRows([]string{"id", "name", "color"}).AddRow(3, "work", "#1e88e5")
+				mock.ExpectQuery(`SELECT l.id, l.name, l.color FROM labels l INNER JOIN items_labels il`).
+					WithArgs(2, 1).WillReturnRows(rows)
+			},
+			labels:  []todo.Label{{Id: 3, Name: "work", Color: "#1e88e5"}},
+			wantErr: assert.NoError,
+		},
+		{
+			name: "Failed",
+			mockBehavior: func() {
+				mock.ExpectQuery(`SELECT l.id, l.name, l.color FROM labels l INNER JOIN items_labels il`).
+					WithArgs(2, 1).WillReturnError(assert.AnError)
+			},
+			wantErr: assert.Error,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			testCase.mockBehavior()
+
+			got, err := r.GetByItem(context.Background(), 1, 2)
+
+			testCase.wantErr(t, err)
+			assert.Equal(t, testCase.labels, got)
+		})
+	}
+}
+
+func TestLabelPostgres_Update(t *testing.T) {
+	db, mock, err := sqlmock.Newx()
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer db.Close()
+
+	r := NewLabelPostgres(db)
+
+	testTable := []struct {
+		name         string
+		input        todo.UpdateLabelInput
+		mockBehavior func()
+		wantErr      assert.ErrorAssertionFunc
+	}{
+		{
+			name:  "Name and color",
+			input: todo.UpdateLabelInput{Name: stringPointer("home"), Color: stringPointer("#43a047")},
+			mockBehavior: func() {
+				mock.ExpectExec(`UPDATE labels SET name=\$1, color=\$2 WHERE id = \$3 AND user_id = \$4`).
+					WithArgs("home", "#43a047", 3, 1).WillReturnResult(sqlmock.NewResult(0, 1))
+			},
+			wantErr: assert.NoError,
+		},
+		{
+			name:  "Color only",
+			input: todo.UpdateLabelInput{Color: stringPointer("#43a047")},
+			mockBehavior: func() {
+				mock.ExpectExec(`UPDATE labels SET color=\$1 WHERE id = \$2 AND user_id = \$3`).
+					WithArgs("#43a047", 3, 1).WillReturnError(assert.AnError)
+			},
+			wantErr: assert.Error,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			testCase.mockBehavior()
+
+			err := r.Update(context.Background(), 1, 3, testCase.input)
+
+			testCase.wantErr(t, err)
+		})
+	}
+}
+
+func TestLabelPostgres_Attach(t *testing.T) {
+	db, mock, err := sqlmock.Newx()
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer db.Close()
+
+	r := NewLabelPostgres(db)
+
+	mock.ExpectExec(`INSERT INTO items_labels \(item_id, label_id\) values \(\$1, \$2\) ON CONFLICT DO NOTHING`).
+		WithArgs(2, 3).WillReturnResult(sqlmock.NewResult(0, 1))
+
+	assert.NoError(t, r.Attach(context.Background(), 2, 3))
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestLabelPostgres_Detach(t *testing.T) {
+	db, mock, err := sqlmock.Newx()
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer db.Close()
+
+	r := NewLabelPostgres(db)
+
+	mock.ExpectExec(`DELETE FROM items_labels il USING labels l`).
+		WithArgs(1, 2, 3).WillReturnError(assert.AnError)
+
+	assert.Error(t, r.Detach(context.Background(), 1, 2, 3))
+}
@@ -0,0 +1,120 @@
+package repository
+
+import (
+	"context"
+	todo "do-app"
+	"fmt"
+	"github.com/jmoiron/sqlx"
+	"strings"
+)
+
+type LabelSQLite struct {
+	db *sqlx.DB
+}
+
+func NewLabelSQLite(db *sqlx.DB) *LabelSQLite {
+	return &LabelSQLite{db: db}
+}
+
+func (r *LabelSQLite) Create(ctx context.Context, userId int, label todo.Label) (int, error) {
+	var id int
+	query := fmt.Sprintf("INSERT INTO %s (user_id, name, color) values (?1, ?2, ?3) RETURNING id", labelsTable)
+	row := conn(ctx, r.db).QueryRowContext(ctx, query, userId, label.Name, label.Color)
+	if err := row.Scan(&id); err != nil {
+		return 0, fmt.Errorf("Create label repository: %w", domainError(err, "label"))
+	}
+	return id, nil
+}
+
+func (r *LabelSQLite) GetAll(ctx context.Context, userId int) ([]todo.Label, error) {
+	var labels []todo.Label
+	query := fmt.Sprintf("SELECT id, name, color FROM %s WHERE user_id = ?1 ORDER BY name", labelsTable)
+	if err := conn(ctx, r.db).SelectContext(ctx, &labels, query, userId); err != nil {
+		return nil, fmt.Errorf("GetAll label repository: %w", err)
+	}
+	return labels, nil
+}
+
+func (r *LabelSQLite) GetById(ctx context.Context, userId, labelId int) (todo.Label, error) {
+	var label todo.Label
+	query := fmt.Sprintf("SELECT id, name, color FROM %s WHERE id = ?1 AND user_id = ?2", labelsTable)
+	if err := conn(ctx, r.db).GetContext(ctx, &label, query, labelId, userId); err != nil {
+		return label, fmt.Errorf("GetById label repository: %w", domainError(err, "label"))
+	}
+	return label, nil
+}
+
+func (r *LabelSQLite) GetByItem(ctx context.Context, userId, itemId int) ([]todo.Label, error) {
+	var labels []todo.Label
+	query := fmt.Sprintf(`SELECT l.id, l.name, l.color FROM %s l INNER JOIN %s il on il.label_id = l.id
+								 WHERE il.item_id = ?1 AND l.user_id = ?2 ORDER BY l.name`,
+		labelsTable, itemsLabelsTable)
+	if err := conn(ctx, r.db).SelectContext(ctx, &labels, query, itemId, userId); err != nil {
+		return nil, fmt.Errorf("GetByItem label repository: %w", err)
+	}
+	return labels, nil
+}
+
+func (r *LabelSQLite) Update(ctx context.Context, userId, labelId int, input todo.UpdateLabelInput) error {
+	setValues := make([]string, 0)
+	args := make([]interface{}, 0)
+	argId := 1
+
+	if input.Name != nil {
+		setValues = append(setValues, fmt.Sprintf("name=?%d", argId))
+		args = append(args, *input.Name)
+		argId++
+	}
+	if input.Color != nil {
+		setValues = append(setValues, fmt.Sprintf("color=?%d", argId))
+		args = append(args, *input.Color)
+		argId++
+	}
+
+	query := fmt.Sprintf("UPDATE %s SET %s WHERE id = ?%d AND user_id = ?%d",
+		labelsTable, strings.Join(setValues, ", "), argId, argId+1)
+	args = append(args, labelId, userId)
+
+	res, err := conn(ctx, r.db).ExecContext(ctx, query, args...)
+	if err != nil {
+		return fmt.Errorf("Update label repository: %w", domainError(err, "label"))
+	}
+	if err = checkAffected(res, "label"); err != nil {
+		return fmt.Errorf("Update label repository: %w", err)
+	}
+	return nil
+}
+
+func (r *LabelSQLite) Delete(ctx context.Context, userId, labelId int) error {
+	query := fmt.Sprintf("DELETE FROM %s WHERE id = ?1 AND user_id = ?2", labelsTable)
+	res, err := conn(ctx, r.db).ExecContext(ctx, query, labelId, userId)
+	if err != nil {
+		return fmt.Errorf("Delete label repository: %w", domainError(err, "label"))
+	}
+	if err = checkAffected(res, "label"); err != nil {
+		return fmt.Errorf("Delete label repository: %w", err)
+	}
+	return nil
+}
+
+func (r *LabelSQLite) Attach(ctx context.Context, itemId, labelId int) error {
+	query := fmt.Sprintf("INSERT INTO %s (item_id, label_id) values (?1, ?2) ON CONFLICT DO NOTHING", itemsLabelsTable)
+	if _, err := conn(ctx, r.db).ExecContext(ctx, query, itemId, labelId); err != nil {
+		return fmt.Errorf("Attach label repository: %w", domainError(err, "label"))
+	}
+	return nil
+}
+
+func (r *LabelSQLite) Detach(ctx context.Context, userId, itemId, labelId int) error {
+	query := fmt.Sprintf(`DELETE FROM %s WHERE label_id IN (SELECT id FROM %s WHERE user_id = ?1)
+								 AND item_id = ?2 AND label_id = ?3`,
+		itemsLabelsTable, labelsTable)
+	res, err := conn(ctx, r.db).ExecContext(ctx, query, userId, itemId, labelId)
+	if err != nil {
+		return fmt.Errorf("Detach label repository: %w", domainError(err, "label"))
+	}
+	if err = checkAffected(res, "label"); err != nil {
+		return fmt.Errorf("Detach label repository: %w", err)
+	}
+	return nil
+}
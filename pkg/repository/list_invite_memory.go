@@ -0,0 +1,109 @@
+package repository
+
+import (
+	"context"
+	todo "do-app"
+	"fmt"
+	"sort"
+	"time"
+)
+
+type ListInviteMemory struct {
+	store *memoryStore
+}
+
+func NewListInviteMemory(store *memoryStore) *ListInviteMemory {
+	return &ListInviteMemory{store: store}
+}
+
+func (r *ListInviteMemory) Create(ctx context.Context, invite todo.ListInvite, tokenHash string) (int, error) {
+	var id int
+	err := r.store.write(ctx, func(d *memoryData) error {
+		if _, ok := d.lists[invite.ListId]; !ok {
+			return todo.NewError(todo.ErrConflict, "invite refers to a missing entity")
+		}
+		for _, i := range d.invites {
+			if i.TokenHash == tokenHash {
+				return todo.NewError(todo.ErrConflict, "invite already exists")
+			}
+		}
+		id = d.nextId(listInvitesTable)
+		invite.Id, invite.Token, invite.Uses, invite.Revoked, invite.CreatedAt = id, "", 0, false, time.Now()
+		put(d, d.invites, id, memoryInvite{ListInvite: invite, TokenHash: tokenHash})
+		return nil
+	})
+	if err != nil {
+		return 0, fmt.Errorf("Create invite repository: %w", err)
+	}
+	return id, nil
+}
+
+func (r *ListInviteMemory) GetAll(ctx context.Context, listId int) ([]todo.ListInvite, error) {
+	var invites []todo.ListInvite
+	err := r.store.read(ctx, func(d *memoryData) error {
+		for _, i := range d.invites {
+			if i.ListId == listId {
+				invites = append(invites, i.ListInvite)
+			}
+		}
+		sort.Slice(invites, func(i, j int) bool {
+			return invites[i].Id < invites[j].Id
+		})
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("GetAll invite repository: %w", err)
+	}
+	return invites, nil
+}
+
+func (r *ListInviteMemory) Revoke(ctx context.Context, listId, inviteId int) error {
+	err := r.store.write(ctx, func(d *memoryData) error {
+		invite, ok := d.invites[inviteId]
+		if !ok || invite.ListId != listId {
+			return todo.NewError(todo.ErrNotFound, "invite not found")
+		}
+		invite.Revoked = true
+		put(d, d.invites, inviteId, invite)
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("Revoke invite repository: %w", err)
+	}
+	return nil
+}
+
+// Redeem consumes one use of a valid invite and makes the user a member of
+// its list. The use is only consumed if the user was not a member before.
+func (r *ListInviteMemory) Redeem(ctx context.Context, tokenHash string, userId int) (int, error) {
+	var listId int
+	err := r.store.write(ctx, func(d *memoryData) error {
+		var invite *memoryInvite
+		for _, i := range d.invites {
+			if i.TokenHash == tokenHash && !i.Revoked && i.ExpiresAt.After(time.Now()) &&
+				(i.MaxUses == nil || i.Uses < *i.MaxUses) && d.lists[i.ListId].DeletedAt == nil {
+				i := i
+				invite = &i
+			}
+		}
+		if invite == nil {
+			return todo.NewError(todo.ErrNotFound, "invite not found")
+		}
+		if _, ok := d.member(userId, invite.ListId); ok {
+			return todo.NewError(todo.ErrConflict, "user is already a member of the list")
+		}
+		if err := addMember(d, userId, invite.ListId, invite.Role); err != nil {
+			return err
+		}
+
+		invite.Uses++
+		put(d, d.invites, invite.Id, *invite)
+		put(d, d.redemptions, invite.Id, append(d.redemptions[invite.Id], userId))
+		listId = invite.ListId
+		return nil
+	})
+	if err != nil {
+		return 0, fmt.Errorf("Redeem invite repository: %w", err)
+	}
+	return listId, nil
+}
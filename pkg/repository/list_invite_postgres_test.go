@@ -0,0 +1,92 @@
+package repository
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	sqlmock "github.com/zhashkevych/go-sqlxmock"
+	"log"
+	"testing"
+)
+
+func TestListInvitePostgres_Redeem(t *testing.T) {
+	db, mock, err := sqlmock.Newx()
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer db.Close()
+
+	r := NewListInvitePostgres(db)
+
+	type mockBehavior func(listId int)
+
+	testTable := []struct {
+		name         string
+		listId       int
+		mockBehavior mockBehavior
+		wantErr      bool
+	}{
+		{
+			name:   "OK",
+			listId: 3,
+			mockBehavior: func(listId int) {
+				mock.ExpectBegin()
+
+				row := sqlmock.NewRows([]string{"id", "list_id", "role"}).AddRow(1, listId, "editor")
+				mock.ExpectQuery(`UPDATE list_invites i SET uses = i.uses \+ 1 FROM todo_lists tl`).
+					WithArgs("hash").WillReturnRows(row)
+
+				mock.ExpectExec(`INSERT INTO users_lists`).
+					WithArgs(2, listId, "editor").WillReturnResult(sqlmock.NewResult(0, 1))
+
+				mock.ExpectExec(`INSERT INTO invite_redemptions`).
+					WithArgs(1, 2).WillReturnResult(sqlmock.NewResult(1, 1))
+
+				mock.ExpectCommit()
+			},
+		},
+		{
+			name: "Expired or used up",
+			mockBehavior: func(listId int) {
+				mock.ExpectBegin()
+
+				mock.ExpectQuery(`UPDATE list_invites i SET uses = i.uses \+ 1 FROM todo_lists tl`).
+					WithArgs("hash").WillReturnRows(sqlmock.NewRows([]string{"id", "list_id", "role"}))
+
+				mock.ExpectRollback()
+			},
+			wantErr: true,
+		},
+		{
+			name: "Already member",
+			mockBehavior: func(listId int) {
+				mock.ExpectBegin()
+
+				row := sqlmock.NewRows([]string{"id", "list_id", "role"}).AddRow(1, 3, "editor")
+				mock.ExpectQuery(`UPDATE list_invites i SET uses = i.uses \+ 1 FROM todo_lists tl`).
+					WithArgs("hash").WillReturnRows(row)
+
+				mock.ExpectExec(`INSERT INTO users_lists`).
+					WithArgs(2, 3, "editor").WillReturnResult(sqlmock.NewResult(0, 0))
+
+				mock.ExpectRollback()
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			testCase.mockBehavior(testCase.listId)
+
+			got, err := r.Redeem(context.Background(), "hash", 2)
+
+			if testCase.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, testCase.listId, got)
+			}
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
@@ -0,0 +1,91 @@
+package repository
+
+import (
+	"context"
+	todo "do-app"
+	"fmt"
+	"github.com/jmoiron/sqlx"
+	"time"
+)
+
+type ListInviteSQLite struct {
+	db *sqlx.DB
+}
+
+func NewListInviteSQLite(db *sqlx.DB) *ListInviteSQLite {
+	return &ListInviteSQLite{db: db}
+}
+
+func (r *ListInviteSQLite) Create(ctx context.Context, invite todo.ListInvite, tokenHash string) (int, error) {
+	var id int
+	query := fmt.Sprintf(`INSERT INTO %s (list_id, token_hash, role, created_by, max_uses, expires_at)
+								 VALUES (?1, ?2, ?3, ?4, ?5, ?6) RETURNING id`, listInvitesTable)
+	row := conn(ctx, r.db).QueryRowContext(ctx, query, invite.ListId, tokenHash, invite.Role, invite.CreatedBy, invite.MaxUses,
+		sqliteTime(invite.ExpiresAt))
+	if err := row.Scan(&id); err != nil {
+		return 0, fmt.Errorf("Create invite repository: %w", err)
+	}
+	return id, nil
+}
+
+func (r *ListInviteSQLite) GetAll(ctx context.Context, listId int) ([]todo.ListInvite, error) {
+	var invites []todo.ListInvite
+	query := fmt.Sprintf(`SELECT id, list_id, role, created_by, max_uses, uses, expires_at, revoked, created_at
+								 FROM %s WHERE list_id = ?1 ORDER BY id`, listInvitesTable)
+	if err := conn(ctx, r.db).SelectContext(ctx, &invites, query, listId); err != nil {
+		return nil, fmt.Errorf("GetAll invite repository: %w", err)
+	}
+	return invites, nil
+}
+
+func (r *ListInviteSQLite) Revoke(ctx context.Context, listId, inviteId int) error {
+	query := fmt.Sprintf("UPDATE %s SET revoked = true WHERE list_id = ?1 AND id = ?2", listInvitesTable)
+	res, err := conn(ctx, r.db).ExecContext(ctx, query, listId, inviteId)
+	if err != nil {
+		return fmt.Errorf("Revoke invite repository: %w", err)
+	}
+	if err = checkAffected(res, "invite"); err != nil {
+		return fmt.Errorf("Revoke invite repository: %w", err)
+	}
+	return nil
+}
+
+// Redeem consumes one use of a valid invite and makes the user a member of
+// its list. The use is only consumed if the user was not a member before.
+func (r *ListInviteSQLite) Redeem(ctx context.Context, tokenHash string, userId int) (int, error) {
+	tx, err := beginTx(ctx, r.db)
+	if err != nil {
+		return 0, fmt.Errorf("Redeem invite repository: %w", err)
+	}
+
+	var invite todo.ListInvite
+	useQuery := fmt.Sprintf(`UPDATE %s SET uses = uses + 1 WHERE token_hash = ?1 AND revoked = false
+								 AND expires_at > ?2 AND (max_uses IS NULL OR uses < max_uses)
+								 AND list_id IN (SELECT id FROM %s WHERE deleted_at IS NULL)
+								 RETURNING id, list_id, role`, listInvitesTable, todoListsTable)
+	if err = tx.GetContext(ctx, &invite, useQuery, tokenHash, sqliteTime(time.Now())); err != nil {
+		tx.Rollback()
+		return 0, fmt.Errorf("Redeem invite repository: %w", domainError(err, "invite"))
+	}
+
+	memberQuery := fmt.Sprintf(`INSERT INTO %s (user_id, list_id, role) VALUES (?1, ?2, ?3)
+								 ON CONFLICT (user_id, list_id) DO NOTHING`, usersListsTable)
+	res, err := tx.ExecContext(ctx, memberQuery, userId, invite.ListId, invite.Role)
+	if err != nil {
+		tx.Rollback()
+		return 0, fmt.Errorf("Redeem invite repository: %w", err)
+	}
+	if affected, err := res.RowsAffected(); err != nil || affected == 0 {
+		tx.Rollback()
+		return 0, fmt.Errorf("Redeem invite repository: %w",
+			todo.NewError(todo.ErrConflict, "user is already a member of the list"))
+	}
+
+	redemptionQuery := fmt.Sprintf("INSERT INTO %s (invite_id, user_id) VALUES (?1, ?2)", inviteRedemptionsTable)
+	if _, err = tx.ExecContext(ctx, redemptionQuery, invite.Id, userId); err != nil {
+		tx.Rollback()
+		return 0, fmt.Errorf("Redeem invite repository: %w", err)
+	}
+
+	return invite.ListId, tx.Commit()
+}
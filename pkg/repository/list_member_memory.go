@@ -0,0 +1,135 @@
+package repository
+
+import (
+	"context"
+	todo "do-app"
+	"fmt"
+	"sort"
+)
+
+type ListMemberMemory struct {
+	store *memoryStore
+}
+
+func NewListMemberMemory(store *memoryStore) *ListMemberMemory {
+	return &ListMemberMemory{store: store}
+}
+
+func (r *ListMemberMemory) GetAll(ctx context.Context, listId int) ([]todo.ListMember, error) {
+	var members []todo.ListMember
+	err := r.store.read(ctx, func(d *memoryData) error {
+		var memberships []todo.UserList
+		for _, m := range d.members {
+			if m.ListId == listId {
+				memberships = append(memberships, m)
+			}
+		}
+		sort.Slice(memberships, func(i, j int) bool {
+			return memberships[i].Id < memberships[j].Id
+		})
+		for _, m := range memberships {
+			user := d.users[m.UserId]
+			members = append(members, todo.ListMember{UserId: m.UserId, Name: user.Name, Username: user.Username, Role: m.Role})
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("GetAll list member repository: %w", err)
+	}
+	return members, nil
+}
+
+func (r *ListMemberMemory) GetRole(ctx context.Context, userId, listId int) (string, error) {
+	var role string
+	err := r.store.read(ctx, func(d *memoryData) error {
+		list, ok := d.lists[listId]
+		role = d.role(userId, listId)
+		if !ok || role == "" || list.DeletedAt != nil {
+			return todo.NewError(todo.ErrNotFound, "list not found")
+		}
+		return nil
+	})
+	if err != nil {
+		return "", fmt.Errorf("GetRole list member repository: %w", err)
+	}
+	return role, nil
+}
+
+func (r *ListMemberMemory) Add(ctx context.Context, listId int, input todo.AddMemberInput) (int, error) {
+	var userId int
+	err := r.store.write(ctx, func(d *memoryData) error {
+		for _, u := range d.users {
+			if u.Username == input.Username {
+				userId = u.Id
+			}
+		}
+		if userId == 0 {
+			return todo.NewError(todo.ErrNotFound, "user %q not found", input.Username)
+		}
+		return addMember(d, userId, listId, input.Role)
+	})
+	if err != nil {
+		return 0, fmt.Errorf("Add list member repository: %w", err)
+	}
+	return userId, nil
+}
+
+// addMember gives the user the role in the list.
+func addMember(d *memoryData, userId, listId int, role string) error {
+	if _, ok := d.lists[listId]; !ok {
+		return todo.NewError(todo.ErrConflict, "member refers to a missing entity")
+	}
+	if _, ok := d.member(userId, listId); ok {
+		return todo.NewError(todo.ErrConflict, "member already exists")
+	}
+	id := d.nextId(usersListsTable)
+	put(d, d.members, id, todo.UserList{Id: id, UserId: userId, ListId: listId, Role: role})
+	return nil
+}
+
+func (r *ListMemberMemory) UpdateRole(ctx context.Context, listId, memberId int, role string) error {
+	err := r.store.write(ctx, func(d *memoryData) error {
+		m, ok := d.member(memberId, listId)
+		if !ok {
+			return todo.NewError(todo.ErrNotFound, "member not found")
+		}
+		m.Role = role
+		put(d, d.members, m.Id, m)
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("UpdateRole list member repository: %w", err)
+	}
+	return nil
+}
+
+func (r *ListMemberMemory) Remove(ctx context.Context, listId, memberId int) error {
+	err := r.store.write(ctx, func(d *memoryData) error {
+		m, ok := d.member(memberId, listId)
+		if !ok {
+			return todo.NewError(todo.ErrNotFound, "member not found")
+		}
+		remove(d, d.members, m.Id)
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("Remove list member repository: %w", err)
+	}
+	return nil
+}
+
+func (r *ListMemberMemory) CountOwners(ctx context.Context, listId int) (int, error) {
+	var count int
+	err := r.store.read(ctx, func(d *memoryData) error {
+		for _, m := range d.members {
+			if m.ListId == listId && m.Role == todo.RoleOwner {
+				count++
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, fmt.Errorf("CountOwners list member repository: %w", err)
+	}
+	return count, nil
+}
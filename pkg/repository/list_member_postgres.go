@@ -0,0 +1,90 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	todo "do-app"
+	"errors"
+	"fmt"
+	"github.com/jmoiron/sqlx"
+)
+
+type ListMemberPostgres struct {
+	db *sqlx.DB
+}
+
+func NewListMemberPostgres(db *sqlx.DB) *ListMemberPostgres {
+	return &ListMemberPostgres{db: db}
+}
+
+func (r *ListMemberPostgres) GetAll(ctx context.Context, listId int) ([]todo.ListMember, error) {
+	var members []todo.ListMember
+	query := fmt.Sprintf(`SELECT ul.user_id, u.name, u.username, ul.role FROM %s ul INNER JOIN %s u on u.id = ul.user_id
+								 WHERE ul.list_id = $1 ORDER BY ul.id`, usersListsTable, usersTable)
+	if err := conn(ctx, r.db).SelectContext(ctx, &members, query, listId); err != nil {
+		return nil, fmt.Errorf("GetAll list member repository: %w", err)
+	}
+	return members, nil
+}
+
+func (r *ListMemberPostgres) GetRole(ctx context.Context, userId, listId int) (string, error) {
+	var role string
+	query := fmt.Sprintf(`SELECT ul.role FROM %s ul INNER JOIN %s tl on tl.id = ul.list_id
+								 WHERE ul.user_id = $1 AND ul.list_id = $2 AND tl.deleted_at IS NULL`,
+		usersListsTable, todoListsTable)
+	if err := conn(ctx, r.db).GetContext(ctx, &role, query, userId, listId); err != nil {
+		return "", fmt.Errorf("GetRole list member repository: %w", domainError(err, "list"))
+	}
+	return role, nil
+}
+
+func (r *ListMemberPostgres) Add(ctx context.Context, listId int, input todo.AddMemberInput) (int, error) {
+	var userId int
+	query := fmt.Sprintf(`INSERT INTO %s (user_id, list_id, role) SELECT id, $2, $3 FROM %s WHERE username = $1
+								 RETURNING user_id`, usersListsTable, usersTable)
+	row := conn(ctx, r.db).QueryRowContext(ctx, query, input.Username, listId, input.Role)
+	if err := row.Scan(&userId); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			err = todo.NewError(todo.ErrNotFound, "user %q not found", input.Username)
+		}
+		return 0, fmt.Errorf("Add list member repository: %w", domainError(err, "member"))
+	}
+	return userId, nil
+}
+
+func (r *ListMemberPostgres) UpdateRole(ctx context.Context, listId, memberId int, role string) error {
+	query := fmt.Sprintf("UPDATE %s SET role = $1 WHERE list_id = $2 AND user_id = $3", usersListsTable)
+	res, err := conn(ctx, r.db).ExecContext(ctx, query, role, listId, memberId)
+	if err != nil {
+		return fmt.Errorf("UpdateRole list member repository: %w", err)
+	}
+	if err = checkAffected(res, "member"); err != nil {
+		return fmt.Errorf("UpdateRole list member repository: %w", err)
+	}
+	return nil
+}
+
+func (r *ListMemberPostgres) Remove(ctx context.Context, listId, memberId int) error {
+	query := fmt.Sprintf("DELETE FROM %s WHERE list_id = $1 AND user_id = $2", usersListsTable)
+	res, err := conn(ctx, r.db).ExecContext(ctx, query, listId, memberId)
+	if err != nil {
+		return fmt.Errorf("Remove list member repository: %w", err)
+	}
+	if err = checkAffected(res, "member"); err != nil {
+		return fmt.Errorf("Remove list member repository: %w", err)
+	}
+	return nil
+}
+
+// CountOwners counts the owners of the list and locks their memberships for the
+// rest of the transaction, so that units of work demoting or removing owners at
+// the same time count one after the other.
+func (r *ListMemberPostgres) CountOwners(ctx context.Context, listId int) (int, error) {
+	var count int
+	query := fmt.Sprintf("SELECT count(*) FROM (SELECT id FROM %s WHERE list_id = $1 AND role = $2 FOR UPDATE) owners",
+		usersListsTable)
+	if err := conn(ctx, r.db).GetContext(ctx, &count, query, listId, todo.RoleOwner); err != nil {
+		return 0, fmt.Errorf("CountOwners list member repository: %w", err)
+	}
+	return count, nil
+}
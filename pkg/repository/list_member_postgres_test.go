@@ -0,0 +1,205 @@
+package repository
+
+import (
+	"context"
+	todo "do-app"
+	"github.com/stretchr/testify/assert"
+	sqlmock "github.com/zhashkevych/go-sqlxmock"
+	"log"
+	"testing"
+)
+
+func TestListMemberPostgres_GetAll(t *testing.T) {
+	db, mock, err := sqlmock.Newx()
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer db.Close()
+
+	r := NewListMemberPostgres(db)
+
+	testTable := []struct {
+		name         string
+		members      []todo.ListMember
+		mockBehavior func(members []todo.ListMember)
+		wantErr      assert.ErrorAssertionFunc
+	}{
+		{
+			name: "OK",
+			members: []todo.ListMember{
+				{UserId: 1, Name: "Owner", Username: "owner", Role: todo.RoleOwner},
+				{UserId: 2, Name: "Viewer", Username: "viewer", Role: todo.RoleViewer},
+			},
+			mockBehavior: func(members []todo.ListMember) {
+				rows := sqlmock.NewRows([]string{"user_id", "name", "username", "role"})
+				for _, m := range members {
+					rows.AddRow(m.UserId, m.Name, m.Username, m.Role)
+				}
+				mock.ExpectQuery(`SELECT ul.user_id, u.name, u.username, ul.role FROM users_lists ul`).
+					WithArgs(1).WillReturnRows(rows)
+			},
+			wantErr: assert.NoError,
+		},
+		{
+			name: "Failed",
+			mockBehavior: func(members []todo.ListMember) {
+				mock.ExpectQuery(`SELECT ul.user_id, u.name, u.username, ul.role FROM users_lists ul`).
+					WithArgs(1).WillReturnError(assert.AnError)
+			},
+			wantErr: assert.Error,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			testCase.mockBehavior(testCase.members)
+
+			got, err := r.GetAll(context.Background(), 1)
+
+			testCase.wantErr(t, err)
+			assert.Equal(t, testCase.members, got)
+		})
+	}
+}
+
+func TestListMemberPostgres_Add(t *testing.T) {
+	db, mock, err := sqlmock.Newx()
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer db.Close()
+
+	r := NewListMemberPostgres(db)
+
+	input := todo.AddMemberInput{Username: "friend", Role: todo.RoleEditor}
+
+	testTable := []struct {
+		name         string
+		userId       int
+		mockBehavior func(userId int)
+		wantErr      assert.ErrorAssertionFunc
+	}{
+		{
+			name:   "OK",
+			userId: 2,
+			mockBehavior: func(userId int) {
+				row := sqlmock.NewRows([]string{"user_id"}).AddRow(userId)
+				mock.ExpectQuery(`INSERT INTO users_lists`).
+					WithArgs(input.Username, 1, input.Role).WillReturnRows(row)
+			},
+			wantErr: assert.NoError,
+		},
+		{
+			name: "Unknown user",
+			mockBehavior: func(userId int) {
+				mock.ExpectQuery(`INSERT INTO users_lists`).
+					WithArgs(input.Username, 1, input.Role).WillReturnRows(sqlmock.NewRows([]string{"user_id"}))
+			},
+			wantErr: assert.Error,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			testCase.mockBehavior(testCase.userId)
+
+			got, err := r.Add(context.Background(), 1, input)
+
+			testCase.wantErr(t, err)
+			assert.Equal(t, testCase.userId, got)
+		})
+	}
+}
+
+func TestListMemberPostgres_GetRole(t *testing.T) {
+	db, mock, err := sqlmock.Newx()
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer db.Close()
+
+	r := NewListMemberPostgres(db)
+
+	testTable := []struct {
+		name         string
+		role         string
+		mockBehavior func(role string)
+		wantErr      assert.ErrorAssertionFunc
+	}{
+		{
+			name: "OK",
+			role: todo.RoleViewer,
+			mockBehavior: func(role string) {
+				row := sqlmock.NewRows([]string{"role"}).AddRow(role)
+				mock.ExpectQuery(`SELECT ul.role FROM users_lists ul INNER JOIN todo_lists tl`).WithArgs(1, 2).WillReturnRows(row)
+			},
+			wantErr: assert.NoError,
+		},
+		{
+			name: "Not a member",
+			mockBehavior: func(role string) {
+				mock.ExpectQuery(`SELECT ul.role FROM users_lists ul INNER JOIN todo_lists tl`).WithArgs(1, 2).
+					WillReturnRows(sqlmock.NewRows([]string{"role"}))
+			},
+			wantErr: assert.Error,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			testCase.mockBehavior(testCase.role)
+
+			got, err := r.GetRole(context.Background(), 1, 2)
+
+			testCase.wantErr(t, err)
+			assert.Equal(t, testCase.role, got)
+		})
+	}
+}
+
+func TestListMemberPostgres_CountOwners(t *testing.T) {
+	db, mock, err := sqlmock.Newx()
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer db.Close()
+
+	r := NewListMemberPostgres(db)
+
+	testTable := []struct {
+		name         string
+		mockBehavior func()
+		want         int
+		wantErr      assert.ErrorAssertionFunc
+	}{
+		{
+			name: "OK",
+			mockBehavior: func() {
+				row := sqlmock.NewRows([]string{"count"}).AddRow(2)
+				mock.ExpectQuery(`SELECT count\(\*\) FROM \(SELECT id FROM users_lists WHERE list_id = \$1 AND role = \$2 FOR UPDATE\)`).
+					WithArgs(1, todo.RoleOwner).WillReturnRows(row)
+			},
+			want:    2,
+			wantErr: assert.NoError,
+		},
+		{
+			name: "Failed",
+			mockBehavior: func() {
+				mock.ExpectQuery(`SELECT count\(\*\) FROM`).WithArgs(1, todo.RoleOwner).WillReturnError(assert.AnError)
+			},
+			wantErr: assert.Error,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			testCase.mockBehavior()
+
+			got, err := r.CountOwners(context.Background(), 1)
+
+			testCase.wantErr(t, err)
+			assert.Equal(t, testCase.want, got)
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
@@ -0,0 +1,374 @@
+package repository
+
+import (
+	"context"
+	todo "do-app"
+	"encoding/json"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// memoryList is a row of todo_lists. DeletedAt is set while the list is in the
+// trash.
+type memoryList struct {
+	todo.TodoList
+	DeletedAt *time.Time
+}
+
+// memoryItem is a row of todo_items together with its row of lists_items,
+// which gives the item its ListId and Position.
+type memoryItem struct {
+	todo.TodoItem
+	DeletedAt       *time.Time
+	ReminderSentAt  *time.Time
+	ReminderRetryAt *time.Time
+}
+
+type memoryInvite struct {
+	todo.ListInvite
+	TokenHash string
+}
+
+type memoryLabel struct {
+	todo.Label
+	UserId int
+}
+
+type itemLabel struct {
+	ItemId  int
+	LabelId int
+}
+
+type memoryUndoToken struct {
+	ActivityId int
+	UserId     int
+	ExpiresAt  time.Time
+}
+
+type memoryDelivery struct {
+	ItemId      int
+	AttemptedAt time.Time
+	Success     bool
+	Error       *string
+}
+
+// memoryData holds the tables of the memory store. Rows are stored by value,
+// and every change of a table goes through put, remove or replace, which log
+// how to revert it; a failed call rolls its changes back from that undo log.
+type memoryData struct {
+	ids         map[string]int
+	users       map[int]todo.User
+	sessions    map[int]todo.Session
+	lists       map[int]memoryList
+	members     map[int]todo.UserList
+	invites     map[int]memoryInvite
+	items       map[int]memoryItem
+	series      map[int]todo.ItemSeries
+	labels      map[int]memoryLabel
+	itemLabels  map[itemLabel]bool
+	subtasks    map[int]todo.Subtask
+	activities  []todo.Activity
+	undoTokens  map[string]memoryUndoToken
+	deliveries  []memoryDelivery
+	redemptions map[int][]int
+	// undo reverts the changes that are not committed yet, the latest last.
+	undo []func()
+}
+
+func newMemoryData() *memoryData {
+	return &memoryData{
+		ids:         make(map[string]int),
+		users:       make(map[int]todo.User),
+		sessions:    make(map[int]todo.Session),
+		lists:       make(map[int]memoryList),
+		members:     make(map[int]todo.UserList),
+		invites:     make(map[int]memoryInvite),
+		items:       make(map[int]memoryItem),
+		series:      make(map[int]todo.ItemSeries),
+		labels:      make(map[int]memoryLabel),
+		itemLabels:  make(map[itemLabel]bool),
+		subtasks:    make(map[int]todo.Subtask),
+		undoTokens:  make(map[string]memoryUndoToken),
+		redemptions: make(map[int][]int),
+	}
+}
+
+// rollback reverts the changes logged since the undo log had length mark.
+func (d *memoryData) rollback(mark int) {
+	for i := len(d.undo) - 1; i >= mark; i-- {
+		d.undo[i]()
+	}
+	d.undo = d.undo[:mark]
+}
+
+// put sets the row of key in table.
+func put[K comparable, V any](d *memoryData, table map[K]V, key K, row V) {
+	old, ok := table[key]
+	d.undo = append(d.undo, func() {
+		if ok {
+			table[key] = old
+		} else {
+			delete(table, key)
+		}
+	})
+	table[key] = row
+}
+
+// remove deletes the row of key from table.
+func remove[K comparable, V any](d *memoryData, table map[K]V, key K) {
+	old, ok := table[key]
+	if !ok {
+		return
+	}
+	d.undo = append(d.undo, func() { table[key] = old })
+	delete(table, key)
+}
+
+// replace sets the rows of a table kept as a slice. Appending to the old
+// slice is fine, as the old rows stay as they were; filtering it must build a
+// new slice.
+func replace[V any](d *memoryData, table *[]V, rows []V) {
+	old := *table
+	d.undo = append(d.undo, func() { *table = old })
+	*table = rows
+}
+
+// nextId returns the next value of the id sequence of table. Like a sequence
+// of the database it is not reverted by a rollback.
+func (d *memoryData) nextId(table string) int {
+	d.ids[table]++
+	return d.ids[table]
+}
+
+// member returns the membership of the user in the list.
+func (d *memoryData) member(userId, listId int) (todo.UserList, bool) {
+	for _, m := range d.members {
+		if m.UserId == userId && m.ListId == listId {
+			return m, true
+		}
+	}
+	return todo.UserList{}, false
+}
+
+// role returns the role of the user in the list, or "" if the user is not a
+// member.
+func (d *memoryData) role(userId, listId int) string {
+	m, _ := d.member(userId, listId)
+	return m.Role
+}
+
+// touchList and touchItem give a row a new version, like the bump_version
+// trigger does for every update of what clients see of it. Reminder
+// bookkeeping leaves the version alone.
+func touchList(list *memoryList) {
+	list.Version++
+	list.UpdatedAt = time.Now()
+}
+
+func touchItem(item *memoryItem) {
+	item.Version++
+	item.UpdatedAt = time.Now()
+}
+
+// memoryStore is a concurrency-safe store of all repository tables. Every
+// repository call holds its mutex, and a unit of work holds it from start to
+// end, so units of work are serializable and never need to be retried.
+type memoryStore struct {
+	mu   sync.Mutex
+	data *memoryData
+}
+
+func newMemoryStore() *memoryStore {
+	return &memoryStore{data: newMemoryData()}
+}
+
+// memoryTxKey marks the context of a unit of work of the store it holds, whose
+// mutex is already locked.
+type memoryTxKey struct{}
+
+func (s *memoryStore) inTx(ctx context.Context) bool {
+	store, ok := ctx.Value(memoryTxKey{}).(*memoryStore)
+	return ok && store == s
+}
+
+// read runs fn with the store locked.
+func (s *memoryStore) read(ctx context.Context, fn func(d *memoryData) error) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if !s.inTx(ctx) {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+	}
+	return fn(s.data)
+}
+
+// write runs fn with the store locked and rolls back all of its changes when
+// it fails, so a repository call is atomic like a transaction. Inside a unit
+// of work the changes stay in the undo log until the unit ends.
+func (s *memoryStore) write(ctx context.Context, fn func(d *memoryData) error) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	inTx := s.inTx(ctx)
+	if !inTx {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+	}
+	mark := len(s.data.undo)
+	if err := fn(s.data); err != nil {
+		s.data.rollback(mark)
+		return err
+	}
+	if !inTx {
+		s.data.undo = nil
+	}
+	return nil
+}
+
+// memoryPage sorts n rows by the sort key of the keyset and their id and
+// returns the indexes of the rows on the page and the cursor of the next one.
+// value gives the sort value and id of a row in the form of cursor values.
+func memoryPage(keys *keyset, n int, value func(i int) (string, int)) ([]int, string, error) {
+	rows := make([]int, n)
+	for i := range rows {
+		rows[i] = i
+	}
+	sort.SliceStable(rows, func(a, b int) bool {
+		va, ida := value(rows[a])
+		vb, idb := value(rows[b])
+		c, _ := compareSortValues(keys.key.cast, va, vb)
+		if c == 0 {
+			c = ida - idb
+		}
+		if keys.desc {
+			return c > 0
+		}
+		return c < 0
+	})
+
+	page := make([]int, 0, keys.limit+1)
+	for _, i := range rows {
+		if keys.after != nil {
+			v, id := value(i)
+			c, err := compareSortValues(keys.key.cast, v, keys.after.Value)
+			if err != nil {
+				return nil, "", errInvalidCursor
+			}
+			if c == 0 {
+				c = id - keys.after.Id
+			}
+			if keys.desc {
+				c = -c
+			}
+			if c <= 0 {
+				continue
+			}
+		}
+		page = append(page, i)
+		if len(page) > keys.limit {
+			break
+		}
+	}
+	count, next := keys.page(len(page), func(i int) (string, int) {
+		return value(page[i])
+	})
+	return page[:count], next, nil
+}
+
+// compareSortValues compares two cursor values of a sort key the way the
+// database compares them after casting them to cast.
+func compareSortValues(cast, a, b string) (int, error) {
+	switch cast {
+	case "int":
+		x, err := strconv.Atoi(a)
+		if err != nil {
+			return 0, err
+		}
+		y, err := strconv.Atoi(b)
+		if err != nil {
+			return 0, err
+		}
+		return x - y, nil
+	case "double precision":
+		x, err := strconv.ParseFloat(a, 64)
+		if err != nil {
+			return 0, err
+		}
+		y, err := strconv.ParseFloat(b, 64)
+		if err != nil {
+			return 0, err
+		}
+		switch {
+		case x < y:
+			return -1, nil
+		case x > y:
+			return 1, nil
+		}
+		return 0, nil
+	case "timestamptz":
+		x, err := parseSortTime(a)
+		if err != nil {
+			return 0, err
+		}
+		y, err := parseSortTime(b)
+		if err != nil {
+			return 0, err
+		}
+		return x.Compare(y), nil
+	default:
+		return strings.Compare(a, b), nil
+	}
+}
+
+// farFuture stands in for the 'infinity' timestamp of the due sort.
+var farFuture = time.Date(9999, 12, 31, 0, 0, 0, 0, time.UTC)
+
+func parseSortTime(value string) (time.Time, error) {
+	if value == "infinity" {
+		return farFuture, nil
+	}
+	return time.Parse(time.RFC3339Nano, value)
+}
+
+// marshalColumns returns the columns of a row as JSON, like to_jsonb does for
+// the snapshots of activity records.
+func marshalColumns(columns map[string]interface{}) []byte {
+	data, _ := json.Marshal(columns)
+	return data
+}
+
+// recordMemoryActivity is recordActivity for the memory store.
+func recordMemoryActivity(d *memoryData, activity todo.Activity) int {
+	activity.Id = d.nextId(activitiesTable)
+	activity.CreatedAt = time.Now()
+	replace(d, &d.activities, append(d.activities, activity))
+	return activity.Id
+}
+
+// recordMemoryChange is recordListChange and recordItemChange for the memory
+// store; itemId is 0 for changes of the list itself.
+func recordMemoryChange(d *memoryData, userId, listId, itemId int, action string, before, after []byte) (int, error) {
+	changes, err := diffSnapshots(before, after)
+	if err != nil {
+		return 0, err
+	}
+	activity := todo.Activity{ListId: listId, UserId: userId, Entity: todo.ActivityList, Action: action, Changes: changes}
+	if itemId != 0 {
+		activity.ItemId, activity.Entity = &itemId, todo.ActivityItem
+	}
+	return recordMemoryActivity(d, activity), nil
+}
+
+// errVersion is the error of a change the user may not make or that expected
+// another version, like checkVersion reports it for statements that matched
+// no rows.
+func errVersion(entity string, version int) error {
+	if version == 0 {
+		return todo.NewError(todo.ErrNotFound, "%s not found", entity)
+	}
+	return todo.NewError(todo.ErrPreconditionFailed, "%s has been changed by someone else", entity)
+}
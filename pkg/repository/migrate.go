@@ -0,0 +1,365 @@
+package repository
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"github.com/jmoiron/sqlx"
+	"github.com/sirupsen/logrus"
+	"io/fs"
+	"regexp"
+	"sort"
+	"strconv"
+	"time"
+)
+
+const (
+	migrationsTable = "migrations"
+	// legacyMigrationsTable is where the migrate CLI recorded the schema
+	// version before the migrations were embedded.
+	legacyMigrationsTable = "schema_migrations"
+)
+
+// migrationLockId is the key of the advisory lock that keeps replicas from
+// migrating the same database at the same time.
+const migrationLockId = 4_166_190_427
+
+// Migration states reported by Migrator.Status.
+const (
+	MigrationApplied  = "applied"
+	MigrationPending  = "pending"
+	MigrationModified = "modified"
+	MigrationMissing  = "missing"
+)
+
+var migrationFileName = regexp.MustCompile(`^(\d+)_(\w+)\.(up|down)\.sql$`)
+
+type migration struct {
+	version  int
+	name     string
+	up       string
+	down     string
+	checksum string
+}
+
+type appliedMigration struct {
+	Version   int       `db:"version"`
+	Name      string    `db:"name"`
+	Checksum  string    `db:"checksum"`
+	AppliedAt time.Time `db:"applied_at"`
+}
+
+// MigrationStatus is the state of a migration in the database. AppliedAt is
+// nil for pending migrations.
+type MigrationStatus struct {
+	Version   int
+	Name      string
+	State     string
+	AppliedAt *time.Time
+}
+
+// Migrator applies the migrations of a file system to the database. Applied
+// migrations are recorded with a checksum of their files, and the migrator
+// refuses to run when an applied migration has been changed or removed.
+type Migrator struct {
+	db         *sqlx.DB
+	migrations []migration
+}
+
+func NewMigrator(db *sqlx.DB, fsys fs.FS) (*Migrator, error) {
+	migrations, err := loadMigrations(fsys)
+	if err != nil {
+		return nil, err
+	}
+	return &Migrator{db: db, migrations: migrations}, nil
+}
+
+// loadMigrations reads the pairs of up and down files of fsys, ordered by
+// version.
+func loadMigrations(fsys fs.FS) ([]migration, error) {
+	entries, err := fs.ReadDir(fsys, ".")
+	if err != nil {
+		return nil, fmt.Errorf("load migrations: %w", err)
+	}
+
+	byVersion := make(map[int]*migration)
+	for _, entry := range entries {
+		match := migrationFileName.FindStringSubmatch(entry.Name())
+		if entry.IsDir() || match == nil {
+			continue
+		}
+		version, err := strconv.Atoi(match[1])
+		if err != nil || version == 0 {
+			return nil, fmt.Errorf("load migrations: invalid version in %s", entry.Name())
+		}
+		data, err := fs.ReadFile(fsys, entry.Name())
+		if err != nil {
+			return nil, fmt.Errorf("load migrations: %w", err)
+		}
+
+		m, ok := byVersion[version]
+		if !ok {
+			m = &migration{version: version, name: match[2]}
+			byVersion[version] = m
+		}
+		if m.name != match[2] {
+			return nil, fmt.Errorf("load migrations: version %d is used by %s and %s", version, m.name, match[2])
+		}
+		if match[3] == "up" {
+			m.up = string(data)
+		} else {
+			m.down = string(data)
+		}
+	}
+
+	migrations := make([]migration, 0, len(byVersion))
+	for _, m := range byVersion {
+		if m.up == "" || m.down == "" {
+			return nil, fmt.Errorf("load migrations: %06d_%s needs an up and a down file", m.version, m.name)
+		}
+		sum := sha256.Sum256([]byte(m.up + "\x00" + m.down))
+		m.checksum = hex.EncodeToString(sum[:])
+		migrations = append(migrations, *m)
+	}
+	sort.Slice(migrations, func(i, j int) bool {
+		return migrations[i].version < migrations[j].version
+	})
+	return migrations, nil
+}
+
+// Up applies all pending migrations.
+func (m *Migrator) Up(ctx context.Context) error {
+	if len(m.migrations) == 0 {
+		return nil
+	}
+	return m.Goto(ctx, m.migrations[len(m.migrations)-1].version)
+}
+
+// Down rolls back the last applied migration.
+func (m *Migrator) Down(ctx context.Context) error {
+	return m.locked(ctx, func(conn *sqlx.Conn, applied []appliedMigration) error {
+		if len(applied) == 0 {
+			return nil
+		}
+		target := 0
+		if len(applied) > 1 {
+			target = applied[len(applied)-2].Version
+		}
+		return m.migrate(ctx, conn, applied, target)
+	})
+}
+
+// Goto applies or rolls back migrations until version is the last applied
+// one. Version 0 rolls back all migrations.
+func (m *Migrator) Goto(ctx context.Context, version int) error {
+	if version != 0 && m.find(version) == nil {
+		return fmt.Errorf("migrate: unknown version %d", version)
+	}
+	return m.locked(ctx, func(conn *sqlx.Conn, applied []appliedMigration) error {
+		return m.migrate(ctx, conn, applied, version)
+	})
+}
+
+// Status lists the migrations in the order of their versions.
+func (m *Migrator) Status(ctx context.Context) ([]MigrationStatus, error) {
+	var statuses []MigrationStatus
+	err := m.locked(ctx, func(conn *sqlx.Conn, applied []appliedMigration) error {
+		appliedAt := make(map[int]appliedMigration, len(applied))
+		for _, a := range applied {
+			appliedAt[a.Version] = a
+			if m.find(a.Version) == nil {
+				at := a.AppliedAt
+				statuses = append(statuses, MigrationStatus{
+					Version: a.Version, Name: a.Name, State: MigrationMissing, AppliedAt: &at,
+				})
+			}
+		}
+		for _, mg := range m.migrations {
+			status := MigrationStatus{Version: mg.version, Name: mg.name, State: MigrationPending}
+			if a, ok := appliedAt[mg.version]; ok {
+				at := a.AppliedAt
+				status.State, status.AppliedAt = MigrationApplied, &at
+				if a.Checksum != mg.checksum {
+					status.State = MigrationModified
+				}
+			}
+			statuses = append(statuses, status)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	sort.SliceStable(statuses, func(i, j int) bool {
+		return statuses[i].Version < statuses[j].Version
+	})
+	return statuses, nil
+}
+
+func (m *Migrator) find(version int) *migration {
+	for i := range m.migrations {
+		if m.migrations[i].version == version {
+			return &m.migrations[i]
+		}
+	}
+	return nil
+}
+
+// locked runs fn with the advisory lock held on conn, after making sure the
+// migrations table exists. SQLite has no advisory locks; its database files
+// belong to a single process, which migrates before it serves requests.
+func (m *Migrator) locked(ctx context.Context, fn func(conn *sqlx.Conn, applied []appliedMigration) error) error {
+	conn, err := m.db.Connx(ctx)
+	if err != nil {
+		return fmt.Errorf("migrate: %w", err)
+	}
+	defer conn.Close()
+
+	sqlite := m.db.DriverName() == sqliteDriver
+	appliedAt := "timestamptz not null default now()"
+	if sqlite {
+		appliedAt = "datetime not null default (strftime('%Y-%m-%d %H:%M:%f000000+00:00', 'now'))"
+	} else {
+		if _, err = conn.ExecContext(ctx, "SELECT pg_advisory_lock($1)", migrationLockId); err != nil {
+			return fmt.Errorf("migrate: lock: %w", err)
+		}
+		defer conn.ExecContext(context.Background(), "SELECT pg_advisory_unlock($1)", migrationLockId)
+	}
+
+	createQuery := fmt.Sprintf(`CREATE TABLE IF NOT EXISTS %s (
+		version int not null unique,
+		name varchar(255) not null,
+		checksum varchar(64) not null,
+		applied_at %s)`, migrationsTable, appliedAt)
+	if _, err = conn.ExecContext(ctx, createQuery); err != nil {
+		return fmt.Errorf("migrate: %w", err)
+	}
+
+	var applied []appliedMigration
+	query := fmt.Sprintf("SELECT version, name, checksum, applied_at FROM %s ORDER BY version", migrationsTable)
+	if err = conn.SelectContext(ctx, &applied, query); err != nil {
+		return fmt.Errorf("migrate: %w", err)
+	}
+	if len(applied) == 0 && !sqlite {
+		if applied, err = m.adoptLegacy(ctx, conn); err != nil {
+			return fmt.Errorf("migrate: %w", err)
+		}
+	}
+	return fn(conn, applied)
+}
+
+// adoptLegacy records the migrations applied by the migrate CLI, so that a
+// database set up by hand is not migrated again.
+func (m *Migrator) adoptLegacy(ctx context.Context, conn *sqlx.Conn) ([]appliedMigration, error) {
+	var exists bool
+	if err := conn.GetContext(ctx, &exists, "SELECT to_regclass($1) IS NOT NULL", legacyMigrationsTable); err != nil {
+		return nil, err
+	}
+	if !exists {
+		return nil, nil
+	}
+
+	var legacy struct {
+		Version int  `db:"version"`
+		Dirty   bool `db:"dirty"`
+	}
+	query := fmt.Sprintf("SELECT version, dirty FROM %s LIMIT 1", legacyMigrationsTable)
+	if err := conn.GetContext(ctx, &legacy, query); err != nil {
+		return nil, err
+	}
+	if legacy.Dirty {
+		return nil, fmt.Errorf("%s is dirty at version %d, fix the schema by hand first", legacyMigrationsTable, legacy.Version)
+	}
+
+	var applied []appliedMigration
+	insertQuery := fmt.Sprintf("INSERT INTO %s (version, name, checksum) VALUES ($1, $2, $3) RETURNING applied_at",
+		migrationsTable)
+	for _, mg := range m.migrations {
+		if mg.version > legacy.Version {
+			break
+		}
+		a := appliedMigration{Version: mg.version, Name: mg.name, Checksum: mg.checksum}
+		if err := conn.GetContext(ctx, &a.AppliedAt, insertQuery, mg.version, mg.name, mg.checksum); err != nil {
+			return nil, err
+		}
+		applied = append(applied, a)
+	}
+	return applied, nil
+}
+
+// verify refuses applied migrations whose files have been changed or removed,
+// and pending migrations older than the last applied one.
+func (m *Migrator) verify(applied []appliedMigration) error {
+	last := 0
+	for _, a := range applied {
+		mg := m.find(a.Version)
+		if mg == nil {
+			return fmt.Errorf("migrate: applied migration %06d_%s is missing", a.Version, a.Name)
+		}
+		if mg.checksum != a.Checksum {
+			return fmt.Errorf("migrate: migration %06d_%s was changed after it was applied", a.Version, a.Name)
+		}
+		last = a.Version
+	}
+	for _, mg := range m.migrations {
+		if mg.version < last && !isApplied(applied, mg.version) {
+			return fmt.Errorf("migrate: migration %06d_%s is older than the applied version %d", mg.version, mg.name, last)
+		}
+	}
+	return nil
+}
+
+func isApplied(applied []appliedMigration, version int) bool {
+	for _, a := range applied {
+		if a.Version == version {
+			return true
+		}
+	}
+	return false
+}
+
+func (m *Migrator) migrate(ctx context.Context, conn *sqlx.Conn, applied []appliedMigration, target int) error {
+	if err := m.verify(applied); err != nil {
+		return err
+	}
+
+	for _, mg := range m.migrations {
+		if mg.version > target || isApplied(applied, mg.version) {
+			continue
+		}
+		insertQuery := fmt.Sprintf("INSERT INTO %s (version, name, checksum) VALUES ($1, $2, $3)", migrationsTable)
+		if err := runMigration(ctx, conn, mg.up, insertQuery, mg.version, mg.name, mg.checksum); err != nil {
+			return fmt.Errorf("migrate: apply %06d_%s: %w", mg.version, mg.name, err)
+		}
+		logrus.Infof("applied migration %06d_%s", mg.version, mg.name)
+	}
+
+	for i := len(applied) - 1; i >= 0 && applied[i].Version > target; i-- {
+		mg := m.find(applied[i].Version)
+		deleteQuery := fmt.Sprintf("DELETE FROM %s WHERE version = $1", migrationsTable)
+		if err := runMigration(ctx, conn, mg.down, deleteQuery, mg.version); err != nil {
+			return fmt.Errorf("migrate: roll back %06d_%s: %w", mg.version, mg.name, err)
+		}
+		logrus.Infof("rolled back migration %06d_%s", mg.version, mg.name)
+	}
+	return nil
+}
+
+// runMigration runs the statements of a migration file and the statement
+// recording it in one transaction.
+func runMigration(ctx context.Context, conn *sqlx.Conn, statements, record string, args ...interface{}) error {
+	tx, err := conn.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	if _, err = tx.ExecContext(ctx, statements); err != nil {
+		tx.Rollback()
+		return err
+	}
+	if _, err = tx.ExecContext(ctx, record, args...); err != nil {
+		tx.Rollback()
+		return err
+	}
+	return tx.Commit()
+}
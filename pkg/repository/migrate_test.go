@@ -0,0 +1,265 @@
+package repository
+
+import (
+	"context"
+	"do-app/schema"
+	"github.com/stretchr/testify/assert"
+	sqlmock "github.com/zhashkevych/go-sqlxmock"
+	"log"
+	"testing"
+	"testing/fstest"
+	"time"
+)
+
+var testMigrations = fstest.MapFS{
+	"000001_lists.up.sql":   {Data: []byte("CREATE TABLE lists (id serial)")},
+	"000001_lists.down.sql": {Data: []byte("DROP TABLE lists")},
+	"000002_items.up.sql":   {Data: []byte("CREATE TABLE items (id serial)")},
+	"000002_items.down.sql": {Data: []byte("DROP TABLE items")},
+	"README.md":             {Data: []byte("not a migration")},
+}
+
+func TestLoadMigrations(t *testing.T) {
+	testTable := []struct {
+		name     string
+		fsys     fstest.MapFS
+		versions []int
+		wantErr  bool
+	}{
+		{
+			name:     "OK",
+			fsys:     testMigrations,
+			versions: []int{1, 2},
+		},
+		{
+			name: "Missing down",
+			fsys: fstest.MapFS{
+				"000001_lists.up.sql": {Data: []byte("CREATE TABLE lists (id serial)")},
+			},
+			wantErr: true,
+		},
+		{
+			name: "Duplicate version",
+			fsys: fstest.MapFS{
+				"000001_lists.up.sql":   {Data: []byte("CREATE TABLE lists (id serial)")},
+				"000001_lists.down.sql": {Data: []byte("DROP TABLE lists")},
+				"000001_items.up.sql":   {Data: []byte("CREATE TABLE items (id serial)")},
+				"000001_items.down.sql": {Data: []byte("DROP TABLE items")},
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			migrations, err := loadMigrations(testCase.fsys)
+
+			if testCase.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			var versions []int
+			for _, m := range migrations {
+				versions = append(versions, m.version)
+			}
+			assert.Equal(t, testCase.versions, versions)
+		})
+	}
+}
+
+func TestLoadMigrations_Schema(t *testing.T) {
+	migrations, err := loadMigrations(schema.Migrations)
+
+	assert.NoError(t, err)
+	for i, m := range migrations {
+		assert.Equal(t, i+1, m.version)
+	}
+}
+
+func TestMigrator(t *testing.T) {
+	db, mock, err := sqlmock.Newx()
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer db.Close()
+
+	m, err := NewMigrator(db, testMigrations)
+	if err != nil {
+		log.Fatal(err)
+	}
+	lists, items := m.migrations[0], m.migrations[1]
+	appliedAt := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	appliedColumns := []string{"version", "name", "checksum", "applied_at"}
+
+	expectLocked := func(applied *sqlmock.Rows) {
+		mock.ExpectExec(`SELECT pg_advisory_lock`).WithArgs(sqlmock.AnyArg()).WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectExec(`CREATE TABLE IF NOT EXISTS migrations`).WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectQuery(`SELECT version, name, checksum, applied_at FROM migrations ORDER BY version`).
+			WillReturnRows(applied)
+	}
+	expectUnlock := func() {
+		mock.ExpectExec(`SELECT pg_advisory_unlock`).WithArgs(sqlmock.AnyArg()).WillReturnResult(sqlmock.NewResult(0, 0))
+	}
+
+	testTable := []struct {
+		name         string
+		run          func(ctx context.Context) error
+		mockBehavior func()
+		wantErr      bool
+	}{
+		{
+			name: "Up",
+			run:  m.Up,
+			mockBehavior: func() {
+				expectLocked(sqlmock.NewRows(appliedColumns).
+					AddRow(lists.version, lists.name, lists.checksum, appliedAt))
+				mock.ExpectBegin()
+				mock.ExpectExec(`CREATE TABLE items`).WillReturnResult(sqlmock.NewResult(0, 0))
+				mock.ExpectExec(`INSERT INTO migrations \(version, name, checksum\) VALUES`).
+					WithArgs(items.version, items.name, items.checksum).WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectCommit()
+				expectUnlock()
+			},
+		},
+		{
+			name: "Up adopts legacy version",
+			run:  m.Up,
+			mockBehavior: func() {
+				expectLocked(sqlmock.NewRows(appliedColumns))
+				mock.ExpectQuery(`SELECT to_regclass`).WithArgs("schema_migrations").
+					WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(true))
+				mock.ExpectQuery(`SELECT version, dirty FROM schema_migrations`).
+					WillReturnRows(sqlmock.NewRows([]string{"version", "dirty"}).AddRow(1, false))
+				mock.ExpectQuery(`INSERT INTO migrations (.+) RETURNING applied_at`).
+					WithArgs(lists.version, lists.name, lists.checksum).
+					WillReturnRows(sqlmock.NewRows([]string{"applied_at"}).AddRow(appliedAt))
+				mock.ExpectBegin()
+				mock.ExpectExec(`CREATE TABLE items`).WillReturnResult(sqlmock.NewResult(0, 0))
+				mock.ExpectExec(`INSERT INTO migrations`).
+					WithArgs(items.version, items.name, items.checksum).WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectCommit()
+				expectUnlock()
+			},
+		},
+		{
+			name: "Down",
+			run:  m.Down,
+			mockBehavior: func() {
+				expectLocked(sqlmock.NewRows(appliedColumns).
+					AddRow(lists.version, lists.name, lists.checksum, appliedAt).
+					AddRow(items.version, items.name, items.checksum, appliedAt))
+				mock.ExpectBegin()
+				mock.ExpectExec(`DROP TABLE items`).WillReturnResult(sqlmock.NewResult(0, 0))
+				mock.ExpectExec(`DELETE FROM migrations WHERE version = \$1`).
+					WithArgs(items.version).WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectCommit()
+				expectUnlock()
+			},
+		},
+		{
+			name: "Goto 0",
+			run: func(ctx context.Context) error {
+				return m.Goto(ctx, 0)
+			},
+			mockBehavior: func() {
+				expectLocked(sqlmock.NewRows(appliedColumns).
+					AddRow(lists.version, lists.name, lists.checksum, appliedAt).
+					AddRow(items.version, items.name, items.checksum, appliedAt))
+				for _, mg := range []migration{items, lists} {
+					mock.ExpectBegin()
+					mock.ExpectExec(mg.down).WillReturnResult(sqlmock.NewResult(0, 0))
+					mock.ExpectExec(`DELETE FROM migrations`).WithArgs(mg.version).WillReturnResult(sqlmock.NewResult(0, 1))
+					mock.ExpectCommit()
+				}
+				expectUnlock()
+			},
+		},
+		{
+			name: "Failed Migration",
+			run:  m.Up,
+			mockBehavior: func() {
+				expectLocked(sqlmock.NewRows(appliedColumns).
+					AddRow(lists.version, lists.name, lists.checksum, appliedAt))
+				mock.ExpectBegin()
+				mock.ExpectExec(`CREATE TABLE items`).WillReturnError(assert.AnError)
+				mock.ExpectRollback()
+				expectUnlock()
+			},
+			wantErr: true,
+		},
+		{
+			name: "Changed Migration",
+			run:  m.Up,
+			mockBehavior: func() {
+				expectLocked(sqlmock.NewRows(appliedColumns).
+					AddRow(lists.version, lists.name, "edited", appliedAt))
+				expectUnlock()
+			},
+			wantErr: true,
+		},
+		{
+			name: "Missing Migration",
+			run:  m.Up,
+			mockBehavior: func() {
+				expectLocked(sqlmock.NewRows(appliedColumns).
+					AddRow(3, "labels", "checksum", appliedAt))
+				expectUnlock()
+			},
+			wantErr: true,
+		},
+		{
+			name: "Unknown Version",
+			run: func(ctx context.Context) error {
+				return m.Goto(ctx, 5)
+			},
+			mockBehavior: func() {},
+			wantErr:      true,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			testCase.mockBehavior()
+
+			err := testCase.run(context.Background())
+
+			if testCase.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestMigrator_Status(t *testing.T) {
+	db, mock, err := sqlmock.Newx()
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer db.Close()
+
+	m, err := NewMigrator(db, testMigrations)
+	if err != nil {
+		log.Fatal(err)
+	}
+	appliedAt := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	mock.ExpectExec(`SELECT pg_advisory_lock`).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec(`CREATE TABLE IF NOT EXISTS migrations`).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectQuery(`SELECT version, name, checksum, applied_at FROM migrations`).
+		WillReturnRows(sqlmock.NewRows([]string{"version", "name", "checksum", "applied_at"}).
+			AddRow(1, "lists", "edited", appliedAt))
+	mock.ExpectExec(`SELECT pg_advisory_unlock`).WillReturnResult(sqlmock.NewResult(0, 0))
+
+	got, err := m.Status(context.Background())
+
+	assert.NoError(t, err)
+	assert.Equal(t, []MigrationStatus{
+		{Version: 1, Name: "lists", State: MigrationModified, AppliedAt: &appliedAt},
+		{Version: 2, Name: "items", State: MigrationPending},
+	}, got)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
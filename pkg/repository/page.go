@@ -0,0 +1,158 @@
+package repository
+
+import (
+	todo "do-app"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var errInvalidCursor = todo.NewError(todo.ErrValidation, "invalid cursor")
+
+// sortKey is the SQL expression a collection is ordered by and the type its
+// cursor value is cast to.
+type sortKey struct {
+	expr string
+	cast string
+}
+
+// cursor is the position after the last row of a page. It is handed out
+// base64-encoded so clients treat it as opaque.
+type cursor struct {
+	Sort  string `json:"s"`
+	Value string `json:"v"`
+	Id    int    `json:"id"`
+}
+
+func encodeCursor(c cursor) string {
+	data, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func decodeCursor(value string) (cursor, error) {
+	var c cursor
+	data, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil {
+		return c, errInvalidCursor
+	}
+	if err = json.Unmarshal(data, &c); err != nil {
+		return c, errInvalidCursor
+	}
+	return c, nil
+}
+
+// keyset paginates a query by the sort key and the row id, so every page is a
+// range scan that starts right after the last row of the previous one.
+type keyset struct {
+	sort   string
+	name   string
+	desc   bool
+	key    sortKey
+	idExpr string
+	limit  int
+	after  *cursor
+}
+
+func newKeyset(page todo.PageRequest, defaultSort string, keys map[string]sortKey, idExpr string) (*keyset, error) {
+	sort := page.Sort
+	if sort == "" {
+		sort = defaultSort
+	}
+	name := strings.TrimPrefix(sort, "-")
+	key, ok := keys[name]
+	if !ok {
+		return nil, todo.NewError(todo.ErrValidation, "unknown sort %q", name)
+	}
+
+	k := &keyset{sort: sort, name: name, desc: sort != name, key: key, idExpr: idExpr, limit: page.Limit}
+	if k.limit <= 0 {
+		k.limit = todo.DefaultPageLimit
+	}
+	if page.Cursor != "" {
+		after, err := decodeCursor(page.Cursor)
+		if err != nil {
+			return nil, err
+		}
+		if after.Sort != sort {
+			return nil, errInvalidCursor
+		}
+		k.after = &after
+	}
+	return k, nil
+}
+
+// conditions adds the condition that skips the rows of previous pages.
+func (k *keyset) conditions(conditions []string, args []interface{}) ([]string, []interface{}) {
+	if k.after == nil {
+		return conditions, args
+	}
+	op := ">"
+	if k.desc {
+		op = "<"
+	}
+	args = append(args, k.after.Value, k.after.Id)
+	conditions = append(conditions, fmt.Sprintf("(%s, %s) %s ($%d::%s, $%d)",
+		k.key.expr, k.idExpr, op, len(args)-1, k.key.cast, len(args)))
+	return conditions, args
+}
+
+// sqliteConditions is conditions for SQLite, which has no casts: the cursor
+// value is converted to the type of the sort key before it is bound.
+func (k *keyset) sqliteConditions(conditions []string, args []interface{}) ([]string, []interface{}, error) {
+	if k.after == nil {
+		return conditions, args, nil
+	}
+	var value interface{}
+	var err error
+	switch k.key.cast {
+	case "int":
+		value, err = strconv.Atoi(k.after.Value)
+	case "double precision":
+		value, err = strconv.ParseFloat(k.after.Value, 64)
+	case "timestamptz":
+		value = k.after.Value
+		if k.after.Value != "infinity" {
+			var t time.Time
+			t, err = time.Parse(time.RFC3339Nano, k.after.Value)
+			value = sqliteTime(t)
+		}
+	default:
+		value = k.after.Value
+	}
+	if err != nil {
+		return nil, nil, errInvalidCursor
+	}
+
+	op := ">"
+	if k.desc {
+		op = "<"
+	}
+	args = append(args, value, k.after.Id)
+	conditions = append(conditions, fmt.Sprintf("(%s, %s) %s (?%d, ?%d)",
+		k.key.expr, k.idExpr, op, len(args)-1, len(args)))
+	return conditions, args, nil
+}
+
+// orderBy fetches one row more than the limit to tell whether a next page
+// exists.
+func (k *keyset) orderBy() string {
+	direction := "ASC"
+	if k.desc {
+		direction = "DESC"
+	}
+	return fmt.Sprintf("ORDER BY %s %s, %s %s LIMIT %d", k.key.expr, direction, k.idExpr, direction, k.limit+1)
+}
+
+// page returns how many of the n fetched rows belong to the page and the cursor
+// of the next page, which is empty on the last one. last gives the sort value
+// and id of a row.
+func (k *keyset) page(n int, last func(i int) (string, int)) (int, string) {
+	if n <= k.limit {
+		return n, ""
+	}
+	value, id := last(k.limit - 1)
+	return k.limit, encodeCursor(cursor{Sort: k.sort, Value: value, Id: id})
+}
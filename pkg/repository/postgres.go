@@ -1,29 +1,65 @@
 package repository
 
 import (
+	todo "do-app"
 	"fmt"
 	"github.com/jmoiron/sqlx"
 )
 
 const (
-	usersTable      = "users"
-	todoListsTable  = "todo_lists"
-	usersListsTable = "users_lists"
-	todoItemsTable  = "todo_items"
-	listsItemsTable = "lists_items"
+	usersTable              = "users"
+	todoListsTable          = "todo_lists"
+	usersListsTable         = "users_lists"
+	todoItemsTable          = "todo_items"
+	listsItemsTable         = "lists_items"
+	sessionsTable           = "sessions"
+	listInvitesTable        = "list_invites"
+	inviteRedemptionsTable  = "invite_redemptions"
+	reminderDeliveriesTable = "reminder_deliveries"
+	itemSeriesTable         = "item_series"
+	labelsTable             = "labels"
+	itemsLabelsTable        = "items_labels"
+	subtasksTable           = "subtasks"
+	activitiesTable         = "activities"
+	undoTokensTable         = "undo_tokens"
 )
 
+// editorRoles is the SQL list of roles allowed to modify a list and its items.
+var editorRoles = fmt.Sprintf("'%s', '%s'", todo.RoleOwner, todo.RoleEditor)
+
+// Database drivers, by the names database/sql knows them under.
+const (
+	postgresDriver = "postgres"
+	sqliteDriver   = "sqlite3"
+)
+
+// Config locates the database. Driver is postgres, the default, or sqlite;
+// Path is the database file of SQLite, which uses none of the other fields.
 type Config struct {
+	Driver   string
 	Host     string
 	Port     string
 	Username string
 	Password string
 	DBName   string
 	SSLMode  string
+	Path     string
+}
+
+// NewDB opens the database of the driver cfg selects.
+func NewDB(cfg Config) (*sqlx.DB, error) {
+	switch cfg.Driver {
+	case "", postgresDriver:
+		return NewPostgresDB(cfg)
+	case "sqlite", sqliteDriver:
+		return NewSQLiteDB(cfg)
+	default:
+		return nil, fmt.Errorf("unknown database driver %q, expected postgres or sqlite", cfg.Driver)
+	}
 }
 
 func NewPostgresDB(cfg Config) (*sqlx.DB, error) {
-	db, err := sqlx.Open("postgres", fmt.Sprintf("host=%s port=%s user=%s dbname=%s password=%s sslmode=%s",
+	db, err := sqlx.Open(postgresDriver, fmt.Sprintf("host=%s port=%s user=%s dbname=%s password=%s sslmode=%s",
 		cfg.Host, cfg.Port, cfg.Username, cfg.DBName, cfg.Password, cfg.SSLMode))
 	if err != nil {
 		return nil, err
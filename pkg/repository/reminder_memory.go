@@ -0,0 +1,100 @@
+package repository
+
+import (
+	"context"
+	todo "do-app"
+	"fmt"
+	"sort"
+	"time"
+)
+
+type ReminderMemory struct {
+	store *memoryStore
+}
+
+func NewReminderMemory(store *memoryStore) *ReminderMemory {
+	return &ReminderMemory{store: store}
+}
+
+// ProcessDue claims up to limit due reminders and passes each of them to
+// deliver. The store is not locked while deliver runs, so a slow notifier does
+// not block requests; claiming leases the reminders for retryDelay instead,
+// like ReminderPostgres does. Every attempt is recorded as soon as it
+// finishes; failed reminders are retried after retryDelay.
+func (r *ReminderMemory) ProcessDue(ctx context.Context, limit int, retryDelay time.Duration,
+	deliver func(context.Context, todo.Reminder) error) (int, error) {
+	var reminders []todo.Reminder
+	err := r.store.write(ctx, func(d *memoryData) error {
+		now := time.Now()
+		for _, item := range d.items {
+			if item.RemindAt == nil || item.RemindAt.After(now) || item.Done || item.DeletedAt != nil ||
+				item.ReminderSentAt != nil || (item.ReminderRetryAt != nil && item.ReminderRetryAt.After(now)) {
+				continue
+			}
+			reminders = append(reminders, todo.Reminder{ItemId: item.Id, ListId: item.ListId, Title: item.Title,
+				Description: item.Description, DueAt: item.DueAt, RemindAt: *item.RemindAt})
+		}
+		sort.Slice(reminders, func(i, j int) bool {
+			return reminders[i].RemindAt.Before(reminders[j].RemindAt)
+		})
+		if len(reminders) > limit {
+			reminders = reminders[:limit]
+		}
+		leasedUntil := now.Add(retryDelay)
+		for _, reminder := range reminders {
+			item := d.items[reminder.ItemId]
+			item.ReminderRetryAt = &leasedUntil
+			put(d, d.items, item.Id, item)
+		}
+
+		for i := range reminders {
+			var members []todo.UserList
+			for _, m := range d.members {
+				if m.ListId == reminders[i].ListId {
+					members = append(members, m)
+				}
+			}
+			sort.Slice(members, func(a, b int) bool {
+				return members[a].UserId < members[b].UserId
+			})
+			for _, m := range members {
+				user := d.users[m.UserId]
+				reminders[i].Recipients = append(reminders[i].Recipients,
+					todo.ReminderRecipient{UserId: user.Id, Name: user.Name, Username: user.Username})
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, fmt.Errorf("ProcessDue reminder repository: %w", err)
+	}
+
+	for _, reminder := range reminders {
+		delivery := memoryDelivery{ItemId: reminder.ItemId, AttemptedAt: time.Now(), Success: true}
+		if err := deliver(ctx, reminder); err != nil {
+			message := err.Error()
+			delivery.Success, delivery.Error = false, &message
+		}
+
+		err = r.store.write(ctx, func(d *memoryData) error {
+			item, ok := d.items[delivery.ItemId]
+			if !ok {
+				return nil
+			}
+			replace(d, &d.deliveries, append(d.deliveries, delivery))
+			if delivery.Success {
+				sentAt := delivery.AttemptedAt
+				item.ReminderSentAt, item.ReminderRetryAt = &sentAt, nil
+			} else {
+				retryAt := delivery.AttemptedAt.Add(retryDelay)
+				item.ReminderRetryAt = &retryAt
+			}
+			put(d, d.items, delivery.ItemId, item)
+			return nil
+		})
+		if err != nil {
+			return 0, fmt.Errorf("ProcessDue reminder repository: %w", err)
+		}
+	}
+	return len(reminders), nil
+}
@@ -0,0 +1,105 @@
+package repository
+
+import (
+	"context"
+	todo "do-app"
+	"fmt"
+	"github.com/jmoiron/sqlx"
+	"time"
+)
+
+type ReminderPostgres struct {
+	db *sqlx.DB
+}
+
+func NewReminderPostgres(db *sqlx.DB) *ReminderPostgres {
+	return &ReminderPostgres{db: db}
+}
+
+// ProcessDue claims up to limit due reminders and passes each of them to
+// deliver. No locks are held while deliver runs: claiming leases the
+// reminders for retryDelay, so other replicas skip them, and a reminder whose
+// process dies during delivery is picked up again when its lease runs out.
+// Every attempt is recorded as soon as it finishes; failed reminders are
+// retried after retryDelay.
+func (r *ReminderPostgres) ProcessDue(ctx context.Context, limit int, retryDelay time.Duration,
+	deliver func(context.Context, todo.Reminder) error) (int, error) {
+	reminders, err := r.claim(ctx, limit, retryDelay)
+	if err != nil {
+		return 0, fmt.Errorf("ProcessDue reminder repository: %w", err)
+	}
+
+	for _, reminder := range reminders {
+		var deliveryErr *string
+		if err := deliver(ctx, reminder); err != nil {
+			message := err.Error()
+			deliveryErr = &message
+		}
+		if err = r.record(ctx, reminder.ItemId, deliveryErr, retryDelay); err != nil {
+			return 0, fmt.Errorf("ProcessDue reminder repository: %w", err)
+		}
+	}
+	return len(reminders), nil
+}
+
+// claim leases up to limit due reminders and reads their recipients. Rows
+// locked by another replica are skipped, so a reminder is only claimed once.
+func (r *ReminderPostgres) claim(ctx context.Context, limit int, lease time.Duration) ([]todo.Reminder, error) {
+	tx, err := beginTx(ctx, r.db)
+	if err != nil {
+		return nil, err
+	}
+
+	var reminders []todo.Reminder
+	claimQuery := fmt.Sprintf(`UPDATE %s ti SET reminder_retry_at = $2 FROM %s li
+								 WHERE li.item_id = ti.id AND ti.id IN (SELECT id FROM %s
+								 WHERE remind_at <= now() AND reminder_sent_at IS NULL AND done = false AND deleted_at IS NULL
+								 AND (reminder_retry_at IS NULL OR reminder_retry_at <= now())
+								 ORDER BY remind_at LIMIT $1 FOR UPDATE SKIP LOCKED)
+								 RETURNING ti.id, li.list_id, ti.title, ti.description, ti.due_at, ti.remind_at`,
+		todoItemsTable, listsItemsTable, todoItemsTable)
+	if err = tx.SelectContext(ctx, &reminders, claimQuery, limit, time.Now().Add(lease)); err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+
+	recipientsQuery := fmt.Sprintf(`SELECT u.id, u.name, u.username FROM %s u
+								 INNER JOIN %s ul on ul.user_id = u.id WHERE ul.list_id = $1 ORDER BY u.id`,
+		usersTable, usersListsTable)
+	for i := range reminders {
+		if err = tx.SelectContext(ctx, &reminders[i].Recipients, recipientsQuery, reminders[i].ListId); err != nil {
+			tx.Rollback()
+			return nil, err
+		}
+	}
+	return reminders, tx.Commit()
+}
+
+// record stores the outcome of a delivery: a sent reminder is done, a failed
+// one is due again after retryDelay.
+func (r *ReminderPostgres) record(ctx context.Context, itemId int, deliveryErr *string, retryDelay time.Duration) error {
+	tx, err := beginTx(ctx, r.db)
+	if err != nil {
+		return err
+	}
+
+	deliveryQuery := fmt.Sprintf("INSERT INTO %s (item_id, success, error) VALUES ($1, $2, $3)", reminderDeliveriesTable)
+	if _, err = tx.ExecContext(ctx, deliveryQuery, itemId, deliveryErr == nil, deliveryErr); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	if deliveryErr == nil {
+		sentQuery := fmt.Sprintf("UPDATE %s SET reminder_sent_at = now(), reminder_retry_at = NULL WHERE id = $1",
+			todoItemsTable)
+		_, err = tx.ExecContext(ctx, sentQuery, itemId)
+	} else {
+		retryQuery := fmt.Sprintf("UPDATE %s SET reminder_retry_at = $1 WHERE id = $2", todoItemsTable)
+		_, err = tx.ExecContext(ctx, retryQuery, time.Now().Add(retryDelay), itemId)
+	}
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+	return tx.Commit()
+}
@@ -0,0 +1,136 @@
+package repository
+
+import (
+	"context"
+	todo "do-app"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	sqlmock "github.com/zhashkevych/go-sqlxmock"
+	"log"
+	"testing"
+	"time"
+)
+
+func TestReminderPostgres_ProcessDue(t *testing.T) {
+	db, mock, err := sqlmock.Newx()
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer db.Close()
+
+	r := NewReminderPostgres(db)
+
+	remindAt := time.Date(2030, 1, 2, 9, 0, 0, 0, time.UTC)
+
+	type mockBehavior func()
+
+	testTable := []struct {
+		name         string
+		deliverErr   error
+		mockBehavior mockBehavior
+		processed    int
+		wantErr      bool
+	}{
+		{
+			name: "Delivered",
+			mockBehavior: func() {
+				mock.ExpectBegin()
+
+				rows := sqlmock.NewRows([]string{"id", "list_id", "title", "description", "due_at", "remind_at"}).
+					AddRow(1, 2, "report", "", nil, remindAt)
+				mock.ExpectQuery(`UPDATE todo_items ti SET reminder_retry_at = (.+) FOR UPDATE SKIP LOCKED`).
+					WithArgs(10, sqlmock.AnyArg()).WillReturnRows(rows)
+
+				recipients := sqlmock.NewRows([]string{"id", "name", "username"}).AddRow(1, "Test", "test@example.com")
+				mock.ExpectQuery(`SELECT u.id, u.name, u.username FROM users u`).WithArgs(2).WillReturnRows(recipients)
+
+				mock.ExpectCommit()
+
+				mock.ExpectBegin()
+				mock.ExpectExec(`INSERT INTO reminder_deliveries`).
+					WithArgs(1, true, nil).WillReturnResult(sqlmock.NewResult(1, 1))
+				mock.ExpectExec(`UPDATE todo_items SET reminder_sent_at = now\(\)`).
+					WithArgs(1).WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectCommit()
+			},
+			processed: 1,
+		},
+		{
+			name:       "Delivery failed",
+			deliverErr: errors.New("connection refused"),
+			mockBehavior: func() {
+				mock.ExpectBegin()
+
+				rows := sqlmock.NewRows([]string{"id", "list_id", "title", "description", "due_at", "remind_at"}).
+					AddRow(1, 2, "report", "", nil, remindAt)
+				mock.ExpectQuery(`UPDATE todo_items ti SET reminder_retry_at = (.+) FOR UPDATE SKIP LOCKED`).
+					WithArgs(10, sqlmock.AnyArg()).WillReturnRows(rows)
+
+				recipients := sqlmock.NewRows([]string{"id", "name", "username"}).AddRow(1, "Test", "test")
+				mock.ExpectQuery(`SELECT u.id, u.name, u.username FROM users u`).WithArgs(2).WillReturnRows(recipients)
+
+				mock.ExpectCommit()
+
+				mock.ExpectBegin()
+				mock.ExpectExec(`INSERT INTO reminder_deliveries`).
+					WithArgs(1, false, "connection refused").WillReturnResult(sqlmock.NewResult(1, 1))
+				mock.ExpectExec(`UPDATE todo_items SET reminder_retry_at`).
+					WithArgs(sqlmock.AnyArg(), 1).WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectCommit()
+			},
+			processed: 1,
+		},
+		{
+			name: "Record error",
+			mockBehavior: func() {
+				mock.ExpectBegin()
+
+				rows := sqlmock.NewRows([]string{"id", "list_id", "title", "description", "due_at", "remind_at"}).
+					AddRow(1, 2, "report", "", nil, remindAt)
+				mock.ExpectQuery(`UPDATE todo_items ti SET reminder_retry_at = (.+) FOR UPDATE SKIP LOCKED`).
+					WithArgs(10, sqlmock.AnyArg()).WillReturnRows(rows)
+				mock.ExpectQuery(`SELECT u.id, u.name, u.username FROM users u`).WithArgs(2).
+					WillReturnRows(sqlmock.NewRows([]string{"id", "name", "username"}))
+				mock.ExpectCommit()
+
+				mock.ExpectBegin()
+				mock.ExpectExec(`INSERT INTO reminder_deliveries`).
+					WithArgs(1, true, nil).WillReturnError(assert.AnError)
+				mock.ExpectRollback()
+			},
+			wantErr: true,
+		},
+		{
+			name: "Claim error",
+			mockBehavior: func() {
+				mock.ExpectBegin()
+				mock.ExpectQuery(`UPDATE todo_items ti SET reminder_retry_at = (.+) FOR UPDATE SKIP LOCKED`).
+					WithArgs(10, sqlmock.AnyArg()).WillReturnError(assert.AnError)
+				mock.ExpectRollback()
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			testCase.mockBehavior()
+
+			var delivered []todo.Reminder
+			got, err := r.ProcessDue(context.Background(), 10, time.Minute, func(ctx context.Context, reminder todo.Reminder) error {
+				delivered = append(delivered, reminder)
+				return testCase.deliverErr
+			})
+
+			if testCase.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, testCase.processed, got)
+				assert.Len(t, delivered, testCase.processed)
+				assert.Len(t, delivered[0].Recipients, 1)
+			}
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
@@ -0,0 +1,113 @@
+package repository
+
+import (
+	"context"
+	todo "do-app"
+	"fmt"
+	"github.com/jmoiron/sqlx"
+	"time"
+)
+
+type ReminderSQLite struct {
+	db *sqlx.DB
+}
+
+func NewReminderSQLite(db *sqlx.DB) *ReminderSQLite {
+	return &ReminderSQLite{db: db}
+}
+
+// ProcessDue claims up to limit due reminders and passes each of them to
+// deliver, like ReminderPostgres does. A transaction would hold the write lock
+// of the whole database while deliver runs, so claiming leases the reminders
+// for retryDelay instead, and a reminder whose process dies during delivery
+// is picked up again when its lease runs out. Every attempt is recorded as
+// soon as it finishes; failed reminders are retried after retryDelay.
+func (r *ReminderSQLite) ProcessDue(ctx context.Context, limit int, retryDelay time.Duration,
+	deliver func(context.Context, todo.Reminder) error) (int, error) {
+	reminders, err := r.claim(ctx, limit, retryDelay)
+	if err != nil {
+		return 0, fmt.Errorf("ProcessDue reminder repository: %w", err)
+	}
+
+	for _, reminder := range reminders {
+		var deliveryErr *string
+		if err := deliver(ctx, reminder); err != nil {
+			message := err.Error()
+			deliveryErr = &message
+		}
+		if err = r.record(ctx, reminder.ItemId, deliveryErr, retryDelay); err != nil {
+			return 0, fmt.Errorf("ProcessDue reminder repository: %w", err)
+		}
+	}
+	return len(reminders), nil
+}
+
+// claim leases up to limit due reminders and reads their recipients. The
+// transaction holds the write lock of the database, so a reminder is only
+// claimed once.
+func (r *ReminderSQLite) claim(ctx context.Context, limit int, lease time.Duration) ([]todo.Reminder, error) {
+	tx, err := beginTx(ctx, r.db)
+	if err != nil {
+		return nil, err
+	}
+
+	var reminders []todo.Reminder
+	dueQuery := fmt.Sprintf(`SELECT ti.id, li.list_id, ti.title, ti.description, ti.due_at, ti.remind_at FROM %s ti
+								 INNER JOIN %s li on li.item_id = ti.id
+								 WHERE ti.remind_at <= ?1 AND ti.reminder_sent_at IS NULL AND ti.done = false AND ti.deleted_at IS NULL
+								 AND (ti.reminder_retry_at IS NULL OR ti.reminder_retry_at <= ?1)
+								 ORDER BY ti.remind_at LIMIT ?2`,
+		todoItemsTable, listsItemsTable)
+	if err = tx.SelectContext(ctx, &reminders, dueQuery, sqliteTime(time.Now()), limit); err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+
+	leaseQuery := fmt.Sprintf("UPDATE %s SET reminder_retry_at = ?1 WHERE id = ?2", todoItemsTable)
+	recipientsQuery := fmt.Sprintf(`SELECT u.id, u.name, u.username FROM %s u
+								 INNER JOIN %s ul on ul.user_id = u.id WHERE ul.list_id = ?1 ORDER BY u.id`,
+		usersTable, usersListsTable)
+	leasedUntil := sqliteTime(time.Now().Add(lease))
+	for i := range reminders {
+		if _, err = tx.ExecContext(ctx, leaseQuery, leasedUntil, reminders[i].ItemId); err != nil {
+			tx.Rollback()
+			return nil, err
+		}
+		if err = tx.SelectContext(ctx, &reminders[i].Recipients, recipientsQuery, reminders[i].ListId); err != nil {
+			tx.Rollback()
+			return nil, err
+		}
+	}
+	return reminders, tx.Commit()
+}
+
+// record stores the outcome of a delivery: a sent reminder is done, a failed
+// one is due again after retryDelay. Items purged during the delivery have
+// nothing left to record the attempt for.
+func (r *ReminderSQLite) record(ctx context.Context, itemId int, deliveryErr *string, retryDelay time.Duration) error {
+	tx, err := beginTx(ctx, r.db)
+	if err != nil {
+		return err
+	}
+
+	deliveryQuery := fmt.Sprintf(`INSERT INTO %s (item_id, success, error) SELECT ?1, ?2, ?3
+								 WHERE EXISTS (SELECT 1 FROM %s WHERE id = ?1)`, reminderDeliveriesTable, todoItemsTable)
+	if _, err = tx.ExecContext(ctx, deliveryQuery, itemId, deliveryErr == nil, deliveryErr); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	if deliveryErr == nil {
+		sentQuery := fmt.Sprintf("UPDATE %s SET reminder_sent_at = ?1, reminder_retry_at = NULL WHERE id = ?2",
+			todoItemsTable)
+		_, err = tx.ExecContext(ctx, sentQuery, sqliteTime(time.Now()), itemId)
+	} else {
+		retryQuery := fmt.Sprintf("UPDATE %s SET reminder_retry_at = ?1 WHERE id = ?2", todoItemsTable)
+		_, err = tx.ExecContext(ctx, retryQuery, sqliteTime(time.Now().Add(retryDelay)), itemId)
+	}
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+	return tx.Commit()
+}
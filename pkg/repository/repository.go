@@ -1,41 +1,197 @@
 package repository
 
 import (
+	"context"
 	todo "do-app"
 	"github.com/jmoiron/sqlx"
+	"time"
 )
 
+// Transactor runs units of work, which make the repository calls of a
+// service method atomic.
+type Transactor interface {
+	WithinTx(ctx context.Context, opts TxOptions, fn func(ctx context.Context) error) error
+}
+
 type Authorization interface {
-	CreateUser(user todo.User) (int, error)
-	GetUser(username, password string) (todo.User, error)
+	CreateUser(ctx context.Context, user todo.User) (int, error)
+	GetUser(ctx context.Context, username string) (todo.User, error)
+	UpdatePasswordHash(ctx context.Context, userId int, passwordHash, algo string) error
+}
+
+type Sessions interface {
+	Create(ctx context.Context, session todo.Session) (int, error)
+	GetByRefreshToken(ctx context.Context, refreshTokenHash string) (todo.Session, error)
+	Rotate(ctx context.Context, sessionId int, oldHash, newHash string, expiresAt time.Time) error
+	Revoke(ctx context.Context, sessionId int) error
+	IsActive(ctx context.Context, sessionId int) (bool, error)
 }
 
 type TodoLists interface {
-	Create(userId int, list todo.TodoList) (int, error)
-	GetAll(userId int) ([]todo.TodoList, error)
-	GetById(userId, listId int) (todo.TodoList, error)
-	Delete(userId, listId int) error
-	Update(userId, listId int, input todo.UpdateListInput) error
+	Create(ctx context.Context, userId int, list todo.TodoList) (int, error)
+	GetAll(ctx context.Context, userId int, filter todo.ListFilter, page todo.PageRequest) ([]todo.TodoList, string, error)
+	GetById(ctx context.Context, userId, listId int) (todo.TodoList, error)
+	Delete(ctx context.Context, userId, listId, version int) (int, error)
+	Update(ctx context.Context, userId, listId int, input todo.UpdateListInput, version int) (int, error)
+}
+
+type ListMembers interface {
+	GetAll(ctx context.Context, listId int) ([]todo.ListMember, error)
+	GetRole(ctx context.Context, userId, listId int) (string, error)
+	Add(ctx context.Context, listId int, input todo.AddMemberInput) (int, error)
+	UpdateRole(ctx context.Context, listId, memberId int, role string) error
+	Remove(ctx context.Context, listId, memberId int) error
+	CountOwners(ctx context.Context, listId int) (int, error)
+}
+
+type ListInvites interface {
+	Create(ctx context.Context, invite todo.ListInvite, tokenHash string) (int, error)
+	GetAll(ctx context.Context, listId int) ([]todo.ListInvite, error)
+	Revoke(ctx context.Context, listId, inviteId int) error
+	Redeem(ctx context.Context, tokenHash string, userId int) (int, error)
 }
 
 type TodoItems interface {
-	Create(listId int, input todo.TodoItem) (int, error)
-	GetAll(userId, listId int) ([]todo.TodoItem, error)
-	GetById(userId, itemId int) (todo.TodoItem, error)
-	Delete(userId, itemId int) error
-	Update(userId, itemId int, input todo.UpdateItemInput) error
+	Create(ctx context.Context, userId, listId int, input todo.TodoItem) (int, error)
+	GetAll(ctx context.Context, userId, listId int, filter todo.ItemFilter,
+		page todo.PageRequest) ([]todo.TodoItem, string, error)
+	GetAllByUser(ctx context.Context, userId int, filter todo.ItemFilter,
+		page todo.PageRequest) ([]todo.TodoItem, string, error)
+	GetById(ctx context.Context, userId, itemId int) (todo.TodoItem, error)
+	Delete(ctx context.Context, userId, itemId, version int) (int, error)
+	Update(ctx context.Context, userId, itemId int, input todo.UpdateItemInput, version int) (int, error)
+	Move(ctx context.Context, userId, itemId, listId int, input todo.MoveItemInput) (float64, error)
+	CompleteOccurrence(ctx context.Context, userId, itemId int, input todo.UpdateItemInput, next todo.TodoItem,
+		version int) (int, error)
+	GetSeries(ctx context.Context, itemId int) (todo.ItemSeries, error)
+	SetSeriesRule(ctx context.Context, userId, itemId int, rule string, dtstart time.Time, version int) (int, error)
+	UpdateSeries(ctx context.Context, userId, seriesId int, input todo.UpdateSeriesInput) error
+	StopSeries(ctx context.Context, userId, seriesId int) error
+}
+
+type Subtasks interface {
+	Create(ctx context.Context, itemId int, subtask todo.Subtask) (int, error)
+	GetAll(ctx context.Context, itemId int) ([]todo.Subtask, error)
+	Update(ctx context.Context, itemId, subtaskId int, input todo.UpdateSubtaskInput) error
+	Delete(ctx context.Context, itemId, subtaskId int) error
+	Progress(ctx context.Context, itemId int) (int, int, error)
+}
+
+type Labels interface {
+	Create(ctx context.Context, userId int, label todo.Label) (int, error)
+	GetAll(ctx context.Context, userId int) ([]todo.Label, error)
+	GetById(ctx context.Context, userId, labelId int) (todo.Label, error)
+	GetByItem(ctx context.Context, userId, itemId int) ([]todo.Label, error)
+	Update(ctx context.Context, userId, labelId int, input todo.UpdateLabelInput) error
+	Delete(ctx context.Context, userId, labelId int) error
+	Attach(ctx context.Context, itemId, labelId int) error
+	Detach(ctx context.Context, userId, itemId, labelId int) error
+}
+
+type Search interface {
+	Search(ctx context.Context, userId int, query todo.SearchQuery) ([]todo.SearchResult, error)
+}
+
+type Trash interface {
+	GetAll(ctx context.Context, userId int) ([]todo.TrashEntry, error)
+	RestoreList(ctx context.Context, userId, listId int) error
+	RestoreItem(ctx context.Context, userId, itemId int) error
+	Purge(ctx context.Context, before time.Time) (int64, error)
+}
+
+type Activity interface {
+	GetByList(ctx context.Context, listId int, page todo.PageRequest) ([]todo.Activity, string, error)
+	GetByItem(ctx context.Context, itemId int, page todo.PageRequest) ([]todo.Activity, string, error)
+}
+
+type Undo interface {
+	Create(ctx context.Context, userId, activityId int, tokenHash string, expiresAt time.Time) error
+	Undo(ctx context.Context, userId int, tokenHash string) error
+}
+
+type Reminders interface {
+	ProcessDue(ctx context.Context, limit int, retryDelay time.Duration, deliver func(context.Context, todo.Reminder) error) (int, error)
 }
 
 type Repository struct {
+	Transactor
 	Authorization
+	Sessions
 	TodoLists
+	ListMembers
+	ListInvites
 	TodoItems
+	Subtasks
+	Labels
+	Search
+	Trash
+	Activity
+	Undo
+	Reminders
 }
 
-func NewRepository(db *sqlx.DB) *Repository {
+// NewRepository returns the repositories for the database of db, which is
+// either Postgres or SQLite.
+func NewRepository(db *sqlx.DB, txConfig TxConfig) *Repository {
+	if db.DriverName() == sqliteDriver {
+		return newSQLiteRepository(db, txConfig)
+	}
 	return &Repository{
+		Transactor:    NewTxPostgres(db, txConfig),
 		Authorization: NewAuthPostgres(db),
+		Sessions:      NewSessionPostgres(db),
 		TodoLists:     NewTodoListPostgres(db),
+		ListMembers:   NewListMemberPostgres(db),
+		ListInvites:   NewListInvitePostgres(db),
 		TodoItems:     NewTodoItemPostgres(db),
+		Subtasks:      NewSubtaskPostgres(db),
+		Labels:        NewLabelPostgres(db),
+		Search:        NewSearchPostgres(db),
+		Trash:         NewTrashPostgres(db),
+		Activity:      NewActivityPostgres(db),
+		Undo:          NewUndoPostgres(db),
+		Reminders:     NewReminderPostgres(db),
+	}
+}
+
+func newSQLiteRepository(db *sqlx.DB, txConfig TxConfig) *Repository {
+	return &Repository{
+		Transactor:    NewTxSQLite(db, txConfig),
+		Authorization: NewAuthSQLite(db),
+		Sessions:      NewSessionSQLite(db),
+		TodoLists:     NewTodoListSQLite(db),
+		ListMembers:   NewListMemberSQLite(db),
+		ListInvites:   NewListInviteSQLite(db),
+		TodoItems:     NewTodoItemSQLite(db),
+		Subtasks:      NewSubtaskSQLite(db),
+		Labels:        NewLabelSQLite(db),
+		Search:        NewSearchSQLite(db),
+		Trash:         NewTrashSQLite(db),
+		Activity:      NewActivitySQLite(db),
+		Undo:          NewUndoSQLite(db),
+		Reminders:     NewReminderSQLite(db),
+	}
+}
+
+// NewMemoryRepository returns repositories that keep all data in memory, for
+// running the API without a database. They follow the same ownership rules as
+// the Postgres repositories; the data is lost when the process exits.
+func NewMemoryRepository() *Repository {
+	store := newMemoryStore()
+	return &Repository{
+		Transactor:    NewTxMemory(store),
+		Authorization: NewAuthMemory(store),
+		Sessions:      NewSessionMemory(store),
+		TodoLists:     NewTodoListMemory(store),
+		ListMembers:   NewListMemberMemory(store),
+		ListInvites:   NewListInviteMemory(store),
+		TodoItems:     NewTodoItemMemory(store),
+		Subtasks:      NewSubtaskMemory(store),
+		Labels:        NewLabelMemory(store),
+		Search:        NewSearchMemory(store),
+		Trash:         NewTrashMemory(store),
+		Activity:      NewActivityMemory(store),
+		Undo:          NewUndoMemory(store),
+		Reminders:     NewReminderMemory(store),
 	}
 }
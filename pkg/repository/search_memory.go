@@ -0,0 +1,229 @@
+package repository
+
+import (
+	"context"
+	todo "do-app"
+	"fmt"
+	"html"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// Weights of title and description matches, like the default weights of
+// ts_rank for the A and B parts of the search columns.
+const (
+	searchTitleWeight       = 1.0
+	searchDescriptionWeight = 0.4
+	// searchSnippetWords is the number of words of a snippet.
+	searchSnippetWords = 20
+)
+
+type SearchMemory struct {
+	store *memoryStore
+}
+
+func NewSearchMemory(store *memoryStore) *SearchMemory {
+	return &SearchMemory{store: store}
+}
+
+// searchTerm is a word or quoted phrase of a query split into lexemes. Negated
+// terms must not occur in a match.
+type searchTerm struct {
+	lexemes []string
+	negated bool
+}
+
+// parseSearchQuery splits a query in web search syntax into alternatives
+// separated by "or", each of which matches when all of its terms do.
+func parseSearchQuery(query string) [][]searchTerm {
+	var alternatives [][]searchTerm
+	var terms []searchTerm
+	for len(query) > 0 {
+		query = strings.TrimLeftFunc(query, unicode.IsSpace)
+		if query == "" {
+			break
+		}
+		negated := strings.HasPrefix(query, "-")
+		if negated {
+			query = query[1:]
+		}
+
+		var text string
+		if strings.HasPrefix(query, `"`) {
+			end := strings.Index(query[1:], `"`)
+			if end < 0 {
+				text, query = query[1:], ""
+			} else {
+				text, query = query[1:end+1], query[end+2:]
+			}
+		} else {
+			end := strings.IndexFunc(query, unicode.IsSpace)
+			if end < 0 {
+				end = len(query)
+			}
+			text, query = query[:end], query[end:]
+			if !negated && strings.EqualFold(text, "or") && len(terms) > 0 {
+				alternatives, terms = append(alternatives, terms), nil
+				continue
+			}
+		}
+		if lexemes := searchLexemes(text); len(lexemes) > 0 {
+			terms = append(terms, searchTerm{lexemes: lexemes, negated: negated})
+		}
+	}
+	if len(terms) > 0 {
+		alternatives = append(alternatives, terms)
+	}
+	return alternatives
+}
+
+// searchLexemes splits text into lower case words, like the simple text
+// search configuration does.
+func searchLexemes(text string) []string {
+	return strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
+
+// occurrences counts where the lexemes of the term follow each other in text.
+func (t searchTerm) occurrences(text []string) int {
+	count := 0
+	for i := 0; i+len(t.lexemes) <= len(text); i++ {
+		match := true
+		for j, lexeme := range t.lexemes {
+			if text[i+j] != lexeme {
+				match = false
+				break
+			}
+		}
+		if match {
+			count++
+		}
+	}
+	return count
+}
+
+// searchRank returns the rank of a title and description for the query, or 0
+// if they do not match it.
+func searchRank(alternatives [][]searchTerm, title, description string) float64 {
+	titleLexemes, descriptionLexemes := searchLexemes(title), searchLexemes(description)
+	best := 0.0
+	for _, terms := range alternatives {
+		rank, matched := 0.0, true
+		for _, term := range terms {
+			inTitle, inDescription := term.occurrences(titleLexemes), term.occurrences(descriptionLexemes)
+			found := inTitle+inDescription > 0
+			if found == term.negated {
+				matched = false
+				break
+			}
+			rank += float64(inTitle)*searchTitleWeight + float64(inDescription)*searchDescriptionWeight
+		}
+		if matched && rank > best {
+			best = rank
+		}
+	}
+	return best
+}
+
+// searchSnippet is ts_headline for the memory store: it returns up to
+// searchSnippetWords words of text from shortly before the first match, with
+// the words matching the query wrapped in <b></b>. The snippet is HTML, so the
+// words themselves are escaped.
+func searchSnippet(alternatives [][]searchTerm, text string) string {
+	highlight := make(map[string]bool)
+	for _, terms := range alternatives {
+		for _, term := range terms {
+			for _, lexeme := range term.lexemes {
+				highlight[lexeme] = !term.negated || highlight[lexeme]
+			}
+		}
+	}
+
+	words := strings.Fields(text)
+	first := -1
+	for i, word := range words {
+		words[i] = html.EscapeString(word)
+		for _, lexeme := range searchLexemes(word) {
+			if highlight[lexeme] {
+				words[i] = "<b>" + words[i] + "</b>"
+				if first < 0 {
+					first = i
+				}
+				break
+			}
+		}
+	}
+	start := 0
+	if first > searchSnippetWords/2 {
+		start = first - searchSnippetWords/4
+	}
+	end := start + searchSnippetWords
+	if end > len(words) {
+		end = len(words)
+	}
+	return strings.Join(words[start:end], " ")
+}
+
+// Search matches the query against the titles and descriptions of lists and
+// items the user is a member of. Titles weigh more than descriptions in the
+// rank.
+func (r *SearchMemory) Search(ctx context.Context, userId int, query todo.SearchQuery) ([]todo.SearchResult, error) {
+	alternatives := parseSearchQuery(query.Query)
+	var results []todo.SearchResult
+	err := r.store.read(ctx, func(d *memoryData) error {
+		visible := func(listId int) bool {
+			return d.role(userId, listId) != "" && (query.ListId == 0 || query.ListId == listId)
+		}
+		if query.Type != todo.SearchItem {
+			for _, list := range d.lists {
+				if list.DeletedAt != nil || !visible(list.Id) {
+					continue
+				}
+				if rank := searchRank(alternatives, list.Title, list.Description); rank > 0 {
+					results = append(results, todo.SearchResult{Type: todo.SearchList, Id: list.Id, ListId: list.Id,
+						Title: list.Title, Snippet: searchSnippet(alternatives, list.Title+" "+list.Description), Rank: rank})
+				}
+			}
+		}
+		if query.Type != todo.SearchList {
+			for _, item := range d.items {
+				if item.DeletedAt != nil || !visible(item.ListId) {
+					continue
+				}
+				if rank := searchRank(alternatives, item.Title, item.Description); rank > 0 {
+					results = append(results, todo.SearchResult{Type: todo.SearchItem, Id: item.Id, ListId: item.ListId,
+						Title: item.Title, Snippet: searchSnippet(alternatives, item.Title+" "+item.Description), Rank: rank})
+				}
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("Search repository: %w", err)
+	}
+	return bestResults(results, query.Limit), nil
+}
+
+// bestResults orders results by rank, like the Postgres search does, and
+// keeps the first limit of them.
+func bestResults(results []todo.SearchResult, limit int) []todo.SearchResult {
+	sort.Slice(results, func(i, j int) bool {
+		a, b := results[i], results[j]
+		if a.Rank != b.Rank {
+			return a.Rank > b.Rank
+		}
+		if a.Type != b.Type {
+			return a.Type < b.Type
+		}
+		return a.Id < b.Id
+	})
+	if limit <= 0 {
+		limit = todo.DefaultSearchLimit
+	}
+	if len(results) > limit {
+		results = results[:limit]
+	}
+	return results
+}
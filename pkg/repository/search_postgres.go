@@ -0,0 +1,74 @@
+package repository
+
+import (
+	"context"
+	todo "do-app"
+	"fmt"
+	"github.com/jmoiron/sqlx"
+	"strings"
+)
+
+// searchHeadline configures the ts_headline snippets of search results.
+const searchHeadline = "StartSel=<b>, StopSel=</b>, MaxWords=20, MinWords=5, MaxFragments=2"
+
+// htmlEscape is the SQL expression that HTML-escapes the text of expr.
+// Snippets are HTML, so the user text in them is escaped before ts_headline
+// adds its markup.
+func htmlEscape(expr string) string {
+	for _, r := range [][2]string{{"&", "&amp;"}, {"<", "&lt;"}, {">", "&gt;"}, {`"`, "&#34;"}, {"'", "&#39;"}} {
+		expr = fmt.Sprintf("replace(%s, '%s', '%s')", expr, strings.ReplaceAll(r[0], "'", "''"), r[1])
+	}
+	return expr
+}
+
+type SearchPostgres struct {
+	db *sqlx.DB
+}
+
+func NewSearchPostgres(db *sqlx.DB) *SearchPostgres {
+	return &SearchPostgres{db: db}
+}
+
+// Search matches the query against the search columns of lists and items the
+// user is a member of. Titles weigh more than descriptions in the rank.
+func (r *SearchPostgres) Search(ctx context.Context, userId int, query todo.SearchQuery) ([]todo.SearchResult, error) {
+	args := []interface{}{userId, query.Query}
+	listCondition := ""
+	if query.ListId != 0 {
+		args = append(args, query.ListId)
+		listCondition = fmt.Sprintf(" AND ul.list_id = $%d", len(args))
+	}
+
+	parts := make([]string, 0, 2)
+	if query.Type != todo.SearchItem {
+		parts = append(parts, fmt.Sprintf(`SELECT 'list' AS type, tl.id, tl.id AS list_id, tl.title,
+				ts_headline('simple', %s, q, '%s') AS snippet,
+				ts_rank(tl.search, q) AS rank
+				FROM %s tl INNER JOIN %s ul on ul.list_id = tl.id, websearch_to_tsquery('simple', $2) q
+				WHERE ul.user_id = $1 AND tl.deleted_at IS NULL AND tl.search @@ q%s`,
+			htmlEscape("tl.title || ' ' || COALESCE(tl.description, '')"), searchHeadline,
+			todoListsTable, usersListsTable, listCondition))
+	}
+	if query.Type != todo.SearchList {
+		parts = append(parts, fmt.Sprintf(`SELECT 'item' AS type, ti.id, li.list_id, ti.title,
+				ts_headline('simple', %s, q, '%s') AS snippet,
+				ts_rank(ti.search, q) AS rank
+				FROM %s ti INNER JOIN %s li on li.item_id = ti.id INNER JOIN %s ul on ul.list_id = li.list_id,
+				websearch_to_tsquery('simple', $2) q
+				WHERE ul.user_id = $1 AND ti.deleted_at IS NULL AND ti.search @@ q%s`,
+			htmlEscape("ti.title || ' ' || COALESCE(ti.description, '')"), searchHeadline,
+			todoItemsTable, listsItemsTable, usersListsTable, listCondition))
+	}
+
+	limit := query.Limit
+	if limit <= 0 {
+		limit = todo.DefaultSearchLimit
+	}
+
+	var results []todo.SearchResult
+	selectQuery := fmt.Sprintf("%s ORDER BY rank DESC, type, id LIMIT %d", strings.Join(parts, " UNION ALL "), limit)
+	if err := conn(ctx, r.db).SelectContext(ctx, &results, selectQuery, args...); err != nil {
+		return nil, fmt.Errorf("Search repository: %w", err)
+	}
+	return results, nil
+}
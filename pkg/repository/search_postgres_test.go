@@ -0,0 +1,88 @@
+package repository
+
+import (
+	"context"
+	todo "do-app"
+	"github.com/stretchr/testify/assert"
+	sqlmock "github.com/zhashkevych/go-sqlxmock"
+	"log"
+	"testing"
+)
+
+func TestSearchPostgres_Search(t *testing.T) {
+	db, mock, err := sqlmock.Newx()
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer db.Close()
+
+	r := NewSearchPostgres(db)
+
+	columns := []string{"type", "id", "list_id", "title", "snippet", "rank"}
+
+	testTable := []struct {
+		name         string
+		query        todo.SearchQuery
+		results      []todo.SearchResult
+		mockBehavior func()
+		wantErr      bool
+	}{
+		{
+			name:  "Lists and items",
+			query: todo.SearchQuery{Query: "milk"},
+			results: []todo.SearchResult{
+				{Type: "list", Id: 2, ListId: 2, Title: "milk run", Snippet: "<b>milk</b> run", Rank: 0.6},
+				{Type: "item", Id: 4, ListId: 2, Title: "buy milk", Snippet: "buy <b>milk</b>", Rank: 0.4},
+			},
+			mockBehavior: func() {
+				rows := sqlmock.NewRows(columns).
+					AddRow("list", 2, 2, "milk run", "<b>milk</b> run", 0.6).
+					AddRow("item", 4, 2, "buy milk", "buy <b>milk</b>", 0.4)
+				mock.ExpectQuery(`SELECT 'list' AS type, (.+) FROM todo_lists tl (.+) UNION ALL SELECT 'item' AS type, `+
+					`(.+) FROM todo_items ti (.+) ORDER BY rank DESC, type, id LIMIT 20`).
+					WithArgs(1, "milk").WillReturnRows(rows)
+			},
+		},
+		{
+			name:  "Items of list",
+			query: todo.SearchQuery{Query: "milk", Type: todo.SearchItem, ListId: 2, Limit: 5},
+			mockBehavior: func() {
+				mock.ExpectQuery(`SELECT 'item' AS type, (.+) WHERE ul.user_id = \$1 AND ti.deleted_at IS NULL AND ti.search @@ q AND ul.list_id = \$3 `+
+					`ORDER BY rank DESC, type, id LIMIT 5`).
+					WithArgs(1, "milk", 2).WillReturnRows(sqlmock.NewRows(columns))
+			},
+		},
+		{
+			name:  "Only lists",
+			query: todo.SearchQuery{Query: "milk", Type: todo.SearchList},
+			mockBehavior: func() {
+				mock.ExpectQuery(`SELECT 'list' AS type, (.+) WHERE ul.user_id = \$1 AND tl.deleted_at IS NULL AND tl.search @@ q ORDER BY`).
+					WithArgs(1, "milk").WillReturnRows(sqlmock.NewRows(columns))
+			},
+		},
+		{
+			name:  "Select Error",
+			query: todo.SearchQuery{Query: "milk"},
+			mockBehavior: func() {
+				mock.ExpectQuery(`SELECT 'list' AS type`).WithArgs(1, "milk").WillReturnError(assert.AnError)
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			testCase.mockBehavior()
+
+			got, err := r.Search(context.Background(), 1, testCase.query)
+
+			if testCase.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, testCase.results, got)
+				assert.NoError(t, mock.ExpectationsWereMet())
+			}
+		})
+	}
+}
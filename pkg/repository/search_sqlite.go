@@ -0,0 +1,118 @@
+package repository
+
+import (
+	"context"
+	todo "do-app"
+	"fmt"
+	"github.com/jmoiron/sqlx"
+	"strings"
+)
+
+// The FTS4 indexes of the search. The docid of a row is the id of its list or
+// item.
+const (
+	listSearchTable = "list_search"
+	itemSearchTable = "item_search"
+)
+
+type SearchSQLite struct {
+	db *sqlx.DB
+}
+
+func NewSearchSQLite(db *sqlx.DB) *SearchSQLite {
+	return &SearchSQLite{db: db}
+}
+
+// Search matches the query against the titles and descriptions of lists and
+// items the user is a member of. Titles weigh more than descriptions in the
+// rank. The full text indexes find the candidates; FTS4 has no rank function
+// and no negation on its own, so the candidates are filtered and ranked like
+// in the memory store.
+func (r *SearchSQLite) Search(ctx context.Context, userId int, query todo.SearchQuery) ([]todo.SearchResult, error) {
+	alternatives := parseSearchQuery(query.Query)
+	args := []interface{}{userId}
+	matchArg, listArg := 0, 0
+	if match, ok := ftsMatch(alternatives); ok {
+		args = append(args, match)
+		matchArg = len(args)
+	}
+	if query.ListId != 0 {
+		args = append(args, query.ListId)
+		listArg = len(args)
+	}
+	// conditions returns the conditions on the index table and the list.
+	conditions := func(table string) string {
+		var c string
+		if matchArg != 0 {
+			c += fmt.Sprintf(" AND %s MATCH ?%d", table, matchArg)
+		}
+		if listArg != 0 {
+			c += fmt.Sprintf(" AND ul.list_id = ?%d", listArg)
+		}
+		return c
+	}
+
+	parts := make([]string, 0, 2)
+	if query.Type != todo.SearchItem {
+		parts = append(parts, fmt.Sprintf(`SELECT 'list' AS type, tl.id, tl.id AS list_id, tl.title,
+				COALESCE(tl.description, '') AS description
+				FROM %s INNER JOIN %s tl on tl.id = %s.docid INNER JOIN %s ul on ul.list_id = tl.id
+				WHERE ul.user_id = ?1 AND tl.deleted_at IS NULL%s`,
+			listSearchTable, todoListsTable, listSearchTable, usersListsTable, conditions(listSearchTable)))
+	}
+	if query.Type != todo.SearchList {
+		parts = append(parts, fmt.Sprintf(`SELECT 'item' AS type, ti.id, li.list_id, ti.title,
+				COALESCE(ti.description, '') AS description
+				FROM %s INNER JOIN %s ti on ti.id = %s.docid INNER JOIN %s li on li.item_id = ti.id
+				INNER JOIN %s ul on ul.list_id = li.list_id
+				WHERE ul.user_id = ?1 AND ti.deleted_at IS NULL%s`,
+			itemSearchTable, todoItemsTable, itemSearchTable, listsItemsTable, usersListsTable,
+			conditions(itemSearchTable)))
+	}
+
+	var candidates []struct {
+		Type        string `db:"type"`
+		Id          int    `db:"id"`
+		ListId      int    `db:"list_id"`
+		Title       string `db:"title"`
+		Description string `db:"description"`
+	}
+	if err := conn(ctx, r.db).SelectContext(ctx, &candidates, strings.Join(parts, " UNION ALL "), args...); err != nil {
+		return nil, fmt.Errorf("Search repository: %w", err)
+	}
+
+	var results []todo.SearchResult
+	for _, c := range candidates {
+		if rank := searchRank(alternatives, c.Title, c.Description); rank > 0 {
+			results = append(results, todo.SearchResult{Type: c.Type, Id: c.Id, ListId: c.ListId, Title: c.Title,
+				Snippet: searchSnippet(alternatives, c.Title+" "+c.Description), Rank: rank})
+		}
+	}
+	return bestResults(results, query.Limit), nil
+}
+
+// ftsMatch returns the FTS4 query that finds every row the alternatives can
+// match: for each alternative the rows containing all of its terms that are
+// not negated. It reports false when an alternative has no such terms, as
+// FTS4 cannot search for rows without a word; all rows are candidates then.
+func ftsMatch(alternatives [][]searchTerm) (string, bool) {
+	if len(alternatives) == 0 {
+		return "", false
+	}
+	ors := make([]string, 0, len(alternatives))
+	for _, terms := range alternatives {
+		ands := make([]string, 0, len(terms))
+		for _, term := range terms {
+			if !term.negated {
+				// Lexemes are letters and digits only, so quoting them keeps
+				// words like "or" and "not" from being read as operators.
+				ands = append(ands, `"`+strings.Join(term.lexemes, " ")+`"`)
+			}
+		}
+		if len(ands) == 0 {
+			return "", false
+		}
+		ors = append(ors, "("+strings.Join(ands, " ")+")")
+	}
+	return strings.Join(ors, " OR "), true
+}
@@ -0,0 +1,100 @@
+package repository
+
+import (
+	"context"
+	todo "do-app"
+	"fmt"
+	"time"
+)
+
+type SessionMemory struct {
+	store *memoryStore
+}
+
+func NewSessionMemory(store *memoryStore) *SessionMemory {
+	return &SessionMemory{store: store}
+}
+
+func (r *SessionMemory) Create(ctx context.Context, session todo.Session) (int, error) {
+	var id int
+	err := r.store.write(ctx, func(d *memoryData) error {
+		if _, ok := d.users[session.UserId]; !ok {
+			return todo.NewError(todo.ErrConflict, "session refers to a missing entity")
+		}
+		for _, s := range d.sessions {
+			if s.RefreshTokenHash == session.RefreshTokenHash {
+				return todo.NewError(todo.ErrConflict, "session already exists")
+			}
+		}
+		id = d.nextId(sessionsTable)
+		session.Id, session.Revoked = id, false
+		put(d, d.sessions, id, session)
+		return nil
+	})
+	if err != nil {
+		return 0, fmt.Errorf("Create session repository: %w", err)
+	}
+	return id, nil
+}
+
+func (r *SessionMemory) GetByRefreshToken(ctx context.Context, refreshTokenHash string) (todo.Session, error) {
+	var session todo.Session
+	err := r.store.read(ctx, func(d *memoryData) error {
+		for _, s := range d.sessions {
+			if s.RefreshTokenHash == refreshTokenHash {
+				session = s
+				return nil
+			}
+		}
+		return todo.NewError(todo.ErrNotFound, "session not found")
+	})
+	if err != nil {
+		return session, fmt.Errorf("GetByRefreshToken session repository: %w", err)
+	}
+	return session, nil
+}
+
+// Rotate replaces the refresh token of an active session if it still has the
+// old one.
+func (r *SessionMemory) Rotate(ctx context.Context, sessionId int, oldHash, newHash string, expiresAt time.Time) error {
+	err := r.store.write(ctx, func(d *memoryData) error {
+		session, ok := d.sessions[sessionId]
+		if !ok || session.RefreshTokenHash != oldHash || session.Revoked {
+			return errSessionInactive
+		}
+		session.RefreshTokenHash, session.ExpiresAt = newHash, expiresAt
+		put(d, d.sessions, sessionId, session)
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("Rotate session repository: %w", err)
+	}
+	return nil
+}
+
+func (r *SessionMemory) Revoke(ctx context.Context, sessionId int) error {
+	err := r.store.write(ctx, func(d *memoryData) error {
+		if session, ok := d.sessions[sessionId]; ok {
+			session.Revoked = true
+			put(d, d.sessions, sessionId, session)
+		}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("Revoke session repository: %w", err)
+	}
+	return nil
+}
+
+func (r *SessionMemory) IsActive(ctx context.Context, sessionId int) (bool, error) {
+	var active bool
+	err := r.store.read(ctx, func(d *memoryData) error {
+		session, ok := d.sessions[sessionId]
+		active = ok && !session.Revoked && session.ExpiresAt.After(time.Now())
+		return nil
+	})
+	if err != nil {
+		return false, fmt.Errorf("IsActive session repository: %w", err)
+	}
+	return active, nil
+}
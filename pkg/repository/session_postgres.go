@@ -0,0 +1,76 @@
+package repository
+
+import (
+	"context"
+	todo "do-app"
+	"fmt"
+	"github.com/jmoiron/sqlx"
+	"time"
+)
+
+type SessionPostgres struct {
+	db *sqlx.DB
+}
+
+func NewSessionPostgres(db *sqlx.DB) *SessionPostgres {
+	return &SessionPostgres{db: db}
+}
+
+func (r *SessionPostgres) Create(ctx context.Context, session todo.Session) (int, error) {
+	var id int
+	query := fmt.Sprintf("INSERT INTO %s (user_id, refresh_token_hash, expires_at) VALUES ($1, $2, $3) RETURNING id",
+		sessionsTable)
+	row := conn(ctx, r.db).QueryRowContext(ctx, query, session.UserId, session.RefreshTokenHash, session.ExpiresAt)
+	if err := row.Scan(&id); err != nil {
+		return 0, fmt.Errorf("Create session repository: %w", err)
+	}
+	return id, nil
+}
+
+func (r *SessionPostgres) GetByRefreshToken(ctx context.Context, refreshTokenHash string) (todo.Session, error) {
+	var session todo.Session
+	query := fmt.Sprintf(`SELECT id, user_id, refresh_token_hash, expires_at, revoked FROM %s
+								 WHERE refresh_token_hash = $1`, sessionsTable)
+	if err := conn(ctx, r.db).GetContext(ctx, &session, query, refreshTokenHash); err != nil {
+		return session, fmt.Errorf("GetByRefreshToken session repository: %w", domainError(err, "session"))
+	}
+	return session, nil
+}
+
+// Rotate replaces the refresh token of an active session. The old hash is part
+// of the condition so that two concurrent refreshes with the same token cannot
+// both succeed.
+func (r *SessionPostgres) Rotate(ctx context.Context, sessionId int, oldHash, newHash string, expiresAt time.Time) error {
+	query := fmt.Sprintf(`UPDATE %s SET refresh_token_hash = $1, expires_at = $2
+								 WHERE id = $3 AND refresh_token_hash = $4 AND revoked = false`, sessionsTable)
+	res, err := conn(ctx, r.db).ExecContext(ctx, query, newHash, expiresAt, sessionId, oldHash)
+	if err != nil {
+		return fmt.Errorf("Rotate session repository: %w", err)
+	}
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("Rotate session repository: %w", err)
+	}
+	if affected == 0 {
+		return fmt.Errorf("Rotate session repository: %w", errSessionInactive)
+	}
+	return nil
+}
+
+func (r *SessionPostgres) Revoke(ctx context.Context, sessionId int) error {
+	query := fmt.Sprintf("UPDATE %s SET revoked = true WHERE id = $1", sessionsTable)
+	if _, err := conn(ctx, r.db).ExecContext(ctx, query, sessionId); err != nil {
+		return fmt.Errorf("Revoke session repository: %w", err)
+	}
+	return nil
+}
+
+func (r *SessionPostgres) IsActive(ctx context.Context, sessionId int) (bool, error) {
+	var active bool
+	query := fmt.Sprintf("SELECT EXISTS (SELECT 1 FROM %s WHERE id = $1 AND revoked = false AND expires_at > now())",
+		sessionsTable)
+	if err := conn(ctx, r.db).GetContext(ctx, &active, query, sessionId); err != nil {
+		return false, fmt.Errorf("IsActive session repository: %w", err)
+	}
+	return active, nil
+}
@@ -0,0 +1,170 @@
+package repository
+
+import (
+	"context"
+	todo "do-app"
+	"github.com/stretchr/testify/assert"
+	sqlmock "github.com/zhashkevych/go-sqlxmock"
+	"log"
+	"testing"
+	"time"
+)
+
+func TestSessionPostgres_Create(t *testing.T) {
+	db, mock, err := sqlmock.Newx()
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer db.Close()
+
+	r := NewSessionPostgres(db)
+
+	expiresAt := time.Now().Add(time.Hour)
+
+	testTable := []struct {
+		name         string
+		session      todo.Session
+		id           int
+		mockBehavior func(session todo.Session, id int)
+		wantErr      assert.ErrorAssertionFunc
+	}{
+		{
+			name:    "OK",
+			session: todo.Session{UserId: 1, RefreshTokenHash: "hash", ExpiresAt: expiresAt},
+			id:      3,
+			mockBehavior: func(session todo.Session, id int) {
+				row := sqlmock.NewRows([]string{"id"}).AddRow(id)
+				mock.ExpectQuery(`INSERT INTO sessions`).
+					WithArgs(session.UserId, session.RefreshTokenHash, session.ExpiresAt).WillReturnRows(row)
+			},
+			wantErr: assert.NoError,
+		},
+		{
+			name:    "Failed",
+			session: todo.Session{UserId: 1, RefreshTokenHash: "hash", ExpiresAt: expiresAt},
+			mockBehavior: func(session todo.Session, id int) {
+				mock.ExpectQuery(`INSERT INTO sessions`).
+					WithArgs(session.UserId, session.RefreshTokenHash, session.ExpiresAt).WillReturnError(assert.AnError)
+			},
+			wantErr: assert.Error,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			testCase.mockBehavior(testCase.session, testCase.id)
+
+			got, err := r.Create(context.Background(), testCase.session)
+
+			testCase.wantErr(t, err)
+			assert.Equal(t, testCase.id, got)
+		})
+	}
+}
+
+func TestSessionPostgres_Rotate(t *testing.T) {
+	db, mock, err := sqlmock.Newx()
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer db.Close()
+
+	r := NewSessionPostgres(db)
+
+	expiresAt := time.Now().Add(time.Hour)
+
+	testTable := []struct {
+		name         string
+		mockBehavior func()
+		wantErr      assert.ErrorAssertionFunc
+	}{
+		{
+			name: "OK",
+			mockBehavior: func() {
+				mock.ExpectExec(`UPDATE sessions SET refresh_token_hash`).
+					WithArgs("new", expiresAt, 1, "old").WillReturnResult(sqlmock.NewResult(0, 1))
+			},
+			wantErr: assert.NoError,
+		},
+		{
+			name: "Already rotated",
+			mockBehavior: func() {
+				mock.ExpectExec(`UPDATE sessions SET refresh_token_hash`).
+					WithArgs("new", expiresAt, 1, "old").WillReturnResult(sqlmock.NewResult(0, 0))
+			},
+			wantErr: assert.Error,
+		},
+		{
+			name: "Failed",
+			mockBehavior: func() {
+				mock.ExpectExec(`UPDATE sessions SET refresh_token_hash`).
+					WithArgs("new", expiresAt, 1, "old").WillReturnError(assert.AnError)
+			},
+			wantErr: assert.Error,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			testCase.mockBehavior()
+
+			err := r.Rotate(context.Background(), 1, "old", "new", expiresAt)
+
+			testCase.wantErr(t, err)
+		})
+	}
+}
+
+func TestSessionPostgres_IsActive(t *testing.T) {
+	db, mock, err := sqlmock.Newx()
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer db.Close()
+
+	r := NewSessionPostgres(db)
+
+	testTable := []struct {
+		name         string
+		active       bool
+		mockBehavior func(active bool)
+		wantErr      assert.ErrorAssertionFunc
+	}{
+		{
+			name:   "Active",
+			active: true,
+			mockBehavior: func(active bool) {
+				row := sqlmock.NewRows([]string{"exists"}).AddRow(active)
+				mock.ExpectQuery(`SELECT EXISTS`).WithArgs(1).WillReturnRows(row)
+			},
+			wantErr: assert.NoError,
+		},
+		{
+			name:   "Revoked",
+			active: false,
+			mockBehavior: func(active bool) {
+				row := sqlmock.NewRows([]string{"exists"}).AddRow(active)
+				mock.ExpectQuery(`SELECT EXISTS`).WithArgs(1).WillReturnRows(row)
+			},
+			wantErr: assert.NoError,
+		},
+		{
+			name: "Failed",
+			mockBehavior: func(active bool) {
+				mock.ExpectQuery(`SELECT EXISTS`).WithArgs(1).WillReturnError(assert.AnError)
+			},
+			wantErr: assert.Error,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			testCase.mockBehavior(testCase.active)
+
+			got, err := r.IsActive(context.Background(), 1)
+
+			testCase.wantErr(t, err)
+			assert.Equal(t, testCase.active, got)
+		})
+	}
+}
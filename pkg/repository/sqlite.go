@@ -0,0 +1,51 @@
+package repository
+
+import (
+	"errors"
+	"fmt"
+	"github.com/jmoiron/sqlx"
+	"time"
+)
+
+// sqliteBusyTimeout is how long a write waits for the write of another
+// connection to finish.
+const sqliteBusyTimeout = 5 * time.Second
+
+// sqliteTimeFormat is how SQLite stores times: in UTC and with a fixed number
+// of fraction digits, so that comparing them as text compares the times.
+// Column defaults and triggers write the current time in the same format.
+const sqliteTimeFormat = "2006-01-02 15:04:05.000000000-07:00"
+
+// NewSQLiteDB opens the database file cfg.Path, which is created if it does
+// not exist. Foreign keys are enforced like in Postgres, and transactions take
+// the write lock when they begin, so two of them never deadlock trying to
+// upgrade their read locks.
+func NewSQLiteDB(cfg Config) (*sqlx.DB, error) {
+	if sqliteErrors == nil {
+		return nil, errors.New("the sqlite driver needs a binary built with cgo")
+	}
+	db, err := sqlx.Open(sqliteDriver, fmt.Sprintf(
+		"%s?_foreign_keys=on&_busy_timeout=%d&_txlock=immediate&_journal_mode=WAL&_loc=UTC",
+		cfg.Path, sqliteBusyTimeout.Milliseconds()))
+	if err != nil {
+		return nil, err
+	}
+	err = db.Ping()
+	if err != nil {
+		return nil, err
+	}
+	return db, nil
+}
+
+// sqliteTime returns t as the text SQLite stores it as.
+func sqliteTime(t time.Time) string {
+	return t.UTC().Format(sqliteTimeFormat)
+}
+
+// sqliteNullTime is sqliteTime for optional times, which stay NULL when nil.
+func sqliteNullTime(t *time.Time) interface{} {
+	if t == nil {
+		return nil
+	}
+	return sqliteTime(*t)
+}
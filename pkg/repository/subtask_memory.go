@@ -0,0 +1,118 @@
+package repository
+
+import (
+	"context"
+	todo "do-app"
+	"fmt"
+	"sort"
+)
+
+type SubtaskMemory struct {
+	store *memoryStore
+}
+
+func NewSubtaskMemory(store *memoryStore) *SubtaskMemory {
+	return &SubtaskMemory{store: store}
+}
+
+func (r *SubtaskMemory) Create(ctx context.Context, itemId int, subtask todo.Subtask) (int, error) {
+	var id int
+	err := r.store.write(ctx, func(d *memoryData) error {
+		if _, ok := d.items[itemId]; !ok {
+			return todo.NewError(todo.ErrConflict, "item refers to a missing entity")
+		}
+		id = createMemorySubtask(d, itemId, subtask)
+		return nil
+	})
+	if err != nil {
+		return 0, fmt.Errorf("Create subtask repository: %w", err)
+	}
+	return id, nil
+}
+
+// createMemorySubtask adds the subtask to the item, which gets a new version
+// like the touch_item trigger gives it.
+func createMemorySubtask(d *memoryData, itemId int, subtask todo.Subtask) int {
+	id := d.nextId(subtasksTable)
+	put(d, d.subtasks, id, todo.Subtask{Id: id, ItemId: itemId, Title: subtask.Title, Done: subtask.Done})
+	touchMemoryItem(d, itemId)
+	return id
+}
+
+func touchMemoryItem(d *memoryData, itemId int) {
+	if item, ok := d.items[itemId]; ok {
+		touchItem(&item)
+		put(d, d.items, itemId, item)
+	}
+}
+
+func (r *SubtaskMemory) GetAll(ctx context.Context, itemId int) ([]todo.Subtask, error) {
+	var subtasks []todo.Subtask
+	err := r.store.read(ctx, func(d *memoryData) error {
+		for _, subtask := range d.subtasks {
+			if subtask.ItemId == itemId {
+				subtasks = append(subtasks, subtask)
+			}
+		}
+		sort.Slice(subtasks, func(i, j int) bool {
+			return subtasks[i].Id < subtasks[j].Id
+		})
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("GetAll subtask repository: %w", err)
+	}
+	return subtasks, nil
+}
+
+func (r *SubtaskMemory) Update(ctx context.Context, itemId, subtaskId int, input todo.UpdateSubtaskInput) error {
+	err := r.store.write(ctx, func(d *memoryData) error {
+		subtask, ok := d.subtasks[subtaskId]
+		if !ok || subtask.ItemId != itemId {
+			return todo.NewError(todo.ErrNotFound, "subtask not found")
+		}
+		if input.Title != nil {
+			subtask.Title = *input.Title
+		}
+		if input.Done != nil {
+			subtask.Done = *input.Done
+		}
+		put(d, d.subtasks, subtaskId, subtask)
+		touchMemoryItem(d, itemId)
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("Update subtask repository: %w", err)
+	}
+	return nil
+}
+
+func (r *SubtaskMemory) Delete(ctx context.Context, itemId, subtaskId int) error {
+	err := r.store.write(ctx, func(d *memoryData) error {
+		subtask, ok := d.subtasks[subtaskId]
+		if !ok || subtask.ItemId != itemId {
+			return todo.NewError(todo.ErrNotFound, "subtask not found")
+		}
+		remove(d, d.subtasks, subtaskId)
+		touchMemoryItem(d, itemId)
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("Delete subtask repository: %w", err)
+	}
+	return nil
+}
+
+// Progress returns how many subtasks of the item are done and how many it has.
+func (r *SubtaskMemory) Progress(ctx context.Context, itemId int) (int, int, error) {
+	var done, total int
+	err := r.store.read(ctx, func(d *memoryData) error {
+		view := d.itemView(memoryItem{TodoItem: todo.TodoItem{Id: itemId}})
+		done, total = view.SubtasksDone, view.SubtasksTotal
+		return nil
+	})
+	if err != nil {
+		return 0, 0, fmt.Errorf("Progress subtask repository: %w", err)
+	}
+	return done, total, nil
+}
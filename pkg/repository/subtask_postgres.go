@@ -0,0 +1,92 @@
+package repository
+
+import (
+	"context"
+	todo "do-app"
+	"fmt"
+	"github.com/jmoiron/sqlx"
+	"strings"
+)
+
+type SubtaskPostgres struct {
+	db *sqlx.DB
+}
+
+func NewSubtaskPostgres(db *sqlx.DB) *SubtaskPostgres {
+	return &SubtaskPostgres{db: db}
+}
+
+func (r *SubtaskPostgres) Create(ctx context.Context, itemId int, subtask todo.Subtask) (int, error) {
+	var id int
+	query := fmt.Sprintf("INSERT INTO %s (item_id, title, done) values ($1, $2, $3) RETURNING id", subtasksTable)
+	row := conn(ctx, r.db).QueryRowContext(ctx, query, itemId, subtask.Title, subtask.Done)
+	if err := row.Scan(&id); err != nil {
+		return 0, fmt.Errorf("Create subtask repository: %w", domainError(err, "item"))
+	}
+	return id, nil
+}
+
+func (r *SubtaskPostgres) GetAll(ctx context.Context, itemId int) ([]todo.Subtask, error) {
+	var subtasks []todo.Subtask
+	query := fmt.Sprintf("SELECT id, item_id, title, done FROM %s WHERE item_id = $1 ORDER BY id", subtasksTable)
+	if err := conn(ctx, r.db).SelectContext(ctx, &subtasks, query, itemId); err != nil {
+		return nil, fmt.Errorf("GetAll subtask repository: %w", err)
+	}
+	return subtasks, nil
+}
+
+func (r *SubtaskPostgres) Update(ctx context.Context, itemId, subtaskId int, input todo.UpdateSubtaskInput) error {
+	setValues := make([]string, 0)
+	args := make([]interface{}, 0)
+	argId := 1
+
+	if input.Title != nil {
+		setValues = append(setValues, fmt.Sprintf("title=$%d", argId))
+		args = append(args, *input.Title)
+		argId++
+	}
+	if input.Done != nil {
+		setValues = append(setValues, fmt.Sprintf("done=$%d", argId))
+		args = append(args, *input.Done)
+		argId++
+	}
+
+	query := fmt.Sprintf("UPDATE %s SET %s WHERE id = $%d AND item_id = $%d",
+		subtasksTable, strings.Join(setValues, ", "), argId, argId+1)
+	args = append(args, subtaskId, itemId)
+
+	res, err := conn(ctx, r.db).ExecContext(ctx, query, args...)
+	if err != nil {
+		return fmt.Errorf("Update subtask repository: %w", err)
+	}
+	if err = checkAffected(res, "subtask"); err != nil {
+		return fmt.Errorf("Update subtask repository: %w", err)
+	}
+	return nil
+}
+
+func (r *SubtaskPostgres) Delete(ctx context.Context, itemId, subtaskId int) error {
+	query := fmt.Sprintf("DELETE FROM %s WHERE id = $1 AND item_id = $2", subtasksTable)
+	res, err := conn(ctx, r.db).ExecContext(ctx, query, subtaskId, itemId)
+	if err != nil {
+		return fmt.Errorf("Delete subtask repository: %w", err)
+	}
+	if err = checkAffected(res, "subtask"); err != nil {
+		return fmt.Errorf("Delete subtask repository: %w", err)
+	}
+	return nil
+}
+
+// Progress returns how many subtasks of the item are done and how many it has.
+func (r *SubtaskPostgres) Progress(ctx context.Context, itemId int) (int, int, error) {
+	var progress struct {
+		Done  int `db:"done"`
+		Total int `db:"total"`
+	}
+	query := fmt.Sprintf("SELECT count(*) FILTER (WHERE done) AS done, count(*) AS total FROM %s WHERE item_id = $1",
+		subtasksTable)
+	if err := conn(ctx, r.db).GetContext(ctx, &progress, query, itemId); err != nil {
+		return 0, 0, fmt.Errorf("Progress subtask repository: %w", err)
+	}
+	return progress.Done, progress.Total, nil
+}
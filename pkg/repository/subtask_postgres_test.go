@@ -0,0 +1,123 @@
+package repository
+
+import (
+	"context"
+	todo "do-app"
+	"github.com/stretchr/testify/assert"
+	sqlmock "github.com/zhashkevych/go-sqlxmock"
+	"log"
+	"testing"
+)
+
+func TestSubtaskPostgres_Create(t *testing.T) {
+	db, mock, err := sqlmock.Newx()
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer db.Close()
+
+	r := NewSubtaskPostgres(db)
+
+	subtask := todo.Subtask{Title: "collect receipts"}
+
+	testTable := []struct {
+		name         string
+		mockBehavior func()
+		id           int
+		wantErr      assert.ErrorAssertionFunc
+	}{
+		{
+			name: "OK",
+			mockBehavior: func() {
+				mock.ExpectQuery(`INSERT INTO subtasks`).WithArgs(2, subtask.Title, false).
+					WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(4))
+			},
+			id:      4,
+			wantErr: assert.NoError,
+		},
+		{
+			name: "Failed",
+			mockBehavior: func() {
+				mock.ExpectQuery(`INSERT INTO subtasks`).WithArgs(2, subtask.Title, false).
+					WillReturnError(assert.AnError)
+			},
+			wantErr: assert.Error,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			testCase.mockBehavior()
+
+			got, err := r.Create(context.Background(), 2, subtask)
+
+			testCase.wantErr(t, err)
+			assert.Equal(t, testCase.id, got)
+		})
+	}
+}
+
+func TestSubtaskPostgres_Update(t *testing.T) {
+	db, mock, err := sqlmock.Newx()
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer db.Close()
+
+	r := NewSubtaskPostgres(db)
+
+	testTable := []struct {
+		name         string
+		input        todo.UpdateSubtaskInput
+		mockBehavior func()
+		wantErr      assert.ErrorAssertionFunc
+	}{
+		{
+			name:  "Done",
+			input: todo.UpdateSubtaskInput{Done: boolPointer(true)},
+			mockBehavior: func() {
+				mock.ExpectExec(`UPDATE subtasks SET done=\$1 WHERE id = \$2 AND item_id = \$3`).
+					WithArgs(true, 4, 2).WillReturnResult(sqlmock.NewResult(0, 1))
+			},
+			wantErr: assert.NoError,
+		},
+		{
+			name:  "Failed",
+			input: todo.UpdateSubtaskInput{Title: stringPointer("title"), Done: boolPointer(false)},
+			mockBehavior: func() {
+				mock.ExpectExec(`UPDATE subtasks SET title=\$1, done=\$2 WHERE id = \$3 AND item_id = \$4`).
+					WithArgs("title", false, 4, 2).WillReturnError(assert.AnError)
+			},
+			wantErr: assert.Error,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			testCase.mockBehavior()
+
+			err := r.Update(context.Background(), 2, 4, testCase.input)
+
+			testCase.wantErr(t, err)
+		})
+	}
+}
+
+func TestSubtaskPostgres_Progress(t *testing.T) {
+	db, mock, err := sqlmock.Newx()
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer db.Close()
+
+	r := NewSubtaskPostgres(db)
+
+	mock.ExpectQuery(`SELECT count\(\*\) FILTER \(WHERE done\) AS done, count\(\*\) AS total FROM subtasks`).
+		WithArgs(2).WillReturnRows(sqlmock.NewRows([]string{"done", "total"}).AddRow(3, 5))
+
+	done, total, err := r.Progress(context.Background(), 2)
+
+	assert.NoError(t, err)
+	assert.Equal(t, 3, done)
+	assert.Equal(t, 5, total)
+}
@@ -0,0 +1,664 @@
+package repository
+
+import (
+	"context"
+	todo "do-app"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+type TodoItemMemory struct {
+	store *memoryStore
+}
+
+func NewTodoItemMemory(store *memoryStore) *TodoItemMemory {
+	return &TodoItemMemory{store: store}
+}
+
+// itemSnapshot returns the columns of the item that activity records track,
+// with the rule of its active series like itemJSON.
+func (d *memoryData) itemSnapshot(i memoryItem) []byte {
+	var rule *string
+	if i.SeriesId != nil {
+		if series, ok := d.series[*i.SeriesId]; ok && series.StoppedAt == nil {
+			rule = &series.RRule
+		}
+	}
+	return marshalColumns(map[string]interface{}{
+		"id":            i.Id,
+		"title":         i.Title,
+		"description":   i.Description,
+		"done":          i.Done,
+		"due_at":        i.DueAt,
+		"remind_at":     i.RemindAt,
+		"series_id":     i.SeriesId,
+		"priority":      i.Priority,
+		"auto_complete": i.AutoComplete,
+		"completed_at":  i.CompletedAt,
+		"rrule":         rule,
+	})
+}
+
+// itemView returns the item together with the rule of its active series and
+// the progress of its subtasks, like itemSelect reads it.
+func (d *memoryData) itemView(item memoryItem) todo.TodoItem {
+	view := item.TodoItem
+	view.RRule, view.SubtasksDone, view.SubtasksTotal = "", 0, 0
+	if item.SeriesId != nil {
+		if series, ok := d.series[*item.SeriesId]; ok && series.StoppedAt == nil {
+			view.RRule = series.RRule
+		}
+	}
+	for _, subtask := range d.subtasks {
+		if subtask.ItemId == item.Id {
+			view.SubtasksTotal++
+			if subtask.Done {
+				view.SubtasksDone++
+			}
+		}
+	}
+	return view
+}
+
+func (r *TodoItemMemory) Create(ctx context.Context, userId, listId int, item todo.TodoItem) (int, error) {
+	var itemId int
+	// A new item only joins the series created for its own rule.
+	item.SeriesId = nil
+	err := r.store.write(ctx, func(d *memoryData) error {
+		if item.RRule != "" {
+			seriesId := d.nextId(itemSeriesTable)
+			dtstart := time.Now()
+			if item.DueAt != nil {
+				dtstart = *item.DueAt
+			}
+			put(d, d.series, seriesId, todo.ItemSeries{Id: seriesId, RRule: item.RRule, Dtstart: dtstart})
+			item.SeriesId = &seriesId
+		}
+
+		var err error
+		itemId, err = createMemoryItem(d, userId, listId, item)
+		return err
+	})
+	if err != nil {
+		return 0, fmt.Errorf("Create item repository: %w", err)
+	}
+	return itemId, nil
+}
+
+// createMemoryItem adds the item to the end of the list and records its
+// creation by the user.
+func createMemoryItem(d *memoryData, userId, listId int, item todo.TodoItem) (int, error) {
+	if _, ok := d.lists[listId]; !ok {
+		return 0, todo.NewError(todo.ErrConflict, "item refers to a missing entity")
+	}
+	position := 0.0
+	for _, other := range d.items {
+		if other.ListId == listId && other.Position > position {
+			position = other.Position
+		}
+	}
+
+	now := time.Now()
+	id := d.nextId(todoItemsTable)
+	created := memoryItem{TodoItem: todo.TodoItem{
+		Id:           id,
+		ListId:       listId,
+		Title:        item.Title,
+		Description:  item.Description,
+		DueAt:        item.DueAt,
+		RemindAt:     item.RemindAt,
+		SeriesId:     item.SeriesId,
+		Priority:     item.Priority,
+		Position:     position + 1,
+		AutoComplete: item.AutoComplete,
+		Version:      1,
+		CreatedAt:    now,
+		UpdatedAt:    now,
+	}}
+	put(d, d.items, id, created)
+
+	if _, err := recordMemoryChange(d, userId, listId, id, todo.ActionCreate, nil, d.itemSnapshot(created)); err != nil {
+		return 0, err
+	}
+	return id, nil
+}
+
+func (r *TodoItemMemory) GetAll(ctx context.Context, userId, listId int, filter todo.ItemFilter,
+	page todo.PageRequest) ([]todo.TodoItem, string, error) {
+	items, next, err := r.selectPage(ctx, userId, listId, filter, page, todo.SortPosition)
+	if err != nil {
+		return nil, "", fmt.Errorf("GetAll item repository: %w", err)
+	}
+	return items, next, nil
+}
+
+func (r *TodoItemMemory) GetAllByUser(ctx context.Context, userId int, filter todo.ItemFilter,
+	page todo.PageRequest) ([]todo.TodoItem, string, error) {
+	items, next, err := r.selectPage(ctx, userId, 0, filter, page, todo.SortDue)
+	if err != nil {
+		return nil, "", fmt.Errorf("GetAllByUser item repository: %w", err)
+	}
+	return items, next, nil
+}
+
+// selectPage reads a page of the items of the lists the user is a member of,
+// of all of them when listId is 0.
+func (r *TodoItemMemory) selectPage(ctx context.Context, userId, listId int, filter todo.ItemFilter,
+	page todo.PageRequest, defaultSort string) ([]todo.TodoItem, string, error) {
+	keys, err := newKeyset(page, defaultSort, itemSortKeys, "ti.id")
+	if err != nil {
+		return nil, "", err
+	}
+
+	var items []todo.TodoItem
+	var next string
+	err = r.store.read(ctx, func(d *memoryData) error {
+		var all []todo.TodoItem
+		for _, item := range d.items {
+			if item.DeletedAt != nil || (listId != 0 && item.ListId != listId) || d.role(userId, item.ListId) == "" {
+				continue
+			}
+			if matchesFilter(d, userId, item, filter) {
+				all = append(all, d.itemView(item))
+			}
+		}
+
+		rows, cursor, err := memoryPage(keys, len(all), func(i int) (string, int) {
+			return itemSortValue(all[i], keys.name), all[i].Id
+		})
+		for _, i := range rows {
+			items = append(items, all[i])
+		}
+		next = cursor
+		return err
+	})
+	return items, next, err
+}
+
+// matchesFilter is filterConditions for the memory store. Labels are looked up
+// among the labels of the user.
+func matchesFilter(d *memoryData, userId int, item memoryItem, filter todo.ItemFilter) bool {
+	if filter.DueBefore != nil && (item.DueAt == nil || !item.DueAt.Before(*filter.DueBefore)) {
+		return false
+	}
+	if filter.Overdue && (item.DueAt == nil || !item.DueAt.Before(time.Now()) || item.Done) {
+		return false
+	}
+	if len(filter.Labels) > 0 {
+		matched := 0
+		for _, name := range filter.Labels {
+			for _, label := range d.labels {
+				if label.UserId == userId && label.Name == name && d.itemLabels[itemLabel{ItemId: item.Id, LabelId: label.Id}] {
+					matched++
+				}
+			}
+		}
+		if matched == 0 || (filter.AllLabels && matched != len(filter.Labels)) {
+			return false
+		}
+	}
+	if filter.Done != nil && item.Done != *filter.Done {
+		return false
+	}
+	if filter.Query != "" && !strings.Contains(strings.ToLower(item.Title), strings.ToLower(filter.Query)) {
+		return false
+	}
+	return true
+}
+
+func (r *TodoItemMemory) GetById(ctx context.Context, userId, itemId int) (todo.TodoItem, error) {
+	var item todo.TodoItem
+	err := r.store.read(ctx, func(d *memoryData) error {
+		stored, ok := d.items[itemId]
+		if !ok || stored.DeletedAt != nil || d.role(userId, stored.ListId) == "" {
+			return todo.NewError(todo.ErrNotFound, "item not found")
+		}
+		item = d.itemView(stored)
+		return nil
+	})
+	if err != nil {
+		return item, fmt.Errorf("GetById item repository: %w", err)
+	}
+	return item, nil
+}
+
+// liveItem returns the item unless it does not exist or is in the trash.
+func (d *memoryData) liveItem(itemId int) (memoryItem, error) {
+	item, ok := d.items[itemId]
+	if !ok || item.DeletedAt != nil {
+		return item, todo.NewError(todo.ErrNotFound, "item not found")
+	}
+	return item, nil
+}
+
+// Delete moves the item to the trash if it is still at version, or at any
+// version when version is 0, and returns the id of the activity record of the
+// deletion.
+func (r *TodoItemMemory) Delete(ctx context.Context, userId, itemId, version int) (int, error) {
+	var activityId int
+	err := r.store.write(ctx, func(d *memoryData) error {
+		item, err := d.liveItem(itemId)
+		if err != nil {
+			return err
+		}
+		if !todo.CanEdit(d.role(userId, item.ListId)) || (version != 0 && item.Version != version) {
+			return errVersion("item", version)
+		}
+
+		before := d.itemSnapshot(item)
+		now := time.Now()
+		item.DeletedAt = &now
+		touchItem(&item)
+		put(d, d.items, itemId, item)
+
+		activityId, err = recordMemoryChange(d, userId, item.ListId, itemId, todo.ActionDelete, before, nil)
+		return err
+	})
+	if err != nil {
+		return 0, fmt.Errorf("Delete item repository: %w", err)
+	}
+	return activityId, nil
+}
+
+// Update changes the item if it is still at version, or at any version when
+// version is 0, and returns the id of the activity record of the change.
+func (r *TodoItemMemory) Update(ctx context.Context, userId, itemId int, input todo.UpdateItemInput, version int) (int, error) {
+	var activityId int
+	err := r.store.write(ctx, func(d *memoryData) error {
+		item, err := d.liveItem(itemId)
+		if err != nil {
+			return err
+		}
+		before := d.itemSnapshot(item)
+		if item, err = updateMemoryItem(d, userId, item, input, version); err != nil {
+			return err
+		}
+
+		activityId, err = recordMemoryChange(d, userId, item.ListId, itemId, todo.ActionUpdate, before, d.itemSnapshot(item))
+		return err
+	})
+	if err != nil {
+		return 0, fmt.Errorf("Update item repository: %w", err)
+	}
+	return activityId, nil
+}
+
+// updateMemoryItem is updateItem for the memory store. It returns the changed
+// item.
+func updateMemoryItem(d *memoryData, userId int, item memoryItem, input todo.UpdateItemInput,
+	version int) (memoryItem, error) {
+	if !todo.CanEdit(d.role(userId, item.ListId)) || (version != 0 && item.Version != version) {
+		return item, errVersion("item", version)
+	}
+
+	if input.Title != nil {
+		item.Title = *input.Title
+	}
+	if input.Description != nil {
+		item.Description = *input.Description
+	}
+	if input.Done != nil {
+		// Completing an item that is already done keeps the time it was first
+		// completed at.
+		item.Done = *input.Done
+		if !item.Done {
+			item.CompletedAt = nil
+		} else if item.CompletedAt == nil {
+			now := time.Now()
+			item.CompletedAt = &now
+		}
+	}
+	if input.DueAt.Set {
+		item.DueAt = input.DueAt.Time
+	}
+	if input.RemindAt.Set {
+		item.RemindAt, item.ReminderSentAt, item.ReminderRetryAt = input.RemindAt.Time, nil, nil
+	}
+	if input.Priority != nil {
+		item.Priority = *input.Priority
+	}
+	if input.AutoComplete != nil {
+		item.AutoComplete = *input.AutoComplete
+	}
+	touchItem(&item)
+	put(d, d.items, item.Id, item)
+	return item, nil
+}
+
+// Move puts the item into the list at the place described by input. Like in
+// the database, positions are fractional and the list is only renumbered when
+// the gap between neighbours is exhausted.
+func (r *TodoItemMemory) Move(ctx context.Context, userId, itemId, listId int, input todo.MoveItemInput) (float64, error) {
+	var position float64
+	err := r.store.write(ctx, func(d *memoryData) error {
+		from, ok := d.items[itemId]
+		if !ok {
+			return todo.NewError(todo.ErrNotFound, "item not found")
+		}
+		if _, ok = d.lists[listId]; !ok {
+			return todo.NewError(todo.ErrConflict, "item refers to a missing entity")
+		}
+
+		var err error
+		position, err = memoryMovePosition(d, itemId, listId, input)
+		if errors.Is(err, errNoGap) {
+			renumberMemoryList(d, listId)
+			position, err = memoryMovePosition(d, itemId, listId, input)
+		}
+		if err != nil {
+			return err
+		}
+
+		item := d.items[itemId]
+		item.ListId, item.Position = listId, position
+		touchItem(&item)
+		put(d, d.items, itemId, item)
+
+		// The move is recorded in the activity of the list the item ends up in.
+		changes := todo.Changes{"position": newChange(from.Position, position)}
+		if from.ListId != listId {
+			changes["list_id"] = newChange(from.ListId, listId)
+		}
+		recordMemoryActivity(d, todo.Activity{ListId: listId, ItemId: &itemId, UserId: userId, Entity: todo.ActivityItem,
+			Action: todo.ActionMove, Changes: changes})
+		return nil
+	})
+	if err != nil {
+		return 0, fmt.Errorf("Move item repository: %w", err)
+	}
+	return position, nil
+}
+
+func memoryMovePosition(d *memoryData, itemId, listId int, input todo.MoveItemInput) (float64, error) {
+	switch {
+	case input.AfterId != nil:
+		anchor, err := memoryItemPosition(d, listId, *input.AfterId)
+		if err != nil {
+			return 0, err
+		}
+		found, neighbour := false, 0.0
+		for _, other := range d.items {
+			if other.ListId == listId && other.Id != itemId && other.Position > anchor && (!found || other.Position < neighbour) {
+				found, neighbour = true, other.Position
+			}
+		}
+		if !found {
+			return anchor + 1, nil
+		}
+		return between(anchor, neighbour)
+	case input.BeforeId != nil:
+		anchor, err := memoryItemPosition(d, listId, *input.BeforeId)
+		if err != nil {
+			return 0, err
+		}
+		found, neighbour := false, 0.0
+		for _, other := range d.items {
+			if other.ListId == listId && other.Id != itemId && other.Position < anchor && (!found || other.Position > neighbour) {
+				found, neighbour = true, other.Position
+			}
+		}
+		if !found {
+			return anchor - 1, nil
+		}
+		return between(neighbour, anchor)
+	default:
+		found, last := false, 0.0
+		for _, other := range d.items {
+			if other.ListId == listId && other.Id != itemId && (!found || other.Position > last) {
+				found, last = true, other.Position
+			}
+		}
+		return last + 1, nil
+	}
+}
+
+func memoryItemPosition(d *memoryData, listId, itemId int) (float64, error) {
+	item, ok := d.items[itemId]
+	if !ok || item.ListId != listId {
+		return 0, todo.NewError(todo.ErrValidation, "item %d is not in list %d", itemId, listId)
+	}
+	return item.Position, nil
+}
+
+// renumberMemoryList gives the items of the list the positions 1, 2, 3, ... in
+// their current order.
+func renumberMemoryList(d *memoryData, listId int) {
+	var items []memoryItem
+	for _, item := range d.items {
+		if item.ListId == listId {
+			items = append(items, item)
+		}
+	}
+	sort.Slice(items, func(i, j int) bool {
+		if items[i].Position != items[j].Position {
+			return items[i].Position < items[j].Position
+		}
+		return items[i].Id < items[j].Id
+	})
+	for n, item := range items {
+		item.Position = float64(n + 1)
+		touchItem(&item)
+		put(d, d.items, item.Id, item)
+	}
+}
+
+// CompleteOccurrence applies the update that marks a recurring item done and,
+// if the item was still open, creates its next occurrence with an unchecked
+// copy of its subtasks. The item has to be at version, or at any version when
+// version is 0. The id of the next occurrence is returned, or 0 if the item
+// had already been completed.
+func (r *TodoItemMemory) CompleteOccurrence(ctx context.Context, userId, itemId int, input todo.UpdateItemInput,
+	next todo.TodoItem, version int) (int, error) {
+	var nextId int
+	err := r.store.write(ctx, func(d *memoryData) error {
+		item, err := d.liveItem(itemId)
+		if err != nil {
+			return err
+		}
+		before := d.itemSnapshot(item)
+
+		completed := !item.Done
+		if item, err = updateMemoryItem(d, userId, item, input, version); err != nil {
+			return err
+		}
+		if _, err = recordMemoryChange(d, userId, item.ListId, itemId, todo.ActionUpdate, before, d.itemSnapshot(item)); err != nil {
+			return err
+		}
+
+		if !completed {
+			return nil
+		}
+		if nextId, err = createMemoryItem(d, userId, next.ListId, next); err != nil {
+			return err
+		}
+		var subtasks []todo.Subtask
+		for _, subtask := range d.subtasks {
+			if subtask.ItemId == itemId {
+				subtasks = append(subtasks, subtask)
+			}
+		}
+		sort.Slice(subtasks, func(i, j int) bool {
+			return subtasks[i].Id < subtasks[j].Id
+		})
+		for _, subtask := range subtasks {
+			createMemorySubtask(d, nextId, todo.Subtask{Title: subtask.Title})
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, fmt.Errorf("CompleteOccurrence item repository: %w", err)
+	}
+	return nextId, nil
+}
+
+func (r *TodoItemMemory) GetSeries(ctx context.Context, itemId int) (todo.ItemSeries, error) {
+	var series todo.ItemSeries
+	err := r.store.read(ctx, func(d *memoryData) error {
+		item, ok := d.items[itemId]
+		if !ok || item.SeriesId == nil {
+			return todo.NewError(todo.ErrNotFound, "series not found")
+		}
+		if series, ok = d.series[*item.SeriesId]; !ok {
+			return todo.NewError(todo.ErrNotFound, "series not found")
+		}
+		return nil
+	})
+	if err != nil {
+		return series, fmt.Errorf("GetSeries item repository: %w", err)
+	}
+	return series, nil
+}
+
+// SetSeriesRule makes the item recurring if it is still at version, or at any
+// version when version is 0. An existing series of the item gets the new rule
+// and is resumed if it was stopped, otherwise a series starting at dtstart is
+// created.
+func (r *TodoItemMemory) SetSeriesRule(ctx context.Context, userId, itemId int, rule string, dtstart time.Time,
+	version int) (int, error) {
+	var seriesId int
+	err := r.store.write(ctx, func(d *memoryData) error {
+		item, err := d.liveItem(itemId)
+		if err != nil {
+			return err
+		}
+		if !todo.CanEdit(d.role(userId, item.ListId)) || (version != 0 && item.Version != version) {
+			return errVersion("item", version)
+		}
+		before := d.itemSnapshot(item)
+
+		series, ok := todo.ItemSeries{}, false
+		if item.SeriesId != nil {
+			series, ok = d.series[*item.SeriesId]
+		}
+		if ok {
+			series.RRule, series.StoppedAt = rule, nil
+		} else {
+			series = todo.ItemSeries{Id: d.nextId(itemSeriesTable), RRule: rule, Dtstart: dtstart}
+		}
+		put(d, d.series, series.Id, series)
+		seriesId = series.Id
+
+		// The rule is part of the item, so it always gets a new version.
+		item.SeriesId = &seriesId
+		touchItem(&item)
+		put(d, d.items, itemId, item)
+		_, err = recordMemoryChange(d, userId, item.ListId, itemId, todo.ActionUpdate, before, d.itemSnapshot(item))
+		return err
+	})
+	if err != nil {
+		return 0, fmt.Errorf("SetSeriesRule item repository: %w", err)
+	}
+	return seriesId, nil
+}
+
+// seriesItems returns the ids of the open occurrences of the series in lists
+// the user may edit, in the order seriesSnapshot returns them.
+func (d *memoryData) seriesItems(userId, seriesId int) []int {
+	var ids []int
+	for id, item := range d.items {
+		if item.SeriesId != nil && *item.SeriesId == seriesId && !item.Done && item.DeletedAt == nil &&
+			todo.CanEdit(d.role(userId, item.ListId)) {
+			ids = append(ids, id)
+		}
+	}
+	sort.Ints(ids)
+	return ids
+}
+
+// editableSeries reports whether the user may edit one of the occurrences of
+// the series, like lockSeries.
+func (d *memoryData) editableSeries(userId, seriesId int) bool {
+	for _, item := range d.items {
+		if item.SeriesId != nil && *item.SeriesId == seriesId && item.DeletedAt == nil &&
+			todo.CanEdit(d.role(userId, item.ListId)) {
+			return true
+		}
+	}
+	return false
+}
+
+// touchSeriesItems gives every occurrence of the series a new version, like
+// touchSeriesItems of the database repositories.
+func (d *memoryData) touchSeriesItems(seriesId int) {
+	for id, item := range d.items {
+		if item.SeriesId != nil && *item.SeriesId == seriesId {
+			touchItem(&item)
+			put(d, d.items, id, item)
+		}
+	}
+}
+
+// changeSeries applies change to the series and records the change of each of
+// its open occurrences, like recordSeriesChange.
+func (d *memoryData) changeSeries(userId, seriesId int, change func(ids []int)) error {
+	if _, ok := d.series[seriesId]; !ok || !d.editableSeries(userId, seriesId) {
+		return todo.NewError(todo.ErrNotFound, "series not found")
+	}
+	ids := d.seriesItems(userId, seriesId)
+	before := make([][]byte, len(ids))
+	for n, id := range ids {
+		before[n] = d.itemSnapshot(d.items[id])
+	}
+	change(ids)
+	for n, id := range ids {
+		item := d.items[id]
+		if _, err := recordMemoryChange(d, userId, item.ListId, id, todo.ActionUpdate, before[n], d.itemSnapshot(item)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// UpdateSeries changes the rule of the series and applies title and
+// description to all of its open occurrences, recording the change of each.
+func (r *TodoItemMemory) UpdateSeries(ctx context.Context, userId, seriesId int, input todo.UpdateSeriesInput) error {
+	err := r.store.write(ctx, func(d *memoryData) error {
+		return d.changeSeries(userId, seriesId, func(ids []int) {
+			if series, ok := d.series[seriesId]; ok && input.RRule != nil {
+				series.RRule = *input.RRule
+				put(d, d.series, seriesId, series)
+				d.touchSeriesItems(seriesId)
+			}
+			if input.Title == nil && input.Description == nil {
+				return
+			}
+			for _, id := range ids {
+				item := d.items[id]
+				if input.Title != nil {
+					item.Title = *input.Title
+				}
+				if input.Description != nil {
+					item.Description = *input.Description
+				}
+				touchItem(&item)
+				put(d, d.items, id, item)
+			}
+		})
+	})
+	if err != nil {
+		return fmt.Errorf("UpdateSeries item repository: %w", err)
+	}
+	return nil
+}
+
+// StopSeries ends the series, recording the change of its open occurrences,
+// which are left as they are but no longer recur.
+func (r *TodoItemMemory) StopSeries(ctx context.Context, userId, seriesId int) error {
+	err := r.store.write(ctx, func(d *memoryData) error {
+		return d.changeSeries(userId, seriesId, func([]int) {
+			if series, ok := d.series[seriesId]; ok && series.StoppedAt == nil {
+				now := time.Now()
+				series.StoppedAt = &now
+				put(d, d.series, seriesId, series)
+				d.touchSeriesItems(seriesId)
+			}
+		})
+	})
+	if err != nil {
+		return fmt.Errorf("StopSeries item repository: %w", err)
+	}
+	return nil
+}
@@ -1,10 +1,15 @@
 package repository
 
 import (
+	"context"
+	"database/sql"
 	todo "do-app"
+	"errors"
 	"fmt"
 	"github.com/jmoiron/sqlx"
+	"strconv"
 	"strings"
+	"time"
 )
 
 type TodoItemPostgres struct {
@@ -15,23 +20,25 @@ func NewTodoItemPostgres(db *sqlx.DB) *TodoItemPostgres {
 	return &TodoItemPostgres{db: db}
 }
 
-func (r *TodoItemPostgres) Create(listId int, item todo.TodoItem) (int, error) {
-	tx, err := r.db.Begin()
+func (r *TodoItemPostgres) Create(ctx context.Context, userId, listId int, item todo.TodoItem) (int, error) {
+	tx, err := beginTx(ctx, r.db)
 	if err != nil {
 		return 0, fmt.Errorf("Create item repository: %w", err)
 	}
 
-	var itemId int
-	createItemQuery := fmt.Sprintf("INSERT INTO %s (title, description) values ($1, $2) RETURNING id", todoItemsTable)
-	row := tx.QueryRow(createItemQuery, item.Title, item.Description)
-	err = row.Scan(&itemId)
-	if err != nil {
-		tx.Rollback()
-		return 0, fmt.Errorf("Create item repository: %w", err)
+	// A new item only joins the series created for its own rule.
+	item.SeriesId = nil
+	if item.RRule != "" {
+		var seriesId int
+		createSeriesQuery := fmt.Sprintf("INSERT INTO %s (rrule, dtstart) values ($1, $2) RETURNING id", itemSeriesTable)
+		if err = tx.QueryRowContext(ctx, createSeriesQuery, item.RRule, item.DueAt).Scan(&seriesId); err != nil {
+			tx.Rollback()
+			return 0, fmt.Errorf("Create item repository: %w", err)
+		}
+		item.SeriesId = &seriesId
 	}
 
-	createListItemsQuery := fmt.Sprintf("INSERT INTO %s (list_id, item_id) values ($1, $2)", listsItemsTable)
-	_, err = tx.Exec(createListItemsQuery, listId, itemId)
+	itemId, err := createItem(ctx, tx, userId, listId, item)
 	if err != nil {
 		tx.Rollback()
 		return 0, fmt.Errorf("Create item repository: %w", err)
@@ -39,37 +46,236 @@ func (r *TodoItemPostgres) Create(listId int, item todo.TodoItem) (int, error) {
 	return itemId, tx.Commit()
 }
 
-func (r *TodoItemPostgres) GetAll(userId, listId int) ([]todo.TodoItem, error) {
+// createItem adds the item to the end of the list and records its creation by
+// the user.
+func createItem(ctx context.Context, tx *Tx, userId, listId int, item todo.TodoItem) (int, error) {
+	var created struct {
+		Id   int    `db:"id"`
+		Item []byte `db:"item"`
+	}
+	createItemQuery := fmt.Sprintf(`INSERT INTO %s AS ti (title, description, due_at, remind_at, series_id, priority, auto_complete)
+								 values ($1, $2, $3, $4, $5, $6, $7) RETURNING ti.id, %s AS item`, todoItemsTable, itemJSON)
+	err := tx.GetContext(ctx, &created, createItemQuery, item.Title, item.Description, item.DueAt, item.RemindAt, item.SeriesId,
+		item.Priority, item.AutoComplete)
+	if err != nil {
+		return 0, err
+	}
+	itemId := created.Id
+
+	if err = lockPositions(ctx, tx, listId); err != nil {
+		return 0, err
+	}
+	createListItemsQuery := fmt.Sprintf(`INSERT INTO %s (list_id, item_id, position)
+								 SELECT $1, $2, COALESCE(MAX(position), 0) + 1 FROM %s WHERE list_id = $1`,
+		listsItemsTable, listsItemsTable)
+	if _, err = tx.ExecContext(ctx, createListItemsQuery, listId, itemId); err != nil {
+		return 0, err
+	}
+	if _, err = recordItemChange(ctx, tx, userId, listId, itemId, todo.ActionCreate, nil, created.Item); err != nil {
+		return 0, err
+	}
+	return itemId, nil
+}
+
+// itemSelect reads items together with their list, the rule of their active
+// series and the progress of their subtasks. Callers append the WHERE clause;
+// users_lists is joined as ul.
+var itemSelect = fmt.Sprintf(`SELECT ti.id, li.list_id, ti.title, ti.description, ti.done, ti.due_at, ti.remind_at,
+       							 ti.series_id, COALESCE(s.rrule, '') AS rrule, ti.priority, li.position, ti.auto_complete,
+       							 st.done AS subtasks_done, st.total AS subtasks_total, ti.version,
+       							 ti.created_at, ti.updated_at, ti.completed_at
+       							 FROM %s ti INNER JOIN %s li on li.item_id = ti.id INNER JOIN %s ul on ul.list_id = li.list_id
+       							 LEFT JOIN %s s on s.id = ti.series_id AND s.stopped_at IS NULL
+       							 LEFT JOIN LATERAL (SELECT count(*) FILTER (WHERE done) AS done, count(*) AS total
+       							 FROM %s WHERE item_id = ti.id) st on true`,
+	todoItemsTable, listsItemsTable, usersListsTable, itemSeriesTable, subtasksTable)
+
+var itemSortKeys = map[string]sortKey{
+	todo.SortPosition: {expr: "li.position", cast: "double precision"},
+	todo.SortTitle:    {expr: "ti.title", cast: "text"},
+	todo.SortCreated:  {expr: "ti.id", cast: "int"},
+	todo.SortUpdated:  {expr: "ti.updated_at", cast: "timestamptz"},
+	todo.SortDue:      {expr: "COALESCE(ti.due_at, 'infinity')", cast: "timestamptz"},
+	todo.SortPriority: {expr: "ti.priority", cast: "int"},
+}
+
+func (r *TodoItemPostgres) GetAll(ctx context.Context, userId, listId int, filter todo.ItemFilter,
+	page todo.PageRequest) ([]todo.TodoItem, string, error) {
+	conditions, args := filterConditions([]string{"li.list_id = $1", "ul.user_id = $2", "ti.deleted_at IS NULL"},
+		[]interface{}{listId, userId}, filter)
+
+	items, next, err := r.selectPage(ctx, conditions, args, page, todo.SortPosition)
+	if err != nil {
+		return nil, "", fmt.Errorf("GetAll item repository: %w", err)
+	}
+	return items, next, nil
+}
+
+func (r *TodoItemPostgres) GetAllByUser(ctx context.Context, userId int, filter todo.ItemFilter,
+	page todo.PageRequest) ([]todo.TodoItem, string, error) {
+	conditions, args := filterConditions([]string{"ul.user_id = $1", "ti.deleted_at IS NULL"}, []interface{}{userId}, filter)
+
+	items, next, err := r.selectPage(ctx, conditions, args, page, todo.SortDue)
+	if err != nil {
+		return nil, "", fmt.Errorf("GetAllByUser item repository: %w", err)
+	}
+	return items, next, nil
+}
+
+func (r *TodoItemPostgres) selectPage(ctx context.Context, conditions []string, args []interface{}, page todo.PageRequest,
+	defaultSort string) ([]todo.TodoItem, string, error) {
+	keys, err := newKeyset(page, defaultSort, itemSortKeys, "ti.id")
+	if err != nil {
+		return nil, "", err
+	}
+	conditions, args = keys.conditions(conditions, args)
+
 	var items []todo.TodoItem
-	query := fmt.Sprintf(`SELECT ti.id, ti.title, ti.description, ti.done FROM %s ti INNER JOIN %s li on li.item_id = ti.id 
-    							 INNER JOIN %s ul on ul.list_id = li.list_id WHERE li.list_id = $1 AND ul.user_id = $2`,
-		todoItemsTable, listsItemsTable, usersListsTable)
-	if err := r.db.Select(&items, query, listId, userId); err != nil {
-		return nil, fmt.Errorf("GetAll item repository: %w", err)
+	query := fmt.Sprintf("%s WHERE %s %s", itemSelect, strings.Join(conditions, " AND "), keys.orderBy())
+	if err = conn(ctx, r.db).SelectContext(ctx, &items, query, args...); err != nil {
+		return nil, "", err
+	}
+
+	n, next := keys.page(len(items), func(i int) (string, int) {
+		return itemSortValue(items[i], keys.name), items[i].Id
+	})
+	return items[:n], next, nil
+}
+
+func itemSortValue(item todo.TodoItem, sort string) string {
+	switch sort {
+	case todo.SortPosition:
+		return strconv.FormatFloat(item.Position, 'g', -1, 64)
+	case todo.SortTitle:
+		return item.Title
+	case todo.SortUpdated:
+		return item.UpdatedAt.Format(time.RFC3339Nano)
+	case todo.SortDue:
+		if item.DueAt == nil {
+			return "infinity"
+		}
+		return item.DueAt.Format(time.RFC3339Nano)
+	case todo.SortPriority:
+		return strconv.Itoa(item.Priority)
+	default:
+		return strconv.Itoa(item.Id)
 	}
-	return items, nil
 }
 
-func (r *TodoItemPostgres) GetById(userId, itemId int) (todo.TodoItem, error) {
+// filterConditions adds the conditions of the filter to a query that joins
+// users_lists as ul. Labels are looked up among the labels of ul.user_id.
+func filterConditions(conditions []string, args []interface{}, filter todo.ItemFilter) ([]string, []interface{}) {
+	if filter.DueBefore != nil {
+		args = append(args, *filter.DueBefore)
+		conditions = append(conditions, fmt.Sprintf("ti.due_at < $%d", len(args)))
+	}
+	if filter.Overdue {
+		conditions = append(conditions, "ti.due_at < now() AND ti.done = false")
+	}
+	if len(filter.Labels) > 0 {
+		placeholders := make([]string, 0, len(filter.Labels))
+		for _, name := range filter.Labels {
+			args = append(args, name)
+			placeholders = append(placeholders, fmt.Sprintf("$%d", len(args)))
+		}
+		labelQuery := fmt.Sprintf(`ti.id IN (SELECT il.item_id FROM %s il INNER JOIN %s l on l.id = il.label_id
+								 WHERE l.user_id = ul.user_id AND l.name IN (%s) GROUP BY il.item_id`,
+			itemsLabelsTable, labelsTable, strings.Join(placeholders, ", "))
+		if filter.AllLabels {
+			labelQuery += fmt.Sprintf(" HAVING count(*) = %d", len(filter.Labels))
+		}
+		conditions = append(conditions, labelQuery+")")
+	}
+	if filter.Done != nil {
+		args = append(args, *filter.Done)
+		conditions = append(conditions, fmt.Sprintf("ti.done = $%d", len(args)))
+	}
+	if filter.Query != "" {
+		args = append(args, filter.Query)
+		conditions = append(conditions, fmt.Sprintf("strpos(lower(ti.title), lower($%d)) > 0", len(args)))
+	}
+	return conditions, args
+}
+
+func (r *TodoItemPostgres) GetById(ctx context.Context, userId, itemId int) (todo.TodoItem, error) {
 	var item todo.TodoItem
-	query := fmt.Sprintf(`SELECT ti.id, ti.title, ti.description, ti.done FROM %s ti INNER JOIN %s li on li.item_id = ti.id 
-    							 INNER JOIN %s ul on ul.list_id = li.list_id WHERE ti.id = $1 AND ul.user_id = $2`,
-		todoItemsTable, listsItemsTable, usersListsTable)
-	if err := r.db.Get(&item, query, itemId, userId); err != nil {
-		return item, fmt.Errorf("GetById item repository: %w", err)
+	query := fmt.Sprintf("%s WHERE ti.id = $1 AND ul.user_id = $2 AND ti.deleted_at IS NULL", itemSelect)
+	if err := conn(ctx, r.db).GetContext(ctx, &item, query, itemId, userId); err != nil {
+		return item, fmt.Errorf("GetById item repository: %w", domainError(err, "item"))
 	}
 	return item, nil
 }
 
-func (r *TodoItemPostgres) Delete(userId, itemId int) error {
-	query := fmt.Sprintf(`DELETE FROM %s ti USING %s li, %s ul 
-       							 WHERE ti.id = li.item_id AND li.list_id = ul.list_id AND ul.user_id = $1 AND ti.id = $2`,
-		todoItemsTable, listsItemsTable, usersListsTable)
-	_, err := r.db.Exec(query, userId, itemId)
-	return err
+// Delete moves the item to the trash if it is still at version, or at any
+// version when version is 0, and returns the id of the activity record of the
+// deletion.
+func (r *TodoItemPostgres) Delete(ctx context.Context, userId, itemId, version int) (int, error) {
+	tx, err := beginTx(ctx, r.db)
+	if err != nil {
+		return 0, fmt.Errorf("Delete item repository: %w", err)
+	}
+
+	listId, before, err := itemSnapshot(ctx, tx, itemId)
+	if err != nil {
+		tx.Rollback()
+		return 0, fmt.Errorf("Delete item repository: %w", err)
+	}
+
+	query := fmt.Sprintf(`UPDATE %s ti SET deleted_at = now() FROM %s li, %s ul 
+       							 WHERE ti.id = li.item_id AND li.list_id = ul.list_id AND ul.user_id = $1 AND ti.id = $2
+       							 AND ul.role IN (%s) AND ($3 = 0 OR ti.version = $3) AND ti.deleted_at IS NULL`,
+		todoItemsTable, listsItemsTable, usersListsTable, editorRoles)
+	res, err := tx.ExecContext(ctx, query, userId, itemId, version)
+	if err != nil {
+		tx.Rollback()
+		return 0, fmt.Errorf("Delete item repository: %w", err)
+	}
+	if err = checkVersion(res, "item", version); err != nil {
+		tx.Rollback()
+		return 0, fmt.Errorf("Delete item repository: %w", err)
+	}
+
+	activityId, err := recordItemChange(ctx, tx, userId, listId, itemId, todo.ActionDelete, before, nil)
+	if err != nil {
+		tx.Rollback()
+		return 0, fmt.Errorf("Delete item repository: %w", err)
+	}
+	return activityId, tx.Commit()
+}
+
+// Update changes the item if it is still at version, or at any version when
+// version is 0, and returns the id of the activity record of the change.
+func (r *TodoItemPostgres) Update(ctx context.Context, userId, itemId int, input todo.UpdateItemInput, version int) (int, error) {
+	tx, err := beginTx(ctx, r.db)
+	if err != nil {
+		return 0, fmt.Errorf("Update item repository: %w", err)
+	}
+
+	listId, before, err := itemSnapshot(ctx, tx, itemId)
+	if err != nil {
+		tx.Rollback()
+		return 0, fmt.Errorf("Update item repository: %w", err)
+	}
+	if err = updateItem(ctx, tx, userId, itemId, input, version); err != nil {
+		tx.Rollback()
+		return 0, fmt.Errorf("Update item repository: %w", err)
+	}
+	_, after, err := itemSnapshot(ctx, tx, itemId)
+	if err != nil {
+		tx.Rollback()
+		return 0, fmt.Errorf("Update item repository: %w", err)
+	}
+
+	activityId, err := recordItemChange(ctx, tx, userId, listId, itemId, todo.ActionUpdate, before, after)
+	if err != nil {
+		tx.Rollback()
+		return 0, fmt.Errorf("Update item repository: %w", err)
+	}
+	return activityId, tx.Commit()
 }
 
-func (r *TodoItemPostgres) Update(userId, itemId int, input todo.UpdateItemInput) error {
+func updateItem(ctx context.Context, exec sqlx.ExecerContext, userId, itemId int,
+	input todo.UpdateItemInput, version int) error {
 	setValues := make([]string, 0)
 	args := make([]interface{}, 0)
 	argId := 1
@@ -85,18 +291,439 @@ func (r *TodoItemPostgres) Update(userId, itemId int, input todo.UpdateItemInput
 		argId++
 	}
 	if input.Done != nil {
-		setValues = append(setValues, fmt.Sprintf("done=$%d", argId))
+		// Completing an item that is already done keeps the time it was first
+		// completed at.
+		setValues = append(setValues, fmt.Sprintf(
+			"done=$%d, completed_at=CASE WHEN $%d THEN COALESCE(ti.completed_at, now()) END", argId, argId))
 		args = append(args, *input.Done)
 		argId++
 	}
+	if input.DueAt.Set {
+		setValues = append(setValues, fmt.Sprintf("due_at=$%d", argId))
+		args = append(args, input.DueAt.Time)
+		argId++
+	}
+	if input.RemindAt.Set {
+		setValues = append(setValues, fmt.Sprintf("remind_at=$%d, reminder_sent_at=NULL, reminder_retry_at=NULL", argId))
+		args = append(args, input.RemindAt.Time)
+		argId++
+	}
+	if input.Priority != nil {
+		setValues = append(setValues, fmt.Sprintf("priority=$%d", argId))
+		args = append(args, *input.Priority)
+		argId++
+	}
+	if input.AutoComplete != nil {
+		setValues = append(setValues, fmt.Sprintf("auto_complete=$%d", argId))
+		args = append(args, *input.AutoComplete)
+		argId++
+	}
 
 	setQuery := strings.Join(setValues, ", ")
 
 	query := fmt.Sprintf(`UPDATE %s ti SET %s FROM %s li, %s ul 
-                    			WHERE ti.id = li.item_id AND li.list_id = ul.list_id AND ul.user_id = $%d AND ti.id = $%d`,
-		todoItemsTable, setQuery, listsItemsTable, usersListsTable, argId, argId+1)
-	args = append(args, userId, itemId)
+                    			WHERE ti.id = li.item_id AND li.list_id = ul.list_id AND ul.user_id = $%d AND ti.id = $%d
+                    			AND ul.role IN (%s) AND ($%d = 0 OR ti.version = $%d) AND ti.deleted_at IS NULL`,
+		todoItemsTable, setQuery, listsItemsTable, usersListsTable, argId, argId+1, editorRoles, argId+2, argId+2)
+	args = append(args, userId, itemId, version)
+
+	res, err := exec.ExecContext(ctx, query, args...)
+	if err != nil {
+		return err
+	}
+	return checkVersion(res, "item", version)
+}
+
+// errNoGap is returned by movePosition when two neighbouring positions are too
+// close to put an item between them.
+var errNoGap = errors.New("no gap between positions")
+
+// Move puts the item into the list at the place described by input. Positions
+// are fractional, so a move is a single row write; only when the gap between
+// neighbours is exhausted the list is renumbered first.
+func (r *TodoItemPostgres) Move(ctx context.Context, userId, itemId, listId int, input todo.MoveItemInput) (float64, error) {
+	tx, err := beginTx(ctx, r.db)
+	if err != nil {
+		return 0, fmt.Errorf("Move item repository: %w", err)
+	}
+
+	var from struct {
+		ListId   int     `db:"list_id"`
+		Position float64 `db:"position"`
+	}
+	fromQuery := fmt.Sprintf("SELECT list_id, position FROM %s WHERE item_id = $1 FOR UPDATE", listsItemsTable)
+	if err = tx.GetContext(ctx, &from, fromQuery, itemId); err != nil {
+		tx.Rollback()
+		return 0, fmt.Errorf("Move item repository: %w", domainError(err, "item"))
+	}
+	if err = lockPositions(ctx, tx, listId); err != nil {
+		tx.Rollback()
+		return 0, fmt.Errorf("Move item repository: %w", err)
+	}
 
-	_, err := r.db.Exec(query, args...)
+	position, err := movePosition(ctx, tx, itemId, listId, input)
+	if errors.Is(err, errNoGap) {
+		renumberQuery := fmt.Sprintf(`UPDATE %s li SET position = r.n FROM
+                    			(SELECT id, row_number() OVER (ORDER BY position, item_id) AS n FROM %s WHERE list_id = $1) r
+                    			WHERE li.id = r.id`, listsItemsTable, listsItemsTable)
+		if _, err = tx.ExecContext(ctx, renumberQuery, listId); err == nil {
+			position, err = movePosition(ctx, tx, itemId, listId, input)
+		}
+	}
+	if err != nil {
+		tx.Rollback()
+		return 0, fmt.Errorf("Move item repository: %w", err)
+	}
+
+	query := fmt.Sprintf("UPDATE %s SET list_id = $1, position = $2 WHERE item_id = $3", listsItemsTable)
+	if _, err = tx.ExecContext(ctx, query, listId, position, itemId); err != nil {
+		tx.Rollback()
+		return 0, fmt.Errorf("Move item repository: %w", err)
+	}
+
+	// The move is recorded in the activity of the list the item ends up in.
+	changes := todo.Changes{"position": newChange(from.Position, position)}
+	if from.ListId != listId {
+		changes["list_id"] = newChange(from.ListId, listId)
+	}
+	activity := todo.Activity{ListId: listId, ItemId: &itemId, UserId: userId, Entity: todo.ActivityItem,
+		Action: todo.ActionMove, Changes: changes}
+	if _, err = recordActivity(ctx, tx, activity); err != nil {
+		tx.Rollback()
+		return 0, fmt.Errorf("Move item repository: %w", err)
+	}
+	return position, tx.Commit()
+}
+
+func movePosition(ctx context.Context, tx *Tx, itemId, listId int, input todo.MoveItemInput) (float64, error) {
+	var neighbour sql.NullFloat64
+	switch {
+	case input.AfterId != nil:
+		anchor, err := itemPosition(ctx, tx, listId, *input.AfterId)
+		if err != nil {
+			return 0, err
+		}
+		query := fmt.Sprintf("SELECT MIN(position) FROM %s WHERE list_id = $1 AND position > $2 AND item_id <> $3",
+			listsItemsTable)
+		if err = tx.GetContext(ctx, &neighbour, query, listId, anchor, itemId); err != nil {
+			return 0, err
+		}
+		if !neighbour.Valid {
+			return anchor + 1, nil
+		}
+		return between(anchor, neighbour.Float64)
+	case input.BeforeId != nil:
+		anchor, err := itemPosition(ctx, tx, listId, *input.BeforeId)
+		if err != nil {
+			return 0, err
+		}
+		query := fmt.Sprintf("SELECT MAX(position) FROM %s WHERE list_id = $1 AND position < $2 AND item_id <> $3",
+			listsItemsTable)
+		if err = tx.GetContext(ctx, &neighbour, query, listId, anchor, itemId); err != nil {
+			return 0, err
+		}
+		if !neighbour.Valid {
+			return anchor - 1, nil
+		}
+		return between(neighbour.Float64, anchor)
+	default:
+		query := fmt.Sprintf("SELECT MAX(position) FROM %s WHERE list_id = $1 AND item_id <> $2", listsItemsTable)
+		if err := tx.GetContext(ctx, &neighbour, query, listId, itemId); err != nil {
+			return 0, err
+		}
+		return neighbour.Float64 + 1, nil
+	}
+}
+
+// lockPositions locks the list until the end of the transaction, so that
+// items added or moved to it concurrently do not compute the same position
+// from the same neighbours. The lock leaves key references to the list alone.
+func lockPositions(ctx context.Context, tx *Tx, listId int) error {
+	query := fmt.Sprintf("SELECT id FROM %s WHERE id = $1 FOR NO KEY UPDATE", todoListsTable)
+	_, err := tx.ExecContext(ctx, query, listId)
 	return err
 }
+
+func itemPosition(ctx context.Context, tx *Tx, listId, itemId int) (float64, error) {
+	var position float64
+	query := fmt.Sprintf("SELECT position FROM %s WHERE list_id = $1 AND item_id = $2", listsItemsTable)
+	if err := tx.GetContext(ctx, &position, query, listId, itemId); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return 0, todo.NewError(todo.ErrValidation, "item %d is not in list %d", itemId, listId)
+		}
+		return 0, err
+	}
+	return position, nil
+}
+
+func between(low, high float64) (float64, error) {
+	position := low + (high-low)/2
+	if position <= low || position >= high {
+		return 0, errNoGap
+	}
+	return position, nil
+}
+
+// CompleteOccurrence applies the update that marks a recurring item done and
+// creates its next occurrence, with an unchecked copy of its subtasks, in the
+// same transaction. The item has to be at version, or at any version when
+// version is 0. Only an item that was still open gets a next occurrence, so
+// concurrent requests generate a single one; its id is returned, or 0 if the
+// item had already been completed.
+func (r *TodoItemPostgres) CompleteOccurrence(ctx context.Context, userId, itemId int, input todo.UpdateItemInput,
+	next todo.TodoItem, version int) (int, error) {
+	tx, err := beginTx(ctx, r.db)
+	if err != nil {
+		return 0, fmt.Errorf("CompleteOccurrence item repository: %w", err)
+	}
+
+	listId, before, err := itemSnapshot(ctx, tx, itemId)
+	if err != nil {
+		tx.Rollback()
+		return 0, fmt.Errorf("CompleteOccurrence item repository: %w", err)
+	}
+	done, err := snapshotDone(before)
+	if err != nil {
+		tx.Rollback()
+		return 0, fmt.Errorf("CompleteOccurrence item repository: %w", err)
+	}
+
+	if err = updateItem(ctx, tx, userId, itemId, input, version); err != nil {
+		tx.Rollback()
+		return 0, fmt.Errorf("CompleteOccurrence item repository: %w", err)
+	}
+	_, after, err := itemSnapshot(ctx, tx, itemId)
+	if err != nil {
+		tx.Rollback()
+		return 0, fmt.Errorf("CompleteOccurrence item repository: %w", err)
+	}
+	if _, err = recordItemChange(ctx, tx, userId, listId, itemId, todo.ActionUpdate, before, after); err != nil {
+		tx.Rollback()
+		return 0, fmt.Errorf("CompleteOccurrence item repository: %w", err)
+	}
+
+	var nextId int
+	if !done {
+		nextId, err = createItem(ctx, tx, userId, next.ListId, next)
+		if err != nil {
+			tx.Rollback()
+			return 0, fmt.Errorf("CompleteOccurrence item repository: %w", err)
+		}
+		copySubtasksQuery := fmt.Sprintf("INSERT INTO %s (item_id, title) SELECT $1, title FROM %s WHERE item_id = $2 ORDER BY id",
+			subtasksTable, subtasksTable)
+		if _, err = tx.ExecContext(ctx, copySubtasksQuery, nextId, itemId); err != nil {
+			tx.Rollback()
+			return 0, fmt.Errorf("CompleteOccurrence item repository: %w", err)
+		}
+	}
+	return nextId, tx.Commit()
+}
+
+func (r *TodoItemPostgres) GetSeries(ctx context.Context, itemId int) (todo.ItemSeries, error) {
+	var series todo.ItemSeries
+	query := fmt.Sprintf(`SELECT s.id, s.rrule, s.dtstart, s.stopped_at FROM %s s
+								 INNER JOIN %s ti on ti.series_id = s.id WHERE ti.id = $1`,
+		itemSeriesTable, todoItemsTable)
+	if err := conn(ctx, r.db).GetContext(ctx, &series, query, itemId); err != nil {
+		return series, fmt.Errorf("GetSeries item repository: %w", domainError(err, "series"))
+	}
+	return series, nil
+}
+
+// SetSeriesRule makes the item recurring if it is still at version, or at any
+// version when version is 0. An existing series of the item gets the new rule
+// and is resumed if it was stopped, otherwise a series starting at dtstart is
+// created.
+func (r *TodoItemPostgres) SetSeriesRule(ctx context.Context, userId, itemId int, rule string, dtstart time.Time,
+	version int) (int, error) {
+	tx, err := beginTx(ctx, r.db)
+	if err != nil {
+		return 0, fmt.Errorf("SetSeriesRule item repository: %w", err)
+	}
+
+	listId, before, err := itemSnapshot(ctx, tx, itemId)
+	if err != nil {
+		tx.Rollback()
+		return 0, fmt.Errorf("SetSeriesRule item repository: %w", err)
+	}
+
+	var seriesId int
+	updateQuery := fmt.Sprintf(`UPDATE %s s SET rrule = $1, stopped_at = NULL FROM %s ti
+                    			WHERE ti.series_id = s.id AND ti.id = $2 RETURNING s.id`,
+		itemSeriesTable, todoItemsTable)
+	err = tx.QueryRowContext(ctx, updateQuery, rule, itemId).Scan(&seriesId)
+	if errors.Is(err, sql.ErrNoRows) {
+		createQuery := fmt.Sprintf("INSERT INTO %s (rrule, dtstart) values ($1, $2) RETURNING id", itemSeriesTable)
+		err = tx.QueryRowContext(ctx, createQuery, rule, dtstart).Scan(&seriesId)
+	}
+	if err != nil {
+		tx.Rollback()
+		return 0, fmt.Errorf("SetSeriesRule item repository: %w", err)
+	}
+
+	// The rule is part of the item, so linking the series always gives the
+	// item a new version.
+	linkQuery := fmt.Sprintf(`UPDATE %s ti SET series_id = $1 FROM %s li, %s ul
+                    			WHERE ti.id = li.item_id AND li.list_id = ul.list_id AND ul.user_id = $2 AND ti.id = $3
+                    			AND ul.role IN (%s) AND ($4 = 0 OR ti.version = $4) AND ti.deleted_at IS NULL`,
+		todoItemsTable, listsItemsTable, usersListsTable, editorRoles)
+	res, err := tx.ExecContext(ctx, linkQuery, seriesId, userId, itemId, version)
+	if err != nil {
+		tx.Rollback()
+		return 0, fmt.Errorf("SetSeriesRule item repository: %w", err)
+	}
+	if err = checkVersion(res, "item", version); err != nil {
+		tx.Rollback()
+		return 0, fmt.Errorf("SetSeriesRule item repository: %w", err)
+	}
+
+	_, after, err := itemSnapshot(ctx, tx, itemId)
+	if err != nil {
+		tx.Rollback()
+		return 0, fmt.Errorf("SetSeriesRule item repository: %w", err)
+	}
+	if _, err = recordItemChange(ctx, tx, userId, listId, itemId, todo.ActionUpdate, before, after); err != nil {
+		tx.Rollback()
+		return 0, fmt.Errorf("SetSeriesRule item repository: %w", err)
+	}
+	return seriesId, tx.Commit()
+}
+
+// lockSeries locks the series for the rest of the transaction if the user may edit one of its occurrences. A
+// series only shared by lists of other users is not found.
+func lockSeries(ctx context.Context, tx *Tx, userId, seriesId int) error {
+	var id int
+	query := fmt.Sprintf(`SELECT s.id FROM %s s WHERE s.id = $1 AND EXISTS (SELECT 1 FROM %s ti
+								 INNER JOIN %s li on li.item_id = ti.id INNER JOIN %s ul on ul.list_id = li.list_id
+								 WHERE ti.series_id = s.id AND ul.user_id = $2 AND ul.role IN (%s) AND ti.deleted_at IS NULL) FOR UPDATE OF s`,
+		itemSeriesTable, todoItemsTable, listsItemsTable, usersListsTable, editorRoles)
+	if err := tx.GetContext(ctx, &id, query, seriesId, userId); err != nil {
+		return domainError(err, "series")
+	}
+	return nil
+}
+
+// touchSeriesItems gives every occurrence of the series a new version,
+// for changes of the series that change the rule clients see on its items.
+func touchSeriesItems(ctx context.Context, tx *Tx, seriesId int) error {
+	query := fmt.Sprintf("UPDATE %s SET version = version WHERE series_id = $1", todoItemsTable)
+	_, err := tx.ExecContext(ctx, query, seriesId)
+	return err
+}
+
+// UpdateSeries changes the rule of the series and applies title and
+// description to all of its open occurrences, recording the change of each.
+func (r *TodoItemPostgres) UpdateSeries(ctx context.Context, userId, seriesId int, input todo.UpdateSeriesInput) error {
+	tx, err := beginTx(ctx, r.db)
+	if err != nil {
+		return fmt.Errorf("UpdateSeries item repository: %w", err)
+	}
+
+	if err = lockSeries(ctx, tx, userId, seriesId); err != nil {
+		tx.Rollback()
+		return fmt.Errorf("UpdateSeries item repository: %w", err)
+	}
+	before, err := seriesSnapshot(ctx, tx, userId, seriesId)
+	if err != nil {
+		tx.Rollback()
+		return fmt.Errorf("UpdateSeries item repository: %w", err)
+	}
+
+	if input.RRule != nil {
+		ruleQuery := fmt.Sprintf("UPDATE %s SET rrule = $1 WHERE id = $2", itemSeriesTable)
+		if _, err = tx.ExecContext(ctx, ruleQuery, *input.RRule, seriesId); err != nil {
+			tx.Rollback()
+			return fmt.Errorf("UpdateSeries item repository: %w", err)
+		}
+		if err = touchSeriesItems(ctx, tx, seriesId); err != nil {
+			tx.Rollback()
+			return fmt.Errorf("UpdateSeries item repository: %w", err)
+		}
+	}
+
+	setValues := make([]string, 0)
+	args := make([]interface{}, 0)
+	argId := 1
+
+	if input.Title != nil {
+		setValues = append(setValues, fmt.Sprintf("title=$%d", argId))
+		args = append(args, *input.Title)
+		argId++
+	}
+	if input.Description != nil {
+		setValues = append(setValues, fmt.Sprintf("description=$%d", argId))
+		args = append(args, *input.Description)
+		argId++
+	}
+
+	if len(setValues) > 0 {
+		itemsQuery := fmt.Sprintf(`UPDATE %s SET %s WHERE series_id = $%d AND done = false AND deleted_at IS NULL
+								 AND id IN (SELECT li.item_id FROM %s li INNER JOIN %s ul on ul.list_id = li.list_id
+								 WHERE ul.user_id = $%d AND ul.role IN (%s))`,
+			todoItemsTable, strings.Join(setValues, ", "), argId, listsItemsTable, usersListsTable, argId+1, editorRoles)
+		args = append(args, seriesId, userId)
+		if _, err = tx.ExecContext(ctx, itemsQuery, args...); err != nil {
+			tx.Rollback()
+			return fmt.Errorf("UpdateSeries item repository: %w", err)
+		}
+	}
+
+	after, err := seriesSnapshot(ctx, tx, userId, seriesId)
+	if err != nil {
+		tx.Rollback()
+		return fmt.Errorf("UpdateSeries item repository: %w", err)
+	}
+	if err = recordSeriesChange(ctx, tx, userId, before, after); err != nil {
+		tx.Rollback()
+		return fmt.Errorf("UpdateSeries item repository: %w", err)
+	}
+	return tx.Commit()
+}
+
+// StopSeries ends the series, recording the change of its open occurrences,
+// which are left as they are but no longer recur.
+func (r *TodoItemPostgres) StopSeries(ctx context.Context, userId, seriesId int) error {
+	tx, err := beginTx(ctx, r.db)
+	if err != nil {
+		return fmt.Errorf("StopSeries item repository: %w", err)
+	}
+
+	if err = lockSeries(ctx, tx, userId, seriesId); err != nil {
+		tx.Rollback()
+		return fmt.Errorf("StopSeries item repository: %w", err)
+	}
+	before, err := seriesSnapshot(ctx, tx, userId, seriesId)
+	if err != nil {
+		tx.Rollback()
+		return fmt.Errorf("StopSeries item repository: %w", err)
+	}
+
+	query := fmt.Sprintf("UPDATE %s SET stopped_at = now() WHERE id = $1 AND stopped_at IS NULL", itemSeriesTable)
+	res, err := tx.ExecContext(ctx, query, seriesId)
+	if err != nil {
+		tx.Rollback()
+		return fmt.Errorf("StopSeries item repository: %w", err)
+	}
+	stopped, err := res.RowsAffected()
+	if err != nil {
+		tx.Rollback()
+		return fmt.Errorf("StopSeries item repository: %w", err)
+	}
+	if stopped > 0 {
+		if err = touchSeriesItems(ctx, tx, seriesId); err != nil {
+			tx.Rollback()
+			return fmt.Errorf("StopSeries item repository: %w", err)
+		}
+	}
+
+	after, err := seriesSnapshot(ctx, tx, userId, seriesId)
+	if err != nil {
+		tx.Rollback()
+		return fmt.Errorf("StopSeries item repository: %w", err)
+	}
+	if err = recordSeriesChange(ctx, tx, userId, before, after); err != nil {
+		tx.Rollback()
+		return fmt.Errorf("StopSeries item repository: %w", err)
+	}
+	return tx.Commit()
+}
@@ -1,6 +1,7 @@
 package repository
 
 import (
+	"context"
 	"database/sql"
 	todo "do-app"
 	"fmt"
@@ -8,6 +9,7 @@ import (
 	sqlmock "github.com/zhashkevych/go-sqlxmock"
 	"log"
 	"testing"
+	"time"
 )
 
 func TestTodoItemPostgres_Create(t *testing.T) {
@@ -19,6 +21,8 @@ func TestTodoItemPostgres_Create(t *testing.T) {
 
 	r := NewTodoItemPostgres(db)
 
+	dueAt := time.Date(2030, 1, 7, 9, 0, 0, 0, time.UTC)
+
 	type args struct {
 		listId int
 		item   todo.TodoItem
@@ -45,13 +49,46 @@ func TestTodoItemPostgres_Create(t *testing.T) {
 			mockBehavior: func(args args, id int) {
 				mock.ExpectBegin()
 
-				row := sqlmock.NewRows([]string{"id"}).AddRow(id)
-				mock.ExpectQuery("INSERT INTO todo_items").
-					WithArgs(args.item.Title, args.item.Description).WillReturnRows(row)
+				row := sqlmock.NewRows([]string{"id", "item"}).AddRow(id, `{"id": 2, "title": "test title"}`)
+				mock.ExpectQuery("INSERT INTO todo_items AS ti (.+) RETURNING ti.id, to_jsonb\\(ti\\)").
+					WithArgs(args.item.Title, args.item.Description, args.item.DueAt, args.item.RemindAt, args.item.SeriesId, args.item.Priority, args.item.AutoComplete).WillReturnRows(row)
 
+				expectLockPositions(mock, args.listId)
 				mock.ExpectExec("INSERT INTO lists_items").
 					WithArgs(args.listId, id).WillReturnResult(sqlmock.NewResult(1, 1))
 
+				expectActivity(mock, args.listId, 1, "item", "create").WillReturnRows(activityRow(1))
+
+				mock.ExpectCommit()
+			},
+			id: 2,
+		},
+		{
+			name: "Recurring",
+			args: args{
+				listId: 1,
+				item: todo.TodoItem{
+					Title: "weekly report",
+					DueAt: &dueAt,
+					RRule: "FREQ=WEEKLY;BYDAY=MO",
+				},
+			},
+			mockBehavior: func(args args, id int) {
+				mock.ExpectBegin()
+
+				mock.ExpectQuery("INSERT INTO item_series").
+					WithArgs(args.item.RRule, args.item.DueAt).WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(5))
+
+				row := sqlmock.NewRows([]string{"id", "item"}).AddRow(id, `{"id": 2, "title": "test title"}`)
+				mock.ExpectQuery("INSERT INTO todo_items AS ti (.+) RETURNING ti.id, to_jsonb\\(ti\\)").
+					WithArgs(args.item.Title, args.item.Description, args.item.DueAt, args.item.RemindAt, 5, args.item.Priority, args.item.AutoComplete).WillReturnRows(row)
+
+				expectLockPositions(mock, args.listId)
+				mock.ExpectExec("INSERT INTO lists_items").
+					WithArgs(args.listId, id).WillReturnResult(sqlmock.NewResult(1, 1))
+
+				expectActivity(mock, args.listId, 1, "item", "create").WillReturnRows(activityRow(1))
+
 				mock.ExpectCommit()
 			},
 			id: 2,
@@ -69,7 +106,7 @@ func TestTodoItemPostgres_Create(t *testing.T) {
 				mock.ExpectBegin()
 
 				mock.ExpectQuery("INSERT INTO todo_items").
-					WithArgs(args.item.Title, args.item.Description).WillReturnError(fmt.Errorf("some error"))
+					WithArgs(args.item.Title, args.item.Description, args.item.DueAt, args.item.RemindAt, args.item.SeriesId, args.item.Priority, args.item.AutoComplete).WillReturnError(fmt.Errorf("some error"))
 
 				mock.ExpectRollback()
 			},
@@ -88,10 +125,11 @@ func TestTodoItemPostgres_Create(t *testing.T) {
 			mockBehavior: func(args args, id int) {
 				mock.ExpectBegin()
 
-				row := sqlmock.NewRows([]string{"id"}).AddRow(id)
-				mock.ExpectQuery("INSERT INTO todo_items").
-					WithArgs(args.item.Title, args.item.Description).WillReturnRows(row)
+				row := sqlmock.NewRows([]string{"id", "item"}).AddRow(id, `{"id": 2, "title": "test title"}`)
+				mock.ExpectQuery("INSERT INTO todo_items AS ti (.+) RETURNING ti.id, to_jsonb\\(ti\\)").
+					WithArgs(args.item.Title, args.item.Description, args.item.DueAt, args.item.RemindAt, args.item.SeriesId, args.item.Priority, args.item.AutoComplete).WillReturnRows(row)
 
+				expectLockPositions(mock, args.listId)
 				mock.ExpectExec("INSERT INTO lists_items").WithArgs(args.listId, id).
 					WillReturnError(fmt.Errorf("some error"))
 
@@ -120,7 +158,7 @@ func TestTodoItemPostgres_Create(t *testing.T) {
 		t.Run(testCase.name, func(t *testing.T) {
 			testCase.mockBehavior(testCase.args, testCase.id)
 
-			got, err := r.Create(testCase.args.listId, testCase.args.item)
+			got, err := r.Create(context.Background(), 1, testCase.args.listId, testCase.args.item)
 
 			if testCase.wantErr {
 				assert.Error(t, err)
@@ -144,6 +182,8 @@ func TestTodoItemPostgres_GetAll(t *testing.T) {
 	type args struct {
 		listId int
 		userId int
+		filter todo.ItemFilter
+		page   todo.PageRequest
 	}
 
 	type mockBehavior func(args args, items []todo.TodoItem)
@@ -152,6 +192,7 @@ func TestTodoItemPostgres_GetAll(t *testing.T) {
 		name         string
 		args         args
 		items        []todo.TodoItem
+		next         string
 		mockBehavior mockBehavior
 		wantErr      bool
 	}{
@@ -186,10 +227,78 @@ func TestTodoItemPostgres_GetAll(t *testing.T) {
 					AddRow(items[0].Id, items[0].Title, items[0].Description, items[0].Done).
 					AddRow(items[1].Id, items[1].Title, items[1].Description, items[1].Done).
 					AddRow(items[2].Id, items[2].Title, items[2].Description, items[2].Done)
-				mock.ExpectQuery(`SELECT ti.id, ti.title, ti.description, ti.done FROM todo_items ti`).
+				mock.ExpectQuery(`SELECT ti.id, li.list_id, (.+) FROM todo_items ti (.+) WHERE li.list_id = \$1 AND ul.user_id = \$2 AND ti.deleted_at IS NULL ORDER BY li.position`).
 					WithArgs(args.listId, args.userId).WillReturnRows().WillReturnRows(row)
 			},
 		},
+		{
+			name: "Next page",
+			args: args{
+				listId: 1,
+				userId: 1,
+				filter: todo.ItemFilter{Done: boolPointer(false), Query: "milk"},
+				page:   todo.PageRequest{Limit: 1, Sort: "-title"},
+			},
+			items: []todo.TodoItem{
+				{Id: 4, Title: "buy milk"},
+			},
+			next: encodeCursor(cursor{Sort: "-title", Value: "buy milk", Id: 4}),
+			mockBehavior: func(args args, items []todo.TodoItem) {
+
+				row := sqlmock.NewRows([]string{"id", "title"}).
+					AddRow(4, "buy milk").
+					AddRow(2, "almond milk")
+				mock.ExpectQuery(`WHERE li.list_id = \$1 AND ul.user_id = \$2 AND ti.deleted_at IS NULL AND ti.done = \$3 `+
+					`AND strpos\(lower\(ti.title\), lower\(\$4\)\) > 0 ORDER BY ti.title DESC, ti.id DESC LIMIT 2`).
+					WithArgs(args.listId, args.userId, false, "milk").WillReturnRows(row)
+			},
+		},
+		{
+			name: "After cursor",
+			args: args{
+				listId: 1,
+				userId: 1,
+				page:   todo.PageRequest{Cursor: encodeCursor(cursor{Sort: "due", Value: "infinity", Id: 7}), Sort: "due"},
+			},
+			items: []todo.TodoItem{
+				{Id: 8, Title: "no due date"},
+			},
+			mockBehavior: func(args args, items []todo.TodoItem) {
+
+				row := sqlmock.NewRows([]string{"id", "title"}).AddRow(8, "no due date")
+				mock.ExpectQuery(`AND \(COALESCE\(ti.due_at, 'infinity'\), ti.id\) > \(\$3::timestamptz, \$4\) `+
+					`ORDER BY COALESCE\(ti.due_at, 'infinity'\) ASC, ti.id ASC LIMIT 51`).
+					WithArgs(args.listId, args.userId, "infinity", 7).WillReturnRows(row)
+			},
+		},
+		{
+			name: "After cursor with an offset",
+			args: args{
+				listId: 1,
+				userId: 1,
+				page: todo.PageRequest{Cursor: encodeCursor(cursor{Sort: "due", Value: "2030-01-02T10:00:00+01:00", Id: 7}),
+					Sort: "due"},
+			},
+			items: []todo.TodoItem{
+				{Id: 8, Title: "no due date"},
+			},
+			mockBehavior: func(args args, items []todo.TodoItem) {
+
+				row := sqlmock.NewRows([]string{"id", "title"}).AddRow(8, "no due date")
+				mock.ExpectQuery(`AND \(COALESCE\(ti.due_at, 'infinity'\), ti.id\) > \(\$3::timestamptz, \$4\) `).
+					WithArgs(args.listId, args.userId, "2030-01-02T10:00:00+01:00", 7).WillReturnRows(row)
+			},
+		},
+		{
+			name: "Invalid cursor",
+			args: args{
+				listId: 1,
+				userId: 1,
+				page:   todo.PageRequest{Cursor: "not a cursor"},
+			},
+			mockBehavior: func(args args, items []todo.TodoItem) {},
+			wantErr:      true,
+		},
 		{
 			name: "Select Error",
 			args: args{
@@ -198,7 +307,7 @@ func TestTodoItemPostgres_GetAll(t *testing.T) {
 			},
 			mockBehavior: func(args args, items []todo.TodoItem) {
 
-				mock.ExpectQuery(`SELECT ti.id, ti.title, ti.description, ti.done FROM todo_items ti`).
+				mock.ExpectQuery(`SELECT ti.id, li.list_id, (.+) FROM todo_items ti (.+) WHERE li.list_id = \$1 AND ul.user_id = \$2 AND ti.deleted_at IS NULL ORDER BY li.position`).
 					WithArgs(args.listId, args.userId).WillReturnError(assert.AnError)
 			},
 			wantErr: true,
@@ -209,7 +318,7 @@ func TestTodoItemPostgres_GetAll(t *testing.T) {
 		t.Run(testCase.name, func(t *testing.T) {
 			testCase.mockBehavior(testCase.args, testCase.items)
 
-			got, err := r.GetAll(testCase.args.userId, testCase.args.listId)
+			got, next, err := r.GetAll(context.Background(), testCase.args.userId, testCase.args.listId, testCase.args.filter, testCase.args.page)
 
 			if testCase.wantErr {
 				assert.Error(t, err)
@@ -217,6 +326,7 @@ func TestTodoItemPostgres_GetAll(t *testing.T) {
 			} else {
 				assert.NoError(t, err)
 				assert.Equal(t, testCase.items, got)
+				assert.Equal(t, testCase.next, next)
 			}
 		})
 	}
@@ -248,19 +358,21 @@ func TestTodoItemPostgres_GetById(t *testing.T) {
 		{
 			name: "OK",
 			item: todo.TodoItem{
-				Id:          1,
-				Title:       "test title",
-				Description: "test description",
-				Done:        true,
+				Id:            1,
+				Title:         "test title",
+				Description:   "test description",
+				Done:          true,
+				SubtasksDone:  3,
+				SubtasksTotal: 5,
 			},
 			args: args{
 				userId: 1,
 				itemId: 1,
 			},
 			mockBehavior: func(args args, item todo.TodoItem) {
-				row := sqlmock.NewRows([]string{"id", "title", "description", "done"}).
-					AddRow(item.Id, item.Title, item.Description, item.Done)
-				mock.ExpectQuery(`SELECT ti.id, ti.title, ti.description, ti.done FROM todo_items ti`).
+				row := sqlmock.NewRows([]string{"id", "title", "description", "done", "subtasks_done", "subtasks_total"}).
+					AddRow(item.Id, item.Title, item.Description, item.Done, item.SubtasksDone, item.SubtasksTotal)
+				mock.ExpectQuery(`SELECT ti.id, li.list_id, ti.title, (.+) FROM todo_items ti`).
 					WithArgs(args.itemId, args.userId).WillReturnRows(row)
 			},
 		},
@@ -271,7 +383,7 @@ func TestTodoItemPostgres_GetById(t *testing.T) {
 				itemId: 1,
 			},
 			mockBehavior: func(args args, item todo.TodoItem) {
-				mock.ExpectQuery(`SELECT ti.id, ti.title, ti.description, ti.done FROM todo_items ti`).
+				mock.ExpectQuery(`SELECT ti.id, li.list_id, ti.title, (.+) FROM todo_items ti`).
 					WithArgs(args.itemId, args.userId).WillReturnError(fmt.Errorf("some error"))
 			},
 			wantErr: true,
@@ -283,7 +395,7 @@ func TestTodoItemPostgres_GetById(t *testing.T) {
 
 			testCase.mockBehavior(testCase.args, testCase.item)
 
-			got, err := r.GetById(testCase.args.userId, testCase.args.itemId)
+			got, err := r.GetById(context.Background(), testCase.args.userId, testCase.args.itemId)
 
 			if testCase.wantErr {
 				assert.Error(t, err)
@@ -306,8 +418,9 @@ func TestTodoItemPostgres_Delete(t *testing.T) {
 	r := NewTodoItemPostgres(db)
 
 	type args struct {
-		userId int
-		itemId int
+		userId  int
+		itemId  int
+		version int
 	}
 
 	type mockBehavior func(args args)
@@ -317,6 +430,7 @@ func TestTodoItemPostgres_Delete(t *testing.T) {
 		args         args
 		mockBehavior mockBehavior
 		wantErr      bool
+		wantKind     error
 	}{
 		{
 			name: "OK",
@@ -325,8 +439,12 @@ func TestTodoItemPostgres_Delete(t *testing.T) {
 				itemId: 1,
 			},
 			mockBehavior: func(args args) {
-				mock.ExpectExec(`DELETE FROM todo_items ti USING lists_items li, users_lists ul`).
-					WithArgs(args.userId, args.itemId).WillReturnResult(sqlmock.NewResult(1, 0))
+				mock.ExpectBegin()
+				expectItemSnapshot(mock, args.itemId, 2, `{}`)
+				mock.ExpectExec(`UPDATE todo_items ti SET deleted_at = now\(\) FROM lists_items li, users_lists ul`).
+					WithArgs(args.userId, args.itemId, args.version).WillReturnResult(sqlmock.NewResult(0, 1))
+				expectActivity(mock, 2, args.userId, "item", "delete").WillReturnRows(activityRow(1))
+				mock.ExpectCommit()
 			},
 		},
 		{
@@ -336,10 +454,45 @@ func TestTodoItemPostgres_Delete(t *testing.T) {
 				itemId: 1,
 			},
 			mockBehavior: func(args args) {
-				mock.ExpectExec(`DELETE FROM todo_items ti USING lists_items li, users_lists ul`).
-					WithArgs(args.userId, args.itemId).WillReturnError(sql.ErrNoRows)
+				mock.ExpectBegin()
+				mock.ExpectQuery(`SELECT li.list_id, to_jsonb\(ti\) (.+) AS item`).WithArgs(args.itemId).WillReturnError(sql.ErrNoRows)
+				mock.ExpectRollback()
 			},
-			wantErr: true,
+			wantErr:  true,
+			wantKind: todo.ErrNotFound,
+		},
+		{
+			name: "Nothing Deleted",
+			args: args{
+				userId: 1,
+				itemId: 1,
+			},
+			mockBehavior: func(args args) {
+				mock.ExpectBegin()
+				expectItemSnapshot(mock, args.itemId, 2, `{}`)
+				mock.ExpectExec(`UPDATE todo_items ti SET deleted_at = now\(\) FROM lists_items li, users_lists ul`).
+					WithArgs(args.userId, args.itemId, args.version).WillReturnResult(sqlmock.NewResult(0, 0))
+				mock.ExpectRollback()
+			},
+			wantErr:  true,
+			wantKind: todo.ErrNotFound,
+		},
+		{
+			name: "Stale Version",
+			args: args{
+				userId:  1,
+				itemId:  1,
+				version: 3,
+			},
+			mockBehavior: func(args args) {
+				mock.ExpectBegin()
+				expectItemSnapshot(mock, args.itemId, 2, `{}`)
+				mock.ExpectExec(`UPDATE todo_items ti SET deleted_at = now\(\) FROM lists_items li, users_lists ul`).
+					WithArgs(args.userId, args.itemId, args.version).WillReturnResult(sqlmock.NewResult(0, 0))
+				mock.ExpectRollback()
+			},
+			wantErr:  true,
+			wantKind: todo.ErrPreconditionFailed,
 		},
 	}
 
@@ -348,12 +501,16 @@ func TestTodoItemPostgres_Delete(t *testing.T) {
 
 			testCase.mockBehavior(testCase.args)
 
-			err = r.Delete(testCase.args.userId, testCase.args.itemId)
+			_, err = r.Delete(context.Background(), testCase.args.userId, testCase.args.itemId, testCase.args.version)
 			if testCase.wantErr {
 				assert.Error(t, err)
+				if testCase.wantKind != nil {
+					assert.ErrorIs(t, err, testCase.wantKind)
+				}
 			} else {
 				assert.NoError(t, err)
 			}
+			assert.NoError(t, mock.ExpectationsWereMet())
 		})
 	}
 }
@@ -376,9 +533,10 @@ func TestTodoItemPostgres_update(t *testing.T) {
 	r := NewTodoItemPostgres(db)
 
 	type args struct {
-		userId int
-		itemId int
-		input  todo.UpdateItemInput
+		userId  int
+		itemId  int
+		input   todo.UpdateItemInput
+		version int
 	}
 
 	type mockBehavior func(args args)
@@ -401,10 +559,15 @@ func TestTodoItemPostgres_update(t *testing.T) {
 				},
 			},
 			mockBehavior: func(args args) {
+				mock.ExpectBegin()
+				expectItemSnapshot(mock, args.itemId, 2, `{"title": "milk"}`)
 				mock.ExpectExec(`UPDATE todo_items ti SET (.+) FROM lists_items li, users_lists ul 
                     						 WHERE (.+)`).
-					WithArgs(args.input.Title, args.input.Description, args.input.Done, args.userId, args.itemId).
-					WillReturnResult(sqlmock.NewResult(1, 0))
+					WithArgs(args.input.Title, args.input.Description, args.input.Done, args.userId, args.itemId, args.version).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				expectItemSnapshot(mock, args.itemId, 2, `{"title": "title test"}`)
+				expectActivity(mock, 2, args.userId, "item", "update").WillReturnRows(activityRow(1))
+				mock.ExpectCommit()
 			},
 		},
 		{
@@ -418,10 +581,35 @@ func TestTodoItemPostgres_update(t *testing.T) {
 				},
 			},
 			mockBehavior: func(args args) {
+				mock.ExpectBegin()
+				expectItemSnapshot(mock, args.itemId, 2, `{"title": "milk"}`)
 				mock.ExpectExec(`UPDATE todo_items ti SET (.+) FROM lists_items li, users_lists ul 
                     						 WHERE (.+)`).
-					WithArgs(args.input.Title, args.input.Done, args.userId, args.itemId).
-					WillReturnResult(sqlmock.NewResult(1, 0))
+					WithArgs(args.input.Title, args.input.Done, args.userId, args.itemId, args.version).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				expectItemSnapshot(mock, args.itemId, 2, `{"title": "title test"}`)
+				expectActivity(mock, 2, args.userId, "item", "update").WillReturnRows(activityRow(1))
+				mock.ExpectCommit()
+			},
+		},
+		{
+			name: "Reopen",
+			args: args{
+				userId: 1,
+				itemId: 1,
+				input: todo.UpdateItemInput{
+					Done: boolPointer(false),
+				},
+			},
+			mockBehavior: func(args args) {
+				mock.ExpectBegin()
+				expectItemSnapshot(mock, args.itemId, 2, `{"title": "milk"}`)
+				mock.ExpectExec(`UPDATE todo_items ti SET done=\$1, completed_at=CASE WHEN \$1 THEN (.+) END FROM`).
+					WithArgs(false, args.userId, args.itemId, args.version).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				expectItemSnapshot(mock, args.itemId, 2, `{"title": "title test"}`)
+				expectActivity(mock, 2, args.userId, "item", "update").WillReturnRows(activityRow(1))
+				mock.ExpectCommit()
 			},
 		},
 		{
@@ -432,12 +620,57 @@ func TestTodoItemPostgres_update(t *testing.T) {
 				input:  todo.UpdateItemInput{},
 			},
 			mockBehavior: func(args args) {
+				mock.ExpectBegin()
+				expectItemSnapshot(mock, args.itemId, 2, `{"title": "milk"}`)
 				mock.ExpectExec(`UPDATE todo_items ti SET FROM lists_items li, users_lists ul 
                     						 WHERE (.+)`).
-					WithArgs(args.userId, args.itemId).
-					WillReturnResult(sqlmock.NewResult(1, 0))
+					WithArgs(args.userId, args.itemId, args.version).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				expectItemSnapshot(mock, args.itemId, 2, `{"title": "title test"}`)
+				expectActivity(mock, 2, args.userId, "item", "update").WillReturnRows(activityRow(1))
+				mock.ExpectCommit()
 			},
 		},
+		{
+			name: "Clear due date",
+			args: args{
+				userId: 1,
+				itemId: 1,
+				input: todo.UpdateItemInput{
+					DueAt: todo.NullableTime{Set: true},
+				},
+			},
+			mockBehavior: func(args args) {
+				mock.ExpectBegin()
+				expectItemSnapshot(mock, args.itemId, 2, `{"title": "milk"}`)
+				mock.ExpectExec(`UPDATE todo_items ti SET due_at=\$1 FROM lists_items li, users_lists ul 
+                    						 WHERE (.+)`).
+					WithArgs(nil, args.userId, args.itemId, args.version).
+					WillReturnResult(sqlmock.NewResult(1, 1))
+				expectItemSnapshot(mock, args.itemId, 2, `{"title": "title test"}`)
+				expectActivity(mock, 2, args.userId, "item", "update").WillReturnRows(activityRow(1))
+				mock.ExpectCommit()
+			},
+		},
+		{
+			name: "Activity Error",
+			args: args{
+				userId: 1,
+				itemId: 1,
+				input:  todo.UpdateItemInput{Title: stringPointer("title test")},
+			},
+			mockBehavior: func(args args) {
+				mock.ExpectBegin()
+				expectItemSnapshot(mock, args.itemId, 2, `{"title": "milk"}`)
+				mock.ExpectExec(`UPDATE todo_items ti SET title=\$1`).
+					WithArgs(args.input.Title, args.userId, args.itemId, args.version).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				expectItemSnapshot(mock, args.itemId, 2, `{"title": "title test"}`)
+				expectActivity(mock, 2, args.userId, "item", "update").WillReturnError(assert.AnError)
+				mock.ExpectRollback()
+			},
+			wantErr: true,
+		},
 	}
 
 	for _, testCase := range testTable {
@@ -445,12 +678,562 @@ func TestTodoItemPostgres_update(t *testing.T) {
 
 			testCase.mockBehavior(testCase.args)
 
-			err = r.Update(testCase.args.userId, testCase.args.itemId, testCase.args.input)
+			_, err = r.Update(context.Background(), testCase.args.userId, testCase.args.itemId, testCase.args.input, testCase.args.version)
+			if testCase.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestTodoItemPostgres_GetAllByUser(t *testing.T) {
+	db, mock, err := sqlmock.Newx()
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer db.Close()
+
+	r := NewTodoItemPostgres(db)
+
+	dueBefore := time.Date(2030, 1, 2, 0, 0, 0, 0, time.UTC)
+	dueAt := dueBefore.Add(-time.Hour)
+
+	type mockBehavior func(items []todo.TodoItem)
+
+	testTable := []struct {
+		name         string
+		filter       todo.ItemFilter
+		items        []todo.TodoItem
+		mockBehavior mockBehavior
+		wantErr      bool
+	}{
+		{
+			name:   "Due before",
+			filter: todo.ItemFilter{DueBefore: &dueBefore},
+			items: []todo.TodoItem{
+				{Id: 1, ListId: 2, Title: "report", DueAt: &dueAt},
+			},
+			mockBehavior: func(items []todo.TodoItem) {
+				rows := sqlmock.NewRows([]string{"id", "list_id", "title", "description", "done", "due_at", "remind_at"}).
+					AddRow(items[0].Id, items[0].ListId, items[0].Title, items[0].Description, items[0].Done, dueAt, nil)
+				mock.ExpectQuery(`SELECT ti.id, li.list_id, (.+) WHERE ul.user_id = \$1 AND ti.deleted_at IS NULL AND ti.due_at < \$2 ORDER BY`).
+					WithArgs(1, dueBefore).WillReturnRows(rows)
+			},
+		},
+		{
+			name:   "Overdue",
+			filter: todo.ItemFilter{Overdue: true},
+			mockBehavior: func(items []todo.TodoItem) {
+				rows := sqlmock.NewRows([]string{"id", "list_id", "title", "description", "done", "due_at", "remind_at"})
+				mock.ExpectQuery(`SELECT ti.id, li.list_id, (.+) WHERE ul.user_id = \$1 AND ti.deleted_at IS NULL AND ti.due_at < now\(\) AND ti.done = false`).
+					WithArgs(1).WillReturnRows(rows)
+			},
+		},
+		{
+			name:   "Any label",
+			filter: todo.ItemFilter{Labels: []string{"work", "home"}},
+			mockBehavior: func(items []todo.TodoItem) {
+				rows := sqlmock.NewRows([]string{"id", "list_id", "title", "description", "done", "due_at", "remind_at"})
+				mock.ExpectQuery(`SELECT ti.id, li.list_id, (.+) WHERE ul.user_id = \$1 AND ti.deleted_at IS NULL AND ti.id IN \(SELECT il.item_id FROM items_labels il (.+) l.name IN \(\$2, \$3\) GROUP BY il.item_id\) ORDER BY`).
+					WithArgs(1, "work", "home").WillReturnRows(rows)
+			},
+		},
+		{
+			name:   "All labels",
+			filter: todo.ItemFilter{Labels: []string{"work", "home"}, AllLabels: true},
+			mockBehavior: func(items []todo.TodoItem) {
+				rows := sqlmock.NewRows([]string{"id", "list_id", "title", "description", "done", "due_at", "remind_at"})
+				mock.ExpectQuery(`SELECT ti.id, li.list_id, (.+) GROUP BY il.item_id HAVING count\(\*\) = 2\) ORDER BY`).
+					WithArgs(1, "work", "home").WillReturnRows(rows)
+			},
+		},
+		{
+			name:   "Select Error",
+			filter: todo.ItemFilter{},
+			mockBehavior: func(items []todo.TodoItem) {
+				mock.ExpectQuery(`SELECT ti.id, li.list_id`).WithArgs(1).WillReturnError(assert.AnError)
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			testCase.mockBehavior(testCase.items)
+
+			got, _, err := r.GetAllByUser(context.Background(), 1, testCase.filter, todo.PageRequest{})
+
+			if testCase.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, testCase.items, got)
+			}
+		})
+	}
+}
+
+func TestTodoItemPostgres_CompleteOccurrence(t *testing.T) {
+	db, mock, err := sqlmock.Newx()
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer db.Close()
+
+	r := NewTodoItemPostgres(db)
+
+	seriesId := 5
+	dueAt := time.Date(2030, 1, 14, 9, 0, 0, 0, time.UTC)
+	input := todo.UpdateItemInput{Done: boolPointer(true)}
+	next := todo.TodoItem{ListId: 3, Title: "weekly report", DueAt: &dueAt, SeriesId: &seriesId}
+
+	testTable := []struct {
+		name         string
+		mockBehavior func()
+		want         int
+		wantErr      bool
+	}{
+		{
+			name: "OK",
+			mockBehavior: func() {
+				mock.ExpectBegin()
+				expectItemSnapshot(mock, 1, 3, `{"done": false}`)
+				mock.ExpectExec(`UPDATE todo_items ti SET done=\$1, completed_at=CASE WHEN \$1 THEN COALESCE\(ti.completed_at, now\(\)\) END FROM lists_items li`).
+					WithArgs(true, 2, 1, 4).WillReturnResult(sqlmock.NewResult(0, 1))
+				expectItemSnapshot(mock, 1, 3, `{"done": true}`)
+				expectActivity(mock, 3, 2, "item", "update").WillReturnRows(activityRow(1))
+				mock.ExpectQuery("INSERT INTO todo_items").
+					WithArgs(next.Title, next.Description, next.DueAt, next.RemindAt, seriesId, next.Priority, next.AutoComplete).
+					WillReturnRows(sqlmock.NewRows([]string{"id", "item"}).AddRow(7, `{"title": "weekly report"}`))
+				expectLockPositions(mock, next.ListId)
+				mock.ExpectExec("INSERT INTO lists_items").
+					WithArgs(next.ListId, 7).WillReturnResult(sqlmock.NewResult(1, 1))
+				expectActivity(mock, next.ListId, 2, "item", "create").WillReturnRows(activityRow(2))
+				mock.ExpectExec(`INSERT INTO subtasks \(item_id, title\) SELECT \$1, title FROM subtasks WHERE item_id = \$2`).
+					WithArgs(7, 1).WillReturnResult(sqlmock.NewResult(0, 2))
+				mock.ExpectCommit()
+			},
+			want: 7,
+		},
+		{
+			name: "Already done",
+			mockBehavior: func() {
+				mock.ExpectBegin()
+				expectItemSnapshot(mock, 1, 3, `{"done": true}`)
+				mock.ExpectExec(`UPDATE todo_items ti SET done=\$1, completed_at=CASE WHEN \$1 THEN COALESCE\(ti.completed_at, now\(\)\) END FROM lists_items li`).
+					WithArgs(true, 2, 1, 4).WillReturnResult(sqlmock.NewResult(0, 1))
+				expectItemSnapshot(mock, 1, 3, `{"done": true}`)
+				expectActivity(mock, 3, 2, "item", "update").WillReturnRows(activityRow(1))
+				mock.ExpectCommit()
+			},
+		},
+		{
+			name: "Insert Error",
+			mockBehavior: func() {
+				mock.ExpectBegin()
+				expectItemSnapshot(mock, 1, 3, `{"done": false}`)
+				mock.ExpectExec(`UPDATE todo_items ti SET done=\$1, completed_at=CASE WHEN \$1 THEN COALESCE\(ti.completed_at, now\(\)\) END FROM lists_items li`).
+					WithArgs(true, 2, 1, 4).WillReturnResult(sqlmock.NewResult(0, 1))
+				expectItemSnapshot(mock, 1, 3, `{"done": true}`)
+				expectActivity(mock, 3, 2, "item", "update").WillReturnRows(activityRow(1))
+				mock.ExpectQuery("INSERT INTO todo_items").
+					WithArgs(next.Title, next.Description, next.DueAt, next.RemindAt, seriesId, next.Priority, next.AutoComplete).
+					WillReturnError(assert.AnError)
+				mock.ExpectRollback()
+			},
+			wantErr: true,
+		},
+		{
+			name: "Version mismatch",
+			mockBehavior: func() {
+				mock.ExpectBegin()
+				expectItemSnapshot(mock, 1, 3, `{"done": false}`)
+				mock.ExpectExec(`UPDATE todo_items ti SET done=\$1, completed_at=CASE WHEN \$1 THEN COALESCE\(ti.completed_at, now\(\)\) END FROM lists_items li`).
+					WithArgs(true, 2, 1, 4).WillReturnResult(sqlmock.NewResult(0, 0))
+				mock.ExpectRollback()
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			testCase.mockBehavior()
+
+			got, err := r.CompleteOccurrence(context.Background(), 2, 1, input, next, 4)
+
+			if testCase.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, testCase.want, got)
+			}
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestTodoItemPostgres_SetSeriesRule(t *testing.T) {
+	db, mock, err := sqlmock.Newx()
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer db.Close()
+
+	r := NewTodoItemPostgres(db)
+
+	rule := "FREQ=DAILY"
+	dtstart := time.Date(2030, 1, 7, 9, 0, 0, 0, time.UTC)
+
+	testTable := []struct {
+		name         string
+		mockBehavior func()
+		want         int
+		wantErr      bool
+	}{
+		{
+			name: "Existing series",
+			mockBehavior: func() {
+				mock.ExpectBegin()
+				expectItemSnapshot(mock, 1, 3, `{"series_id": 5, "rrule": "FREQ=WEEKLY"}`)
+				mock.ExpectQuery(`UPDATE item_series s SET rrule = \$1, stopped_at = NULL FROM todo_items ti`).
+					WithArgs(rule, 1).WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(5))
+				mock.ExpectExec(`UPDATE todo_items ti SET series_id = \$1 FROM lists_items li, users_lists ul`).
+					WithArgs(5, 2, 1, 4).WillReturnResult(sqlmock.NewResult(0, 1))
+				expectItemSnapshot(mock, 1, 3, `{"series_id": 5, "rrule": "FREQ=DAILY"}`)
+				expectActivity(mock, 3, 2, "item", "update").WillReturnRows(activityRow(1))
+				mock.ExpectCommit()
+			},
+			want: 5,
+		},
+		{
+			name: "New series",
+			mockBehavior: func() {
+				mock.ExpectBegin()
+				expectItemSnapshot(mock, 1, 3, `{"series_id": null, "rrule": null}`)
+				mock.ExpectQuery(`UPDATE item_series s SET rrule = \$1, stopped_at = NULL FROM todo_items ti`).
+					WithArgs(rule, 1).WillReturnError(sql.ErrNoRows)
+				mock.ExpectQuery(`INSERT INTO item_series`).
+					WithArgs(rule, dtstart).WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(6))
+				mock.ExpectExec(`UPDATE todo_items ti SET series_id = \$1 FROM lists_items li, users_lists ul`).
+					WithArgs(6, 2, 1, 4).WillReturnResult(sqlmock.NewResult(0, 1))
+				expectItemSnapshot(mock, 1, 3, `{"series_id": 6, "rrule": "FREQ=DAILY"}`)
+				expectActivity(mock, 3, 2, "item", "update").WillReturnRows(activityRow(1))
+				mock.ExpectCommit()
+			},
+			want: 6,
+		},
+		{
+			name: "Version mismatch",
+			mockBehavior: func() {
+				mock.ExpectBegin()
+				expectItemSnapshot(mock, 1, 3, `{"series_id": 5, "rrule": "FREQ=WEEKLY"}`)
+				mock.ExpectQuery(`UPDATE item_series s SET rrule = \$1, stopped_at = NULL FROM todo_items ti`).
+					WithArgs(rule, 1).WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(5))
+				mock.ExpectExec(`UPDATE todo_items ti SET series_id = \$1 FROM lists_items li, users_lists ul`).
+					WithArgs(5, 2, 1, 4).WillReturnResult(sqlmock.NewResult(0, 0))
+				mock.ExpectRollback()
+			},
+			wantErr: true,
+		},
+		{
+			name: "Failed",
+			mockBehavior: func() {
+				mock.ExpectBegin()
+				expectItemSnapshot(mock, 1, 3, `{"series_id": 5, "rrule": "FREQ=WEEKLY"}`)
+				mock.ExpectQuery(`UPDATE item_series s SET rrule = \$1, stopped_at = NULL FROM todo_items ti`).
+					WithArgs(rule, 1).WillReturnError(assert.AnError)
+				mock.ExpectRollback()
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			testCase.mockBehavior()
+
+			got, err := r.SetSeriesRule(context.Background(), 2, 1, rule, dtstart, 4)
+
+			if testCase.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, testCase.want, got)
+			}
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestTodoItemPostgres_UpdateSeries(t *testing.T) {
+	db, mock, err := sqlmock.Newx()
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer db.Close()
+
+	r := NewTodoItemPostgres(db)
+
+	testTable := []struct {
+		name         string
+		input        todo.UpdateSeriesInput
+		mockBehavior func()
+		wantErr      bool
+	}{
+		{
+			name:  "Rule and title",
+			input: todo.UpdateSeriesInput{RRule: stringPointer("FREQ=MONTHLY"), Title: stringPointer("invoice")},
+			mockBehavior: func() {
+				mock.ExpectBegin()
+				expectLockSeries(mock, 5, 2)
+				expectSeriesSnapshot(mock, 5, 2, 3, 1, `{"title": "bill", "rrule": "FREQ=WEEKLY"}`)
+				mock.ExpectExec(`UPDATE item_series SET rrule = \$1 WHERE id = \$2`).
+					WithArgs("FREQ=MONTHLY", 5).WillReturnResult(sqlmock.NewResult(0, 1))
+				expectTouchSeriesItems(mock, 5)
+				mock.ExpectExec(`UPDATE todo_items SET title=\$1 WHERE series_id = \$2 AND done = false (.+) ul.user_id = \$3`).
+					WithArgs("invoice", 5, 2).WillReturnResult(sqlmock.NewResult(0, 1))
+				expectSeriesSnapshot(mock, 5, 2, 3, 1, `{"title": "invoice", "rrule": "FREQ=MONTHLY"}`)
+				expectActivity(mock, 3, 2, "item", "update").WillReturnRows(activityRow(1))
+				mock.ExpectCommit()
+			},
+		},
+		{
+			name:  "Failed",
+			input: todo.UpdateSeriesInput{Description: stringPointer("monthly")},
+			mockBehavior: func() {
+				mock.ExpectBegin()
+				expectLockSeries(mock, 5, 2)
+				expectSeriesSnapshot(mock, 5, 2, 3, 1, `{"description": ""}`)
+				mock.ExpectExec(`UPDATE todo_items SET description=\$1 WHERE series_id = \$2 AND done = false (.+) ul.user_id = \$3`).
+					WithArgs("monthly", 5, 2).WillReturnError(assert.AnError)
+				mock.ExpectRollback()
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			testCase.mockBehavior()
+
+			err := r.UpdateSeries(context.Background(), 2, 5, testCase.input)
+
+			if testCase.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestTodoItemPostgres_StopSeries(t *testing.T) {
+	db, mock, err := sqlmock.Newx()
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer db.Close()
+
+	r := NewTodoItemPostgres(db)
+
+	testTable := []struct {
+		name         string
+		mockBehavior func()
+		wantErr      bool
+	}{
+		{
+			name: "OK",
+			mockBehavior: func() {
+				mock.ExpectBegin()
+				expectLockSeries(mock, 5, 2)
+				expectSeriesSnapshot(mock, 5, 2, 3, 1, `{"rrule": "FREQ=WEEKLY"}`)
+				mock.ExpectExec(`UPDATE item_series SET stopped_at = now\(\) WHERE id = \$1 AND stopped_at IS NULL`).
+					WithArgs(5).WillReturnResult(sqlmock.NewResult(0, 1))
+				expectTouchSeriesItems(mock, 5)
+				expectSeriesSnapshot(mock, 5, 2, 3, 1, `{"rrule": null}`)
+				expectActivity(mock, 3, 2, "item", "update").WillReturnRows(activityRow(1))
+				mock.ExpectCommit()
+			},
+		},
+		{
+			name: "Failed",
+			mockBehavior: func() {
+				mock.ExpectBegin()
+				expectLockSeries(mock, 5, 2)
+				expectSeriesSnapshot(mock, 5, 2, 3, 1, `{"rrule": "FREQ=WEEKLY"}`)
+				mock.ExpectExec(`UPDATE item_series SET stopped_at = now\(\)`).
+					WithArgs(5).WillReturnError(assert.AnError)
+				mock.ExpectRollback()
+			},
+			wantErr: true,
+		},
+		{
+			name: "Already stopped",
+			mockBehavior: func() {
+				mock.ExpectBegin()
+				expectLockSeries(mock, 5, 2)
+				expectSeriesSnapshot(mock, 5, 2, 3, 1, `{"rrule": null}`)
+				mock.ExpectExec(`UPDATE item_series SET stopped_at = now\(\)`).
+					WithArgs(5).WillReturnResult(sqlmock.NewResult(0, 0))
+				expectSeriesSnapshot(mock, 5, 2, 3, 1, `{"rrule": null}`)
+				expectActivity(mock, 3, 2, "item", "update").WillReturnRows(activityRow(1))
+				mock.ExpectCommit()
+			},
+		},
+		{
+			name: "Series of lists of other users",
+			mockBehavior: func() {
+				mock.ExpectBegin()
+				expectLockSeries(mock, 5, 2).WillReturnRows(sqlmock.NewRows([]string{"id"}))
+				mock.ExpectRollback()
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			testCase.mockBehavior()
+
+			err := r.StopSeries(context.Background(), 2, 5)
+
+			if testCase.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func expectLockPositions(mock sqlmock.Sqlmock, listId int) {
+	mock.ExpectExec(`SELECT id FROM todo_lists WHERE id = \$1 FOR NO KEY UPDATE`).
+		WithArgs(listId).WillReturnResult(sqlmock.NewResult(0, 1))
+}
+
+func expectTouchSeriesItems(mock sqlmock.Sqlmock, seriesId int) {
+	mock.ExpectExec(`UPDATE todo_items SET version = version WHERE series_id = \$1`).
+		WithArgs(seriesId).WillReturnResult(sqlmock.NewResult(0, 1))
+}
+
+func expectLockSeries(mock sqlmock.Sqlmock, seriesId, userId int) *sqlmock.ExpectedQuery {
+	return mock.ExpectQuery(`SELECT s.id FROM item_series s WHERE s.id = \$1 AND EXISTS (.+) FOR UPDATE OF s`).
+		WithArgs(seriesId, userId).WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(seriesId))
+}
+
+func TestTodoItemPostgres_Move(t *testing.T) {
+	db, mock, err := sqlmock.Newx()
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer db.Close()
+
+	r := NewTodoItemPostgres(db)
+
+	after := 4
+
+	testTable := []struct {
+		name         string
+		input        todo.MoveItemInput
+		mockBehavior func()
+		want         float64
+		wantErr      bool
+	}{
+		{
+			name:  "After item",
+			input: todo.MoveItemInput{AfterId: &after},
+			mockBehavior: func() {
+				mock.ExpectBegin()
+				mock.ExpectQuery(`SELECT list_id, position FROM lists_items WHERE item_id = \$1 FOR UPDATE`).
+					WithArgs(1).WillReturnRows(sqlmock.NewRows([]string{"list_id", "position"}).AddRow(2, 3.0))
+				expectLockPositions(mock, 2)
+				mock.ExpectQuery(`SELECT position FROM lists_items WHERE list_id = \$1 AND item_id = \$2`).
+					WithArgs(2, after).WillReturnRows(sqlmock.NewRows([]string{"position"}).AddRow(1.0))
+				mock.ExpectQuery(`SELECT MIN\(position\) FROM lists_items`).
+					WithArgs(2, 1.0, 1).WillReturnRows(sqlmock.NewRows([]string{"min"}).AddRow(2.0))
+				mock.ExpectExec(`UPDATE lists_items SET list_id = \$1, position = \$2 WHERE item_id = \$3`).
+					WithArgs(2, 1.5, 1).WillReturnResult(sqlmock.NewResult(0, 1))
+				expectActivity(mock, 2, 3, "item", "move").WillReturnRows(activityRow(1))
+				mock.ExpectCommit()
+			},
+			want: 1.5,
+		},
+		{
+			name: "End of list",
+			mockBehavior: func() {
+				mock.ExpectBegin()
+				mock.ExpectQuery(`SELECT list_id, position FROM lists_items WHERE item_id = \$1 FOR UPDATE`).
+					WithArgs(1).WillReturnRows(sqlmock.NewRows([]string{"list_id", "position"}).AddRow(2, 3.0))
+				expectLockPositions(mock, 2)
+				mock.ExpectQuery(`SELECT MAX\(position\) FROM lists_items WHERE list_id = \$1 AND item_id <> \$2`).
+					WithArgs(2, 1).WillReturnRows(sqlmock.NewRows([]string{"max"}).AddRow(nil))
+				mock.ExpectExec(`UPDATE lists_items SET list_id = \$1, position = \$2 WHERE item_id = \$3`).
+					WithArgs(2, 1.0, 1).WillReturnResult(sqlmock.NewResult(0, 1))
+				expectActivity(mock, 2, 3, "item", "move").WillReturnRows(activityRow(1))
+				mock.ExpectCommit()
+			},
+			want: 1,
+		},
+		{
+			name:  "Renumber without gap",
+			input: todo.MoveItemInput{AfterId: &after},
+			mockBehavior: func() {
+				mock.ExpectBegin()
+				mock.ExpectQuery(`SELECT list_id, position FROM lists_items WHERE item_id = \$1 FOR UPDATE`).
+					WithArgs(1).WillReturnRows(sqlmock.NewRows([]string{"list_id", "position"}).AddRow(2, 3.0))
+				expectLockPositions(mock, 2)
+				mock.ExpectQuery(`SELECT position FROM lists_items WHERE list_id = \$1 AND item_id = \$2`).
+					WithArgs(2, after).WillReturnRows(sqlmock.NewRows([]string{"position"}).AddRow(1.0))
+				mock.ExpectQuery(`SELECT MIN\(position\) FROM lists_items`).
+					WithArgs(2, 1.0, 1).WillReturnRows(sqlmock.NewRows([]string{"min"}).AddRow(1.0000000000000002))
+				mock.ExpectExec(`UPDATE lists_items li SET position = r.n`).
+					WithArgs(2).WillReturnResult(sqlmock.NewResult(0, 3))
+				mock.ExpectQuery(`SELECT position FROM lists_items WHERE list_id = \$1 AND item_id = \$2`).
+					WithArgs(2, after).WillReturnRows(sqlmock.NewRows([]string{"position"}).AddRow(2.0))
+				mock.ExpectQuery(`SELECT MIN\(position\) FROM lists_items`).
+					WithArgs(2, 2.0, 1).WillReturnRows(sqlmock.NewRows([]string{"min"}).AddRow(3.0))
+				mock.ExpectExec(`UPDATE lists_items SET list_id = \$1, position = \$2 WHERE item_id = \$3`).
+					WithArgs(2, 2.5, 1).WillReturnResult(sqlmock.NewResult(0, 1))
+				expectActivity(mock, 2, 3, "item", "move").WillReturnRows(activityRow(1))
+				mock.ExpectCommit()
+			},
+			want: 2.5,
+		},
+		{
+			name:  "Neighbour in other list",
+			input: todo.MoveItemInput{AfterId: &after},
+			mockBehavior: func() {
+				mock.ExpectBegin()
+				mock.ExpectQuery(`SELECT list_id, position FROM lists_items WHERE item_id = \$1 FOR UPDATE`).
+					WithArgs(1).WillReturnRows(sqlmock.NewRows([]string{"list_id", "position"}).AddRow(2, 3.0))
+				expectLockPositions(mock, 2)
+				mock.ExpectQuery(`SELECT position FROM lists_items WHERE list_id = \$1 AND item_id = \$2`).
+					WithArgs(2, after).WillReturnError(sql.ErrNoRows)
+				mock.ExpectRollback()
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			testCase.mockBehavior()
+
+			got, err := r.Move(context.Background(), 3, 1, 2, testCase.input)
+
 			if testCase.wantErr {
 				assert.Error(t, err)
 			} else {
 				assert.NoError(t, err)
+				assert.Equal(t, testCase.want, got)
 			}
+			assert.NoError(t, mock.ExpectationsWereMet())
 		})
 	}
 }
@@ -0,0 +1,679 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	todo "do-app"
+	"errors"
+	"fmt"
+	"github.com/jmoiron/sqlx"
+	"strings"
+	"time"
+)
+
+type TodoItemSQLite struct {
+	db *sqlx.DB
+}
+
+func NewTodoItemSQLite(db *sqlx.DB) *TodoItemSQLite {
+	return &TodoItemSQLite{db: db}
+}
+
+func (r *TodoItemSQLite) Create(ctx context.Context, userId, listId int, item todo.TodoItem) (int, error) {
+	tx, err := beginTx(ctx, r.db)
+	if err != nil {
+		return 0, fmt.Errorf("Create item repository: %w", err)
+	}
+
+	// A new item only joins the series created for its own rule.
+	item.SeriesId = nil
+	if item.RRule != "" {
+		var seriesId int
+		createSeriesQuery := fmt.Sprintf("INSERT INTO %s (rrule, dtstart) values (?1, ?2) RETURNING id", itemSeriesTable)
+		err = tx.QueryRowContext(ctx, createSeriesQuery, item.RRule, sqliteNullTime(item.DueAt)).Scan(&seriesId)
+		if err != nil {
+			tx.Rollback()
+			return 0, fmt.Errorf("Create item repository: %w", err)
+		}
+		item.SeriesId = &seriesId
+	}
+
+	itemId, err := createItemSQLite(ctx, tx, userId, listId, item)
+	if err != nil {
+		tx.Rollback()
+		return 0, fmt.Errorf("Create item repository: %w", err)
+	}
+	return itemId, tx.Commit()
+}
+
+// createItemSQLite adds the item to the end of the list and records its
+// creation by the user.
+func createItemSQLite(ctx context.Context, tx *Tx, userId, listId int, item todo.TodoItem) (int, error) {
+	var itemId int
+	createItemQuery := fmt.Sprintf(`INSERT INTO %s (title, description, due_at, remind_at, series_id, priority, auto_complete)
+								 values (?1, ?2, ?3, ?4, ?5, ?6, ?7) RETURNING id`, todoItemsTable)
+	err := tx.QueryRowContext(ctx, createItemQuery, item.Title, item.Description, sqliteNullTime(item.DueAt),
+		sqliteNullTime(item.RemindAt), item.SeriesId, item.Priority, item.AutoComplete).Scan(&itemId)
+	if err != nil {
+		return 0, err
+	}
+
+	createListItemsQuery := fmt.Sprintf(`INSERT INTO %s (list_id, item_id, position)
+								 SELECT ?1, ?2, COALESCE(MAX(position), 0) + 1 FROM %s WHERE list_id = ?1`,
+		listsItemsTable, listsItemsTable)
+	if _, err = tx.ExecContext(ctx, createListItemsQuery, listId, itemId); err != nil {
+		return 0, err
+	}
+
+	_, created, err := itemSnapshotSQLite(ctx, tx, itemId)
+	if err != nil {
+		return 0, err
+	}
+	if _, err = recordItemChange(ctx, tx, userId, listId, itemId, todo.ActionCreate, nil, created); err != nil {
+		return 0, err
+	}
+	return itemId, nil
+}
+
+// itemSelectSQLite is itemSelect without lateral joins: the progress of the
+// subtasks is counted by correlated subqueries.
+var itemSelectSQLite = fmt.Sprintf(`SELECT ti.id, li.list_id, ti.title, ti.description, ti.done, ti.due_at, ti.remind_at,
+       							 ti.series_id, COALESCE(s.rrule, '') AS rrule, ti.priority, li.position, ti.auto_complete,
+       							 (SELECT count(*) FROM %s WHERE item_id = ti.id AND done) AS subtasks_done,
+       							 (SELECT count(*) FROM %s WHERE item_id = ti.id) AS subtasks_total, ti.version,
+       							 ti.created_at, ti.updated_at, ti.completed_at
+       							 FROM %s ti INNER JOIN %s li on li.item_id = ti.id INNER JOIN %s ul on ul.list_id = li.list_id
+       							 LEFT JOIN %s s on s.id = ti.series_id AND s.stopped_at IS NULL`,
+	subtasksTable, subtasksTable, todoItemsTable, listsItemsTable, usersListsTable, itemSeriesTable)
+
+func (r *TodoItemSQLite) GetAll(ctx context.Context, userId, listId int, filter todo.ItemFilter,
+	page todo.PageRequest) ([]todo.TodoItem, string, error) {
+	conditions, args := filterConditionsSQLite([]string{"li.list_id = ?1", "ul.user_id = ?2", "ti.deleted_at IS NULL"},
+		[]interface{}{listId, userId}, filter)
+
+	items, next, err := r.selectPage(ctx, conditions, args, page, todo.SortPosition)
+	if err != nil {
+		return nil, "", fmt.Errorf("GetAll item repository: %w", err)
+	}
+	return items, next, nil
+}
+
+func (r *TodoItemSQLite) GetAllByUser(ctx context.Context, userId int, filter todo.ItemFilter,
+	page todo.PageRequest) ([]todo.TodoItem, string, error) {
+	conditions, args := filterConditionsSQLite([]string{"ul.user_id = ?1", "ti.deleted_at IS NULL"}, []interface{}{userId}, filter)
+
+	items, next, err := r.selectPage(ctx, conditions, args, page, todo.SortDue)
+	if err != nil {
+		return nil, "", fmt.Errorf("GetAllByUser item repository: %w", err)
+	}
+	return items, next, nil
+}
+
+func (r *TodoItemSQLite) selectPage(ctx context.Context, conditions []string, args []interface{}, page todo.PageRequest,
+	defaultSort string) ([]todo.TodoItem, string, error) {
+	keys, err := newKeyset(page, defaultSort, itemSortKeys, "ti.id")
+	if err != nil {
+		return nil, "", err
+	}
+	conditions, args, err = keys.sqliteConditions(conditions, args)
+	if err != nil {
+		return nil, "", err
+	}
+
+	var items []todo.TodoItem
+	query := fmt.Sprintf("%s WHERE %s %s", itemSelectSQLite, strings.Join(conditions, " AND "), keys.orderBy())
+	if err = conn(ctx, r.db).SelectContext(ctx, &items, query, args...); err != nil {
+		return nil, "", err
+	}
+
+	n, next := keys.page(len(items), func(i int) (string, int) {
+		return itemSortValue(items[i], keys.name), items[i].Id
+	})
+	return items[:n], next, nil
+}
+
+// filterConditionsSQLite is filterConditions with SQLite placeholders and
+// times.
+func filterConditionsSQLite(conditions []string, args []interface{}, filter todo.ItemFilter) ([]string, []interface{}) {
+	if filter.DueBefore != nil {
+		args = append(args, sqliteTime(*filter.DueBefore))
+		conditions = append(conditions, fmt.Sprintf("ti.due_at < ?%d", len(args)))
+	}
+	if filter.Overdue {
+		args = append(args, sqliteTime(time.Now()))
+		conditions = append(conditions, fmt.Sprintf("ti.due_at < ?%d AND ti.done = false", len(args)))
+	}
+	if len(filter.Labels) > 0 {
+		placeholders := make([]string, 0, len(filter.Labels))
+		for _, name := range filter.Labels {
+			args = append(args, name)
+			placeholders = append(placeholders, fmt.Sprintf("?%d", len(args)))
+		}
+		labelQuery := fmt.Sprintf(`ti.id IN (SELECT il.item_id FROM %s il INNER JOIN %s l on l.id = il.label_id
+								 WHERE l.user_id = ul.user_id AND l.name IN (%s) GROUP BY il.item_id`,
+			itemsLabelsTable, labelsTable, strings.Join(placeholders, ", "))
+		if filter.AllLabels {
+			labelQuery += fmt.Sprintf(" HAVING count(*) = %d", len(filter.Labels))
+		}
+		conditions = append(conditions, labelQuery+")")
+	}
+	if filter.Done != nil {
+		args = append(args, *filter.Done)
+		conditions = append(conditions, fmt.Sprintf("ti.done = ?%d", len(args)))
+	}
+	if filter.Query != "" {
+		args = append(args, filter.Query)
+		conditions = append(conditions, fmt.Sprintf("instr(lower(ti.title), lower(?%d)) > 0", len(args)))
+	}
+	return conditions, args
+}
+
+func (r *TodoItemSQLite) GetById(ctx context.Context, userId, itemId int) (todo.TodoItem, error) {
+	var item todo.TodoItem
+	query := fmt.Sprintf("%s WHERE ti.id = ?1 AND ul.user_id = ?2 AND ti.deleted_at IS NULL", itemSelectSQLite)
+	if err := conn(ctx, r.db).GetContext(ctx, &item, query, itemId, userId); err != nil {
+		return item, fmt.Errorf("GetById item repository: %w", domainError(err, "item"))
+	}
+	return item, nil
+}
+
+// itemEditorSQLite is the condition of item updates that the user of the
+// placeholder can edit the list of the item. It takes the place of the joins
+// of the Postgres updates, which SQLite only allows in their FROM clause.
+const itemEditorSQLite = `EXISTS (SELECT 1 FROM %s li INNER JOIN %s ul on ul.list_id = li.list_id
+								 WHERE li.item_id = %s.id AND ul.user_id = ?%d AND ul.role IN (%s))`
+
+// Delete moves the item to the trash if it is still at version, or at any
+// version when version is 0, and returns the id of the activity record of the
+// deletion.
+func (r *TodoItemSQLite) Delete(ctx context.Context, userId, itemId, version int) (int, error) {
+	tx, err := beginTx(ctx, r.db)
+	if err != nil {
+		return 0, fmt.Errorf("Delete item repository: %w", err)
+	}
+
+	listId, before, err := itemSnapshotSQLite(ctx, tx, itemId)
+	if err != nil {
+		tx.Rollback()
+		return 0, fmt.Errorf("Delete item repository: %w", err)
+	}
+
+	query := fmt.Sprintf(`UPDATE %s SET deleted_at = ?1 WHERE id = ?2 AND (?3 = 0 OR version = ?3) AND deleted_at IS NULL
+								 AND `+itemEditorSQLite,
+		todoItemsTable, listsItemsTable, usersListsTable, todoItemsTable, 4, editorRoles)
+	res, err := tx.ExecContext(ctx, query, sqliteTime(time.Now()), itemId, version, userId)
+	if err != nil {
+		tx.Rollback()
+		return 0, fmt.Errorf("Delete item repository: %w", err)
+	}
+	if err = checkVersion(res, "item", version); err != nil {
+		tx.Rollback()
+		return 0, fmt.Errorf("Delete item repository: %w", err)
+	}
+
+	activityId, err := recordItemChange(ctx, tx, userId, listId, itemId, todo.ActionDelete, before, nil)
+	if err != nil {
+		tx.Rollback()
+		return 0, fmt.Errorf("Delete item repository: %w", err)
+	}
+	return activityId, tx.Commit()
+}
+
+// Update changes the item if it is still at version, or at any version when
+// version is 0, and returns the id of the activity record of the change.
+func (r *TodoItemSQLite) Update(ctx context.Context, userId, itemId int, input todo.UpdateItemInput, version int) (int, error) {
+	tx, err := beginTx(ctx, r.db)
+	if err != nil {
+		return 0, fmt.Errorf("Update item repository: %w", err)
+	}
+
+	listId, before, err := itemSnapshotSQLite(ctx, tx, itemId)
+	if err != nil {
+		tx.Rollback()
+		return 0, fmt.Errorf("Update item repository: %w", err)
+	}
+	if err = updateItemSQLite(ctx, tx, userId, itemId, input, version); err != nil {
+		tx.Rollback()
+		return 0, fmt.Errorf("Update item repository: %w", err)
+	}
+	_, after, err := itemSnapshotSQLite(ctx, tx, itemId)
+	if err != nil {
+		tx.Rollback()
+		return 0, fmt.Errorf("Update item repository: %w", err)
+	}
+
+	activityId, err := recordItemChange(ctx, tx, userId, listId, itemId, todo.ActionUpdate, before, after)
+	if err != nil {
+		tx.Rollback()
+		return 0, fmt.Errorf("Update item repository: %w", err)
+	}
+	return activityId, tx.Commit()
+}
+
+func updateItemSQLite(ctx context.Context, exec sqlx.ExecerContext, userId, itemId int,
+	input todo.UpdateItemInput, version int) error {
+	setValues := make([]string, 0)
+	args := make([]interface{}, 0)
+	argId := 1
+
+	if input.Title != nil {
+		setValues = append(setValues, fmt.Sprintf("title=?%d", argId))
+		args = append(args, *input.Title)
+		argId++
+	}
+	if input.Description != nil {
+		setValues = append(setValues, fmt.Sprintf("description=?%d", argId))
+		args = append(args, *input.Description)
+		argId++
+	}
+	if input.Done != nil {
+		// Completing an item that is already done keeps the time it was first
+		// completed at.
+		setValues = append(setValues, fmt.Sprintf(
+			"done=?%d, completed_at=CASE WHEN ?%d THEN COALESCE(completed_at, ?%d) END", argId, argId, argId+1))
+		args = append(args, *input.Done, sqliteTime(time.Now()))
+		argId += 2
+	}
+	if input.DueAt.Set {
+		setValues = append(setValues, fmt.Sprintf("due_at=?%d", argId))
+		args = append(args, sqliteNullTime(input.DueAt.Time))
+		argId++
+	}
+	if input.RemindAt.Set {
+		setValues = append(setValues, fmt.Sprintf("remind_at=?%d, reminder_sent_at=NULL, reminder_retry_at=NULL", argId))
+		args = append(args, sqliteNullTime(input.RemindAt.Time))
+		argId++
+	}
+	if input.Priority != nil {
+		setValues = append(setValues, fmt.Sprintf("priority=?%d", argId))
+		args = append(args, *input.Priority)
+		argId++
+	}
+	if input.AutoComplete != nil {
+		setValues = append(setValues, fmt.Sprintf("auto_complete=?%d", argId))
+		args = append(args, *input.AutoComplete)
+		argId++
+	}
+
+	setQuery := strings.Join(setValues, ", ")
+
+	query := fmt.Sprintf(`UPDATE %s SET %s WHERE id = ?%d AND (?%d = 0 OR version = ?%d) AND deleted_at IS NULL
+                    			AND `+itemEditorSQLite,
+		todoItemsTable, setQuery, argId, argId+1, argId+1,
+		listsItemsTable, usersListsTable, todoItemsTable, argId+2, editorRoles)
+	args = append(args, itemId, version, userId)
+
+	res, err := exec.ExecContext(ctx, query, args...)
+	if err != nil {
+		return err
+	}
+	return checkVersion(res, "item", version)
+}
+
+// Move puts the item into the list at the place described by input. Positions
+// are fractional, so a move is a single row write; only when the gap between
+// neighbours is exhausted the list is renumbered first.
+func (r *TodoItemSQLite) Move(ctx context.Context, userId, itemId, listId int, input todo.MoveItemInput) (float64, error) {
+	tx, err := beginTx(ctx, r.db)
+	if err != nil {
+		return 0, fmt.Errorf("Move item repository: %w", err)
+	}
+
+	var from struct {
+		ListId   int     `db:"list_id"`
+		Position float64 `db:"position"`
+	}
+	fromQuery := fmt.Sprintf("SELECT list_id, position FROM %s WHERE item_id = ?1", listsItemsTable)
+	if err = tx.GetContext(ctx, &from, fromQuery, itemId); err != nil {
+		tx.Rollback()
+		return 0, fmt.Errorf("Move item repository: %w", domainError(err, "item"))
+	}
+
+	position, err := movePositionSQLite(ctx, tx, itemId, listId, input)
+	if errors.Is(err, errNoGap) {
+		renumberQuery := fmt.Sprintf(`UPDATE %s SET position = r.n FROM
+                    			(SELECT id, row_number() OVER (ORDER BY position, item_id) AS n FROM %s WHERE list_id = ?1) r
+                    			WHERE %s.id = r.id`, listsItemsTable, listsItemsTable, listsItemsTable)
+		if _, err = tx.ExecContext(ctx, renumberQuery, listId); err == nil {
+			position, err = movePositionSQLite(ctx, tx, itemId, listId, input)
+		}
+	}
+	if err != nil {
+		tx.Rollback()
+		return 0, fmt.Errorf("Move item repository: %w", err)
+	}
+
+	query := fmt.Sprintf("UPDATE %s SET list_id = ?1, position = ?2 WHERE item_id = ?3", listsItemsTable)
+	if _, err = tx.ExecContext(ctx, query, listId, position, itemId); err != nil {
+		tx.Rollback()
+		return 0, fmt.Errorf("Move item repository: %w", err)
+	}
+
+	// The move is recorded in the activity of the list the item ends up in.
+	changes := todo.Changes{"position": newChange(from.Position, position)}
+	if from.ListId != listId {
+		changes["list_id"] = newChange(from.ListId, listId)
+	}
+	activity := todo.Activity{ListId: listId, ItemId: &itemId, UserId: userId, Entity: todo.ActivityItem,
+		Action: todo.ActionMove, Changes: changes}
+	if _, err = recordActivity(ctx, tx, activity); err != nil {
+		tx.Rollback()
+		return 0, fmt.Errorf("Move item repository: %w", err)
+	}
+	return position, tx.Commit()
+}
+
+func movePositionSQLite(ctx context.Context, tx *Tx, itemId, listId int, input todo.MoveItemInput) (float64, error) {
+	var neighbour sql.NullFloat64
+	switch {
+	case input.AfterId != nil:
+		anchor, err := itemPositionSQLite(ctx, tx, listId, *input.AfterId)
+		if err != nil {
+			return 0, err
+		}
+		query := fmt.Sprintf("SELECT MIN(position) FROM %s WHERE list_id = ?1 AND position > ?2 AND item_id <> ?3",
+			listsItemsTable)
+		if err = tx.GetContext(ctx, &neighbour, query, listId, anchor, itemId); err != nil {
+			return 0, err
+		}
+		if !neighbour.Valid {
+			return anchor + 1, nil
+		}
+		return between(anchor, neighbour.Float64)
+	case input.BeforeId != nil:
+		anchor, err := itemPositionSQLite(ctx, tx, listId, *input.BeforeId)
+		if err != nil {
+			return 0, err
+		}
+		query := fmt.Sprintf("SELECT MAX(position) FROM %s WHERE list_id = ?1 AND position < ?2 AND item_id <> ?3",
+			listsItemsTable)
+		if err = tx.GetContext(ctx, &neighbour, query, listId, anchor, itemId); err != nil {
+			return 0, err
+		}
+		if !neighbour.Valid {
+			return anchor - 1, nil
+		}
+		return between(neighbour.Float64, anchor)
+	default:
+		query := fmt.Sprintf("SELECT MAX(position) FROM %s WHERE list_id = ?1 AND item_id <> ?2", listsItemsTable)
+		if err := tx.GetContext(ctx, &neighbour, query, listId, itemId); err != nil {
+			return 0, err
+		}
+		return neighbour.Float64 + 1, nil
+	}
+}
+
+func itemPositionSQLite(ctx context.Context, tx *Tx, listId, itemId int) (float64, error) {
+	var position float64
+	query := fmt.Sprintf("SELECT position FROM %s WHERE list_id = ?1 AND item_id = ?2", listsItemsTable)
+	if err := tx.GetContext(ctx, &position, query, listId, itemId); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return 0, todo.NewError(todo.ErrValidation, "item %d is not in list %d", itemId, listId)
+		}
+		return 0, err
+	}
+	return position, nil
+}
+
+// CompleteOccurrence applies the update that marks a recurring item done and
+// creates its next occurrence, with an unchecked copy of its subtasks, in the
+// same transaction. The item has to be at version, or at any version when
+// version is 0. Only an item that was still open gets a next occurrence, so
+// concurrent requests generate a single one; its id is returned, or 0 if the
+// item had already been completed.
+func (r *TodoItemSQLite) CompleteOccurrence(ctx context.Context, userId, itemId int, input todo.UpdateItemInput,
+	next todo.TodoItem, version int) (int, error) {
+	tx, err := beginTx(ctx, r.db)
+	if err != nil {
+		return 0, fmt.Errorf("CompleteOccurrence item repository: %w", err)
+	}
+
+	listId, before, err := itemSnapshotSQLite(ctx, tx, itemId)
+	if err != nil {
+		tx.Rollback()
+		return 0, fmt.Errorf("CompleteOccurrence item repository: %w", err)
+	}
+	done, err := snapshotDone(before)
+	if err != nil {
+		tx.Rollback()
+		return 0, fmt.Errorf("CompleteOccurrence item repository: %w", err)
+	}
+
+	if err = updateItemSQLite(ctx, tx, userId, itemId, input, version); err != nil {
+		tx.Rollback()
+		return 0, fmt.Errorf("CompleteOccurrence item repository: %w", err)
+	}
+	_, after, err := itemSnapshotSQLite(ctx, tx, itemId)
+	if err != nil {
+		tx.Rollback()
+		return 0, fmt.Errorf("CompleteOccurrence item repository: %w", err)
+	}
+	if _, err = recordItemChange(ctx, tx, userId, listId, itemId, todo.ActionUpdate, before, after); err != nil {
+		tx.Rollback()
+		return 0, fmt.Errorf("CompleteOccurrence item repository: %w", err)
+	}
+
+	var nextId int
+	if !done {
+		nextId, err = createItemSQLite(ctx, tx, userId, next.ListId, next)
+		if err != nil {
+			tx.Rollback()
+			return 0, fmt.Errorf("CompleteOccurrence item repository: %w", err)
+		}
+		copySubtasksQuery := fmt.Sprintf("INSERT INTO %s (item_id, title) SELECT ?1, title FROM %s WHERE item_id = ?2 ORDER BY id",
+			subtasksTable, subtasksTable)
+		if _, err = tx.ExecContext(ctx, copySubtasksQuery, nextId, itemId); err != nil {
+			tx.Rollback()
+			return 0, fmt.Errorf("CompleteOccurrence item repository: %w", err)
+		}
+	}
+	return nextId, tx.Commit()
+}
+
+func (r *TodoItemSQLite) GetSeries(ctx context.Context, itemId int) (todo.ItemSeries, error) {
+	var series todo.ItemSeries
+	query := fmt.Sprintf(`SELECT s.id, s.rrule, s.dtstart, s.stopped_at FROM %s s
+								 INNER JOIN %s ti on ti.series_id = s.id WHERE ti.id = ?1`,
+		itemSeriesTable, todoItemsTable)
+	if err := conn(ctx, r.db).GetContext(ctx, &series, query, itemId); err != nil {
+		return series, fmt.Errorf("GetSeries item repository: %w", domainError(err, "series"))
+	}
+	return series, nil
+}
+
+// SetSeriesRule makes the item recurring if it is still at version, or at any
+// version when version is 0. An existing series of the item gets the new rule
+// and is resumed if it was stopped, otherwise a series starting at dtstart is
+// created.
+func (r *TodoItemSQLite) SetSeriesRule(ctx context.Context, userId, itemId int, rule string, dtstart time.Time,
+	version int) (int, error) {
+	tx, err := beginTx(ctx, r.db)
+	if err != nil {
+		return 0, fmt.Errorf("SetSeriesRule item repository: %w", err)
+	}
+
+	listId, before, err := itemSnapshotSQLite(ctx, tx, itemId)
+	if err != nil {
+		tx.Rollback()
+		return 0, fmt.Errorf("SetSeriesRule item repository: %w", err)
+	}
+
+	var seriesId int
+	updateQuery := fmt.Sprintf(`UPDATE %s SET rrule = ?1, stopped_at = NULL
+                    			WHERE id = (SELECT series_id FROM %s WHERE id = ?2) RETURNING id`,
+		itemSeriesTable, todoItemsTable)
+	err = tx.QueryRowContext(ctx, updateQuery, rule, itemId).Scan(&seriesId)
+	if errors.Is(err, sql.ErrNoRows) {
+		createQuery := fmt.Sprintf("INSERT INTO %s (rrule, dtstart) values (?1, ?2) RETURNING id", itemSeriesTable)
+		err = tx.QueryRowContext(ctx, createQuery, rule, sqliteTime(dtstart)).Scan(&seriesId)
+	}
+	if err != nil {
+		tx.Rollback()
+		return 0, fmt.Errorf("SetSeriesRule item repository: %w", err)
+	}
+
+	// The rule is part of the item, so linking the series always gives the
+	// item a new version.
+	linkQuery := fmt.Sprintf(`UPDATE %s SET series_id = ?1 WHERE id = ?2 AND (?3 = 0 OR version = ?3) AND deleted_at IS NULL
+                    			AND `+itemEditorSQLite,
+		todoItemsTable, listsItemsTable, usersListsTable, todoItemsTable, 4, editorRoles)
+	res, err := tx.ExecContext(ctx, linkQuery, seriesId, itemId, version, userId)
+	if err != nil {
+		tx.Rollback()
+		return 0, fmt.Errorf("SetSeriesRule item repository: %w", err)
+	}
+	if err = checkVersion(res, "item", version); err != nil {
+		tx.Rollback()
+		return 0, fmt.Errorf("SetSeriesRule item repository: %w", err)
+	}
+
+	_, after, err := itemSnapshotSQLite(ctx, tx, itemId)
+	if err != nil {
+		tx.Rollback()
+		return 0, fmt.Errorf("SetSeriesRule item repository: %w", err)
+	}
+	if _, err = recordItemChange(ctx, tx, userId, listId, itemId, todo.ActionUpdate, before, after); err != nil {
+		tx.Rollback()
+		return 0, fmt.Errorf("SetSeriesRule item repository: %w", err)
+	}
+	return seriesId, tx.Commit()
+}
+
+// lockSeriesSQLite locks the series if the user may edit one of its occurrences. A
+// series only shared by lists of other users is not found.
+func lockSeriesSQLite(ctx context.Context, tx *Tx, userId, seriesId int) error {
+	var id int
+	query := fmt.Sprintf(`SELECT s.id FROM %s s WHERE s.id = ?1 AND EXISTS (SELECT 1 FROM %s ti
+								 INNER JOIN %s li on li.item_id = ti.id INNER JOIN %s ul on ul.list_id = li.list_id
+								 WHERE ti.series_id = s.id AND ul.user_id = ?2 AND ul.role IN (%s) AND ti.deleted_at IS NULL)`,
+		itemSeriesTable, todoItemsTable, listsItemsTable, usersListsTable, editorRoles)
+	if err := tx.GetContext(ctx, &id, query, seriesId, userId); err != nil {
+		return domainError(err, "series")
+	}
+	return nil
+}
+
+// touchSeriesItemsSQLite gives every occurrence of the series a new version,
+// for changes of the series that change the rule clients see on its items.
+func touchSeriesItemsSQLite(ctx context.Context, tx *Tx, seriesId int) error {
+	query := fmt.Sprintf("UPDATE %s SET version = version WHERE series_id = ?1", todoItemsTable)
+	_, err := tx.ExecContext(ctx, query, seriesId)
+	return err
+}
+
+// UpdateSeries changes the rule of the series and applies title and
+// description to all of its open occurrences, recording the change of each.
+func (r *TodoItemSQLite) UpdateSeries(ctx context.Context, userId, seriesId int, input todo.UpdateSeriesInput) error {
+	tx, err := beginTx(ctx, r.db)
+	if err != nil {
+		return fmt.Errorf("UpdateSeries item repository: %w", err)
+	}
+
+	if err = lockSeriesSQLite(ctx, tx, userId, seriesId); err != nil {
+		tx.Rollback()
+		return fmt.Errorf("UpdateSeries item repository: %w", err)
+	}
+	before, err := seriesSnapshotSQLite(ctx, tx, userId, seriesId)
+	if err != nil {
+		tx.Rollback()
+		return fmt.Errorf("UpdateSeries item repository: %w", err)
+	}
+
+	if input.RRule != nil {
+		ruleQuery := fmt.Sprintf("UPDATE %s SET rrule = ?1 WHERE id = ?2", itemSeriesTable)
+		if _, err = tx.ExecContext(ctx, ruleQuery, *input.RRule, seriesId); err != nil {
+			tx.Rollback()
+			return fmt.Errorf("UpdateSeries item repository: %w", err)
+		}
+		if err = touchSeriesItemsSQLite(ctx, tx, seriesId); err != nil {
+			tx.Rollback()
+			return fmt.Errorf("UpdateSeries item repository: %w", err)
+		}
+	}
+
+	setValues := make([]string, 0)
+	args := make([]interface{}, 0)
+	argId := 1
+
+	if input.Title != nil {
+		setValues = append(setValues, fmt.Sprintf("title=?%d", argId))
+		args = append(args, *input.Title)
+		argId++
+	}
+	if input.Description != nil {
+		setValues = append(setValues, fmt.Sprintf("description=?%d", argId))
+		args = append(args, *input.Description)
+		argId++
+	}
+
+	if len(setValues) > 0 {
+		itemsQuery := fmt.Sprintf(`UPDATE %s SET %s WHERE series_id = ?%d AND done = false AND deleted_at IS NULL
+								 AND id IN (SELECT li.item_id FROM %s li INNER JOIN %s ul on ul.list_id = li.list_id
+								 WHERE ul.user_id = ?%d AND ul.role IN (%s))`,
+			todoItemsTable, strings.Join(setValues, ", "), argId, listsItemsTable, usersListsTable, argId+1, editorRoles)
+		args = append(args, seriesId, userId)
+		if _, err = tx.ExecContext(ctx, itemsQuery, args...); err != nil {
+			tx.Rollback()
+			return fmt.Errorf("UpdateSeries item repository: %w", err)
+		}
+	}
+
+	after, err := seriesSnapshotSQLite(ctx, tx, userId, seriesId)
+	if err != nil {
+		tx.Rollback()
+		return fmt.Errorf("UpdateSeries item repository: %w", err)
+	}
+	if err = recordSeriesChange(ctx, tx, userId, before, after); err != nil {
+		tx.Rollback()
+		return fmt.Errorf("UpdateSeries item repository: %w", err)
+	}
+	return tx.Commit()
+}
+
+// StopSeries ends the series, recording the change of its open occurrences,
+// which are left as they are but no longer recur.
+func (r *TodoItemSQLite) StopSeries(ctx context.Context, userId, seriesId int) error {
+	tx, err := beginTx(ctx, r.db)
+	if err != nil {
+		return fmt.Errorf("StopSeries item repository: %w", err)
+	}
+
+	if err = lockSeriesSQLite(ctx, tx, userId, seriesId); err != nil {
+		tx.Rollback()
+		return fmt.Errorf("StopSeries item repository: %w", err)
+	}
+	before, err := seriesSnapshotSQLite(ctx, tx, userId, seriesId)
+	if err != nil {
+		tx.Rollback()
+		return fmt.Errorf("StopSeries item repository: %w", err)
+	}
+
+	query := fmt.Sprintf("UPDATE %s SET stopped_at = ?1 WHERE id = ?2 AND stopped_at IS NULL", itemSeriesTable)
+	res, err := tx.ExecContext(ctx, query, sqliteTime(time.Now()), seriesId)
+	if err != nil {
+		tx.Rollback()
+		return fmt.Errorf("StopSeries item repository: %w", err)
+	}
+	stopped, err := res.RowsAffected()
+	if err != nil {
+		tx.Rollback()
+		return fmt.Errorf("StopSeries item repository: %w", err)
+	}
+	if stopped > 0 {
+		if err = touchSeriesItemsSQLite(ctx, tx, seriesId); err != nil {
+			tx.Rollback()
+			return fmt.Errorf("StopSeries item repository: %w", err)
+		}
+	}
+
+	after, err := seriesSnapshotSQLite(ctx, tx, userId, seriesId)
+	if err != nil {
+		tx.Rollback()
+		return fmt.Errorf("StopSeries item repository: %w", err)
+	}
+	if err = recordSeriesChange(ctx, tx, userId, before, after); err != nil {
+		tx.Rollback()
+		return fmt.Errorf("StopSeries item repository: %w", err)
+	}
+	return tx.Commit()
+}
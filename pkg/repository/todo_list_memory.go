@@ -0,0 +1,172 @@
+package repository
+
+import (
+	"context"
+	todo "do-app"
+	"fmt"
+	"strings"
+	"time"
+)
+
+type TodoListMemory struct {
+	store *memoryStore
+}
+
+func NewTodoListMemory(store *memoryStore) *TodoListMemory {
+	return &TodoListMemory{store: store}
+}
+
+// snapshot returns the columns of the list that activity records track.
+func (l memoryList) snapshot() []byte {
+	return marshalColumns(map[string]interface{}{
+		"id":          l.Id,
+		"title":       l.Title,
+		"description": l.Description,
+	})
+}
+
+func (r *TodoListMemory) Create(ctx context.Context, userId int, list todo.TodoList) (int, error) {
+	var id int
+	err := r.store.write(ctx, func(d *memoryData) error {
+		if _, ok := d.users[userId]; !ok {
+			return todo.NewError(todo.ErrConflict, "list refers to a missing entity")
+		}
+		now := time.Now()
+		id = d.nextId(todoListsTable)
+		created := memoryList{TodoList: todo.TodoList{Id: id, Title: list.Title, Description: list.Description,
+			Version: 1, CreatedAt: now, UpdatedAt: now}}
+		put(d, d.lists, id, created)
+
+		memberId := d.nextId(usersListsTable)
+		put(d, d.members, memberId, todo.UserList{Id: memberId, UserId: userId, ListId: id, Role: todo.RoleOwner})
+
+		_, err := recordMemoryChange(d, userId, id, 0, todo.ActionCreate, nil, created.snapshot())
+		return err
+	})
+	if err != nil {
+		return 0, fmt.Errorf("create list memory: %w", err)
+	}
+	return id, nil
+}
+
+func (r *TodoListMemory) GetAll(ctx context.Context, userId int, filter todo.ListFilter, page todo.PageRequest) ([]todo.TodoList, string, error) {
+	keys, err := newKeyset(page, todo.SortCreated, listSortKeys, "tl.id")
+	if err != nil {
+		return nil, "", fmt.Errorf("Get All lists repository: %w", err)
+	}
+
+	var lists []todo.TodoList
+	var next string
+	err = r.store.read(ctx, func(d *memoryData) error {
+		var all []todo.TodoList
+		for _, m := range d.members {
+			list := d.lists[m.ListId]
+			if m.UserId != userId || list.DeletedAt != nil {
+				continue
+			}
+			if filter.Query != "" && !strings.Contains(strings.ToLower(list.Title), strings.ToLower(filter.Query)) {
+				continue
+			}
+			all = append(all, list.TodoList)
+		}
+
+		rows, cursor, err := memoryPage(keys, len(all), func(i int) (string, int) {
+			return listSortValue(all[i], keys.name), all[i].Id
+		})
+		for _, i := range rows {
+			lists = append(lists, all[i])
+		}
+		next = cursor
+		return err
+	})
+	if err != nil {
+		return nil, "", fmt.Errorf("Get All lists repository: %w", err)
+	}
+	return lists, next, nil
+}
+
+func (r *TodoListMemory) GetById(ctx context.Context, userId, listId int) (todo.TodoList, error) {
+	var list todo.TodoList
+	err := r.store.read(ctx, func(d *memoryData) error {
+		l, ok := d.lists[listId]
+		if _, member := d.member(userId, listId); !ok || !member || l.DeletedAt != nil {
+			return todo.NewError(todo.ErrNotFound, "list not found")
+		}
+		list = l.TodoList
+		return nil
+	})
+	if err != nil {
+		return list, fmt.Errorf("GetById list repository: %w", err)
+	}
+	return list, nil
+}
+
+// Delete moves the list and its items to the trash if it is still at version,
+// or at any version when version is 0, and returns the id of the activity
+// record of the deletion.
+func (r *TodoListMemory) Delete(ctx context.Context, userId, listId, version int) (int, error) {
+	var activityId int
+	err := r.store.write(ctx, func(d *memoryData) error {
+		list, ok := d.lists[listId]
+		if !ok || list.DeletedAt != nil {
+			return todo.NewError(todo.ErrNotFound, "list not found")
+		}
+		if d.role(userId, listId) != todo.RoleOwner || (version != 0 && list.Version != version) {
+			return errVersion("list", version)
+		}
+
+		before := list.snapshot()
+		now := time.Now()
+		list.DeletedAt = &now
+		touchList(&list)
+		put(d, d.lists, listId, list)
+		for id, item := range d.items {
+			if item.ListId == listId && item.DeletedAt == nil {
+				item.DeletedAt = &now
+				touchItem(&item)
+				put(d, d.items, id, item)
+			}
+		}
+
+		var err error
+		activityId, err = recordMemoryChange(d, userId, listId, 0, todo.ActionDelete, before, nil)
+		return err
+	})
+	if err != nil {
+		return 0, fmt.Errorf("Delete list repository: %w", err)
+	}
+	return activityId, nil
+}
+
+// Update changes the list if it is still at version, or at any version when
+// version is 0, and returns the id of the activity record of the change.
+func (r *TodoListMemory) Update(ctx context.Context, userId, listId int, input todo.UpdateListInput, version int) (int, error) {
+	var activityId int
+	err := r.store.write(ctx, func(d *memoryData) error {
+		list, ok := d.lists[listId]
+		if !ok || list.DeletedAt != nil {
+			return todo.NewError(todo.ErrNotFound, "list not found")
+		}
+		if !todo.CanEdit(d.role(userId, listId)) || (version != 0 && list.Version != version) {
+			return errVersion("list", version)
+		}
+
+		before := list.snapshot()
+		if input.Title != nil {
+			list.Title = *input.Title
+		}
+		if input.Description != nil {
+			list.Description = *input.Description
+		}
+		touchList(&list)
+		put(d, d.lists, listId, list)
+
+		var err error
+		activityId, err = recordMemoryChange(d, userId, listId, 0, todo.ActionUpdate, before, list.snapshot())
+		return err
+	})
+	if err != nil {
+		return 0, fmt.Errorf("Update list repository: %w", err)
+	}
+	return activityId, nil
+}
@@ -1,11 +1,14 @@
 package repository
 
 import (
+	"context"
 	todo "do-app"
 	"fmt"
 	"github.com/jmoiron/sqlx"
 	"github.com/sirupsen/logrus"
+	"strconv"
 	"strings"
+	"time"
 )
 
 type TodoListPostgres struct {
@@ -16,68 +19,147 @@ func NewTodoListPostgres(db *sqlx.DB) *TodoListPostgres {
 	return &TodoListPostgres{db: db}
 }
 
-func (r *TodoListPostgres) Create(userId int, list todo.TodoList) (int, error) {
-	tx, err := r.db.Begin()
+func (r *TodoListPostgres) Create(ctx context.Context, userId int, list todo.TodoList) (int, error) {
+	tx, err := beginTx(ctx, r.db)
 	if err != nil {
 		return 0, fmt.Errorf("create list postgres: %w", err)
 	}
 
-	var id int
-	createListQuery := fmt.Sprintf("INSERT INTO %s (title, description) VALUES ($1, $2) RETURNING id", todoListsTable)
-	row := tx.QueryRow(createListQuery, list.Title, list.Description)
-	if err := row.Scan(&id); err != nil {
+	var created struct {
+		Id   int    `db:"id"`
+		List []byte `db:"list"`
+	}
+	createListQuery := fmt.Sprintf("INSERT INTO %s AS tl (title, description) VALUES ($1, $2) RETURNING tl.id, to_jsonb(tl) AS list",
+		todoListsTable)
+	if err = tx.GetContext(ctx, &created, createListQuery, list.Title, list.Description); err != nil {
 		tx.Rollback()
 		return 0, fmt.Errorf("create list postgres: %w", err)
 	}
+	id := created.Id
 
-	createUsersListQuery := fmt.Sprintf("INSERT INTO %s (user_id, list_id) VALUES ($1, $2)", usersListsTable)
-	_, err = tx.Exec(createUsersListQuery, userId, id)
+	createUsersListQuery := fmt.Sprintf("INSERT INTO %s (user_id, list_id, role) VALUES ($1, $2, '%s')",
+		usersListsTable, todo.RoleOwner)
+	_, err = tx.ExecContext(ctx, createUsersListQuery, userId, id)
 	if err != nil {
 		tx.Rollback()
 		return 0, fmt.Errorf("create list postgres: %w", err)
 	}
 
+	if _, err = recordListChange(ctx, tx, userId, id, todo.ActionCreate, nil, created.List); err != nil {
+		tx.Rollback()
+		return 0, fmt.Errorf("create list postgres: %w", err)
+	}
 	return id, tx.Commit()
 }
 
-func (r *TodoListPostgres) GetAll(userId int) ([]todo.TodoList, error) {
+var listSortKeys = map[string]sortKey{
+	todo.SortTitle:   {expr: "tl.title", cast: "text"},
+	todo.SortCreated: {expr: "tl.id", cast: "int"},
+	todo.SortUpdated: {expr: "tl.updated_at", cast: "timestamptz"},
+}
+
+func (r *TodoListPostgres) GetAll(ctx context.Context, userId int, filter todo.ListFilter, page todo.PageRequest) ([]todo.TodoList, string, error) {
+	keys, err := newKeyset(page, todo.SortCreated, listSortKeys, "tl.id")
+	if err != nil {
+		return nil, "", fmt.Errorf("Get All lists repository: %w", err)
+	}
+
+	conditions := []string{"ul.user_id = $1", "tl.deleted_at IS NULL"}
+	args := []interface{}{userId}
+	if filter.Query != "" {
+		args = append(args, filter.Query)
+		conditions = append(conditions, fmt.Sprintf("strpos(lower(tl.title), lower($%d)) > 0", len(args)))
+	}
+	conditions, args = keys.conditions(conditions, args)
+
 	var lists []todo.TodoList
-	query := fmt.Sprintf("SELECT tl.id, tl.title, tl.description FROM %s tl INNER JOIN"+
-		" %s ul on tl.id = ul.list_id WHERE ul.user_id = $1", todoListsTable, usersListsTable)
-	err := r.db.Select(&lists, query, userId)
+	query := fmt.Sprintf("SELECT tl.id, tl.title, tl.description, tl.version, tl.created_at, tl.updated_at FROM %s tl INNER JOIN"+
+		" %s ul on tl.id = ul.list_id WHERE %s %s",
+		todoListsTable, usersListsTable, strings.Join(conditions, " AND "), keys.orderBy())
+	err = conn(ctx, r.db).SelectContext(ctx, &lists, query, args...)
 	if err != nil {
-		return nil, fmt.Errorf("Get All lists repository: %w", err)
+		return nil, "", fmt.Errorf("Get All lists repository: %w", err)
 	}
 
-	return lists, nil
+	n, next := keys.page(len(lists), func(i int) (string, int) {
+		return listSortValue(lists[i], keys.name), lists[i].Id
+	})
+	return lists[:n], next, nil
 }
 
-func (r *TodoListPostgres) GetById(userId, listId int) (todo.TodoList, error) {
+func listSortValue(list todo.TodoList, sort string) string {
+	switch sort {
+	case todo.SortTitle:
+		return list.Title
+	case todo.SortUpdated:
+		return list.UpdatedAt.Format(time.RFC3339Nano)
+	default:
+		return strconv.Itoa(list.Id)
+	}
+}
+
+func (r *TodoListPostgres) GetById(ctx context.Context, userId, listId int) (todo.TodoList, error) {
 	var list todo.TodoList
 
-	query := fmt.Sprintf(`SELECT tl.id, tl.title, tl.description FROM %s tl INNER JOIN 
-                                 %s ul on tl.id = ul.list_id WHERE ul.user_id = $1 AND ul.list_id = $2`,
+	query := fmt.Sprintf(`SELECT tl.id, tl.title, tl.description, tl.version, tl.created_at, tl.updated_at FROM %s tl INNER JOIN 
+                                 %s ul on tl.id = ul.list_id WHERE ul.user_id = $1 AND ul.list_id = $2 AND tl.deleted_at IS NULL`,
 		todoListsTable, usersListsTable)
-	err := r.db.Get(&list, query, userId, listId)
+	err := conn(ctx, r.db).GetContext(ctx, &list, query, userId, listId)
 	if err != nil {
-		return list, fmt.Errorf("GetById list repository: %w", err)
+		return list, fmt.Errorf("GetById list repository: %w", domainError(err, "list"))
 	}
 
 	return list, nil
 }
 
-func (r *TodoListPostgres) Delete(userId, listId int) error {
-	query := fmt.Sprintf("DELETE FROM %s tl USING %s ul WHERE tl.id = ul.list_id AND ul.user_id=$1 AND ul.list_id=$2",
-		todoListsTable, usersListsTable)
-	_, err := r.db.Exec(query, userId, listId)
+// Delete moves the list to the trash if it is still at version, or at any
+// version when version is 0. Its items are trashed at the same time, so that
+// restoring the list brings back exactly the items deleted with it. The id of
+// the activity record of the deletion is returned.
+func (r *TodoListPostgres) Delete(ctx context.Context, userId, listId, version int) (int, error) {
+	tx, err := beginTx(ctx, r.db)
+	if err != nil {
+		return 0, fmt.Errorf("Delete list repository: %w", err)
+	}
+
+	before, err := listSnapshot(ctx, tx, listId)
 	if err != nil {
-		return fmt.Errorf("Delete list repository: %w", err)
+		tx.Rollback()
+		return 0, fmt.Errorf("Delete list repository: %w", err)
+	}
+
+	query := fmt.Sprintf(`UPDATE %s tl SET deleted_at = now() FROM %s ul WHERE tl.id = ul.list_id AND ul.user_id=$1
+								 AND ul.list_id=$2 AND ul.role = '%s' AND ($3 = 0 OR tl.version = $3) AND tl.deleted_at IS NULL`,
+		todoListsTable, usersListsTable, todo.RoleOwner)
+	res, err := tx.ExecContext(ctx, query, userId, listId, version)
+	if err != nil {
+		tx.Rollback()
+		return 0, fmt.Errorf("Delete list repository: %w", err)
+	}
+	if err = checkVersion(res, "list", version); err != nil {
+		tx.Rollback()
+		return 0, fmt.Errorf("Delete list repository: %w", err)
+	}
+
+	itemsQuery := fmt.Sprintf(`UPDATE %s ti SET deleted_at = now() FROM %s li
+								 WHERE ti.id = li.item_id AND li.list_id = $1 AND ti.deleted_at IS NULL`,
+		todoItemsTable, listsItemsTable)
+	if _, err = tx.ExecContext(ctx, itemsQuery, listId); err != nil {
+		tx.Rollback()
+		return 0, fmt.Errorf("Delete list repository: %w", err)
 	}
 
-	return nil
+	activityId, err := recordListChange(ctx, tx, userId, listId, todo.ActionDelete, before, nil)
+	if err != nil {
+		tx.Rollback()
+		return 0, fmt.Errorf("Delete list repository: %w", err)
+	}
+	return activityId, tx.Commit()
 }
 
-func (r *TodoListPostgres) Update(userId, listId int, input todo.UpdateListInput) error {
+// Update changes the list if it is still at version, or at any version when
+// version is 0, and returns the id of the activity record of the change.
+func (r *TodoListPostgres) Update(ctx context.Context, userId, listId int, input todo.UpdateListInput, version int) (int, error) {
 	setValues := make([]string, 0)
 	args := make([]interface{}, 0)
 	argId := 1
@@ -95,16 +177,43 @@ func (r *TodoListPostgres) Update(userId, listId int, input todo.UpdateListInput
 
 	setQuery := strings.Join(setValues, ", ")
 
-	query := fmt.Sprintf("UPDATE %s tl SET %s FROM %s ul WHERE tl.id = ul.list_id AND ul.list_id=$%d AND ul.user_id=$%d", todoListsTable, setQuery, usersListsTable, argId, argId+1)
-	args = append(args, listId, userId)
+	query := fmt.Sprintf("UPDATE %s tl SET %s FROM %s ul WHERE tl.id = ul.list_id AND ul.list_id=$%d AND ul.user_id=$%d AND ul.role IN (%s)"+
+		" AND ($%d = 0 OR tl.version = $%d) AND tl.deleted_at IS NULL",
+		todoListsTable, setQuery, usersListsTable, argId, argId+1, editorRoles, argId+2, argId+2)
+	args = append(args, listId, userId, version)
 
 	logrus.Debugf("updateQuery: %s", query)
 	logrus.Debugf("updateArgs: %s", args)
 
-	_, err := r.db.Exec(query, args...)
+	tx, err := beginTx(ctx, r.db)
 	if err != nil {
-		return fmt.Errorf("Update list repository: %w", err)
+		return 0, fmt.Errorf("Update list repository: %w", err)
 	}
 
-	return nil
+	before, err := listSnapshot(ctx, tx, listId)
+	if err != nil {
+		tx.Rollback()
+		return 0, fmt.Errorf("Update list repository: %w", err)
+	}
+	res, err := tx.ExecContext(ctx, query, args...)
+	if err != nil {
+		tx.Rollback()
+		return 0, fmt.Errorf("Update list repository: %w", err)
+	}
+	if err = checkVersion(res, "list", version); err != nil {
+		tx.Rollback()
+		return 0, fmt.Errorf("Update list repository: %w", err)
+	}
+	after, err := listSnapshot(ctx, tx, listId)
+	if err != nil {
+		tx.Rollback()
+		return 0, fmt.Errorf("Update list repository: %w", err)
+	}
+
+	activityId, err := recordListChange(ctx, tx, userId, listId, todo.ActionUpdate, before, after)
+	if err != nil {
+		tx.Rollback()
+		return 0, fmt.Errorf("Update list repository: %w", err)
+	}
+	return activityId, tx.Commit()
 }
@@ -1,6 +1,8 @@
 package repository
 
 import (
+	"context"
+	"database/sql"
 	todo "do-app"
 	"fmt"
 	"github.com/stretchr/testify/assert"
@@ -45,13 +47,15 @@ func TestTodoListPostgres_Create(t *testing.T) {
 			mockBehavior: func(args args, listId int) {
 				mock.ExpectBegin()
 
-				row := sqlmock.NewRows([]string{"id"}).AddRow(listId)
-				mock.ExpectQuery(`INSERT INTO todo_lists`).
+				row := sqlmock.NewRows([]string{"id", "list"}).AddRow(listId, `{"id": 1, "title": "test title"}`)
+				mock.ExpectQuery(`INSERT INTO todo_lists AS tl (.+) RETURNING tl.id, to_jsonb\(tl\) AS list`).
 					WithArgs(args.list.Title, args.list.Description).WillReturnRows(row)
 
 				mock.ExpectExec(`INSERT INTO users_lists`).
 					WithArgs(args.userId, listId).WillReturnResult(sqlmock.NewResult(1, 1))
 
+				expectActivity(mock, listId, args.userId, "list", "create").WillReturnRows(activityRow(1))
+
 				mock.ExpectCommit()
 			},
 		},
@@ -118,7 +122,7 @@ func TestTodoListPostgres_Create(t *testing.T) {
 
 			testCase.mockBehavior(testCase.args, testCase.listId)
 
-			got, err := r.Create(testCase.args.userId, testCase.args.list)
+			got, err := r.Create(context.Background(), testCase.args.userId, testCase.args.list)
 			if testCase.wantErr {
 				assert.Error(t, err)
 			} else {
@@ -140,6 +144,8 @@ func TestTodoListPostgres_GetAll(t *testing.T) {
 
 	type args struct {
 		userId int
+		filter todo.ListFilter
+		page   todo.PageRequest
 	}
 
 	type mockBehavior func(args args, lists []todo.TodoList)
@@ -147,6 +153,7 @@ func TestTodoListPostgres_GetAll(t *testing.T) {
 	testTable := []struct {
 		name         string
 		lists        []todo.TodoList
+		next         string
 		args         args
 		mockBehavior mockBehavior
 		wantErr      assert.ErrorAssertionFunc
@@ -176,11 +183,50 @@ func TestTodoListPostgres_GetAll(t *testing.T) {
 					AddRow(lists[1].Id, lists[1].Title, lists[1].Description).
 					AddRow(lists[2].Id, lists[2].Title, lists[2].Description)
 
-				mock.ExpectQuery(`SELECT tl.id, tl.title, tl.description FROM todo_lists tl`).
+				mock.ExpectQuery(`SELECT tl.id, tl.title, tl.description, tl.version, tl.created_at, tl.updated_at FROM todo_lists tl`).
 					WithArgs(args.userId).WillReturnRows(row)
 			},
 			wantErr: assert.NoError,
 		},
+		{
+			name: "Next page",
+			lists: []todo.TodoList{
+				{Id: 3, Title: "groceries"},
+			},
+			next: encodeCursor(cursor{Sort: "title", Value: "groceries", Id: 3}),
+			args: args{
+				userId: 1,
+				filter: todo.ListFilter{Query: "gro"},
+				page: todo.PageRequest{
+					Limit:  1,
+					Cursor: encodeCursor(cursor{Sort: "title", Value: "errands", Id: 5}),
+					Sort:   "title",
+				},
+			},
+			mockBehavior: func(args args, lists []todo.TodoList) {
+
+				row := sqlmock.NewRows([]string{"id", "title", "description"}).
+					AddRow(3, "groceries", "").
+					AddRow(1, "grocery store", "")
+
+				mock.ExpectQuery(regexp.QuoteMeta(`WHERE ul.user_id = $1 AND tl.deleted_at IS NULL AND strpos(lower(tl.title), lower($2)) > 0 `+
+					`AND (tl.title, tl.id) > ($3::text, $4) ORDER BY tl.title ASC, tl.id ASC LIMIT 2`)).
+					WithArgs(args.userId, "gro", "errands", 5).WillReturnRows(row)
+			},
+			wantErr: assert.NoError,
+		},
+		{
+			name: "Cursor of other sort",
+			args: args{
+				userId: 1,
+				page: todo.PageRequest{
+					Cursor: encodeCursor(cursor{Sort: "title", Value: "errands", Id: 5}),
+					Sort:   "-title",
+				},
+			},
+			mockBehavior: func(args args, lists []todo.TodoList) {},
+			wantErr:      assert.Error,
+		},
 		{
 			name: "Error",
 			args: args{
@@ -188,7 +234,7 @@ func TestTodoListPostgres_GetAll(t *testing.T) {
 			},
 			mockBehavior: func(args args, lists []todo.TodoList) {
 
-				mock.ExpectQuery(`SELECT tl.id, tl.title, tl.description FROM todo_lists tl`).
+				mock.ExpectQuery(`SELECT tl.id, tl.title, tl.description, tl.version, tl.created_at, tl.updated_at FROM todo_lists tl`).
 					WithArgs(args.userId).WillReturnError(assert.AnError)
 			},
 			wantErr: assert.Error,
@@ -199,10 +245,11 @@ func TestTodoListPostgres_GetAll(t *testing.T) {
 		t.Run(testCase.name, func(t *testing.T) {
 			testCase.mockBehavior(testCase.args, testCase.lists)
 
-			got, err := r.GetAll(testCase.args.userId)
+			got, next, err := r.GetAll(context.Background(), testCase.args.userId, testCase.args.filter, testCase.args.page)
 
 			testCase.wantErr(t, err)
 			assert.Equal(t, testCase.lists, got)
+			assert.Equal(t, testCase.next, next)
 		})
 	}
 }
@@ -236,6 +283,7 @@ func TestTodoListPostgres_GetById(t *testing.T) {
 				Title:       "test",
 				Id:          2,
 				Description: "test desc",
+				Version:     3,
 			},
 			args: args{
 				userId: 1,
@@ -243,10 +291,10 @@ func TestTodoListPostgres_GetById(t *testing.T) {
 			},
 			mockBehavior: func(args args, list todo.TodoList) {
 
-				row := sqlmock.NewRows([]string{"id", "title", "description"}).
-					AddRow(list.Id, list.Title, list.Description)
+				row := sqlmock.NewRows([]string{"id", "title", "description", "version"}).
+					AddRow(list.Id, list.Title, list.Description, list.Version)
 
-				mock.ExpectQuery(`SELECT tl.id, tl.title, tl.description FROM todo_lists tl`).
+				mock.ExpectQuery(`SELECT tl.id, tl.title, tl.description, tl.version, tl.created_at, tl.updated_at FROM todo_lists tl`).
 					WithArgs(args.userId, args.listId).WillReturnRows(row)
 
 			},
@@ -260,12 +308,28 @@ func TestTodoListPostgres_GetById(t *testing.T) {
 			},
 			mockBehavior: func(args args, list todo.TodoList) {
 
-				mock.ExpectQuery(`SELECT tl.id, tl.title, tl.description FROM todo_lists tl`).
+				mock.ExpectQuery(`SELECT tl.id, tl.title, tl.description, tl.version, tl.created_at, tl.updated_at FROM todo_lists tl`).
 					WithArgs(args.userId, args.listId).WillReturnError(assert.AnError)
 
 			},
 			wantErr: assert.Error,
 		},
+		{
+			name: "Not Found",
+			args: args{
+				userId: 1,
+				listId: 0,
+			},
+			mockBehavior: func(args args, list todo.TodoList) {
+
+				mock.ExpectQuery(`SELECT tl.id, tl.title, tl.description, tl.version, tl.created_at, tl.updated_at FROM todo_lists tl`).
+					WithArgs(args.userId, args.listId).WillReturnError(sql.ErrNoRows)
+
+			},
+			wantErr: func(t assert.TestingT, err error, _ ...interface{}) bool {
+				return assert.ErrorIs(t, err, todo.ErrNotFound)
+			},
+		},
 	}
 
 	for _, testCase := range testTable {
@@ -273,7 +337,7 @@ func TestTodoListPostgres_GetById(t *testing.T) {
 
 			testCase.mockBehavior(testCase.args, testCase.list)
 
-			got, err := r.GetById(testCase.args.userId, testCase.list.Id)
+			got, err := r.GetById(context.Background(), testCase.args.userId, testCase.list.Id)
 
 			assert.Equal(t, testCase.list, got)
 			testCase.wantErr(t, err)
@@ -291,8 +355,9 @@ func TestTodoListPostgres_Delete(t *testing.T) {
 	r := NewTodoListPostgres(db)
 
 	type args struct {
-		userId int
-		listId int
+		userId  int
+		listId  int
+		version int
 	}
 
 	type mockBehavior func(args args)
@@ -310,10 +375,14 @@ func TestTodoListPostgres_Delete(t *testing.T) {
 				listId: 1,
 			},
 			mockBehavior: func(args args) {
-
-				mock.ExpectExec(`DELETE FROM todo_lists`).
-					WithArgs(args.userId, args.listId).WillReturnResult(sqlmock.NewResult(1, 0))
-
+				mock.ExpectBegin()
+				expectListSnapshot(mock, args.listId, `{"title": "groceries"}`)
+				mock.ExpectExec(`UPDATE todo_lists tl SET deleted_at = now\(\) FROM users_lists ul`).
+					WithArgs(args.userId, args.listId, args.version).WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectExec(`UPDATE todo_items ti SET deleted_at = now\(\) FROM lists_items li`).
+					WithArgs(args.listId).WillReturnResult(sqlmock.NewResult(0, 3))
+				expectActivity(mock, args.listId, args.userId, "list", "delete").WillReturnRows(activityRow(1))
+				mock.ExpectCommit()
 			},
 			wantErr: assert.NoError,
 		},
@@ -324,13 +393,31 @@ func TestTodoListPostgres_Delete(t *testing.T) {
 				listId: 1,
 			},
 			mockBehavior: func(args args) {
-
-				mock.ExpectExec(`DELETE FROM todo_lists`).
-					WithArgs(args.userId, args.listId).WillReturnError(assert.AnError)
-
+				mock.ExpectBegin()
+				expectListSnapshot(mock, args.listId, `{"title": "groceries"}`)
+				mock.ExpectExec(`UPDATE todo_lists tl SET deleted_at = now\(\) FROM users_lists ul`).
+					WithArgs(args.userId, args.listId, args.version).WillReturnError(assert.AnError)
+				mock.ExpectRollback()
 			},
 			wantErr: assert.Error,
 		},
+		{
+			name: "Already Deleted",
+			args: args{
+				userId: 1,
+				listId: 1,
+			},
+			mockBehavior: func(args args) {
+				mock.ExpectBegin()
+				expectListSnapshot(mock, args.listId, `{"title": "groceries"}`)
+				mock.ExpectExec(`UPDATE todo_lists tl SET deleted_at = now\(\) FROM users_lists ul`).
+					WithArgs(args.userId, args.listId, args.version).WillReturnResult(sqlmock.NewResult(0, 0))
+				mock.ExpectRollback()
+			},
+			wantErr: func(t assert.TestingT, err error, _ ...interface{}) bool {
+				return assert.ErrorIs(t, err, todo.ErrNotFound)
+			},
+		},
 	}
 
 	for _, testCase := range testTable {
@@ -338,9 +425,10 @@ func TestTodoListPostgres_Delete(t *testing.T) {
 
 			testCase.mockBehavior(testCase.args)
 
-			err = r.Delete(testCase.args.userId, testCase.args.listId)
+			_, err = r.Delete(context.Background(), testCase.args.userId, testCase.args.listId, testCase.args.version)
 
 			testCase.wantErr(t, err)
+			assert.NoError(t, mock.ExpectationsWereMet())
 		})
 	}
 }
@@ -355,9 +443,10 @@ func TestTodoListPostgres_Update(t *testing.T) {
 	r := NewTodoListPostgres(db)
 
 	type args struct {
-		userId int
-		listId int
-		input  todo.UpdateListInput
+		userId  int
+		listId  int
+		input   todo.UpdateListInput
+		version int
 	}
 
 	type mockBehavior func(args args)
@@ -379,11 +468,16 @@ func TestTodoListPostgres_Update(t *testing.T) {
 				},
 			},
 			mockBehavior: func(args args) {
+				mock.ExpectBegin()
+				expectListSnapshot(mock, args.listId, `{"title": "groceries"}`)
 				mock.ExpectExec(regexp.QuoteMeta(`UPDATE todo_lists tl SET title=$1, description=$2 
 												FROM users_lists ul WHERE tl.id = ul.list_id AND ul.list_id=$3 
 												                        AND ul.user_id=$4`)).
-					WithArgs(args.input.Title, args.input.Description, args.listId, args.userId).
-					WillReturnResult(sqlmock.NewResult(1, 0))
+					WithArgs(args.input.Title, args.input.Description, args.listId, args.userId, args.version).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				expectListSnapshot(mock, args.listId, `{"title": "title test"}`)
+				expectActivity(mock, args.listId, args.userId, "list", "update").WillReturnRows(activityRow(1))
+				mock.ExpectCommit()
 			},
 			wantErr: assert.NoError,
 		},
@@ -397,11 +491,16 @@ func TestTodoListPostgres_Update(t *testing.T) {
 				},
 			},
 			mockBehavior: func(args args) {
+				mock.ExpectBegin()
+				expectListSnapshot(mock, args.listId, `{"title": "groceries"}`)
 				mock.ExpectExec(regexp.QuoteMeta(`UPDATE todo_lists tl SET title=$1
 												FROM users_lists ul WHERE tl.id = ul.list_id AND ul.list_id=$2 
 												                        AND ul.user_id=$3`)).
-					WithArgs(args.input.Title, args.listId, args.userId).
-					WillReturnResult(sqlmock.NewResult(1, 0))
+					WithArgs(args.input.Title, args.listId, args.userId, args.version).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				expectListSnapshot(mock, args.listId, `{"title": "title test"}`)
+				expectActivity(mock, args.listId, args.userId, "list", "update").WillReturnRows(activityRow(1))
+				mock.ExpectCommit()
 			},
 			wantErr: assert.NoError,
 		},
@@ -415,11 +514,16 @@ func TestTodoListPostgres_Update(t *testing.T) {
 				},
 			},
 			mockBehavior: func(args args) {
+				mock.ExpectBegin()
+				expectListSnapshot(mock, args.listId, `{"title": "groceries"}`)
 				mock.ExpectExec(regexp.QuoteMeta(`UPDATE todo_lists tl SET description=$1
 												FROM users_lists ul WHERE tl.id = ul.list_id AND ul.list_id=$2 
 												                        AND ul.user_id=$3`)).
-					WithArgs(args.input.Description, args.listId, args.userId).
-					WillReturnResult(sqlmock.NewResult(1, 0))
+					WithArgs(args.input.Description, args.listId, args.userId, args.version).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				expectListSnapshot(mock, args.listId, `{"title": "title test"}`)
+				expectActivity(mock, args.listId, args.userId, "list", "update").WillReturnRows(activityRow(1))
+				mock.ExpectCommit()
 			},
 			wantErr: assert.NoError,
 		},
@@ -434,23 +538,51 @@ func TestTodoListPostgres_Update(t *testing.T) {
 				},
 			},
 			mockBehavior: func(args args) {
+				mock.ExpectBegin()
+				expectListSnapshot(mock, args.listId, `{"title": "groceries"}`)
 				mock.ExpectExec(regexp.QuoteMeta(`UPDATE todo_lists tl SET title=$1, description=$2 
 												FROM users_lists ul WHERE tl.id = ul.list_id AND ul.list_id=$3 
 												                        AND ul.user_id=$4`)).
-					WithArgs(args.input.Title, args.input.Description, args.listId, args.userId).
+					WithArgs(args.input.Title, args.input.Description, args.listId, args.userId, args.version).
 					WillReturnError(assert.AnError)
+				mock.ExpectRollback()
 			},
 			wantErr: assert.Error,
 		},
+		{
+			name: "Stale Version",
+			args: args{
+				userId: 1,
+				listId: 1,
+				input: todo.UpdateListInput{
+					Title: stringPointer("title test"),
+				},
+				version: 3,
+			},
+			mockBehavior: func(args args) {
+				mock.ExpectBegin()
+				expectListSnapshot(mock, args.listId, `{"title": "groceries"}`)
+				mock.ExpectExec(regexp.QuoteMeta(`UPDATE todo_lists tl SET title=$1
+												FROM users_lists ul WHERE tl.id = ul.list_id AND ul.list_id=$2 
+												                        AND ul.user_id=$3`)).
+					WithArgs(args.input.Title, args.listId, args.userId, args.version).
+					WillReturnResult(sqlmock.NewResult(0, 0))
+				mock.ExpectRollback()
+			},
+			wantErr: func(t assert.TestingT, err error, _ ...interface{}) bool {
+				return assert.ErrorIs(t, err, todo.ErrPreconditionFailed)
+			},
+		},
 	}
 
 	for _, testCase := range testTable {
 		t.Run(testCase.name, func(t *testing.T) {
 			testCase.mockBehavior(testCase.args)
 
-			err = r.Update(testCase.args.userId, testCase.args.listId, testCase.args.input)
+			_, err = r.Update(context.Background(), testCase.args.userId, testCase.args.listId, testCase.args.input, testCase.args.version)
 
 			testCase.wantErr(t, err)
+			assert.NoError(t, mock.ExpectationsWereMet())
 		})
 	}
 }
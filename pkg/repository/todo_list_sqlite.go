@@ -0,0 +1,205 @@
+package repository
+
+import (
+	"context"
+	todo "do-app"
+	"fmt"
+	"github.com/jmoiron/sqlx"
+	"github.com/sirupsen/logrus"
+	"strings"
+	"time"
+)
+
+type TodoListSQLite struct {
+	db *sqlx.DB
+}
+
+func NewTodoListSQLite(db *sqlx.DB) *TodoListSQLite {
+	return &TodoListSQLite{db: db}
+}
+
+func (r *TodoListSQLite) Create(ctx context.Context, userId int, list todo.TodoList) (int, error) {
+	tx, err := beginTx(ctx, r.db)
+	if err != nil {
+		return 0, fmt.Errorf("create list sqlite: %w", err)
+	}
+
+	var id int
+	createListQuery := fmt.Sprintf("INSERT INTO %s (title, description) VALUES (?1, ?2) RETURNING id", todoListsTable)
+	if err = tx.QueryRowContext(ctx, createListQuery, list.Title, list.Description).Scan(&id); err != nil {
+		tx.Rollback()
+		return 0, fmt.Errorf("create list sqlite: %w", err)
+	}
+	created, err := listSnapshotSQLite(ctx, tx, id)
+	if err != nil {
+		tx.Rollback()
+		return 0, fmt.Errorf("create list sqlite: %w", err)
+	}
+
+	createUsersListQuery := fmt.Sprintf("INSERT INTO %s (user_id, list_id, role) VALUES (?1, ?2, '%s')",
+		usersListsTable, todo.RoleOwner)
+	_, err = tx.ExecContext(ctx, createUsersListQuery, userId, id)
+	if err != nil {
+		tx.Rollback()
+		return 0, fmt.Errorf("create list sqlite: %w", err)
+	}
+
+	if _, err = recordListChange(ctx, tx, userId, id, todo.ActionCreate, nil, created); err != nil {
+		tx.Rollback()
+		return 0, fmt.Errorf("create list sqlite: %w", err)
+	}
+	return id, tx.Commit()
+}
+
+func (r *TodoListSQLite) GetAll(ctx context.Context, userId int, filter todo.ListFilter, page todo.PageRequest) ([]todo.TodoList, string, error) {
+	keys, err := newKeyset(page, todo.SortCreated, listSortKeys, "tl.id")
+	if err != nil {
+		return nil, "", fmt.Errorf("Get All lists repository: %w", err)
+	}
+
+	conditions := []string{"ul.user_id = ?1", "tl.deleted_at IS NULL"}
+	args := []interface{}{userId}
+	if filter.Query != "" {
+		args = append(args, filter.Query)
+		conditions = append(conditions, fmt.Sprintf("instr(lower(tl.title), lower(?%d)) > 0", len(args)))
+	}
+	conditions, args, err = keys.sqliteConditions(conditions, args)
+	if err != nil {
+		return nil, "", fmt.Errorf("Get All lists repository: %w", err)
+	}
+
+	var lists []todo.TodoList
+	query := fmt.Sprintf("SELECT tl.id, tl.title, tl.description, tl.version, tl.created_at, tl.updated_at FROM %s tl INNER JOIN"+
+		" %s ul on tl.id = ul.list_id WHERE %s %s",
+		todoListsTable, usersListsTable, strings.Join(conditions, " AND "), keys.orderBy())
+	err = conn(ctx, r.db).SelectContext(ctx, &lists, query, args...)
+	if err != nil {
+		return nil, "", fmt.Errorf("Get All lists repository: %w", err)
+	}
+
+	n, next := keys.page(len(lists), func(i int) (string, int) {
+		return listSortValue(lists[i], keys.name), lists[i].Id
+	})
+	return lists[:n], next, nil
+}
+
+func (r *TodoListSQLite) GetById(ctx context.Context, userId, listId int) (todo.TodoList, error) {
+	var list todo.TodoList
+
+	query := fmt.Sprintf(`SELECT tl.id, tl.title, tl.description, tl.version, tl.created_at, tl.updated_at FROM %s tl INNER JOIN 
+                                 %s ul on tl.id = ul.list_id WHERE ul.user_id = ?1 AND ul.list_id = ?2 AND tl.deleted_at IS NULL`,
+		todoListsTable, usersListsTable)
+	err := conn(ctx, r.db).GetContext(ctx, &list, query, userId, listId)
+	if err != nil {
+		return list, fmt.Errorf("GetById list repository: %w", domainError(err, "list"))
+	}
+
+	return list, nil
+}
+
+// Delete moves the list to the trash if it is still at version, or at any
+// version when version is 0. Its items are trashed with the same deletion
+// time, so that restoring the list brings back exactly the items deleted with
+// it. The id of the activity record of the deletion is returned.
+func (r *TodoListSQLite) Delete(ctx context.Context, userId, listId, version int) (int, error) {
+	tx, err := beginTx(ctx, r.db)
+	if err != nil {
+		return 0, fmt.Errorf("Delete list repository: %w", err)
+	}
+
+	before, err := listSnapshotSQLite(ctx, tx, listId)
+	if err != nil {
+		tx.Rollback()
+		return 0, fmt.Errorf("Delete list repository: %w", err)
+	}
+
+	now := sqliteTime(time.Now())
+	query := fmt.Sprintf(`UPDATE %s SET deleted_at = ?1 WHERE id = ?2 AND (?3 = 0 OR version = ?3) AND deleted_at IS NULL
+								 AND EXISTS (SELECT 1 FROM %s WHERE list_id = ?2 AND user_id = ?4 AND role = '%s')`,
+		todoListsTable, usersListsTable, todo.RoleOwner)
+	res, err := tx.ExecContext(ctx, query, now, listId, version, userId)
+	if err != nil {
+		tx.Rollback()
+		return 0, fmt.Errorf("Delete list repository: %w", err)
+	}
+	if err = checkVersion(res, "list", version); err != nil {
+		tx.Rollback()
+		return 0, fmt.Errorf("Delete list repository: %w", err)
+	}
+
+	itemsQuery := fmt.Sprintf(`UPDATE %s SET deleted_at = ?1 WHERE deleted_at IS NULL
+								 AND id IN (SELECT item_id FROM %s WHERE list_id = ?2)`,
+		todoItemsTable, listsItemsTable)
+	if _, err = tx.ExecContext(ctx, itemsQuery, now, listId); err != nil {
+		tx.Rollback()
+		return 0, fmt.Errorf("Delete list repository: %w", err)
+	}
+
+	activityId, err := recordListChange(ctx, tx, userId, listId, todo.ActionDelete, before, nil)
+	if err != nil {
+		tx.Rollback()
+		return 0, fmt.Errorf("Delete list repository: %w", err)
+	}
+	return activityId, tx.Commit()
+}
+
+// Update changes the list if it is still at version, or at any version when
+// version is 0, and returns the id of the activity record of the change.
+func (r *TodoListSQLite) Update(ctx context.Context, userId, listId int, input todo.UpdateListInput, version int) (int, error) {
+	setValues := make([]string, 0)
+	args := make([]interface{}, 0)
+	argId := 1
+
+	if input.Title != nil {
+		setValues = append(setValues, fmt.Sprintf("title=?%d", argId))
+		args = append(args, *input.Title)
+		argId++
+	}
+	if input.Description != nil {
+		setValues = append(setValues, fmt.Sprintf("description=?%d", argId))
+		args = append(args, *input.Description)
+		argId++
+	}
+
+	setQuery := strings.Join(setValues, ", ")
+
+	query := fmt.Sprintf("UPDATE %s SET %s WHERE id = ?%d AND (?%d = 0 OR version = ?%d) AND deleted_at IS NULL"+
+		" AND EXISTS (SELECT 1 FROM %s WHERE list_id = ?%d AND user_id = ?%d AND role IN (%s))",
+		todoListsTable, setQuery, argId, argId+2, argId+2, usersListsTable, argId, argId+1, editorRoles)
+	args = append(args, listId, userId, version)
+
+	logrus.Debugf("updateQuery: %s", query)
+	logrus.Debugf("updateArgs: %s", args)
+
+	tx, err := beginTx(ctx, r.db)
+	if err != nil {
+		return 0, fmt.Errorf("Update list repository: %w", err)
+	}
+
+	before, err := listSnapshotSQLite(ctx, tx, listId)
+	if err != nil {
+		tx.Rollback()
+		return 0, fmt.Errorf("Update list repository: %w", err)
+	}
+	res, err := tx.ExecContext(ctx, query, args...)
+	if err != nil {
+		tx.Rollback()
+		return 0, fmt.Errorf("Update list repository: %w", err)
+	}
+	if err = checkVersion(res, "list", version); err != nil {
+		tx.Rollback()
+		return 0, fmt.Errorf("Update list repository: %w", err)
+	}
+	after, err := listSnapshotSQLite(ctx, tx, listId)
+	if err != nil {
+		tx.Rollback()
+		return 0, fmt.Errorf("Update list repository: %w", err)
+	}
+
+	activityId, err := recordListChange(ctx, tx, userId, listId, todo.ActionUpdate, before, after)
+	if err != nil {
+		tx.Rollback()
+		return 0, fmt.Errorf("Update list repository: %w", err)
+	}
+	return activityId, tx.Commit()
+}
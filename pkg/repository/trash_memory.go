@@ -0,0 +1,188 @@
+package repository
+
+import (
+	"context"
+	todo "do-app"
+	"fmt"
+	"sort"
+	"time"
+)
+
+type TrashMemory struct {
+	store *memoryStore
+}
+
+func NewTrashMemory(store *memoryStore) *TrashMemory {
+	return &TrashMemory{store: store}
+}
+
+// GetAll returns the lists the user owns and the items of lists the user can
+// edit that are in the trash, most recently deleted first.
+func (r *TrashMemory) GetAll(ctx context.Context, userId int) ([]todo.TrashEntry, error) {
+	var entries []todo.TrashEntry
+	err := r.store.read(ctx, func(d *memoryData) error {
+		for _, list := range d.lists {
+			if list.DeletedAt != nil && d.role(userId, list.Id) == todo.RoleOwner {
+				entries = append(entries, todo.TrashEntry{Type: todo.TrashList, Id: list.Id, ListId: list.Id,
+					Title: list.Title, DeletedAt: *list.DeletedAt})
+			}
+		}
+		for _, item := range d.items {
+			if item.DeletedAt != nil && d.lists[item.ListId].DeletedAt == nil && todo.CanEdit(d.role(userId, item.ListId)) {
+				entries = append(entries, todo.TrashEntry{Type: todo.TrashItem, Id: item.Id, ListId: item.ListId,
+					Title: item.Title, DeletedAt: *item.DeletedAt})
+			}
+		}
+		sort.Slice(entries, func(i, j int) bool {
+			a, b := entries[i], entries[j]
+			if !a.DeletedAt.Equal(b.DeletedAt) {
+				return a.DeletedAt.After(b.DeletedAt)
+			}
+			if a.Type != b.Type {
+				return a.Type < b.Type
+			}
+			return a.Id < b.Id
+		})
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("GetAll trash repository: %w", err)
+	}
+	return entries, nil
+}
+
+// RestoreList takes a list the user owns out of the trash together with the
+// items that were deleted with it.
+func (r *TrashMemory) RestoreList(ctx context.Context, userId, listId int) error {
+	err := r.store.write(ctx, func(d *memoryData) error {
+		list, ok := d.lists[listId]
+		if !ok || list.DeletedAt == nil || d.role(userId, listId) != todo.RoleOwner {
+			return todo.NewError(todo.ErrNotFound, "list not found in trash")
+		}
+		restoreMemoryList(d, list)
+		_, err := recordMemoryChange(d, userId, listId, 0, todo.ActionRestore, nil, nil)
+		return err
+	})
+	if err != nil {
+		return fmt.Errorf("RestoreList trash repository: %w", err)
+	}
+	return nil
+}
+
+// restoreMemoryList takes the list out of the trash together with the items
+// that were deleted with it.
+func restoreMemoryList(d *memoryData, list memoryList) {
+	for id, item := range d.items {
+		if item.ListId == list.Id && item.DeletedAt != nil && item.DeletedAt.Equal(*list.DeletedAt) {
+			item.DeletedAt = nil
+			touchItem(&item)
+			put(d, d.items, id, item)
+		}
+	}
+	list.DeletedAt = nil
+	touchList(&list)
+	put(d, d.lists, list.Id, list)
+}
+
+// RestoreItem takes an item out of the trash. Items of a list in the trash
+// can only come back with their list.
+func (r *TrashMemory) RestoreItem(ctx context.Context, userId, itemId int) error {
+	err := r.store.write(ctx, func(d *memoryData) error {
+		item, ok := d.items[itemId]
+		if !ok || item.DeletedAt == nil || d.lists[item.ListId].DeletedAt != nil ||
+			!todo.CanEdit(d.role(userId, item.ListId)) {
+			return todo.NewError(todo.ErrNotFound, "item not found in trash")
+		}
+		item.DeletedAt = nil
+		touchItem(&item)
+		put(d, d.items, itemId, item)
+
+		_, err := recordMemoryChange(d, userId, item.ListId, itemId, todo.ActionRestore, nil, nil)
+		return err
+	})
+	if err != nil {
+		return fmt.Errorf("RestoreItem trash repository: %w", err)
+	}
+	return nil
+}
+
+// Purge permanently removes lists and items that were moved to the trash
+// before the given time and returns how many lists and items were removed.
+func (r *TrashMemory) Purge(ctx context.Context, before time.Time) (int64, error) {
+	var purged int64
+	err := r.store.write(ctx, func(d *memoryData) error {
+		for id, item := range d.items {
+			list := d.lists[item.ListId]
+			if (list.DeletedAt != nil && list.DeletedAt.Before(before)) ||
+				(item.DeletedAt != nil && item.DeletedAt.Before(before)) {
+				removeMemoryItem(d, id)
+				purged++
+			}
+		}
+		for id, list := range d.lists {
+			if list.DeletedAt != nil && list.DeletedAt.Before(before) {
+				removeMemoryList(d, id)
+				purged++
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, fmt.Errorf("Purge trash repository: %w", err)
+	}
+	return purged, nil
+}
+
+// removeMemoryItem deletes the item and the rows that refer to it, like the
+// foreign keys of the database cascade.
+func removeMemoryItem(d *memoryData, itemId int) {
+	remove(d, d.items, itemId)
+	for id, subtask := range d.subtasks {
+		if subtask.ItemId == itemId {
+			remove(d, d.subtasks, id)
+		}
+	}
+	for link := range d.itemLabels {
+		if link.ItemId == itemId {
+			remove(d, d.itemLabels, link)
+		}
+	}
+	deliveries := make([]memoryDelivery, 0, len(d.deliveries))
+	for _, delivery := range d.deliveries {
+		if delivery.ItemId != itemId {
+			deliveries = append(deliveries, delivery)
+		}
+	}
+	replace(d, &d.deliveries, deliveries)
+}
+
+// removeMemoryList deletes the list and the rows that refer to it.
+func removeMemoryList(d *memoryData, listId int) {
+	remove(d, d.lists, listId)
+	for id, m := range d.members {
+		if m.ListId == listId {
+			remove(d, d.members, id)
+		}
+	}
+	for id, invite := range d.invites {
+		if invite.ListId == listId {
+			remove(d, d.invites, id)
+			remove(d, d.redemptions, id)
+		}
+	}
+	removed := make(map[int]bool)
+	activities := make([]todo.Activity, 0, len(d.activities))
+	for _, a := range d.activities {
+		if a.ListId == listId {
+			removed[a.Id] = true
+			continue
+		}
+		activities = append(activities, a)
+	}
+	replace(d, &d.activities, activities)
+	for hash, token := range d.undoTokens {
+		if removed[token.ActivityId] {
+			remove(d, d.undoTokens, hash)
+		}
+	}
+}
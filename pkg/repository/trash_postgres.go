@@ -0,0 +1,149 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	todo "do-app"
+	"errors"
+	"fmt"
+	"github.com/jmoiron/sqlx"
+	"time"
+)
+
+type TrashPostgres struct {
+	db *sqlx.DB
+}
+
+func NewTrashPostgres(db *sqlx.DB) *TrashPostgres {
+	return &TrashPostgres{db: db}
+}
+
+// GetAll returns the lists the user owns and the items of lists the user can
+// edit that are in the trash, most recently deleted first.
+func (r *TrashPostgres) GetAll(ctx context.Context, userId int) ([]todo.TrashEntry, error) {
+	query := fmt.Sprintf(`SELECT 'list' AS type, tl.id, tl.id AS list_id, tl.title, tl.deleted_at
+								 FROM %s tl INNER JOIN %s ul on ul.list_id = tl.id
+								 WHERE ul.user_id = $1 AND ul.role = '%s' AND tl.deleted_at IS NOT NULL
+								 UNION ALL
+								 SELECT 'item' AS type, ti.id, li.list_id, ti.title, ti.deleted_at
+								 FROM %s ti INNER JOIN %s li on li.item_id = ti.id INNER JOIN %s ul on ul.list_id = li.list_id
+								 INNER JOIN %s tl on tl.id = li.list_id
+								 WHERE ul.user_id = $1 AND ul.role IN (%s) AND ti.deleted_at IS NOT NULL AND tl.deleted_at IS NULL
+								 ORDER BY deleted_at DESC, type, id`,
+		todoListsTable, usersListsTable, todo.RoleOwner,
+		todoItemsTable, listsItemsTable, usersListsTable, todoListsTable, editorRoles)
+
+	var entries []todo.TrashEntry
+	if err := conn(ctx, r.db).SelectContext(ctx, &entries, query, userId); err != nil {
+		return nil, fmt.Errorf("GetAll trash repository: %w", err)
+	}
+	return entries, nil
+}
+
+// RestoreList takes a list the user owns out of the trash together with the
+// items that were deleted with it.
+func (r *TrashPostgres) RestoreList(ctx context.Context, userId, listId int) error {
+	tx, err := beginTx(ctx, r.db)
+	if err != nil {
+		return fmt.Errorf("RestoreList trash repository: %w", err)
+	}
+
+	var deletedAt time.Time
+	lockQuery := fmt.Sprintf(`SELECT tl.deleted_at FROM %s tl INNER JOIN %s ul on ul.list_id = tl.id
+								 WHERE ul.user_id = $1 AND tl.id = $2 AND ul.role = '%s' AND tl.deleted_at IS NOT NULL
+								 FOR UPDATE OF tl`, todoListsTable, usersListsTable, todo.RoleOwner)
+	if err = tx.GetContext(ctx, &deletedAt, lockQuery, userId, listId); err != nil {
+		tx.Rollback()
+		if errors.Is(err, sql.ErrNoRows) {
+			err = todo.NewError(todo.ErrNotFound, "list not found in trash")
+		}
+		return fmt.Errorf("RestoreList trash repository: %w", err)
+	}
+
+	if err = restoreList(ctx, tx, listId, deletedAt); err != nil {
+		tx.Rollback()
+		return fmt.Errorf("RestoreList trash repository: %w", err)
+	}
+
+	if _, err = recordListChange(ctx, tx, userId, listId, todo.ActionRestore, nil, nil); err != nil {
+		tx.Rollback()
+		return fmt.Errorf("RestoreList trash repository: %w", err)
+	}
+	return tx.Commit()
+}
+
+// restoreList takes the list out of the trash together with the items that
+// were deleted with it at deletedAt.
+func restoreList(ctx context.Context, tx *Tx, listId int, deletedAt time.Time) error {
+	itemsQuery := fmt.Sprintf(`UPDATE %s ti SET deleted_at = NULL FROM %s li
+								 WHERE ti.id = li.item_id AND li.list_id = $1 AND ti.deleted_at = $2`,
+		todoItemsTable, listsItemsTable)
+	if _, err := tx.ExecContext(ctx, itemsQuery, listId, deletedAt); err != nil {
+		return err
+	}
+
+	listQuery := fmt.Sprintf("UPDATE %s SET deleted_at = NULL WHERE id = $1", todoListsTable)
+	_, err := tx.ExecContext(ctx, listQuery, listId)
+	return err
+}
+
+// RestoreItem takes an item out of the trash. Items of a list in the trash
+// can only come back with their list.
+func (r *TrashPostgres) RestoreItem(ctx context.Context, userId, itemId int) error {
+	tx, err := beginTx(ctx, r.db)
+	if err != nil {
+		return fmt.Errorf("RestoreItem trash repository: %w", err)
+	}
+
+	var listId int
+	query := fmt.Sprintf(`UPDATE %s ti SET deleted_at = NULL FROM %s li, %s ul, %s tl
+								 WHERE ti.id = li.item_id AND li.list_id = ul.list_id AND tl.id = li.list_id
+								 AND ul.user_id = $1 AND ti.id = $2 AND ul.role IN (%s)
+								 AND ti.deleted_at IS NOT NULL AND tl.deleted_at IS NULL RETURNING li.list_id`,
+		todoItemsTable, listsItemsTable, usersListsTable, todoListsTable, editorRoles)
+	if err = tx.GetContext(ctx, &listId, query, userId, itemId); err != nil {
+		tx.Rollback()
+		if errors.Is(err, sql.ErrNoRows) {
+			err = todo.NewError(todo.ErrNotFound, "item not found in trash")
+		}
+		return fmt.Errorf("RestoreItem trash repository: %w", err)
+	}
+
+	if _, err = recordItemChange(ctx, tx, userId, listId, itemId, todo.ActionRestore, nil, nil); err != nil {
+		tx.Rollback()
+		return fmt.Errorf("RestoreItem trash repository: %w", err)
+	}
+	return tx.Commit()
+}
+
+// Purge permanently removes lists and items that were moved to the trash
+// before the given time and returns how many lists and items were removed.
+func (r *TrashPostgres) Purge(ctx context.Context, before time.Time) (int64, error) {
+	tx, err := beginTx(ctx, r.db)
+	if err != nil {
+		return 0, fmt.Errorf("Purge trash repository: %w", err)
+	}
+
+	queries := []string{
+		fmt.Sprintf(`DELETE FROM %s ti USING %s li, %s tl
+								 WHERE ti.id = li.item_id AND tl.id = li.list_id AND tl.deleted_at < $1`,
+			todoItemsTable, listsItemsTable, todoListsTable),
+		fmt.Sprintf("DELETE FROM %s WHERE deleted_at < $1", todoItemsTable),
+		fmt.Sprintf("DELETE FROM %s WHERE deleted_at < $1", todoListsTable),
+	}
+	var purged int64
+	for _, query := range queries {
+		res, err := tx.ExecContext(ctx, query, before)
+		if err != nil {
+			tx.Rollback()
+			return 0, fmt.Errorf("Purge trash repository: %w", err)
+		}
+		affected, err := res.RowsAffected()
+		if err != nil {
+			tx.Rollback()
+			return 0, fmt.Errorf("Purge trash repository: %w", err)
+		}
+		purged += affected
+	}
+	return purged, tx.Commit()
+}
@@ -0,0 +1,192 @@
+package repository
+
+import (
+	"context"
+	todo "do-app"
+	"github.com/stretchr/testify/assert"
+	sqlmock "github.com/zhashkevych/go-sqlxmock"
+	"log"
+	"testing"
+	"time"
+)
+
+func TestTrashPostgres_GetAll(t *testing.T) {
+	db, mock, err := sqlmock.Newx()
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer db.Close()
+
+	r := NewTrashPostgres(db)
+
+	deletedAt := time.Date(2030, 1, 2, 10, 0, 0, 0, time.UTC)
+	rows := sqlmock.NewRows([]string{"type", "id", "list_id", "title", "deleted_at"}).
+		AddRow("list", 2, 2, "groceries", deletedAt).
+		AddRow("item", 5, 3, "call mom", deletedAt.Add(-time.Hour))
+	mock.ExpectQuery(`SELECT 'list' AS type, (.+) WHERE ul.user_id = \$1 AND ul.role = 'owner' AND tl.deleted_at IS NOT NULL ` +
+		`UNION ALL SELECT 'item' AS type, (.+) AND ti.deleted_at IS NOT NULL AND tl.deleted_at IS NULL ORDER BY deleted_at DESC`).
+		WithArgs(1).WillReturnRows(rows)
+
+	got, err := r.GetAll(context.Background(), 1)
+
+	assert.NoError(t, err)
+	assert.Equal(t, []todo.TrashEntry{
+		{Type: "list", Id: 2, ListId: 2, Title: "groceries", DeletedAt: deletedAt},
+		{Type: "item", Id: 5, ListId: 3, Title: "call mom", DeletedAt: deletedAt.Add(-time.Hour)},
+	}, got)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestTrashPostgres_RestoreList(t *testing.T) {
+	db, mock, err := sqlmock.Newx()
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer db.Close()
+
+	r := NewTrashPostgres(db)
+
+	deletedAt := time.Date(2030, 1, 2, 10, 0, 0, 0, time.UTC)
+
+	testTable := []struct {
+		name         string
+		mockBehavior func()
+		wantKind     error
+		wantErr      bool
+	}{
+		{
+			name: "OK",
+			mockBehavior: func() {
+				mock.ExpectBegin()
+				mock.ExpectQuery(`SELECT tl.deleted_at FROM todo_lists tl (.+) FOR UPDATE OF tl`).
+					WithArgs(1, 2).WillReturnRows(sqlmock.NewRows([]string{"deleted_at"}).AddRow(deletedAt))
+				mock.ExpectExec(`UPDATE todo_items ti SET deleted_at = NULL FROM lists_items li (.+) AND ti.deleted_at = \$2`).
+					WithArgs(2, deletedAt).WillReturnResult(sqlmock.NewResult(0, 3))
+				mock.ExpectExec(`UPDATE todo_lists SET deleted_at = NULL WHERE id = \$1`).
+					WithArgs(2).WillReturnResult(sqlmock.NewResult(0, 1))
+				expectActivity(mock, 2, 1, "list", "restore").WillReturnRows(activityRow(1))
+				mock.ExpectCommit()
+			},
+		},
+		{
+			name: "Not In Trash",
+			mockBehavior: func() {
+				mock.ExpectBegin()
+				mock.ExpectQuery(`SELECT tl.deleted_at FROM todo_lists tl`).
+					WithArgs(1, 2).WillReturnRows(sqlmock.NewRows([]string{"deleted_at"}))
+				mock.ExpectRollback()
+			},
+			wantErr:  true,
+			wantKind: todo.ErrNotFound,
+		},
+		{
+			name: "Restore Items Error",
+			mockBehavior: func() {
+				mock.ExpectBegin()
+				mock.ExpectQuery(`SELECT tl.deleted_at FROM todo_lists tl`).
+					WithArgs(1, 2).WillReturnRows(sqlmock.NewRows([]string{"deleted_at"}).AddRow(deletedAt))
+				mock.ExpectExec(`UPDATE todo_items ti SET deleted_at = NULL`).
+					WithArgs(2, deletedAt).WillReturnError(assert.AnError)
+				mock.ExpectRollback()
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			testCase.mockBehavior()
+
+			err := r.RestoreList(context.Background(), 1, 2)
+
+			if testCase.wantErr {
+				assert.Error(t, err)
+				if testCase.wantKind != nil {
+					assert.ErrorIs(t, err, testCase.wantKind)
+				}
+			} else {
+				assert.NoError(t, err)
+			}
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestTrashPostgres_RestoreItem(t *testing.T) {
+	db, mock, err := sqlmock.Newx()
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer db.Close()
+
+	r := NewTrashPostgres(db)
+
+	testTable := []struct {
+		name         string
+		mockBehavior func()
+		wantKind     error
+	}{
+		{
+			name: "OK",
+			mockBehavior: func() {
+				mock.ExpectBegin()
+				mock.ExpectQuery(`UPDATE todo_items ti SET deleted_at = NULL FROM lists_items li, users_lists ul, todo_lists tl `+
+					`(.+) AND ti.deleted_at IS NOT NULL AND tl.deleted_at IS NULL RETURNING li.list_id`).
+					WithArgs(1, 5).WillReturnRows(sqlmock.NewRows([]string{"list_id"}).AddRow(2))
+				expectActivity(mock, 2, 1, "item", "restore").WillReturnRows(activityRow(1))
+				mock.ExpectCommit()
+			},
+		},
+		{
+			name: "Not In Trash",
+			mockBehavior: func() {
+				mock.ExpectBegin()
+				mock.ExpectQuery(`UPDATE todo_items ti SET deleted_at = NULL`).
+					WithArgs(1, 5).WillReturnRows(sqlmock.NewRows([]string{"list_id"}))
+				mock.ExpectRollback()
+			},
+			wantKind: todo.ErrNotFound,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			testCase.mockBehavior()
+
+			err := r.RestoreItem(context.Background(), 1, 5)
+
+			if testCase.wantKind != nil {
+				assert.ErrorIs(t, err, testCase.wantKind)
+			} else {
+				assert.NoError(t, err)
+			}
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestTrashPostgres_Purge(t *testing.T) {
+	db, mock, err := sqlmock.Newx()
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer db.Close()
+
+	r := NewTrashPostgres(db)
+
+	before := time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC)
+	mock.ExpectBegin()
+	mock.ExpectExec(`DELETE FROM todo_items ti USING lists_items li, todo_lists tl (.+) AND tl.deleted_at < \$1`).
+		WithArgs(before).WillReturnResult(sqlmock.NewResult(0, 4))
+	mock.ExpectExec(`DELETE FROM todo_items WHERE deleted_at < \$1`).
+		WithArgs(before).WillReturnResult(sqlmock.NewResult(0, 2))
+	mock.ExpectExec(`DELETE FROM todo_lists WHERE deleted_at < \$1`).
+		WithArgs(before).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	purged, err := r.Purge(context.Background(), before)
+
+	assert.NoError(t, err)
+	assert.Equal(t, int64(7), purged)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,155 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	todo "do-app"
+	"errors"
+	"fmt"
+	"github.com/jmoiron/sqlx"
+	"time"
+)
+
+type TrashSQLite struct {
+	db *sqlx.DB
+}
+
+func NewTrashSQLite(db *sqlx.DB) *TrashSQLite {
+	return &TrashSQLite{db: db}
+}
+
+// GetAll returns the lists the user owns and the items of lists the user can
+// edit that are in the trash, most recently deleted first.
+func (r *TrashSQLite) GetAll(ctx context.Context, userId int) ([]todo.TrashEntry, error) {
+	query := fmt.Sprintf(`SELECT 'list' AS type, tl.id AS id, tl.id AS list_id, tl.title, tl.deleted_at AS deleted_at
+								 FROM %s tl INNER JOIN %s ul on ul.list_id = tl.id
+								 WHERE ul.user_id = ?1 AND ul.role = '%s' AND tl.deleted_at IS NOT NULL
+								 UNION ALL
+								 SELECT 'item' AS type, ti.id, li.list_id, ti.title, ti.deleted_at
+								 FROM %s ti INNER JOIN %s li on li.item_id = ti.id INNER JOIN %s ul on ul.list_id = li.list_id
+								 INNER JOIN %s tl on tl.id = li.list_id
+								 WHERE ul.user_id = ?1 AND ul.role IN (%s) AND ti.deleted_at IS NOT NULL AND tl.deleted_at IS NULL
+								 ORDER BY deleted_at DESC, type, id`,
+		todoListsTable, usersListsTable, todo.RoleOwner,
+		todoItemsTable, listsItemsTable, usersListsTable, todoListsTable, editorRoles)
+
+	var entries []todo.TrashEntry
+	if err := conn(ctx, r.db).SelectContext(ctx, &entries, query, userId); err != nil {
+		return nil, fmt.Errorf("GetAll trash repository: %w", err)
+	}
+	return entries, nil
+}
+
+// RestoreList takes a list the user owns out of the trash together with the
+// items that were deleted with it.
+func (r *TrashSQLite) RestoreList(ctx context.Context, userId, listId int) error {
+	tx, err := beginTx(ctx, r.db)
+	if err != nil {
+		return fmt.Errorf("RestoreList trash repository: %w", err)
+	}
+
+	var deletedAt time.Time
+	deletedQuery := fmt.Sprintf(`SELECT tl.deleted_at FROM %s tl INNER JOIN %s ul on ul.list_id = tl.id
+								 WHERE ul.user_id = ?1 AND tl.id = ?2 AND ul.role = '%s' AND tl.deleted_at IS NOT NULL`,
+		todoListsTable, usersListsTable, todo.RoleOwner)
+	if err = tx.GetContext(ctx, &deletedAt, deletedQuery, userId, listId); err != nil {
+		tx.Rollback()
+		if errors.Is(err, sql.ErrNoRows) {
+			err = todo.NewError(todo.ErrNotFound, "list not found in trash")
+		}
+		return fmt.Errorf("RestoreList trash repository: %w", err)
+	}
+
+	if err = restoreListSQLite(ctx, tx, listId, deletedAt); err != nil {
+		tx.Rollback()
+		return fmt.Errorf("RestoreList trash repository: %w", err)
+	}
+
+	if _, err = recordListChange(ctx, tx, userId, listId, todo.ActionRestore, nil, nil); err != nil {
+		tx.Rollback()
+		return fmt.Errorf("RestoreList trash repository: %w", err)
+	}
+	return tx.Commit()
+}
+
+// restoreListSQLite takes the list out of the trash together with the items
+// that were deleted with it at deletedAt.
+func restoreListSQLite(ctx context.Context, tx *Tx, listId int, deletedAt time.Time) error {
+	itemsQuery := fmt.Sprintf(`UPDATE %s SET deleted_at = NULL WHERE deleted_at = ?2
+								 AND id IN (SELECT item_id FROM %s WHERE list_id = ?1)`,
+		todoItemsTable, listsItemsTable)
+	if _, err := tx.ExecContext(ctx, itemsQuery, listId, sqliteTime(deletedAt)); err != nil {
+		return err
+	}
+
+	listQuery := fmt.Sprintf("UPDATE %s SET deleted_at = NULL WHERE id = ?1", todoListsTable)
+	_, err := tx.ExecContext(ctx, listQuery, listId)
+	return err
+}
+
+// RestoreItem takes an item out of the trash. Items of a list in the trash
+// can only come back with their list.
+func (r *TrashSQLite) RestoreItem(ctx context.Context, userId, itemId int) error {
+	tx, err := beginTx(ctx, r.db)
+	if err != nil {
+		return fmt.Errorf("RestoreItem trash repository: %w", err)
+	}
+
+	var listId int
+	listQuery := fmt.Sprintf(`SELECT li.list_id FROM %s ti INNER JOIN %s li on li.item_id = ti.id
+								 INNER JOIN %s ul on ul.list_id = li.list_id INNER JOIN %s tl on tl.id = li.list_id
+								 WHERE ul.user_id = ?1 AND ti.id = ?2 AND ul.role IN (%s)
+								 AND ti.deleted_at IS NOT NULL AND tl.deleted_at IS NULL`,
+		todoItemsTable, listsItemsTable, usersListsTable, todoListsTable, editorRoles)
+	if err = tx.GetContext(ctx, &listId, listQuery, userId, itemId); err != nil {
+		tx.Rollback()
+		if errors.Is(err, sql.ErrNoRows) {
+			err = todo.NewError(todo.ErrNotFound, "item not found in trash")
+		}
+		return fmt.Errorf("RestoreItem trash repository: %w", err)
+	}
+
+	query := fmt.Sprintf("UPDATE %s SET deleted_at = NULL WHERE id = ?1", todoItemsTable)
+	if _, err = tx.ExecContext(ctx, query, itemId); err != nil {
+		tx.Rollback()
+		return fmt.Errorf("RestoreItem trash repository: %w", err)
+	}
+
+	if _, err = recordItemChange(ctx, tx, userId, listId, itemId, todo.ActionRestore, nil, nil); err != nil {
+		tx.Rollback()
+		return fmt.Errorf("RestoreItem trash repository: %w", err)
+	}
+	return tx.Commit()
+}
+
+// Purge permanently removes lists and items that were moved to the trash
+// before the given time and returns how many lists and items were removed.
+func (r *TrashSQLite) Purge(ctx context.Context, before time.Time) (int64, error) {
+	tx, err := beginTx(ctx, r.db)
+	if err != nil {
+		return 0, fmt.Errorf("Purge trash repository: %w", err)
+	}
+
+	queries := []string{
+		fmt.Sprintf(`DELETE FROM %s WHERE id IN (SELECT li.item_id FROM %s li INNER JOIN %s tl on tl.id = li.list_id
+								 WHERE tl.deleted_at < ?1)`,
+			todoItemsTable, listsItemsTable, todoListsTable),
+		fmt.Sprintf("DELETE FROM %s WHERE deleted_at < ?1", todoItemsTable),
+		fmt.Sprintf("DELETE FROM %s WHERE deleted_at < ?1", todoListsTable),
+	}
+	var purged int64
+	for _, query := range queries {
+		res, err := tx.ExecContext(ctx, query, sqliteTime(before))
+		if err != nil {
+			tx.Rollback()
+			return 0, fmt.Errorf("Purge trash repository: %w", err)
+		}
+		affected, err := res.RowsAffected()
+		if err != nil {
+			tx.Rollback()
+			return 0, fmt.Errorf("Purge trash repository: %w", err)
+		}
+		purged += affected
+	}
+	return purged, tx.Commit()
+}
@@ -0,0 +1,46 @@
+package repository
+
+import (
+	"context"
+)
+
+type TxMemory struct {
+	store *memoryStore
+}
+
+func NewTxMemory(store *memoryStore) *TxMemory {
+	return &TxMemory{store: store}
+}
+
+// WithinTx runs fn as a unit of work with the store locked, so it sees no
+// concurrent changes at any isolation level. The changes of fn are rolled back
+// when it returns an error; a unit of work started inside another one only
+// rolls back its own changes, like a savepoint.
+func (r *TxMemory) WithinTx(ctx context.Context, opts TxOptions, fn func(ctx context.Context) error) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	outer := !r.store.inTx(ctx)
+	if outer {
+		r.store.mu.Lock()
+		defer r.store.mu.Unlock()
+		ctx = context.WithValue(ctx, memoryTxKey{}, r.store)
+	}
+
+	d := r.store.data
+	mark := len(d.undo)
+	defer func() {
+		if p := recover(); p != nil {
+			d.rollback(mark)
+			panic(p)
+		}
+	}()
+	if err := fn(ctx); err != nil {
+		d.rollback(mark)
+		return err
+	}
+	if outer {
+		d.undo = nil
+	}
+	return nil
+}
@@ -0,0 +1,193 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+	"strings"
+	"time"
+)
+
+const (
+	serializationFailure = "40001"
+	deadlockDetected     = "40P01"
+)
+
+// txRetryDelay is the pause before the first retry of a unit of work. Every
+// further retry waits one more delay.
+const txRetryDelay = 20 * time.Millisecond
+
+// TxConfig sets the defaults of units of work. A unit of work that failed to
+// serialize with a concurrent one is run again up to MaxRetries times.
+type TxConfig struct {
+	Isolation  sql.IsolationLevel
+	MaxRetries int
+}
+
+// TxOptions overrides the isolation level of TxConfig for one unit of work.
+type TxOptions struct {
+	Isolation sql.IsolationLevel
+	ReadOnly  bool
+}
+
+// ParseIsolation returns the isolation level named like in SQL, e.g.
+// "repeatable read". An empty name is the default level of the database.
+func ParseIsolation(name string) (sql.IsolationLevel, error) {
+	switch strings.ToLower(strings.TrimSpace(name)) {
+	case "":
+		return sql.LevelDefault, nil
+	case "read committed":
+		return sql.LevelReadCommitted, nil
+	case "repeatable read":
+		return sql.LevelRepeatableRead, nil
+	case "serializable":
+		return sql.LevelSerializable, nil
+	default:
+		return sql.LevelDefault, fmt.Errorf("unknown isolation level %q", name)
+	}
+}
+
+type txKey struct{}
+
+// txState is the transaction of a unit of work, carried by its context.
+type txState struct {
+	tx         *sqlx.Tx
+	savepoints int
+}
+
+type TxPostgres struct {
+	db  *sqlx.DB
+	cfg TxConfig
+}
+
+func NewTxPostgres(db *sqlx.DB, cfg TxConfig) *TxPostgres {
+	return &TxPostgres{db: db, cfg: cfg}
+}
+
+// WithinTx runs fn as a unit of work: every repository call made with the
+// context passed to fn takes part in one transaction, which is committed when
+// fn returns nil and rolled back otherwise. A unit of work started inside
+// another one runs in a savepoint of the outer transaction, whose options
+// apply; only the outermost unit is retried on serialization failures.
+func (r *TxPostgres) WithinTx(ctx context.Context, opts TxOptions, fn func(ctx context.Context) error) error {
+	if _, ok := ctx.Value(txKey{}).(*txState); ok {
+		return runTx(ctx, r.db, nil, fn)
+	}
+
+	txOpts := &sql.TxOptions{Isolation: opts.Isolation, ReadOnly: opts.ReadOnly}
+	if txOpts.Isolation == sql.LevelDefault {
+		txOpts.Isolation = r.cfg.Isolation
+	}
+	for attempt := 1; ; attempt++ {
+		err := runTx(ctx, r.db, txOpts, fn)
+		if err == nil || attempt > r.cfg.MaxRetries || !retryable(err) {
+			return err
+		}
+		select {
+		case <-ctx.Done():
+			return err
+		case <-time.After(time.Duration(attempt) * txRetryDelay):
+		}
+	}
+}
+
+func runTx(ctx context.Context, db *sqlx.DB, opts *sql.TxOptions, fn func(ctx context.Context) error) (err error) {
+	tx, err := beginTxOptions(ctx, db, opts)
+	if err != nil {
+		return fmt.Errorf("WithinTx tx repository: %w", err)
+	}
+	defer func() {
+		if p := recover(); p != nil {
+			tx.Rollback()
+			panic(p)
+		}
+	}()
+
+	if err = fn(tx.ctx); err != nil {
+		tx.Rollback()
+		return err
+	}
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("WithinTx tx repository: %w", err)
+	}
+	return nil
+}
+
+// retryable reports whether err is a transient conflict with a concurrent
+// transaction, after which the unit of work may succeed when run again. For
+// SQLite that is a write lock held for longer than the busy timeout.
+func retryable(err error) bool {
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) {
+		return pqErr.Code == serializationFailure || pqErr.Code == deadlockDetected
+	}
+	return sqliteErrors != nil && sqliteErrors.retryable(err)
+}
+
+// Tx is a transaction of a repository method. Inside a unit of work it is a
+// savepoint of the unit's transaction, so that Rollback only undoes the
+// changes of the method and Commit leaves committing to the unit.
+type Tx struct {
+	*sqlx.Tx
+	ctx       context.Context
+	savepoint string
+}
+
+// beginTx starts a transaction, or a savepoint if ctx belongs to a unit of
+// work.
+func beginTx(ctx context.Context, db *sqlx.DB) (*Tx, error) {
+	return beginTxOptions(ctx, db, nil)
+}
+
+func beginTxOptions(ctx context.Context, db *sqlx.DB, opts *sql.TxOptions) (*Tx, error) {
+	if state, ok := ctx.Value(txKey{}).(*txState); ok {
+		state.savepoints++
+		savepoint := fmt.Sprintf("sp_%d", state.savepoints)
+		if _, err := state.tx.ExecContext(ctx, "SAVEPOINT "+savepoint); err != nil {
+			return nil, err
+		}
+		return &Tx{Tx: state.tx, ctx: ctx, savepoint: savepoint}, nil
+	}
+
+	tx, err := db.BeginTxx(ctx, opts)
+	if err != nil {
+		return nil, err
+	}
+	return &Tx{Tx: tx, ctx: context.WithValue(ctx, txKey{}, &txState{tx: tx})}, nil
+}
+
+func (t *Tx) Commit() error {
+	if t.savepoint == "" {
+		return t.Tx.Commit()
+	}
+	_, err := t.Tx.ExecContext(t.ctx, "RELEASE SAVEPOINT "+t.savepoint)
+	return err
+}
+
+func (t *Tx) Rollback() error {
+	if t.savepoint == "" {
+		return t.Tx.Rollback()
+	}
+	_, err := t.Tx.ExecContext(t.ctx, "ROLLBACK TO SAVEPOINT "+t.savepoint)
+	return err
+}
+
+// querier is the part of *sqlx.DB and *sqlx.Tx the repositories query with.
+type querier interface {
+	sqlx.ExtContext
+	GetContext(ctx context.Context, dest interface{}, query string, args ...interface{}) error
+	SelectContext(ctx context.Context, dest interface{}, query string, args ...interface{}) error
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
+}
+
+// conn returns the transaction of the unit of work ctx belongs to, or db
+// outside of one.
+func conn(ctx context.Context, db *sqlx.DB) querier {
+	if state, ok := ctx.Value(txKey{}).(*txState); ok {
+		return state.tx
+	}
+	return db
+}
@@ -0,0 +1,155 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/assert"
+	sqlmock "github.com/zhashkevych/go-sqlxmock"
+	"log"
+	"testing"
+)
+
+func TestTxPostgres_WithinTx(t *testing.T) {
+	db, mock, err := sqlmock.Newx()
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer db.Close()
+
+	r := NewTxPostgres(db, TxConfig{Isolation: sql.LevelReadCommitted, MaxRetries: 1})
+	sessions := NewSessionPostgres(db)
+
+	revoke := func(ctx context.Context) error {
+		return sessions.Revoke(ctx, 1)
+	}
+
+	testTable := []struct {
+		name         string
+		fn           func(ctx context.Context) error
+		mockBehavior func()
+		wantErr      bool
+	}{
+		{
+			name: "Commit",
+			fn:   revoke,
+			mockBehavior: func() {
+				mock.ExpectBegin()
+				mock.ExpectExec(`UPDATE sessions SET revoked = true`).WithArgs(1).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectCommit()
+			},
+		},
+		{
+			name: "Rollback",
+			fn:   revoke,
+			mockBehavior: func() {
+				mock.ExpectBegin()
+				mock.ExpectExec(`UPDATE sessions SET revoked = true`).WithArgs(1).WillReturnError(assert.AnError)
+				mock.ExpectRollback()
+			},
+			wantErr: true,
+		},
+		{
+			name: "Nested",
+			fn: func(ctx context.Context) error {
+				return r.WithinTx(ctx, TxOptions{}, revoke)
+			},
+			mockBehavior: func() {
+				mock.ExpectBegin()
+				mock.ExpectExec(`SAVEPOINT sp_1`).WillReturnResult(sqlmock.NewResult(0, 0))
+				mock.ExpectExec(`UPDATE sessions SET revoked = true`).WithArgs(1).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectExec(`RELEASE SAVEPOINT sp_1`).WillReturnResult(sqlmock.NewResult(0, 0))
+				mock.ExpectCommit()
+			},
+		},
+		{
+			name: "Nested Rollback",
+			fn: func(ctx context.Context) error {
+				if err := r.WithinTx(ctx, TxOptions{}, revoke); err == nil {
+					return assert.AnError
+				}
+				return revoke(ctx)
+			},
+			mockBehavior: func() {
+				mock.ExpectBegin()
+				mock.ExpectExec(`SAVEPOINT sp_1`).WillReturnResult(sqlmock.NewResult(0, 0))
+				mock.ExpectExec(`UPDATE sessions SET revoked = true`).WithArgs(1).WillReturnError(assert.AnError)
+				mock.ExpectExec(`ROLLBACK TO SAVEPOINT sp_1`).WillReturnResult(sqlmock.NewResult(0, 0))
+				mock.ExpectExec(`UPDATE sessions SET revoked = true`).WithArgs(1).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectCommit()
+			},
+		},
+		{
+			name: "Retry",
+			fn:   revoke,
+			mockBehavior: func() {
+				mock.ExpectBegin()
+				mock.ExpectExec(`UPDATE sessions SET revoked = true`).WithArgs(1).
+					WillReturnError(&pq.Error{Code: serializationFailure})
+				mock.ExpectRollback()
+				mock.ExpectBegin()
+				mock.ExpectExec(`UPDATE sessions SET revoked = true`).WithArgs(1).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectCommit()
+			},
+		},
+		{
+			name: "Retries Exhausted",
+			fn:   revoke,
+			mockBehavior: func() {
+				for i := 0; i < 2; i++ {
+					mock.ExpectBegin()
+					mock.ExpectExec(`UPDATE sessions SET revoked = true`).WithArgs(1).
+						WillReturnError(&pq.Error{Code: deadlockDetected})
+					mock.ExpectRollback()
+				}
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			testCase.mockBehavior()
+
+			err := r.WithinTx(context.Background(), TxOptions{}, testCase.fn)
+
+			if testCase.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestParseIsolation(t *testing.T) {
+	testTable := []struct {
+		name      string
+		isolation string
+		want      sql.IsolationLevel
+		wantErr   bool
+	}{
+		{name: "Default", isolation: "", want: sql.LevelDefault},
+		{name: "Serializable", isolation: "Serializable", want: sql.LevelSerializable},
+		{name: "Repeatable read", isolation: "repeatable read", want: sql.LevelRepeatableRead},
+		{name: "Unknown", isolation: "snapshot", wantErr: true},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			got, err := ParseIsolation(testCase.isolation)
+
+			if testCase.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, testCase.want, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,15 @@
+package repository
+
+import "github.com/jmoiron/sqlx"
+
+// TxSQLite runs units of work on SQLite, with the transactions and savepoints
+// of TxPostgres. SQLite has a single isolation level: a transaction holds the
+// write lock of the database from its start, so units of work are serialized
+// and the isolation of TxOptions is ignored.
+type TxSQLite struct {
+	*TxPostgres
+}
+
+func NewTxSQLite(db *sqlx.DB, cfg TxConfig) *TxSQLite {
+	return &TxSQLite{TxPostgres: NewTxPostgres(db, cfg)}
+}
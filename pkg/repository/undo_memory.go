@@ -0,0 +1,239 @@
+package repository
+
+import (
+	"bytes"
+	"context"
+	todo "do-app"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+type UndoMemory struct {
+	store *memoryStore
+}
+
+func NewUndoMemory(store *memoryStore) *UndoMemory {
+	return &UndoMemory{store: store}
+}
+
+// Create stores the hash of an undo token for the activity record of a change
+// the user made. Expired tokens are removed at the same time.
+func (r *UndoMemory) Create(ctx context.Context, userId, activityId int, tokenHash string, expiresAt time.Time) error {
+	err := r.store.write(ctx, func(d *memoryData) error {
+		now := time.Now()
+		for hash, token := range d.undoTokens {
+			if token.ExpiresAt.Before(now) {
+				remove(d, d.undoTokens, hash)
+			}
+		}
+		if _, ok := d.activity(activityId); !ok {
+			return todo.NewError(todo.ErrConflict, "undo token refers to a missing entity")
+		}
+		if _, ok := d.undoTokens[tokenHash]; ok {
+			return todo.NewError(todo.ErrConflict, "undo token already exists")
+		}
+		put(d, d.undoTokens, tokenHash, memoryUndoToken{ActivityId: activityId, UserId: userId, ExpiresAt: expiresAt})
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("Create undo repository: %w", err)
+	}
+	return nil
+}
+
+func (d *memoryData) activity(activityId int) (todo.Activity, bool) {
+	for _, a := range d.activities {
+		if a.Id == activityId {
+			return a, true
+		}
+	}
+	return todo.Activity{}, false
+}
+
+// Undo reverts the change recorded by the activity record of the token. The
+// token has to belong to the user, who still has to be allowed to make the
+// change, and is used up by the undo. The undo fails with a conflict when the
+// entity has been changed since.
+func (r *UndoMemory) Undo(ctx context.Context, userId int, tokenHash string) error {
+	err := r.store.write(ctx, func(d *memoryData) error {
+		token, ok := d.undoTokens[tokenHash]
+		activity, found := d.activity(token.ActivityId)
+		role := d.role(userId, activity.ListId)
+		if !ok || !found || token.UserId != userId || !token.ExpiresAt.After(time.Now()) || !todo.CanEdit(role) ||
+			(activity.Entity == todo.ActivityList && activity.Action == todo.ActionDelete && role != todo.RoleOwner) {
+			return todo.NewError(todo.ErrNotFound, "undo token not found")
+		}
+		remove(d, d.undoTokens, tokenHash)
+		return undoMemoryActivity(d, userId, activity)
+	})
+	if err != nil {
+		return fmt.Errorf("Undo repository: %w", err)
+	}
+	return nil
+}
+
+func undoMemoryActivity(d *memoryData, userId int, activity todo.Activity) error {
+	id := activity.ListId
+	if activity.ItemId != nil {
+		id = *activity.ItemId
+	}
+	errChanged := todo.NewError(todo.ErrConflict, "%s has been changed since", activity.Entity)
+
+	// Any later record of the entity means someone changed it since.
+	for _, a := range d.activities {
+		same := a.ListId == id
+		if activity.ItemId != nil {
+			same = a.ItemId != nil && *a.ItemId == id
+		}
+		if a.Id > activity.Id && a.Entity == activity.Entity && same {
+			return errChanged
+		}
+	}
+
+	undo := todo.Activity{ListId: activity.ListId, ItemId: activity.ItemId, UserId: userId, Entity: activity.Entity}
+	switch activity.Action {
+	case todo.ActionUpdate:
+		reverted, err := revertMemoryChanges(d, activity.Entity, id, activity.Changes)
+		if err != nil {
+			return err
+		}
+		if reverted == nil {
+			return errChanged
+		}
+		undo.Action, undo.Changes = todo.ActionUpdate, reverted
+	case todo.ActionDelete:
+		if !undoMemoryDelete(d, activity.Entity, id) {
+			return errChanged
+		}
+		undo.Action = todo.ActionRestore
+	default:
+		return todo.NewError(todo.ErrValidation, "only updates and deletions can be undone")
+	}
+	recordMemoryActivity(d, undo)
+	return nil
+}
+
+// revertMemoryChanges writes the old values of the changes back if the entity
+// still has the new ones, and returns the changes it made. It returns nil
+// changes when the entity has other values by now.
+func revertMemoryChanges(d *memoryData, entity string, id int, changes todo.Changes) (todo.Changes, error) {
+	var snapshot []byte
+	var set func(column string, value json.RawMessage) error
+	var save func()
+	switch entity {
+	case todo.ActivityList:
+		list, ok := d.lists[id]
+		if !ok || list.DeletedAt != nil {
+			return nil, nil
+		}
+		snapshot, set = list.snapshot(), list.setColumn
+		save = func() {
+			touchList(&list)
+			put(d, d.lists, id, list)
+		}
+	default:
+		item, ok := d.items[id]
+		if !ok || item.DeletedAt != nil {
+			return nil, nil
+		}
+		snapshot, set = d.itemSnapshot(item), item.setColumn
+		save = func() {
+			touchItem(&item)
+			put(d, d.items, id, item)
+		}
+	}
+
+	var current map[string]json.RawMessage
+	if err := json.Unmarshal(snapshot, &current); err != nil {
+		return nil, err
+	}
+	reverted := make(todo.Changes)
+	for _, column := range undoColumns[entity] {
+		change, ok := changes[column]
+		if !ok {
+			continue
+		}
+		if !bytes.Equal(current[column], change.To) {
+			return nil, nil
+		}
+		reverted[column] = todo.Change{From: change.To, To: change.From}
+	}
+	if len(reverted) == 0 {
+		return reverted, nil
+	}
+	for column, change := range reverted {
+		if err := set(column, change.To); err != nil {
+			return nil, err
+		}
+	}
+	save()
+	return reverted, nil
+}
+
+// setColumn sets an undoable column of the list to a JSON value.
+func (l *memoryList) setColumn(column string, value json.RawMessage) error {
+	switch column {
+	case "title":
+		return json.Unmarshal(value, &l.Title)
+	case "description":
+		return json.Unmarshal(value, &l.Description)
+	}
+	return fmt.Errorf("unknown list column %q", column)
+}
+
+// setColumn sets an undoable column of the item to a JSON value.
+func (i *memoryItem) setColumn(column string, value json.RawMessage) error {
+	switch column {
+	case "title":
+		return json.Unmarshal(value, &i.Title)
+	case "description":
+		return json.Unmarshal(value, &i.Description)
+	case "done":
+		return json.Unmarshal(value, &i.Done)
+	case "completed_at":
+		return unmarshalTime(value, &i.CompletedAt)
+	case "due_at":
+		return unmarshalTime(value, &i.DueAt)
+	case "remind_at":
+		return unmarshalTime(value, &i.RemindAt)
+	case "priority":
+		return json.Unmarshal(value, &i.Priority)
+	case "auto_complete":
+		return json.Unmarshal(value, &i.AutoComplete)
+	}
+	return fmt.Errorf("unknown item column %q", column)
+}
+
+// unmarshalTime replaces the time dst points to instead of decoding into it,
+// since the time may be shared with a snapshot of the store.
+func unmarshalTime(value json.RawMessage, dst **time.Time) error {
+	var t *time.Time
+	if err := json.Unmarshal(value, &t); err != nil {
+		return err
+	}
+	*dst = t
+	return nil
+}
+
+// undoMemoryDelete takes the entity out of the trash, a list together with the
+// items deleted with it. It reports false if the entity is no longer in the
+// trash or, for an item, its list is.
+func undoMemoryDelete(d *memoryData, entity string, id int) bool {
+	if entity == todo.ActivityList {
+		list, ok := d.lists[id]
+		if !ok || list.DeletedAt == nil {
+			return false
+		}
+		restoreMemoryList(d, list)
+		return true
+	}
+	item, ok := d.items[id]
+	if !ok || item.DeletedAt == nil || d.lists[item.ListId].DeletedAt != nil {
+		return false
+	}
+	item.DeletedAt = nil
+	touchItem(&item)
+	put(d, d.items, id, item)
+	return true
+}
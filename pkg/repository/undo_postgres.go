@@ -0,0 +1,195 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	todo "do-app"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/jmoiron/sqlx"
+	"strings"
+	"time"
+)
+
+type UndoPostgres struct {
+	db *sqlx.DB
+}
+
+func NewUndoPostgres(db *sqlx.DB) *UndoPostgres {
+	return &UndoPostgres{db: db}
+}
+
+// Create stores the hash of an undo token for the activity record of a change
+// the user made. Expired tokens are removed at the same time.
+func (r *UndoPostgres) Create(ctx context.Context, userId, activityId int, tokenHash string, expiresAt time.Time) error {
+	query := fmt.Sprintf(`WITH expired AS (DELETE FROM %s WHERE expires_at < now())
+								 INSERT INTO %s (token_hash, activity_id, user_id, expires_at) VALUES ($1, $2, $3, $4)`,
+		undoTokensTable, undoTokensTable)
+	if _, err := conn(ctx, r.db).ExecContext(ctx, query, tokenHash, activityId, userId, expiresAt); err != nil {
+		return fmt.Errorf("Create undo repository: %w", err)
+	}
+	return nil
+}
+
+// undoColumns are the columns an undo writes back, by entity.
+var undoColumns = map[string][]string{
+	todo.ActivityList: {"title", "description"},
+	todo.ActivityItem: {"title", "description", "done", "completed_at", "due_at", "remind_at", "priority", "auto_complete"},
+}
+
+// Undo reverts the change recorded by the activity record of the token. The
+// token has to belong to the user, who still has to be allowed to make the
+// change, and is used up by the undo. The undo fails with a conflict when the
+// entity has been changed since.
+func (r *UndoPostgres) Undo(ctx context.Context, userId int, tokenHash string) error {
+	tx, err := beginTx(ctx, r.db)
+	if err != nil {
+		return fmt.Errorf("Undo repository: %w", err)
+	}
+
+	var activity todo.Activity
+	tokenQuery := fmt.Sprintf(`DELETE FROM %s ut USING %s a, %s ul
+								 WHERE a.id = ut.activity_id AND ul.list_id = a.list_id AND ul.user_id = ut.user_id
+								 AND ut.token_hash = $1 AND ut.user_id = $2 AND ut.expires_at > now() AND ul.role IN (%s)
+								 AND (a.entity <> '%s' OR a.action <> '%s' OR ul.role = '%s')
+								 RETURNING a.id, a.list_id, a.item_id, a.entity, a.action, a.changes`,
+		undoTokensTable, activitiesTable, usersListsTable, editorRoles,
+		todo.ActivityList, todo.ActionDelete, todo.RoleOwner)
+	if err = tx.GetContext(ctx, &activity, tokenQuery, tokenHash, userId); err != nil {
+		tx.Rollback()
+		if errors.Is(err, sql.ErrNoRows) {
+			err = todo.NewError(todo.ErrNotFound, "undo token not found")
+		}
+		return fmt.Errorf("Undo repository: %w", err)
+	}
+
+	if err = undoActivity(ctx, tx, userId, activity); err != nil {
+		tx.Rollback()
+		return fmt.Errorf("Undo repository: %w", err)
+	}
+	return tx.Commit()
+}
+
+func undoActivity(ctx context.Context, tx *Tx, userId int, activity todo.Activity) error {
+	id, entityCondition := activity.ListId, "list_id = $2 AND entity = $3"
+	if activity.ItemId != nil {
+		id, entityCondition = *activity.ItemId, "item_id = $2 AND entity = $3"
+	}
+	errChanged := todo.NewError(todo.ErrConflict, "%s has been changed since", activity.Entity)
+
+	// Any later record of the entity means someone changed it since.
+	var changed bool
+	laterQuery := fmt.Sprintf("SELECT EXISTS (SELECT 1 FROM %s WHERE id > $1 AND %s)", activitiesTable, entityCondition)
+	if err := tx.GetContext(ctx, &changed, laterQuery, activity.Id, id, activity.Entity); err != nil {
+		return err
+	}
+	if changed {
+		return errChanged
+	}
+
+	undo := todo.Activity{ListId: activity.ListId, ItemId: activity.ItemId, UserId: userId, Entity: activity.Entity}
+	switch activity.Action {
+	case todo.ActionUpdate:
+		reverted, err := revertChanges(ctx, tx, activity.Entity, id, activity.Changes)
+		if err != nil {
+			return err
+		}
+		if reverted == nil {
+			return errChanged
+		}
+		undo.Action, undo.Changes = todo.ActionUpdate, reverted
+	case todo.ActionDelete:
+		restored, err := undoDelete(ctx, tx, activity.Entity, id)
+		if err != nil {
+			return err
+		}
+		if !restored {
+			return errChanged
+		}
+		undo.Action = todo.ActionRestore
+	default:
+		return todo.NewError(todo.ErrValidation, "only updates and deletions can be undone")
+	}
+
+	_, err := recordActivity(ctx, tx, undo)
+	return err
+}
+
+// revertChanges writes the old values of the changes back if the entity still
+// has the new ones, and returns the changes it made. It returns nil changes
+// when the entity has other values by now.
+func revertChanges(ctx context.Context, tx *Tx, entity string, id int, changes todo.Changes) (todo.Changes, error) {
+	table := todoListsTable
+	if entity == todo.ActivityItem {
+		table = todoItemsTable
+	}
+
+	from := make(map[string]json.RawMessage)
+	to := make(map[string]json.RawMessage)
+	reverted := make(todo.Changes)
+	setValues := make([]string, 0)
+	for _, column := range undoColumns[entity] {
+		change, ok := changes[column]
+		if !ok {
+			continue
+		}
+		from[column], to[column] = change.From, change.To
+		reverted[column] = todo.Change{From: change.To, To: change.From}
+		setValues = append(setValues, fmt.Sprintf("%s = r.%s", column, column))
+	}
+	if len(setValues) == 0 {
+		return reverted, nil
+	}
+
+	fromValues, err := json.Marshal(from)
+	if err != nil {
+		return nil, err
+	}
+	toValues, err := json.Marshal(to)
+	if err != nil {
+		return nil, err
+	}
+
+	query := fmt.Sprintf(`UPDATE %s e SET %s FROM jsonb_populate_record(NULL::%s, $1::jsonb) r
+								 WHERE e.id = $2 AND e.deleted_at IS NULL AND to_jsonb(e) @> $3::jsonb`,
+		table, strings.Join(setValues, ", "), table)
+	res, err := tx.ExecContext(ctx, query, string(fromValues), id, string(toValues))
+	if err != nil {
+		return nil, err
+	}
+	affected, err := res.RowsAffected()
+	if err != nil || affected == 0 {
+		return nil, err
+	}
+	return reverted, nil
+}
+
+// undoDelete takes the entity out of the trash, a list together with the items
+// deleted with it. It reports false if the entity is no longer in the trash
+// or, for an item, its list is.
+func undoDelete(ctx context.Context, tx *Tx, entity string, id int) (bool, error) {
+	if entity == todo.ActivityList {
+		var deletedAt time.Time
+		lockQuery := fmt.Sprintf("SELECT deleted_at FROM %s WHERE id = $1 AND deleted_at IS NOT NULL FOR UPDATE",
+			todoListsTable)
+		if err := tx.GetContext(ctx, &deletedAt, lockQuery, id); err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				return false, nil
+			}
+			return false, err
+		}
+		return true, restoreList(ctx, tx, id, deletedAt)
+	}
+
+	query := fmt.Sprintf(`UPDATE %s ti SET deleted_at = NULL FROM %s li, %s tl
+								 WHERE ti.id = li.item_id AND tl.id = li.list_id AND ti.id = $1
+								 AND ti.deleted_at IS NOT NULL AND tl.deleted_at IS NULL`,
+		todoItemsTable, listsItemsTable, todoListsTable)
+	res, err := tx.ExecContext(ctx, query, id)
+	if err != nil {
+		return false, err
+	}
+	affected, err := res.RowsAffected()
+	return affected > 0, err
+}
@@ -0,0 +1,143 @@
+package repository
+
+import (
+	"context"
+	todo "do-app"
+	"github.com/stretchr/testify/assert"
+	sqlmock "github.com/zhashkevych/go-sqlxmock"
+	"log"
+	"testing"
+	"time"
+)
+
+func TestUndoPostgres_Create(t *testing.T) {
+	db, mock, err := sqlmock.Newx()
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer db.Close()
+
+	r := NewUndoPostgres(db)
+
+	expiresAt := time.Date(2030, 1, 7, 9, 10, 0, 0, time.UTC)
+	mock.ExpectExec(`WITH expired AS \(DELETE FROM undo_tokens WHERE expires_at < now\(\)\) INSERT INTO undo_tokens`).
+		WithArgs("hash", 8, 1, expiresAt).WillReturnResult(sqlmock.NewResult(1, 1))
+
+	assert.NoError(t, r.Create(context.Background(), 1, 8, "hash", expiresAt))
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestUndoPostgres_Undo(t *testing.T) {
+	db, mock, err := sqlmock.Newx()
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer db.Close()
+
+	r := NewUndoPostgres(db)
+
+	columns := []string{"id", "list_id", "item_id", "entity", "action", "changes"}
+	deletedAt := time.Date(2030, 1, 7, 9, 0, 0, 0, time.UTC)
+
+	expectToken := func(rows *sqlmock.Rows) {
+		mock.ExpectBegin()
+		mock.ExpectQuery(`DELETE FROM undo_tokens ut USING activities a, users_lists ul (.+) `+
+			`RETURNING a.id, a.list_id, a.item_id, a.entity, a.action, a.changes`).
+			WithArgs("hash", 1).WillReturnRows(rows)
+	}
+	expectLater := func(condition string, id int, entity string, later bool) {
+		mock.ExpectQuery(`SELECT EXISTS \(SELECT 1 FROM activities WHERE id > \$1 AND `+condition+`\)`).
+			WithArgs(8, id, entity).WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(later))
+	}
+
+	testTable := []struct {
+		name         string
+		mockBehavior func()
+		wantKind     error
+	}{
+		{
+			name: "Update",
+			mockBehavior: func() {
+				expectToken(sqlmock.NewRows(columns).
+					AddRow(8, 2, 4, "item", "update", `{"title": {"from": "milk", "to": "oat milk"}, "search": {"from": "a", "to": "b"}}`))
+				expectLater(`item_id = \$2 AND entity = \$3`, 4, "item", false)
+				mock.ExpectExec(`UPDATE todo_items e SET title = r.title FROM jsonb_populate_record\(NULL::todo_items, \$1::jsonb\) r `+
+					`WHERE e.id = \$2 AND e.deleted_at IS NULL AND to_jsonb\(e\) @> \$3::jsonb`).
+					WithArgs(`{"title":"milk"}`, 4, `{"title":"oat milk"}`).WillReturnResult(sqlmock.NewResult(0, 1))
+				expectActivity(mock, 2, 1, "item", "update").WillReturnRows(activityRow(9))
+				mock.ExpectCommit()
+			},
+		},
+		{
+			name: "Updated since",
+			mockBehavior: func() {
+				expectToken(sqlmock.NewRows(columns).
+					AddRow(8, 2, nil, "list", "update", `{"title": {"from": "milk run", "to": "groceries"}}`))
+				expectLater(`list_id = \$2 AND entity = \$3`, 2, "list", false)
+				mock.ExpectExec(`UPDATE todo_lists e SET title = r.title`).
+					WithArgs(`{"title":"milk run"}`, 2, `{"title":"groceries"}`).WillReturnResult(sqlmock.NewResult(0, 0))
+				mock.ExpectRollback()
+			},
+			wantKind: todo.ErrConflict,
+		},
+		{
+			name: "Delete item",
+			mockBehavior: func() {
+				expectToken(sqlmock.NewRows(columns).AddRow(8, 2, 4, "item", "delete", nil))
+				expectLater(`item_id = \$2 AND entity = \$3`, 4, "item", false)
+				mock.ExpectExec(`UPDATE todo_items ti SET deleted_at = NULL FROM lists_items li, todo_lists tl (.+) ` +
+					`AND ti.deleted_at IS NOT NULL AND tl.deleted_at IS NULL`).
+					WithArgs(4).WillReturnResult(sqlmock.NewResult(0, 1))
+				expectActivity(mock, 2, 1, "item", "restore").WillReturnRows(activityRow(9))
+				mock.ExpectCommit()
+			},
+		},
+		{
+			name: "Delete list",
+			mockBehavior: func() {
+				expectToken(sqlmock.NewRows(columns).AddRow(8, 2, nil, "list", "delete", nil))
+				expectLater(`list_id = \$2 AND entity = \$3`, 2, "list", false)
+				mock.ExpectQuery(`SELECT deleted_at FROM todo_lists WHERE id = \$1 AND deleted_at IS NOT NULL FOR UPDATE`).
+					WithArgs(2).WillReturnRows(sqlmock.NewRows([]string{"deleted_at"}).AddRow(deletedAt))
+				mock.ExpectExec(`UPDATE todo_items ti SET deleted_at = NULL FROM lists_items li`).
+					WithArgs(2, deletedAt).WillReturnResult(sqlmock.NewResult(0, 3))
+				mock.ExpectExec(`UPDATE todo_lists SET deleted_at = NULL WHERE id = \$1`).
+					WithArgs(2).WillReturnResult(sqlmock.NewResult(0, 1))
+				expectActivity(mock, 2, 1, "list", "restore").WillReturnRows(activityRow(9))
+				mock.ExpectCommit()
+			},
+		},
+		{
+			name: "Restored since",
+			mockBehavior: func() {
+				expectToken(sqlmock.NewRows(columns).AddRow(8, 2, 4, "item", "delete", nil))
+				expectLater(`item_id = \$2 AND entity = \$3`, 4, "item", true)
+				mock.ExpectRollback()
+			},
+			wantKind: todo.ErrConflict,
+		},
+		{
+			name: "Unknown token",
+			mockBehavior: func() {
+				expectToken(sqlmock.NewRows(columns))
+				mock.ExpectRollback()
+			},
+			wantKind: todo.ErrNotFound,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			testCase.mockBehavior()
+
+			err := r.Undo(context.Background(), 1, "hash")
+
+			if testCase.wantKind != nil {
+				assert.ErrorIs(t, err, testCase.wantKind)
+			} else {
+				assert.NoError(t, err)
+			}
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
@@ -0,0 +1,231 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	todo "do-app"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/jmoiron/sqlx"
+	"reflect"
+	"strings"
+	"time"
+)
+
+type UndoSQLite struct {
+	db *sqlx.DB
+}
+
+func NewUndoSQLite(db *sqlx.DB) *UndoSQLite {
+	return &UndoSQLite{db: db}
+}
+
+// Create stores the hash of an undo token for the activity record of a change
+// the user made. Expired tokens are removed at the same time.
+func (r *UndoSQLite) Create(ctx context.Context, userId, activityId int, tokenHash string, expiresAt time.Time) error {
+	tx, err := beginTx(ctx, r.db)
+	if err != nil {
+		return fmt.Errorf("Create undo repository: %w", err)
+	}
+
+	expiredQuery := fmt.Sprintf("DELETE FROM %s WHERE expires_at < ?1", undoTokensTable)
+	if _, err = tx.ExecContext(ctx, expiredQuery, sqliteTime(time.Now())); err != nil {
+		tx.Rollback()
+		return fmt.Errorf("Create undo repository: %w", err)
+	}
+
+	query := fmt.Sprintf("INSERT INTO %s (token_hash, activity_id, user_id, expires_at) VALUES (?1, ?2, ?3, ?4)",
+		undoTokensTable)
+	if _, err = tx.ExecContext(ctx, query, tokenHash, activityId, userId, sqliteTime(expiresAt)); err != nil {
+		tx.Rollback()
+		return fmt.Errorf("Create undo repository: %w", err)
+	}
+	return tx.Commit()
+}
+
+// Undo reverts the change recorded by the activity record of the token. The
+// token has to belong to the user, who still has to be allowed to make the
+// change, and is used up by the undo. The undo fails with a conflict when the
+// entity has been changed since.
+func (r *UndoSQLite) Undo(ctx context.Context, userId int, tokenHash string) error {
+	tx, err := beginTx(ctx, r.db)
+	if err != nil {
+		return fmt.Errorf("Undo repository: %w", err)
+	}
+
+	var token struct {
+		todo.Activity
+		TokenId int `db:"token_id"`
+	}
+	tokenQuery := fmt.Sprintf(`SELECT ut.id AS token_id, a.id, a.list_id, a.item_id, a.entity, a.action, a.changes
+								 FROM %s ut INNER JOIN %s a on a.id = ut.activity_id
+								 INNER JOIN %s ul on ul.list_id = a.list_id AND ul.user_id = ut.user_id
+								 WHERE ut.token_hash = ?1 AND ut.user_id = ?2 AND ut.expires_at > ?3 AND ul.role IN (%s)
+								 AND (a.entity <> '%s' OR a.action <> '%s' OR ul.role = '%s')`,
+		undoTokensTable, activitiesTable, usersListsTable, editorRoles,
+		todo.ActivityList, todo.ActionDelete, todo.RoleOwner)
+	if err = tx.GetContext(ctx, &token, tokenQuery, tokenHash, userId, sqliteTime(time.Now())); err != nil {
+		tx.Rollback()
+		if errors.Is(err, sql.ErrNoRows) {
+			err = todo.NewError(todo.ErrNotFound, "undo token not found")
+		}
+		return fmt.Errorf("Undo repository: %w", err)
+	}
+
+	deleteQuery := fmt.Sprintf("DELETE FROM %s WHERE id = ?1", undoTokensTable)
+	if _, err = tx.ExecContext(ctx, deleteQuery, token.TokenId); err != nil {
+		tx.Rollback()
+		return fmt.Errorf("Undo repository: %w", err)
+	}
+
+	if err = undoActivitySQLite(ctx, tx, userId, token.Activity); err != nil {
+		tx.Rollback()
+		return fmt.Errorf("Undo repository: %w", err)
+	}
+	return tx.Commit()
+}
+
+func undoActivitySQLite(ctx context.Context, tx *Tx, userId int, activity todo.Activity) error {
+	id, entityCondition := activity.ListId, "list_id = ?2 AND entity = ?3"
+	if activity.ItemId != nil {
+		id, entityCondition = *activity.ItemId, "item_id = ?2 AND entity = ?3"
+	}
+	errChanged := todo.NewError(todo.ErrConflict, "%s has been changed since", activity.Entity)
+
+	// Any later record of the entity means someone changed it since.
+	var changed bool
+	laterQuery := fmt.Sprintf("SELECT EXISTS (SELECT 1 FROM %s WHERE id > ?1 AND %s)", activitiesTable, entityCondition)
+	if err := tx.GetContext(ctx, &changed, laterQuery, activity.Id, id, activity.Entity); err != nil {
+		return err
+	}
+	if changed {
+		return errChanged
+	}
+
+	undo := todo.Activity{ListId: activity.ListId, ItemId: activity.ItemId, UserId: userId, Entity: activity.Entity}
+	switch activity.Action {
+	case todo.ActionUpdate:
+		reverted, err := revertChangesSQLite(ctx, tx, activity.Entity, id, activity.Changes)
+		if err != nil {
+			return err
+		}
+		if reverted == nil {
+			return errChanged
+		}
+		undo.Action, undo.Changes = todo.ActionUpdate, reverted
+	case todo.ActionDelete:
+		restored, err := undoDeleteSQLite(ctx, tx, activity.Entity, id)
+		if err != nil {
+			return err
+		}
+		if !restored {
+			return errChanged
+		}
+		undo.Action = todo.ActionRestore
+	default:
+		return todo.NewError(todo.ErrValidation, "only updates and deletions can be undone")
+	}
+
+	_, err := recordActivity(ctx, tx, undo)
+	return err
+}
+
+// sqliteTimeColumns are the undoable columns that hold times. Snapshots have
+// them in JSON form, so they are converted back when they are reverted.
+var sqliteTimeColumns = map[string]bool{
+	"completed_at": true,
+	"due_at":       true,
+	"remind_at":    true,
+}
+
+// revertChangesSQLite writes the old values of the changes back if the entity
+// still has the new ones, and returns the changes it made. It returns nil
+// changes when the entity has other values by now.
+func revertChangesSQLite(ctx context.Context, tx *Tx, entity string, id int, changes todo.Changes) (todo.Changes, error) {
+	table := todoListsTable
+	var current []byte
+	var err error
+	if entity == todo.ActivityItem {
+		table = todoItemsTable
+		_, current, err = itemSnapshotSQLite(ctx, tx, id)
+	} else {
+		current, err = listSnapshotSQLite(ctx, tx, id)
+	}
+	if errors.Is(err, todo.ErrNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var values map[string]interface{}
+	if err = json.Unmarshal(current, &values); err != nil {
+		return nil, err
+	}
+
+	from := make(map[string]json.RawMessage)
+	reverted := make(todo.Changes)
+	setValues := make([]string, 0)
+	for _, column := range undoColumns[entity] {
+		change, ok := changes[column]
+		if !ok {
+			continue
+		}
+		var to interface{}
+		if err = json.Unmarshal(change.To, &to); err != nil {
+			return nil, err
+		}
+		if !reflect.DeepEqual(values[column], to) {
+			return nil, nil
+		}
+		from[column] = change.From
+		reverted[column] = todo.Change{From: change.To, To: change.From}
+
+		value := fmt.Sprintf("json_extract(?1, '$.%s')", column)
+		if sqliteTimeColumns[column] {
+			value = fmt.Sprintf("strftime('%%Y-%%m-%%d %%H:%%M:%%f000000+00:00', %s)", value)
+		}
+		setValues = append(setValues, fmt.Sprintf("%s = %s", column, value))
+	}
+	if len(setValues) == 0 {
+		return reverted, nil
+	}
+
+	fromValues, err := json.Marshal(from)
+	if err != nil {
+		return nil, err
+	}
+	query := fmt.Sprintf("UPDATE %s SET %s WHERE id = ?2", table, strings.Join(setValues, ", "))
+	if _, err = tx.ExecContext(ctx, query, string(fromValues), id); err != nil {
+		return nil, err
+	}
+	return reverted, nil
+}
+
+// undoDeleteSQLite takes the entity out of the trash, a list together with the
+// items deleted with it. It reports false if the entity is no longer in the
+// trash or, for an item, its list is.
+func undoDeleteSQLite(ctx context.Context, tx *Tx, entity string, id int) (bool, error) {
+	if entity == todo.ActivityList {
+		var deletedAt time.Time
+		deletedQuery := fmt.Sprintf("SELECT deleted_at FROM %s WHERE id = ?1 AND deleted_at IS NOT NULL", todoListsTable)
+		if err := tx.GetContext(ctx, &deletedAt, deletedQuery, id); err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				return false, nil
+			}
+			return false, err
+		}
+		return true, restoreListSQLite(ctx, tx, id, deletedAt)
+	}
+
+	query := fmt.Sprintf(`UPDATE %s SET deleted_at = NULL WHERE id = ?1 AND deleted_at IS NOT NULL
+								 AND EXISTS (SELECT 1 FROM %s li INNER JOIN %s tl on tl.id = li.list_id
+								 WHERE li.item_id = ?1 AND tl.deleted_at IS NULL)`,
+		todoItemsTable, listsItemsTable, todoListsTable)
+	res, err := tx.ExecContext(ctx, query, id)
+	if err != nil {
+		return false, err
+	}
+	affected, err := res.RowsAffected()
+	return affected > 0, err
+}
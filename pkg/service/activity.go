@@ -0,0 +1,44 @@
+package service
+
+import (
+	"context"
+	todo "do-app"
+	"do-app/pkg/repository"
+	"fmt"
+)
+
+type ActivityService struct {
+	repo       repository.Activity
+	itemRepo   repository.TodoItems
+	memberRepo repository.ListMembers
+}
+
+func NewActivityService(repo repository.Activity, itemRepo repository.TodoItems,
+	memberRepo repository.ListMembers) *ActivityService {
+	return &ActivityService{repo: repo, itemRepo: itemRepo, memberRepo: memberRepo}
+}
+
+var activitySorts = []string{todo.SortCreated}
+
+// GetByList returns the activity of a list the user is a member of, including
+// the activity of its items.
+func (s *ActivityService) GetByList(ctx context.Context, userId, listId int, page todo.PageRequest) ([]todo.Activity, string, error) {
+	if err := page.Validate(activitySorts...); err != nil {
+		return nil, "", err
+	}
+	if _, err := s.memberRepo.GetRole(ctx, userId, listId); err != nil {
+		return nil, "", fmt.Errorf("GetByList activity service: %w", err)
+	}
+	return s.repo.GetByList(ctx, listId, page)
+}
+
+// GetByItem returns the history of an item the user can see.
+func (s *ActivityService) GetByItem(ctx context.Context, userId, itemId int, page todo.PageRequest) ([]todo.Activity, string, error) {
+	if err := page.Validate(activitySorts...); err != nil {
+		return nil, "", err
+	}
+	if _, err := s.itemRepo.GetById(ctx, userId, itemId); err != nil {
+		return nil, "", fmt.Errorf("GetByItem activity service: %w", err)
+	}
+	return s.repo.GetByItem(ctx, itemId, page)
+}
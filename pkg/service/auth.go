@@ -1,63 +1,160 @@
 package service
 
 import (
-	"crypto/sha1"
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
 	todo "do-app"
 	"do-app/pkg/repository"
+	"encoding/hex"
+	"errors"
 	"fmt"
 	"github.com/golang-jwt/jwt/v5"
+	"github.com/sirupsen/logrus"
 	"time"
 )
 
 const (
-	salt       = "jvhbfdvoivdfjn2343"
-	signingKey = "iubvrdie0werfsdfjk'r9983i"
-	tokenTTL   = 12 * time.Hour
+	defaultAccessTokenTTL  = 15 * time.Minute
+	defaultRefreshTokenTTL = 30 * 24 * time.Hour
 )
 
+var (
+	errInvalidCredentials  = todo.NewError(todo.ErrUnauthorized, "invalid username or password")
+	errInvalidRefreshToken = todo.NewError(todo.ErrUnauthorized, "invalid refresh token")
+)
+
+type AuthConfig struct {
+	AccessTokenTTL  time.Duration
+	RefreshTokenTTL time.Duration
+	Keys            *KeySet
+}
+
 type AuthService struct {
-	repo repository.Authorization
+	repo     repository.Authorization
+	sessions repository.Sessions
+	cfg      AuthConfig
 }
 
 type tokenClaims struct {
 	jwt.RegisteredClaims
-	UserId int `json:"user_id"`
+	UserId    int `json:"user_id"`
+	SessionId int `json:"sid"`
 }
 
-func NewAuthService(repo repository.Authorization) *AuthService {
-	return &AuthService{repo}
+func NewAuthService(repo repository.Authorization, sessions repository.Sessions, cfg AuthConfig) *AuthService {
+	if cfg.AccessTokenTTL == 0 {
+		cfg.AccessTokenTTL = defaultAccessTokenTTL
+	}
+	if cfg.RefreshTokenTTL == 0 {
+		cfg.RefreshTokenTTL = defaultRefreshTokenTTL
+	}
+	return &AuthService{repo: repo, sessions: sessions, cfg: cfg}
 }
 
-func (s *AuthService) CreateUser(user todo.User) (int, error) {
-	user.Password = generatePasswordHash(user.Password)
-	return s.repo.CreateUser(user)
+func (s *AuthService) CreateUser(ctx context.Context, user todo.User) (int, error) {
+	hash, algo, err := generatePasswordHash(user.Password)
+	if err != nil {
+		return 0, err
+	}
+	user.Password, user.PasswordAlgo = hash, algo
+	return s.repo.CreateUser(ctx, user)
 }
 
-func (s *AuthService) GenerateToken(username, password string) (string, error) {
-	user, err := s.repo.GetUser(username, generatePasswordHash(password))
+func (s *AuthService) GenerateToken(ctx context.Context, username, password string) (todo.Tokens, error) {
+	// An unknown username and a wrong password get the same answer, so that
+	// sign-in cannot be used to find out which usernames exist.
+	user, err := s.repo.GetUser(ctx, username)
+	if errors.Is(err, todo.ErrNotFound) {
+		return todo.Tokens{}, fmt.Errorf("generate token: %w", errInvalidCredentials)
+	}
 	if err != nil {
+		return todo.Tokens{}, fmt.Errorf("generate token: %w", err)
+	}
 
-		return "", fmt.Errorf("generate token: %w", err)
+	ok, rehash := verifyPassword(password, user.Password, user.PasswordAlgo)
+	if !ok {
+		return todo.Tokens{}, fmt.Errorf("generate token: %w", errInvalidCredentials)
+	}
+	if rehash {
+		s.upgradePasswordHash(ctx, user.Id, password)
 	}
 
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, &tokenClaims{
-		jwt.RegisteredClaims{
-			ExpiresAt: &jwt.NumericDate{time.Now().Add(tokenTTL)},
-			IssuedAt:  &jwt.NumericDate{time.Now()},
-		},
-		user.Id,
+	refreshToken, err := newRandomToken()
+	if err != nil {
+		return todo.Tokens{}, fmt.Errorf("generate token: %w", err)
+	}
+
+	sessionId, err := s.sessions.Create(ctx, todo.Session{
+		UserId:           user.Id,
+		RefreshTokenHash: hashToken(refreshToken),
+		ExpiresAt:        time.Now().Add(s.cfg.RefreshTokenTTL),
 	})
+	if err != nil {
+		return todo.Tokens{}, fmt.Errorf("generate token: %w", err)
+	}
+
+	accessToken, err := s.newAccessToken(user.Id, sessionId)
+	if err != nil {
+		return todo.Tokens{}, fmt.Errorf("generate token: %w", err)
+	}
 
-	return token.SignedString([]byte(signingKey))
+	return todo.Tokens{AccessToken: accessToken, RefreshToken: refreshToken}, nil
 }
 
-func (s *AuthService) ParseToken(tokenSting string) (int, error) {
-	token, err := jwt.ParseWithClaims(tokenSting, &tokenClaims{}, func(token *jwt.Token) (interface{}, error) {
-		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-			return nil, fmt.Errorf("invalid signing method")
-		}
-		return []byte(signingKey), nil
-	})
+// RefreshToken exchanges a valid refresh token for a new token pair. The
+// presented refresh token is invalidated, so every refresh token is single use.
+func (s *AuthService) RefreshToken(ctx context.Context, refreshToken string) (todo.Tokens, error) {
+	oldHash := hashToken(refreshToken)
+	session, err := s.sessions.GetByRefreshToken(ctx, oldHash)
+	if errors.Is(err, todo.ErrNotFound) {
+		return todo.Tokens{}, fmt.Errorf("refresh token: %w", errInvalidRefreshToken)
+	}
+	if err != nil {
+		return todo.Tokens{}, fmt.Errorf("refresh token: %w", err)
+	}
+	if session.Revoked || time.Now().After(session.ExpiresAt) {
+		return todo.Tokens{}, fmt.Errorf("refresh token: %w", errInvalidRefreshToken)
+	}
+
+	newToken, err := newRandomToken()
+	if err != nil {
+		return todo.Tokens{}, fmt.Errorf("refresh token: %w", err)
+	}
+
+	// The token has been used by a concurrent refresh if the session no longer
+	// has it.
+	err = s.sessions.Rotate(ctx, session.Id, oldHash, hashToken(newToken), time.Now().Add(s.cfg.RefreshTokenTTL))
+	if errors.Is(err, todo.ErrConflict) {
+		return todo.Tokens{}, fmt.Errorf("refresh token: %w", errInvalidRefreshToken)
+	}
+	if err != nil {
+		return todo.Tokens{}, fmt.Errorf("refresh token: %w", err)
+	}
+
+	accessToken, err := s.newAccessToken(session.UserId, session.Id)
+	if err != nil {
+		return todo.Tokens{}, fmt.Errorf("refresh token: %w", err)
+	}
+
+	return todo.Tokens{AccessToken: accessToken, RefreshToken: newToken}, nil
+}
+
+// SignOut revokes the session the refresh token belongs to. Access tokens
+// issued for that session stop being accepted by ParseToken immediately.
+func (s *AuthService) SignOut(ctx context.Context, refreshToken string) error {
+	session, err := s.sessions.GetByRefreshToken(ctx, hashToken(refreshToken))
+	if errors.Is(err, todo.ErrNotFound) {
+		return fmt.Errorf("sign out: %w", errInvalidRefreshToken)
+	}
+	if err != nil {
+		return fmt.Errorf("sign out: %w", err)
+	}
+	return s.sessions.Revoke(ctx, session.Id)
+}
+
+func (s *AuthService) ParseToken(ctx context.Context, tokenSting string) (int, error) {
+	token, err := jwt.ParseWithClaims(tokenSting, &tokenClaims{}, s.cfg.Keys.keyFunc)
 	if err != nil {
 		return 0, fmt.Errorf("Parse token: %w", err)
 	}
@@ -66,12 +163,53 @@ func (s *AuthService) ParseToken(tokenSting string) (int, error) {
 	if !ok {
 		return 0, fmt.Errorf("token claim are not of type *tokenClaims")
 	}
+
+	active, err := s.sessions.IsActive(ctx, claims.SessionId)
+	if err != nil {
+		return 0, fmt.Errorf("Parse token: %w", err)
+	}
+	if !active {
+		return 0, fmt.Errorf("Parse token: session revoked")
+	}
 	return claims.UserId, nil
 }
 
-func generatePasswordHash(password string) string {
-	hash := sha1.New()
-	hash.Write([]byte(password))
+// upgradePasswordHash replaces an outdated password hash. Failures are only
+// logged since the user has already been authenticated.
+func (s *AuthService) upgradePasswordHash(ctx context.Context, userId int, password string) {
+	hash, algo, err := generatePasswordHash(password)
+	if err == nil {
+		err = s.repo.UpdatePasswordHash(ctx, userId, hash, algo)
+	}
+	if err != nil {
+		logrus.Errorf("upgrade password hash of user %d: %s", userId, err.Error())
+	}
+}
+
+func (s *AuthService) JWKS() todo.JSONWebKeySet {
+	return s.cfg.Keys.JWKS()
+}
+
+func (s *AuthService) newAccessToken(userId, sessionId int) (string, error) {
+	return s.cfg.Keys.sign(&tokenClaims{
+		jwt.RegisteredClaims{
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(s.cfg.AccessTokenTTL)),
+			IssuedAt:  jwt.NewNumericDate(time.Now()),
+		},
+		userId,
+		sessionId,
+	})
+}
+
+func newRandomToken() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
 
-	return fmt.Sprintf("%x", hash.Sum([]byte(salt)))
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
 }
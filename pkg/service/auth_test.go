@@ -0,0 +1,202 @@
+package service
+
+import (
+	"context"
+	todo "do-app"
+	"do-app/pkg/repository"
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/crypto/bcrypt"
+	"testing"
+)
+
+func newTestAuthService(t *testing.T, repos *repository.Repository) *AuthService {
+	keys, err := NewKeySet("hs", []SigningKeyConfig{{Kid: "hs", Algorithm: algorithmHS256, Secret: "secret"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return NewAuthService(repos.Authorization, repos.Sessions, AuthConfig{Keys: keys})
+}
+
+func TestAuthService_GenerateToken(t *testing.T) {
+	cheap, err := bcrypt.GenerateFromPassword([]byte("qwerty"), bcrypt.MinCost)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	testTable := []struct {
+		name         string
+		hash         string
+		algo         string
+		username     string
+		password     string
+		expectErr    error
+		expectRehash bool
+	}{
+		{
+			name:         "Legacy hash is upgraded",
+			hash:         generateLegacyPasswordHash("qwerty"),
+			algo:         passwordAlgoSHA1,
+			username:     "alice",
+			password:     "qwerty",
+			expectRehash: true,
+		},
+		{
+			name:         "Cheap bcrypt hash is upgraded",
+			hash:         string(cheap),
+			algo:         passwordAlgoBcrypt,
+			username:     "alice",
+			password:     "qwerty",
+			expectRehash: true,
+		},
+		{
+			name:      "Wrong password keeps the legacy hash",
+			hash:      generateLegacyPasswordHash("qwerty"),
+			algo:      passwordAlgoSHA1,
+			username:  "alice",
+			password:  "qwerty1",
+			expectErr: todo.ErrUnauthorized,
+		},
+		{
+			name:      "Unknown user",
+			hash:      generateLegacyPasswordHash("qwerty"),
+			algo:      passwordAlgoSHA1,
+			username:  "bob",
+			password:  "qwerty",
+			expectErr: todo.ErrUnauthorized,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			ctx := context.Background()
+			repos := repository.NewMemoryRepository()
+			userId, err := repos.CreateUser(ctx, todo.User{Name: "alice", Username: "alice", Password: testCase.hash,
+				PasswordAlgo: testCase.algo})
+			if err != nil {
+				t.Fatal(err)
+			}
+			s := newTestAuthService(t, repos)
+
+			tokens, err := s.GenerateToken(ctx, testCase.username, testCase.password)
+
+			user, getErr := repos.GetUser(ctx, "alice")
+			assert.NoError(t, getErr)
+			if testCase.expectErr != nil {
+				assert.ErrorIs(t, err, testCase.expectErr)
+				assert.Equal(t, testCase.hash, user.Password)
+				return
+			}
+			assert.NoError(t, err)
+			parsedId, err := s.ParseToken(ctx, tokens.AccessToken)
+			assert.NoError(t, err)
+			assert.Equal(t, userId, parsedId)
+
+			if testCase.expectRehash {
+				assert.NotEqual(t, testCase.hash, user.Password)
+				assert.Equal(t, passwordAlgoBcrypt, user.PasswordAlgo)
+				ok, rehash := verifyPassword(testCase.password, user.Password, user.PasswordAlgo)
+				assert.True(t, ok)
+				assert.False(t, rehash)
+			} else {
+				assert.Equal(t, testCase.hash, user.Password)
+			}
+
+			// The upgraded hash is used from the next sign in on.
+			_, err = s.GenerateToken(ctx, testCase.username, testCase.password)
+			assert.NoError(t, err)
+			again, err := repos.GetUser(ctx, "alice")
+			assert.NoError(t, err)
+			assert.Equal(t, user.Password, again.Password)
+		})
+	}
+}
+
+func TestAuthService_RefreshToken(t *testing.T) {
+	testTable := []struct {
+		name      string
+		prepare   func(t *testing.T, s *AuthService, token string) (context.Context, string)
+		expectErr error
+	}{
+		{
+			name: "OK",
+			prepare: func(t *testing.T, s *AuthService, token string) (context.Context, string) {
+				return context.Background(), token
+			},
+		},
+		{
+			name: "Used token",
+			prepare: func(t *testing.T, s *AuthService, token string) (context.Context, string) {
+				if _, err := s.RefreshToken(context.Background(), token); err != nil {
+					t.Fatal(err)
+				}
+				return context.Background(), token
+			},
+			expectErr: todo.ErrUnauthorized,
+		},
+		{
+			name: "Signed out",
+			prepare: func(t *testing.T, s *AuthService, token string) (context.Context, string) {
+				if err := s.SignOut(context.Background(), token); err != nil {
+					t.Fatal(err)
+				}
+				return context.Background(), token
+			},
+			expectErr: todo.ErrUnauthorized,
+		},
+		{
+			name: "Unknown token",
+			prepare: func(t *testing.T, s *AuthService, token string) (context.Context, string) {
+				return context.Background(), "unknown"
+			},
+			expectErr: todo.ErrUnauthorized,
+		},
+		{
+			name: "Canceled request",
+			prepare: func(t *testing.T, s *AuthService, token string) (context.Context, string) {
+				ctx, cancel := context.WithCancel(context.Background())
+				cancel()
+				return ctx, token
+			},
+			expectErr: context.Canceled,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			repos := repository.NewMemoryRepository()
+			hash, algo, err := generatePasswordHash("qwerty")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if _, err = repos.CreateUser(context.Background(), todo.User{Name: "alice", Username: "alice", Password: hash,
+				PasswordAlgo: algo}); err != nil {
+				t.Fatal(err)
+			}
+			s := newTestAuthService(t, repos)
+			signedIn, err := s.GenerateToken(context.Background(), "alice", "qwerty")
+			if err != nil {
+				t.Fatal(err)
+			}
+			ctx, token := testCase.prepare(t, s, signedIn.RefreshToken)
+
+			tokens, err := s.RefreshToken(ctx, token)
+			signOutErr := s.SignOut(ctx, token)
+
+			if testCase.expectErr == nil {
+				assert.NoError(t, err)
+				assert.NotEqual(t, token, tokens.RefreshToken)
+				// Signing out needs the current refresh token.
+				assert.ErrorIs(t, signOutErr, todo.ErrUnauthorized)
+				assert.NoError(t, s.SignOut(ctx, tokens.RefreshToken))
+				return
+			}
+			assert.ErrorIs(t, err, testCase.expectErr)
+			if testCase.expectErr != todo.ErrUnauthorized {
+				// Failures other than a bad token must not look like one.
+				assert.NotErrorIs(t, err, todo.ErrUnauthorized)
+				assert.ErrorIs(t, signOutErr, testCase.expectErr)
+				assert.NotErrorIs(t, signOutErr, todo.ErrUnauthorized)
+			}
+		})
+	}
+}
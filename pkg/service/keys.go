@@ -0,0 +1,159 @@
+package service
+
+import (
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/x509"
+	todo "do-app"
+	"encoding/base64"
+	"encoding/pem"
+	"fmt"
+	"github.com/golang-jwt/jwt/v5"
+	"math/big"
+	"sort"
+)
+
+const (
+	algorithmHS256 = "HS256"
+	algorithmRS256 = "RS256"
+	algorithmEdDSA = "EdDSA"
+)
+
+// SigningKeyConfig describes one key of the key set. HS256 keys use Secret,
+// RS256 and EdDSA keys use a PEM encoded private key.
+type SigningKeyConfig struct {
+	Kid           string
+	Algorithm     string
+	Secret        string
+	PrivateKeyPEM []byte
+}
+
+type signingKey struct {
+	kid       string
+	method    jwt.SigningMethod
+	signKey   interface{}
+	verifyKey interface{}
+}
+
+// KeySet holds every key that is accepted for verification. Only the active
+// key is used to sign new tokens, so old keys can stay in the set for the
+// duration of a rotation window.
+type KeySet struct {
+	active *signingKey
+	keys   map[string]*signingKey
+}
+
+func NewKeySet(activeKid string, configs []SigningKeyConfig) (*KeySet, error) {
+	set := &KeySet{keys: make(map[string]*signingKey, len(configs))}
+	for _, cfg := range configs {
+		if cfg.Kid == "" {
+			return nil, fmt.Errorf("key set: key without kid")
+		}
+		if _, ok := set.keys[cfg.Kid]; ok {
+			return nil, fmt.Errorf("key set: duplicate kid %q", cfg.Kid)
+		}
+		key, err := newSigningKey(cfg)
+		if err != nil {
+			return nil, fmt.Errorf("key set: %w", err)
+		}
+		set.keys[cfg.Kid] = key
+	}
+
+	active, ok := set.keys[activeKid]
+	if !ok {
+		return nil, fmt.Errorf("key set: active key %q not found", activeKid)
+	}
+	set.active = active
+	return set, nil
+}
+
+func newSigningKey(cfg SigningKeyConfig) (*signingKey, error) {
+	switch cfg.Algorithm {
+	case algorithmHS256:
+		if cfg.Secret == "" {
+			return nil, fmt.Errorf("key %q: empty secret", cfg.Kid)
+		}
+		secret := []byte(cfg.Secret)
+		return &signingKey{kid: cfg.Kid, method: jwt.SigningMethodHS256, signKey: secret, verifyKey: secret}, nil
+	case algorithmRS256:
+		private, err := parsePrivateKey(cfg.PrivateKeyPEM)
+		if err != nil {
+			return nil, fmt.Errorf("key %q: %w", cfg.Kid, err)
+		}
+		rsaKey, ok := private.(*rsa.PrivateKey)
+		if !ok {
+			return nil, fmt.Errorf("key %q: not an RSA private key", cfg.Kid)
+		}
+		return &signingKey{kid: cfg.Kid, method: jwt.SigningMethodRS256, signKey: rsaKey, verifyKey: &rsaKey.PublicKey}, nil
+	case algorithmEdDSA:
+		private, err := parsePrivateKey(cfg.PrivateKeyPEM)
+		if err != nil {
+			return nil, fmt.Errorf("key %q: %w", cfg.Kid, err)
+		}
+		edKey, ok := private.(ed25519.PrivateKey)
+		if !ok {
+			return nil, fmt.Errorf("key %q: not an Ed25519 private key", cfg.Kid)
+		}
+		return &signingKey{kid: cfg.Kid, method: jwt.SigningMethodEdDSA, signKey: edKey, verifyKey: edKey.Public()}, nil
+	default:
+		return nil, fmt.Errorf("key %q: unsupported algorithm %q", cfg.Kid, cfg.Algorithm)
+	}
+}
+
+func parsePrivateKey(data []byte) (interface{}, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, fmt.Errorf("invalid PEM data")
+	}
+	if key, err := x509.ParsePKCS8PrivateKey(block.Bytes); err == nil {
+		return key, nil
+	}
+	return x509.ParsePKCS1PrivateKey(block.Bytes)
+}
+
+func (k *KeySet) sign(claims jwt.Claims) (string, error) {
+	token := jwt.NewWithClaims(k.active.method, claims)
+	token.Header["kid"] = k.active.kid
+	return token.SignedString(k.active.signKey)
+}
+
+func (k *KeySet) keyFunc(token *jwt.Token) (interface{}, error) {
+	kid, _ := token.Header["kid"].(string)
+	key, ok := k.keys[kid]
+	if !ok {
+		return nil, fmt.Errorf("unknown key id %q", kid)
+	}
+	if token.Method.Alg() != key.method.Alg() {
+		return nil, fmt.Errorf("invalid signing method")
+	}
+	return key.verifyKey, nil
+}
+
+// JWKS returns the public keys of the set. Symmetric keys are never published.
+func (k *KeySet) JWKS() todo.JSONWebKeySet {
+	set := todo.JSONWebKeySet{Keys: make([]todo.JSONWebKey, 0, len(k.keys))}
+	for _, key := range k.keys {
+		switch public := key.verifyKey.(type) {
+		case *rsa.PublicKey:
+			set.Keys = append(set.Keys, todo.JSONWebKey{
+				Kty: "RSA",
+				Kid: key.kid,
+				Alg: key.method.Alg(),
+				Use: "sig",
+				N:   base64.RawURLEncoding.EncodeToString(public.N.Bytes()),
+				E:   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(public.E)).Bytes()),
+			})
+		case ed25519.PublicKey:
+			set.Keys = append(set.Keys, todo.JSONWebKey{
+				Kty: "OKP",
+				Kid: key.kid,
+				Alg: key.method.Alg(),
+				Use: "sig",
+				Crv: "Ed25519",
+				X:   base64.RawURLEncoding.EncodeToString(public),
+			})
+		}
+	}
+	sort.Slice(set.Keys, func(i, j int) bool { return set.Keys[i].Kid < set.Keys[j].Kid })
+	return set
+}
@@ -0,0 +1,287 @@
+package service
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	todo "do-app"
+	"encoding/base64"
+	"encoding/pem"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+	"math/big"
+	"testing"
+)
+
+type testKeys struct {
+	rsa        *rsa.PrivateKey
+	rsaPKCS8   []byte
+	rsaPKCS1   []byte
+	ed25519    ed25519.PrivateKey
+	ed25519PEM []byte
+}
+
+func newTestKeys(t *testing.T) testKeys {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, edKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pkcs8 := func(key interface{}) []byte {
+		der, err := x509.MarshalPKCS8PrivateKey(key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der})
+	}
+	return testKeys{
+		rsa:      rsaKey,
+		rsaPKCS8: pkcs8(rsaKey),
+		rsaPKCS1: pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY",
+			Bytes: x509.MarshalPKCS1PrivateKey(rsaKey)}),
+		ed25519:    edKey,
+		ed25519PEM: pkcs8(edKey),
+	}
+}
+
+func TestNewKeySet(t *testing.T) {
+	keys := newTestKeys(t)
+
+	testTable := []struct {
+		name      string
+		activeKid string
+		configs   []SigningKeyConfig
+		expectErr string
+	}{
+		{
+			name:      "OK",
+			activeKid: "ed",
+			configs: []SigningKeyConfig{
+				{Kid: "hs", Algorithm: algorithmHS256, Secret: "secret"},
+				{Kid: "rsa", Algorithm: algorithmRS256, PrivateKeyPEM: keys.rsaPKCS8},
+				{Kid: "ed", Algorithm: algorithmEdDSA, PrivateKeyPEM: keys.ed25519PEM},
+			},
+		},
+		{
+			name:      "PKCS #1 RSA key",
+			activeKid: "rsa",
+			configs:   []SigningKeyConfig{{Kid: "rsa", Algorithm: algorithmRS256, PrivateKeyPEM: keys.rsaPKCS1}},
+		},
+		{
+			name:      "Key without kid",
+			activeKid: "hs",
+			configs:   []SigningKeyConfig{{Algorithm: algorithmHS256, Secret: "secret"}},
+			expectErr: "key set: key without kid",
+		},
+		{
+			name:      "Duplicate kid",
+			activeKid: "hs",
+			configs: []SigningKeyConfig{
+				{Kid: "hs", Algorithm: algorithmHS256, Secret: "secret"},
+				{Kid: "hs", Algorithm: algorithmHS256, Secret: "other"},
+			},
+			expectErr: `key set: duplicate kid "hs"`,
+		},
+		{
+			name:      "Empty secret",
+			activeKid: "hs",
+			configs:   []SigningKeyConfig{{Kid: "hs", Algorithm: algorithmHS256}},
+			expectErr: `key set: key "hs": empty secret`,
+		},
+		{
+			name:      "Invalid PEM",
+			activeKid: "rsa",
+			configs:   []SigningKeyConfig{{Kid: "rsa", Algorithm: algorithmRS256, PrivateKeyPEM: []byte("not a key")}},
+			expectErr: `key set: key "rsa": invalid PEM data`,
+		},
+		{
+			name:      "Ed25519 key for RS256",
+			activeKid: "rsa",
+			configs:   []SigningKeyConfig{{Kid: "rsa", Algorithm: algorithmRS256, PrivateKeyPEM: keys.ed25519PEM}},
+			expectErr: `key set: key "rsa": not an RSA private key`,
+		},
+		{
+			name:      "RSA key for EdDSA",
+			activeKid: "ed",
+			configs:   []SigningKeyConfig{{Kid: "ed", Algorithm: algorithmEdDSA, PrivateKeyPEM: keys.rsaPKCS8}},
+			expectErr: `key set: key "ed": not an Ed25519 private key`,
+		},
+		{
+			name:      "Unsupported algorithm",
+			activeKid: "es",
+			configs:   []SigningKeyConfig{{Kid: "es", Algorithm: "ES256", PrivateKeyPEM: keys.rsaPKCS8}},
+			expectErr: `key set: key "es": unsupported algorithm "ES256"`,
+		},
+		{
+			name:      "Active key not found",
+			activeKid: "new",
+			configs:   []SigningKeyConfig{{Kid: "hs", Algorithm: algorithmHS256, Secret: "secret"}},
+			expectErr: `key set: active key "new" not found`,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			set, err := NewKeySet(testCase.activeKid, testCase.configs)
+			if testCase.expectErr != "" {
+				assert.EqualError(t, err, testCase.expectErr)
+				assert.Nil(t, set)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, testCase.activeKid, set.active.kid)
+		})
+	}
+}
+
+func TestKeySet_keyFunc(t *testing.T) {
+	keys := newTestKeys(t)
+	set, err := NewKeySet("ed", []SigningKeyConfig{
+		{Kid: "hs", Algorithm: algorithmHS256, Secret: "secret"},
+		{Kid: "rsa", Algorithm: algorithmRS256, PrivateKeyPEM: keys.rsaPKCS8},
+		{Kid: "ed", Algorithm: algorithmEdDSA, PrivateKeyPEM: keys.ed25519PEM},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	testTable := []struct {
+		name      string
+		method    jwt.SigningMethod
+		header    map[string]interface{}
+		expectKey interface{}
+		expectErr string
+	}{
+		{
+			name:      "HS256",
+			method:    jwt.SigningMethodHS256,
+			header:    map[string]interface{}{"kid": "hs"},
+			expectKey: []byte("secret"),
+		},
+		{
+			name:      "RS256",
+			method:    jwt.SigningMethodRS256,
+			header:    map[string]interface{}{"kid": "rsa"},
+			expectKey: &keys.rsa.PublicKey,
+		},
+		{
+			name:      "EdDSA",
+			method:    jwt.SigningMethodEdDSA,
+			header:    map[string]interface{}{"kid": "ed"},
+			expectKey: keys.ed25519.Public(),
+		},
+		{
+			name:      "Unknown kid",
+			method:    jwt.SigningMethodHS256,
+			header:    map[string]interface{}{"kid": "old"},
+			expectErr: `unknown key id "old"`,
+		},
+		{
+			name:      "No kid",
+			method:    jwt.SigningMethodHS256,
+			header:    map[string]interface{}{},
+			expectErr: `unknown key id ""`,
+		},
+		{
+			name:      "HS256 with the kid of an RSA key",
+			method:    jwt.SigningMethodHS256,
+			header:    map[string]interface{}{"kid": "rsa"},
+			expectErr: "invalid signing method",
+		},
+		{
+			name:      "RS256 with the kid of an EdDSA key",
+			method:    jwt.SigningMethodRS256,
+			header:    map[string]interface{}{"kid": "ed"},
+			expectErr: "invalid signing method",
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			key, err := set.keyFunc(&jwt.Token{Method: testCase.method, Header: testCase.header})
+			if testCase.expectErr != "" {
+				assert.EqualError(t, err, testCase.expectErr)
+				assert.Nil(t, key)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, testCase.expectKey, key)
+		})
+	}
+}
+
+func TestKeySet_rotation(t *testing.T) {
+	keys := newTestKeys(t)
+	configs := []SigningKeyConfig{
+		{Kid: "old", Algorithm: algorithmRS256, PrivateKeyPEM: keys.rsaPKCS8},
+		{Kid: "new", Algorithm: algorithmEdDSA, PrivateKeyPEM: keys.ed25519PEM},
+	}
+	oldSet, err := NewKeySet("old", configs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	newSet, err := NewKeySet("new", configs)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	signed, err := oldSet.sign(jwt.RegisteredClaims{Subject: "1"})
+	assert.NoError(t, err)
+	token, err := jwt.Parse(signed, newSet.keyFunc)
+	assert.NoError(t, err)
+	assert.True(t, token.Valid)
+
+	signed, err = newSet.sign(jwt.RegisteredClaims{Subject: "1"})
+	assert.NoError(t, err)
+	token, err = jwt.Parse(signed, newSet.keyFunc)
+	assert.NoError(t, err)
+	assert.Equal(t, "new", token.Header["kid"])
+	assert.Equal(t, algorithmEdDSA, token.Method.Alg())
+}
+
+func TestKeySet_JWKS(t *testing.T) {
+	keys := newTestKeys(t)
+	set, err := NewKeySet("hs", []SigningKeyConfig{
+		{Kid: "hs", Algorithm: algorithmHS256, Secret: "secret"},
+		{Kid: "rsa", Algorithm: algorithmRS256, PrivateKeyPEM: keys.rsaPKCS8},
+		{Kid: "ed", Algorithm: algorithmEdDSA, PrivateKeyPEM: keys.ed25519PEM},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	jwks := set.JWKS()
+
+	assert.Equal(t, todo.JSONWebKeySet{Keys: []todo.JSONWebKey{
+		{
+			Kty: "OKP",
+			Kid: "ed",
+			Alg: algorithmEdDSA,
+			Use: "sig",
+			Crv: "Ed25519",
+			X:   base64.RawURLEncoding.EncodeToString(keys.ed25519.Public().(ed25519.PublicKey)),
+		},
+		{
+			Kty: "RSA",
+			Kid: "rsa",
+			Alg: algorithmRS256,
+			Use: "sig",
+			N:   base64.RawURLEncoding.EncodeToString(keys.rsa.N.Bytes()),
+			E:   "AQAB",
+		},
+	}}, jwks)
+
+	// The published key has to verify what the private key signed.
+	n, err := base64.RawURLEncoding.DecodeString(jwks.Keys[1].N)
+	assert.NoError(t, err)
+	e, err := base64.RawURLEncoding.DecodeString(jwks.Keys[1].E)
+	assert.NoError(t, err)
+	public := &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(new(big.Int).SetBytes(e).Int64())}
+	signature, err := jwt.SigningMethodRS256.Sign("payload", keys.rsa)
+	assert.NoError(t, err)
+	assert.NoError(t, jwt.SigningMethodRS256.Verify("payload", signature, public))
+}
@@ -0,0 +1,69 @@
+package service
+
+import (
+	"context"
+	todo "do-app"
+	"do-app/pkg/repository"
+	"fmt"
+)
+
+type LabelService struct {
+	repo     repository.Labels
+	itemRepo repository.TodoItems
+}
+
+func NewLabelService(repo repository.Labels, itemRepo repository.TodoItems) *LabelService {
+	return &LabelService{repo: repo, itemRepo: itemRepo}
+}
+
+func (s *LabelService) Create(ctx context.Context, userId int, label todo.Label) (int, error) {
+	if err := label.Validate(); err != nil {
+		return 0, err
+	}
+	if label.Color == "" {
+		label.Color = todo.DefaultLabelColor
+	}
+	return s.repo.Create(ctx, userId, label)
+}
+
+func (s *LabelService) GetAll(ctx context.Context, userId int) ([]todo.Label, error) {
+	return s.repo.GetAll(ctx, userId)
+}
+
+func (s *LabelService) GetById(ctx context.Context, userId, labelId int) (todo.Label, error) {
+	return s.repo.GetById(ctx, userId, labelId)
+}
+
+func (s *LabelService) Update(ctx context.Context, userId, labelId int, input todo.UpdateLabelInput) error {
+	if err := input.Validate(); err != nil {
+		return err
+	}
+	return s.repo.Update(ctx, userId, labelId, input)
+}
+
+func (s *LabelService) Delete(ctx context.Context, userId, labelId int) error {
+	return s.repo.Delete(ctx, userId, labelId)
+}
+
+func (s *LabelService) GetByItem(ctx context.Context, userId, itemId int) ([]todo.Label, error) {
+	if _, err := s.itemRepo.GetById(ctx, userId, itemId); err != nil {
+		return nil, fmt.Errorf("GetByItem label service: %w", err)
+	}
+	return s.repo.GetByItem(ctx, userId, itemId)
+}
+
+// Attach labels an item the user can see. Labels are personal, so viewers of
+// a shared list may label its items too.
+func (s *LabelService) Attach(ctx context.Context, userId, itemId, labelId int) error {
+	if _, err := s.itemRepo.GetById(ctx, userId, itemId); err != nil {
+		return fmt.Errorf("Attach label service: %w", err)
+	}
+	if _, err := s.repo.GetById(ctx, userId, labelId); err != nil {
+		return fmt.Errorf("Attach label service: %w", err)
+	}
+	return s.repo.Attach(ctx, itemId, labelId)
+}
+
+func (s *LabelService) Detach(ctx context.Context, userId, itemId, labelId int) error {
+	return s.repo.Detach(ctx, userId, itemId, labelId)
+}
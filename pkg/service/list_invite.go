@@ -0,0 +1,76 @@
+package service
+
+import (
+	"context"
+	todo "do-app"
+	"do-app/pkg/repository"
+	"fmt"
+	"time"
+)
+
+type ListInviteService struct {
+	repo       repository.ListInvites
+	memberRepo repository.ListMembers
+}
+
+func NewListInviteService(repo repository.ListInvites, memberRepo repository.ListMembers) *ListInviteService {
+	return &ListInviteService{repo: repo, memberRepo: memberRepo}
+}
+
+// Create stores a new invite and returns it together with its token. The token
+// is only stored hashed, so this is the only time it can be shown.
+func (s *ListInviteService) Create(ctx context.Context, userId, listId int, input todo.CreateInviteInput) (todo.ListInvite, error) {
+	if err := input.Validate(); err != nil {
+		return todo.ListInvite{}, err
+	}
+	if err := requireOwner(ctx, s.memberRepo, userId, listId); err != nil {
+		return todo.ListInvite{}, fmt.Errorf("Create invite service: %w", err)
+	}
+
+	ttl, _ := input.TTL()
+	invite := todo.ListInvite{
+		ListId:    listId,
+		Role:      input.Role,
+		CreatedBy: userId,
+		ExpiresAt: time.Now().Add(ttl),
+		CreatedAt: time.Now(),
+	}
+	switch {
+	case input.MaxUses == nil:
+		single := 1
+		invite.MaxUses = &single
+	case *input.MaxUses > 0:
+		invite.MaxUses = input.MaxUses
+	}
+
+	token, err := newRandomToken()
+	if err != nil {
+		return todo.ListInvite{}, fmt.Errorf("Create invite service: %w", err)
+	}
+
+	invite.Id, err = s.repo.Create(ctx, invite, hashToken(token))
+	if err != nil {
+		return todo.ListInvite{}, fmt.Errorf("Create invite service: %w", err)
+	}
+	invite.Token = token
+
+	return invite, nil
+}
+
+func (s *ListInviteService) GetAll(ctx context.Context, userId, listId int) ([]todo.ListInvite, error) {
+	if err := requireOwner(ctx, s.memberRepo, userId, listId); err != nil {
+		return nil, fmt.Errorf("GetAll invite service: %w", err)
+	}
+	return s.repo.GetAll(ctx, listId)
+}
+
+func (s *ListInviteService) Revoke(ctx context.Context, userId, listId, inviteId int) error {
+	if err := requireOwner(ctx, s.memberRepo, userId, listId); err != nil {
+		return fmt.Errorf("Revoke invite service: %w", err)
+	}
+	return s.repo.Revoke(ctx, listId, inviteId)
+}
+
+func (s *ListInviteService) Accept(ctx context.Context, userId int, token string) (int, error) {
+	return s.repo.Redeem(ctx, hashToken(token), userId)
+}
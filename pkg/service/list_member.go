@@ -0,0 +1,109 @@
+package service
+
+import (
+	"context"
+	todo "do-app"
+	"do-app/pkg/repository"
+	"fmt"
+)
+
+type ListMemberService struct {
+	repo repository.ListMembers
+	tx   repository.Transactor
+}
+
+func NewListMemberService(repo repository.ListMembers, tx repository.Transactor) *ListMemberService {
+	return &ListMemberService{repo: repo, tx: tx}
+}
+
+func (s *ListMemberService) GetAll(ctx context.Context, userId, listId int) ([]todo.ListMember, error) {
+	if _, err := s.repo.GetRole(ctx, userId, listId); err != nil {
+		return nil, fmt.Errorf("GetAll members service: %w", err)
+	}
+	return s.repo.GetAll(ctx, listId)
+}
+
+func (s *ListMemberService) Add(ctx context.Context, userId, listId int, input todo.AddMemberInput) (int, error) {
+	if err := input.Validate(); err != nil {
+		return 0, err
+	}
+	if err := requireOwner(ctx, s.repo, userId, listId); err != nil {
+		return 0, fmt.Errorf("Add member service: %w", err)
+	}
+	return s.repo.Add(ctx, listId, input)
+}
+
+// UpdateRole changes the role of a member. The check that the list keeps an
+// owner and the change run in one unit of work.
+func (s *ListMemberService) UpdateRole(ctx context.Context, userId, listId, memberId int, input todo.UpdateMemberInput) error {
+	if err := input.Validate(); err != nil {
+		return err
+	}
+	err := s.tx.WithinTx(ctx, repository.TxOptions{}, func(ctx context.Context) error {
+		if err := requireOwner(ctx, s.repo, userId, listId); err != nil {
+			return err
+		}
+		if input.Role != todo.RoleOwner {
+			if err := s.keepOwner(ctx, listId, memberId); err != nil {
+				return err
+			}
+		}
+		return s.repo.UpdateRole(ctx, listId, memberId, input.Role)
+	})
+	if err != nil {
+		return fmt.Errorf("Update member service: %w", err)
+	}
+	return nil
+}
+
+// Remove deletes a member from the list. Owners may remove anyone, every
+// other member may only leave the list themselves. The check that the list
+// keeps an owner and the removal run in one unit of work.
+func (s *ListMemberService) Remove(ctx context.Context, userId, listId, memberId int) error {
+	err := s.tx.WithinTx(ctx, repository.TxOptions{}, func(ctx context.Context) error {
+		if userId != memberId {
+			if err := requireOwner(ctx, s.repo, userId, listId); err != nil {
+				return err
+			}
+		}
+		if err := s.keepOwner(ctx, listId, memberId); err != nil {
+			return err
+		}
+		return s.repo.Remove(ctx, listId, memberId)
+	})
+	if err != nil {
+		return fmt.Errorf("Remove member service: %w", err)
+	}
+	return nil
+}
+
+func requireOwner(ctx context.Context, repo repository.ListMembers, userId, listId int) error {
+	role, err := repo.GetRole(ctx, userId, listId)
+	if err != nil {
+		return err
+	}
+	if role != todo.RoleOwner {
+		return todo.NewError(todo.ErrForbidden, "only list owners can manage members")
+	}
+	return nil
+}
+
+// keepOwner refuses to demote or remove the last owner of a list. Counting the
+// owners locks them until the unit of work of the caller ends.
+func (s *ListMemberService) keepOwner(ctx context.Context, listId, memberId int) error {
+	role, err := s.repo.GetRole(ctx, memberId, listId)
+	if err != nil {
+		return err
+	}
+	if role != todo.RoleOwner {
+		return nil
+	}
+	owners, err := s.repo.CountOwners(ctx, listId)
+	if err != nil {
+		return err
+	}
+	if owners <= 1 {
+		return todo.NewError(todo.ErrConflict, "list must keep at least one owner")
+	}
+	return nil
+}
@@ -0,0 +1,77 @@
+package service
+
+import (
+	"context"
+	todo "do-app"
+	"do-app/pkg/repository"
+	"github.com/stretchr/testify/assert"
+	"sync"
+	"testing"
+)
+
+func TestListMemberService_keepOwner(t *testing.T) {
+	testTable := []struct {
+		name   string
+		change func(s *ListMemberService, userId, otherId, listId int) error
+	}{
+		{
+			name: "Owners demote each other",
+			change: func(s *ListMemberService, userId, otherId, listId int) error {
+				return s.UpdateRole(context.Background(), userId, listId, otherId, todo.UpdateMemberInput{Role: todo.RoleEditor})
+			},
+		},
+		{
+			name: "Owners remove each other",
+			change: func(s *ListMemberService, userId, otherId, listId int) error {
+				return s.Remove(context.Background(), userId, listId, otherId)
+			},
+		},
+		{
+			name: "Owners leave",
+			change: func(s *ListMemberService, userId, otherId, listId int) error {
+				return s.Remove(context.Background(), userId, listId, userId)
+			},
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			ctx := context.Background()
+			repos := repository.NewMemoryRepository()
+			aliceId := createTestUser(t, repos, "alice")
+			bobId := createTestUser(t, repos, "bob")
+			listId, err := repos.TodoLists.Create(ctx, aliceId, todo.TodoList{Title: "shared"})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if _, err = repos.ListMembers.Add(ctx, listId, todo.AddMemberInput{Username: "bob", Role: todo.RoleOwner}); err != nil {
+				t.Fatal(err)
+			}
+
+			s := NewListMemberService(repos.ListMembers, repos.Transactor)
+			var wg sync.WaitGroup
+			errs := make([]error, 2)
+			for i, users := range [][2]int{{aliceId, bobId}, {bobId, aliceId}} {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					errs[i] = testCase.change(s, users[0], users[1], listId)
+				}()
+			}
+			wg.Wait()
+
+			// Only one of the changes gets through, the other one finds the
+			// list with a single owner or the user no longer owning it.
+			failed := 0
+			for _, err := range errs {
+				if err != nil {
+					failed++
+				}
+			}
+			assert.Equal(t, 1, failed)
+			owners, err := repos.ListMembers.CountOwners(ctx, listId)
+			assert.NoError(t, err)
+			assert.Equal(t, 1, owners)
+		})
+	}
+}
@@ -5,6 +5,7 @@
 package mock_service
 
 import (
+	context "context"
 	do_app "do-app"
 	reflect "reflect"
 
@@ -35,48 +36,91 @@ func (m *MockAuthorization) EXPECT() *MockAuthorizationMockRecorder {
 }
 
 // CreateUser mocks base method.
-func (m *MockAuthorization) CreateUser(user do_app.User) (int, error) {
+func (m *MockAuthorization) CreateUser(ctx context.Context, user do_app.User) (int, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "CreateUser", user)
+	ret := m.ctrl.Call(m, "CreateUser", ctx, user)
 	ret0, _ := ret[0].(int)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // CreateUser indicates an expected call of CreateUser.
-func (mr *MockAuthorizationMockRecorder) CreateUser(user interface{}) *gomock.Call {
+func (mr *MockAuthorizationMockRecorder) CreateUser(ctx, user interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateUser", reflect.TypeOf((*MockAuthorization)(nil).CreateUser), user)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateUser", reflect.TypeOf((*MockAuthorization)(nil).CreateUser), ctx, user)
 }
 
 // GenerateToken mocks base method.
-func (m *MockAuthorization) GenerateToken(username, password string) (string, error) {
+func (m *MockAuthorization) GenerateToken(ctx context.Context, username, password string) (do_app.Tokens, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GenerateToken", username, password)
-	ret0, _ := ret[0].(string)
+	ret := m.ctrl.Call(m, "GenerateToken", ctx, username, password)
+	ret0, _ := ret[0].(do_app.Tokens)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // GenerateToken indicates an expected call of GenerateToken.
-func (mr *MockAuthorizationMockRecorder) GenerateToken(username, password interface{}) *gomock.Call {
+func (mr *MockAuthorizationMockRecorder) GenerateToken(ctx, username, password interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GenerateToken", reflect.TypeOf((*MockAuthorization)(nil).GenerateToken), username, password)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GenerateToken", reflect.TypeOf((*MockAuthorization)(nil).GenerateToken), ctx, username, password)
+}
+
+// JWKS mocks base method.
+func (m *MockAuthorization) JWKS() do_app.JSONWebKeySet {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "JWKS")
+	ret0, _ := ret[0].(do_app.JSONWebKeySet)
+	return ret0
+}
+
+// JWKS indicates an expected call of JWKS.
+func (mr *MockAuthorizationMockRecorder) JWKS() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "JWKS", reflect.TypeOf((*MockAuthorization)(nil).JWKS))
 }
 
 // ParseToken mocks base method.
-func (m *MockAuthorization) ParseToken(token string) (int, error) {
+func (m *MockAuthorization) ParseToken(ctx context.Context, token string) (int, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "ParseToken", token)
+	ret := m.ctrl.Call(m, "ParseToken", ctx, token)
 	ret0, _ := ret[0].(int)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // ParseToken indicates an expected call of ParseToken.
-func (mr *MockAuthorizationMockRecorder) ParseToken(token interface{}) *gomock.Call {
+func (mr *MockAuthorizationMockRecorder) ParseToken(ctx, token interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ParseToken", reflect.TypeOf((*MockAuthorization)(nil).ParseToken), ctx, token)
+}
+
+// RefreshToken mocks base method.
+func (m *MockAuthorization) RefreshToken(ctx context.Context, refreshToken string) (do_app.Tokens, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RefreshToken", ctx, refreshToken)
+	ret0, _ := ret[0].(do_app.Tokens)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RefreshToken indicates an expected call of RefreshToken.
+func (mr *MockAuthorizationMockRecorder) RefreshToken(ctx, refreshToken interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ParseToken", reflect.TypeOf((*MockAuthorization)(nil).ParseToken), token)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RefreshToken", reflect.TypeOf((*MockAuthorization)(nil).RefreshToken), ctx, refreshToken)
+}
+
+// SignOut mocks base method.
+func (m *MockAuthorization) SignOut(ctx context.Context, refreshToken string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SignOut", ctx, refreshToken)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SignOut indicates an expected call of SignOut.
+func (mr *MockAuthorizationMockRecorder) SignOut(ctx, refreshToken interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SignOut", reflect.TypeOf((*MockAuthorization)(nil).SignOut), ctx, refreshToken)
 }
 
 // MockTodoLists is a mock of TodoLists interface.
@@ -102,77 +146,259 @@ func (m *MockTodoLists) EXPECT() *MockTodoListsMockRecorder {
 	return m.recorder
 }
 
+// Copy mocks base method.
+func (m *MockTodoLists) Copy(ctx context.Context, userId, listId int) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Copy", ctx, userId, listId)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Copy indicates an expected call of Copy.
+func (mr *MockTodoListsMockRecorder) Copy(ctx, userId, listId interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Copy", reflect.TypeOf((*MockTodoLists)(nil).Copy), ctx, userId, listId)
+}
+
 // Create mocks base method.
-func (m *MockTodoLists) Create(userId int, list do_app.TodoList) (int, error) {
+func (m *MockTodoLists) Create(ctx context.Context, userId int, list do_app.TodoList) (int, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "Create", userId, list)
+	ret := m.ctrl.Call(m, "Create", ctx, userId, list)
 	ret0, _ := ret[0].(int)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // Create indicates an expected call of Create.
-func (mr *MockTodoListsMockRecorder) Create(userId, list interface{}) *gomock.Call {
+func (mr *MockTodoListsMockRecorder) Create(ctx, userId, list interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Create", reflect.TypeOf((*MockTodoLists)(nil).Create), userId, list)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Create", reflect.TypeOf((*MockTodoLists)(nil).Create), ctx, userId, list)
 }
 
 // Delete mocks base method.
-func (m *MockTodoLists) Delete(userId, listId int) error {
+func (m *MockTodoLists) Delete(ctx context.Context, userId, listId, version int) (string, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "Delete", userId, listId)
-	ret0, _ := ret[0].(error)
-	return ret0
+	ret := m.ctrl.Call(m, "Delete", ctx, userId, listId, version)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
 }
 
 // Delete indicates an expected call of Delete.
-func (mr *MockTodoListsMockRecorder) Delete(userId, listId interface{}) *gomock.Call {
+func (mr *MockTodoListsMockRecorder) Delete(ctx, userId, listId, version interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Delete", reflect.TypeOf((*MockTodoLists)(nil).Delete), userId, listId)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Delete", reflect.TypeOf((*MockTodoLists)(nil).Delete), ctx, userId, listId, version)
 }
 
 // GetAll mocks base method.
-func (m *MockTodoLists) GetAll(userId int) ([]do_app.TodoList, error) {
+func (m *MockTodoLists) GetAll(ctx context.Context, userId int, filter do_app.ListFilter, page do_app.PageRequest) ([]do_app.TodoList, string, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetAll", userId)
+	ret := m.ctrl.Call(m, "GetAll", ctx, userId, filter, page)
 	ret0, _ := ret[0].([]do_app.TodoList)
-	ret1, _ := ret[1].(error)
-	return ret0, ret1
+	ret1, _ := ret[1].(string)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
 }
 
 // GetAll indicates an expected call of GetAll.
-func (mr *MockTodoListsMockRecorder) GetAll(userId interface{}) *gomock.Call {
+func (mr *MockTodoListsMockRecorder) GetAll(ctx, userId, filter, page interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAll", reflect.TypeOf((*MockTodoLists)(nil).GetAll), userId)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAll", reflect.TypeOf((*MockTodoLists)(nil).GetAll), ctx, userId, filter, page)
 }
 
 // GetById mocks base method.
-func (m *MockTodoLists) GetById(userId, listId int) (do_app.TodoList, error) {
+func (m *MockTodoLists) GetById(ctx context.Context, userId, listId int) (do_app.TodoList, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetById", userId, listId)
+	ret := m.ctrl.Call(m, "GetById", ctx, userId, listId)
 	ret0, _ := ret[0].(do_app.TodoList)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // GetById indicates an expected call of GetById.
-func (mr *MockTodoListsMockRecorder) GetById(userId, listId interface{}) *gomock.Call {
+func (mr *MockTodoListsMockRecorder) GetById(ctx, userId, listId interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetById", reflect.TypeOf((*MockTodoLists)(nil).GetById), userId, listId)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetById", reflect.TypeOf((*MockTodoLists)(nil).GetById), ctx, userId, listId)
 }
 
 // Update mocks base method.
-func (m *MockTodoLists) Update(userId, listId int, input do_app.UpdateListInput) error {
+func (m *MockTodoLists) Update(ctx context.Context, userId, listId int, input do_app.UpdateListInput, version int) (string, int, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "Update", userId, listId, input)
+	ret := m.ctrl.Call(m, "Update", ctx, userId, listId, input, version)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(int)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// Update indicates an expected call of Update.
+func (mr *MockTodoListsMockRecorder) Update(ctx, userId, listId, input, version interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Update", reflect.TypeOf((*MockTodoLists)(nil).Update), ctx, userId, listId, input, version)
+}
+
+// MockListMembers is a mock of ListMembers interface.
+type MockListMembers struct {
+	ctrl     *gomock.Controller
+	recorder *MockListMembersMockRecorder
+}
+
+// MockListMembersMockRecorder is the mock recorder for MockListMembers.
+type MockListMembersMockRecorder struct {
+	mock *MockListMembers
+}
+
+// NewMockListMembers creates a new mock instance.
+func NewMockListMembers(ctrl *gomock.Controller) *MockListMembers {
+	mock := &MockListMembers{ctrl: ctrl}
+	mock.recorder = &MockListMembersMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockListMembers) EXPECT() *MockListMembersMockRecorder {
+	return m.recorder
+}
+
+// Add mocks base method.
+func (m *MockListMembers) Add(ctx context.Context, userId, listId int, input do_app.AddMemberInput) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Add", ctx, userId, listId, input)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Add indicates an expected call of Add.
+func (mr *MockListMembersMockRecorder) Add(ctx, userId, listId, input interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Add", reflect.TypeOf((*MockListMembers)(nil).Add), ctx, userId, listId, input)
+}
+
+// GetAll mocks base method.
+func (m *MockListMembers) GetAll(ctx context.Context, userId, listId int) ([]do_app.ListMember, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetAll", ctx, userId, listId)
+	ret0, _ := ret[0].([]do_app.ListMember)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetAll indicates an expected call of GetAll.
+func (mr *MockListMembersMockRecorder) GetAll(ctx, userId, listId interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAll", reflect.TypeOf((*MockListMembers)(nil).GetAll), ctx, userId, listId)
+}
+
+// Remove mocks base method.
+func (m *MockListMembers) Remove(ctx context.Context, userId, listId, memberId int) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Remove", ctx, userId, listId, memberId)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
-// Update indicates an expected call of Update.
-func (mr *MockTodoListsMockRecorder) Update(userId, listId, input interface{}) *gomock.Call {
+// Remove indicates an expected call of Remove.
+func (mr *MockListMembersMockRecorder) Remove(ctx, userId, listId, memberId interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Remove", reflect.TypeOf((*MockListMembers)(nil).Remove), ctx, userId, listId, memberId)
+}
+
+// UpdateRole mocks base method.
+func (m *MockListMembers) UpdateRole(ctx context.Context, userId, listId, memberId int, input do_app.UpdateMemberInput) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateRole", ctx, userId, listId, memberId, input)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateRole indicates an expected call of UpdateRole.
+func (mr *MockListMembersMockRecorder) UpdateRole(ctx, userId, listId, memberId, input interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateRole", reflect.TypeOf((*MockListMembers)(nil).UpdateRole), ctx, userId, listId, memberId, input)
+}
+
+// MockListInvites is a mock of ListInvites interface.
+type MockListInvites struct {
+	ctrl     *gomock.Controller
+	recorder *MockListInvitesMockRecorder
+}
+
+// MockListInvitesMockRecorder is the mock recorder for MockListInvites.
+type MockListInvitesMockRecorder struct {
+	mock *MockListInvites
+}
+
+// NewMockListInvites creates a new mock instance.
+func NewMockListInvites(ctrl *gomock.Controller) *MockListInvites {
+	mock := &MockListInvites{ctrl: ctrl}
+	mock.recorder = &MockListInvitesMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockListInvites) EXPECT() *MockListInvitesMockRecorder {
+	return m.recorder
+}
+
+// Accept mocks base method.
+func (m *MockListInvites) Accept(ctx context.Context, userId int, token string) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Accept", ctx, userId, token)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Accept indicates an expected call of Accept.
+func (mr *MockListInvitesMockRecorder) Accept(ctx, userId, token interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Accept", reflect.TypeOf((*MockListInvites)(nil).Accept), ctx, userId, token)
+}
+
+// Create mocks base method.
+func (m *MockListInvites) Create(ctx context.Context, userId, listId int, input do_app.CreateInviteInput) (do_app.ListInvite, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Create", ctx, userId, listId, input)
+	ret0, _ := ret[0].(do_app.ListInvite)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Create indicates an expected call of Create.
+func (mr *MockListInvitesMockRecorder) Create(ctx, userId, listId, input interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Create", reflect.TypeOf((*MockListInvites)(nil).Create), ctx, userId, listId, input)
+}
+
+// GetAll mocks base method.
+func (m *MockListInvites) GetAll(ctx context.Context, userId, listId int) ([]do_app.ListInvite, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetAll", ctx, userId, listId)
+	ret0, _ := ret[0].([]do_app.ListInvite)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetAll indicates an expected call of GetAll.
+func (mr *MockListInvitesMockRecorder) GetAll(ctx, userId, listId interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Update", reflect.TypeOf((*MockTodoLists)(nil).Update), userId, listId, input)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAll", reflect.TypeOf((*MockListInvites)(nil).GetAll), ctx, userId, listId)
+}
+
+// Revoke mocks base method.
+func (m *MockListInvites) Revoke(ctx context.Context, userId, listId, inviteId int) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Revoke", ctx, userId, listId, inviteId)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Revoke indicates an expected call of Revoke.
+func (mr *MockListInvitesMockRecorder) Revoke(ctx, userId, listId, inviteId interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Revoke", reflect.TypeOf((*MockListInvites)(nil).Revoke), ctx, userId, listId, inviteId)
 }
 
 // MockTodoItems is a mock of TodoItems interface.
@@ -199,74 +425,554 @@ func (m *MockTodoItems) EXPECT() *MockTodoItemsMockRecorder {
 }
 
 // Create mocks base method.
-func (m *MockTodoItems) Create(userId, listId int, input do_app.TodoItem) (int, error) {
+func (m *MockTodoItems) Create(ctx context.Context, userId, listId int, input do_app.TodoItem) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Create", ctx, userId, listId, input)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Create indicates an expected call of Create.
+func (mr *MockTodoItemsMockRecorder) Create(ctx, userId, listId, input interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Create", reflect.TypeOf((*MockTodoItems)(nil).Create), ctx, userId, listId, input)
+}
+
+// Delete mocks base method.
+func (m *MockTodoItems) Delete(ctx context.Context, userId, itemId, version int) (string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Delete", ctx, userId, itemId, version)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Delete indicates an expected call of Delete.
+func (mr *MockTodoItemsMockRecorder) Delete(ctx, userId, itemId, version interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Delete", reflect.TypeOf((*MockTodoItems)(nil).Delete), ctx, userId, itemId, version)
+}
+
+// GetAll mocks base method.
+func (m *MockTodoItems) GetAll(ctx context.Context, userId, listId int, filter do_app.ItemFilter, page do_app.PageRequest) ([]do_app.TodoItem, string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetAll", ctx, userId, listId, filter, page)
+	ret0, _ := ret[0].([]do_app.TodoItem)
+	ret1, _ := ret[1].(string)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// GetAll indicates an expected call of GetAll.
+func (mr *MockTodoItemsMockRecorder) GetAll(ctx, userId, listId, filter, page interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAll", reflect.TypeOf((*MockTodoItems)(nil).GetAll), ctx, userId, listId, filter, page)
+}
+
+// GetAllByUser mocks base method.
+func (m *MockTodoItems) GetAllByUser(ctx context.Context, userId int, filter do_app.ItemFilter, page do_app.PageRequest) ([]do_app.TodoItem, string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetAllByUser", ctx, userId, filter, page)
+	ret0, _ := ret[0].([]do_app.TodoItem)
+	ret1, _ := ret[1].(string)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// GetAllByUser indicates an expected call of GetAllByUser.
+func (mr *MockTodoItemsMockRecorder) GetAllByUser(ctx, userId, filter, page interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAllByUser", reflect.TypeOf((*MockTodoItems)(nil).GetAllByUser), ctx, userId, filter, page)
+}
+
+// GetById mocks base method.
+func (m *MockTodoItems) GetById(ctx context.Context, userId, itemId int) (do_app.TodoItem, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetById", ctx, userId, itemId)
+	ret0, _ := ret[0].(do_app.TodoItem)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetById indicates an expected call of GetById.
+func (mr *MockTodoItemsMockRecorder) GetById(ctx, userId, itemId interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetById", reflect.TypeOf((*MockTodoItems)(nil).GetById), ctx, userId, itemId)
+}
+
+// GetSeries mocks base method.
+func (m *MockTodoItems) GetSeries(ctx context.Context, userId, itemId int) (do_app.ItemSeries, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetSeries", ctx, userId, itemId)
+	ret0, _ := ret[0].(do_app.ItemSeries)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetSeries indicates an expected call of GetSeries.
+func (mr *MockTodoItemsMockRecorder) GetSeries(ctx, userId, itemId interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetSeries", reflect.TypeOf((*MockTodoItems)(nil).GetSeries), ctx, userId, itemId)
+}
+
+// Move mocks base method.
+func (m *MockTodoItems) Move(ctx context.Context, userId, itemId int, input do_app.MoveItemInput) (float64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Move", ctx, userId, itemId, input)
+	ret0, _ := ret[0].(float64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Move indicates an expected call of Move.
+func (mr *MockTodoItemsMockRecorder) Move(ctx, userId, itemId, input interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Move", reflect.TypeOf((*MockTodoItems)(nil).Move), ctx, userId, itemId, input)
+}
+
+// StopSeries mocks base method.
+func (m *MockTodoItems) StopSeries(ctx context.Context, userId, itemId int) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "StopSeries", ctx, userId, itemId)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// StopSeries indicates an expected call of StopSeries.
+func (mr *MockTodoItemsMockRecorder) StopSeries(ctx, userId, itemId interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "StopSeries", reflect.TypeOf((*MockTodoItems)(nil).StopSeries), ctx, userId, itemId)
+}
+
+// Update mocks base method.
+func (m *MockTodoItems) Update(ctx context.Context, userId, itemId int, input do_app.UpdateItemInput, version int) (string, int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Update", ctx, userId, itemId, input, version)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(int)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// Update indicates an expected call of Update.
+func (mr *MockTodoItemsMockRecorder) Update(ctx, userId, itemId, input, version interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Update", reflect.TypeOf((*MockTodoItems)(nil).Update), ctx, userId, itemId, input, version)
+}
+
+// UpdateSeries mocks base method.
+func (m *MockTodoItems) UpdateSeries(ctx context.Context, userId, itemId int, input do_app.UpdateSeriesInput) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateSeries", ctx, userId, itemId, input)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateSeries indicates an expected call of UpdateSeries.
+func (mr *MockTodoItemsMockRecorder) UpdateSeries(ctx, userId, itemId, input interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateSeries", reflect.TypeOf((*MockTodoItems)(nil).UpdateSeries), ctx, userId, itemId, input)
+}
+
+// MockSubtasks is a mock of Subtasks interface.
+type MockSubtasks struct {
+	ctrl     *gomock.Controller
+	recorder *MockSubtasksMockRecorder
+}
+
+// MockSubtasksMockRecorder is the mock recorder for MockSubtasks.
+type MockSubtasksMockRecorder struct {
+	mock *MockSubtasks
+}
+
+// NewMockSubtasks creates a new mock instance.
+func NewMockSubtasks(ctrl *gomock.Controller) *MockSubtasks {
+	mock := &MockSubtasks{ctrl: ctrl}
+	mock.recorder = &MockSubtasksMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockSubtasks) EXPECT() *MockSubtasksMockRecorder {
+	return m.recorder
+}
+
+// Create mocks base method.
+func (m *MockSubtasks) Create(ctx context.Context, userId, itemId int, subtask do_app.Subtask) (int, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "Create", userId, listId, input)
+	ret := m.ctrl.Call(m, "Create", ctx, userId, itemId, subtask)
 	ret0, _ := ret[0].(int)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // Create indicates an expected call of Create.
-func (mr *MockTodoItemsMockRecorder) Create(userId, listId, input interface{}) *gomock.Call {
+func (mr *MockSubtasksMockRecorder) Create(ctx, userId, itemId, subtask interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Create", reflect.TypeOf((*MockTodoItems)(nil).Create), userId, listId, input)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Create", reflect.TypeOf((*MockSubtasks)(nil).Create), ctx, userId, itemId, subtask)
 }
 
 // Delete mocks base method.
-func (m *MockTodoItems) Delete(userId, itemId int) error {
+func (m *MockSubtasks) Delete(ctx context.Context, userId, itemId, subtaskId int) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "Delete", userId, itemId)
+	ret := m.ctrl.Call(m, "Delete", ctx, userId, itemId, subtaskId)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
 // Delete indicates an expected call of Delete.
-func (mr *MockTodoItemsMockRecorder) Delete(userId, itemId interface{}) *gomock.Call {
+func (mr *MockSubtasksMockRecorder) Delete(ctx, userId, itemId, subtaskId interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Delete", reflect.TypeOf((*MockTodoItems)(nil).Delete), userId, itemId)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Delete", reflect.TypeOf((*MockSubtasks)(nil).Delete), ctx, userId, itemId, subtaskId)
 }
 
 // GetAll mocks base method.
-func (m *MockTodoItems) GetAll(userId, listId int) ([]do_app.TodoItem, error) {
+func (m *MockSubtasks) GetAll(ctx context.Context, userId, itemId int) ([]do_app.Subtask, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetAll", userId, listId)
-	ret0, _ := ret[0].([]do_app.TodoItem)
+	ret := m.ctrl.Call(m, "GetAll", ctx, userId, itemId)
+	ret0, _ := ret[0].([]do_app.Subtask)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetAll indicates an expected call of GetAll.
+func (mr *MockSubtasksMockRecorder) GetAll(ctx, userId, itemId interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAll", reflect.TypeOf((*MockSubtasks)(nil).GetAll), ctx, userId, itemId)
+}
+
+// Update mocks base method.
+func (m *MockSubtasks) Update(ctx context.Context, userId, itemId, subtaskId int, input do_app.UpdateSubtaskInput) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Update", ctx, userId, itemId, subtaskId, input)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Update indicates an expected call of Update.
+func (mr *MockSubtasksMockRecorder) Update(ctx, userId, itemId, subtaskId, input interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Update", reflect.TypeOf((*MockSubtasks)(nil).Update), ctx, userId, itemId, subtaskId, input)
+}
+
+// MockLabels is a mock of Labels interface.
+type MockLabels struct {
+	ctrl     *gomock.Controller
+	recorder *MockLabelsMockRecorder
+}
+
+// MockLabelsMockRecorder is the mock recorder for MockLabels.
+type MockLabelsMockRecorder struct {
+	mock *MockLabels
+}
+
+// NewMockLabels creates a new mock instance.
+func NewMockLabels(ctrl *gomock.Controller) *MockLabels {
+	mock := &MockLabels{ctrl: ctrl}
+	mock.recorder = &MockLabelsMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockLabels) EXPECT() *MockLabelsMockRecorder {
+	return m.recorder
+}
+
+// Attach mocks base method.
+func (m *MockLabels) Attach(ctx context.Context, userId, itemId, labelId int) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Attach", ctx, userId, itemId, labelId)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Attach indicates an expected call of Attach.
+func (mr *MockLabelsMockRecorder) Attach(ctx, userId, itemId, labelId interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Attach", reflect.TypeOf((*MockLabels)(nil).Attach), ctx, userId, itemId, labelId)
+}
+
+// Create mocks base method.
+func (m *MockLabels) Create(ctx context.Context, userId int, label do_app.Label) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Create", ctx, userId, label)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Create indicates an expected call of Create.
+func (mr *MockLabelsMockRecorder) Create(ctx, userId, label interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Create", reflect.TypeOf((*MockLabels)(nil).Create), ctx, userId, label)
+}
+
+// Delete mocks base method.
+func (m *MockLabels) Delete(ctx context.Context, userId, labelId int) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Delete", ctx, userId, labelId)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Delete indicates an expected call of Delete.
+func (mr *MockLabelsMockRecorder) Delete(ctx, userId, labelId interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Delete", reflect.TypeOf((*MockLabels)(nil).Delete), ctx, userId, labelId)
+}
+
+// Detach mocks base method.
+func (m *MockLabels) Detach(ctx context.Context, userId, itemId, labelId int) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Detach", ctx, userId, itemId, labelId)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Detach indicates an expected call of Detach.
+func (mr *MockLabelsMockRecorder) Detach(ctx, userId, itemId, labelId interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Detach", reflect.TypeOf((*MockLabels)(nil).Detach), ctx, userId, itemId, labelId)
+}
+
+// GetAll mocks base method.
+func (m *MockLabels) GetAll(ctx context.Context, userId int) ([]do_app.Label, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetAll", ctx, userId)
+	ret0, _ := ret[0].([]do_app.Label)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // GetAll indicates an expected call of GetAll.
-func (mr *MockTodoItemsMockRecorder) GetAll(userId, listId interface{}) *gomock.Call {
+func (mr *MockLabelsMockRecorder) GetAll(ctx, userId interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAll", reflect.TypeOf((*MockTodoItems)(nil).GetAll), userId, listId)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAll", reflect.TypeOf((*MockLabels)(nil).GetAll), ctx, userId)
 }
 
 // GetById mocks base method.
-func (m *MockTodoItems) GetById(userId, itemId int) (do_app.TodoItem, error) {
+func (m *MockLabels) GetById(ctx context.Context, userId, labelId int) (do_app.Label, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetById", userId, itemId)
-	ret0, _ := ret[0].(do_app.TodoItem)
+	ret := m.ctrl.Call(m, "GetById", ctx, userId, labelId)
+	ret0, _ := ret[0].(do_app.Label)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // GetById indicates an expected call of GetById.
-func (mr *MockTodoItemsMockRecorder) GetById(userId, itemId interface{}) *gomock.Call {
+func (mr *MockLabelsMockRecorder) GetById(ctx, userId, labelId interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetById", reflect.TypeOf((*MockTodoItems)(nil).GetById), userId, itemId)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetById", reflect.TypeOf((*MockLabels)(nil).GetById), ctx, userId, labelId)
+}
+
+// GetByItem mocks base method.
+func (m *MockLabels) GetByItem(ctx context.Context, userId, itemId int) ([]do_app.Label, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetByItem", ctx, userId, itemId)
+	ret0, _ := ret[0].([]do_app.Label)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetByItem indicates an expected call of GetByItem.
+func (mr *MockLabelsMockRecorder) GetByItem(ctx, userId, itemId interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetByItem", reflect.TypeOf((*MockLabels)(nil).GetByItem), ctx, userId, itemId)
 }
 
 // Update mocks base method.
-func (m *MockTodoItems) Update(userId, itemId int, input do_app.UpdateItemInput) error {
+func (m *MockLabels) Update(ctx context.Context, userId, labelId int, input do_app.UpdateLabelInput) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "Update", userId, itemId, input)
+	ret := m.ctrl.Call(m, "Update", ctx, userId, labelId, input)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
 // Update indicates an expected call of Update.
-func (mr *MockTodoItemsMockRecorder) Update(userId, itemId, input interface{}) *gomock.Call {
+func (mr *MockLabelsMockRecorder) Update(ctx, userId, labelId, input interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Update", reflect.TypeOf((*MockLabels)(nil).Update), ctx, userId, labelId, input)
+}
+
+// MockSearch is a mock of Search interface.
+type MockSearch struct {
+	ctrl     *gomock.Controller
+	recorder *MockSearchMockRecorder
+}
+
+// MockSearchMockRecorder is the mock recorder for MockSearch.
+type MockSearchMockRecorder struct {
+	mock *MockSearch
+}
+
+// NewMockSearch creates a new mock instance.
+func NewMockSearch(ctrl *gomock.Controller) *MockSearch {
+	mock := &MockSearch{ctrl: ctrl}
+	mock.recorder = &MockSearchMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockSearch) EXPECT() *MockSearchMockRecorder {
+	return m.recorder
+}
+
+// Search mocks base method.
+func (m *MockSearch) Search(ctx context.Context, userId int, query do_app.SearchQuery) ([]do_app.SearchResult, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Search", ctx, userId, query)
+	ret0, _ := ret[0].([]do_app.SearchResult)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Search indicates an expected call of Search.
+func (mr *MockSearchMockRecorder) Search(ctx, userId, query interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Search", reflect.TypeOf((*MockSearch)(nil).Search), ctx, userId, query)
+}
+
+// MockTrash is a mock of Trash interface.
+type MockTrash struct {
+	ctrl     *gomock.Controller
+	recorder *MockTrashMockRecorder
+}
+
+// MockTrashMockRecorder is the mock recorder for MockTrash.
+type MockTrashMockRecorder struct {
+	mock *MockTrash
+}
+
+// NewMockTrash creates a new mock instance.
+func NewMockTrash(ctrl *gomock.Controller) *MockTrash {
+	mock := &MockTrash{ctrl: ctrl}
+	mock.recorder = &MockTrashMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockTrash) EXPECT() *MockTrashMockRecorder {
+	return m.recorder
+}
+
+// GetAll mocks base method.
+func (m *MockTrash) GetAll(ctx context.Context, userId int) ([]do_app.TrashEntry, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetAll", ctx, userId)
+	ret0, _ := ret[0].([]do_app.TrashEntry)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetAll indicates an expected call of GetAll.
+func (mr *MockTrashMockRecorder) GetAll(ctx, userId interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAll", reflect.TypeOf((*MockTrash)(nil).GetAll), ctx, userId)
+}
+
+// Restore mocks base method.
+func (m *MockTrash) Restore(ctx context.Context, userId int, entryType string, id int) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Restore", ctx, userId, entryType, id)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Restore indicates an expected call of Restore.
+func (mr *MockTrashMockRecorder) Restore(ctx, userId, entryType, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Restore", reflect.TypeOf((*MockTrash)(nil).Restore), ctx, userId, entryType, id)
+}
+
+// MockActivity is a mock of Activity interface.
+type MockActivity struct {
+	ctrl     *gomock.Controller
+	recorder *MockActivityMockRecorder
+}
+
+// MockActivityMockRecorder is the mock recorder for MockActivity.
+type MockActivityMockRecorder struct {
+	mock *MockActivity
+}
+
+// NewMockActivity creates a new mock instance.
+func NewMockActivity(ctrl *gomock.Controller) *MockActivity {
+	mock := &MockActivity{ctrl: ctrl}
+	mock.recorder = &MockActivityMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockActivity) EXPECT() *MockActivityMockRecorder {
+	return m.recorder
+}
+
+// GetByItem mocks base method.
+func (m *MockActivity) GetByItem(ctx context.Context, userId, itemId int, page do_app.PageRequest) ([]do_app.Activity, string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetByItem", ctx, userId, itemId, page)
+	ret0, _ := ret[0].([]do_app.Activity)
+	ret1, _ := ret[1].(string)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// GetByItem indicates an expected call of GetByItem.
+func (mr *MockActivityMockRecorder) GetByItem(ctx, userId, itemId, page interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetByItem", reflect.TypeOf((*MockActivity)(nil).GetByItem), ctx, userId, itemId, page)
+}
+
+// GetByList mocks base method.
+func (m *MockActivity) GetByList(ctx context.Context, userId, listId int, page do_app.PageRequest) ([]do_app.Activity, string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetByList", ctx, userId, listId, page)
+	ret0, _ := ret[0].([]do_app.Activity)
+	ret1, _ := ret[1].(string)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// GetByList indicates an expected call of GetByList.
+func (mr *MockActivityMockRecorder) GetByList(ctx, userId, listId, page interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetByList", reflect.TypeOf((*MockActivity)(nil).GetByList), ctx, userId, listId, page)
+}
+
+// MockUndo is a mock of Undo interface.
+type MockUndo struct {
+	ctrl     *gomock.Controller
+	recorder *MockUndoMockRecorder
+}
+
+// MockUndoMockRecorder is the mock recorder for MockUndo.
+type MockUndoMockRecorder struct {
+	mock *MockUndo
+}
+
+// NewMockUndo creates a new mock instance.
+func NewMockUndo(ctrl *gomock.Controller) *MockUndo {
+	mock := &MockUndo{ctrl: ctrl}
+	mock.recorder = &MockUndoMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockUndo) EXPECT() *MockUndoMockRecorder {
+	return m.recorder
+}
+
+// Undo mocks base method.
+func (m *MockUndo) Undo(ctx context.Context, userId int, token string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Undo", ctx, userId, token)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Undo indicates an expected call of Undo.
+func (mr *MockUndoMockRecorder) Undo(ctx, userId, token interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Update", reflect.TypeOf((*MockTodoItems)(nil).Update), userId, itemId, input)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Undo", reflect.TypeOf((*MockUndo)(nil).Undo), ctx, userId, token)
 }
@@ -0,0 +1,52 @@
+package service
+
+import (
+	"crypto/sha1"
+	"crypto/subtle"
+	"fmt"
+	"golang.org/x/crypto/bcrypt"
+)
+
+const (
+	passwordAlgoSHA1   = "sha1"
+	passwordAlgoBcrypt = "bcrypt"
+
+	// legacySalt is only used to verify hashes created before bcrypt was
+	// introduced. Such hashes are upgraded on the next successful sign in.
+	legacySalt = "jvhbfdvoivdfjn2343"
+)
+
+var passwordCost = bcrypt.DefaultCost
+
+func generatePasswordHash(password string) (string, string, error) {
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), passwordCost)
+	if err != nil {
+		return "", "", fmt.Errorf("generate password hash: %w", err)
+	}
+	return string(hash), passwordAlgoBcrypt, nil
+}
+
+// verifyPassword reports whether password matches the stored hash and whether
+// the stored hash should be replaced with a fresh one.
+func verifyPassword(password, hash, algo string) (bool, bool) {
+	switch algo {
+	case passwordAlgoBcrypt:
+		if bcrypt.CompareHashAndPassword([]byte(hash), []byte(password)) != nil {
+			return false, false
+		}
+		cost, err := bcrypt.Cost([]byte(hash))
+		return true, err != nil || cost < passwordCost
+	case passwordAlgoSHA1:
+		legacy := generateLegacyPasswordHash(password)
+		return subtle.ConstantTimeCompare([]byte(legacy), []byte(hash)) == 1, true
+	default:
+		return false, false
+	}
+}
+
+func generateLegacyPasswordHash(password string) string {
+	hash := sha1.New()
+	hash.Write([]byte(password))
+
+	return fmt.Sprintf("%x", hash.Sum([]byte(legacySalt)))
+}
@@ -0,0 +1,92 @@
+package service
+
+import (
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/crypto/bcrypt"
+	"testing"
+)
+
+func TestVerifyPassword(t *testing.T) {
+	current, algo, err := generatePasswordHash("qwerty")
+	if err != nil {
+		t.Fatal(err)
+	}
+	cheap, err := bcrypt.GenerateFromPassword([]byte("qwerty"), bcrypt.MinCost)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	testTable := []struct {
+		name         string
+		password     string
+		hash         string
+		algo         string
+		expectOk     bool
+		expectRehash bool
+	}{
+		{
+			name:     "Bcrypt",
+			password: "qwerty",
+			hash:     current,
+			algo:     algo,
+			expectOk: true,
+		},
+		{
+			name:         "Bcrypt below the current cost",
+			password:     "qwerty",
+			hash:         string(cheap),
+			algo:         passwordAlgoBcrypt,
+			expectOk:     true,
+			expectRehash: true,
+		},
+		{
+			name:     "Wrong password",
+			password: "qwerty1",
+			hash:     current,
+			algo:     passwordAlgoBcrypt,
+		},
+		{
+			name:     "Broken bcrypt hash",
+			password: "qwerty",
+			hash:     "$2a$10$broken",
+			algo:     passwordAlgoBcrypt,
+		},
+		{
+			name:         "Legacy hash",
+			password:     "qwerty",
+			hash:         generateLegacyPasswordHash("qwerty"),
+			algo:         passwordAlgoSHA1,
+			expectOk:     true,
+			expectRehash: true,
+		},
+		{
+			name:     "Wrong password for legacy hash",
+			password: "qwerty1",
+			hash:     generateLegacyPasswordHash("qwerty"),
+			algo:     passwordAlgoSHA1,
+		},
+		{
+			name:     "Bcrypt hash taken for legacy",
+			password: "qwerty",
+			hash:     current,
+			algo:     passwordAlgoSHA1,
+		},
+		{
+			name:     "Unknown algorithm",
+			password: "qwerty",
+			hash:     current,
+			algo:     "md5",
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			ok, rehash := verifyPassword(testCase.password, testCase.hash, testCase.algo)
+
+			assert.Equal(t, testCase.expectOk, ok)
+			if ok {
+				assert.Equal(t, testCase.expectRehash, rehash)
+			}
+		})
+	}
+}
@@ -0,0 +1,44 @@
+package service
+
+import (
+	todo "do-app"
+	"github.com/teambition/rrule-go"
+	"strings"
+	"time"
+)
+
+// parseRRule parses a single RFC 5545 RRULE line anchored at dtstart. Rules
+// repeating more often than hourly are rejected, a todo list is no cron.
+func parseRRule(rule string, dtstart time.Time) (*rrule.RRule, error) {
+	if strings.ContainsAny(rule, "\r\n") {
+		return nil, todo.NewError(todo.ErrValidation, "invalid rrule: only a single RRULE line is supported")
+	}
+	option, err := rrule.StrToROption(strings.TrimPrefix(rule, "RRULE:"))
+	if err != nil {
+		return nil, todo.NewError(todo.ErrValidation, "invalid rrule: %w", err)
+	}
+	if option.Freq == rrule.MINUTELY || option.Freq == rrule.SECONDLY {
+		return nil, todo.NewError(todo.ErrValidation, "invalid rrule: frequency %s is not supported", option.Freq)
+	}
+	option.Dtstart = dtstart
+	return rrule.NewRRule(*option)
+}
+
+func validateRRule(rule string) error {
+	_, err := parseRRule(rule, time.Now())
+	return err
+}
+
+// nextOccurrence returns the first date of the series after the given one, or
+// nil once the rule has run out of dates.
+func nextOccurrence(series todo.ItemSeries, after time.Time) (*time.Time, error) {
+	rule, err := parseRRule(series.RRule, series.Dtstart)
+	if err != nil {
+		return nil, err
+	}
+	next := rule.After(after, false)
+	if next.IsZero() {
+		return nil, nil
+	}
+	return &next, nil
+}
@@ -0,0 +1,247 @@
+package service
+
+import (
+	"context"
+	todo "do-app"
+	"do-app/pkg/repository"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestValidateRRule(t *testing.T) {
+	testTable := []struct {
+		name      string
+		rule      string
+		expectErr bool
+	}{
+		{name: "Weekly", rule: "FREQ=WEEKLY;BYDAY=MO,TH"},
+		{name: "RRULE prefix", rule: "RRULE:FREQ=DAILY;INTERVAL=2"},
+		{name: "Count", rule: "FREQ=MONTHLY;COUNT=3"},
+		{name: "Until", rule: "FREQ=DAILY;UNTIL=20300101T000000Z"},
+		{name: "Hourly", rule: "FREQ=HOURLY"},
+		{name: "Minutely", rule: "FREQ=MINUTELY", expectErr: true},
+		{name: "Secondly", rule: "FREQ=SECONDLY", expectErr: true},
+		{name: "Several lines", rule: "RRULE:FREQ=DAILY\r\nEXDATE:20300102T000000Z", expectErr: true},
+		{name: "Unknown frequency", rule: "FREQ=FORTNIGHTLY", expectErr: true},
+		{name: "Not a rule", rule: "every monday", expectErr: true},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			err := validateRRule(testCase.rule)
+			if testCase.expectErr {
+				assert.ErrorIs(t, err, todo.ErrValidation)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestNextOccurrence(t *testing.T) {
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	if err != nil {
+		t.Skip("no time zone database:", err)
+	}
+	date := func(year int, month time.Month, day, hour int, loc *time.Location) time.Time {
+		return time.Date(year, month, day, hour, 0, 0, 0, loc)
+	}
+
+	testTable := []struct {
+		name    string
+		rule    string
+		dtstart time.Time
+		after   time.Time
+		expect  *time.Time
+	}{
+		{
+			name:    "Weekly",
+			rule:    "FREQ=WEEKLY",
+			dtstart: date(2030, 1, 2, 10, time.UTC),
+			after:   date(2030, 1, 2, 10, time.UTC),
+			expect:  timePtr(date(2030, 1, 9, 10, time.UTC)),
+		},
+		{
+			name:    "After a late completion",
+			rule:    "FREQ=WEEKLY",
+			dtstart: date(2030, 1, 2, 10, time.UTC),
+			after:   date(2030, 1, 20, 10, time.UTC),
+			expect:  timePtr(date(2030, 1, 23, 10, time.UTC)),
+		},
+		{
+			name:    "Monthly on the 31st skips short months",
+			rule:    "FREQ=MONTHLY",
+			dtstart: date(2030, 1, 31, 10, time.UTC),
+			after:   date(2030, 1, 31, 10, time.UTC),
+			expect:  timePtr(date(2030, 3, 31, 10, time.UTC)),
+		},
+		{
+			name:    "Last day of the month",
+			rule:    "FREQ=MONTHLY;BYMONTHDAY=-1",
+			dtstart: date(2030, 1, 31, 10, time.UTC),
+			after:   date(2030, 1, 31, 10, time.UTC),
+			expect:  timePtr(date(2030, 2, 28, 10, time.UTC)),
+		},
+		{
+			name:    "Last day of February in a leap year",
+			rule:    "FREQ=MONTHLY;BYMONTHDAY=-1",
+			dtstart: date(2032, 1, 31, 10, time.UTC),
+			after:   date(2032, 1, 31, 10, time.UTC),
+			expect:  timePtr(date(2032, 2, 29, 10, time.UTC)),
+		},
+		{
+			name:    "Count left",
+			rule:    "FREQ=DAILY;COUNT=2",
+			dtstart: date(2030, 1, 2, 10, time.UTC),
+			after:   date(2030, 1, 2, 10, time.UTC),
+			expect:  timePtr(date(2030, 1, 3, 10, time.UTC)),
+		},
+		{
+			name:    "Count used up",
+			rule:    "FREQ=DAILY;COUNT=2",
+			dtstart: date(2030, 1, 2, 10, time.UTC),
+			after:   date(2030, 1, 3, 10, time.UTC),
+		},
+		{
+			name:    "Until not reached",
+			rule:    "FREQ=DAILY;UNTIL=20300104T100000Z",
+			dtstart: date(2030, 1, 2, 10, time.UTC),
+			after:   date(2030, 1, 3, 10, time.UTC),
+			expect:  timePtr(date(2030, 1, 4, 10, time.UTC)),
+		},
+		{
+			name:    "Until passed",
+			rule:    "FREQ=DAILY;UNTIL=20300104T100000Z",
+			dtstart: date(2030, 1, 2, 10, time.UTC),
+			after:   date(2030, 1, 4, 10, time.UTC),
+		},
+		{
+			name:    "Daylight saving time keeps the local hour",
+			rule:    "FREQ=DAILY",
+			dtstart: date(2030, 3, 30, 9, berlin),
+			after:   date(2030, 3, 30, 9, berlin),
+			expect:  timePtr(date(2030, 3, 31, 9, berlin)),
+		},
+		{
+			name:    "Daylight saving time in UTC keeps the UTC hour",
+			rule:    "FREQ=DAILY",
+			dtstart: date(2030, 3, 30, 8, time.UTC),
+			after:   date(2030, 3, 30, 8, time.UTC),
+			expect:  timePtr(date(2030, 3, 31, 8, time.UTC)),
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			next, err := nextOccurrence(todo.ItemSeries{RRule: testCase.rule, Dtstart: testCase.dtstart}, testCase.after)
+			assert.NoError(t, err)
+			if testCase.expect == nil {
+				assert.Nil(t, next)
+				return
+			}
+			if assert.NotNil(t, next) {
+				assert.True(t, testCase.expect.Equal(*next), "expected %s, got %s", testCase.expect, next)
+			}
+		})
+	}
+}
+
+func TestNextItem(t *testing.T) {
+	due := time.Date(2030, 1, 31, 10, 0, 0, 0, time.UTC)
+	remind := due.Add(-time.Hour)
+	newRemind := due.Add(-2 * time.Hour)
+
+	testTable := []struct {
+		name         string
+		rule         string
+		remindAt     *time.Time
+		input        todo.UpdateItemInput
+		expectNil    bool
+		expectDue    time.Time
+		expectRemind *time.Time
+		expectTitle  string
+	}{
+		{
+			name:        "OK",
+			rule:        "FREQ=MONTHLY;BYMONTHDAY=-1",
+			expectDue:   time.Date(2030, 2, 28, 10, 0, 0, 0, time.UTC),
+			expectTitle: "rent",
+		},
+		{
+			name:         "Reminder keeps its distance",
+			rule:         "FREQ=WEEKLY",
+			remindAt:     &remind,
+			expectDue:    due.AddDate(0, 0, 7),
+			expectRemind: timePtr(due.AddDate(0, 0, 7).Add(-time.Hour)),
+			expectTitle:  "rent",
+		},
+		{
+			name:     "Update applies to the next occurrence",
+			rule:     "FREQ=WEEKLY",
+			remindAt: &remind,
+			input: todo.UpdateItemInput{
+				Title:    stringPtr("pay rent"),
+				RemindAt: todo.NullableTime{Set: true, Time: &newRemind},
+			},
+			expectDue:    due.AddDate(0, 0, 7),
+			expectRemind: timePtr(due.AddDate(0, 0, 7).Add(-2 * time.Hour)),
+			expectTitle:  "pay rent",
+		},
+		{
+			name:      "Series ended",
+			rule:      "FREQ=WEEKLY;COUNT=1",
+			expectNil: true,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			ctx := context.Background()
+			repos := repository.NewMemoryRepository()
+			userId := createTestUser(t, repos, "alice")
+			listId, err := repos.TodoLists.Create(ctx, userId, todo.TodoList{Title: "bills"})
+			if err != nil {
+				t.Fatal(err)
+			}
+			itemId, err := repos.TodoItems.Create(ctx, userId, listId, todo.TodoItem{Title: "rent", DueAt: &due,
+				RemindAt: testCase.remindAt, RRule: testCase.rule})
+			if err != nil {
+				t.Fatal(err)
+			}
+			item, err := repos.TodoItems.GetById(ctx, userId, itemId)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			next, err := nextItem(ctx, repos.TodoItems, item, testCase.input)
+
+			assert.NoError(t, err)
+			if testCase.expectNil {
+				assert.Nil(t, next)
+				return
+			}
+			if !assert.NotNil(t, next) {
+				return
+			}
+			assert.Equal(t, listId, next.ListId)
+			assert.Equal(t, testCase.expectTitle, next.Title)
+			assert.Equal(t, item.SeriesId, next.SeriesId)
+			assert.True(t, testCase.expectDue.Equal(*next.DueAt), "expected due %s, got %s", testCase.expectDue, next.DueAt)
+			if testCase.expectRemind == nil {
+				assert.Nil(t, next.RemindAt)
+			} else if assert.NotNil(t, next.RemindAt) {
+				assert.True(t, testCase.expectRemind.Equal(*next.RemindAt),
+					"expected reminder %s, got %s", testCase.expectRemind, next.RemindAt)
+			}
+		})
+	}
+}
+
+func timePtr(t time.Time) *time.Time {
+	return &t
+}
+
+func stringPtr(s string) *string {
+	return &s
+}
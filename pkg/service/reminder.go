@@ -0,0 +1,108 @@
+package service
+
+import (
+	"context"
+	todo "do-app"
+	"do-app/pkg/notifier"
+	"do-app/pkg/repository"
+	"github.com/sirupsen/logrus"
+	"time"
+)
+
+type ReminderConfig struct {
+	Interval   time.Duration
+	BatchSize  int
+	RetryDelay time.Duration
+}
+
+// ReminderScheduler periodically hands due reminders to a notifier until it
+// is shut down.
+type ReminderScheduler struct {
+	repo     repository.Reminders
+	notifier notifier.Notifier
+	cfg      ReminderConfig
+	stop     chan struct{}
+	cancel   context.CancelFunc
+	done     chan struct{}
+}
+
+func NewReminderScheduler(repo repository.Reminders, notifier notifier.Notifier, cfg ReminderConfig) *ReminderScheduler {
+	if cfg.Interval <= 0 {
+		cfg.Interval = time.Minute
+	}
+	if cfg.BatchSize <= 0 {
+		cfg.BatchSize = 100
+	}
+	if cfg.RetryDelay <= 0 {
+		cfg.RetryDelay = 5 * time.Minute
+	}
+	return &ReminderScheduler{
+		repo:     repo,
+		notifier: notifier,
+		cfg:      cfg,
+		stop:     make(chan struct{}),
+		done:     make(chan struct{}),
+	}
+}
+
+func (s *ReminderScheduler) Start() {
+	ctx, cancel := context.WithCancel(context.Background())
+	s.cancel = cancel
+	go s.run(ctx)
+}
+
+// Shutdown stops polling and waits for the batch in progress to finish. If
+// ctx expires first, the queries still running are canceled.
+func (s *ReminderScheduler) Shutdown(ctx context.Context) error {
+	defer s.cancel()
+	close(s.stop)
+	select {
+	case <-s.done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (s *ReminderScheduler) run(ctx context.Context) {
+	defer close(s.done)
+
+	ticker := time.NewTicker(s.cfg.Interval)
+	defer ticker.Stop()
+
+	for {
+		s.poll(ctx)
+		select {
+		case <-s.stop:
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// poll processes batches until there are no due reminders left.
+func (s *ReminderScheduler) poll(ctx context.Context) {
+	for {
+		processed, err := s.repo.ProcessDue(ctx, s.cfg.BatchSize, s.cfg.RetryDelay, s.deliver)
+		if err != nil {
+			logrus.Errorf("process reminders: %s", err.Error())
+			return
+		}
+		if processed < s.cfg.BatchSize {
+			return
+		}
+		select {
+		case <-s.stop:
+			return
+		default:
+		}
+	}
+}
+
+func (s *ReminderScheduler) deliver(ctx context.Context, reminder todo.Reminder) error {
+	if err := s.notifier.Notify(ctx, reminder); err != nil {
+		logrus.Errorf("deliver reminder of item %d: %s", reminder.ItemId, err.Error())
+		return err
+	}
+	return nil
+}
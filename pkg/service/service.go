@@ -1,6 +1,7 @@
 package service
 
 import (
+	"context"
 	todo "do-app"
 	"do-app/pkg/repository"
 )
@@ -8,37 +9,116 @@ import (
 //go:generate mockgen -source=service.go -destination=mocks/mock.go
 
 type Authorization interface {
-	CreateUser(user todo.User) (int, error)
-	GenerateToken(username, password string) (string, error)
-	ParseToken(token string) (int, error)
+	CreateUser(ctx context.Context, user todo.User) (int, error)
+	GenerateToken(ctx context.Context, username, password string) (todo.Tokens, error)
+	RefreshToken(ctx context.Context, refreshToken string) (todo.Tokens, error)
+	SignOut(ctx context.Context, refreshToken string) error
+	ParseToken(ctx context.Context, token string) (int, error)
+	JWKS() todo.JSONWebKeySet
 }
 
 type TodoLists interface {
-	Create(userId int, list todo.TodoList) (int, error)
-	GetAll(userId int) ([]todo.TodoList, error)
-	GetById(userId, listId int) (todo.TodoList, error)
-	Delete(userId, listId int) error
-	Update(userId, listId int, input todo.UpdateListInput) error
+	Create(ctx context.Context, userId int, list todo.TodoList) (int, error)
+	GetAll(ctx context.Context, userId int, filter todo.ListFilter, page todo.PageRequest) ([]todo.TodoList, string, error)
+	GetById(ctx context.Context, userId, listId int) (todo.TodoList, error)
+	Copy(ctx context.Context, userId, listId int) (int, error)
+	Delete(ctx context.Context, userId, listId, version int) (string, error)
+	Update(ctx context.Context, userId, listId int, input todo.UpdateListInput, version int) (string, int, error)
+}
+
+type ListMembers interface {
+	GetAll(ctx context.Context, userId, listId int) ([]todo.ListMember, error)
+	Add(ctx context.Context, userId, listId int, input todo.AddMemberInput) (int, error)
+	UpdateRole(ctx context.Context, userId, listId, memberId int, input todo.UpdateMemberInput) error
+	Remove(ctx context.Context, userId, listId, memberId int) error
+}
+
+type ListInvites interface {
+	Create(ctx context.Context, userId, listId int, input todo.CreateInviteInput) (todo.ListInvite, error)
+	GetAll(ctx context.Context, userId, listId int) ([]todo.ListInvite, error)
+	Revoke(ctx context.Context, userId, listId, inviteId int) error
+	Accept(ctx context.Context, userId int, token string) (int, error)
 }
 
 type TodoItems interface {
-	Create(userId, listId int, input todo.TodoItem) (int, error)
-	GetAll(userId, listId int) ([]todo.TodoItem, error)
-	GetById(userId, itemId int) (todo.TodoItem, error)
-	Delete(userId, itemId int) error
-	Update(userId, itemId int, input todo.UpdateItemInput) error
+	Create(ctx context.Context, userId, listId int, input todo.TodoItem) (int, error)
+	GetAll(ctx context.Context, userId, listId int, filter todo.ItemFilter,
+		page todo.PageRequest) ([]todo.TodoItem, string, error)
+	GetAllByUser(ctx context.Context, userId int, filter todo.ItemFilter,
+		page todo.PageRequest) ([]todo.TodoItem, string, error)
+	GetById(ctx context.Context, userId, itemId int) (todo.TodoItem, error)
+	Delete(ctx context.Context, userId, itemId, version int) (string, error)
+	Update(ctx context.Context, userId, itemId int, input todo.UpdateItemInput, version int) (string, int, error)
+	Move(ctx context.Context, userId, itemId int, input todo.MoveItemInput) (float64, error)
+	GetSeries(ctx context.Context, userId, itemId int) (todo.ItemSeries, error)
+	UpdateSeries(ctx context.Context, userId, itemId int, input todo.UpdateSeriesInput) error
+	StopSeries(ctx context.Context, userId, itemId int) error
+}
+
+type Subtasks interface {
+	Create(ctx context.Context, userId, itemId int, subtask todo.Subtask) (int, error)
+	GetAll(ctx context.Context, userId, itemId int) ([]todo.Subtask, error)
+	Update(ctx context.Context, userId, itemId, subtaskId int, input todo.UpdateSubtaskInput) error
+	Delete(ctx context.Context, userId, itemId, subtaskId int) error
+}
+
+type Labels interface {
+	Create(ctx context.Context, userId int, label todo.Label) (int, error)
+	GetAll(ctx context.Context, userId int) ([]todo.Label, error)
+	GetById(ctx context.Context, userId, labelId int) (todo.Label, error)
+	Update(ctx context.Context, userId, labelId int, input todo.UpdateLabelInput) error
+	Delete(ctx context.Context, userId, labelId int) error
+	GetByItem(ctx context.Context, userId, itemId int) ([]todo.Label, error)
+	Attach(ctx context.Context, userId, itemId, labelId int) error
+	Detach(ctx context.Context, userId, itemId, labelId int) error
+}
+
+type Search interface {
+	Search(ctx context.Context, userId int, query todo.SearchQuery) ([]todo.SearchResult, error)
+}
+
+type Trash interface {
+	GetAll(ctx context.Context, userId int) ([]todo.TrashEntry, error)
+	Restore(ctx context.Context, userId int, entryType string, id int) error
+}
+
+type Activity interface {
+	GetByList(ctx context.Context, userId, listId int, page todo.PageRequest) ([]todo.Activity, string, error)
+	GetByItem(ctx context.Context, userId, itemId int, page todo.PageRequest) ([]todo.Activity, string, error)
+}
+
+type Undo interface {
+	Undo(ctx context.Context, userId int, token string) error
 }
 
 type Service struct {
 	Authorization
 	TodoLists
+	ListMembers
+	ListInvites
 	TodoItems
+	Subtasks
+	Labels
+	Search
+	Trash
+	Activity
+	Undo
 }
 
-func NewService(repos *repository.Repository) *Service {
+func NewService(repos *repository.Repository, auth AuthConfig, undoConfig UndoConfig) *Service {
+	undo := NewUndoService(repos.Undo, undoConfig)
+	items := NewTodoItemService(repos.TodoItems, repos.TodoLists, repos.ListMembers, repos.Transactor, undo)
 	return &Service{
-		Authorization: NewAuthService(repos.Authorization),
-		TodoLists:     NewTodoListService(repos.TodoLists),
-		TodoItems:     NewTodoItemService(repos.TodoItems, repos.TodoLists),
+		Authorization: NewAuthService(repos.Authorization, repos.Sessions, auth),
+		TodoLists:     NewTodoListService(repos.TodoLists, repos.TodoItems, repos.ListMembers, repos.Transactor, undo),
+		ListMembers:   NewListMemberService(repos.ListMembers, repos.Transactor),
+		ListInvites:   NewListInviteService(repos.ListInvites, repos.ListMembers),
+		TodoItems:     items,
+		Subtasks:      NewSubtaskService(repos.Subtasks, repos.TodoItems, repos.ListMembers, repos.Transactor),
+		Labels:        NewLabelService(repos.Labels, repos.TodoItems),
+		Search:        NewSearchService(repos.Search),
+		Trash:         NewTrashService(repos.Trash),
+		Activity:      NewActivityService(repos.Activity, repos.TodoItems, repos.ListMembers),
+		Undo:          undo,
 	}
 }
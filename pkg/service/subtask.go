@@ -0,0 +1,105 @@
+package service
+
+import (
+	"context"
+	todo "do-app"
+	"do-app/pkg/repository"
+	"fmt"
+)
+
+type SubtaskService struct {
+	repo       repository.Subtasks
+	itemRepo   repository.TodoItems
+	memberRepo repository.ListMembers
+	tx         repository.Transactor
+}
+
+func NewSubtaskService(repo repository.Subtasks, itemRepo repository.TodoItems, memberRepo repository.ListMembers,
+	tx repository.Transactor) *SubtaskService {
+	return &SubtaskService{repo: repo, itemRepo: itemRepo, memberRepo: memberRepo, tx: tx}
+}
+
+func (s *SubtaskService) Create(ctx context.Context, userId, itemId int, subtask todo.Subtask) (int, error) {
+	var subtaskId int
+	err := s.tx.WithinTx(ctx, repository.TxOptions{}, func(ctx context.Context) error {
+		if _, err := editableItem(ctx, s.itemRepo, s.memberRepo, userId, itemId); err != nil {
+			return err
+		}
+		var err error
+		subtaskId, err = s.repo.Create(ctx, itemId, subtask)
+		return err
+	})
+	if err != nil {
+		return 0, fmt.Errorf("Create subtask service: %w", err)
+	}
+	return subtaskId, nil
+}
+
+func (s *SubtaskService) GetAll(ctx context.Context, userId, itemId int) ([]todo.Subtask, error) {
+	if _, err := s.itemRepo.GetById(ctx, userId, itemId); err != nil {
+		return nil, fmt.Errorf("GetAll subtask service: %w", err)
+	}
+	return s.repo.GetAll(ctx, itemId)
+}
+
+// Update changes the subtask and completes its item when that was the last
+// open subtask of an auto-completing item. Both happen in one serializable
+// unit of work, so concurrent updates of two subtasks cannot each miss the
+// other and leave the item open.
+func (s *SubtaskService) Update(ctx context.Context, userId, itemId, subtaskId int, input todo.UpdateSubtaskInput) error {
+	if err := input.Validate(); err != nil {
+		return err
+	}
+	err := s.tx.WithinTx(ctx, serializableTx, func(ctx context.Context) error {
+		item, err := editableItem(ctx, s.itemRepo, s.memberRepo, userId, itemId)
+		if err != nil {
+			return err
+		}
+		if err = s.repo.Update(ctx, itemId, subtaskId, input); err != nil {
+			return err
+		}
+		return s.completeParent(ctx, userId, item)
+	})
+	if err != nil {
+		return fmt.Errorf("Update subtask service: %w", err)
+	}
+	return nil
+}
+
+// Delete removes the subtask and, like Update, completes the item once the
+// remaining subtasks are all done.
+func (s *SubtaskService) Delete(ctx context.Context, userId, itemId, subtaskId int) error {
+	err := s.tx.WithinTx(ctx, serializableTx, func(ctx context.Context) error {
+		item, err := editableItem(ctx, s.itemRepo, s.memberRepo, userId, itemId)
+		if err != nil {
+			return err
+		}
+		if err = s.repo.Delete(ctx, itemId, subtaskId); err != nil {
+			return err
+		}
+		return s.completeParent(ctx, userId, item)
+	})
+	if err != nil {
+		return fmt.Errorf("Delete subtask service: %w", err)
+	}
+	return nil
+}
+
+// completeParent marks an auto-completing item done once its last open
+// subtask is done. Recurring items get their next occurrence like on any
+// other completion.
+func (s *SubtaskService) completeParent(ctx context.Context, userId int, item todo.TodoItem) error {
+	if !item.AutoComplete || item.Done {
+		return nil
+	}
+	done, total, err := s.repo.Progress(ctx, item.Id)
+	if err != nil {
+		return err
+	}
+	if total == 0 || done < total {
+		return nil
+	}
+	complete := true
+	_, err = updateOccurrence(ctx, s.itemRepo, userId, item, todo.UpdateItemInput{Done: &complete}, 0)
+	return err
+}
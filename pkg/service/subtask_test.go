@@ -0,0 +1,96 @@
+package service
+
+import (
+	"context"
+	todo "do-app"
+	"do-app/pkg/repository"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestSubtaskService_completeParent(t *testing.T) {
+	due := time.Date(2030, 1, 2, 10, 0, 0, 0, time.UTC)
+
+	testTable := []struct {
+		name           string
+		item           todo.TodoItem
+		complete       []int
+		deleteOpen     bool
+		expectDone     bool
+		expectNextItem bool
+	}{
+		{
+			name:       "Last subtask done",
+			item:       todo.TodoItem{Title: "pack", AutoComplete: true},
+			complete:   []int{0, 1},
+			expectDone: true,
+		},
+		{
+			name:     "Subtask left open",
+			item:     todo.TodoItem{Title: "pack", AutoComplete: true},
+			complete: []int{0},
+		},
+		{
+			name:       "Open subtask deleted",
+			item:       todo.TodoItem{Title: "pack", AutoComplete: true},
+			complete:   []int{0},
+			deleteOpen: true,
+			expectDone: true,
+		},
+		{
+			name:     "No auto-completion",
+			item:     todo.TodoItem{Title: "pack"},
+			complete: []int{0, 1},
+		},
+		{
+			name:           "Recurring item",
+			item:           todo.TodoItem{Title: "pack", AutoComplete: true, DueAt: &due, RRule: "FREQ=WEEKLY"},
+			complete:       []int{0, 1},
+			expectDone:     true,
+			expectNextItem: true,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			ctx := context.Background()
+			repos := repository.NewMemoryRepository()
+			userId := createTestUser(t, repos, "alice")
+			listId, err := repos.TodoLists.Create(ctx, userId, todo.TodoList{Title: "trip"})
+			if err != nil {
+				t.Fatal(err)
+			}
+			itemId, err := repos.TodoItems.Create(ctx, userId, listId, testCase.item)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			s := NewSubtaskService(repos.Subtasks, repos.TodoItems, repos.ListMembers, repos.Transactor)
+			subtaskIds := make([]int, 2)
+			for i, title := range []string{"socks", "charger"} {
+				if subtaskIds[i], err = s.Create(ctx, userId, itemId, todo.Subtask{Title: title}); err != nil {
+					t.Fatal(err)
+				}
+			}
+			done := true
+			for _, i := range testCase.complete {
+				assert.NoError(t, s.Update(ctx, userId, itemId, subtaskIds[i], todo.UpdateSubtaskInput{Done: &done}))
+			}
+			if testCase.deleteOpen {
+				assert.NoError(t, s.Delete(ctx, userId, itemId, subtaskIds[1]))
+			}
+
+			item, err := repos.TodoItems.GetById(ctx, userId, itemId)
+			assert.NoError(t, err)
+			assert.Equal(t, testCase.expectDone, item.Done)
+			items, _, err := repos.TodoItems.GetAll(ctx, userId, listId, todo.ItemFilter{}, todo.PageRequest{})
+			assert.NoError(t, err)
+			if testCase.expectNextItem {
+				assert.Len(t, items, 2)
+			} else {
+				assert.Len(t, items, 1)
+			}
+		})
+	}
+}
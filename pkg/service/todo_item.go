@@ -1,40 +1,378 @@
 package service
 
 import (
+	"context"
+	"database/sql"
 	todo "do-app"
 	"do-app/pkg/repository"
 	"fmt"
 )
 
+var (
+	errNoDueDate    = todo.NewError(todo.ErrValidation, "recurring items need a due date")
+	errNotRecurring = todo.NewError(todo.ErrConflict, "item is not recurring")
+)
+
+// serializableTx runs units of work whose checks must still hold when they
+// commit, e.g. that the list an item is added to has not been deleted.
+var serializableTx = repository.TxOptions{Isolation: sql.LevelSerializable}
+
 type TodoItemService struct {
-	repo     repository.TodoItems
-	listRepo repository.TodoLists
+	repo       repository.TodoItems
+	listRepo   repository.TodoLists
+	memberRepo repository.ListMembers
+	tx         repository.Transactor
+	undo       *UndoService
 }
 
-func NewTodoItemService(repo repository.TodoItems, listRepo repository.TodoLists) *TodoItemService {
-	return &TodoItemService{repo: repo, listRepo: listRepo}
+func NewTodoItemService(repo repository.TodoItems, listRepo repository.TodoLists,
+	memberRepo repository.ListMembers, tx repository.Transactor, undo *UndoService) *TodoItemService {
+	return &TodoItemService{repo: repo, listRepo: listRepo, memberRepo: memberRepo, tx: tx, undo: undo}
 }
 
-func (i *TodoItemService) Create(userId, listId int, input todo.TodoItem) (int, error) {
-	_, err := i.listRepo.GetById(userId, listId)
+// Create adds the item to the list. The list is checked and the item inserted
+// in one unit of work, so the item cannot end up in a list deleted meanwhile.
+// Only the fields a client chooses are taken from input: the series, position,
+// state and version of a new item are set by the repository.
+func (i *TodoItemService) Create(ctx context.Context, userId, listId int, input todo.TodoItem) (int, error) {
+	input = todo.TodoItem{
+		Title:        input.Title,
+		Description:  input.Description,
+		DueAt:        input.DueAt,
+		RemindAt:     input.RemindAt,
+		RRule:        input.RRule,
+		Priority:     input.Priority,
+		AutoComplete: input.AutoComplete,
+	}
+	var itemId int
+	err := i.tx.WithinTx(ctx, serializableTx, func(ctx context.Context) error {
+		_, err := i.listRepo.GetById(ctx, userId, listId)
+		if err != nil {
+			return err
+		}
+		role, err := i.memberRepo.GetRole(ctx, userId, listId)
+		if err != nil {
+			return err
+		}
+		if !todo.CanEdit(role) {
+			return todo.NewError(todo.ErrForbidden, "viewers cannot add items")
+		}
+		if !todo.ValidPriority(input.Priority) {
+			return todo.NewError(todo.ErrValidation,
+				"priority must be between %d and %d", todo.PriorityNone, todo.PriorityHigh)
+		}
+		if input.RRule != "" {
+			if input.DueAt == nil {
+				return errNoDueDate
+			}
+			if err = validateRRule(input.RRule); err != nil {
+				return err
+			}
+		}
+		itemId, err = i.repo.Create(ctx, userId, listId, input)
+		return err
+	})
 	if err != nil {
 		return 0, fmt.Errorf("Create service item: %w", err)
 	}
-	return i.repo.Create(listId, input)
+	return itemId, nil
+}
+
+var itemSorts = []string{todo.SortPosition, todo.SortTitle, todo.SortCreated, todo.SortUpdated, todo.SortDue, todo.SortPriority}
+
+func (s *TodoItemService) GetAll(ctx context.Context, userId, listId int, filter todo.ItemFilter,
+	page todo.PageRequest) ([]todo.TodoItem, string, error) {
+	if err := page.Validate(itemSorts...); err != nil {
+		return nil, "", err
+	}
+	return s.repo.GetAll(ctx, userId, listId, filter, page)
+}
+
+func (s *TodoItemService) GetAllByUser(ctx context.Context, userId int, filter todo.ItemFilter,
+	page todo.PageRequest) ([]todo.TodoItem, string, error) {
+	if err := page.Validate(itemSorts...); err != nil {
+		return nil, "", err
+	}
+	return s.repo.GetAllByUser(ctx, userId, filter, page)
+}
+
+func (s *TodoItemService) GetById(ctx context.Context, userId, itemId int) (todo.TodoItem, error) {
+	return s.repo.GetById(ctx, userId, itemId)
+}
+
+// Delete removes the item and returns a token that undoes the deletion. A
+// non-zero version is the version the client expects the item to be at.
+func (s *TodoItemService) Delete(ctx context.Context, userId, itemId, version int) (string, error) {
+	var token string
+	err := s.tx.WithinTx(ctx, repository.TxOptions{}, func(ctx context.Context) error {
+		item, err := s.editableItem(ctx, userId, itemId)
+		if err != nil {
+			return err
+		}
+		if err = checkVersion(item, version); err != nil {
+			return err
+		}
+		activityId, err := s.repo.Delete(ctx, userId, itemId, version)
+		if err != nil {
+			return err
+		}
+		token, err = s.undo.issue(ctx, userId, activityId)
+		return err
+	})
+	if err != nil {
+		return "", fmt.Errorf("Delete service item: %w", err)
+	}
+	return token, nil
+}
+
+// Update changes the item and returns the version it is at afterwards. Marking
+// an open recurring item done also creates its next occurrence, and a non-nil
+// RRule makes the item recurring or, when empty, stops its series. A non-zero
+// version is the version the client expects the item to be at. The returned
+// token undoes the change; changes of the series cannot be undone and return
+// no token.
+func (s *TodoItemService) Update(ctx context.Context, userId, itemId int, input todo.UpdateItemInput,
+	version int) (string, int, error) {
+	if err := input.Validate(); err != nil {
+		return "", 0, fmt.Errorf("Update service item: %w", err)
+	}
+	var token string
+	var newVersion int
+	err := s.tx.WithinTx(ctx, repository.TxOptions{}, func(ctx context.Context) error {
+		activityId, err := s.update(ctx, userId, itemId, input, version)
+		if err != nil {
+			return err
+		}
+		if activityId != 0 {
+			if token, err = s.undo.issue(ctx, userId, activityId); err != nil {
+				return err
+			}
+		}
+		item, err := s.repo.GetById(ctx, userId, itemId)
+		newVersion = item.Version
+		return err
+	})
+	if err != nil {
+		return "", 0, fmt.Errorf("Update service item: %w", err)
+	}
+	return token, newVersion, nil
+}
+
+// update applies the change of Update and returns the id of its activity
+// record, or 0 for changes of the series.
+func (s *TodoItemService) update(ctx context.Context, userId, itemId int, input todo.UpdateItemInput, version int) (int, error) {
+	item, err := s.editableItem(ctx, userId, itemId)
+	if err != nil {
+		return 0, err
+	}
+	if err = checkVersion(item, version); err != nil {
+		return 0, err
+	}
+	completing := input.Done != nil && *input.Done
+	if input.RRule == nil && !completing {
+		return s.repo.Update(ctx, userId, itemId, input, version)
+	}
+	if input.DueAt.Set {
+		item.DueAt = input.DueAt.Time
+	}
+
+	if input.RRule != nil {
+		if err = s.setRule(ctx, userId, item, *input.RRule, version); err != nil {
+			return 0, err
+		}
+		if *input.RRule != "" {
+			// Setting the rule has checked the version and gave the item a new
+			// one, and the item stays locked until the unit of work ends.
+			version = 0
+		}
+		item.RRule = *input.RRule
+		input.RRule = nil
+		if input == (todo.UpdateItemInput{}) {
+			return 0, nil
+		}
+	}
+
+	return updateOccurrence(ctx, s.repo, userId, item, input, version)
+}
+
+// updateOccurrence applies input to the item. Marking an open recurring item
+// done creates its next occurrence in the same step; that change of the
+// series returns no activity id.
+func updateOccurrence(ctx context.Context, repo repository.TodoItems, userId int, item todo.TodoItem,
+	input todo.UpdateItemInput, version int) (int, error) {
+	completing := input.Done != nil && *input.Done
+	if completing && !item.Done && item.RRule != "" && item.DueAt != nil {
+		next, err := nextItem(ctx, repo, item, input)
+		if err != nil {
+			return 0, err
+		}
+		if next != nil {
+			_, err = repo.CompleteOccurrence(ctx, userId, item.Id, input, *next, version)
+			return 0, err
+		}
+	}
+	return repo.Update(ctx, userId, item.Id, input, version)
+}
+
+// Move reorders the item inside its list or moves it to another list the user
+// owns. Editors of a shared list may reorder its items but not take them to
+// another list. The checks and the move run in one unit of work.
+func (s *TodoItemService) Move(ctx context.Context, userId, itemId int, input todo.MoveItemInput) (float64, error) {
+	if err := input.Validate(); err != nil {
+		return 0, fmt.Errorf("Move service item: %w", err)
+	}
+	if (input.AfterId != nil && *input.AfterId == itemId) || (input.BeforeId != nil && *input.BeforeId == itemId) {
+		return 0, fmt.Errorf("Move service item: %w",
+			todo.NewError(todo.ErrValidation, "item cannot be placed next to itself"))
+	}
+
+	var position float64
+	err := s.tx.WithinTx(ctx, serializableTx, func(ctx context.Context) error {
+		item, err := s.editableItem(ctx, userId, itemId)
+		if err != nil {
+			return err
+		}
+
+		listId := item.ListId
+		if input.ListId != nil && *input.ListId != listId {
+			role, err := s.memberRepo.GetRole(ctx, userId, *input.ListId)
+			if err != nil {
+				return err
+			}
+			if role != todo.RoleOwner {
+				return todo.NewError(todo.ErrForbidden, "items can only be moved to lists you own")
+			}
+			listId = *input.ListId
+		}
+		position, err = s.repo.Move(ctx, userId, itemId, listId, input)
+		return err
+	})
+	if err != nil {
+		return 0, fmt.Errorf("Move service item: %w", err)
+	}
+	return position, nil
+}
+
+func (s *TodoItemService) GetSeries(ctx context.Context, userId, itemId int) (todo.ItemSeries, error) {
+	if _, err := s.repo.GetById(ctx, userId, itemId); err != nil {
+		return todo.ItemSeries{}, fmt.Errorf("GetSeries service item: %w", err)
+	}
+	return s.repo.GetSeries(ctx, itemId)
+}
+
+func (s *TodoItemService) UpdateSeries(ctx context.Context, userId, itemId int, input todo.UpdateSeriesInput) error {
+	if err := input.Validate(); err != nil {
+		return fmt.Errorf("UpdateSeries service item: %w", err)
+	}
+	if input.RRule != nil {
+		if err := validateRRule(*input.RRule); err != nil {
+			return fmt.Errorf("UpdateSeries service item: %w", err)
+		}
+	}
+	item, err := s.editableItem(ctx, userId, itemId)
+	if err != nil {
+		return fmt.Errorf("UpdateSeries service item: %w", err)
+	}
+	if item.SeriesId == nil {
+		return fmt.Errorf("UpdateSeries service item: %w", errNotRecurring)
+	}
+	return s.repo.UpdateSeries(ctx, userId, *item.SeriesId, input)
 }
 
-func (s *TodoItemService) GetAll(userId, listId int) ([]todo.TodoItem, error) {
-	return s.repo.GetAll(userId, listId)
+func (s *TodoItemService) StopSeries(ctx context.Context, userId, itemId int) error {
+	item, err := s.editableItem(ctx, userId, itemId)
+	if err != nil {
+		return fmt.Errorf("StopSeries service item: %w", err)
+	}
+	if item.SeriesId == nil {
+		return fmt.Errorf("StopSeries service item: %w", errNotRecurring)
+	}
+	return s.repo.StopSeries(ctx, userId, *item.SeriesId)
 }
 
-func (s *TodoItemService) GetById(userId, itemId int) (todo.TodoItem, error) {
-	return s.repo.GetById(userId, itemId)
+// checkVersion fails if the client expects the item at another version than
+// the one it has been loaded at.
+func checkVersion(item todo.TodoItem, version int) error {
+	if version != 0 && item.Version != version {
+		return todo.NewError(todo.ErrPreconditionFailed, "item has been changed by someone else")
+	}
+	return nil
 }
 
-func (s *TodoItemService) Delete(userId, itemId int) error {
-	return s.repo.Delete(userId, itemId)
+func (s *TodoItemService) editableItem(ctx context.Context, userId, itemId int) (todo.TodoItem, error) {
+	return editableItem(ctx, s.repo, s.memberRepo, userId, itemId)
 }
 
-func (s *TodoItemService) Update(userId, itemId int, input todo.UpdateItemInput) error {
-	return s.repo.Update(userId, itemId, input)
+// editableItem loads the item and checks that the user may change it.
+func editableItem(ctx context.Context, repo repository.TodoItems, memberRepo repository.ListMembers,
+	userId, itemId int) (todo.TodoItem, error) {
+	item, err := repo.GetById(ctx, userId, itemId)
+	if err != nil {
+		return item, err
+	}
+	role, err := memberRepo.GetRole(ctx, userId, item.ListId)
+	if err != nil {
+		return item, err
+	}
+	if !todo.CanEdit(role) {
+		return item, todo.NewError(todo.ErrForbidden, "viewers cannot change items")
+	}
+	return item, nil
+}
+
+func (s *TodoItemService) setRule(ctx context.Context, userId int, item todo.TodoItem, rule string, version int) error {
+	if rule == "" {
+		if item.SeriesId == nil {
+			return nil
+		}
+		return s.repo.StopSeries(ctx, userId, *item.SeriesId)
+	}
+	if item.DueAt == nil {
+		return errNoDueDate
+	}
+	if err := validateRRule(rule); err != nil {
+		return err
+	}
+	_, err := s.repo.SetSeriesRule(ctx, userId, item.Id, rule, *item.DueAt, version)
+	return err
+}
+
+// nextItem builds the occurrence that follows item, with the update applied
+// and the reminder kept at the same distance from the due date. It returns nil
+// once the series has no more dates.
+func nextItem(ctx context.Context, repo repository.TodoItems, item todo.TodoItem,
+	input todo.UpdateItemInput) (*todo.TodoItem, error) {
+	series, err := repo.GetSeries(ctx, item.Id)
+	if err != nil {
+		return nil, err
+	}
+	dueAt, err := nextOccurrence(series, *item.DueAt)
+	if err != nil || dueAt == nil {
+		return nil, err
+	}
+
+	next := todo.TodoItem{
+		ListId:       item.ListId,
+		Title:        item.Title,
+		Description:  item.Description,
+		DueAt:        dueAt,
+		SeriesId:     &series.Id,
+		Priority:     item.Priority,
+		AutoComplete: item.AutoComplete,
+	}
+	if input.Title != nil {
+		next.Title = *input.Title
+	}
+	if input.Description != nil {
+		next.Description = *input.Description
+	}
+	remindAt := item.RemindAt
+	if input.RemindAt.Set {
+		remindAt = input.RemindAt.Time
+	}
+	if remindAt != nil {
+		nextRemindAt := dueAt.Add(remindAt.Sub(*item.DueAt))
+		next.RemindAt = &nextRemindAt
+	}
+	return &next, nil
 }
@@ -0,0 +1,121 @@
+package service
+
+import (
+	"context"
+	todo "do-app"
+	"do-app/pkg/repository"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestTodoItemService_Move(t *testing.T) {
+	testTable := []struct {
+		name       string
+		sourceRole string
+		targetRole string
+		toOther    bool
+		expectErr  error
+	}{
+		{
+			name:       "Reorder as owner",
+			sourceRole: todo.RoleOwner,
+		},
+		{
+			name:       "Reorder as editor",
+			sourceRole: todo.RoleEditor,
+		},
+		{
+			name:       "Reorder as viewer",
+			sourceRole: todo.RoleViewer,
+			expectErr:  todo.ErrForbidden,
+		},
+		{
+			name:       "To an owned list",
+			sourceRole: todo.RoleEditor,
+			targetRole: todo.RoleOwner,
+			toOther:    true,
+		},
+		{
+			name:       "To a list the user edits",
+			sourceRole: todo.RoleOwner,
+			targetRole: todo.RoleEditor,
+			toOther:    true,
+			expectErr:  todo.ErrForbidden,
+		},
+		{
+			name:       "To a list of someone else",
+			sourceRole: todo.RoleOwner,
+			toOther:    true,
+			expectErr:  todo.ErrNotFound,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			ctx := context.Background()
+			repos := repository.NewMemoryRepository()
+			aliceId := createTestUser(t, repos, "alice")
+			bobId := createTestUser(t, repos, "bob")
+			// listFor returns a list of alice that bob has the given role in,
+			// or a list of bob's own for the owner role.
+			listFor := func(role string) int {
+				ownerId := aliceId
+				if role == todo.RoleOwner {
+					ownerId = bobId
+				}
+				listId, err := repos.TodoLists.Create(ctx, ownerId, todo.TodoList{Title: "list"})
+				if err != nil {
+					t.Fatal(err)
+				}
+				if role != "" && role != todo.RoleOwner {
+					if _, err = repos.ListMembers.Add(ctx, listId, todo.AddMemberInput{Username: "bob", Role: role}); err != nil {
+						t.Fatal(err)
+					}
+				}
+				return listId
+			}
+
+			sourceId := listFor(testCase.sourceRole)
+			ownerId := aliceId
+			if testCase.sourceRole == todo.RoleOwner {
+				ownerId = bobId
+			}
+			first, err := repos.TodoItems.Create(ctx, ownerId, sourceId, todo.TodoItem{Title: "first"})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if _, err = repos.TodoItems.Create(ctx, ownerId, sourceId, todo.TodoItem{Title: "second"}); err != nil {
+				t.Fatal(err)
+			}
+			input := todo.MoveItemInput{}
+			if testCase.toOther {
+				targetId := listFor(testCase.targetRole)
+				input.ListId = &targetId
+			}
+
+			s := NewTodoItemService(repos.TodoItems, repos.TodoLists, repos.ListMembers, repos.Transactor, nil)
+			_, err = s.Move(ctx, bobId, first, input)
+
+			if testCase.expectErr != nil {
+				assert.ErrorIs(t, err, testCase.expectErr)
+				return
+			}
+			assert.NoError(t, err)
+			item, err := repos.TodoItems.GetById(ctx, bobId, first)
+			assert.NoError(t, err)
+			if input.ListId != nil {
+				assert.Equal(t, *input.ListId, item.ListId)
+			} else {
+				assert.Equal(t, sourceId, item.ListId)
+			}
+		})
+	}
+}
+
+func createTestUser(t *testing.T, repos *repository.Repository, username string) int {
+	id, err := repos.CreateUser(context.Background(), todo.User{Name: username, Username: username, Password: "hash"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return id
+}
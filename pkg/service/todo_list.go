@@ -1,37 +1,146 @@
 package service
 
 import (
+	"context"
+	"database/sql"
 	todo "do-app"
 	"do-app/pkg/repository"
+	"fmt"
 )
 
 type TodoListService struct {
-	repo repository.TodoLists
+	repo       repository.TodoLists
+	itemRepo   repository.TodoItems
+	memberRepo repository.ListMembers
+	tx         repository.Transactor
+	undo       *UndoService
 }
 
-func NewTodoListService(repo repository.TodoLists) *TodoListService {
-	return &TodoListService{repo: repo}
+func NewTodoListService(repo repository.TodoLists, itemRepo repository.TodoItems, memberRepo repository.ListMembers,
+	tx repository.Transactor, undo *UndoService) *TodoListService {
+	return &TodoListService{repo: repo, itemRepo: itemRepo, memberRepo: memberRepo, tx: tx, undo: undo}
 }
 
-func (s *TodoListService) Create(userId int, list todo.TodoList) (int, error) {
-	return s.repo.Create(userId, list)
+func (s *TodoListService) Create(ctx context.Context, userId int, list todo.TodoList) (int, error) {
+	return s.repo.Create(ctx, userId, list)
 }
 
-func (s *TodoListService) GetAll(userId int) ([]todo.TodoList, error) {
-	return s.repo.GetAll(userId)
+func (s *TodoListService) GetAll(ctx context.Context, userId int, filter todo.ListFilter, page todo.PageRequest) ([]todo.TodoList, string, error) {
+	if err := page.Validate(todo.SortTitle, todo.SortCreated, todo.SortUpdated); err != nil {
+		return nil, "", err
+	}
+	return s.repo.GetAll(ctx, userId, filter, page)
+}
+
+func (s *TodoListService) GetById(ctx context.Context, userId, listId int) (todo.TodoList, error) {
+	return s.repo.GetById(ctx, userId, listId)
 }
 
-func (s *TodoListService) GetById(userId, listId int) (todo.TodoList, error) {
-	return s.repo.GetById(userId, listId)
+// Copy creates a list owned by the user with the title and description of the
+// list and copies of its items. Either the whole list is copied or nothing.
+func (s *TodoListService) Copy(ctx context.Context, userId, listId int) (int, error) {
+	var copyId int
+	err := s.tx.WithinTx(ctx, repository.TxOptions{Isolation: sql.LevelRepeatableRead}, func(ctx context.Context) error {
+		list, err := s.repo.GetById(ctx, userId, listId)
+		if err != nil {
+			return err
+		}
+		copyId, err = s.repo.Create(ctx, userId, todo.TodoList{
+			Title:       list.Title + " (copy)",
+			Description: list.Description,
+		})
+		if err != nil {
+			return err
+		}
+
+		page := todo.PageRequest{Limit: todo.MaxPageLimit}
+		for {
+			items, next, err := s.itemRepo.GetAll(ctx, userId, listId, todo.ItemFilter{}, page)
+			if err != nil {
+				return err
+			}
+			for _, item := range items {
+				_, err = s.itemRepo.Create(ctx, userId, copyId, todo.TodoItem{
+					Title:       item.Title,
+					Description: item.Description,
+					Done:        item.Done,
+					DueAt:       item.DueAt,
+					Priority:    item.Priority,
+				})
+				if err != nil {
+					return err
+				}
+			}
+			if next == "" {
+				return nil
+			}
+			page.Cursor = next
+		}
+	})
+	if err != nil {
+		return 0, fmt.Errorf("Copy list service: %w", err)
+	}
+	return copyId, nil
 }
 
-func (s *TodoListService) Delete(userId, listId int) error {
-	return s.repo.Delete(userId, listId)
+// Delete removes the list and returns a token that undoes the deletion. A
+// non-zero version is the version the client expects the list to be at. It is
+// a serializable unit of work, like adding an item, so that an item added
+// concurrently is either deleted with the list or not added at all.
+func (s *TodoListService) Delete(ctx context.Context, userId, listId, version int) (string, error) {
+	var token string
+	err := s.tx.WithinTx(ctx, serializableTx, func(ctx context.Context) error {
+		role, err := s.memberRepo.GetRole(ctx, userId, listId)
+		if err != nil {
+			return err
+		}
+		if role != todo.RoleOwner {
+			return todo.NewError(todo.ErrForbidden, "only list owners can delete the list")
+		}
+		activityId, err := s.repo.Delete(ctx, userId, listId, version)
+		if err != nil {
+			return err
+		}
+		token, err = s.undo.issue(ctx, userId, activityId)
+		return err
+	})
+	if err != nil {
+		return "", fmt.Errorf("Delete list service: %w", err)
+	}
+	return token, nil
 }
 
-func (s *TodoListService) Update(userId, listId int, input todo.UpdateListInput) error {
+// Update changes the list and returns a token that undoes the change and the
+// version the list is at afterwards. A non-zero version is the version the
+// client expects the list to be at.
+func (s *TodoListService) Update(ctx context.Context, userId, listId int, input todo.UpdateListInput,
+	version int) (string, int, error) {
 	if err := input.Validate(); err != nil {
+		return "", 0, err
+	}
+	var token string
+	var newVersion int
+	err := s.tx.WithinTx(ctx, repository.TxOptions{}, func(ctx context.Context) error {
+		role, err := s.memberRepo.GetRole(ctx, userId, listId)
+		if err != nil {
+			return err
+		}
+		if !todo.CanEdit(role) {
+			return todo.NewError(todo.ErrForbidden, "viewers cannot change the list")
+		}
+		activityId, err := s.repo.Update(ctx, userId, listId, input, version)
+		if err != nil {
+			return err
+		}
+		if token, err = s.undo.issue(ctx, userId, activityId); err != nil {
+			return err
+		}
+		list, err := s.repo.GetById(ctx, userId, listId)
+		newVersion = list.Version
 		return err
+	})
+	if err != nil {
+		return "", 0, fmt.Errorf("Update list service: %w", err)
 	}
-	return s.repo.Update(userId, listId, input)
+	return token, newVersion, nil
 }
@@ -0,0 +1,111 @@
+package service
+
+import (
+	"context"
+	todo "do-app"
+	"do-app/pkg/repository"
+	"fmt"
+	"github.com/sirupsen/logrus"
+	"time"
+)
+
+type TrashService struct {
+	repo repository.Trash
+}
+
+func NewTrashService(repo repository.Trash) *TrashService {
+	return &TrashService{repo: repo}
+}
+
+func (s *TrashService) GetAll(ctx context.Context, userId int) ([]todo.TrashEntry, error) {
+	return s.repo.GetAll(ctx, userId)
+}
+
+// Restore takes the list or item with the id out of the trash.
+func (s *TrashService) Restore(ctx context.Context, userId int, entryType string, id int) error {
+	switch entryType {
+	case todo.TrashList:
+		return s.repo.RestoreList(ctx, userId, id)
+	case todo.TrashItem:
+		return s.repo.RestoreItem(ctx, userId, id)
+	default:
+		return fmt.Errorf("Restore trash service: %w", todo.NewError(todo.ErrValidation,
+			"type must be %s or %s", todo.TrashList, todo.TrashItem))
+	}
+}
+
+type TrashConfig struct {
+	Retention time.Duration
+	Interval  time.Duration
+}
+
+// TrashPurger periodically removes lists and items that have been in the
+// trash for longer than the retention period, until it is shut down.
+type TrashPurger struct {
+	repo   repository.Trash
+	cfg    TrashConfig
+	stop   chan struct{}
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+func NewTrashPurger(repo repository.Trash, cfg TrashConfig) *TrashPurger {
+	if cfg.Retention <= 0 {
+		cfg.Retention = 30 * 24 * time.Hour
+	}
+	if cfg.Interval <= 0 {
+		cfg.Interval = time.Hour
+	}
+	return &TrashPurger{
+		repo: repo,
+		cfg:  cfg,
+		stop: make(chan struct{}),
+		done: make(chan struct{}),
+	}
+}
+
+func (p *TrashPurger) Start() {
+	ctx, cancel := context.WithCancel(context.Background())
+	p.cancel = cancel
+	go p.run(ctx)
+}
+
+// Shutdown stops purging and waits for a purge in progress to finish. If
+// ctx expires first, the queries still running are canceled.
+func (p *TrashPurger) Shutdown(ctx context.Context) error {
+	defer p.cancel()
+	close(p.stop)
+	select {
+	case <-p.done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (p *TrashPurger) run(ctx context.Context) {
+	defer close(p.done)
+
+	ticker := time.NewTicker(p.cfg.Interval)
+	defer ticker.Stop()
+
+	for {
+		p.purge(ctx)
+		select {
+		case <-p.stop:
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (p *TrashPurger) purge(ctx context.Context) {
+	purged, err := p.repo.Purge(ctx, time.Now().Add(-p.cfg.Retention))
+	if err != nil {
+		logrus.Errorf("purge trash: %s", err.Error())
+		return
+	}
+	if purged > 0 {
+		logrus.Infof("purged %d lists and items from trash", purged)
+	}
+}
@@ -0,0 +1,51 @@
+package service
+
+import (
+	"context"
+	"do-app/pkg/repository"
+	"fmt"
+	"time"
+)
+
+type UndoConfig struct {
+	Window time.Duration
+}
+
+// UndoService issues the tokens that undo a change and redeems them. Updates
+// and deletions of lists and items can be undone. Creating and moving entities
+// cannot, and neither can changes of a series, i.e. setting or stopping the
+// rule of an item and completing a recurring item, whose next occurrence an
+// undo would leave behind.
+type UndoService struct {
+	repo repository.Undo
+	cfg  UndoConfig
+}
+
+func NewUndoService(repo repository.Undo, cfg UndoConfig) *UndoService {
+	if cfg.Window <= 0 {
+		cfg.Window = 10 * time.Minute
+	}
+	return &UndoService{repo: repo, cfg: cfg}
+}
+
+// issue returns a token that undoes the change recorded by the activity record
+// within the undo window. Only the hash of the token is stored. It is called
+// in the unit of work of the change, so that a change is never applied without
+// its token.
+func (s *UndoService) issue(ctx context.Context, userId, activityId int) (string, error) {
+	token, err := newRandomToken()
+	if err != nil {
+		return "", fmt.Errorf("issue undo service: %w", err)
+	}
+	if err = s.repo.Create(ctx, userId, activityId, hashToken(token), time.Now().Add(s.cfg.Window)); err != nil {
+		return "", fmt.Errorf("issue undo service: %w", err)
+	}
+	return token, nil
+}
+
+func (s *UndoService) Undo(ctx context.Context, userId int, token string) error {
+	if err := s.repo.Undo(ctx, userId, hashToken(token)); err != nil {
+		return fmt.Errorf("Undo service: %w", err)
+	}
+	return nil
+}
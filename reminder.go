@@ -0,0 +1,21 @@
+package todo
+
+import "time"
+
+type ReminderRecipient struct {
+	UserId   int    `json:"user_id" db:"id"`
+	Name     string `json:"name" db:"name"`
+	Username string `json:"username" db:"username"`
+}
+
+// Reminder is a due reminder of an item together with every member of the
+// item's list.
+type Reminder struct {
+	ItemId      int                 `json:"item_id" db:"id"`
+	ListId      int                 `json:"list_id" db:"list_id"`
+	Title       string              `json:"title" db:"title"`
+	Description string              `json:"description" db:"description"`
+	DueAt       *time.Time          `json:"due_at,omitempty" db:"due_at"`
+	RemindAt    time.Time           `json:"remind_at" db:"remind_at"`
+	Recipients  []ReminderRecipient `json:"recipients" db:"-"`
+}
@@ -0,0 +1 @@
+ALTER TABLE users DROP COLUMN password_algo;
\ No newline at end of file
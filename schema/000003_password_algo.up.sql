@@ -0,0 +1 @@
+ALTER TABLE users ADD COLUMN password_algo varchar(16) not null default 'sha1';
@@ -0,0 +1,3 @@
+ALTER TABLE users_lists DROP CONSTRAINT users_lists_user_list_key;
+
+ALTER TABLE users_lists DROP COLUMN role;
\ No newline at end of file
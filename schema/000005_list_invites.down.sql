@@ -0,0 +1,3 @@
+DROP TABLE invite_redemptions;
+
+DROP TABLE list_invites;
\ No newline at end of file
@@ -0,0 +1,21 @@
+CREATE TABLE list_invites
+(
+    id serial not null unique,
+    list_id int references todo_lists (id) on delete cascade not null,
+    token_hash varchar(64) not null unique,
+    role varchar(16) not null,
+    created_by int references users (id) on delete cascade not null,
+    max_uses int,
+    uses int not null default 0,
+    expires_at timestamp not null,
+    revoked boolean not null default false,
+    created_at timestamp not null default now()
+);
+
+CREATE TABLE invite_redemptions
+(
+    id serial not null unique,
+    invite_id int references list_invites (id) on delete cascade not null,
+    user_id int references users (id) on delete cascade not null,
+    redeemed_at timestamp not null default now()
+);
\ No newline at end of file
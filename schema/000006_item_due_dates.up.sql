@@ -0,0 +1,5 @@
+ALTER TABLE todo_items ADD COLUMN due_at timestamp;
+
+ALTER TABLE todo_items ADD COLUMN remind_at timestamp;
+
+CREATE INDEX todo_items_due_at_idx ON todo_items (due_at) WHERE done = false;
\ No newline at end of file
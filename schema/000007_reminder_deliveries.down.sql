@@ -0,0 +1,7 @@
+DROP TABLE reminder_deliveries;
+
+DROP INDEX todo_items_pending_reminders_idx;
+
+ALTER TABLE todo_items DROP COLUMN reminder_retry_at;
+
+ALTER TABLE todo_items DROP COLUMN reminder_sent_at;
\ No newline at end of file
@@ -0,0 +1,14 @@
+ALTER TABLE todo_items ADD COLUMN reminder_sent_at timestamp;
+
+ALTER TABLE todo_items ADD COLUMN reminder_retry_at timestamp;
+
+CREATE INDEX todo_items_pending_reminders_idx ON todo_items (remind_at) WHERE reminder_sent_at IS NULL;
+
+CREATE TABLE reminder_deliveries
+(
+    id serial not null unique,
+    item_id int references todo_items (id) on delete cascade not null,
+    attempted_at timestamp not null default now(),
+    success boolean not null,
+    error text
+);
\ No newline at end of file
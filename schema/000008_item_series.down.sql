@@ -0,0 +1,5 @@
+DROP INDEX todo_items_series_id_idx;
+
+ALTER TABLE todo_items DROP COLUMN series_id;
+
+DROP TABLE item_series;
\ No newline at end of file
@@ -0,0 +1,11 @@
+CREATE TABLE item_series
+(
+    id serial not null unique,
+    rrule text not null,
+    dtstart timestamp not null,
+    stopped_at timestamp
+);
+
+ALTER TABLE todo_items ADD COLUMN series_id int references item_series (id) on delete set null;
+
+CREATE INDEX todo_items_series_id_idx ON todo_items (series_id);
\ No newline at end of file
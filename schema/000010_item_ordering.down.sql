@@ -0,0 +1,5 @@
+DROP INDEX lists_items_list_position_idx;
+
+ALTER TABLE lists_items DROP COLUMN position;
+
+ALTER TABLE todo_items DROP COLUMN priority;
\ No newline at end of file
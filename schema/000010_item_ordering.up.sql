@@ -0,0 +1,9 @@
+ALTER TABLE todo_items ADD COLUMN priority smallint not null default 0;
+
+ALTER TABLE lists_items ADD COLUMN position double precision;
+
+UPDATE lists_items SET position = id;
+
+ALTER TABLE lists_items ALTER COLUMN position SET NOT NULL;
+
+CREATE INDEX lists_items_list_position_idx ON lists_items (list_id, position);
\ No newline at end of file
@@ -0,0 +1,3 @@
+ALTER TABLE todo_items DROP COLUMN auto_complete;
+
+DROP TABLE subtasks;
\ No newline at end of file
@@ -0,0 +1,11 @@
+CREATE TABLE subtasks
+(
+    id serial not null unique,
+    item_id int references todo_items (id) on delete cascade not null,
+    title varchar(255) not null,
+    done boolean not null default false
+);
+
+CREATE INDEX subtasks_item_id_idx ON subtasks (item_id);
+
+ALTER TABLE todo_items ADD COLUMN auto_complete boolean not null default false;
\ No newline at end of file
@@ -0,0 +1,10 @@
+DROP TRIGGER lists_items_touch_item ON lists_items;
+DROP TRIGGER subtasks_touch_item ON subtasks;
+DROP FUNCTION touch_item();
+
+DROP TRIGGER todo_items_version ON todo_items;
+DROP TRIGGER todo_lists_version ON todo_lists;
+DROP FUNCTION bump_version();
+
+ALTER TABLE todo_items DROP COLUMN version;
+ALTER TABLE todo_lists DROP COLUMN version;
\ No newline at end of file
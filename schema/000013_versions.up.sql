@@ -0,0 +1,32 @@
+ALTER TABLE todo_lists ADD COLUMN version int not null default 1;
+ALTER TABLE todo_items ADD COLUMN version int not null default 1;
+
+CREATE FUNCTION bump_version() RETURNS trigger AS $$
+BEGIN
+    NEW.version := OLD.version + 1;
+    RETURN NEW;
+END
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER todo_lists_version BEFORE UPDATE ON todo_lists
+    FOR EACH ROW EXECUTE FUNCTION bump_version();
+CREATE TRIGGER todo_items_version BEFORE UPDATE ON todo_items
+    FOR EACH ROW EXECUTE FUNCTION bump_version();
+
+-- Subtask progress and the position of an item are part of the item as
+-- clients see it, so changing them touches the item to give it a new version.
+CREATE FUNCTION touch_item() RETURNS trigger AS $$
+BEGIN
+    IF TG_OP = 'DELETE' THEN
+        UPDATE todo_items SET version = version WHERE id = OLD.item_id;
+        RETURN OLD;
+    END IF;
+    UPDATE todo_items SET version = version WHERE id = NEW.item_id;
+    RETURN NEW;
+END
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER subtasks_touch_item AFTER INSERT OR UPDATE OR DELETE ON subtasks
+    FOR EACH ROW EXECUTE FUNCTION touch_item();
+CREATE TRIGGER lists_items_touch_item AFTER UPDATE ON lists_items
+    FOR EACH ROW EXECUTE FUNCTION touch_item();
\ No newline at end of file
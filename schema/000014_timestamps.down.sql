@@ -0,0 +1,16 @@
+CREATE OR REPLACE FUNCTION bump_version() RETURNS trigger AS $$
+BEGIN
+    NEW.version := OLD.version + 1;
+    RETURN NEW;
+END
+$$ LANGUAGE plpgsql;
+
+DROP INDEX todo_items_updated_at_idx;
+DROP INDEX todo_lists_updated_at_idx;
+
+ALTER TABLE todo_items DROP COLUMN completed_at;
+ALTER TABLE todo_items DROP COLUMN updated_at;
+ALTER TABLE todo_items DROP COLUMN created_at;
+
+ALTER TABLE todo_lists DROP COLUMN updated_at;
+ALTER TABLE todo_lists DROP COLUMN created_at;
\ No newline at end of file
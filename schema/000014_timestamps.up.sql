@@ -0,0 +1,19 @@
+ALTER TABLE todo_lists ADD COLUMN created_at timestamptz not null default now();
+ALTER TABLE todo_lists ADD COLUMN updated_at timestamptz not null default now();
+
+ALTER TABLE todo_items ADD COLUMN created_at timestamptz not null default now();
+ALTER TABLE todo_items ADD COLUMN updated_at timestamptz not null default now();
+ALTER TABLE todo_items ADD COLUMN completed_at timestamptz;
+
+CREATE INDEX todo_lists_updated_at_idx ON todo_lists (updated_at, id);
+CREATE INDEX todo_items_updated_at_idx ON todo_items (updated_at, id);
+
+-- Every update gives the row a new version, so it is also the moment the row
+-- was last updated.
+CREATE OR REPLACE FUNCTION bump_version() RETURNS trigger AS $$
+BEGIN
+    NEW.version := OLD.version + 1;
+    NEW.updated_at := now();
+    RETURN NEW;
+END
+$$ LANGUAGE plpgsql;
\ No newline at end of file
@@ -0,0 +1,14 @@
+CREATE TABLE activities
+(
+    id serial not null unique,
+    list_id int references todo_lists (id) on delete cascade not null,
+    item_id int,
+    user_id int references users (id) on delete cascade not null,
+    entity varchar(16) not null,
+    action varchar(16) not null,
+    changes jsonb,
+    created_at timestamptz not null default now()
+);
+
+CREATE INDEX activities_list_id_idx ON activities (list_id, id);
+CREATE INDEX activities_item_id_idx ON activities (item_id, id) WHERE item_id IS NOT NULL;
\ No newline at end of file
@@ -0,0 +1 @@
+DROP TABLE undo_tokens;
\ No newline at end of file
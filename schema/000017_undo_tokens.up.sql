@@ -0,0 +1,10 @@
+CREATE TABLE undo_tokens
+(
+    id serial not null unique,
+    token_hash varchar(64) not null unique,
+    activity_id int references activities (id) on delete cascade not null,
+    user_id int references users (id) on delete cascade not null,
+    expires_at timestamptz not null
+);
+
+CREATE INDEX undo_tokens_expires_at_idx ON undo_tokens (expires_at);
\ No newline at end of file
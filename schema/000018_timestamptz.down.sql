@@ -0,0 +1,17 @@
+ALTER TABLE item_series ALTER COLUMN dtstart TYPE timestamp,
+                        ALTER COLUMN stopped_at TYPE timestamp;
+
+ALTER TABLE reminder_deliveries ALTER COLUMN attempted_at TYPE timestamp;
+
+ALTER TABLE todo_items ALTER COLUMN due_at TYPE timestamp,
+                       ALTER COLUMN remind_at TYPE timestamp,
+                       ALTER COLUMN reminder_sent_at TYPE timestamp,
+                       ALTER COLUMN reminder_retry_at TYPE timestamp;
+
+ALTER TABLE invite_redemptions ALTER COLUMN redeemed_at TYPE timestamp;
+
+ALTER TABLE list_invites ALTER COLUMN expires_at TYPE timestamp,
+                         ALTER COLUMN created_at TYPE timestamp;
+
+ALTER TABLE sessions ALTER COLUMN expires_at TYPE timestamp,
+                     ALTER COLUMN created_at TYPE timestamp;
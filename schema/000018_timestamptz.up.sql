@@ -0,0 +1,19 @@
+-- Values stored so far are taken to be in the server's time zone, the one
+-- now() and the comparisons against it have used.
+ALTER TABLE sessions ALTER COLUMN expires_at TYPE timestamptz,
+                     ALTER COLUMN created_at TYPE timestamptz;
+
+ALTER TABLE list_invites ALTER COLUMN expires_at TYPE timestamptz,
+                         ALTER COLUMN created_at TYPE timestamptz;
+
+ALTER TABLE invite_redemptions ALTER COLUMN redeemed_at TYPE timestamptz;
+
+ALTER TABLE todo_items ALTER COLUMN due_at TYPE timestamptz,
+                       ALTER COLUMN remind_at TYPE timestamptz,
+                       ALTER COLUMN reminder_sent_at TYPE timestamptz,
+                       ALTER COLUMN reminder_retry_at TYPE timestamptz;
+
+ALTER TABLE reminder_deliveries ALTER COLUMN attempted_at TYPE timestamptz;
+
+ALTER TABLE item_series ALTER COLUMN dtstart TYPE timestamptz,
+                        ALTER COLUMN stopped_at TYPE timestamptz;
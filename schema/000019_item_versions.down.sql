@@ -0,0 +1,3 @@
+DROP TRIGGER todo_items_version ON todo_items;
+CREATE TRIGGER todo_items_version BEFORE UPDATE ON todo_items
+    FOR EACH ROW EXECUTE FUNCTION bump_version();
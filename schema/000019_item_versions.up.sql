@@ -0,0 +1,8 @@
+-- Reminder bookkeeping is not part of the item as clients see it, so only
+-- updates of the other columns give an item a new version. Setting version
+-- itself, as touch_item does, still does.
+DROP TRIGGER todo_items_version ON todo_items;
+CREATE TRIGGER todo_items_version
+    BEFORE UPDATE OF title, description, done, due_at, remind_at, series_id, priority, auto_complete, version,
+        completed_at, deleted_at ON todo_items
+    FOR EACH ROW EXECUTE FUNCTION bump_version();
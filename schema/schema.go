@@ -0,0 +1,22 @@
+// Package schema embeds the database migrations, so that the binary can apply
+// them itself.
+package schema
+
+import (
+	"embed"
+	"io/fs"
+)
+
+// Migrations holds the pairs of NNNNNN_name.up.sql and NNNNNN_name.down.sql
+// files, applied in the order of their version NNNNNN.
+//
+//go:embed *.sql
+var Migrations embed.FS
+
+//go:embed sqlite/*.sql
+var sqliteFiles embed.FS
+
+// SQLiteMigrations holds the migrations of SQLite databases, in the same
+// layout as Migrations. SQLite lacks too much of the Postgres syntax to share
+// the migrations, so it has a set of its own.
+var SQLiteMigrations, _ = fs.Sub(sqliteFiles, "sqlite")
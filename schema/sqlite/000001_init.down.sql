@@ -0,0 +1,15 @@
+DROP TABLE undo_tokens;
+DROP TABLE activities;
+DROP TABLE subtasks;
+DROP TABLE items_labels;
+DROP TABLE labels;
+DROP TABLE reminder_deliveries;
+DROP TABLE lists_items;
+DROP TABLE todo_items;
+DROP TABLE item_series;
+DROP TABLE invite_redemptions;
+DROP TABLE list_invites;
+DROP TABLE users_lists;
+DROP TABLE todo_lists;
+DROP TABLE sessions;
+DROP TABLE users;
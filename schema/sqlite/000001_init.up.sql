@@ -0,0 +1,212 @@
+-- The SQLite schema starts out at the state the Postgres migrations built up
+-- to. Times are stored as text in UTC with nine fraction digits, so that they
+-- sort like the times they stand for.
+
+CREATE TABLE users
+(
+    id integer primary key autoincrement,
+    name varchar(255) not null,
+    username varchar(255) not null unique,
+    password_hash varchar(255) not null,
+    password_algo varchar(16) not null default 'sha1'
+);
+
+CREATE TABLE sessions
+(
+    id integer primary key autoincrement,
+    user_id int references users (id) on delete cascade not null,
+    refresh_token_hash varchar(64) not null unique,
+    expires_at datetime not null,
+    revoked boolean not null default false,
+    created_at datetime not null default (strftime('%Y-%m-%d %H:%M:%f000000+00:00', 'now'))
+);
+
+CREATE TABLE todo_lists
+(
+    id integer primary key autoincrement,
+    title varchar(255) not null,
+    description varchar(255),
+    version int not null default 1,
+    created_at datetime not null default (strftime('%Y-%m-%d %H:%M:%f000000+00:00', 'now')),
+    updated_at datetime not null default (strftime('%Y-%m-%d %H:%M:%f000000+00:00', 'now')),
+    deleted_at datetime
+);
+
+CREATE INDEX todo_lists_updated_at_idx ON todo_lists (updated_at, id);
+CREATE INDEX todo_lists_deleted_at_idx ON todo_lists (deleted_at) WHERE deleted_at IS NOT NULL;
+
+CREATE TABLE users_lists
+(
+    id integer primary key autoincrement,
+    user_id int references users (id) on delete cascade not null,
+    list_id int references todo_lists (id) on delete cascade not null,
+    role varchar(16) not null default 'owner',
+    UNIQUE (user_id, list_id)
+);
+
+CREATE TABLE list_invites
+(
+    id integer primary key autoincrement,
+    list_id int references todo_lists (id) on delete cascade not null,
+    token_hash varchar(64) not null unique,
+    role varchar(16) not null,
+    created_by int references users (id) on delete cascade not null,
+    max_uses int,
+    uses int not null default 0,
+    expires_at datetime not null,
+    revoked boolean not null default false,
+    created_at datetime not null default (strftime('%Y-%m-%d %H:%M:%f000000+00:00', 'now'))
+);
+
+CREATE TABLE invite_redemptions
+(
+    id integer primary key autoincrement,
+    invite_id int references list_invites (id) on delete cascade not null,
+    user_id int references users (id) on delete cascade not null,
+    redeemed_at datetime not null default (strftime('%Y-%m-%d %H:%M:%f000000+00:00', 'now'))
+);
+
+CREATE TABLE item_series
+(
+    id integer primary key autoincrement,
+    rrule text not null,
+    dtstart datetime not null,
+    stopped_at datetime
+);
+
+CREATE TABLE todo_items
+(
+    id integer primary key autoincrement,
+    title varchar(255) not null,
+    description varchar(255),
+    done boolean not null default false,
+    due_at datetime,
+    remind_at datetime,
+    reminder_sent_at datetime,
+    reminder_retry_at datetime,
+    series_id int references item_series (id) on delete set null,
+    priority smallint not null default 0,
+    auto_complete boolean not null default false,
+    version int not null default 1,
+    created_at datetime not null default (strftime('%Y-%m-%d %H:%M:%f000000+00:00', 'now')),
+    updated_at datetime not null default (strftime('%Y-%m-%d %H:%M:%f000000+00:00', 'now')),
+    completed_at datetime,
+    deleted_at datetime
+);
+
+CREATE INDEX todo_items_due_at_idx ON todo_items (due_at) WHERE done = false;
+CREATE INDEX todo_items_pending_reminders_idx ON todo_items (remind_at) WHERE reminder_sent_at IS NULL;
+CREATE INDEX todo_items_series_id_idx ON todo_items (series_id);
+CREATE INDEX todo_items_updated_at_idx ON todo_items (updated_at, id);
+CREATE INDEX todo_items_deleted_at_idx ON todo_items (deleted_at) WHERE deleted_at IS NOT NULL;
+
+CREATE TABLE lists_items
+(
+    id integer primary key autoincrement,
+    item_id int references todo_items (id) on delete cascade not null,
+    list_id int references todo_lists (id) on delete cascade not null,
+    position double precision not null
+);
+
+CREATE INDEX lists_items_list_position_idx ON lists_items (list_id, position);
+CREATE INDEX lists_items_item_id_idx ON lists_items (item_id);
+
+CREATE TABLE reminder_deliveries
+(
+    id integer primary key autoincrement,
+    item_id int references todo_items (id) on delete cascade not null,
+    attempted_at datetime not null default (strftime('%Y-%m-%d %H:%M:%f000000+00:00', 'now')),
+    success boolean not null,
+    error text
+);
+
+CREATE TABLE labels
+(
+    id integer primary key autoincrement,
+    user_id int references users (id) on delete cascade not null,
+    name varchar(64) not null,
+    color varchar(7) not null,
+    UNIQUE (user_id, name)
+);
+
+CREATE TABLE items_labels
+(
+    item_id int references todo_items (id) on delete cascade not null,
+    label_id int references labels (id) on delete cascade not null,
+    PRIMARY KEY (item_id, label_id)
+);
+
+CREATE INDEX items_labels_label_id_idx ON items_labels (label_id);
+
+CREATE TABLE subtasks
+(
+    id integer primary key autoincrement,
+    item_id int references todo_items (id) on delete cascade not null,
+    title varchar(255) not null,
+    done boolean not null default false
+);
+
+CREATE INDEX subtasks_item_id_idx ON subtasks (item_id);
+
+CREATE TABLE activities
+(
+    id integer primary key autoincrement,
+    list_id int references todo_lists (id) on delete cascade not null,
+    item_id int,
+    user_id int references users (id) on delete cascade not null,
+    entity varchar(16) not null,
+    action varchar(16) not null,
+    changes text,
+    created_at datetime not null default (strftime('%Y-%m-%d %H:%M:%f000000+00:00', 'now'))
+);
+
+CREATE INDEX activities_list_id_idx ON activities (list_id, id);
+CREATE INDEX activities_item_id_idx ON activities (item_id, id) WHERE item_id IS NOT NULL;
+
+CREATE TABLE undo_tokens
+(
+    id integer primary key autoincrement,
+    token_hash varchar(64) not null unique,
+    activity_id int references activities (id) on delete cascade not null,
+    user_id int references users (id) on delete cascade not null,
+    expires_at datetime not null
+);
+
+CREATE INDEX undo_tokens_expires_at_idx ON undo_tokens (expires_at);
+
+-- Every update gives the row a new version, so it is also the moment the row
+-- was last updated. Recursive triggers are off, so the update of a trigger
+-- does not fire it again.
+CREATE TRIGGER todo_lists_version AFTER UPDATE ON todo_lists
+BEGIN
+    UPDATE todo_lists SET version = OLD.version + 1,
+        updated_at = strftime('%Y-%m-%d %H:%M:%f000000+00:00', 'now') WHERE id = NEW.id;
+END;
+
+CREATE TRIGGER todo_items_version AFTER UPDATE ON todo_items
+BEGIN
+    UPDATE todo_items SET version = OLD.version + 1,
+        updated_at = strftime('%Y-%m-%d %H:%M:%f000000+00:00', 'now') WHERE id = NEW.id;
+END;
+
+-- Subtask progress and the position of an item are part of the item as
+-- clients see it, so changing them touches the item to give it a new version.
+CREATE TRIGGER subtasks_insert_touch_item AFTER INSERT ON subtasks
+BEGIN
+    UPDATE todo_items SET version = version WHERE id = NEW.item_id;
+END;
+
+CREATE TRIGGER subtasks_update_touch_item AFTER UPDATE ON subtasks
+BEGIN
+    UPDATE todo_items SET version = version WHERE id = NEW.item_id;
+END;
+
+CREATE TRIGGER subtasks_delete_touch_item AFTER DELETE ON subtasks
+BEGIN
+    UPDATE todo_items SET version = version WHERE id = OLD.item_id;
+END;
+
+CREATE TRIGGER lists_items_touch_item AFTER UPDATE ON lists_items
+BEGIN
+    UPDATE todo_items SET version = version WHERE id = NEW.item_id;
+END;
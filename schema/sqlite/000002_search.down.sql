@@ -0,0 +1,8 @@
+DROP TRIGGER todo_items_search_delete;
+DROP TRIGGER todo_items_search_update;
+DROP TRIGGER todo_items_search_insert;
+DROP TRIGGER todo_lists_search_delete;
+DROP TRIGGER todo_lists_search_update;
+DROP TRIGGER todo_lists_search_insert;
+DROP TABLE item_search;
+DROP TABLE list_search;
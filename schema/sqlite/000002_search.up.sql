@@ -0,0 +1,39 @@
+-- Full text indexes of the titles and descriptions of lists and items. FTS4
+-- is part of every build of the SQLite driver, FTS5 is not. The docid of a
+-- row is the id of its list or item. Diacritics are kept, so that words match
+-- like in the Postgres simple configuration.
+CREATE VIRTUAL TABLE list_search USING fts4(title, description, tokenize=unicode61 "remove_diacritics=0");
+CREATE VIRTUAL TABLE item_search USING fts4(title, description, tokenize=unicode61 "remove_diacritics=0");
+
+INSERT INTO list_search (docid, title, description) SELECT id, title, COALESCE(description, '') FROM todo_lists;
+INSERT INTO item_search (docid, title, description) SELECT id, title, COALESCE(description, '') FROM todo_items;
+
+CREATE TRIGGER todo_lists_search_insert AFTER INSERT ON todo_lists
+BEGIN
+    INSERT INTO list_search (docid, title, description) VALUES (NEW.id, NEW.title, COALESCE(NEW.description, ''));
+END;
+
+CREATE TRIGGER todo_lists_search_update AFTER UPDATE OF title, description ON todo_lists
+BEGIN
+    UPDATE list_search SET title = NEW.title, description = COALESCE(NEW.description, '') WHERE docid = NEW.id;
+END;
+
+CREATE TRIGGER todo_lists_search_delete AFTER DELETE ON todo_lists
+BEGIN
+    DELETE FROM list_search WHERE docid = OLD.id;
+END;
+
+CREATE TRIGGER todo_items_search_insert AFTER INSERT ON todo_items
+BEGIN
+    INSERT INTO item_search (docid, title, description) VALUES (NEW.id, NEW.title, COALESCE(NEW.description, ''));
+END;
+
+CREATE TRIGGER todo_items_search_update AFTER UPDATE OF title, description ON todo_items
+BEGIN
+    UPDATE item_search SET title = NEW.title, description = COALESCE(NEW.description, '') WHERE docid = NEW.id;
+END;
+
+CREATE TRIGGER todo_items_search_delete AFTER DELETE ON todo_items
+BEGIN
+    DELETE FROM item_search WHERE docid = OLD.id;
+END;
@@ -0,0 +1,6 @@
+DROP TRIGGER todo_items_version;
+CREATE TRIGGER todo_items_version AFTER UPDATE ON todo_items
+BEGIN
+    UPDATE todo_items SET version = OLD.version + 1,
+        updated_at = strftime('%Y-%m-%d %H:%M:%f000000+00:00', 'now') WHERE id = NEW.id;
+END;
@@ -0,0 +1,11 @@
+-- Reminder bookkeeping is not part of the item as clients see it, so only
+-- updates of the other columns give an item a new version. Setting version
+-- itself, as the touch triggers do, still does.
+DROP TRIGGER todo_items_version;
+CREATE TRIGGER todo_items_version
+    AFTER UPDATE OF title, description, done, due_at, remind_at, series_id, priority, auto_complete, version,
+        completed_at, deleted_at ON todo_items
+BEGIN
+    UPDATE todo_items SET version = OLD.version + 1,
+        updated_at = strftime('%Y-%m-%d %H:%M:%f000000+00:00', 'now') WHERE id = NEW.id;
+END;
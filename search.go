@@ -0,0 +1,47 @@
+package todo
+
+// Search result types.
+const (
+	SearchList = "list"
+	SearchItem = "item"
+)
+
+const (
+	DefaultSearchLimit = 20
+	MaxSearchLimit     = 100
+)
+
+// SearchQuery is a full-text query over the lists and items a user can access.
+// Query uses web search syntax: quoted phrases, "or" and a leading "-" to
+// exclude a word. Type and ListId narrow the results when set.
+type SearchQuery struct {
+	Query  string
+	Type   string
+	ListId int
+	Limit  int
+}
+
+func (q SearchQuery) Validate() error {
+	if q.Query == "" {
+		return NewError(ErrValidation, "search query is empty")
+	}
+	if q.Type != "" && q.Type != SearchList && q.Type != SearchItem {
+		return NewError(ErrValidation, "type must be %s or %s", SearchList, SearchItem)
+	}
+	if q.Limit < 0 || q.Limit > MaxSearchLimit {
+		return NewError(ErrValidation, "limit must be between 1 and %d", MaxSearchLimit)
+	}
+	return nil
+}
+
+// SearchResult is a list or an item matching a search query. Snippet is an
+// excerpt of the title and description with the matched words wrapped in
+// <b></b>; results are ordered by Rank, best first.
+type SearchResult struct {
+	Type    string  `json:"type" db:"type"`
+	Id      int     `json:"id" db:"id"`
+	ListId  int     `json:"list_id" db:"list_id"`
+	Title   string  `json:"title" db:"title"`
+	Snippet string  `json:"snippet" db:"snippet"`
+	Rank    float64 `json:"rank" db:"rank"`
+}
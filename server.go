@@ -2,25 +2,42 @@ package todo
 
 import (
 	"context"
+	"net"
 	"net/http"
 	"time"
 )
 
 type Server struct {
 	httpServer *http.Server
+	cancel     context.CancelFunc
 }
 
-func (s *Server) Run(port string, handler http.Handler) error {
-	s.httpServer = &http.Server{
-		Addr:           ":" + port,
-		Handler:        handler,
-		MaxHeaderBytes: 1 << 20,
-		ReadTimeout:    10 * time.Second,
-		WriteTimeout:   10 * time.Second,
+// NewServer prepares the server, so that Shutdown may be called before or
+// while Run is running.
+func NewServer(port string, handler http.Handler) *Server {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &Server{
+		httpServer: &http.Server{
+			Addr:           ":" + port,
+			Handler:        handler,
+			MaxHeaderBytes: 1 << 20,
+			ReadTimeout:    10 * time.Second,
+			WriteTimeout:   10 * time.Second,
+			BaseContext:    func(net.Listener) context.Context { return ctx },
+		},
+		cancel: cancel,
 	}
+}
+
+// Run serves until Shutdown is called, after which it returns
+// http.ErrServerClosed.
+func (s *Server) Run() error {
 	return s.httpServer.ListenAndServe()
 }
 
+// Shutdown waits for active requests to finish. Requests still running when
+// ctx expires have their context, and with it their queries, canceled.
 func (s *Server) Shutdown(ctx context.Context) error {
+	defer s.cancel()
 	return s.httpServer.Shutdown(ctx)
 }
@@ -1,24 +1,165 @@
 package todo
 
-import "fmt"
+import (
+	"encoding/json"
+	"time"
+)
 
 type TodoList struct {
-	Id          int    `json:"id" db:"id"`
-	Title       string `json:"title" db:"title" binding:"required"`
-	Description string `json:"description" db:"description"`
+	Id          int       `json:"id" db:"id"`
+	Title       string    `json:"title" db:"title" binding:"required"`
+	Description string    `json:"description" db:"description"`
+	Version     int       `json:"version,omitempty" db:"version"`
+	CreatedAt   time.Time `json:"created_at" db:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at" db:"updated_at"`
 }
 
+const (
+	RoleOwner  = "owner"
+	RoleEditor = "editor"
+	RoleViewer = "viewer"
+)
+
 type UserList struct {
 	Id     int
 	UserId int
 	ListId int
+	Role   string
+}
+
+// ValidRole reports whether role is one of the list member roles.
+func ValidRole(role string) bool {
+	return role == RoleOwner || role == RoleEditor || role == RoleViewer
+}
+
+// CanEdit reports whether a member with the role may change the list and its items.
+func CanEdit(role string) bool {
+	return role == RoleOwner || role == RoleEditor
+}
+
+type ListMember struct {
+	UserId   int    `json:"user_id" db:"user_id"`
+	Name     string `json:"name" db:"name"`
+	Username string `json:"username" db:"username"`
+	Role     string `json:"role" db:"role"`
+}
+
+type AddMemberInput struct {
+	Username string `json:"username" binding:"required"`
+	Role     string `json:"role" binding:"required"`
+}
+
+func (i AddMemberInput) Validate() error {
+	if !ValidRole(i.Role) {
+		return NewError(ErrValidation, "unknown role %q", i.Role)
+	}
+	return nil
+}
+
+type UpdateMemberInput struct {
+	Role string `json:"role" binding:"required"`
+}
+
+func (i UpdateMemberInput) Validate() error {
+	if !ValidRole(i.Role) {
+		return NewError(ErrValidation, "unknown role %q", i.Role)
+	}
+	return nil
 }
 
 type TodoItem struct {
-	Id          int    `json:"id" db:"id"`
-	Title       string `json:"title" db:"title" binding:"required"`
-	Description string `json:"description" db:"description"`
-	Done        bool   `json:"done" db:"done"`
+	Id            int        `json:"id" db:"id"`
+	ListId        int        `json:"list_id,omitempty" db:"list_id"`
+	Title         string     `json:"title" db:"title" binding:"required"`
+	Description   string     `json:"description" db:"description"`
+	Done          bool       `json:"done" db:"done"`
+	DueAt         *time.Time `json:"due_at,omitempty" db:"due_at"`
+	RemindAt      *time.Time `json:"remind_at,omitempty" db:"remind_at"`
+	RRule         string     `json:"rrule,omitempty" db:"rrule"`
+	SeriesId      *int       `json:"series_id,omitempty" db:"series_id"`
+	Priority      int        `json:"priority,omitempty" db:"priority"`
+	Position      float64    `json:"position,omitempty" db:"position"`
+	AutoComplete  bool       `json:"auto_complete,omitempty" db:"auto_complete"`
+	SubtasksDone  int        `json:"subtasks_done" db:"subtasks_done"`
+	SubtasksTotal int        `json:"subtasks_total,omitempty" db:"subtasks_total"`
+	Version       int        `json:"version,omitempty" db:"version"`
+	CreatedAt     time.Time  `json:"created_at" db:"created_at"`
+	UpdatedAt     time.Time  `json:"updated_at" db:"updated_at"`
+	CompletedAt   *time.Time `json:"completed_at,omitempty" db:"completed_at"`
+}
+
+// Subtask is a checklist entry of an item.
+type Subtask struct {
+	Id     int    `json:"id" db:"id"`
+	ItemId int    `json:"item_id" db:"item_id"`
+	Title  string `json:"title" db:"title" binding:"required"`
+	Done   bool   `json:"done" db:"done"`
+}
+
+type UpdateSubtaskInput struct {
+	Title *string `json:"title"`
+	Done  *bool   `json:"done"`
+}
+
+func (i UpdateSubtaskInput) Validate() error {
+	if i.Title == nil && i.Done == nil {
+		return NewError(ErrValidation, "update structure has no values")
+	}
+	return nil
+}
+
+const (
+	PriorityNone = iota
+	PriorityLow
+	PriorityMedium
+	PriorityHigh
+)
+
+// ValidPriority reports whether p is one of the item priorities.
+func ValidPriority(p int) bool {
+	return p >= PriorityNone && p <= PriorityHigh
+}
+
+// MoveItemInput places an item right after or before another item of the
+// target list, or at its end when neither is given. ListId defaults to the
+// list the item is in.
+type MoveItemInput struct {
+	ListId   *int `json:"list_id"`
+	AfterId  *int `json:"after_id"`
+	BeforeId *int `json:"before_id"`
+}
+
+func (i MoveItemInput) Validate() error {
+	if i.AfterId != nil && i.BeforeId != nil {
+		return NewError(ErrValidation, "after_id and before_id are mutually exclusive")
+	}
+	return nil
+}
+
+// ItemSeries links the occurrences of a recurring item. The next occurrence is
+// created from RRule when the current one is marked done, until the series is
+// stopped or the rule runs out of dates.
+type ItemSeries struct {
+	Id        int        `json:"id" db:"id"`
+	RRule     string     `json:"rrule" db:"rrule"`
+	Dtstart   time.Time  `json:"dtstart" db:"dtstart"`
+	StoppedAt *time.Time `json:"stopped_at,omitempty" db:"stopped_at"`
+}
+
+type UpdateSeriesInput struct {
+	RRule       *string `json:"rrule"`
+	Title       *string `json:"title"`
+	Description *string `json:"description"`
+}
+
+func (i UpdateSeriesInput) Validate() error {
+	if i.RRule == nil && i.Title == nil && i.Description == nil {
+		return NewError(ErrValidation, "update structure has no values")
+	}
+	if i.RRule != nil && *i.RRule == "" {
+		return NewError(ErrValidation, "rrule must not be empty, stop the series instead")
+	}
+	return nil
 }
 
 type ListItem struct {
@@ -34,20 +175,63 @@ type UpdateListInput struct {
 
 func (i UpdateListInput) Validate() error {
 	if i.Title == nil && i.Description == nil {
-		return fmt.Errorf("update structure has no values")
+		return NewError(ErrValidation, "update structure has no values")
+	}
+	return nil
+}
+
+// NullableTime distinguishes a missing JSON field from an explicit null, so
+// update inputs can clear optional timestamps.
+type NullableTime struct {
+	Set  bool
+	Time *time.Time
+}
+
+func (t *NullableTime) UnmarshalJSON(data []byte) error {
+	t.Set = true
+	if string(data) == "null" {
+		t.Time = nil
+		return nil
+	}
+	var value time.Time
+	if err := json.Unmarshal(data, &value); err != nil {
+		return err
 	}
+	t.Time = &value
 	return nil
 }
 
 type UpdateItemInput struct {
-	Title       *string `json:"title"`
-	Description *string `json:"description"`
-	Done        *bool   `json:"done"`
+	Title        *string      `json:"title"`
+	Description  *string      `json:"description"`
+	Done         *bool        `json:"done"`
+	DueAt        NullableTime `json:"due_at" swaggertype:"string" format:"date-time"`
+	RemindAt     NullableTime `json:"remind_at" swaggertype:"string" format:"date-time"`
+	RRule        *string      `json:"rrule"`
+	Priority     *int         `json:"priority"`
+	AutoComplete *bool        `json:"auto_complete"`
 }
 
 func (i UpdateItemInput) Validate() error {
-	if i.Title == nil && i.Description == nil && i.Done == nil {
-		return fmt.Errorf("update structure has no values")
+	if i.Title == nil && i.Description == nil && i.Done == nil && !i.DueAt.Set && !i.RemindAt.Set &&
+		i.RRule == nil && i.Priority == nil && i.AutoComplete == nil {
+		return NewError(ErrValidation, "update structure has no values")
+	}
+	if i.Priority != nil && !ValidPriority(*i.Priority) {
+		return NewError(ErrValidation, "priority must be between %d and %d", PriorityNone, PriorityHigh)
 	}
 	return nil
 }
+
+// ItemFilter selects items of a user. Overdue items are unfinished items whose
+// due date has passed. Labels are names of the user's labels; an item matches
+// if it carries any of them, or all of them with AllLabels. Query matches a
+// substring of the title.
+type ItemFilter struct {
+	DueBefore *time.Time
+	Overdue   bool
+	Labels    []string
+	AllLabels bool
+	Done      *bool
+	Query     string
+}
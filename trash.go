@@ -0,0 +1,20 @@
+package todo
+
+import "time"
+
+// Types of trash entries.
+const (
+	TrashList = "list"
+	TrashItem = "item"
+)
+
+// TrashEntry is a deleted list or item that can still be restored. Items
+// deleted together with their list are restored with it and are not listed
+// on their own.
+type TrashEntry struct {
+	Type      string    `json:"type" db:"type"`
+	Id        int       `json:"id" db:"id"`
+	ListId    int       `json:"list_id" db:"list_id"`
+	Title     string    `json:"title" db:"title"`
+	DeletedAt time.Time `json:"deleted_at" db:"deleted_at"`
+}
@@ -1,8 +1,40 @@
 package todo
 
+import "time"
+
 type User struct {
-	Id       int    `json:"-" db:"id"`
-	Name     string `json:"name" binding:"required"`
-	Username string `json:"username" binding:"required"`
-	Password string `json:"password" binding:"required"`
+	Id           int    `json:"-" db:"id"`
+	Name         string `json:"name" binding:"required"`
+	Username     string `json:"username" binding:"required"`
+	Password     string `json:"password" db:"password_hash" binding:"required"`
+	PasswordAlgo string `json:"-" db:"password_algo"`
+}
+
+type Session struct {
+	Id               int       `db:"id"`
+	UserId           int       `db:"user_id"`
+	RefreshTokenHash string    `db:"refresh_token_hash"`
+	ExpiresAt        time.Time `db:"expires_at"`
+	Revoked          bool      `db:"revoked"`
+}
+
+type Tokens struct {
+	AccessToken  string `json:"token"`
+	RefreshToken string `json:"refresh_token"`
+}
+
+// JSONWebKey is the public part of a signing key as described in RFC 7517.
+type JSONWebKey struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Alg string `json:"alg"`
+	Use string `json:"use"`
+	N   string `json:"n,omitempty"`
+	E   string `json:"e,omitempty"`
+	Crv string `json:"crv,omitempty"`
+	X   string `json:"x,omitempty"`
+}
+
+type JSONWebKeySet struct {
+	Keys []JSONWebKey `json:"keys"`
 }